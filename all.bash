@@ -5,7 +5,7 @@
 
 source devtools/lib.sh || { echo "Are you at repo root?"; exit 1; }
 
-# Support ** in globs, for check_script_hashes.
+# Support ** in globs.
 shopt -s globstar
 
 warnout() {
@@ -120,13 +120,6 @@ check_templates() {
     -td=content/static/html/pages | warnout
 }
 
-
-# check_script_hashes checks that our CSP hashes match the ones
-# for our HTML scripts.
-check_script_hashes() {
-  runcmd go run ./devtools/cmd/csphash content/static/html/**/*.tmpl
-}
-
 run_prettier() {
   if ! [ -x "$(command -v prettier)" ]; then
     err "prettier must be installed: see https://prettier.io/docs/en/install.html"
@@ -143,7 +136,6 @@ standard_linters() {
   check_staticcheck
   check_misspell
   check_unparam
-  check_script_hashes
 }
 
 usage() {
@@ -161,7 +153,6 @@ Available subcommands:
   unparam     - (lint) run unparam on source files
   prettier    - (lint, nonstandard) run prettier on .js and .css files.
   templates   - (lint, nonstandard) run go-template-lint on templates
-  script_hashses - (lint) check script hashes
 EOUSAGE
 }
 
@@ -192,7 +183,6 @@ main() {
     prettier) run_prettier ;;
     templates) check_templates ;;
     unparam) check_unparam ;;
-    script_hashes) check_script_hashes ;;
     *)
       usage
       exit 1
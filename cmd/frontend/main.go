@@ -23,8 +23,10 @@ import (
 	"golang.org/x/pkgsite/internal/dcensus"
 	"golang.org/x/pkgsite/internal/derrors"
 	"golang.org/x/pkgsite/internal/frontend"
+	"golang.org/x/pkgsite/internal/godoc"
 	"golang.org/x/pkgsite/internal/log"
 	"golang.org/x/pkgsite/internal/middleware"
+	"golang.org/x/pkgsite/internal/migrations"
 	"golang.org/x/pkgsite/internal/postgres"
 	"golang.org/x/pkgsite/internal/proxy"
 	"golang.org/x/pkgsite/internal/proxydatasource"
@@ -46,6 +48,9 @@ var (
 	bypassLicenseCheck = flag.Bool("bypass_license_check", false, "display all information, even for non-redistributable paths")
 )
 
+// replicaCheckInterval is the time between health checks of the read replica.
+const replicaCheckInterval = 10 * time.Second
+
 func main() {
 	flag.Parse()
 	ctx := context.Background()
@@ -61,12 +66,13 @@ func main() {
 	}
 
 	log.SetLevel(cfg.LogLevel)
+	godoc.SourcegraphURL = cfg.SourcegraphURL
 
 	var (
 		dsg        func(context.Context) internal.DataSource
 		fetchQueue queue.Queue
 	)
-	proxyClient, err := proxy.New(*proxyURL)
+	proxyClient, err := proxy.NewWithCredentials(*proxyURL, cmdconfig.ProxyCredentials(cfg))
 	if err != nil {
 		log.Fatal(ctx, err)
 	}
@@ -74,6 +80,9 @@ func main() {
 		log.Info(ctx, "BYPASSING LICENSE CHECKING: DISPLAYING NON-REDISTRIBUTABLE INFORMATION")
 	}
 	expg := cmdconfig.ExperimentGetter(ctx, cfg)
+	snapmw := middleware.Identity()
+	prefsmw := middleware.Identity()
+	noindexmw := middleware.Identity()
 	if *directProxy {
 		var pds *proxydatasource.DataSource
 		if *bypassLicenseCheck {
@@ -92,14 +101,43 @@ func main() {
 		if err != nil {
 			log.Fatal(ctx, err)
 		}
+		if err := migrations.Check(ctx, ddb); err != nil {
+			log.Fatal(ctx, err)
+		}
+		ddb.SetSlowQueryLogging(cfg.SlowQueryThreshold, cfg.ExplainSlowQueries())
+		defer ddb.RecordPoolStats(10 * time.Second)()
 		var db *postgres.DB
 		if *bypassLicenseCheck {
 			db = postgres.NewBypassingLicenseCheck(ddb)
 		} else {
 			db = postgres.New(ddb)
 		}
+		db.SetSearchBoosts(cfg.SearchBoosts)
+		if ci := cfg.DBReplicaConnInfo(); ci != "" {
+			log.Infof(ctx, "opening read replica on host %s", cfg.DBReplicaHost)
+			rdb, err := database.Open(ocDriver, ci, cfg.InstanceID)
+			if err != nil {
+				// Serve from the primary alone rather than not at all.
+				log.Errorf(ctx, "database.Open for replica host %s failed with %v; using only the primary",
+					cfg.DBReplicaHost, err)
+			} else {
+				rdb.SetSlowQueryLogging(cfg.SlowQueryThreshold, cfg.ExplainSlowQueries())
+				db.SetReplica(rdb)
+				go db.MonitorReplica(ctx, replicaCheckInterval)
+			}
+		}
 		defer db.Close()
 		dsg = func(context.Context) internal.DataSource { return db }
+		expg = cmdconfig.ExperimentGetterWithDB(ctx, cfg, db)
+		snapshotter, err := middleware.NewSnapshotter(ctx, time.Minute, db.GetActiveSnapshotRules, db.InsertResponseSnapshot, cfg.AppVersionLabel())
+		if err != nil {
+			log.Fatal(ctx, err)
+		}
+		snapmw = middleware.Snapshot(snapshotter, cfg.UserHeader)
+		if cfg.UserHeader != "" {
+			prefsmw = middleware.UserPreferences(cfg.UserHeader, db.GetUserPreferences)
+		}
+		noindexmw = middleware.NoIndex(frontend.NoIndexFunc(db.IsNoIndex))
 		sourceClient := source.NewClient(config.SourceTimeout)
 		// The closure passed to queue.New is only used for testing and local
 		// execution, not in production. So it's okay that it doesn't use a
@@ -129,6 +167,17 @@ func main() {
 		AppVersionLabel:      cfg.AppVersionLabel(),
 		GoogleTagManagerID:   cfg.GoogleTagManagerID,
 		ServeStats:           cfg.ServeStats,
+		ExportQuota:          cfg.ExportQuota,
+		FeedbackQuota:        cfg.FeedbackQuota,
+		RefreshQuota:         cfg.RefreshQuota,
+		SearchQuota:          cfg.SearchQuota,
+		FetchQuota:           cfg.FetchQuota,
+		StaticQuota:          cfg.StaticQuota,
+		FetchModuleQuota:     cfg.FetchModuleQuota,
+		QuotaClient:          haClient,
+		PlaygroundURL:        cfg.PlaygroundURL,
+		PlaygroundQuota:      cfg.PlaygroundQuota,
+		DocSectionLimit:      cfg.DocSectionLimit,
 	})
 	if err != nil {
 		log.Fatalf(ctx, "frontend.NewServer: %v", err)
@@ -152,14 +201,23 @@ func main() {
 		middleware.CacheErrorCount,
 		middleware.CacheLatency,
 		middleware.QuotaResultCount,
+		middleware.FetchQuotaResultCount,
+		proxy.ProxyRequestCount,
+		proxy.ProxyLatencyDistribution,
+		database.QueryLatency,
+		database.QueryCount,
+		queue.InMemoryDepth,
 	)
+	views = append(views, database.PoolStatsViews...)
 	if err := dcensus.Init(cfg, views...); err != nil {
 		log.Fatal(ctx, err)
 	}
 	// We are not currently forwarding any ports on AppEngine, so serving debug
 	// information is broken.
 	if !cfg.OnAppEngine() {
-		dcensusServer, err := dcensus.NewServer()
+		// The metrics reveal the internals of the server, so they are only
+		// served on the debug port.
+		dcensusServer, err := dcensus.NewServer(cfg.ServePrometheusMetrics)
 		if err != nil {
 			log.Fatal(ctx, err)
 		}
@@ -176,16 +234,25 @@ func main() {
 		ermw = middleware.ErrorReporting(rc.Report)
 	}
 	mw := middleware.Chain(
+		middleware.RequestID(), // must come first so that the ID is logged and propagated
 		middleware.RequestLog(cmdconfig.Logger(ctx, cfg, "frontend-log")),
 		middleware.AcceptRequests(http.MethodGet, http.MethodPost), // accept only GETs and POSTs
-		middleware.Quota(cfg.Quota),
-		middleware.GodocURL(),                                                                 // potentially redirects so should be early in chain
-		middleware.SecureHeaders(!*disableCSP),                                                // must come before any caching for nonces to work
+		middleware.Quota("all", cfg.Quota, haClient),
+		snapmw,                // after the quota, so that rejected requests aren't recorded
+		middleware.GodocURL(), // potentially redirects so should be early in chain
+		middleware.SecureHeaders(middleware.SecureHeadersOptions{
+			CSP:            !*disableCSP,
+			ReferrerPolicy: cfg.SecureHeaders.ReferrerPolicy,
+			HSTSMaxAge:     cfg.SecureHeaders.HSTSMaxAge,
+		}), // must come before any caching for nonces to work
 		middleware.LatestVersions(server.GetLatestMinorVersion, server.GetLatestMajorVersion), // must come before caching for version badge to work
 		middleware.Panic(panicHandler),
 		ermw,
 		middleware.Timeout(54*time.Second),
 		middleware.Experiment(experimenter),
+		middleware.Language(), // must come before caching
+		prefsmw,               // must come before caching
+		noindexmw,
 	)
 	addr := cfg.HostAddr("localhost:8080")
 	log.Infof(ctx, "Listening on addr %s", addr)
@@ -8,15 +8,21 @@ package cmdconfig
 import (
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
 	"cloud.google.com/go/errorreporting"
+	"cloud.google.com/go/logging"
 	"golang.org/x/pkgsite/internal"
 	"golang.org/x/pkgsite/internal/config"
 	"golang.org/x/pkgsite/internal/config/dynconfig"
 	"golang.org/x/pkgsite/internal/log"
 	"golang.org/x/pkgsite/internal/middleware"
+	"golang.org/x/pkgsite/internal/postgres"
+	"golang.org/x/pkgsite/internal/proxy"
 )
 
 // Logger configures a middleware.Logger.
@@ -28,6 +34,29 @@ func Logger(ctx context.Context, cfg *config.Config, logName string) middleware.
 		}
 		return logger
 	}
+	if dir := cfg.RequestLog.Dir; dir != "" {
+		logger, err := middleware.NewFileLogger(filepath.Join(dir, logName+".log"), middleware.FileLoggerOptions{
+			MaxSize:    int64(cfg.RequestLog.MaxSizeMB) << 20,
+			MaxAge:     cfg.RequestLog.MaxAge,
+			MaxBackups: cfg.RequestLog.MaxBackups,
+			Compress:   cfg.RequestLog.Compress,
+		})
+		if err != nil {
+			log.Fatal(ctx, err)
+		}
+		log.Infof(ctx, "writing request logs to %s", dir)
+		return logger
+	}
+	if cfg.RequestLog.Format == "json" {
+		var sev logging.Severity
+		if s := cfg.RequestLog.MinSeverity; s != "" {
+			sev = logging.ParseSeverity(s)
+		}
+		return middleware.NewJSONLogger(os.Stderr, middleware.JSONLoggerOptions{
+			MinSeverity: sev,
+			SampleRate:  cfg.RequestLog.SampleRate,
+		})
+	}
 	return middleware.LocalLogger{}
 }
 
@@ -85,3 +114,50 @@ func ExperimentGetter(ctx context.Context, cfg *config.Config) middleware.Experi
 		return dc.Experiments, nil
 	}
 }
+
+// ExperimentGetterWithDB returns an ExperimentGetter that gets experiments
+// from the config, like ExperimentGetter, and from the experiments table of
+// db. An experiment in the table takes precedence over one of the same name
+// in the config, so that its rollout can be changed without a new config.
+func ExperimentGetterWithDB(ctx context.Context, cfg *config.Config, db *postgres.DB) middleware.ExperimentGetter {
+	getConfigExperiments := ExperimentGetter(ctx, cfg)
+	return func(ctx context.Context) ([]*internal.Experiment, error) {
+		exps, err := getConfigExperiments(ctx)
+		if err != nil {
+			return nil, err
+		}
+		dbExps, err := db.GetExperiments(ctx)
+		if err != nil {
+			return nil, err
+		}
+		byName := map[string]*internal.Experiment{}
+		for _, e := range exps {
+			byName[e.Name] = e
+		}
+		for _, e := range dbExps {
+			if _, ok := internal.Experiments[e.Name]; !ok {
+				log.Errorf(ctx, "unknown experiment %q in the experiments table", e.Name)
+			}
+			if e.Description == "" {
+				e.Description = internal.Experiments[e.Name]
+			}
+			byName[e.Name] = e
+		}
+		var merged []*internal.Experiment
+		for _, e := range byName {
+			merged = append(merged, e)
+		}
+		sort.Slice(merged, func(i, j int) bool { return merged[i].Name < merged[j].Name })
+		return merged, nil
+	}
+}
+
+// ProxyCredentials returns the credentials for the module proxy from the
+// config, or nil if there are none, in which case proxy.NewWithCredentials
+// looks for them in the proxy URL and .netrc.
+func ProxyCredentials(cfg *config.Config) *proxy.Credentials {
+	if cfg.ProxyToken == "" {
+		return nil
+	}
+	return &proxy.Credentials{Token: cfg.ProxyToken}
+}
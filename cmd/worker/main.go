@@ -23,6 +23,7 @@ import (
 	"golang.org/x/pkgsite/internal/database"
 	"golang.org/x/pkgsite/internal/dcensus"
 	"golang.org/x/pkgsite/internal/fetch"
+	"golang.org/x/pkgsite/internal/godoc"
 	"golang.org/x/pkgsite/internal/index"
 	"golang.org/x/pkgsite/internal/queue"
 	"golang.org/x/pkgsite/internal/source"
@@ -30,6 +31,7 @@ import (
 
 	"golang.org/x/pkgsite/internal/log"
 	"golang.org/x/pkgsite/internal/middleware"
+	"golang.org/x/pkgsite/internal/migrations"
 	"golang.org/x/pkgsite/internal/postgres"
 	"golang.org/x/pkgsite/internal/proxy"
 
@@ -43,6 +45,7 @@ var (
 	// flag used in call to safehtml/template.TrustedSourceFromFlag
 	_                  = flag.String("static", "content/static", "path to folder containing static files served")
 	bypassLicenseCheck = flag.Bool("bypass_license_check", false, "insert all data into the DB, even for non-redistributable paths")
+	applyMigrations    = flag.Bool("migrate", false, "apply the database migrations that haven't been applied before starting")
 )
 
 func main() {
@@ -57,6 +60,7 @@ func main() {
 	cfg.Dump(os.Stdout)
 
 	log.SetLevel(cfg.LogLevel)
+	godoc.SourcegraphURL = cfg.SourcegraphURL
 
 	if cfg.UseProfiler {
 		if err := profiler.Start(profiler.Config{}); err != nil {
@@ -71,10 +75,20 @@ func main() {
 	if err != nil {
 		log.Fatalf(ctx, "unable to register the ocsql driver: %v\n", err)
 	}
+	if *applyMigrations {
+		if err := migrations.Apply(cfg.DBConnInfo()); err != nil {
+			log.Fatal(ctx, err)
+		}
+	}
 	ddb, err := database.Open(driverName, cfg.DBConnInfo(), cfg.InstanceID)
 	if err != nil {
 		log.Fatalf(ctx, "database.Open: %v", err)
 	}
+	if err := migrations.Check(ctx, ddb); err != nil {
+		log.Fatal(ctx, err)
+	}
+	ddb.SetSlowQueryLogging(cfg.SlowQueryThreshold, cfg.ExplainSlowQueries())
+	defer ddb.RecordPoolStats(10 * time.Second)()
 	var db *postgres.DB
 	if *bypassLicenseCheck {
 		db = postgres.NewBypassingLicenseCheck(ddb)
@@ -89,12 +103,12 @@ func main() {
 	if err != nil {
 		log.Fatal(ctx, err)
 	}
-	proxyClient, err := proxy.New(cfg.ProxyURL)
+	proxyClient, err := proxy.NewWithCredentials(cfg.ProxyURL, cmdconfig.ProxyCredentials(cfg))
 	if err != nil {
 		log.Fatal(ctx, err)
 	}
 	sourceClient := source.NewClient(config.SourceTimeout)
-	expg := cmdconfig.ExperimentGetter(ctx, cfg)
+	expg := cmdconfig.ExperimentGetterWithDB(ctx, cfg, db)
 	fetchQueue, err := queue.New(ctx, cfg, queueName, *workers, expg,
 		func(ctx context.Context, modulePath, version string) (int, error) {
 			return worker.FetchAndUpdateState(ctx, modulePath, version, proxyClient, sourceClient, db, cfg.AppVersionLabel())
@@ -125,20 +139,35 @@ func main() {
 	}
 	router := dcensus.NewRouter(nil)
 	server.Install(router.Handle)
+	// Let the frontend know when this instance is too busy to take on more
+	// user-requested fetches.
+	go server.ReportLoad(ctx, 30*time.Second)
+	// Convert search documents made before the go_text text search
+	// configuration again, so that they match queries as new ones do.
+	go server.ReconvertSearchDocuments(ctx, 100, 10*time.Second)
 
 	views := append(dcensus.ServerViews,
 		worker.EnqueueResponseCount,
+		worker.ImportedByCountStaleness,
 		fetch.FetchLatencyDistribution,
 		fetch.FetchResponseCount,
 		fetch.SheddedFetchCount,
-		fetch.FetchPackageCount)
+		fetch.FetchPackageCount,
+		proxy.ProxyRequestCount,
+		proxy.ProxyLatencyDistribution,
+		database.QueryLatency,
+		database.QueryCount,
+		queue.InMemoryDepth)
+	views = append(views, database.PoolStatsViews...)
 	if err := dcensus.Init(cfg, views...); err != nil {
 		log.Fatal(ctx, err)
 	}
 	// We are not currently forwarding any ports on AppEngine, so serving debug
 	// information is broken.
 	if !cfg.OnAppEngine() {
-		dcensusServer, err := dcensus.NewServer()
+		// The metrics reveal the internals of the server, so they are only
+		// served on the debug port.
+		dcensusServer, err := dcensus.NewServer(cfg.ServePrometheusMetrics)
 		if err != nil {
 			log.Fatal(ctx, err)
 		}
@@ -224,6 +253,16 @@ func populateExcluded(ctx context.Context, db *postgres.DB) {
 	if user == "" {
 		user = "worker"
 	}
+	// A prefix that is already in the table with a narrower scope is left
+	// alone, since it can't be inserted again until it expires.
+	existing, err := db.GetExcludedPrefixes(ctx)
+	if err != nil {
+		log.Fatalf(ctx, "db.GetExcludedPrefixes: %v", err)
+	}
+	exists := map[string]bool{}
+	for _, p := range existing {
+		exists[p] = true
+	}
 	for _, line := range lines {
 		var prefix, reason string
 		i := strings.IndexAny(line, " \t")
@@ -238,7 +277,7 @@ func populateExcluded(ctx context.Context, db *postgres.DB) {
 		if err != nil {
 			log.Fatalf(ctx, "db.IsExcluded(%q): %v", prefix, err)
 		}
-		if !present {
+		if !present && !exists[prefix] {
 			if err := db.InsertExcludedPrefix(ctx, prefix, user, reason); err != nil {
 				log.Fatalf(ctx, "db.InsertExcludedPrefix(%q, %q, %q): %v", prefix, user, reason, err)
 			}
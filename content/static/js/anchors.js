@@ -0,0 +1,69 @@
+/**
+ * @license
+ * Copyright 2020 The Go Authors. All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+/**
+ * Redirects links to documentation anchors that no longer exist, like those
+ * written for godoc.org, to the anchors of the same declarations on this page.
+ *
+ * The fragment of a URL is not sent to the server, so this is done here.
+ */
+
+/**
+ * Section anchors of godoc.org and the IDs of the equivalent sections.
+ * @type {!Object<string, string>}
+ */
+const legacySections = {
+  'pkg-subdirectories': 'section-directories',
+  'pkg-files': 'section-sourcefiles',
+};
+
+/**
+ * Returns the ID of the element that the legacy anchor id refers to, or null
+ * if there is none.
+ * @param {string} id
+ * @return {?string}
+ */
+function currentID(id) {
+  const section = legacySections[id];
+  if (section) {
+    return document.getElementById(section) ? section : null;
+  }
+  if (id.startsWith('example_')) {
+    // Examples used to be "example_T_M", and those of the package
+    // "example_" or "example__Suffix". They are now "example-T.M",
+    // "example-package" and "example-package-Suffix".
+    let want = id;
+    if (want === 'example_' || want.startsWith('example__')) {
+      want = 'example_package' + want.slice('example_'.length);
+    }
+    for (const el of document.querySelectorAll('[id^="example-"]')) {
+      if (el.id.replace(/[-.]/g, '_') === want) {
+        return el.id;
+      }
+    }
+  }
+  return null;
+}
+
+/**
+ * Replaces the fragment of the location if it is a legacy anchor, and scrolls
+ * to the element it refers to.
+ */
+function redirectLegacyAnchor() {
+  const id = decodeURIComponent(window.location.hash.slice(1));
+  if (!id || document.getElementById(id)) {
+    return;
+  }
+  const newID = currentID(id);
+  if (newID) {
+    window.history.replaceState(null, '', '#' + newID);
+    document.getElementById(newID).scrollIntoView();
+  }
+}
+
+redirectLegacyAnchor();
+window.addEventListener('hashchange', redirectLegacyAnchor);
@@ -5,6 +5,10 @@
  * license that can be found in the LICENSE file.
  */
 
+// maxWaitMillis is how long the page follows the progress of a fetch before
+// asking the user to check back later.
+const maxWaitMillis = 5 * 60 * 1000;
+
 const fetchButton = document.querySelector('.js-fetchButton');
 if (fetchButton) {
   fetchButton.addEventListener('click', e => {
@@ -21,13 +25,50 @@ async function fetchPath() {
   document.querySelector('.js-fetchButton').style.display = 'none';
   document.querySelector('.js-fetchLoading').style.display = 'block';
 
+  // Follow the progress of the fetch, which the request below starts.
+  // The browser reconnects if the stream ends before the fetch does.
+  const events = new EventSource(`/fetch/events${window.location.pathname}`);
+  const stopFollowing = setTimeout(() => {
+    events.close();
+    showResult(`We're still working on “${fetchMessageEl.dataset.path}”. Check back in a few minutes!`);
+  }, maxWaitMillis);
+  const finish = () => {
+    events.close();
+    clearTimeout(stopFollowing);
+  };
+  events.addEventListener('message', e => {
+    const { stage, message } = JSON.parse(e.data);
+    switch (stage) {
+      case 'done':
+        finish();
+        window.location.reload();
+        break;
+      case 'error':
+        finish();
+        showResult(message);
+        break;
+      default:
+        document.querySelector('.js-fetchMessageSecondary').textContent = message;
+    }
+  });
+
   const response = await fetch(`/fetch${window.location.pathname}`, { method: 'POST' });
   if (response.ok) {
+    finish();
     window.location.reload();
     return;
   }
-  const responseText = await response.text();
+  if (response.status === 408) {
+    // The fetch is taking longer than the request could wait. Keep following
+    // its progress.
+    return;
+  }
+  finish();
+  showResult(await response.text());
+}
+
+function showResult(text) {
   document.querySelector('.js-fetchLoading').style.display = 'none';
   document.querySelector('.js-fetchMessageSecondary').textContent = '';
-  document.querySelector('.js-fetchMessage').textContent = responseText;
+  document.querySelector('.js-fetchMessage').textContent = text;
 }
@@ -7,7 +7,7 @@
 
 // This file implements the playground implementation of the documentation
 // page. The playground involves a "play" button that allows you to open up
-// a new link to play.golang.org using the example code.
+// a new link to the playground using the example code.
 
 // The CSS is in content/static/css/stylesheet.css.
 
@@ -142,21 +142,28 @@ class PlaygroundExampleController {
   }
 
   /**
-   * Opens a new window to play.golang.org using the
-   * example snippet's code in the playground.
+   * Opens a new window to the playground using the
+   * example snippet's code in the playground. The
+   * snippet is shared through the site, which redirects
+   * /play/p/<id> to the playground it uses.
    * @param {!MouseEvent} e
    * @private
    */
   handlePlayButtonClick(e) {
-    const PLAYGROUND_BASE_URL = '//play.golang.org/p/';
+    const PLAYGROUND_BASE_URL = '/play/p/';
 
     this.setOutputText('Waiting for remote server…');
 
-    fetch('/play/', {
+    fetch('/play/share', {
       method: 'POST',
       body: this._inputEl.textContent,
     })
-      .then(res => res.text())
+      .then(res => {
+        if (!res.ok) {
+          throw new Error(res.statusText);
+        }
+        return res.text();
+      })
       .then(shareId => {
         window.open(PLAYGROUND_BASE_URL + shareId);
       })
@@ -14,6 +14,6 @@ $jscomp.propertyToPolyfillSymbol[e],$jscomp.defineProperty(d,e,{configurable:!0,
 var PlayExampleClassName={PLAY_HREF:".js-exampleHref",PLAY_CONTAINER:".js-exampleContainer",EXAMPLE_INPUT:".Documentation-exampleCode",EXAMPLE_OUTPUT:".Documentation-exampleOutput",EXAMPLE_ERROR:".Documentation-exampleError",PLAY_BUTTON:".Documentation-examplePlayButton"},PlaygroundExampleController=function(a){var b=this,c=!1;a||(console.warn("Must provide playground example element"),c=!0);this._exampleEl=a;var d=a.querySelector("a");d||(console.warn("anchor tag is not detected"),c=!0);this._anchorEl=
 d;(d=a.querySelector(PlayExampleClassName.EXAMPLE_ERROR))||(c=!0);this._errorEl=d;(d=a.querySelector(PlayExampleClassName.PLAY_BUTTON))||(c=!0);this._playButtonEl=d;d=a.querySelector(PlayExampleClassName.EXAMPLE_INPUT);d||(console.warn("Input element is not detected"),c=!0);this._inputEl=d;this._outputEl=a.querySelector(PlayExampleClassName.EXAMPLE_OUTPUT);c||this._playButtonEl.addEventListener("click",function(e){return b.handlePlayButtonClick(e)})};
 PlaygroundExampleController.prototype.getAnchorHash=function(){return this._anchorEl.hash};PlaygroundExampleController.prototype.expand=function(){this._exampleEl.open=!0};PlaygroundExampleController.prototype.setOutputText=function(a){this._outputEl&&(this._outputEl.textContent=a)};PlaygroundExampleController.prototype.setErrorText=function(a){this._errorEl.textContent=a;this.setOutputText("An error has occurred\u2026")};
-PlaygroundExampleController.prototype.handlePlayButtonClick=function(a){var b=this;this.setOutputText("Waiting for remote server\u2026");fetch("/play/",{method:"POST",body:this._inputEl.textContent}).then(function(c){return c.text()}).then(function(c){window.open("//play.golang.org/p/"+c)}).catch(function(c){b.setErrorText(c)})};var exampleHashRegex=location.hash.match(/^#(example-.*)$/);
+PlaygroundExampleController.prototype.handlePlayButtonClick=function(a){var b=this;this.setOutputText("Waiting for remote server\u2026");fetch("/play/share",{method:"POST",body:this._inputEl.textContent}).then(function(c){if(!c.ok)throw Error(c.statusText);return c.text()}).then(function(c){window.open("/play/p/"+c)}).catch(function(c){b.setErrorText(c)})};var exampleHashRegex=location.hash.match(/^#(example-.*)$/);
 if(exampleHashRegex){var exampleHashEl=document.getElementById(exampleHashRegex[1]);exampleHashEl&&(exampleHashEl.open=!0)}var exampleHrefs=[].concat($jscomp.arrayFromIterable(document.querySelectorAll(PlayExampleClassName.PLAY_HREF))),findExampleHash=function(a){return exampleHrefs.find(function(b){return b.hash===a.getAnchorHash()})};
 document.querySelectorAll(PlayExampleClassName.PLAY_CONTAINER).forEach(function(a){var b=new PlaygroundExampleController(a);(a=findExampleHash(b))?a.addEventListener("click",function(){b.expand()}):console.warn("example href not found")});
@@ -35,3 +35,73 @@ if (readmeExpand && readmeExpand && readmeCollapse) {
     readme.scrollIntoView();
   });
 }
+
+/**
+ * Opens the folded part of a constant or variable group when the page is
+ * opened or navigated to one of its declarations.
+ */
+function openFoldedTarget() {
+  const id = decodeURIComponent(window.location.hash.slice(1));
+  const target = id && document.getElementById(id);
+  const fold = target && target.closest('.Documentation-valueFold');
+  if (fold && !fold.open) {
+    fold.open = true;
+    target.scrollIntoView();
+  }
+}
+openFoldedTarget();
+window.addEventListener('hashchange', openFoldedTarget);
+
+/**
+ * Event handlers for loading the rest of documentation sections that were
+ * truncated because they have too many declarations.
+ */
+const sectionMoreLinks = document.querySelectorAll('.js-docSectionMore');
+sectionMoreLinks.forEach(el =>
+  el.addEventListener('click', e => {
+    e.preventDefault();
+    loadDocSection(el);
+  })
+);
+
+/**
+ * If the page was opened at a declaration that is not displayed, loads the
+ * truncated sections and then jumps to it.
+ */
+const hashID = decodeURIComponent(window.location.hash.slice(1));
+if (sectionMoreLinks.length > 0 && hashID && !document.getElementById(hashID)) {
+  Promise.all(Array.from(sectionMoreLinks).map(loadDocSection)).then(() => {
+    const target = document.getElementById(hashID);
+    if (target) {
+      openFoldedTarget();
+      target.scrollIntoView();
+    }
+  });
+}
+
+/**
+ * Navigates to the documentation in the build context chosen in the build
+ * context selector.
+ */
+const buildContextSelect = document.querySelector('.js-buildContextSelect');
+if (buildContextSelect) {
+  buildContextSelect.addEventListener('change', e => {
+    window.location.href = e.target.value;
+  });
+}
+
+/**
+ * Replaces the contents of the section that contains the link el with the
+ * whole section, served at the link's URL.
+ */
+async function loadDocSection(el) {
+  const section = el.closest('section');
+  const text = el.textContent;
+  el.textContent = 'Loading…';
+  const response = await fetch(el.href);
+  if (!response.ok) {
+    el.textContent = text;
+    return;
+  }
+  section.innerHTML = await response.text();
+}
@@ -0,0 +1,106 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Command migrate applies the migrations compiled into it to the database
+// configured by the GO_DISCOVERY_DATABASE_* environment variables, without
+// the migrate command-line tool.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	"golang.org/x/pkgsite/internal/config"
+	"golang.org/x/pkgsite/internal/log"
+	"golang.org/x/pkgsite/internal/migrations"
+)
+
+func main() {
+	flag.Usage = func() {
+		out := flag.CommandLine.Output()
+		fmt.Fprintf(out, "usage: %s COMMAND\n", os.Args[0])
+		fmt.Fprintln(out, "commands:")
+		fmt.Fprintln(out, "  up          apply the migrations that haven't been applied")
+		fmt.Fprintln(out, "  down N      revert the last N migrations")
+		fmt.Fprintln(out, "  goto V      apply or revert migrations up to version V")
+		fmt.Fprintln(out, "  force V     set the version to V and mark it clean, without migrating")
+		fmt.Fprintln(out, "  version     print the version, and whether it is dirty")
+		fmt.Fprintln(out, "  check       check that the schema is compatible with this binary")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ctx := context.Background()
+	cfg, err := config.Init(ctx)
+	if err != nil {
+		log.Fatal(ctx, err)
+	}
+	m, err := migrations.New(cfg.DBConnInfo())
+	if err != nil {
+		log.Fatal(ctx, err)
+	}
+	defer m.Close()
+	if err := run(m, flag.Arg(0), flag.Args()[1:]); err != nil {
+		m.Close()
+		log.Fatal(ctx, err)
+	}
+}
+
+func run(m *migrations.Migrator, cmd string, args []string) error {
+	// number returns the only argument, which must be a number.
+	number := func() (int, error) {
+		if len(args) != 1 {
+			return 0, fmt.Errorf("%s: need one argument", cmd)
+		}
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("%s: bad argument %q", cmd, args[0])
+		}
+		return n, nil
+	}
+	switch cmd {
+	case "up":
+		return m.Up()
+	case "down":
+		n, err := number()
+		if err != nil {
+			return err
+		}
+		return m.Steps(-n)
+	case "goto":
+		v, err := number()
+		if err != nil {
+			return err
+		}
+		return m.Migrate(uint(v))
+	case "force":
+		v, err := number()
+		if err != nil {
+			return err
+		}
+		return m.Force(v)
+	case "version":
+		v, dirty, err := m.Version()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%d (latest %d)", v, migrations.Latest())
+		if dirty {
+			fmt.Print(" (dirty)")
+		}
+		fmt.Println()
+		return nil
+	case "check":
+		return m.Check()
+	default:
+		return fmt.Errorf("unknown command %q", cmd)
+	}
+}
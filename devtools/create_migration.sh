@@ -17,3 +17,4 @@ BEGIN;
 
 END;"
 for f in $(ls migrations | tail -n 2); do echo "$HEADER" >> "migrations/$f"; done
+echo "After writing the migrations, run 'go generate ./internal/migrations'."
@@ -8,18 +8,16 @@ source devtools/lib.sh || { echo "Are you at repo root?"; exit 1; }
 
 usage() {
   cat <<EOUSAGE
-Usage: $0 [up|down|force|version] {#}"
+Usage: $0 [up|down|goto|force|version|check] {#}"
 EOUSAGE
 }
 
-# Redirect stderr to stdout because migrate outputs to stderr, and we want
-# to be able to use ordinary output redirection.
+# The database is configured by the GO_DISCOVERY_DATABASE_* environment
+# variables, as for the frontend and worker. Redirect stderr to stdout so
+# that we can use ordinary output redirection.
 case "$1" in
-  up|down|force|version)
-    migrate \
-      -source file:migrations \
-      -database "postgres://postgres@localhost:5432/discovery-db?sslmode=disable" \
-      "$@" 2>&1
+  up|down|goto|force|version|check)
+    go run ./devtools/cmd/migrate "$@" 2>&1
     ;;
   *)
     usage
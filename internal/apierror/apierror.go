@@ -0,0 +1,125 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package apierror writes the error responses of the JSON endpoints served by
+// the frontend and the worker. Every such response has the same shape, so
+// that clients can tell errors apart and know whether and when to retry.
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// DefaultRetryAfter is how long clients are told to wait before retrying a
+// request that failed with a retryable error, if the handler did not say.
+const DefaultRetryAfter = 30 * time.Second
+
+// Response is the body of an error response.
+type Response struct {
+	// Code is a machine-readable name for the error, such as "not_found".
+	Code string `json:"code"`
+	// Message describes the error to people.
+	Message string `json:"message"`
+	// Retryable reports whether the same request might succeed later.
+	Retryable bool `json:"retryable"`
+	// RetryAfter is the number of seconds to wait before retrying, if the
+	// request is retryable. The Retry-After header has the same value.
+	RetryAfter int `json:"retry_after,omitempty"`
+}
+
+// codes are the codes of the errors in package derrors that clients can
+// see. They are part of the API, so they must not change if the errors do.
+var codes = []struct {
+	err  error
+	code string
+}{
+	{derrors.NotFound, "not_found"},
+	{derrors.InvalidArgument, "invalid_argument"},
+	{derrors.Excluded, "excluded"},
+	{derrors.SheddingLoad, "shedding_load"},
+	{derrors.ProxyTimedOut, "proxy_timed_out"},
+}
+
+// Code returns the code of an error response with the given HTTP status,
+// caused by err. If err is one of the errors in package derrors with that
+// status, the code names it. Otherwise the code is derived from the status
+// text: for example, "method_not_allowed".
+func Code(status int, err error) string {
+	if err != nil && derrors.ToStatus(err) == status {
+		for _, c := range codes {
+			if errors.Is(err, c.err) {
+				return c.code
+			}
+		}
+	}
+	text := http.StatusText(status)
+	if text == "" {
+		return "unknown"
+	}
+	return strings.ToLower(strings.ReplaceAll(text, " ", "_"))
+}
+
+// Retryable reports whether a request that failed with the given HTTP status
+// might succeed if it is repeated later.
+func Retryable(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// New returns the error response with the given HTTP status, caused by err.
+// If message is empty, the status text is used; err is never shown to
+// clients. Retryable responses wait for retryAfter, or DefaultRetryAfter if
+// it is zero.
+func New(status int, message string, err error, retryAfter time.Duration) *Response {
+	if message == "" {
+		message = http.StatusText(status)
+	}
+	resp := &Response{
+		Code:      Code(status, err),
+		Message:   message,
+		Retryable: Retryable(status),
+	}
+	if resp.Retryable {
+		if retryAfter <= 0 {
+			retryAfter = DefaultRetryAfter
+		}
+		resp.RetryAfter = int(retryAfter.Round(time.Second) / time.Second)
+	}
+	return resp
+}
+
+// Write writes the error response with the given HTTP status to w, as
+// described by New. If the handler has already set the Retry-After header of
+// w to a number of seconds, retryable responses use it.
+func Write(w http.ResponseWriter, status int, message string, err error) {
+	var retryAfter time.Duration
+	if secs, err := strconv.Atoi(w.Header().Get("Retry-After")); err == nil {
+		retryAfter = time.Duration(secs) * time.Second
+	}
+	resp := New(status, message, err, retryAfter)
+	h := w.Header()
+	h.Set("Content-Type", "application/json")
+	h.Set("Cache-Control", "no-store")
+	h.Set("X-Content-Type-Options", "nosniff")
+	if resp.Retryable {
+		h.Set("Retry-After", strconv.Itoa(resp.RetryAfter))
+	} else {
+		h.Del("Retry-After")
+	}
+	w.WriteHeader(status)
+	// As with http.Error, there is nothing to do if the write fails.
+	_ = json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,124 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+func TestCode(t *testing.T) {
+	for _, test := range []struct {
+		status int
+		err    error
+		want   string
+	}{
+		{http.StatusNotFound, fmt.Errorf("GetUnitMeta: %w", derrors.NotFound), "not_found"},
+		{http.StatusServiceUnavailable, derrors.SheddingLoad, "shedding_load"},
+		{http.StatusNotFound, nil, "not_found"},
+		// The error does not match the status, so the status wins.
+		{http.StatusBadRequest, derrors.NotFound, "bad_request"},
+		{http.StatusMethodNotAllowed, nil, "method_not_allowed"},
+		{http.StatusInternalServerError, errors.New("boom"), "internal_server_error"},
+		{599, nil, "unknown"},
+	} {
+		if got := Code(test.status, test.err); got != test.want {
+			t.Errorf("Code(%d, %v) = %q, want %q", test.status, test.err, got, test.want)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		status     int
+		message    string
+		retryAfter time.Duration
+		want       *Response
+	}{
+		{
+			name:   "default message",
+			status: http.StatusNotFound,
+			want:   &Response{Code: "not_found", Message: "Not Found"},
+		},
+		{
+			name:    "not retryable",
+			status:  http.StatusBadRequest,
+			message: "bad version",
+			// Ignored, since the request is not retryable.
+			retryAfter: time.Minute,
+			want:       &Response{Code: "bad_request", Message: "bad version"},
+		},
+		{
+			name:   "retryable",
+			status: http.StatusServiceUnavailable,
+			want: &Response{
+				Code:       "service_unavailable",
+				Message:    "Service Unavailable",
+				Retryable:  true,
+				RetryAfter: 30,
+			},
+		},
+		{
+			name:       "retry after",
+			status:     http.StatusTooManyRequests,
+			retryAfter: 90 * time.Second,
+			want: &Response{
+				Code:       "too_many_requests",
+				Message:    "Too Many Requests",
+				Retryable:  true,
+				RetryAfter: 90,
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got := New(test.status, test.message, nil, test.retryAfter)
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("mismatch (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	w := httptest.NewRecorder()
+	w.Header().Set("Retry-After", "60")
+	Write(w, http.StatusServiceUnavailable, "try later", derrors.SheddingLoad)
+	res := w.Result()
+	if res.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("got status %d, want %d", res.StatusCode, http.StatusServiceUnavailable)
+	}
+	for header, want := range map[string]string{
+		"Content-Type": "application/json",
+		"Retry-After":  "60",
+	} {
+		if got := res.Header.Get(header); got != want {
+			t.Errorf("%s = %q, want %q", header, got, want)
+		}
+	}
+	var got Response
+	if err := json.NewDecoder(res.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	want := Response{Code: "shedding_load", Message: "try later", Retryable: true, RetryAfter: 60}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+
+	w = httptest.NewRecorder()
+	w.Header().Set("Retry-After", "60")
+	Write(w, http.StatusNotFound, "", nil)
+	if got := w.Result().Header.Get("Retry-After"); got != "" {
+		t.Errorf("Retry-After = %q for a response that is not retryable, want none", got)
+	}
+}
@@ -40,6 +40,9 @@ type Completion struct {
 	// Importers is the number of importers of this package. It is used for
 	// sorting completion results.
 	Importers int
+	// Synopsis is the synopsis of the package. It is not stored in the
+	// redis indexes, so it is only set for completions from the database.
+	Synopsis string `json:",omitempty"`
 }
 
 // Encode string-encodes a completion for storing in the completion index.
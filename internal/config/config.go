@@ -18,6 +18,7 @@ import (
 	"log"
 	"math/rand"
 	"net/http"
+	"net/url"
 	"os"
 	"path"
 	"strconv"
@@ -98,6 +99,10 @@ const (
 	// that a request can bypass the quota server.
 	BypassQuotaAuthHeader = "X-Go-Discovery-Auth-Bypass-Quota"
 
+	// APIKeyHeader is the header key used by clients of the frontend server
+	// to give their API key, which gets them a quota of their own.
+	APIKeyHeader = "X-Go-Discovery-API-Key"
+
 	// BypassCacheAuthHeader is the header key used by the frontend server to
 	// know that a request can bypass cache.
 	BypassCacheAuthHeader = "X-Go-Discovery-Auth-Bypass-Cache"
@@ -113,6 +118,11 @@ type Config struct {
 	// Discovery environment variables
 	ProxyURL, IndexURL string
 
+	// ProxyToken, if set, is sent as a bearer token in requests to the
+	// proxy at ProxyURL. Basic auth credentials can instead be supplied in
+	// ProxyURL itself, or in a .netrc file.
+	ProxyToken string `json:"-"`
+
 	// Ports used for hosting. 'DebugPort' is used for serving HTTP debug pages.
 	Port, DebugPort string
 
@@ -155,8 +165,14 @@ type Config struct {
 
 	DBSecret, DBUser, DBHost, DBPort, DBName string
 	DBSecondaryHost                          string // DB host to use if first one is down
+	DBReplicaHost                            string // read replica of the DB, for read-only queries
 	DBPassword                               string `json:"-"`
 
+	// SlowQueryThreshold is the latency at or above which database queries
+	// are logged as slow, with their plans outside of production. Zero
+	// disables the logging of slow queries.
+	SlowQueryThreshold time.Duration
+
 	// Configuration for redis page cache.
 	RedisCacheHost, RedisCachePort string
 
@@ -167,11 +183,62 @@ type Config struct {
 	// UseProfiler specifies whether to enable Stackdriver Profiler.
 	UseProfiler bool
 
+	// SecureHeaders configures the security-related headers that the
+	// frontend sets on its responses.
+	SecureHeaders SecureHeadersSettings
+
+	// ServePrometheusMetrics specifies whether to serve metrics for
+	// Prometheus to scrape at /metrics on the debug port, for deployments
+	// that are not on GCP. It does not affect exporting to Stackdriver.
+	ServePrometheusMetrics bool
+
 	Quota QuotaSettings
 
+	// ExportQuota limits requests for the full lists of imports and
+	// importers served by the frontend, which are more expensive than
+	// ordinary pages.
+	ExportQuota QuotaSettings
+
+	// FeedbackQuota limits the problem reports that users can send from
+	// documentation pages, to throttle spam.
+	FeedbackQuota QuotaSettings
+
+	// RefreshQuota limits the requests of signed-in users to process module
+	// versions again, each of which costs a worker fetch.
+	RefreshQuota QuotaSettings
+
+	// SearchQuota limits search requests, which are among the most
+	// expensive for the database.
+	SearchQuota QuotaSettings
+
+	// FetchQuota limits requests for the frontend to fetch module versions
+	// that it doesn't have, each of which may cost a worker fetch.
+	FetchQuota QuotaSettings
+
+	// StaticQuota limits requests for static files, like stylesheets and
+	// images.
+	StaticQuota QuotaSettings
+
+	// FetchModuleQuota limits the module versions that each client can
+	// have the frontend fetch, each of which makes the worker download a
+	// zip.
+	FetchModuleQuota FetchQuotaSettings
+
+	// PlaygroundURL is the Go playground that the frontend shares example
+	// snippets with, for their Share buttons.
+	PlaygroundURL string
+
+	// PlaygroundQuota limits the example snippets that users can share
+	// through the frontend, each of which is a request to the playground.
+	PlaygroundQuota QuotaSettings
+
 	// Teeproxy sepcifies the configuration values for the teeproxy.
 	Teeproxy TeeproxySettings
 
+	// RequestLog configures the files that request logs are written to when
+	// not running on GCP.
+	RequestLog RequestLogSettings
+
 	// Minimum log level below which no logs will be printed.
 	// Possible values are [debug, info, error, fatal].
 	// In case of invalid/empty value, all logs will be printed.
@@ -184,6 +251,25 @@ type Config struct {
 	// ServeStats determines whether the server has an endpoint that serves statistics for
 	// benchmarking or other purposes.
 	ServeStats bool
+
+	// DocSectionLimit, if positive, is the largest number of declarations
+	// displayed in each section of the documentation that the frontend
+	// renders. The rest of a section is loaded on demand.
+	DocSectionLimit int
+
+	// UserHeader is the request header that holds the identity of the
+	// signed-in user, like X-Goog-Authenticated-User-Email. It must be set
+	// by an authenticating proxy that strips it from incoming requests.
+	// If empty, users can't sign in, and no preferences are stored for them.
+	UserHeader string
+
+	// SearchBoosts configures the boosts applied to search scores.
+	SearchBoosts SearchBoostSettings
+
+	// SourcegraphURL is the base URL of the Sourcegraph instance that
+	// documentation links to for the uses of a declaration. If empty, no
+	// such links are rendered.
+	SourcegraphURL string
 }
 
 // AppVersionLabel returns the version label for the current instance.  This is
@@ -230,6 +316,12 @@ const TaskIDChangeIntervalWorker = 3 * time.Hour
 // version can be re-enqueued to frontend tasks.
 const TaskIDChangeIntervalFrontend = 30 * time.Minute
 
+// ExplainSlowQueries reports whether the plans of slow database queries
+// should be logged, which is everywhere but production.
+func (c *Config) ExplainSlowQueries() bool {
+	return c.DeploymentEnvironment() != "prod"
+}
+
 // DBConnInfo returns a PostgreSQL connection string constructed from
 // environment variables, using the primary database host.
 func (c *Config) DBConnInfo() string {
@@ -246,6 +338,16 @@ func (c *Config) DBSecondaryConnInfo() string {
 	return c.dbConnInfo(c.DBSecondaryHost)
 }
 
+// DBReplicaConnInfo returns a PostgreSQL connection string constructed from
+// environment variables, using the read replica host. It returns the empty
+// string if no replica is configured.
+func (c *Config) DBReplicaConnInfo() string {
+	if c.DBReplicaHost == "" {
+		return ""
+	}
+	return c.dbConnInfo(c.DBReplicaHost)
+}
+
 // dbConnInfo returns a PostgresSQL connection string for the given host.
 func (c *Config) dbConnInfo(host string) string {
 	// For the connection string syntax, see
@@ -317,6 +419,7 @@ func (c *Config) Application() string {
 type configOverride struct {
 	DBHost          string
 	DBSecondaryHost string
+	DBReplicaHost   string
 	DBName          string
 	Quota           QuotaSettings
 }
@@ -332,6 +435,77 @@ type QuotaSettings struct {
 	// AuthValues is the set of values that could be set on the AuthHeader, in
 	// order to bypass checks by the quota server.
 	AuthValues []string
+	// APIKeys is the set of values that could be set on the APIKeyHeader.
+	// Requests with one of them are limited by key rather than by IP.
+	APIKeys []string
+	// TrustedProxies is the number of proxies, like load balancers, that
+	// append to the X-Forwarded-For header of requests before they reach
+	// the server. The client's address is the one before theirs.
+	TrustedProxies int
+}
+
+// FetchQuotaSettings is config for internal/middleware/fetchquota.go.
+type FetchQuotaSettings struct {
+	// ModulesPerHour is the number of distinct module versions that each
+	// client may cause to be fetched in an hour. Zero means no limit.
+	ModulesPerHour int
+	MaxEntries     int // maximum number of clients to keep track of
+	// Allowlist is the set of IP addresses and CIDR ranges of trusted
+	// clients, like CI systems, that are not limited.
+	Allowlist []string
+	// AuthValues is the set of values that could be set on the
+	// BypassQuotaAuthHeader, in order not to be limited.
+	AuthValues []string
+	// APIKeys is the set of values that could be set on the APIKeyHeader.
+	// Requests with one of them are limited by key rather than by IP.
+	APIKeys []string
+	// TrustedProxies is the number of proxies, like load balancers, that
+	// append to the X-Forwarded-For header of requests before they reach
+	// the server. The client's address is the one before theirs.
+	TrustedProxies int
+}
+
+// RequestLogSettings is config for middleware.FileLogger and
+// middleware.JSONLogger.
+type RequestLogSettings struct {
+	// Dir is the directory that request logs are written to. If it is
+	// empty, request logs are written to the process's log instead.
+	Dir        string
+	MaxSizeMB  int           // rotate a log file once it is larger than this
+	MaxAge     time.Duration // rotate a log file once it is older than this
+	MaxBackups int           // number of rotated files to keep; 0 keeps them all
+	Compress   bool          // gzip rotated files
+	// Format is the format of request logs written to the process's
+	// standard error when Dir is empty: "json" for one JSON object per
+	// request, or anything else for the process's log.
+	Format string
+	// MinSeverity is the lowest severity of the JSON request log entries
+	// that are written, like "info" or "warning".
+	MinSeverity string
+	// SampleRate is the fraction of successful requests that are written to
+	// the JSON request log; zero writes all of them.
+	SampleRate float64
+}
+
+// SecureHeadersSettings is config for the security-related headers of
+// frontend responses.
+type SecureHeadersSettings struct {
+	// ReferrerPolicy is the value of the Referrer-Policy header; empty
+	// leaves it out.
+	ReferrerPolicy string
+	// HSTSMaxAge is the max-age of the Strict-Transport-Security header.
+	// Zero leaves the header out, which suits deployments that are not
+	// served over HTTPS.
+	HSTSMaxAge time.Duration
+}
+
+// SearchBoostSettings is config for the search boosts in
+// internal/postgres/search.go. Each boost multiplies the score of the
+// results it applies to; 1 means no boost.
+type SearchBoostSettings struct {
+	ExactName  float64 // package name is the search query
+	Stdlib     float64 // package is in the standard library
+	ModuleRoot float64 // package is at the root of its module
 }
 
 // TeeproxySettings contains the configuration values for the teeproxy. See
@@ -360,6 +534,7 @@ func Init(ctx context.Context) (_ *Config, err error) {
 		AuthValues: parseCommaList(os.Getenv("GO_DISCOVERY_AUTH_VALUES")),
 		IndexURL:   GetEnv("GO_MODULE_INDEX_URL", "https://index.golang.org/index"),
 		ProxyURL:   GetEnv("GO_MODULE_PROXY_URL", "https://proxy.golang.org"),
+		ProxyToken: os.Getenv("GO_MODULE_PROXY_TOKEN"),
 		Port:       os.Getenv("PORT"),
 		DebugPort:  os.Getenv("DEBUG_PORT"),
 		// Resolve AppEngine identifiers
@@ -382,6 +557,7 @@ func Init(ctx context.Context) (_ *Config, err error) {
 		DBUser:               GetEnv("GO_DISCOVERY_DATABASE_USER", "postgres"),
 		DBPassword:           os.Getenv("GO_DISCOVERY_DATABASE_PASSWORD"),
 		DBSecondaryHost:      chooseOne(os.Getenv("GO_DISCOVERY_DATABASE_SECONDARY_HOST")),
+		DBReplicaHost:        chooseOne(os.Getenv("GO_DISCOVERY_DATABASE_REPLICA_HOST")),
 		DBPort:               GetEnv("GO_DISCOVERY_DATABASE_PORT", "5432"),
 		DBName:               GetEnv("GO_DISCOVERY_DATABASE_NAME", "discovery-db"),
 		DBSecret:             os.Getenv("GO_DISCOVERY_DATABASE_SECRET"),
@@ -390,13 +566,93 @@ func Init(ctx context.Context) (_ *Config, err error) {
 		RedisHAHost:          os.Getenv("GO_DISCOVERY_REDIS_HA_HOST"),
 		RedisHAPort:          GetEnv("GO_DISCOVERY_REDIS_HA_PORT", "6379"),
 		Quota: QuotaSettings{
-			QPS:        10,
-			Burst:      20,
-			MaxEntries: 1000,
-			RecordOnly: func() *bool { t := true; return &t }(),
-			AuthValues: parseCommaList(os.Getenv("GO_DISCOVERY_AUTH_VALUES")),
+			QPS:            10,
+			Burst:          20,
+			MaxEntries:     1000,
+			RecordOnly:     func() *bool { t := true; return &t }(),
+			AuthValues:     parseCommaList(os.Getenv("GO_DISCOVERY_AUTH_VALUES")),
+			APIKeys:        parseCommaList(os.Getenv("GO_DISCOVERY_API_KEYS")),
+			TrustedProxies: GetEnvInt("GO_DISCOVERY_TRUSTED_PROXIES", 1),
+		},
+		ExportQuota: QuotaSettings{
+			QPS:            GetEnvInt("GO_DISCOVERY_EXPORT_QPS", 1),
+			Burst:          GetEnvInt("GO_DISCOVERY_EXPORT_BURST", 5),
+			MaxEntries:     1000,
+			RecordOnly:     func() *bool { f := false; return &f }(),
+			AuthValues:     parseCommaList(os.Getenv("GO_DISCOVERY_AUTH_VALUES")),
+			APIKeys:        parseCommaList(os.Getenv("GO_DISCOVERY_API_KEYS")),
+			TrustedProxies: GetEnvInt("GO_DISCOVERY_TRUSTED_PROXIES", 1),
+		},
+		FeedbackQuota: QuotaSettings{
+			QPS:            GetEnvInt("GO_DISCOVERY_FEEDBACK_QPS", 1),
+			Burst:          GetEnvInt("GO_DISCOVERY_FEEDBACK_BURST", 3),
+			MaxEntries:     1000,
+			RecordOnly:     func() *bool { f := false; return &f }(),
+			AuthValues:     parseCommaList(os.Getenv("GO_DISCOVERY_AUTH_VALUES")),
+			APIKeys:        parseCommaList(os.Getenv("GO_DISCOVERY_API_KEYS")),
+			TrustedProxies: GetEnvInt("GO_DISCOVERY_TRUSTED_PROXIES", 1),
+		},
+		RefreshQuota: QuotaSettings{
+			QPS:            GetEnvInt("GO_DISCOVERY_REFRESH_QPS", 1),
+			Burst:          GetEnvInt("GO_DISCOVERY_REFRESH_BURST", 3),
+			MaxEntries:     1000,
+			RecordOnly:     func() *bool { f := false; return &f }(),
+			AuthValues:     parseCommaList(os.Getenv("GO_DISCOVERY_AUTH_VALUES")),
+			APIKeys:        parseCommaList(os.Getenv("GO_DISCOVERY_API_KEYS")),
+			TrustedProxies: GetEnvInt("GO_DISCOVERY_TRUSTED_PROXIES", 1),
+		},
+		SearchQuota: QuotaSettings{
+			QPS:            GetEnvInt("GO_DISCOVERY_SEARCH_QPS", 5),
+			Burst:          GetEnvInt("GO_DISCOVERY_SEARCH_BURST", 20),
+			MaxEntries:     1000,
+			RecordOnly:     func() *bool { f := false; return &f }(),
+			AuthValues:     parseCommaList(os.Getenv("GO_DISCOVERY_AUTH_VALUES")),
+			APIKeys:        parseCommaList(os.Getenv("GO_DISCOVERY_API_KEYS")),
+			TrustedProxies: GetEnvInt("GO_DISCOVERY_TRUSTED_PROXIES", 1),
+		},
+		FetchQuota: QuotaSettings{
+			QPS:            GetEnvInt("GO_DISCOVERY_FETCH_QPS", 1),
+			Burst:          GetEnvInt("GO_DISCOVERY_FETCH_BURST", 5),
+			MaxEntries:     1000,
+			RecordOnly:     func() *bool { f := false; return &f }(),
+			AuthValues:     parseCommaList(os.Getenv("GO_DISCOVERY_AUTH_VALUES")),
+			APIKeys:        parseCommaList(os.Getenv("GO_DISCOVERY_API_KEYS")),
+			TrustedProxies: GetEnvInt("GO_DISCOVERY_TRUSTED_PROXIES", 1),
+		},
+		StaticQuota: QuotaSettings{
+			QPS:            GetEnvInt("GO_DISCOVERY_STATIC_QPS", 50),
+			Burst:          GetEnvInt("GO_DISCOVERY_STATIC_BURST", 100),
+			MaxEntries:     1000,
+			RecordOnly:     func() *bool { f := false; return &f }(),
+			AuthValues:     parseCommaList(os.Getenv("GO_DISCOVERY_AUTH_VALUES")),
+			APIKeys:        parseCommaList(os.Getenv("GO_DISCOVERY_API_KEYS")),
+			TrustedProxies: GetEnvInt("GO_DISCOVERY_TRUSTED_PROXIES", 1),
+		},
+		FetchModuleQuota: FetchQuotaSettings{
+			ModulesPerHour: GetEnvInt("GO_DISCOVERY_FETCH_MODULES_PER_HOUR", 30),
+			MaxEntries:     1000,
+			Allowlist:      parseCommaList(os.Getenv("GO_DISCOVERY_FETCH_ALLOWLIST")),
+			AuthValues:     parseCommaList(os.Getenv("GO_DISCOVERY_AUTH_VALUES")),
+			APIKeys:        parseCommaList(os.Getenv("GO_DISCOVERY_API_KEYS")),
+			TrustedProxies: GetEnvInt("GO_DISCOVERY_TRUSTED_PROXIES", 1),
+		},
+		PlaygroundURL: strings.TrimSuffix(GetEnv("GO_DISCOVERY_PLAYGROUND_URL", "https://play.golang.org"), "/"),
+		PlaygroundQuota: QuotaSettings{
+			QPS:            GetEnvInt("GO_DISCOVERY_PLAYGROUND_QPS", 1),
+			Burst:          GetEnvInt("GO_DISCOVERY_PLAYGROUND_BURST", 5),
+			MaxEntries:     1000,
+			RecordOnly:     func() *bool { f := false; return &f }(),
+			AuthValues:     parseCommaList(os.Getenv("GO_DISCOVERY_AUTH_VALUES")),
+			APIKeys:        parseCommaList(os.Getenv("GO_DISCOVERY_API_KEYS")),
+			TrustedProxies: GetEnvInt("GO_DISCOVERY_TRUSTED_PROXIES", 1),
 		},
 		UseProfiler: os.Getenv("GO_DISCOVERY_USE_PROFILER") == "TRUE",
+		SecureHeaders: SecureHeadersSettings{
+			ReferrerPolicy: GetEnv("GO_DISCOVERY_REFERRER_POLICY", "strict-origin-when-cross-origin"),
+			HSTSMaxAge:     time.Duration(GetEnvInt("GO_DISCOVERY_HSTS_MAX_AGE_SECONDS", 0)) * time.Second,
+		},
+		ServePrometheusMetrics: os.Getenv("GO_DISCOVERY_SERVE_PROMETHEUS_METRICS") == "true",
+		SlowQueryThreshold:     time.Duration(GetEnvInt("GO_DISCOVERY_SLOW_QUERY_THRESHOLD_MS", 1000)) * time.Millisecond,
 		Teeproxy: TeeproxySettings{
 			AuthKey:          BypassQuotaAuthHeader,
 			AuthValue:        os.Getenv("GO_DISCOVERY_TEEPROXY_AUTH_VALUE"),
@@ -410,8 +666,26 @@ func Init(ctx context.Context) (_ *Config, err error) {
 			MaxTimeout:       time.Duration(GetEnvInt("GO_DISCOVERY_TEEPROXY_MAX_TIMEOUT_SECONDS", 240)) * time.Second,
 			SuccsToGreen:     GetEnvInt("GO_DISCOVERY_TEEPROXY_SUCCS_TO_GREEN", 20),
 		},
-		LogLevel:   os.Getenv("GO_DISCOVERY_LOG_LEVEL"),
-		ServeStats: os.Getenv("GO_DISCOVERY_SERVE_STATS") == "true",
+		RequestLog: RequestLogSettings{
+			Dir:         os.Getenv("GO_DISCOVERY_REQUEST_LOG_DIR"),
+			MaxSizeMB:   GetEnvInt("GO_DISCOVERY_REQUEST_LOG_MAX_MB", 100),
+			MaxAge:      time.Duration(GetEnvInt("GO_DISCOVERY_REQUEST_LOG_MAX_AGE_HOURS", 24)) * time.Hour,
+			MaxBackups:  GetEnvInt("GO_DISCOVERY_REQUEST_LOG_MAX_BACKUPS", 7),
+			Compress:    os.Getenv("GO_DISCOVERY_REQUEST_LOG_COMPRESS") != "false",
+			Format:      os.Getenv("GO_DISCOVERY_REQUEST_LOG_FORMAT"),
+			MinSeverity: os.Getenv("GO_DISCOVERY_REQUEST_LOG_SEVERITY"),
+			SampleRate:  GetEnvFloat64("GO_DISCOVERY_REQUEST_LOG_SAMPLE_RATE", 0),
+		},
+		LogLevel:        os.Getenv("GO_DISCOVERY_LOG_LEVEL"),
+		ServeStats:      os.Getenv("GO_DISCOVERY_SERVE_STATS") == "true",
+		DocSectionLimit: GetEnvInt("GO_DISCOVERY_DOC_SECTION_LIMIT", 0),
+		SourcegraphURL:  os.Getenv("GO_DISCOVERY_SOURCEGRAPH_URL"),
+		UserHeader:      os.Getenv("GO_DISCOVERY_USER_HEADER"),
+		SearchBoosts: SearchBoostSettings{
+			ExactName:  GetEnvFloat64("GO_DISCOVERY_SEARCH_BOOST_EXACT_NAME", 2),
+			Stdlib:     GetEnvFloat64("GO_DISCOVERY_SEARCH_BOOST_STDLIB", 1.5),
+			ModuleRoot: GetEnvFloat64("GO_DISCOVERY_SEARCH_BOOST_MODULE_ROOT", 1.2),
+		},
 	}
 	bucket := os.Getenv("GO_DISCOVERY_CONFIG_BUCKET")
 	object := os.Getenv("GO_DISCOVERY_CONFIG_DYNAMIC")
@@ -522,6 +796,7 @@ func processOverrides(cfg *Config, bytes []byte) {
 	}
 	overrideString("DBHost", &cfg.DBHost, ov.DBHost)
 	overrideString("DBSecondaryHost", &cfg.DBSecondaryHost, ov.DBSecondaryHost)
+	overrideString("DBReplicaHost", &cfg.DBReplicaHost, ov.DBReplicaHost)
 	overrideString("DBName", &cfg.DBName, ov.DBName)
 	overrideInt("Quota.QPS", &cfg.Quota.QPS, ov.Quota.QPS)
 	overrideInt("Quota.Burst", &cfg.Quota.Burst, ov.Quota.Burst)
@@ -555,7 +830,15 @@ func (c *Config) Dump(w io.Writer) error {
 	fmt.Fprint(w, "config: ")
 	enc := json.NewEncoder(w)
 	enc.SetIndent("", "    ")
-	return enc.Encode(c)
+	// Don't print proxy credentials.
+	cc := *c
+	if u, err := url.Parse(cc.ProxyURL); err != nil {
+		cc.ProxyURL = "<unparseable URL>"
+	} else if u.User != nil {
+		u.User = url.User("xxxxx")
+		cc.ProxyURL = u.String()
+	}
+	return enc.Encode(&cc)
 }
 
 // chooseOne selects one entry at random from a whitespace-separated
@@ -20,6 +20,7 @@ import (
 	"github.com/lib/pq"
 	"golang.org/x/pkgsite/internal/derrors"
 	"golang.org/x/pkgsite/internal/log"
+	"golang.org/x/pkgsite/internal/requestid"
 )
 
 // DB wraps a sql.DB. The methods it exports correspond closely to those of
@@ -34,6 +35,9 @@ type DB struct {
 	tx         *sql.Tx
 	mu         sync.Mutex
 	maxRetries int // max times a single transaction was retried
+
+	slowQueryThreshold time.Duration // see SetSlowQueryLogging
+	explainSlowQueries bool
 }
 
 // Open creates a new DB  for the given connection string.
@@ -78,6 +82,7 @@ func (db *DB) Close() error {
 // Exec executes a SQL statement and returns the number of rows it affected.
 func (db *DB) Exec(ctx context.Context, query string, args ...interface{}) (_ int64, err error) {
 	defer logQuery(ctx, query, args, db.instanceID)(&err)
+	defer db.instrumentQuery(ctx, query, args)(&err)
 	res, err := db.execResult(ctx, query, args...)
 	if err != nil {
 		return 0, err
@@ -91,15 +96,29 @@ func (db *DB) Exec(ctx context.Context, query string, args ...interface{}) (_ in
 
 // execResult executes a SQL statement and returns a sql.Result.
 func (db *DB) execResult(ctx context.Context, query string, args ...interface{}) (res sql.Result, err error) {
+	query = withRequestID(ctx, query)
 	if db.tx != nil {
 		return db.tx.ExecContext(ctx, query, args...)
 	}
 	return db.db.ExecContext(ctx, query, args...)
 }
 
+// withRequestID prefixes query with a comment holding the request ID in ctx,
+// if there is one, so that the query can be matched to the request in the
+// database logs.
+func withRequestID(ctx context.Context, query string) string {
+	id := requestid.FromContext(ctx)
+	if !requestid.Valid(id) {
+		return query
+	}
+	return "/* request_id=" + id + " */ " + query
+}
+
 // Query runs the DB query.
 func (db *DB) Query(ctx context.Context, query string, args ...interface{}) (_ *sql.Rows, err error) {
 	defer logQuery(ctx, query, args, db.instanceID)(&err)
+	defer db.instrumentQuery(ctx, query, args)(&err)
+	query = withRequestID(ctx, query)
 	if db.tx != nil {
 		return db.tx.QueryContext(ctx, query, args...)
 	}
@@ -108,6 +127,7 @@ func (db *DB) Query(ctx context.Context, query string, args ...interface{}) (_ *
 
 // QueryRow runs the query and returns a single row.
 func (db *DB) QueryRow(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	defer db.instrumentQuery(ctx, query, args)(nil)
 	start := time.Now()
 	defer func() {
 		d, _ := ctx.Deadline()
@@ -119,6 +139,7 @@ func (db *DB) QueryRow(ctx context.Context, query string, args ...interface{}) *
 		}
 		logQuery(ctx, query, args, db.instanceID)(nil)
 	}()
+	query = withRequestID(ctx, query)
 	if db.tx != nil {
 		return db.tx.QueryRowContext(ctx, query, args...)
 	}
@@ -225,6 +246,7 @@ func (db *DB) transact(ctx context.Context, opts *sql.TxOptions, txFunc func(*DB
 
 	dbtx := New(db.db, db.instanceID)
 	dbtx.tx = tx
+	dbtx.SetSlowQueryLogging(db.slowQueryThreshold, db.explainSlowQueries)
 	defer dbtx.logTransaction(ctx, opts)(&err)
 	if err := txFunc(dbtx); err != nil {
 		return fmt.Errorf("txFunc(tx): %w", err)
@@ -377,6 +399,70 @@ func buildInsertQuery(table string, columns, returningColumns []string, nvalues
 	return b.String()
 }
 
+// CopyUpsert is like BulkUpsert, but sends the values to Postgres with the
+// COPY protocol, which is much faster than INSERT statements for large
+// numbers of rows. The values are copied into a temporary table, whose rows
+// are then inserted into table in the order of conflictColumns, so that
+// concurrent upserts lock rows in the same order.
+//
+// CopyUpsert must be called in a transaction.
+func (db *DB) CopyUpsert(ctx context.Context, table string, columns []string, values []interface{}, conflictColumns []string) (err error) {
+	defer derrors.Wrap(&err, "DB.CopyUpsert(ctx, %q, %v, [%d values], %v)",
+		table, columns, len(values), conflictColumns)
+
+	if !db.InTransaction() {
+		return errors.New("not in a transaction")
+	}
+	if remainder := len(values) % len(columns); remainder != 0 {
+		return fmt.Errorf("modulus of len(values) and len(columns) must be 0: got %d", remainder)
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	cols := strings.Join(columns, ", ")
+	tempTable := "copy_upsert_" + table
+	if _, err := db.Exec(ctx, fmt.Sprintf(
+		`CREATE TEMPORARY TABLE %s ON COMMIT DROP AS SELECT %s FROM %s WITH NO DATA`,
+		tempTable, cols, table)); err != nil {
+		return err
+	}
+	if err := db.copyIn(ctx, tempTable, columns, values); err != nil {
+		return err
+	}
+	query := fmt.Sprintf(`INSERT INTO %s (%s) SELECT %s FROM %s ORDER BY %s %s`,
+		table, cols, cols, tempTable, strings.Join(conflictColumns, ", "),
+		buildUpsertConflictAction(columns, conflictColumns))
+	if _, err := db.Exec(ctx, query); err != nil {
+		return err
+	}
+	// Drop the temporary table now, so that it can be created again in the
+	// same transaction.
+	_, err = db.Exec(ctx, `DROP TABLE `+tempTable)
+	return err
+}
+
+// copyIn copies values, which hold len(columns) values for each row, into
+// the columns of table.
+func (db *DB) copyIn(ctx context.Context, table string, columns []string, values []interface{}) (err error) {
+	stmt, err := db.Prepare(ctx, pq.CopyIn(table, columns...))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := stmt.Close(); err == nil {
+			err = cerr
+		}
+	}()
+	for i := 0; i < len(values); i += len(columns) {
+		if _, err := stmt.ExecContext(ctx, values[i:i+len(columns)]...); err != nil {
+			return fmt.Errorf("copying values[%d:%d]: %w", i, i+len(columns), err)
+		}
+	}
+	// Executing the statement with no values flushes the copied rows.
+	_, err = stmt.ExecContext(ctx)
+	return err
+}
+
 func buildUpsertConflictAction(columns, conflictColumns []string) string {
 	var sets []string
 	for _, c := range columns {
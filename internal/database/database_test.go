@@ -18,6 +18,7 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/requestid"
 	"golang.org/x/pkgsite/internal/testing/dbtest"
 )
 
@@ -259,6 +260,56 @@ func TestBulkUpsert(t *testing.T) {
 	}
 }
 
+func TestCopyUpsert(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout*3)
+	defer cancel()
+	if _, err := testDB.Exec(ctx, `CREATE TEMPORARY TABLE test_copy_upsert (C1 int PRIMARY KEY, C2 text, C3 bytea)`); err != nil {
+		t.Fatal(err)
+	}
+	for _, values := range [][]interface{}{
+		{2, "b", []byte{2}, 4, "d", []byte{4}},                                             // First, insert some rows.
+		{1, "a", []byte{1}, 2, "b\t\n", []byte{0}, 3, "c", []byte{3}, 4, "", []byte("\\")}, // Then replace those rows while inserting others.
+	} {
+		err := testDB.Transact(ctx, sql.LevelDefault, func(tx *DB) error {
+			// Upsert twice in the transaction, to check that the temporary
+			// table is dropped.
+			for i := 0; i < 2; i++ {
+				if err := tx.CopyUpsert(ctx, "test_copy_upsert", []string{"C1", "C2", "C3"}, values, []string{"C1"}); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []interface{}
+		err = testDB.RunQuery(ctx, `SELECT C1, C2, C3 FROM test_copy_upsert ORDER BY C1`, func(rows *sql.Rows) error {
+			var (
+				a int
+				b string
+				c []byte
+			)
+			if err := rows.Scan(&a, &b, &c); err != nil {
+				return err
+			}
+			got = append(got, a, b, c)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(values, got); diff != "" {
+			t.Errorf("mismatch (-want, +got):\n%s", diff)
+		}
+	}
+
+	err := testDB.CopyUpsert(ctx, "test_copy_upsert", []string{"C1"}, []interface{}{5}, []string{"C1"})
+	if err == nil {
+		t.Error("got nil error outside a transaction, want error")
+	}
+}
+
 func TestBuildUpsertConflictAction(t *testing.T) {
 	got := buildUpsertConflictAction([]string{"a", "b"}, []string{"c", "d"})
 	want := "ON CONFLICT (c, d) DO UPDATE SET a=excluded.a, b=excluded.b"
@@ -267,6 +318,21 @@ func TestBuildUpsertConflictAction(t *testing.T) {
 	}
 }
 
+func TestWithRequestID(t *testing.T) {
+	const query = "SELECT 1"
+	ctx := context.Background()
+	if got := withRequestID(ctx, query); got != query {
+		t.Errorf("without request ID: got %q, want %q", got, query)
+	}
+	got := withRequestID(requestid.NewContext(ctx, "abc-123"), query)
+	if want := "/* request_id=abc-123 */ SELECT 1"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := withRequestID(requestid.NewContext(ctx, "*/ DROP TABLE x; /*"), query); got != query {
+		t.Errorf("with invalid request ID: got %q, want %q", got, query)
+	}
+}
+
 func TestDBAfterTransactFails(t *testing.T) {
 	ctx := context.Background()
 	var tx *DB
@@ -0,0 +1,203 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"runtime"
+	"strings"
+	"time"
+
+	"contrib.go.opencensus.io/integrations/ocsql"
+	"go.opencensus.io/plugin/ochttp"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	"go.opencensus.io/trace"
+	"golang.org/x/pkgsite/internal/log"
+)
+
+var (
+	keyQueryName   = tag.MustNewKey("db.query_name")
+	keyQueryStatus = tag.MustNewKey("db.query_status")
+
+	queryLatency = stats.Float64(
+		"go-discovery/db/query_latency",
+		"Latency of a database query.",
+		stats.UnitMilliseconds,
+	)
+
+	// QueryLatency is the latency of database queries, by query name and
+	// whether they failed.
+	QueryLatency = &view.View{
+		Name:        "go-discovery/db/query_latency",
+		Measure:     queryLatency,
+		Aggregation: ochttp.DefaultLatencyDistribution,
+		Description: "database query latency, by query name and status",
+		TagKeys:     []tag.Key{keyQueryName, keyQueryStatus},
+	}
+	// QueryCount is the number of database queries, by query name and
+	// whether they failed.
+	QueryCount = &view.View{
+		Name:        "go-discovery/db/query_count",
+		Measure:     queryLatency,
+		Aggregation: view.Count(),
+		Description: "database query count, by query name and status",
+		TagKeys:     []tag.Key{keyQueryName, keyQueryStatus},
+	}
+	// PoolStatsViews are the views of the statistics of the connection
+	// pool that RecordPoolStats records.
+	PoolStatsViews = []*view.View{
+		ocsql.SQLClientOpenConnectionsView,
+		ocsql.SQLClientIdleConnectionsView,
+		ocsql.SQLClientActiveConnectionsView,
+		ocsql.SQLClientWaitCountView,
+		ocsql.SQLClientWaitDurationView,
+	}
+)
+
+// RecordPoolStats records the statistics of db's connection pool every
+// interval, until the returned function is called.
+func (db *DB) RecordPoolStats(interval time.Duration) (stop func()) {
+	return ocsql.RecordStats(db.db, interval)
+}
+
+type queryNameKey struct{}
+
+// WithQueryName returns a context that names the queries run with it, in
+// traces, metrics and slow-query logs. Without a name, a query is named
+// after the function that ran it, like "postgres.(*DB).GetUnitMeta".
+func WithQueryName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, queryNameKey{}, name)
+}
+
+// queryName returns the name of a query run with ctx by the caller of a
+// method of DB.
+func queryName(ctx context.Context) string {
+	if name, ok := ctx.Value(queryNameKey{}).(string); ok {
+		return name
+	}
+	pcs := make([]uintptr, 10)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, dbMethodPrefix) {
+			return callerName(frame.Function)
+		}
+		if !more {
+			return "unknown"
+		}
+	}
+}
+
+// dbMethodPrefix is the prefix of the full names of the methods of DB.
+const dbMethodPrefix = "golang.org/x/pkgsite/internal/database.(*DB)."
+
+// callerName shortens the full name of a function, like
+// "golang.org/x/pkgsite/internal/postgres.(*DB).GetUnit.func1", to its
+// package and name, like "postgres.(*DB).GetUnit".
+func callerName(fn string) string {
+	if i := strings.LastIndexByte(fn, '/'); i >= 0 {
+		fn = fn[i+1:]
+	}
+	// Name function literals after the function that contains them.
+	for {
+		i := strings.LastIndex(fn, ".func")
+		if i < 0 || strings.Trim(fn[i+len(".func"):], "0123456789.") != "" {
+			break
+		}
+		fn = fn[:i]
+	}
+	return fn
+}
+
+// SetSlowQueryLogging makes db log queries that take threshold or longer,
+// with their plans if explain is true. A threshold of zero disables logging
+// of slow queries. Getting the plan of a slow query costs another round trip
+// to the database, so explain should be true only outside production.
+func (db *DB) SetSlowQueryLogging(threshold time.Duration, explain bool) {
+	db.slowQueryThreshold = threshold
+	db.explainSlowQueries = explain
+}
+
+// instrumentQuery starts a trace span for query, and returns a function that
+// ends the span, records the latency of the query and logs it if it was slow.
+// The function should be called with a pointer to the error returned by the
+// query, or with nil if there is none.
+func (db *DB) instrumentQuery(ctx context.Context, query string, args []interface{}) func(*error) {
+	name := queryName(ctx)
+	_, span := trace.StartSpan(ctx, "db/"+name)
+	start := time.Now()
+	return func(errp *error) {
+		dur := time.Since(start)
+		status := "ok"
+		if errp != nil && *errp != nil {
+			status = "error"
+			span.SetStatus(trace.Status{Code: trace.StatusCodeUnknown, Message: (*errp).Error()})
+		}
+		span.End()
+		stats.RecordWithTags(ctx, []tag.Mutator{
+			tag.Upsert(keyQueryName, name),
+			tag.Upsert(keyQueryStatus, status),
+		}, queryLatency.M(float64(dur)/float64(time.Millisecond)))
+		if db.slowQueryThreshold > 0 && dur >= db.slowQueryThreshold {
+			db.logSlowQuery(ctx, name, query, args, dur, status == "ok")
+		}
+	}
+}
+
+// explainTimeout bounds the time to get the plan of a slow query.
+const explainTimeout = 10 * time.Second
+
+// logSlowQuery logs a query that took dur, and its plan if slow queries are
+// explained and the query succeeded.
+func (db *DB) logSlowQuery(ctx context.Context, name, query string, args []interface{}, dur time.Duration, succeeded bool) {
+	msg := compactQuery(query)
+	// Only plan single statements run outside a transaction: the connection
+	// of a transaction may still be busy with the rows of the query.
+	if db.explainSlowQueries && succeeded && db.tx == nil && explainable(query) {
+		ectx, cancel := context.WithTimeout(context.Background(), explainTimeout)
+		defer cancel()
+		plan, err := db.explain(ectx, query, args)
+		if err != nil {
+			plan = "EXPLAIN failed: " + err.Error()
+		}
+		msg += "\n" + plan
+	}
+	log.Warningf(ctx, "slow query %s took %s: %s", name, dur.Round(time.Millisecond), msg)
+}
+
+// explainable reports whether query is a single statement that can be
+// explained without side effects.
+func explainable(query string) bool {
+	fields := strings.Fields(query)
+	if len(fields) == 0 || strings.Contains(strings.TrimRight(query, "; \t\n"), ";") {
+		return false
+	}
+	switch strings.ToUpper(fields[0]) {
+	case "SELECT", "WITH", "INSERT", "UPDATE", "DELETE":
+		return true
+	}
+	return false
+}
+
+// explain returns the plan of query, without running it.
+func (db *DB) explain(ctx context.Context, query string, args []interface{}) (string, error) {
+	rows, err := db.db.QueryContext(ctx, "EXPLAIN "+query, args...)
+	if err != nil {
+		return "", err
+	}
+	var lines []string
+	err = processRows(rows, func(rows *sql.Rows) error {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return err
+		}
+		lines = append(lines, line)
+		return nil
+	})
+	return strings.Join(lines, "\n"), err
+}
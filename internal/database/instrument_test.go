@@ -0,0 +1,57 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package database
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCallerName(t *testing.T) {
+	for _, test := range []struct {
+		in, want string
+	}{
+		{"golang.org/x/pkgsite/internal/postgres.(*DB).GetUnit", "postgres.(*DB).GetUnit"},
+		{"golang.org/x/pkgsite/internal/postgres.(*DB).GetUnit.func1", "postgres.(*DB).GetUnit"},
+		{"golang.org/x/pkgsite/internal/postgres.(*DB).saveModule.func1.2", "postgres.(*DB).saveModule"},
+		{"golang.org/x/pkgsite/internal/postgres.insertLicenses", "postgres.insertLicenses"},
+		{"golang.org/x/pkgsite/internal/postgres.functions", "postgres.functions"},
+		{"main.main", "main.main"},
+	} {
+		if got := callerName(test.in); got != test.want {
+			t.Errorf("callerName(%q) = %q, want %q", test.in, got, test.want)
+		}
+	}
+}
+
+func TestQueryName(t *testing.T) {
+	ctx := context.Background()
+	if got, want := queryName(ctx), "database.TestQueryName"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	ctx = WithQueryName(ctx, "search")
+	if got, want := queryName(ctx), "search"; got != want {
+		t.Errorf("with name: got %q, want %q", got, want)
+	}
+}
+
+func TestExplainable(t *testing.T) {
+	for _, test := range []struct {
+		query string
+		want  bool
+	}{
+		{"SELECT 1", true},
+		{"\n\t\twith x AS (SELECT 1) SELECT * FROM x;", true},
+		{"DELETE FROM modules WHERE module_path = $1", true},
+		{"TRUNCATE modules", false},
+		{"TRUNCATE modules; TRUNCATE imports_unique;", false},
+		{"SELECT 1; SELECT 2", false},
+		{"", false},
+	} {
+		if got := explainable(test.query); got != test.want {
+			t.Errorf("explainable(%q) = %t, want %t", test.query, got, test.want)
+		}
+	}
+}
@@ -38,18 +38,7 @@ func logQuery(ctx context.Context, query string, args []interface{}, instanceID
 	}
 	const maxlen = 300 // maximum length of displayed query
 
-	// To make the query more compact and readable, replace newlines with spaces
-	// and collapse adjacent whitespace.
-	var r []rune
-	for _, c := range query {
-		if c == '\n' {
-			c = ' '
-		}
-		if len(r) == 0 || !unicode.IsSpace(r[len(r)-1]) || !unicode.IsSpace(c) {
-			r = append(r, c)
-		}
-	}
-	query = string(r)
+	query = compactQuery(query)
 	if len(query) > maxlen {
 		query = query[:maxlen] + "..."
 	}
@@ -115,6 +104,21 @@ func logQuery(ctx context.Context, query string, args []interface{}, instanceID
 	}
 }
 
+// compactQuery makes query more compact and readable, by replacing newlines
+// with spaces and collapsing adjacent whitespace.
+func compactQuery(query string) string {
+	var r []rune
+	for _, c := range query {
+		if c == '\n' {
+			c = ' '
+		}
+		if len(r) == 0 || !unicode.IsSpace(r[len(r)-1]) || !unicode.IsSpace(c) {
+			r = append(r, c)
+		}
+	}
+	return string(r)
+}
+
 func (db *DB) logTransaction(ctx context.Context, opts *sql.TxOptions) func(*error) {
 	if QueryLoggingDisabled {
 		return func(*error) {}
@@ -7,6 +7,7 @@ package dcensus
 
 import (
 	"context"
+	"expvar"
 	"fmt"
 	"net/http"
 	"strings"
@@ -75,6 +76,7 @@ const debugPage = `
 <html>
 <p><a href="/tracez">/tracez</a> - trace spans</p>
 <p><a href="/statsz">/statz</a> - prometheus metrics page</p>
+<p><a href="/debug/vars">/debug/vars</a> - exported variables</p>
 `
 
 // Init configures tracing and aggregation according to the given Views. If
@@ -91,15 +93,21 @@ func Init(cfg *config.Config, views ...*view.View) error {
 	return nil
 }
 
-// NewServer creates a new http.Handler for serving debug information.
-func NewServer() (http.Handler, error) {
-	pe, err := prometheus.NewExporter(prometheus.Options{})
+// NewServer creates a new http.Handler for serving debug information. If
+// servePrometheusMetrics is true, it also serves the metrics at /metrics,
+// where Prometheus scrapes them by default.
+func NewServer(servePrometheusMetrics bool) (http.Handler, error) {
+	pe, err := NewPrometheusHandler()
 	if err != nil {
-		return nil, fmt.Errorf("dcensus.NewServer: prometheus.NewExporter: %v", err)
+		return nil, fmt.Errorf("dcensus.NewServer: %v", err)
 	}
 	mux := http.NewServeMux()
 	zpages.Handle(mux, "/")
 	mux.Handle("/statsz", pe)
+	if servePrometheusMetrics {
+		mux.Handle("/metrics", pe)
+	}
+	mux.Handle("/debug/vars", expvar.Handler())
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		fmt.Fprint(w, debugPage)
 	})
@@ -107,6 +115,21 @@ func NewServer() (http.Handler, error) {
 	return mux, nil
 }
 
+// NewPrometheusHandler returns an http.Handler that serves the data of the
+// registered views in the Prometheus exposition format, for Prometheus to
+// scrape.
+func NewPrometheusHandler() (http.Handler, error) {
+	pe, err := prometheus.NewExporter(prometheus.Options{
+		OnError: func(err error) {
+			log.Errorf(context.Background(), "Prometheus exporter: %v", err)
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("prometheus.NewExporter: %v", err)
+	}
+	return pe, nil
+}
+
 // monitoredResource wraps a *mrpb.MonitoredResource to implement the
 // monitoredresource.MonitoredResource interface.
 type monitoredResource mrpb.MonitoredResource
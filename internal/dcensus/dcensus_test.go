@@ -5,6 +5,8 @@
 package dcensus
 
 import (
+	"context"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -12,6 +14,7 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	"go.opencensus.io/plugin/ochttp"
+	"go.opencensus.io/stats"
 	"go.opencensus.io/stats/view"
 )
 
@@ -63,3 +66,39 @@ func TestRouter(t *testing.T) {
 		t.Errorf("unexpected route tag counts (-want +got):\n%s", diff)
 	}
 }
+
+func TestPrometheusHandler(t *testing.T) {
+	m := stats.Int64("go-discovery/test/prometheus_count", "test", stats.UnitDimensionless)
+	v := &view.View{Name: "go-discovery/test/prometheus_count", Measure: m, Aggregation: view.Count()}
+	if err := view.Register(v); err != nil {
+		t.Fatal(err)
+	}
+	defer view.Unregister(v)
+	stats.Record(context.Background(), m.M(1), m.M(1))
+
+	// Prometheus names can't contain slashes or dashes.
+	const want = "go_discovery_test_prometheus_count 2"
+	for _, test := range []struct {
+		servePrometheusMetrics bool
+		path                   string
+		wantMetrics            bool
+	}{
+		{false, "/statsz", true},
+		{false, "/metrics", false},
+		{true, "/metrics", true},
+	} {
+		h, err := NewServer(test.servePrometheusMetrics)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", test.path, nil))
+		body, err := ioutil.ReadAll(w.Result().Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Contains(string(body), want); got != test.wantMetrics {
+			t.Errorf("NewServer(%t), GET %s: got metrics %t, want %t", test.servePrometheusMetrics, test.path, got, test.wantMetrics)
+		}
+	}
+}
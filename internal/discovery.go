@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
 	"golang.org/x/pkgsite/internal/licenses"
 	"golang.org/x/pkgsite/internal/source"
 	"golang.org/x/pkgsite/internal/stdlib"
@@ -38,6 +39,82 @@ type ModuleInfo struct {
 	IsRedistributable bool
 	HasGoMod          bool // whether the module zip has a go.mod file
 	SourceInfo        *source.Info
+	Metadata          *ModuleMetadata // author-supplied presentation metadata, if any
+	Deprecation       *Deprecation    // non-nil if the go.mod file marks the module deprecated
+	Retractions       []*Retraction   // versions that the go.mod file retracts
+}
+
+// Deprecation describes a module that its author has marked as deprecated,
+// with a comment beginning "Deprecated:" on the module directive of its
+// go.mod file.
+type Deprecation struct {
+	// Message is the text of the comment after "Deprecated:".
+	Message string
+	// Successor is the path of the module that Message says to use instead,
+	// or empty if it doesn't name one.
+	Successor string
+}
+
+// A Retraction is a version, or a range of versions, of a module that its
+// author has retracted with a retract directive in the go.mod file. The go
+// command applies the retractions in the go.mod file of the latest version
+// of the module.
+type Retraction struct {
+	// Low and High are the inclusive bounds of the retracted versions. They
+	// are equal if the directive retracts a single version.
+	Low  string `json:"low"`
+	High string `json:"high"`
+	// Rationale is the comment on the directive, if any.
+	Rationale string `json:"rationale,omitempty"`
+}
+
+// Covers reports whether r retracts version v.
+func (r *Retraction) Covers(v string) bool {
+	return semver.Compare(r.Low, v) <= 0 && semver.Compare(v, r.High) <= 0
+}
+
+// ModuleMetadata is information that a module author supplies about how the
+// module should be presented, through comments in the go.mod file or a
+// pkgsite.yaml file at the module root.
+type ModuleMetadata struct {
+	Description string   `json:"description,omitempty"`
+	Keywords    []string `json:"keywords,omitempty"`
+	Category    string   `json:"category,omitempty"`
+	// Documentation is the module's own documentation website, if one was
+	// detected in its files. Unlike the other fields, authors don't supply
+	// it directly.
+	Documentation *DocumentationSite `json:"documentation,omitempty"`
+	// Overview summarizes the module if its root directory is not a
+	// package. Like Documentation, it is derived from the module's files.
+	Overview *ModuleOverview `json:"overview,omitempty"`
+}
+
+// A ModuleOverview summarizes a module whose root directory is not a package,
+// such as one that groups related packages, for its landing page. It is
+// assembled when the module is fetched.
+type ModuleOverview struct {
+	// Packages holds the first packages of the module in path order, up to
+	// a limit. NumPackages is the total number of packages.
+	Packages    []*PackageOverview `json:"packages"`
+	NumPackages int                `json:"num_packages"`
+	// LicenseTypes is the sorted set of the types of all the licenses in
+	// the module.
+	LicenseTypes []string `json:"license_types,omitempty"`
+}
+
+// A PackageOverview describes a package in a ModuleOverview.
+type PackageOverview struct {
+	Path      string `json:"path"`
+	Synopsis  string `json:"synopsis,omitempty"`
+	IsCommand bool   `json:"is_command,omitempty"`
+}
+
+// A DocumentationSite is a website with documentation for a module.
+type DocumentationSite struct {
+	URL string `json:"url"`
+	// Source is the path of the file in which the URL was found, relative
+	// to the module root.
+	Source string `json:"source"`
 }
 
 // VersionMap holds metadata associated with module queries for a version.
@@ -51,6 +128,24 @@ type VersionMap struct {
 	UpdatedAt        time.Time
 }
 
+// A FetchStage says how far a fetch of a module version has progressed.
+type FetchStage string
+
+const (
+	// FetchQueued means that the module version is waiting to be fetched.
+	FetchQueued FetchStage = "queued"
+	// FetchDownloading means that the worker is downloading the module
+	// version from the proxy.
+	FetchDownloading FetchStage = "downloading"
+	// FetchProcessing means that the worker is processing the contents of
+	// the module version and storing the result.
+	FetchProcessing FetchStage = "processing"
+	// FetchDone means that the module version was fetched.
+	FetchDone FetchStage = "done"
+	// FetchError means that the fetch failed.
+	FetchError FetchStage = "error"
+)
+
 // SeriesPath returns the series path for the module.
 //
 // A series is a group of modules that share the same base path and are assumed
@@ -82,6 +177,21 @@ func Suffix(fullPath, basePath string) string {
 	return strings.TrimPrefix(strings.TrimPrefix(fullPath, basePath), "/")
 }
 
+// PathLess reports whether path a sorts before path b in the order in which
+// lists of paths are displayed. Paths are compared element by element, and
+// elements byte by byte, so that a directory is followed immediately by its
+// subdirectories: "a", "a/b", "a-b". The database orders paths in the same
+// way.
+func PathLess(a, b string) bool {
+	as, bs := strings.Split(a, "/"), strings.Split(b, "/")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if as[i] != bs[i] {
+			return as[i] < bs[i]
+		}
+	}
+	return len(as) < len(bs)
+}
+
 // V1Path returns the path for version 1 of the package whose import path
 // is fullPath. If modulePath is the standard library, then V1Path returns
 // fullPath.
@@ -99,10 +209,50 @@ type Module struct {
 	// that may be contained in nested subdirectories.
 	Licenses []*licenses.License
 	Units    []*Unit
+	// Requirements holds the require directives of the module's go.mod file,
+	// sorted by module path.
+	Requirements []*ModuleRequirement
 
 	LegacyPackages []*LegacyPackage
 }
 
+// A ModuleRequirement is a require directive of a go.mod file.
+type ModuleRequirement struct {
+	ModulePath string
+	Version    string
+	// Indirect reports whether the directive is marked with an
+	// "// indirect" comment, because no package of the module imports the
+	// required module directly.
+	Indirect bool
+}
+
+// A ModuleDependency describes whether a module version transitively
+// requires another module, according to the dependency index.
+type ModuleDependency struct {
+	ModulePath           string `json:"module_path"`
+	Version              string `json:"version"`
+	DependencyModulePath string `json:"dependency_module_path"`
+	Depends              bool   `json:"depends"`
+
+	// The following fields are set only if Depends is true.
+
+	// DependencyVersion is the highest version of the dependency required
+	// anywhere in the requirement graph, which is the one the go command
+	// selects.
+	DependencyVersion string `json:"dependency_version,omitempty"`
+	// Depth is the length of the shortest chain of requirements from the
+	// module to the dependency. It is 1 for a direct requirement.
+	Depth int `json:"depth,omitempty"`
+	// Via is the direct requirement of the module that starts that chain.
+	Via string `json:"via,omitempty"`
+
+	// Incomplete reports whether some of the module versions in the
+	// requirement graph were not in the database when it was indexed, so
+	// that some dependencies may be missing.
+	Incomplete bool      `json:"incomplete"`
+	IndexedAt  time.Time `json:"indexed_at"`
+}
+
 // Packages returns all of the units for a module that are packages.
 func (m *Module) Packages() []*Unit {
 	var pkgs []*Unit
@@ -175,6 +325,51 @@ type PackageVersionState struct {
 	Error       string
 }
 
+// A SearchScoreFactor is one of the factors that the score of a search result
+// is the product of, like its relevance to the query or a boost.
+type SearchScoreFactor struct {
+	Name  string  `json:"name"`
+	Value float64 `json:"value"`
+}
+
+// A SearchSnapshotEntry describes one search document in a snapshot of the
+// search corpus, which is used for offline relevance experiments. Only data
+// that is shown on the site is included. The format of a snapshot is
+// described in doc/worker.md.
+type SearchSnapshotEntry struct {
+	PackagePath string `json:"package_path"`
+	ModulePath  string `json:"module_path"`
+	Version     string `json:"version"`
+	Name        string `json:"name"`
+	// Synopsis is empty for packages that are not redistributable.
+	Synopsis        string      `json:"synopsis,omitempty"`
+	Kind            PackageKind `json:"kind,omitempty"`
+	ForkOf          string      `json:"fork_of,omitempty"`
+	ImportedByCount int         `json:"imported_by_count"`
+	Redistributable bool        `json:"redistributable"`
+	HasGoMod        bool        `json:"has_go_mod"`
+	// StaticScore is the product of the factors of the search score that
+	// don't depend on the query: the popularity and the penalties.
+	StaticScore float64 `json:"static_score"`
+}
+
+// Provenance records where and when the zip of a module version was
+// downloaded, for auditing the integrity of modules and debugging
+// inconsistencies between proxy caches. A record is kept each time the
+// module version is processed.
+type Provenance struct {
+	ModulePath string `json:"module_path"`
+	Version    string `json:"version"`
+	// ProxyURL is the URL that the zip was downloaded from.
+	ProxyURL string `json:"proxy_url"`
+	// ResponseHeader holds the headers of the proxy's response.
+	ResponseHeader   map[string][]string `json:"response_header,omitempty"`
+	ZipSHA256        string              `json:"zip_sha256"`
+	ZipSize          int64               `json:"zip_size"`
+	DownloadedAt     time.Time           `json:"downloaded_at"`
+	DownloadDuration time.Duration       `json:"download_duration_ns"`
+}
+
 // SearchResult represents a single search result from SearchDocuments.
 type SearchResult struct {
 	Name        string
@@ -183,10 +378,21 @@ type SearchResult struct {
 	Version     string
 	Synopsis    string
 	Licenses    []string
+	// SynopsisInferred reports whether Synopsis was derived from a README
+	// because the package has no doc comment.
+	SynopsisInferred bool
+	// Kind classifies the package by what its files contain.
+	Kind PackageKind
+	// ForkOf is the path of the standard library package that the package
+	// is a copy of, or empty if it is not a copy.
+	ForkOf string
 
 	CommitTime time.Time
 	// Score is used to sort items in an array of SearchResult.
 	Score float64
+	// Explanation lists the factors whose product is Score. It is only set
+	// when an explanation of the ranking is requested.
+	Explanation []*SearchScoreFactor
 
 	// NumImportedBy is the number of packages that import PackagePath.
 	NumImportedBy uint64
@@ -198,4 +404,12 @@ type SearchResult struct {
 	// can be approximate if search scanned only a subset of documents, and
 	// result count is estimated using the hyperloglog algorithm.
 	Approximate bool
+
+	// SameModule holds the best of the other packages of ModulePath that
+	// matched, when results are grouped by module. NumResults counts
+	// modules in that case.
+	SameModule []*SearchResult
+	// NumSameModule is the number of other packages of ModulePath that
+	// matched, which may be more than len(SameModule).
+	NumSameModule int
 }
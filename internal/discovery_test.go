@@ -59,3 +59,22 @@ func TestV1Path(t *testing.T) {
 		}
 	}
 }
+
+func TestPathLess(t *testing.T) {
+	for _, test := range []struct {
+		a, b string
+		want bool
+	}{
+		{"a", "a", false},
+		{"a", "a/b", true},
+		{"a/b", "a-b", true},
+		{"a-b", "a/b", false},
+		{"a/b/c", "a/c", true},
+		{"a/B", "a/b", true},
+		{"a/b", "a", false},
+	} {
+		if got := PathLess(test.a, test.b); got != test.want {
+			t.Errorf("PathLess(%q, %q) = %t, want %t", test.a, test.b, got, test.want)
+		}
+	}
+}
@@ -7,11 +7,29 @@ package internal
 
 const (
 	ExperimentAltRequeue          = "alt-requeue"
+	ExperimentAPIDiff             = "api-diff"
 	ExperimentAutocomplete        = "autocomplete"
+	ExperimentBuildConstraints    = "build-constraints"
+	ExperimentBuildContextDocs    = "build-context-docs"
+	ExperimentCollapseDeprecated  = "collapse-deprecated"
+	ExperimentDocSiteLinks        = "doc-site-links"
+	ExperimentDocSizeBreakdown    = "doc-size-breakdown"
+	ExperimentEmbedDocs           = "embed-docs"
 	ExperimentFrontendRenderDoc   = "frontend-render-doc"
 	ExperimentInsertPackageSource = "insert-package-source"
+	ExperimentModuleDoc           = "module-doc"
+	ExperimentModuleOverview      = "module-overview"
+	ExperimentModuleRedirects     = "module-redirects"
+	ExperimentReadmePackageLinks  = "readme-package-links"
 	ExperimentRemoveUnusedAST     = "remove-unused-ast"
+	ExperimentSearchFilters       = "search-filters"
+	ExperimentSearchGrouping      = "search-grouping"
+	ExperimentSearchRecency       = "search-recency"
 	ExperimentSidenav             = "sidenav"
+	ExperimentSourceOnlyDoc       = "source-only-doc"
+	ExperimentSplitLargeDoc       = "split-large-doc"
+	ExperimentSymbolHistory       = "symbol-history"
+	ExperimentUnexportedDocs      = "unexported-docs"
 	ExperimentUnitPage            = "unit-page"
 )
 
@@ -19,11 +37,29 @@ const (
 // a description of each experiment.
 var Experiments = map[string]string{
 	ExperimentAltRequeue:          "Requeue modules for reprocessing in a different order.",
+	ExperimentAPIDiff:             "Store the declarations of exported identifiers, and serve a page comparing the API of two versions of a module.",
 	ExperimentAutocomplete:        "Enable autocomplete with search.",
+	ExperimentBuildConstraints:    "Show the files of a package that build constraints leave out of its documentation.",
+	ExperimentBuildContextDocs:    "Store the documentation of packages whose doc text differs between build contexts, and link to it from the unit page.",
+	ExperimentCollapseDeprecated:  "Move deprecated identifiers to a separate section of the documentation index.",
+	ExperimentDocSiteLinks:        "Detect the documentation websites of modules when fetching them, and link to them from the unit header.",
+	ExperimentDocSizeBreakdown:    "Account for the size of each part of rendered documentation HTML, and show the largest packages on the worker home page.",
+	ExperimentEmbedDocs:           "Show the files that //go:embed directives embed in package-level variables next to their declarations.",
 	ExperimentFrontendRenderDoc:   "Render documentation on the frontend if possible.",
 	ExperimentInsertPackageSource: "Insert the source code of a package in the database.",
+	ExperimentModuleDoc:           "Serve the documentation of all the packages in a module on one page.",
+	ExperimentModuleOverview:      "Summarize modules whose root is not a package when fetching them, and show the summary on their overview page.",
+	ExperimentModuleRedirects:     "Redirect the pages of modules whose go.mod file declares a new path to the pages at that path.",
+	ExperimentReadmePackageLinks:  "Link README references to package directories of the same module to the pages of those packages, instead of to their source.",
 	ExperimentRemoveUnusedAST:     "Prune AST prior to rendering documentation HTML.",
+	ExperimentSearchFilters:       "Accept filters like license:MIT in search queries, and show counts of the results that each filter would select on the search page.",
+	ExperimentSearchGrouping:      "Group search results from the same module into one result, with a list of the other packages that matched.",
+	ExperimentSearchRecency:       "Rank search results lower the longer ago the latest version of their module was released, beyond a grace period.",
 	ExperimentSidenav:             "Display documentation index on the left sidenav.",
+	ExperimentSourceOnlyDoc:       "Store the source of package documentation without its HTML, and render the HTML on the frontend when it is served. Requires insert-package-source.",
+	ExperimentSplitLargeDoc:       "Split documentation that is too large to display into several pages.",
+	ExperimentSymbolHistory:       "Record the version in which each exported identifier first appeared, and display it in the documentation.",
+	ExperimentUnexportedDocs:      "Let users view documentation with unexported identifiers on the unit page, with the query parameter m=all.",
 	ExperimentUnitPage:            "Enable the redesigned details page.",
 }
 
@@ -0,0 +1,76 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package internal
+
+import "time"
+
+// A FeedbackReport is a problem with a page that was reported by a user.
+type FeedbackReport struct {
+	ID int
+	// Path and Version identify the package the page is about.
+	Path    string
+	Version string
+	// Category classifies the problem.
+	Category FeedbackCategory
+	// Text is the user's description of the problem.
+	Text string
+	// TraceID is the ID of the trace of the request that rendered the
+	// page, so that its logs can be found.
+	TraceID   string
+	Status    FeedbackStatus
+	CreatedAt time.Time
+}
+
+// A FeedbackCategory classifies the problem a FeedbackReport is about.
+type FeedbackCategory string
+
+const (
+	FeedbackCategoryRendering FeedbackCategory = "rendering"
+	FeedbackCategoryMissing   FeedbackCategory = "missing"
+	FeedbackCategoryLicense   FeedbackCategory = "license"
+	FeedbackCategoryOther     FeedbackCategory = "other"
+)
+
+// FeedbackCategories are the categories that users can choose from, in the
+// order in which they are offered.
+var FeedbackCategories = []FeedbackCategory{
+	FeedbackCategoryRendering,
+	FeedbackCategoryMissing,
+	FeedbackCategoryLicense,
+	FeedbackCategoryOther,
+}
+
+// Description returns a description of c for users.
+func (c FeedbackCategory) Description() string {
+	switch c {
+	case FeedbackCategoryRendering:
+		return "Documentation is displayed incorrectly"
+	case FeedbackCategoryMissing:
+		return "Something is missing from the page"
+	case FeedbackCategoryLicense:
+		return "License is detected incorrectly"
+	default:
+		return "Something else"
+	}
+}
+
+// A FeedbackStatus records the progress of the triage of a FeedbackReport.
+type FeedbackStatus string
+
+const (
+	FeedbackStatusNew      FeedbackStatus = "new"
+	FeedbackStatusTriaged  FeedbackStatus = "triaged"
+	FeedbackStatusResolved FeedbackStatus = "resolved"
+	FeedbackStatusSpam     FeedbackStatus = "spam"
+)
+
+// FeedbackStatuses are all the feedback statuses, in the order in which a
+// report goes through them.
+var FeedbackStatuses = []FeedbackStatus{
+	FeedbackStatusNew,
+	FeedbackStatusTriaged,
+	FeedbackStatusResolved,
+	FeedbackStatusSpam,
+}
@@ -0,0 +1,85 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fetch
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/godoc"
+	"golang.org/x/pkgsite/internal/log"
+)
+
+// buildContextDocs returns the documentation of the package at innerPath in
+// each build context of goEnvs that it can be loaded in, if its text differs
+// between them, and nil otherwise. goos and goarch are the build context of
+// the documentation that is displayed by default; only the build contexts
+// whose documentation differs from it keep their source.
+//
+// Failing to load the package in a build context is not an error: that build
+// context is left out, and the documentation displayed by default is
+// unaffected.
+func buildContextDocs(ctx context.Context, zipGoFiles []*zip.File, innerPath string, modInfo *godoc.ModuleInfo, goos, goarch string) []*internal.BuildContextDoc {
+	var (
+		docs        []*internal.BuildContextDoc
+		defaultHash string
+	)
+	for _, env := range goEnvs {
+		d, err := loadBuildContextDoc(ctx, env.GOOS, env.GOARCH, zipGoFiles, innerPath, modInfo)
+		if err != nil {
+			log.Infof(ctx, "buildContextDocs: %v", err)
+			continue
+		}
+		if d == nil {
+			continue
+		}
+		if d.GOOS == goos && d.GOARCH == goarch {
+			defaultHash = d.Hash
+		}
+		docs = append(docs, d)
+	}
+	if defaultHash == "" {
+		return nil
+	}
+	var differ bool
+	for _, d := range docs {
+		if d.Hash == defaultHash {
+			d.Source = nil
+		} else {
+			differ = true
+		}
+	}
+	if !differ {
+		return nil
+	}
+	return docs
+}
+
+// loadBuildContextDoc returns the documentation of the package at innerPath
+// in the build context given by goos and goarch, or nil if there is no
+// package in that build context.
+func loadBuildContextDoc(ctx context.Context, goos, goarch string, zipGoFiles []*zip.File, innerPath string, modInfo *godoc.ModuleInfo) (*internal.BuildContextDoc, error) {
+	_, goFiles, _, fset, err := loadFilesWithBuildContext(innerPath, goos, goarch, zipGoFiles)
+	if err != nil {
+		if errors.Is(err, derrors.NotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	docPkg := newDocPackage(ctx, fset, goos, goarch, goFiles, innerPath, modInfo)
+	// Encode before computing the hash, which destroys the AST.
+	src, err := docPkg.Encode()
+	if err != nil {
+		return nil, err
+	}
+	hash, err := docPkg.TextHash(innerPath, modInfo)
+	if err != nil {
+		return nil, err
+	}
+	return &internal.BuildContextDoc{GOOS: goos, GOARCH: goarch, Hash: hash, Source: src}, nil
+}
@@ -0,0 +1,91 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fetch
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal/godoc"
+	"golang.org/x/pkgsite/internal/testing/testhelper"
+)
+
+func TestBuildContextDocs(t *testing.T) {
+	const pkgGo = `
+		// Package p does things.
+		package p
+
+		// F does things.
+		func F() {}`
+	const darwinGo = `
+		package p
+
+		// Darwin does things that only make sense on darwin.
+		func Darwin() {}`
+	const unexportedGo = `
+		// +build windows
+
+		package p
+
+		func windowsOnly() {}`
+
+	type buildContext struct {
+		goos, goarch string
+		hasSource    bool
+	}
+	for _, test := range []struct {
+		name     string
+		contents map[string]string
+		want     []buildContext
+	}{
+		{
+			name:     "same everywhere",
+			contents: map[string]string{"p.go": pkgGo},
+			want:     nil,
+		},
+		{
+			name:     "unexported differences",
+			contents: map[string]string{"p.go": pkgGo, "p_windows.go": unexportedGo},
+			want:     nil,
+		},
+		{
+			name:     "darwin differs",
+			contents: map[string]string{"p.go": pkgGo, "p_darwin.go": darwinGo},
+			want: []buildContext{
+				{"linux", "amd64", false},
+				{"windows", "amd64", false},
+				{"darwin", "amd64", true},
+				{"js", "wasm", false},
+				{"linux", "js", false},
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := testhelper.ZipContents(test.contents)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			modInfo := &godoc.ModuleInfo{ModulePath: "example.com/p", ResolvedVersion: "v1.0.0"}
+			docs := buildContextDocs(context.Background(), r.File, "", modInfo, "linux", "amd64")
+			var got []buildContext
+			for _, d := range docs {
+				if d.Hash == "" {
+					t.Errorf("%s/%s: empty hash", d.GOOS, d.GOARCH)
+				}
+				got = append(got, buildContext{d.GOOS, d.GOARCH, len(d.Source) > 0})
+			}
+			if diff := cmp.Diff(test.want, got, cmp.AllowUnexported(buildContext{})); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
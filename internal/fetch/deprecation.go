@@ -0,0 +1,76 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fetch
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"golang.org/x/pkgsite/internal"
+)
+
+// deprecatedRE matches a paragraph of a comment that starts with
+// "Deprecated:", as in the go command.
+var deprecatedRE = regexp.MustCompile(`(?s)(?:^|\n\n)Deprecated: *(.*?)(?:$|\n\n)`)
+
+// extractDeprecation returns the deprecation of the module, if the comments
+// on the module directive of its go.mod file contain a paragraph that starts
+// with "Deprecated:". Otherwise it returns nil.
+func extractDeprecation(modulePath string, goMod []byte) *internal.Deprecation {
+	f, err := modfile.ParseLax("go.mod", goMod, nil)
+	if err != nil || f.Module == nil || f.Module.Syntax == nil {
+		return nil
+	}
+	m := deprecatedRE.FindStringSubmatch(directiveComment(f.Module.Syntax))
+	if m == nil {
+		return nil
+	}
+	msg := strings.Join(strings.Fields(m[1]), " ")
+	return &internal.Deprecation{
+		Message:   msg,
+		Successor: deprecationSuccessor(modulePath, msg),
+	}
+}
+
+// directiveComment returns the text of the comments before and after line,
+// without comment markers.
+func directiveComment(line *modfile.Line) string {
+	comments := append(line.Before, line.Suffix...)
+	var lines []string
+	for _, c := range comments {
+		if !strings.HasPrefix(c.Token, "//") {
+			continue
+		}
+		lines = append(lines, strings.TrimSpace(strings.TrimPrefix(c.Token, "//")))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// deprecationSuccessor returns the first module path in a deprecation
+// message other than modulePath itself, as in "Use example.com/mod/v2
+// instead." It returns the empty string if there is none.
+func deprecationSuccessor(modulePath, msg string) string {
+	for _, word := range strings.Fields(msg) {
+		word = strings.Trim(word, ".,;:!?()[]<>\"'`")
+		for _, prefix := range []string{"https://", "http://", "pkg.go.dev/"} {
+			word = strings.TrimPrefix(word, prefix)
+		}
+		if i := strings.Index(word, "@"); i >= 0 {
+			word = word[:i]
+		}
+		word = strings.TrimSuffix(word, "/")
+		// Require a dot in the first path element, so that ordinary words
+		// and standard library paths are not mistaken for modules.
+		if word == modulePath || !strings.Contains(strings.Split(word, "/")[0], ".") {
+			continue
+		}
+		if module.CheckPath(word) == nil {
+			return word
+		}
+	}
+	return ""
+}
@@ -0,0 +1,64 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fetch
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+)
+
+func TestExtractDeprecation(t *testing.T) {
+	const modulePath = "example.com/mod"
+	for _, test := range []struct {
+		name  string
+		goMod string
+		want  *internal.Deprecation
+	}{
+		{
+			name:  "not deprecated",
+			goMod: "// A module.\nmodule example.com/mod\n",
+			want:  nil,
+		},
+		{
+			name:  "successor",
+			goMod: "// Deprecated: use example.com/mod/v2 instead.\nmodule example.com/mod\n",
+			want: &internal.Deprecation{
+				Message:   "use example.com/mod/v2 instead.",
+				Successor: "example.com/mod/v2",
+			},
+		},
+		{
+			name: "successor URL in later paragraph",
+			goMod: "// Package mod does things.\n//\n" +
+				"// Deprecated: this module is unmaintained.\n// See https://pkg.go.dev/example.org/other@v1.2.0.\n" +
+				"module example.com/mod\n",
+			want: &internal.Deprecation{
+				Message:   "this module is unmaintained. See https://pkg.go.dev/example.org/other@v1.2.0.",
+				Successor: "example.org/other",
+			},
+		},
+		{
+			name:  "suffix comment without successor",
+			goMod: "module example.com/mod // Deprecated: do not use example.com/mod.\n",
+			want: &internal.Deprecation{
+				Message: "do not use example.com/mod.",
+			},
+		},
+		{
+			name:  "not the first word",
+			goMod: "// This is not Deprecated: really.\nmodule example.com/mod\n",
+			want:  nil,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got := extractDeprecation(modulePath, []byte(test.goMod))
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("mismatch (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
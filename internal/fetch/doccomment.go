@@ -0,0 +1,42 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fetch
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"go/ast"
+	"sort"
+	"strings"
+)
+
+// packageCommentHash returns a hash of the package comment of the files,
+// which map file names to ASTs and may include test files, or the empty
+// string if the package has no comment. As in go/doc, the comments of all
+// non-test files are joined in the order of their names. Copies of a package
+// that keep its comment have the same hash, wherever they are. It must be
+// called before the files are given to godoc, which modifies them.
+func packageCommentHash(files map[string]*ast.File) string {
+	var names []string
+	for name, f := range files {
+		if !strings.HasSuffix(name, "_test.go") && f.Doc != nil {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	sort.Strings(names)
+	var texts []string
+	for _, name := range names {
+		if text := strings.TrimSpace(files[name].Doc.Text()); text != "" {
+			texts = append(texts, text)
+		}
+	}
+	if len(texts) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(strings.Join(texts, "\n"))))
+}
@@ -0,0 +1,53 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fetch
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+)
+
+func TestPackageCommentHash(t *testing.T) {
+	hash := func(files map[string]string) string {
+		t.Helper()
+		fset := token.NewFileSet()
+		asts := map[string]*ast.File{}
+		for name, src := range files {
+			f, err := parser.ParseFile(fset, name, src, parser.ParseComments)
+			if err != nil {
+				t.Fatal(err)
+			}
+			asts[name] = f
+		}
+		return packageCommentHash(asts)
+	}
+
+	orig := hash(map[string]string{
+		"doc.go":  "// Package json implements encoding and decoding of JSON.\npackage json",
+		"json.go": "package json\n\nfunc Marshal(v interface{}) ([]byte, error) { return nil, nil }",
+	})
+	if orig == "" {
+		t.Fatal("got empty hash for a package with a comment")
+	}
+	copied := hash(map[string]string{
+		"doc.go":    "// Package json implements encoding and decoding of JSON.\npackage json",
+		"encode.go": "package json\n\nfunc Marshal(v interface{}) ([]byte, error) { return []byte{}, nil }",
+		"x_test.go": "// Package json is tested here.\npackage json",
+	})
+	if copied != orig {
+		t.Errorf("copy with the same comment: got hash %q, want %q", copied, orig)
+	}
+	changed := hash(map[string]string{
+		"doc.go": "// Package json is a faster encoder of JSON.\npackage json",
+	})
+	if changed == orig {
+		t.Error("got the same hash for a different comment")
+	}
+	if got := hash(map[string]string{"p.go": "package p"}); got != "" {
+		t.Errorf("no comment: got hash %q, want empty", got)
+	}
+}
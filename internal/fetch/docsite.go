@@ -0,0 +1,142 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fetch
+
+import (
+	"archive/zip"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/source"
+)
+
+// selfDocHosts are the hosts of sites that serve Go package documentation
+// from module zips, like this one. Links to them are not links to a
+// project's own documentation site, even if they are labeled as docs: they
+// redirect to, or duplicate, the documentation that pkgsite displays.
+var selfDocHosts = map[string]bool{
+	"go.dev":     true,
+	"pkg.go.dev": true,
+	"godoc.org":  true,
+}
+
+// readmeBadgeRegexp matches a Markdown badge: an image that is a link, as in
+//
+//	[![Documentation](https://img.shields.io/badge/docs-latest-blue)](https://example.com/docs)
+//
+// The first submatch is the alt text of the image, and the second is the
+// target of the link.
+var readmeBadgeRegexp = regexp.MustCompile(`\[!\[([^\]]*)\]\([^)]*\)\]\((https?://[^)\s]+)\)`)
+
+// detectDocumentationSite looks for hints that a module has a documentation
+// website of its own, and returns the first that it finds, or nil. In order,
+// the hints are:
+//   - the site_url of a mkdocs.yml file at the module root;
+//   - a site configuration in a docs directory at the module root: its
+//     mkdocs.yml or Jekyll _config.yml, the latter of which GitHub Pages
+//     serves at a well-known URL if it doesn't set one;
+//   - a documentation badge in the README at the module root.
+func detectDocumentationSite(modulePath, resolvedVersion string, r *zip.Reader, readmes []*internal.Readme, sourceInfo *source.Info) *internal.DocumentationSite {
+	prefix := moduleVersionDir(modulePath, resolvedVersion) + "/"
+	files := map[string][]byte{}
+	for _, f := range r.File {
+		name := strings.TrimPrefix(f.Name, prefix)
+		switch name {
+		case "mkdocs.yml", "docs/mkdocs.yml", "docs/_config.yml":
+		default:
+			continue
+		}
+		if f.UncompressedSize64 > MaxFileSize {
+			continue
+		}
+		b, err := readZipFile(f, MaxFileSize)
+		if err != nil {
+			continue
+		}
+		files[name] = b
+	}
+
+	for _, name := range []string{"mkdocs.yml", "docs/mkdocs.yml"} {
+		if b, ok := files[name]; ok {
+			var config struct {
+				SiteURL string `json:"site_url"`
+			}
+			if yaml.Unmarshal(b, &config) == nil {
+				if u := documentationURL(config.SiteURL); u != "" {
+					return &internal.DocumentationSite{URL: u, Source: name}
+				}
+			}
+		}
+	}
+	if b, ok := files["docs/_config.yml"]; ok {
+		var config struct {
+			URL     string `json:"url"`
+			BaseURL string `json:"baseurl"`
+		}
+		if yaml.Unmarshal(b, &config) == nil {
+			u := documentationURL(strings.TrimSuffix(config.URL, "/") + "/" + strings.Trim(config.BaseURL, "/"))
+			if config.URL == "" {
+				u = githubPagesURL(sourceInfo.RepoURL())
+			}
+			if u != "" {
+				return &internal.DocumentationSite{URL: u, Source: "docs/_config.yml"}
+			}
+		}
+	}
+	for _, readme := range readmes {
+		if path.Dir(readme.Filepath) != "." {
+			continue
+		}
+		for _, m := range readmeBadgeRegexp.FindAllStringSubmatch(readme.Contents, -1) {
+			alt, target := strings.ToLower(m[1]), m[2]
+			u := documentationURL(target)
+			if u == "" {
+				continue
+			}
+			if strings.Contains(alt, "doc") || strings.HasSuffix(hostname(u), ".readthedocs.io") {
+				return &internal.DocumentationSite{URL: u, Source: readme.Filepath}
+			}
+		}
+	}
+	return nil
+}
+
+// documentationURL returns rawURL if it is the absolute HTTP(S) URL of a
+// site other than one in selfDocHosts, and the empty string otherwise.
+func documentationURL(rawURL string) string {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ""
+	}
+	if selfDocHosts[strings.TrimPrefix(u.Hostname(), "www.")] {
+		return ""
+	}
+	return u.String()
+}
+
+// hostname returns the host name of the URL rawURL, which must be valid.
+func hostname(rawURL string) string {
+	u, _ := url.Parse(rawURL)
+	return u.Hostname()
+}
+
+// githubPagesURL returns the URL where GitHub Pages serves the site of the
+// GitHub repository at repoURL, or the empty string if repoURL is not a
+// GitHub repository.
+func githubPagesURL(repoURL string) string {
+	const githubPrefix = "https://github.com/"
+	if !strings.HasPrefix(repoURL, githubPrefix) {
+		return ""
+	}
+	parts := strings.Split(strings.TrimPrefix(repoURL, githubPrefix), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return ""
+	}
+	return "https://" + strings.ToLower(parts[0]) + ".github.io/" + parts[1] + "/"
+}
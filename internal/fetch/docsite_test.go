@@ -0,0 +1,95 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fetch
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/source"
+)
+
+func TestDetectDocumentationSite(t *testing.T) {
+	const (
+		modulePath = "github.com/owner/repo"
+		version    = "v1.0.0"
+		prefix     = modulePath + "@" + version + "/"
+	)
+	sourceInfo := source.NewGitHubInfo("https://github.com/Owner/repo", "", version)
+	for _, test := range []struct {
+		name    string
+		files   map[string]string
+		readmes []*internal.Readme
+		want    *internal.DocumentationSite
+	}{
+		{
+			name:  "none",
+			files: map[string]string{prefix + "go.mod": "module " + modulePath},
+		},
+		{
+			name: "mkdocs",
+			files: map[string]string{
+				prefix + "mkdocs.yml":       "site_name: Repo\nsite_url: https://repo.example.com/\n",
+				prefix + "docs/_config.yml": "url: https://other.example.com\n",
+			},
+			want: &internal.DocumentationSite{URL: "https://repo.example.com/", Source: "mkdocs.yml"},
+		},
+		{
+			name: "mkdocs without site_url",
+			files: map[string]string{
+				prefix + "mkdocs.yml": "site_name: Repo\n",
+			},
+		},
+		{
+			name: "jekyll",
+			files: map[string]string{
+				prefix + "docs/_config.yml": "url: https://example.com\nbaseurl: /repo\n",
+			},
+			want: &internal.DocumentationSite{URL: "https://example.com/repo", Source: "docs/_config.yml"},
+		},
+		{
+			name: "github pages",
+			files: map[string]string{
+				prefix + "docs/_config.yml": "theme: minima\n",
+			},
+			want: &internal.DocumentationSite{URL: "https://owner.github.io/repo/", Source: "docs/_config.yml"},
+		},
+		{
+			name: "readme badge",
+			readmes: []*internal.Readme{
+				{Filepath: "sub/README.md", Contents: "[![Docs](https://img/badge)](https://sub.example.com)"},
+				{Filepath: "README.md", Contents: "[![GoDoc](https://godoc.org/x?status.svg)](https://godoc.org/github.com/owner/repo)\n" +
+					"[![Build](https://img/build)](https://ci.example.com)\n" +
+					"[![Documentation](https://img/docs)](https://repo.example.com/docs)\n"},
+			},
+			want: &internal.DocumentationSite{URL: "https://repo.example.com/docs", Source: "README.md"},
+		},
+		{
+			name: "readthedocs badge",
+			readmes: []*internal.Readme{
+				{Filepath: "README.md", Contents: "[![latest](https://img/rtd)](https://repo.readthedocs.io/en/latest/)"},
+			},
+			want: &internal.DocumentationSite{URL: "https://repo.readthedocs.io/en/latest/", Source: "README.md"},
+		},
+		{
+			name: "pkg.go.dev badge",
+			readmes: []*internal.Readme{
+				{Filepath: "README.md", Contents: "[![Go Reference](https://pkg.go.dev/badge/x.svg)](https://pkg.go.dev/github.com/owner/repo)"},
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			files := test.files
+			if files == nil {
+				files = map[string]string{prefix + "go.mod": "module " + modulePath}
+			}
+			got := detectDocumentationSite(modulePath, version, makeZipReader(t, files), test.readmes, sourceInfo)
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("mismatch (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
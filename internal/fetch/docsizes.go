@@ -0,0 +1,72 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fetch
+
+import (
+	"sort"
+	"sync"
+
+	"golang.org/x/pkgsite/internal/godoc"
+)
+
+// maxDocSizes is the number of packages whose documentation sizes are kept.
+const maxDocSizes = 20
+
+// A DocSize describes how the documentation HTML of a package breaks down.
+type DocSize struct {
+	PackagePath string
+	Version     string
+	GOOS        string
+	GOARCH      string
+	godoc.SizeBreakdown
+}
+
+// PercentOfMax returns the size of the documentation as a percentage of
+// godoc.MaxDocumentationHTML.
+func (s *DocSize) PercentOfMax() float64 {
+	return 100 * float64(s.Total) / float64(godoc.MaxDocumentationHTML)
+}
+
+// DocSizeStats holds the sizes of the documentation rendered by this
+// process.
+type DocSizeStats struct {
+	// Packages is the number of packages whose documentation was accounted
+	// for.
+	Packages int
+	// Sum is the sum of their sizes.
+	Sum godoc.SizeBreakdown
+	// Largest holds the packages with the largest documentation, largest
+	// first.
+	Largest []*DocSize
+}
+
+var (
+	docSizeMu    sync.Mutex
+	docSizeStats DocSizeStats
+)
+
+// recordDocSize adds s to the documentation sizes of this process.
+func recordDocSize(s *DocSize) {
+	docSizeMu.Lock()
+	defer docSizeMu.Unlock()
+	docSizeStats.Packages++
+	docSizeStats.Sum.Add(&s.SizeBreakdown)
+	largest := append(docSizeStats.Largest, s)
+	sort.SliceStable(largest, func(i, j int) bool { return largest[i].Total > largest[j].Total })
+	if len(largest) > maxDocSizes {
+		largest = largest[:maxDocSizes]
+	}
+	docSizeStats.Largest = largest
+}
+
+// DocSizes returns the sizes of the documentation rendered by this process
+// with the doc-size-breakdown experiment active.
+func DocSizes() *DocSizeStats {
+	docSizeMu.Lock()
+	defer docSizeMu.Unlock()
+	stats := docSizeStats
+	stats.Largest = append([]*DocSize(nil), docSizeStats.Largest...)
+	return &stats
+}
@@ -0,0 +1,43 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fetch
+
+import (
+	"fmt"
+	"testing"
+
+	"golang.org/x/pkgsite/internal/godoc"
+)
+
+func TestRecordDocSize(t *testing.T) {
+	defer func(s DocSizeStats) { docSizeStats = s }(docSizeStats)
+	docSizeStats = DocSizeStats{}
+
+	const n = maxDocSizes + 5
+	for i := 0; i < n; i++ {
+		recordDocSize(&DocSize{
+			PackagePath:   fmt.Sprintf("p%d", i),
+			SizeBreakdown: godoc.SizeBreakdown{Total: int64(i + 1), Index: 1},
+		})
+	}
+	got := DocSizes()
+	if got.Packages != n {
+		t.Errorf("Packages = %d, want %d", got.Packages, n)
+	}
+	if want := int64(n * (n + 1) / 2); got.Sum.Total != want {
+		t.Errorf("Sum.Total = %d, want %d", got.Sum.Total, want)
+	}
+	if got.Sum.Index != n {
+		t.Errorf("Sum.Index = %d, want %d", got.Sum.Index, n)
+	}
+	if len(got.Largest) != maxDocSizes {
+		t.Fatalf("got %d largest, want %d", len(got.Largest), maxDocSizes)
+	}
+	for i, s := range got.Largest {
+		if want := fmt.Sprintf("p%d", n-1-i); s.PackagePath != want {
+			t.Errorf("Largest[%d] = %s, want %s", i, s.PackagePath, want)
+		}
+	}
+}
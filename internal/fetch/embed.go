@@ -0,0 +1,192 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fetch
+
+import (
+	"go/ast"
+	"go/token"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/pkgsite/internal/godoc"
+)
+
+const embedDirective = "//go:embed"
+
+// embeds returns the files embedded by //go:embed directives in the
+// package-level variables of goFiles, keyed by variable name. dirFiles are
+// the paths of the files in the package directory and its subdirectories,
+// relative to the package directory.
+//
+// Directives that are malformed, or that are not attached to the
+// declaration of a single variable, are ignored.
+func embeds(goFiles map[string]*ast.File, dirFiles []string) map[string]*godoc.Embed {
+	var m map[string]*godoc.Embed
+	for name, f := range goFiles {
+		if strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		for _, decl := range f.Decls {
+			gd, ok := decl.(*ast.GenDecl)
+			if !ok || gd.Tok != token.VAR {
+				continue
+			}
+			for _, spec := range gd.Specs {
+				vs := spec.(*ast.ValueSpec)
+				doc := vs.Doc
+				if !gd.Lparen.IsValid() {
+					doc = gd.Doc
+				}
+				patterns := embedPatterns(doc)
+				if len(patterns) == 0 || len(vs.Names) != 1 {
+					continue
+				}
+				if m == nil {
+					m = map[string]*godoc.Embed{}
+				}
+				m[vs.Names[0].Name] = &godoc.Embed{
+					Patterns: patterns,
+					Files:    matchEmbedPatterns(patterns, dirFiles),
+				}
+			}
+		}
+	}
+	return m
+}
+
+// packageDirFiles returns the paths of the files among moduleFiles that are
+// in the directory innerPath or its subdirectories, relative to innerPath.
+// The paths of moduleFiles are relative to the module root.
+func packageDirFiles(moduleFiles []string, innerPath string) []string {
+	if innerPath == "." {
+		return moduleFiles
+	}
+	var files []string
+	for _, f := range moduleFiles {
+		if strings.HasPrefix(f, innerPath+"/") {
+			files = append(files, f[len(innerPath)+1:])
+		}
+	}
+	return files
+}
+
+// embedPatterns returns the patterns of the //go:embed directives in doc.
+func embedPatterns(doc *ast.CommentGroup) []string {
+	if doc == nil {
+		return nil
+	}
+	var patterns []string
+	for _, c := range doc.List {
+		args := strings.TrimPrefix(c.Text, embedDirective)
+		if args == c.Text || (args != "" && args[0] != ' ' && args[0] != '\t') {
+			continue
+		}
+		ps, ok := parseEmbedArgs(args)
+		if !ok {
+			return nil
+		}
+		patterns = append(patterns, ps...)
+	}
+	return patterns
+}
+
+// parseEmbedArgs splits the arguments of a //go:embed directive into
+// patterns. Like the go command, it accepts patterns in Go string literal
+// syntax, to allow spaces in them. It reports whether args are well formed.
+func parseEmbedArgs(args string) (_ []string, ok bool) {
+	var patterns []string
+	for {
+		args = strings.TrimLeft(args, " \t")
+		if args == "" {
+			return patterns, true
+		}
+		var (
+			p   string
+			end int
+		)
+		switch args[0] {
+		case '`':
+			end = strings.IndexByte(args[1:], '`') + 2
+			if end < 2 {
+				return nil, false
+			}
+			p = args[1 : end-1]
+		case '"':
+			end = 1
+			for end < len(args) && args[end] != '"' {
+				if args[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(args) {
+				return nil, false
+			}
+			end++
+			var err error
+			p, err = strconv.Unquote(args[:end])
+			if err != nil {
+				return nil, false
+			}
+		default:
+			end = strings.IndexAny(args, " \t")
+			if end < 0 {
+				end = len(args)
+			}
+			p = args[:end]
+		}
+		if end < len(args) && args[end] != ' ' && args[end] != '\t' {
+			return nil, false
+		}
+		patterns = append(patterns, p)
+		args = args[end:]
+	}
+}
+
+// matchEmbedPatterns returns the files among dirFiles that patterns embed,
+// sorted. As with the go command, a pattern that matches a directory embeds
+// the files in it and its subdirectories, except for those whose names
+// begin with '.' or '_', unless the pattern has the "all:" prefix.
+func matchEmbedPatterns(patterns, dirFiles []string) []string {
+	seen := map[string]bool{}
+	var files []string
+	for _, pattern := range patterns {
+		all := strings.HasPrefix(pattern, "all:")
+		pattern = strings.TrimPrefix(pattern, "all:")
+		for _, file := range dirFiles {
+			if !seen[file] && embedsFile(pattern, file, all) {
+				seen[file] = true
+				files = append(files, file)
+			}
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+// embedsFile reports whether pattern embeds file, either by naming it or by
+// naming one of the directories that contain it.
+func embedsFile(pattern, file string, all bool) bool {
+	for dir := file; ; {
+		if ok, _ := path.Match(pattern, dir); ok {
+			if dir == file || all {
+				return true
+			}
+			for _, elem := range strings.Split(strings.TrimPrefix(file, dir+"/"), "/") {
+				if strings.HasPrefix(elem, ".") || strings.HasPrefix(elem, "_") {
+					return false
+				}
+			}
+			return true
+		}
+		i := strings.LastIndexByte(dir, '/')
+		if i < 0 {
+			return false
+		}
+		dir = dir[:i]
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fetch
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal/godoc"
+)
+
+func TestEmbeds(t *testing.T) {
+	const src = `package p
+
+import "embed"
+
+//go:embed hello.txt
+var Hello string
+
+var (
+	// Static holds the static files.
+	//go:embed static
+	//go:embed "with space.txt"
+	Static embed.FS
+
+	//go:embed all:static
+	All embed.FS
+
+	NotEmbedded []byte
+)
+
+//go:embed hello.txt
+var A, B string
+
+//go:embed "unterminated
+var Bad string
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dirFiles := []string{
+		"hello.txt",
+		"p.go",
+		"with space.txt",
+		"static/a.css",
+		"static/.hidden",
+		"static/_draft/b.css",
+		"static/img/c.png",
+	}
+	got := embeds(map[string]*ast.File{"p.go": f}, dirFiles)
+	want := map[string]*godoc.Embed{
+		"Hello": {
+			Patterns: []string{"hello.txt"},
+			Files:    []string{"hello.txt"},
+		},
+		"Static": {
+			Patterns: []string{"static", "with space.txt"},
+			Files:    []string{"static/a.css", "static/img/c.png", "with space.txt"},
+		},
+		"All": {
+			Patterns: []string{"all:static"},
+			Files:    []string{"static/.hidden", "static/_draft/b.css", "static/a.css", "static/img/c.png"},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestParseEmbedArgs(t *testing.T) {
+	for _, test := range []struct {
+		args string
+		want []string
+		ok   bool
+	}{
+		{" a.txt b/*.css", []string{"a.txt", "b/*.css"}, true},
+		{"\t`a b.txt`  \"c\\td.txt\"", []string{"a b.txt", "c\td.txt"}, true},
+		{"", nil, true},
+		{` "a.txt`, nil, false},
+		{" `a.txt", nil, false},
+		{` "a.txt"b`, nil, false},
+	} {
+		got, ok := parseEmbedArgs(test.args)
+		if ok != test.ok {
+			t.Errorf("parseEmbedArgs(%q): ok = %t, want %t", test.args, ok, test.ok)
+			continue
+		}
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("parseEmbedArgs(%q) mismatch (-want +got):\n%s", test.args, diff)
+		}
+	}
+}
+
+func TestPackageDirFiles(t *testing.T) {
+	moduleFiles := []string{"go.mod", "a/a.go", "a/static/x.css", "ab/b.go"}
+	if diff := cmp.Diff([]string{"a.go", "static/x.css"}, packageDirFiles(moduleFiles, "a")); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(moduleFiles, packageDirFiles(moduleFiles, ".")); diff != "" {
+		t.Errorf("root mismatch (-want +got):\n%s", diff)
+	}
+}
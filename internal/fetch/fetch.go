@@ -26,6 +26,8 @@ import (
 	"golang.org/x/pkgsite/internal"
 	"golang.org/x/pkgsite/internal/dcensus"
 	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/experiment"
+	"golang.org/x/pkgsite/internal/godoc"
 	"golang.org/x/pkgsite/internal/licenses"
 	"golang.org/x/pkgsite/internal/log"
 	"golang.org/x/pkgsite/internal/proxy"
@@ -98,6 +100,25 @@ type FetchResult struct {
 	Defer                func() // caller must defer this on all code paths
 	Module               *internal.Module
 	PackageVersionStates []*internal.PackageVersionState
+	// ChecksumStatus records the result of verifying the module's content
+	// against the checksum database.
+	ChecksumStatus ChecksumStatus
+	// Provenance records where and when the module zip was downloaded. It
+	// is nil for the standard library, and if the zip was not downloaded.
+	Provenance *internal.Provenance
+}
+
+// ProcessingOptions control how the contents of a module are processed.
+// The zero value processes modules as the worker does.
+type ProcessingOptions struct {
+	// DocFormats lists the formats, besides HTML, in which package
+	// documentation is rendered. The results are in the Text and Markdown
+	// fields of the units' Documentation.
+	DocFormats []godoc.DocFormat
+
+	// Downloaded, if non-nil, is called after the module zip has been
+	// downloaded, before its contents are processed.
+	Downloaded func()
 }
 
 // FetchModule queries the proxy or the Go repo for the requested module
@@ -110,6 +131,12 @@ type FetchResult struct {
 //   defer fr.Defer()
 // immediately after the call.
 func FetchModule(ctx context.Context, modulePath, requestedVersion string, proxyClient *proxy.Client, sourceClient *source.Client) (fr *FetchResult) {
+	return FetchModuleWithOptions(ctx, modulePath, requestedVersion, proxyClient, sourceClient, ProcessingOptions{})
+}
+
+// FetchModuleWithOptions is like FetchModule, but processes the module
+// according to opts.
+func FetchModuleWithOptions(ctx context.Context, modulePath, requestedVersion string, proxyClient *proxy.Client, sourceClient *source.Client, opts ProcessingOptions) (fr *FetchResult) {
 	start := time.Now()
 	fr = &FetchResult{
 		ModulePath:       modulePath,
@@ -140,6 +167,7 @@ func FetchModule(ctx context.Context, modulePath, requestedVersion string, proxy
 		commitTime time.Time
 		zipReader  *zip.Reader
 		zipSize    int64
+		goModBytes []byte
 		err        error
 	)
 	// Get the just information we need to make a load-shedding decision.
@@ -202,7 +230,7 @@ func FetchModule(ctx context.Context, modulePath, requestedVersion string, proxy
 		}
 		fr.GoModPath = stdlib.ModulePath
 	} else {
-		goModBytes, err := proxyClient.GetMod(ctx, modulePath, fr.ResolvedVersion)
+		goModBytes, err = proxyClient.GetMod(ctx, modulePath, fr.ResolvedVersion)
 		if err != nil {
 			fr.Error = err
 			return fr
@@ -220,13 +248,42 @@ func FetchModule(ctx context.Context, modulePath, requestedVersion string, proxy
 			fr.Error = fmt.Errorf("module path=%s, go.mod path=%s: %w", modulePath, goModPath, derrors.AlternativeModule)
 			return fr
 		}
-		zipReader, err = proxyClient.GetZip(ctx, modulePath, fr.ResolvedVersion)
+		z, err := proxyClient.GetZipStream(ctx, modulePath, fr.ResolvedVersion, maxModuleZipSize)
 		if err != nil {
 			fr.Error = err
 			return fr
 		}
+		defer func() {
+			if err := z.Close(); err != nil {
+				log.Errorf(ctx, "closing zip for %s@%s: %v", modulePath, fr.ResolvedVersion, err)
+			}
+		}()
+		zipReader = z.Reader
+		fr.Provenance = &internal.Provenance{
+			ModulePath:       modulePath,
+			Version:          fr.ResolvedVersion,
+			ProxyURL:         z.URL,
+			ResponseHeader:   z.Header,
+			ZipSHA256:        z.SHA256,
+			ZipSize:          z.Size,
+			DownloadedAt:     z.DownloadedAt,
+			DownloadDuration: z.DownloadDuration,
+		}
+		if checksumDB != nil {
+			fr.ChecksumStatus, err = checksumDB.Verify(ctx, modulePath, fr.ResolvedVersion, goModBytes, zipReader)
+			if err != nil {
+				log.Warningf(ctx, "could not verify %s@%s: %v", modulePath, fr.ResolvedVersion, err)
+			}
+			if fr.ChecksumStatus == ChecksumMismatch && enforceChecksums {
+				fr.Error = fmt.Errorf("content does not match checksum database: %w", derrors.BadModule)
+				return fr
+			}
+		}
 	}
-	mod, pvs, err := processZipFile(ctx, modulePath, fr.ResolvedVersion, commitTime, zipReader, sourceClient)
+	if opts.Downloaded != nil {
+		opts.Downloaded()
+	}
+	mod, pvs, err := processZipFile(ctx, modulePath, fr.ResolvedVersion, commitTime, zipReader, sourceClient, opts)
 	if err != nil {
 		fr.Error = err
 		return fr
@@ -235,6 +292,10 @@ func FetchModule(ctx context.Context, modulePath, requestedVersion string, proxy
 	fr.PackageVersionStates = pvs
 	if modulePath == stdlib.ModulePath {
 		fr.Module.HasGoMod = true
+	} else {
+		fr.Module.Deprecation = extractDeprecation(modulePath, goModBytes)
+		fr.Module.Retractions = extractRetractions(goModBytes)
+		fr.Module.Requirements = extractRequirements(goModBytes)
 	}
 	for _, state := range fr.PackageVersionStates {
 		if state.Status != http.StatusOK {
@@ -245,7 +306,7 @@ func FetchModule(ctx context.Context, modulePath, requestedVersion string, proxy
 }
 
 // processZipFile extracts information from the module version zip.
-func processZipFile(ctx context.Context, modulePath string, resolvedVersion string, commitTime time.Time, zipReader *zip.Reader, sourceClient *source.Client) (_ *internal.Module, _ []*internal.PackageVersionState, err error) {
+func processZipFile(ctx context.Context, modulePath string, resolvedVersion string, commitTime time.Time, zipReader *zip.Reader, sourceClient *source.Client, opts ProcessingOptions) (_ *internal.Module, _ []*internal.PackageVersionState, err error) {
 	defer derrors.Wrap(&err, "processZipFile(%q, %q)", modulePath, resolvedVersion)
 
 	ctx, span := trace.StartSpan(ctx, "fetch.processZipFile")
@@ -264,13 +325,35 @@ func processZipFile(ctx context.Context, modulePath string, resolvedVersion stri
 	}
 	d := licenses.NewDetector(modulePath, resolvedVersion, zipReader, logf)
 	allLicenses := d.AllLicenses()
-	packages, packageVersionStates, err := extractPackagesFromZip(ctx, modulePath, resolvedVersion, zipReader, d, sourceInfo)
+	packages, packageVersionStates, err := extractPackagesFromZip(ctx, modulePath, resolvedVersion, zipReader, d, sourceInfo, opts)
 	if errors.Is(err, errModuleContainsNoPackages) || errors.Is(err, errMalformedZip) {
 		return nil, nil, fmt.Errorf("%v: %w", err.Error(), derrors.BadModule)
 	}
 	if err != nil {
 		return nil, nil, fmt.Errorf("extractPackagesFromZip(%q, %q, zipReader, %v): %v", modulePath, resolvedVersion, allLicenses, err)
 	}
+	metadata, err := extractModuleMetadata(modulePath, resolvedVersion, zipReader)
+	if err != nil {
+		// Metadata is optional, so don't fail the fetch because of it.
+		log.Warningf(ctx, "ignoring module metadata: %v", err)
+	}
+	if experiment.IsActive(ctx, internal.ExperimentDocSiteLinks) {
+		if site := detectDocumentationSite(modulePath, resolvedVersion, zipReader, readmes, sourceInfo); site != nil {
+			if metadata == nil {
+				metadata = &internal.ModuleMetadata{}
+			}
+			metadata.Documentation = site
+		}
+	}
+	inferSynopses(modulePath, packages, readmes, metadata)
+	if experiment.IsActive(ctx, internal.ExperimentModuleOverview) {
+		if o := moduleOverview(modulePath, packages, allLicenses); o != nil {
+			if metadata == nil {
+				metadata = &internal.ModuleMetadata{}
+			}
+			metadata.Overview = o
+		}
+	}
 	hasGoMod := zipContainsFilename(zipReader, path.Join(moduleVersionDir(modulePath, resolvedVersion), "go.mod"))
 
 	var legacyPackages []*internal.LegacyPackage
@@ -279,6 +362,8 @@ func processZipFile(ctx context.Context, modulePath string, resolvedVersion stri
 			Path:              p.path,
 			Name:              p.name,
 			Synopsis:          p.synopsis,
+			SynopsisInferred:  p.synopsisInferred,
+			Kind:              p.kind,
 			Imports:           p.imports,
 			DocumentationHTML: p.documentationHTML,
 			GOOS:              p.goos,
@@ -296,6 +381,7 @@ func processZipFile(ctx context.Context, modulePath string, resolvedVersion stri
 			IsRedistributable: d.ModuleIsRedistributable(),
 			HasGoMod:          hasGoMod,
 			SourceInfo:        sourceInfo,
+			Metadata:          metadata,
 		},
 		LegacyPackages: legacyPackages,
 		Licenses:       allLicenses,
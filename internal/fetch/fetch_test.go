@@ -7,9 +7,9 @@ package fetch
 import (
 	"context"
 	"errors"
-	"io"
+	"fmt"
 	"net/http"
-	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -17,11 +17,13 @@ import (
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"golang.org/x/pkgsite/internal"
 	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/experiment"
 	"golang.org/x/pkgsite/internal/godoc"
 	"golang.org/x/pkgsite/internal/proxy"
 	"golang.org/x/pkgsite/internal/source"
 	"golang.org/x/pkgsite/internal/stdlib"
 	"golang.org/x/pkgsite/internal/testing/sample"
+	"golang.org/x/pkgsite/internal/testing/testhelper"
 )
 
 var (
@@ -32,15 +34,6 @@ var (
 func TestFetchModule(t *testing.T) {
 	stdlib.UseTestData = true
 
-	// Stub out the function used to share playground snippets
-	origPost := httpPost
-	httpPost = func(url string, contentType string, body io.Reader) (resp *http.Response, err error) {
-		w := httptest.NewRecorder()
-		w.WriteHeader(http.StatusOK)
-		return w.Result(), nil
-	}
-	defer func() { httpPost = origPost }()
-
 	defer func(oldmax int) { godoc.MaxDocumentationHTML = oldmax }(godoc.MaxDocumentationHTML)
 	godoc.MaxDocumentationHTML = 1 * megabyte
 
@@ -99,9 +92,9 @@ func TestFetchModule(t *testing.T) {
 			sortFetchResult(got)
 			opts := []cmp.Option{
 				cmpopts.IgnoreFields(internal.LegacyPackage{}, "DocumentationHTML"),
-				cmpopts.IgnoreFields(internal.Documentation{}, "HTML"),
+				cmpopts.IgnoreFields(internal.Documentation{}, "HTML", "DocHash", "HasTests"),
 				cmpopts.IgnoreFields(internal.PackageVersionState{}, "Error"),
-				cmpopts.IgnoreFields(FetchResult{}, "Defer"),
+				cmpopts.IgnoreFields(FetchResult{}, "Defer", "Provenance"),
 				cmp.AllowUnexported(source.Info{}),
 				cmpopts.EquateEmpty(),
 			}
@@ -109,6 +102,9 @@ func TestFetchModule(t *testing.T) {
 			if diff := cmp.Diff(fr, got, opts...); diff != "" {
 				t.Fatalf("mismatch (-want +got):\n%s", diff)
 			}
+			if p := got.Provenance; modulePath != stdlib.ModulePath && (p == nil || p.ZipSHA256 == "") {
+				t.Errorf("got provenance %+v, want a record of the zip download", p)
+			}
 			validateDocumentationHTML(t, got.Module, fr.Module)
 		})
 	}
@@ -151,3 +147,99 @@ func TestFetchModule_Errors(t *testing.T) {
 		})
 	}
 }
+
+func TestFetchModuleWithOptions(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	const modulePath = "formats.test"
+	proxyClient, teardownProxy := proxy.SetupTestClient(t, []*proxy.Module{{
+		ModulePath: modulePath,
+		Files: map[string]string{
+			"LICENSE": testhelper.BSD0License,
+			"p/p.go": `
+				// Package p is a package.
+				package p
+
+				// F is a function.
+				func F() {}`,
+		},
+	}})
+	defer teardownProxy()
+
+	opts := ProcessingOptions{DocFormats: []godoc.DocFormat{godoc.DocFormatText, godoc.DocFormatMarkdown}}
+	got := FetchModuleWithOptions(ctx, modulePath, "v1.0.0", proxyClient, source.NewClient(sourceTimeout), opts)
+	defer got.Defer()
+	if got.Error != nil {
+		t.Fatal(got.Error)
+	}
+	var doc *internal.Documentation
+	for _, u := range got.Module.Units {
+		if u.Path == modulePath+"/p" {
+			doc = u.Documentation
+		}
+	}
+	if doc == nil {
+		t.Fatal("no documentation for package p")
+	}
+	for _, want := range []string{"package p // import \"formats.test/p\"", "func F()\n    F is a function."} {
+		if !strings.Contains(doc.Text, want) {
+			t.Errorf("Text does not contain %q:\n%s", want, doc.Text)
+		}
+	}
+	for _, want := range []string{"# package p", "### func F\n\n```go\nfunc F()\n```\n\nF is a function."} {
+		if !strings.Contains(doc.Markdown, want) {
+			t.Errorf("Markdown does not contain %q:\n%s", want, doc.Markdown)
+		}
+	}
+}
+
+func TestFetchModuleSplitsLargeDoc(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	ctx = experiment.NewContext(ctx, internal.ExperimentSplitLargeDoc)
+
+	defer func(oldmax, oldpart int) {
+		godoc.MaxDocumentationHTML = oldmax
+		godoc.MaxDocumentationPartHTML = oldpart
+	}(godoc.MaxDocumentationHTML, godoc.MaxDocumentationPartHTML)
+	godoc.MaxDocumentationHTML = 20_000
+	godoc.MaxDocumentationPartHTML = 10_000
+
+	var src strings.Builder
+	src.WriteString("// Package big has many functions.\npackage big\n")
+	for i := 0; i < 50; i++ {
+		fmt.Fprintf(&src, "\n// F%02d does something.\n%sfunc F%02d() {}\n", i, strings.Repeat("// Really.\n", 20), i)
+	}
+	const modulePath = "split.test"
+	proxyClient, teardownProxy := proxy.SetupTestClient(t, []*proxy.Module{{
+		ModulePath: modulePath,
+		Files: map[string]string{
+			"LICENSE": testhelper.BSD0License,
+			"big.go":  src.String(),
+		},
+	}})
+	defer teardownProxy()
+
+	got := FetchModule(ctx, modulePath, "v1.0.0", proxyClient, source.NewClient(sourceTimeout))
+	defer got.Defer()
+	if got.Error != nil {
+		t.Fatal(got.Error)
+	}
+	if got.Status != http.StatusOK {
+		t.Errorf("got status %d, want 200", got.Status)
+	}
+	doc := got.Module.Units[0].Documentation
+	if len(doc.Parts) < 2 {
+		t.Fatalf("got %d more parts, want at least 2", len(doc.Parts))
+	}
+	if !strings.Contains(doc.HTML.String(), "Package big has many functions.") {
+		t.Error("first part does not contain the package comment")
+	}
+	if first := doc.Parts[0].Title; !strings.HasPrefix(first, "F00") {
+		t.Errorf("title of second part: got %q, want it to start with F00", first)
+	}
+	if last := doc.Parts[len(doc.Parts)-1].Title; !strings.HasSuffix(last, "F49") {
+		t.Errorf("title of last part: got %q, want it to end with F49", last)
+	}
+}
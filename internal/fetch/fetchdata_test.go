@@ -178,7 +178,7 @@ var moduleNoGoMod = &testModule{
 					},
 					Documentation: &internal.Documentation{
 						Synopsis: "Package p is inside a module where a go.mod file hasn't been explicitly added yet.",
-						HTML:     html("const Year = 2009"),
+						HTML:     html(`<span class="keyword">const</span> Year = <span class="number">2009</span>`),
 					},
 				},
 			},
@@ -239,7 +239,7 @@ var moduleBadPackages = &testModule{
 					},
 					Documentation: &internal.Documentation{
 						Synopsis: "Package good is inside a module that has bad packages.",
-						HTML:     html(`const Good = <a href="/builtin#true">true</a>`),
+						HTML:     html(`<span class="keyword">const</span> Good = <a href="/builtin#true">true</a>`),
 					},
 				},
 			},
@@ -302,7 +302,7 @@ var moduleBuildConstraints = &testModule{
 					},
 					Documentation: &internal.Documentation{
 						Synopsis: "Package cpu implements processor feature detection used by the Go standard library.",
-						HTML:     html("const CacheLinePadSize = 3"),
+						HTML:     html(`<span class="keyword">const</span> CacheLinePadSize = <span class="number">3</span>`),
 					},
 				},
 			},
@@ -459,7 +459,7 @@ var moduleBadImportPath = &testModule{
 						Name: "foo",
 						Path: "bad.import.path.com/good/import/path",
 					},
-					Documentation: &internal.Documentation{},
+					Documentation: &internal.Documentation{Kind: internal.PackageKindDocOnly},
 				},
 			},
 		},
@@ -519,6 +519,7 @@ var moduleDocTest = &testModule{
 					},
 					Documentation: &internal.Documentation{
 						Synopsis: "Package permalink is for testing the heading permalink documentation rendering feature.",
+						Kind:     internal.PackageKindDocOnly,
 						HTML:     html("<h3 id=\"hdr-This_is_a_heading\">This is a heading<a href=\"#hdr-This_is_a_heading\">¶</a></h3>"),
 					},
 				},
@@ -553,6 +554,7 @@ var moduleDocTooLarge = &testModule{
 					},
 					Documentation: &internal.Documentation{
 						Synopsis: "This documentation is big.",
+						Kind:     internal.PackageKindDocOnly,
 						HTML:     html(docTooLargeReplacement),
 					},
 				},
@@ -844,6 +846,10 @@ var moduleLatest = &testModule{
 // The substrings are separated by a '~' character.
 func moduleWithExamples(path, source, test string, docSubstrings ...string) *testModule {
 	docHTML := html(strings.Join(docSubstrings, " ~ "))
+	kind := internal.PackageKindDefault
+	if strings.TrimSpace(source) == "" {
+		kind = internal.PackageKindExampleOnly
+	}
 	return &testModule{
 		mod: &proxy.Module{
 			ModulePath: path,
@@ -878,6 +884,7 @@ package example_test
 						},
 						Documentation: &internal.Documentation{
 							Synopsis: "Package example contains examples.",
+							Kind:     kind,
 							HTML:     docHTML,
 						},
 					},
@@ -83,6 +83,7 @@ func cleanFetchResult(fr *FetchResult, detector *licenses.Detector) *FetchResult
 				Imports:           u.Imports,
 				GOOS:              u.Documentation.GOOS,
 				GOARCH:            u.Documentation.GOARCH,
+				Kind:              u.Documentation.Kind,
 				IsRedistributable: u.IsRedistributable,
 			})
 			if shouldSetPVS {
@@ -0,0 +1,80 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fetch
+
+import (
+	"go/ast"
+	"go/token"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/pkgsite/internal"
+)
+
+// packageKind classifies a package by the contents of its files, which map
+// file names to ASTs and include test files. It must be called before the
+// files are given to godoc, which modifies them.
+func packageKind(files map[string]*ast.File) internal.PackageKind {
+	hasExamples := false
+	for name, f := range files {
+		if strings.HasSuffix(name, "_test.go") {
+			hasExamples = hasExamples || containsExample(f)
+			continue
+		}
+		for _, d := range f.Decls {
+			if gd, ok := d.(*ast.GenDecl); ok && gd.Tok == token.IMPORT {
+				// Imports alone, perhaps for side effects, declare nothing.
+				continue
+			}
+			return internal.PackageKindDefault
+		}
+	}
+	if hasExamples {
+		return internal.PackageKindExampleOnly
+	}
+	return internal.PackageKindDocOnly
+}
+
+// hasTestFiles reports whether files, which map file names to ASTs, include
+// test files.
+func hasTestFiles(files map[string]*ast.File) bool {
+	for name := range files {
+		if strings.HasSuffix(name, "_test.go") {
+			return true
+		}
+	}
+	return false
+}
+
+// containsExample reports whether f declares an example function, following
+// the naming rules of go test.
+func containsExample(f *ast.File) bool {
+	for _, d := range f.Decls {
+		fd, ok := d.(*ast.FuncDecl)
+		if !ok || fd.Recv != nil || fd.Type.Params.NumFields() != 0 || fd.Type.Results.NumFields() != 0 {
+			continue
+		}
+		if isExampleName(fd.Name.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// isExampleName reports whether name is the name of an example function:
+// "Example", or "Example" followed by a suffix that doesn't start with a
+// lower-case letter.
+func isExampleName(name string) bool {
+	if !strings.HasPrefix(name, "Example") {
+		return false
+	}
+	rest := name[len("Example"):]
+	if rest == "" {
+		return true
+	}
+	r, _ := utf8.DecodeRuneInString(rest)
+	return !unicode.IsLower(r)
+}
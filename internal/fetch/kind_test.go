@@ -0,0 +1,102 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fetch
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"golang.org/x/pkgsite/internal"
+)
+
+func TestPackageKind(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		files map[string]string
+		want  internal.PackageKind
+	}{
+		{
+			name: "declarations",
+			files: map[string]string{
+				"doc.go": "// Package p does things.\npackage p",
+				"p.go":   "package p\n\nfunc F() {}",
+			},
+			want: internal.PackageKindDefault,
+		},
+		{
+			name: "unexported declarations",
+			files: map[string]string{
+				"p.go": "package p\n\nvar x int",
+			},
+			want: internal.PackageKindDefault,
+		},
+		{
+			name: "doc.go only",
+			files: map[string]string{
+				"doc.go": "// Package p is documented here.\npackage p",
+			},
+			want: internal.PackageKindDocOnly,
+		},
+		{
+			name: "imports only",
+			files: map[string]string{
+				"doc.go": "// Package p is documented here.\npackage p\n\nimport _ \"embed\"",
+			},
+			want: internal.PackageKindDocOnly,
+		},
+		{
+			name: "tests but no examples",
+			files: map[string]string{
+				"doc.go":    "package p",
+				"p_test.go": "package p\n\nimport \"testing\"\n\nfunc TestP(t *testing.T) {}\nfunc Examples() {}",
+			},
+			want: internal.PackageKindDocOnly,
+		},
+		{
+			name: "examples",
+			files: map[string]string{
+				"doc.go":          "// Package p has examples.\npackage p",
+				"example_test.go": "package p_test\n\nfunc Example_usage() {}",
+			},
+			want: internal.PackageKindExampleOnly,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			fset := token.NewFileSet()
+			files := map[string]*ast.File{}
+			for name, src := range test.files {
+				f, err := parser.ParseFile(fset, name, src, parser.ParseComments)
+				if err != nil {
+					t.Fatal(err)
+				}
+				files[name] = f
+			}
+			if got := packageKind(files); got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestHasTestFiles(t *testing.T) {
+	for _, test := range []struct {
+		names []string
+		want  bool
+	}{
+		{[]string{"p.go"}, false},
+		{[]string{"p.go", "p_test.go"}, true},
+		{[]string{"test.go"}, false},
+	} {
+		files := map[string]*ast.File{}
+		for _, name := range test.names {
+			files[name] = nil
+		}
+		if got := hasTestFiles(files); got != test.want {
+			t.Errorf("hasTestFiles(%v) = %t, want %t", test.names, got, test.want)
+		}
+	}
+}
@@ -18,10 +18,10 @@ import (
 	"io"
 	"io/ioutil"
 	"math"
-	"net/http"
 	"os"
 	"path"
 	"runtime"
+	"sort"
 	"strings"
 
 	"go.opencensus.io/trace"
@@ -62,25 +62,25 @@ var goEnvs = []struct{ GOOS, GOARCH string }{
 //
 // If the package is fine except that its documentation is too large, loadPackage
 // returns both a package and a non-nil error with godoc.ErrTooLarge in its chain.
-func loadPackage(ctx context.Context, zipGoFiles []*zip.File, innerPath string, sourceInfo *source.Info, modInfo *godoc.ModuleInfo) (_ *goPackage, err error) {
-	defer derrors.Wrap(&err, "loadPackage(ctx, zipGoFiles, %q, sourceInfo, modInfo)", innerPath)
+func loadPackage(ctx context.Context, zipGoFiles []*zip.File, moduleFiles []string, innerPath string, sourceInfo *source.Info, modInfo *godoc.ModuleInfo, opts ProcessingOptions) (_ *goPackage, err error) {
+	defer derrors.Wrap(&err, "loadPackage(ctx, zipGoFiles, moduleFiles, %q, sourceInfo, modInfo)", innerPath)
 	ctx, span := trace.StartSpan(ctx, "fetch.loadPackage")
 	defer span.End()
 	for _, env := range goEnvs {
-		pkg, err := loadPackageWithBuildContext(ctx, env.GOOS, env.GOARCH, zipGoFiles, innerPath, sourceInfo, modInfo)
+		pkg, err := loadPackageWithBuildContext(ctx, env.GOOS, env.GOARCH, zipGoFiles, moduleFiles, innerPath, sourceInfo, modInfo, opts)
 		if err != nil && !errors.Is(err, godoc.ErrTooLarge) && !errors.Is(err, derrors.NotFound) {
 			return nil, err
 		}
 		if pkg != nil {
+			if experiment.IsActive(ctx, internal.ExperimentBuildContextDocs) {
+				pkg.buildContexts = buildContextDocs(ctx, zipGoFiles, innerPath, modInfo, pkg.goos, pkg.goarch)
+			}
 			return pkg, err
 		}
 	}
 	return nil, nil
 }
 
-// httpPost allows package fetch tests to stub out playground URL fetches.
-var httpPost = http.Post
-
 const docTooLargeReplacement = `<p>Documentation is too large to display.</p>`
 
 // loadPackageWithBuildContext loads a Go package made of .go files in zipGoFiles
@@ -90,7 +90,8 @@ const docTooLargeReplacement = `<p>Documentation is too large to display.</p>`
 // relative to the module root.
 //
 // zipGoFiles must contain only .go files that have been verified
-// to be of reasonable size.
+// to be of reasonable size. moduleFiles holds the paths of all the files of
+// the module relative to its root, which the package may embed.
 //
 // The returned Package.Licenses field is not populated.
 //
@@ -98,27 +99,36 @@ const docTooLargeReplacement = `<p>Documentation is too large to display.</p>`
 // or all .go files have been excluded by constraints.
 // A *BadPackageError error is returned if the directory
 // contains .go files but do not make up a valid package.
-func loadPackageWithBuildContext(ctx context.Context, goos, goarch string, zipGoFiles []*zip.File, innerPath string, sourceInfo *source.Info, modInfo *godoc.ModuleInfo) (_ *goPackage, err error) {
+func loadPackageWithBuildContext(ctx context.Context, goos, goarch string, zipGoFiles []*zip.File, moduleFiles []string, innerPath string, sourceInfo *source.Info, modInfo *godoc.ModuleInfo, opts ProcessingOptions) (_ *goPackage, err error) {
 	modulePath := modInfo.ModulePath
 	defer derrors.Wrap(&err, "loadPackageWithBuildContext(%q, %q, zipGoFiles, %q, %q, %+v)",
 		goos, goarch, innerPath, modulePath, sourceInfo)
 
-	packageName, goFiles, fset, err := loadFilesWithBuildContext(innerPath, goos, goarch, zipGoFiles)
+	packageName, goFiles, excluded, fset, err := loadFilesWithBuildContext(innerPath, goos, goarch, zipGoFiles)
 	if err != nil {
 		return nil, err
 	}
-	docPkg := godoc.NewPackage(fset, goos, goarch, modInfo.ModulePackages)
-	for _, pf := range goFiles {
-		var removeNodes bool
-		if experiment.IsActive(ctx, internal.ExperimentRemoveUnusedAST) {
-			removeNodes = true
-			// Don't strip the seemingly unexported functions from the builtin package;
-			// they are actually Go builtins like make, new, etc.
-			if modulePath == stdlib.ModulePath && innerPath == "builtin" {
-				removeNodes = false
-			}
-		}
-		docPkg.AddFile(pf, removeNodes)
+	kind := packageKind(goFiles)
+	docHash := packageCommentHash(goFiles)
+	hasTests := hasTestFiles(goFiles)
+	var symbols []string
+	if experiment.IsActive(ctx, internal.ExperimentSymbolHistory) && packageName != "main" {
+		symbols = exportedSymbols(goFiles)
+	}
+	var symbolDecls []*internal.SymbolDecl
+	if experiment.IsActive(ctx, internal.ExperimentAPIDiff) && packageName != "main" {
+		symbolDecls = exportedSymbolDecls(fset, goFiles)
+	}
+	// Find the embedded files before building the documentation, which may
+	// remove comments from the AST.
+	var embedded map[string]*godoc.Embed
+	if experiment.IsActive(ctx, internal.ExperimentEmbedDocs) {
+		embedded = embeds(goFiles, packageDirFiles(moduleFiles, innerPath))
+	}
+	docPkg := newDocPackage(ctx, fset, goos, goarch, goFiles, innerPath, modInfo)
+	docPkg.Embeds = embedded
+	if experiment.IsActive(ctx, internal.ExperimentBuildConstraints) {
+		docPkg.ExcludedFiles = excluded
 	}
 
 	// Encode before rendering: both operations mess with the AST, but Encode restores
@@ -131,7 +141,7 @@ func loadPackageWithBuildContext(ctx context.Context, goos, goarch string, zipGo
 		}
 	}
 
-	synopsis, imports, docHTML, err := docPkg.Render(ctx, innerPath, sourceInfo, modInfo, goos, goarch)
+	synopsis, imports, docHTML, docParts, docOther, err := docPkg.RenderFormats(ctx, innerPath, sourceInfo, modInfo, goos, goarch, opts.DocFormats)
 	if err != nil && !errors.Is(err, godoc.ErrTooLarge) {
 		return nil, err
 	}
@@ -139,29 +149,65 @@ func loadPackageWithBuildContext(ctx context.Context, goos, goarch string, zipGo
 	if modulePath == stdlib.ModulePath {
 		importPath = innerPath
 	}
+	if sizes := docPkg.SizeBreakdown(); sizes != nil {
+		recordDocSize(&DocSize{
+			PackagePath:   importPath,
+			Version:       modInfo.ResolvedVersion,
+			GOOS:          goos,
+			GOARCH:        goarch,
+			SizeBreakdown: *sizes,
+		})
+	}
 	v1path := internal.V1Path(importPath, modulePath)
 	return &goPackage{
-		path:              importPath,
-		name:              packageName,
-		synopsis:          synopsis,
-		v1path:            v1path,
-		imports:           imports,
-		documentationHTML: docHTML,
-		goos:              goos,
-		goarch:            goarch,
-		source:            src,
+		path:                  importPath,
+		name:                  packageName,
+		synopsis:              synopsis,
+		kind:                  kind,
+		v1path:                v1path,
+		imports:               imports,
+		documentationHTML:     docHTML,
+		documentationParts:    docParts,
+		documentationText:     docOther[godoc.DocFormatText],
+		documentationMarkdown: docOther[godoc.DocFormatMarkdown],
+		goos:                  goos,
+		goarch:                goarch,
+		source:                src,
+		symbols:               symbols,
+		symbolDecls:           symbolDecls,
+		docHash:               docHash,
+		hasTests:              hasTests,
 	}, err
 }
 
+// newDocPackage returns a godoc.Package made of goFiles, the files of the
+// package at innerPath in the module described by modInfo.
+func newDocPackage(ctx context.Context, fset *token.FileSet, goos, goarch string, goFiles map[string]*ast.File, innerPath string, modInfo *godoc.ModuleInfo) *godoc.Package {
+	docPkg := godoc.NewPackage(fset, goos, goarch, modInfo.ModulePackages)
+	for _, pf := range goFiles {
+		var removeNodes bool
+		if experiment.IsActive(ctx, internal.ExperimentRemoveUnusedAST) {
+			removeNodes = true
+			// Don't strip the seemingly unexported functions from the builtin package;
+			// they are actually Go builtins like make, new, etc.
+			if modInfo.ModulePath == stdlib.ModulePath && innerPath == "builtin" {
+				removeNodes = false
+			}
+		}
+		docPkg.AddFile(pf, removeNodes)
+	}
+	return docPkg
+}
+
 // loadFilesWithBuildContext loads all the Go files at innerPath that match goos
 // and goarch in the zip. It returns the package name as it occurs in the
-// source, a map of the ASTs of all the Go files, and the token.FileSet used for
-// parsing.
-func loadFilesWithBuildContext(innerPath, goos, goarch string, zipGoFiles []*zip.File) (pkgName string, fileMap map[string]*ast.File, _ *token.FileSet, _ error) {
+// source, a map of the ASTs of all the Go files, the non-test files that
+// don't match, and the token.FileSet used for parsing.
+func loadFilesWithBuildContext(innerPath, goos, goarch string, zipGoFiles []*zip.File) (pkgName string, fileMap map[string]*ast.File, excluded []*godoc.ExcludedFile, _ *token.FileSet, _ error) {
 	// Apply build constraints to get a map from matching file names to their contents.
-	files, err := matchingFiles(goos, goarch, zipGoFiles)
+	files, excluded, err := matchingFiles(goos, goarch, zipGoFiles)
 	if err != nil {
-		return "", nil, nil, err
+		return "", nil, nil, nil, err
 	}
 	// Parse .go files and add them to the goFiles slice.
 	var (
@@ -175,9 +221,9 @@ func loadFilesWithBuildContext(innerPath, goos, goarch string, zipGoFiles []*zip
 		pf, err := parser.ParseFile(fset, name, b, parser.ParseComments)
 		if err != nil {
 			if pf == nil {
-				return "", nil, nil, fmt.Errorf("internal error: the source couldn't be read: %v", err)
+				return "", nil, nil, nil, fmt.Errorf("internal error: the source couldn't be read: %v", err)
 			}
-			return "", nil, nil, &BadPackageError{Err: err}
+			return "", nil, nil, nil, &BadPackageError{Err: err}
 		}
 		// Remember all files, including test files for their examples.
 		goFiles[name] = pf
@@ -192,7 +238,7 @@ func loadFilesWithBuildContext(innerPath, goos, goarch string, zipGoFiles []*zip
 			packageName = pf.Name.Name
 			packageNameFile = name
 		} else if pf.Name.Name != packageName {
-			return "", nil, nil, &BadPackageError{Err: &build.MultiplePackageError{
+			return "", nil, nil, nil, &BadPackageError{Err: &build.MultiplePackageError{
 				Dir:      innerPath,
 				Packages: []string{packageName, pf.Name.Name},
 				Files:    []string{packageNameFile, name},
@@ -202,14 +248,15 @@ func loadFilesWithBuildContext(innerPath, goos, goarch string, zipGoFiles []*zip
 	if numNonTestFiles == 0 {
 		// This directory doesn't contain a package, or at least not one
 		// that matches this build context.
-		return "", nil, nil, derrors.NotFound
+		return "", nil, nil, nil, derrors.NotFound
 	}
-	return packageName, goFiles, fset, nil
+	return packageName, goFiles, excluded, fset, nil
 }
 
 // matchingFiles returns a map from file names to their contents, read from zipGoFiles.
 // It includes only those files that match the build context determined by goos and goarch.
-func matchingFiles(goos, goarch string, zipGoFiles []*zip.File) (files map[string][]byte, err error) {
+// It also returns the non-test files that don't match, sorted by name.
+func matchingFiles(goos, goarch string, zipGoFiles []*zip.File) (files map[string][]byte, excluded []*godoc.ExcludedFile, err error) {
 	defer derrors.Wrap(&err, "matchingFiles(%q, %q, zipGoFiles)", goos, goarch)
 	// Populate the map with all the zip files.
 	files = make(map[string][]byte)
@@ -217,7 +264,7 @@ func matchingFiles(goos, goarch string, zipGoFiles []*zip.File) (files map[strin
 		_, name := path.Split(f.Name)
 		b, err := readZipFile(f, MaxFileSize)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		files[name] = b
 	}
@@ -250,14 +297,38 @@ func matchingFiles(goos, goarch string, zipGoFiles []*zip.File) (files map[strin
 	for name := range files {
 		match, err := bctx.MatchFile(".", name) // This will access the file we just added to files map above.
 		if err != nil {
-			return nil, &BadPackageError{Err: fmt.Errorf(`bctx.MatchFile(".", %q): %w`, name, err)}
+			return nil, nil, &BadPackageError{Err: fmt.Errorf(`bctx.MatchFile(".", %q): %w`, name, err)}
 		}
 		if !match {
 			// Excluded by build context.
+			if strings.HasSuffix(name, ".go") && !strings.HasSuffix(name, "_test.go") {
+				excluded = append(excluded, &godoc.ExcludedFile{
+					Name:        name,
+					Constraints: buildConstraintLines(files[name]),
+				})
+			}
 			delete(files, name)
 		}
 	}
-	return files, nil
+	sort.Slice(excluded, func(i, j int) bool { return excluded[i].Name < excluded[j].Name })
+	return files, excluded, nil
+}
+
+// buildConstraintLines returns the build constraint lines in the header of
+// the Go source src: the "//go:build" and "// +build" comments before the
+// package clause.
+func buildConstraintLines(src []byte) []string {
+	var lines []string
+	for _, line := range strings.Split(string(src), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "//") {
+			break
+		}
+		if strings.HasPrefix(line, "//go:build ") || strings.HasPrefix(line, "// +build ") {
+			lines = append(lines, line)
+		}
+	}
+	return lines
 }
 
 // readZipFile decompresses zip file f and returns its uncompressed contents.
@@ -10,6 +10,7 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal/godoc"
 	"golang.org/x/pkgsite/internal/testing/testhelper"
 )
 
@@ -35,11 +36,17 @@ func TestMatchingFiles(t *testing.T) {
 		"LICENSE.md": testhelper.MITLicense,
 		"js/js.go":   jsGoBody,
 	}
+
+	windowsContents := map[string]string{
+		"win/win_windows.go": plainGoBody,
+		"win/win_test.go":    jsGoBody,
+	}
 	for _, test := range []struct {
 		name         string
 		goos, goarch string
 		contents     map[string]string
 		want         map[string][]byte
+		wantExcluded []*godoc.ExcludedFile
 	}{
 		{
 			name:     "plain-linux",
@@ -65,6 +72,9 @@ func TestMatchingFiles(t *testing.T) {
 			goarch:   "amd64",
 			contents: jsContents,
 			want:     map[string][]byte{},
+			wantExcluded: []*godoc.ExcludedFile{
+				{Name: "js.go", Constraints: []string{"// +build js,wasm"}},
+			},
 		},
 		{
 			name:     "wasm-js",
@@ -75,6 +85,16 @@ func TestMatchingFiles(t *testing.T) {
 				"js.go": []byte(jsGoBody),
 			},
 		},
+		{
+			name:     "windows-linux",
+			goos:     "linux",
+			goarch:   "amd64",
+			contents: windowsContents,
+			want:     map[string][]byte{},
+			wantExcluded: []*godoc.ExcludedFile{
+				{Name: "win_windows.go"},
+			},
+		},
 	} {
 		t.Run(test.name, func(t *testing.T) {
 			data, err := testhelper.ZipContents(test.contents)
@@ -85,13 +105,16 @@ func TestMatchingFiles(t *testing.T) {
 			if err != nil {
 				t.Fatal(err)
 			}
-			got, err := matchingFiles(test.goos, test.goarch, r.File)
+			got, gotExcluded, err := matchingFiles(test.goos, test.goarch, r.File)
 			if err != nil {
 				t.Fatal(err)
 			}
 			if diff := cmp.Diff(test.want, got); diff != "" {
 				t.Errorf("mismatch (-want +got):\n%s", diff)
 			}
+			if diff := cmp.Diff(test.wantExcluded, gotExcluded); diff != "" {
+				t.Errorf("excluded mismatch (-want +got):\n%s", diff)
+			}
 		})
 	}
 }
@@ -0,0 +1,149 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fetch
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ghodss/yaml"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+const (
+	// metadataFilename is the name of the file at the module root that can
+	// hold module metadata.
+	metadataFilename = "pkgsite.yaml"
+
+	// metadataCommentPrefix introduces a metadata comment in a go.mod file,
+	// as in
+	//   // pkgsite:description A fast JSON parser.
+	metadataCommentPrefix = "pkgsite:"
+
+	maxDescriptionLen = 300
+	maxKeywords       = 10
+	maxKeywordLen     = 32
+)
+
+// extractModuleMetadata returns the metadata that the module author supplied
+// in comments in the go.mod file or in a pkgsite.yaml file at the module root.
+// Fields set in pkgsite.yaml take precedence over those in go.mod. It returns
+// nil if there is no metadata.
+func extractModuleMetadata(modulePath, resolvedVersion string, r *zip.Reader) (_ *internal.ModuleMetadata, err error) {
+	defer derrors.Wrap(&err, "extractModuleMetadata(%q, %q)", modulePath, resolvedVersion)
+
+	prefix := moduleVersionDir(modulePath, resolvedVersion) + "/"
+	var goMod, yamlFile []byte
+	for _, f := range r.File {
+		var dst *[]byte
+		switch f.Name {
+		case prefix + "go.mod":
+			dst = &goMod
+		case prefix + metadataFilename:
+			dst = &yamlFile
+		default:
+			continue
+		}
+		if f.UncompressedSize64 > MaxFileSize {
+			return nil, fmt.Errorf("%s: file size %d exceeds max limit %d", f.Name, f.UncompressedSize64, MaxFileSize)
+		}
+		if *dst, err = readZipFile(f, MaxFileSize); err != nil {
+			return nil, err
+		}
+	}
+
+	md := parseGoModMetadata(goMod)
+	if yamlFile != nil {
+		var ym internal.ModuleMetadata
+		if err := yaml.Unmarshal(yamlFile, &ym); err != nil {
+			return nil, fmt.Errorf("%s: %v", metadataFilename, err)
+		}
+		if ym.Description != "" {
+			md.Description = ym.Description
+		}
+		if len(ym.Keywords) > 0 {
+			md.Keywords = ym.Keywords
+		}
+		if ym.Category != "" {
+			md.Category = ym.Category
+		}
+	}
+	return normalizeModuleMetadata(md), nil
+}
+
+// parseGoModMetadata returns the metadata in comments of the form
+//
+//	// pkgsite:<key> <value>
+//
+// in the contents of a go.mod file. The keys are "description", "keywords"
+// (a comma-separated list) and "category"; other keys are ignored.
+func parseGoModMetadata(goMod []byte) internal.ModuleMetadata {
+	var md internal.ModuleMetadata
+	scanner := bufio.NewScanner(bytes.NewReader(goMod))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "//") {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "//"))
+		if !strings.HasPrefix(line, metadataCommentPrefix) {
+			continue
+		}
+		line = strings.TrimPrefix(line, metadataCommentPrefix)
+		key, value := line, ""
+		if i := strings.IndexAny(line, " \t"); i >= 0 {
+			key, value = line[:i], strings.TrimSpace(line[i:])
+		}
+		switch key {
+		case "description":
+			md.Description = value
+		case "keywords":
+			md.Keywords = strings.Split(value, ",")
+		case "category":
+			md.Category = value
+		}
+	}
+	return md
+}
+
+// normalizeModuleMetadata cleans up author-supplied metadata, so that it is
+// safe to display and index. It returns nil if there is nothing left.
+func normalizeModuleMetadata(md internal.ModuleMetadata) *internal.ModuleMetadata {
+	md.Description = truncateRunes(strings.Join(strings.Fields(md.Description), " "), maxDescriptionLen)
+	md.Category = truncateRunes(strings.ToLower(strings.TrimSpace(md.Category)), maxKeywordLen)
+	var keywords []string
+	seen := map[string]bool{}
+	for _, k := range md.Keywords {
+		k = truncateRunes(strings.ToLower(strings.TrimSpace(k)), maxKeywordLen)
+		if k == "" || seen[k] {
+			continue
+		}
+		seen[k] = true
+		keywords = append(keywords, k)
+		if len(keywords) == maxKeywords {
+			break
+		}
+	}
+	md.Keywords = keywords
+	if md.Description == "" && md.Category == "" && len(md.Keywords) == 0 {
+		return nil
+	}
+	return &md
+}
+
+// truncateRunes returns s truncated to at most n runes, and with any invalid
+// UTF-8 removed.
+func truncateRunes(s string, n int) string {
+	s = strings.ToValidUTF8(s, "")
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
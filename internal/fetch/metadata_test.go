@@ -0,0 +1,78 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fetch
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+)
+
+func TestExtractModuleMetadata(t *testing.T) {
+	const (
+		modulePath = "example.com/mod"
+		version    = "v1.0.0"
+		prefix     = modulePath + "@" + version + "/"
+	)
+	for _, test := range []struct {
+		name    string
+		files   map[string]string
+		want    *internal.ModuleMetadata
+		wantErr bool
+	}{
+		{
+			name:  "none",
+			files: map[string]string{prefix + "go.mod": "module example.com/mod\n"},
+			want:  nil,
+		},
+		{
+			name: "go.mod comments",
+			files: map[string]string{
+				prefix + "go.mod": "// pkgsite:description   A fast\tparser.\n" +
+					"// pkgsite:keywords JSON, parser, json,\n" +
+					"//pkgsite:category Encoding\n" +
+					"// pkgsite:unknown ignored\n" +
+					"module example.com/mod\n",
+			},
+			want: &internal.ModuleMetadata{
+				Description: "A fast parser.",
+				Keywords:    []string{"json", "parser"},
+				Category:    "encoding",
+			},
+		},
+		{
+			name: "pkgsite.yaml overrides go.mod",
+			files: map[string]string{
+				prefix + "go.mod": "// pkgsite:description From go.mod.\n// pkgsite:category cli\nmodule example.com/mod\n",
+				prefix + "pkgsite.yaml": "description: From pkgsite.yaml.\n" +
+					"keywords: [a, b]\n",
+				prefix + "sub/pkgsite.yaml": "description: Not at the root.\n",
+			},
+			want: &internal.ModuleMetadata{
+				Description: "From pkgsite.yaml.",
+				Keywords:    []string{"a", "b"},
+				Category:    "cli",
+			},
+		},
+		{
+			name: "bad yaml",
+			files: map[string]string{
+				prefix + "pkgsite.yaml": "keywords: {",
+			},
+			wantErr: true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := extractModuleMetadata(modulePath, version, makeZipReader(t, test.files))
+			if (err != nil) != test.wantErr {
+				t.Fatalf("got error %v, want error: %t", err, test.wantErr)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("mismatch (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
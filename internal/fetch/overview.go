@@ -0,0 +1,55 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fetch
+
+import (
+	"sort"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/licenses"
+)
+
+// maxOverviewPackages is the largest number of packages listed in a
+// ModuleOverview.
+const maxOverviewPackages = 100
+
+// moduleOverview returns a summary of the module at modulePath, made of pkgs
+// and licensed under lics, if its root directory is not a package. It returns
+// nil if the root is a package, or if the module has no packages.
+func moduleOverview(modulePath string, pkgs []*goPackage, lics []*licenses.License) *internal.ModuleOverview {
+	if len(pkgs) == 0 {
+		return nil
+	}
+	for _, p := range pkgs {
+		if p.path == modulePath {
+			return nil
+		}
+	}
+	sorted := append([]*goPackage(nil), pkgs...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].path < sorted[j].path })
+	o := &internal.ModuleOverview{NumPackages: len(sorted)}
+	for _, p := range sorted {
+		if len(o.Packages) == maxOverviewPackages {
+			break
+		}
+		po := &internal.PackageOverview{Path: p.path, IsCommand: p.name == "main"}
+		// Synopses of packages that are not redistributable are not shown.
+		if p.isRedistributable {
+			po.Synopsis = p.synopsis
+		}
+		o.Packages = append(o.Packages, po)
+	}
+	types := map[string]bool{}
+	for _, l := range lics {
+		for _, t := range l.Types {
+			types[t] = true
+		}
+	}
+	for t := range types {
+		o.LicenseTypes = append(o.LicenseTypes, t)
+	}
+	sort.Strings(o.LicenseTypes)
+	return o
+}
@@ -0,0 +1,47 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fetch
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/licenses"
+)
+
+func TestModuleOverview(t *testing.T) {
+	const modulePath = "example.com/m"
+	lics := []*licenses.License{
+		{Metadata: &licenses.Metadata{Types: []string{"MIT"}, FilePath: "LICENSE"}},
+		{Metadata: &licenses.Metadata{Types: []string{"BSD-3-Clause", "MIT"}, FilePath: "b/LICENSE"}},
+	}
+	pkgs := []*goPackage{
+		{path: modulePath + "/cmd/tool", name: "main", synopsis: "Tool does things.", isRedistributable: true},
+		{path: modulePath + "/a", name: "a", synopsis: "Package a is a.", isRedistributable: true},
+		{path: modulePath + "/b", name: "b", synopsis: "Package b is b.", isRedistributable: false},
+	}
+	want := &internal.ModuleOverview{
+		Packages: []*internal.PackageOverview{
+			{Path: modulePath + "/a", Synopsis: "Package a is a."},
+			{Path: modulePath + "/b"},
+			{Path: modulePath + "/cmd/tool", Synopsis: "Tool does things.", IsCommand: true},
+		},
+		NumPackages:  3,
+		LicenseTypes: []string{"BSD-3-Clause", "MIT"},
+	}
+	if diff := cmp.Diff(want, moduleOverview(modulePath, pkgs, lics)); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	// A module whose root is a package needs no overview.
+	pkgs = append(pkgs, &goPackage{path: modulePath, name: "m"})
+	if got := moduleOverview(modulePath, pkgs, lics); got != nil {
+		t.Errorf("got %+v for a module whose root is a package, want nil", got)
+	}
+	if got := moduleOverview(modulePath, nil, lics); got != nil {
+		t.Errorf("got %+v for a module without packages, want nil", got)
+	}
+}
@@ -32,10 +32,19 @@ type goPackage struct {
 	path              string
 	name              string
 	synopsis          string
+	synopsisInferred  bool // synopsis was derived from a README, not a doc comment
+	kind              internal.PackageKind
 	imports           []string
 	documentationHTML safehtml.HTML
-	isRedistributable bool
-	licenseMeta       []*licenses.Metadata // metadata of applicable licenses
+	// documentationParts holds the rest of the documentation if it was
+	// split because it was too large.
+	documentationParts []*internal.DocumentationPart
+	// documentationText and documentationMarkdown are only set if requested
+	// in the ProcessingOptions.
+	documentationText     string
+	documentationMarkdown string
+	isRedistributable     bool
+	licenseMeta           []*licenses.Metadata // metadata of applicable licenses
 	// goos and goarch are environment variables used to parse the
 	// package.
 	goos   string
@@ -45,6 +54,19 @@ type goPackage struct {
 	// series.
 	v1path string
 	source []byte // the source files of the package, for generating doc at serving time
+	// symbols holds the exported functions, types and methods of the
+	// package, if the symbol-history experiment is active.
+	symbols []string
+	// symbolDecls holds the declarations of the exported functions, types
+	// and methods of the package, if the api-diff experiment is active.
+	symbolDecls []*internal.SymbolDecl
+	// buildContexts holds the documentation of the package in each build
+	// context, if it differs between them.
+	buildContexts []*internal.BuildContextDoc
+	// docHash is a hash of the package comment; see packageCommentHash.
+	docHash string
+	// hasTests reports whether the package has test files.
+	hasTests bool
 }
 
 // extractPackagesFromZip returns a slice of packages from the module zip r.
@@ -56,7 +78,7 @@ type goPackage struct {
 // * a maximum file size (MaxFileSize)
 // * the particular set of build contexts we consider (goEnvs)
 // * whether the import path is valid.
-func extractPackagesFromZip(ctx context.Context, modulePath, resolvedVersion string, r *zip.Reader, d *licenses.Detector, sourceInfo *source.Info) (_ []*goPackage, _ []*internal.PackageVersionState, err error) {
+func extractPackagesFromZip(ctx context.Context, modulePath, resolvedVersion string, r *zip.Reader, d *licenses.Detector, sourceInfo *source.Info, opts ProcessingOptions) (_ []*goPackage, _ []*internal.PackageVersionState, err error) {
 	defer derrors.Wrap(&err, "extractPackagesFromZip(ctx, %q, %q, r, d)", modulePath, resolvedVersion)
 	ctx, span := trace.StartSpan(ctx, "fetch.extractPackagesFromZip")
 	defer span.End()
@@ -106,6 +128,11 @@ func extractPackagesFromZip(ctx context.Context, modulePath, resolvedVersion str
 		// prevent processing of other packages in the module.
 		incompleteDirs       = make(map[string]bool)
 		packageVersionStates = []*internal.PackageVersionState{}
+
+		// moduleFiles holds the paths of all the files in the module,
+		// relative to the module root, for finding the files that packages
+		// embed.
+		moduleFiles []string
 	)
 
 	// Phase 1.
@@ -125,6 +152,7 @@ func extractPackagesFromZip(ctx context.Context, modulePath, resolvedVersion str
 			return nil, nil, fmt.Errorf("expected file to have prefix %q; got = %q: %w",
 				modulePrefix, f.Name, errMalformedZip)
 		}
+		moduleFiles = append(moduleFiles, f.Name[len(modulePrefix):])
 		innerPath := path.Dir(f.Name[len(modulePrefix):])
 		if incompleteDirs[innerPath] {
 			// We already know this directory cannot be processed, so skip.
@@ -194,7 +222,7 @@ func extractPackagesFromZip(ctx context.Context, modulePath, resolvedVersion str
 			status error
 			errMsg string
 		)
-		pkg, err := loadPackage(ctx, goFiles, innerPath, sourceInfo, modInfo)
+		pkg, err := loadPackage(ctx, goFiles, moduleFiles, innerPath, sourceInfo, modInfo, opts)
 		if bpe := (*BadPackageError)(nil); errors.As(err, &bpe) {
 			incompleteDirs[innerPath] = true
 			status = derrors.PackageInvalidContents
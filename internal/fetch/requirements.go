@@ -0,0 +1,42 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fetch
+
+import (
+	"sort"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/semver"
+	"golang.org/x/pkgsite/internal"
+)
+
+// extractRequirements returns the require directives of the go.mod file
+// goMod, sorted by module path. If a module is required more than once, only
+// its highest version is kept, as the go command would select it, along with
+// whether that directive is indirect. It returns nil if goMod cannot be
+// parsed.
+func extractRequirements(goMod []byte) []*internal.ModuleRequirement {
+	f, err := modfile.ParseLax("go.mod", goMod, nil)
+	if err != nil {
+		return nil
+	}
+	byPath := map[string]*internal.ModuleRequirement{}
+	for _, r := range f.Require {
+		req, ok := byPath[r.Mod.Path]
+		if !ok || semver.Compare(r.Mod.Version, req.Version) > 0 {
+			byPath[r.Mod.Path] = &internal.ModuleRequirement{
+				ModulePath: r.Mod.Path,
+				Version:    r.Mod.Version,
+				Indirect:   r.Indirect,
+			}
+		}
+	}
+	var reqs []*internal.ModuleRequirement
+	for _, req := range byPath {
+		reqs = append(reqs, req)
+	}
+	sort.Slice(reqs, func(i, j int) bool { return reqs[i].ModulePath < reqs[j].ModulePath })
+	return reqs
+}
@@ -0,0 +1,56 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fetch
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+)
+
+func TestExtractRequirements(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		goMod string
+		want  []*internal.ModuleRequirement
+	}{
+		{
+			name:  "no requirements",
+			goMod: "module example.com/mod\n",
+			want:  nil,
+		},
+		{
+			name: "block and single",
+			goMod: "module example.com/mod\n\n" +
+				"require example.org/b v1.0.0\n\n" +
+				"require (\n\texample.org/a v0.2.0 // indirect\n\texample.org/c v2.0.0+incompatible\n)\n",
+			want: []*internal.ModuleRequirement{
+				{ModulePath: "example.org/a", Version: "v0.2.0", Indirect: true},
+				{ModulePath: "example.org/b", Version: "v1.0.0"},
+				{ModulePath: "example.org/c", Version: "v2.0.0+incompatible"},
+			},
+		},
+		{
+			name:  "duplicate",
+			goMod: "module example.com/mod\n\nrequire (\n\texample.org/a v1.2.0\n\texample.org/a v1.10.0 // indirect\n)\n",
+			want: []*internal.ModuleRequirement{
+				{ModulePath: "example.org/a", Version: "v1.10.0", Indirect: true},
+			},
+		},
+		{
+			name:  "unparsable",
+			goMod: "module example.com/mod\nrequire (\n",
+			want:  nil,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got := extractRequirements([]byte(test.goMod))
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fetch
+
+import (
+	"strings"
+
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/semver"
+	"golang.org/x/pkgsite/internal"
+)
+
+// extractRetractions returns the versions that the retract directives of a
+// go.mod file retract, in the order of the directives. The rationale of a
+// directive is its comment, or else the comment on the block that contains
+// it. Directives with invalid versions are skipped.
+//
+// The modfile package doesn't know about retract directives yet, so they
+// are read from the syntax tree, where ParseLax leaves them.
+func extractRetractions(goMod []byte) []*internal.Retraction {
+	f, err := modfile.ParseLax("go.mod", goMod, nil)
+	if err != nil || f.Syntax == nil {
+		return nil
+	}
+	var rs []*internal.Retraction
+	add := func(line *modfile.Line, args []string, blockComment string) {
+		r := parseRetraction(args)
+		if r == nil {
+			return
+		}
+		r.Rationale = directiveComment(line)
+		if r.Rationale == "" {
+			r.Rationale = blockComment
+		}
+		r.Rationale = strings.Join(strings.Fields(r.Rationale), " ")
+		rs = append(rs, r)
+	}
+	for _, stmt := range f.Syntax.Stmt {
+		switch x := stmt.(type) {
+		case *modfile.Line:
+			if len(x.Token) > 0 && x.Token[0] == "retract" {
+				add(x, x.Token[1:], "")
+			}
+		case *modfile.LineBlock:
+			if len(x.Token) != 1 || x.Token[0] != "retract" {
+				continue
+			}
+			blockComment := directiveComment(&modfile.Line{Comments: x.Comments})
+			for _, l := range x.Line {
+				add(l, l.Token, blockComment)
+			}
+		}
+	}
+	return rs
+}
+
+// parseRetraction parses the arguments of a retract directive, which are
+// either a single version or a range "[low, high]". It returns nil if they
+// are not valid.
+func parseRetraction(args []string) *internal.Retraction {
+	var low, high string
+	switch {
+	case len(args) == 1:
+		low, high = args[0], args[0]
+	case len(args) == 5 && args[0] == "[" && args[2] == "," && args[4] == "]":
+		low, high = args[1], args[3]
+	default:
+		return nil
+	}
+	if !semver.IsValid(low) || !semver.IsValid(high) || semver.Compare(low, high) > 0 {
+		return nil
+	}
+	return &internal.Retraction{Low: low, High: high}
+}
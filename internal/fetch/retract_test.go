@@ -0,0 +1,76 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fetch
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+)
+
+func TestExtractRetractions(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		goMod string
+		want  []*internal.Retraction
+	}{
+		{
+			name:  "none",
+			goMod: "module example.com/mod\n",
+			want:  nil,
+		},
+		{
+			name: "single versions and ranges",
+			goMod: `module example.com/mod
+
+// Published too early.
+retract v1.0.0
+
+retract [v1.1.0, v1.1.5] // Contains a bug
+// that corrupts data.
+
+retract v1.2.0
+`,
+			want: []*internal.Retraction{
+				{Low: "v1.0.0", High: "v1.0.0", Rationale: "Published too early."},
+				{Low: "v1.1.0", High: "v1.1.5", Rationale: "Contains a bug"},
+				{Low: "v1.2.0", High: "v1.2.0"},
+			},
+		},
+		{
+			name: "block",
+			goMod: `module example.com/mod
+
+// Broken builds.
+retract (
+	v1.3.0
+	[v1.4.0, v1.4.2] // Missing files.
+)
+`,
+			want: []*internal.Retraction{
+				{Low: "v1.3.0", High: "v1.3.0", Rationale: "Broken builds."},
+				{Low: "v1.4.0", High: "v1.4.2", Rationale: "Missing files."},
+			},
+		},
+		{
+			name: "invalid",
+			goMod: `module example.com/mod
+
+retract 1.0.0
+retract [v1.2.0, v1.1.0]
+retract [v1.0.0 v1.1.0]
+`,
+			want: nil,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got := extractRetractions([]byte(test.goMod))
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("mismatch (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
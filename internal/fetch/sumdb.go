@@ -0,0 +1,275 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fetch
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/groupcache/lru"
+	"golang.org/x/mod/sumdb"
+	"golang.org/x/mod/sumdb/dirhash"
+	"golang.org/x/net/context/ctxhttp"
+	"golang.org/x/pkgsite/internal/config"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/log"
+)
+
+// ChecksumStatus describes the outcome of verifying a module version against
+// a checksum database.
+type ChecksumStatus int
+
+const (
+	// ChecksumUnchecked means that no verification was attempted, because
+	// verification is disabled or does not apply (as for the standard
+	// library).
+	ChecksumUnchecked ChecksumStatus = iota
+	// ChecksumVerified means that the hashes of the module zip and go.mod
+	// file match the checksum database.
+	ChecksumVerified
+	// ChecksumMismatch means that the checksum database has a different hash
+	// for the module zip or go.mod file than the content we downloaded.
+	ChecksumMismatch
+	// ChecksumUnavailable means that the checksum database could not be
+	// consulted, for example because it does not know about the module.
+	ChecksumUnavailable
+)
+
+func (s ChecksumStatus) String() string {
+	switch s {
+	case ChecksumUnchecked:
+		return "unchecked"
+	case ChecksumVerified:
+		return "verified"
+	case ChecksumMismatch:
+		return "mismatch"
+	case ChecksumUnavailable:
+		return "unavailable"
+	default:
+		return fmt.Sprintf("ChecksumStatus(%d)", int(s))
+	}
+}
+
+// sumGolangOrgKey is the verifier key for sum.golang.org, the default
+// checksum database used by the go command.
+const sumGolangOrgKey = "sum.golang.org+033de0ae+Ac4zctda0e5eza+HJyk9SxEdh+s3Ux18htTTAD8OuAn8"
+
+// A ChecksumDB verifies module content against a checksum database such as
+// sum.golang.org.
+type ChecksumDB struct {
+	ops *sumdbOps
+}
+
+// NewChecksumDB returns a ChecksumDB for the database with the given verifier
+// key, served at url. If url is empty, the database is assumed to be served
+// from https://<name>, where <name> is the name in the verifier key.
+func NewChecksumDB(key, url string, httpClient *http.Client) *ChecksumDB {
+	if url == "" {
+		url = "https://" + key[:strings.Index(key+"+", "+")]
+	}
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	ops := &sumdbOps{
+		key:        key,
+		url:        strings.TrimRight(url, "/"),
+		httpClient: httpClient,
+		configs:    map[string][]byte{},
+		cache:      lru.New(sumdbCacheSize),
+	}
+	return &ChecksumDB{ops: ops}
+}
+
+// Verify compares the hashes of the given go.mod contents and module zip
+// with the ones recorded in the checksum database for modulePath@version.
+// It returns a non-nil error only if the database could not be consulted, in
+// which case the status is ChecksumUnavailable.
+func (c *ChecksumDB) Verify(ctx context.Context, modulePath, version string, goModBytes []byte, zipReader *zip.Reader) (_ ChecksumStatus, err error) {
+	defer derrors.Wrap(&err, "ChecksumDB.Verify(%q, %q)", modulePath, version)
+
+	zipHash, err := hashZip(zipReader)
+	if err != nil {
+		return ChecksumUnavailable, err
+	}
+	modHash, err := hashGoMod(goModBytes)
+	if err != nil {
+		return ChecksumUnavailable, err
+	}
+	// sumdb.ClientOps has no context, so use a client whose operations are
+	// bound to ctx. The configuration and tiles are kept in c.ops, so they
+	// are shared by all clients.
+	client := sumdb.NewClient(ctxSumdbOps{c.ops, ctx})
+	// The checksum database records the hashes of the zip and the go.mod file
+	// as separate lines, which are looked up using different versions.
+	for _, h := range []struct{ vers, hash string }{
+		{version, zipHash},
+		{version + "/go.mod", modHash},
+	} {
+		lines, err := client.Lookup(modulePath, h.vers)
+		if err != nil {
+			return ChecksumUnavailable, err
+		}
+		want := fmt.Sprintf("%s %s %s", modulePath, h.vers, h.hash)
+		found := false
+		for _, line := range lines {
+			if line == want {
+				found = true
+				break
+			}
+		}
+		if !found {
+			log.Errorf(ctx, "checksum database lines for %s@%s are %q; want %q", modulePath, h.vers, lines, want)
+			return ChecksumMismatch, nil
+		}
+	}
+	return ChecksumVerified, nil
+}
+
+// hashZip computes the h1: hash of a module zip, as the go command does.
+func hashZip(r *zip.Reader) (string, error) {
+	var files []string
+	zfiles := map[string]*zip.File{}
+	for _, f := range r.File {
+		files = append(files, f.Name)
+		zfiles[f.Name] = f
+	}
+	return dirhash.Hash1(files, func(name string) (io.ReadCloser, error) {
+		f := zfiles[name]
+		if f == nil {
+			return nil, fmt.Errorf("file %q not found in zip", name)
+		}
+		return f.Open()
+	})
+}
+
+// hashGoMod computes the h1: hash of a go.mod file, as the go command does.
+func hashGoMod(contents []byte) (string, error) {
+	return dirhash.Hash1([]string{"go.mod"}, func(string) (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(contents)), nil
+	})
+}
+
+// sumdbCacheSize is the number of tiles that a sumdbOps keeps. A tile holds
+// at most 256 hashes, so the cache holds at most about 8MB.
+const sumdbCacheSize = 1000
+
+// sumdbOps keeps the configuration and cached tiles of a checksum database
+// client in memory. Together with a context, it implements sumdb.ClientOps;
+// see ctxSumdbOps.
+//
+// The configuration is only the latest signed tree head, so it stays small.
+// Only the most recently used tiles are kept; others are fetched again if
+// they are needed.
+type sumdbOps struct {
+	key        string
+	url        string
+	httpClient *http.Client
+
+	mu      sync.Mutex
+	configs map[string][]byte
+	cache   *lru.Cache // file name to []byte
+}
+
+// ctxSumdbOps implements sumdb.ClientOps using the operations of a sumdbOps,
+// making requests with ctx.
+type ctxSumdbOps struct {
+	*sumdbOps
+	ctx context.Context
+}
+
+func (o ctxSumdbOps) ReadRemote(path string) (_ []byte, err error) {
+	defer derrors.Wrap(&err, "ctxSumdbOps.ReadRemote(%q)", path)
+
+	resp, err := ctxhttp.Get(o.ctx, o.httpClient, o.url+path)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+func (o *sumdbOps) ReadConfig(file string) ([]byte, error) {
+	if file == "key" {
+		return []byte(o.key), nil
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	// A missing file means that we start with an empty signed tree.
+	return o.configs[file], nil
+}
+
+func (o *sumdbOps) WriteConfig(file string, old, new []byte) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if !bytes.Equal(o.configs[file], old) {
+		return sumdb.ErrWriteConflict
+	}
+	o.configs[file] = new
+	return nil
+}
+
+func (o *sumdbOps) ReadCache(file string) ([]byte, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	data, ok := o.cache.Get(file)
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", file, derrors.NotFound)
+	}
+	return data.([]byte), nil
+}
+
+func (o *sumdbOps) WriteCache(file string, data []byte) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.cache.Add(file, data)
+}
+
+func (o ctxSumdbOps) Log(msg string) {
+	log.Info(o.ctx, msg)
+}
+
+func (o ctxSumdbOps) SecurityError(msg string) {
+	log.Error(o.ctx, msg)
+}
+
+// checksumDB is used to verify fetched modules. It is nil if verification is
+// disabled.
+var checksumDB *ChecksumDB
+
+// enforceChecksums determines whether modules whose content does not match
+// the checksum database are refused.
+var enforceChecksums = true
+
+func init() {
+	// GO_DISCOVERY_CHECKSUM_DB has the same format as GOSUMDB: either
+	// "sum.golang.org", or a verifier key optionally followed by a URL.
+	// Verification is disabled if it is empty or "off".
+	v := config.GetEnv("GO_DISCOVERY_CHECKSUM_DB", "")
+	if v == "" || v == "off" {
+		return
+	}
+	fields := strings.Fields(v)
+	key, url := fields[0], ""
+	if key == "sum.golang.org" {
+		key = sumGolangOrgKey
+	}
+	if len(fields) > 1 {
+		url = fields[1]
+	}
+	checksumDB = NewChecksumDB(key, url, nil)
+	enforceChecksums = config.GetEnv("GO_DISCOVERY_CHECKSUM_DB_ENFORCE", "true") != "false"
+	log.Infof(context.Background(), "verifying modules against checksum database %s (enforce=%t)", v, enforceChecksums)
+}
@@ -0,0 +1,132 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fetch
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/mod/sumdb"
+	"golang.org/x/mod/sumdb/note"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+func TestChecksumDBVerify(t *testing.T) {
+	const (
+		modulePath = "example.com/mod"
+		version    = "v1.0.0"
+	)
+	goMod := []byte("module example.com/mod\n")
+	zr := makeZipReader(t, map[string]string{
+		modulePath + "@" + version + "/go.mod": string(goMod),
+		modulePath + "@" + version + "/a.go":   "package a",
+	})
+	zipHash, err := hashZip(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	modHash, err := hashGoMod(goMod)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	skey, vkey, err := note.GenerateKey(rand.Reader, "sumdb.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gosum := func(path, vers string) ([]byte, error) {
+		switch path + "@" + vers {
+		case modulePath + "@" + version:
+			return []byte(fmt.Sprintf("%[1]s %[2]s %[3]s\n%[1]s %[2]s/go.mod %[4]s\n", path, vers, zipHash, modHash)), nil
+		case modulePath + "@v1.1.0":
+			// Record a hash for different content.
+			return []byte(fmt.Sprintf("%[1]s %[2]s %[3]s\n%[1]s %[2]s/go.mod %[3]s\n", path, vers, modHash)), nil
+		default:
+			return nil, derrors.NotFound
+		}
+	}
+	server := httptest.NewServer(sumdb.NewServer(sumdb.NewTestServer(skey, gosum)))
+	defer server.Close()
+
+	db := NewChecksumDB(vkey, server.URL, nil)
+	ctx := context.Background()
+	for _, test := range []struct {
+		version string
+		want    ChecksumStatus
+		wantErr bool
+	}{
+		{version, ChecksumVerified, false},
+		{"v1.1.0", ChecksumMismatch, false},
+		{"v2.0.0", ChecksumUnavailable, true},
+	} {
+		t.Run(test.version, func(t *testing.T) {
+			got, err := db.Verify(ctx, modulePath, test.version, goMod, zr)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("got error %v, want error: %t", err, test.wantErr)
+			}
+			if got != test.want {
+				t.Errorf("got %s, want %s", got, test.want)
+			}
+		})
+	}
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+		db := NewChecksumDB(vkey, server.URL, nil)
+		got, err := db.Verify(ctx, modulePath, version, goMod, zr)
+		// The sumdb package doesn't wrap errors, so look at the message.
+		if err == nil || !strings.Contains(err.Error(), context.Canceled.Error()) {
+			t.Errorf("got error %v, want context.Canceled", err)
+		}
+		if got != ChecksumUnavailable {
+			t.Errorf("got %s, want %s", got, ChecksumUnavailable)
+		}
+	})
+}
+
+func TestSumdbOpsCacheIsBounded(t *testing.T) {
+	ops := NewChecksumDB("example.com+00000000+AAAA", "", nil).ops
+	for i := 0; i <= sumdbCacheSize; i++ {
+		ops.WriteCache(fmt.Sprintf("tile/8/0/%d", i), []byte{byte(i)})
+	}
+	if _, err := ops.ReadCache("tile/8/0/0"); !errors.Is(err, derrors.NotFound) {
+		t.Errorf("oldest tile: got error %v, want NotFound", err)
+	}
+	last := fmt.Sprintf("tile/8/0/%d", sumdbCacheSize)
+	if got, err := ops.ReadCache(last); err != nil || len(got) != 1 {
+		t.Errorf("ReadCache(%q) = %v, %v; want the tile", last, got, err)
+	}
+}
+
+func makeZipReader(t *testing.T, contents map[string]string) *zip.Reader {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range contents {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return zr
+}
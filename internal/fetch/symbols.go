@@ -0,0 +1,184 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fetch
+
+import (
+	"bytes"
+	"go/ast"
+	"go/printer"
+	"go/token"
+	"sort"
+	"strings"
+
+	"golang.org/x/pkgsite/internal"
+)
+
+// exportedSymbols returns the sorted names of the exported functions, types
+// and methods declared in the non-test files of goFiles, which is keyed by
+// file name. Methods are named "Type.Method", and only those of exported
+// types are included.
+func exportedSymbols(goFiles map[string]*ast.File) []string {
+	seen := map[string]bool{}
+	forEachExportedDecl(goFiles, func(name string, _ ast.Decl) {
+		seen[name] = true
+	})
+	var symbols []string
+	for s := range seen {
+		symbols = append(symbols, s)
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+// exportedSymbolDecls returns the declarations of the symbols that
+// exportedSymbols returns, sorted by name. Doc comments, function bodies,
+// and the unexported fields and methods of struct and interface types are
+// left out, so that a declaration only changes with the API of the package.
+// It must be called before the AST of goFiles is modified to render
+// documentation.
+func exportedSymbolDecls(fset *token.FileSet, goFiles map[string]*ast.File) []*internal.SymbolDecl {
+	decls := map[string]string{}
+	forEachExportedDecl(goFiles, func(name string, decl ast.Decl) {
+		var buf bytes.Buffer
+		cfg := printer.Config{Mode: printer.UseSpaces | printer.TabIndent, Tabwidth: 8}
+		if err := cfg.Fprint(&buf, fset, decl); err != nil {
+			return
+		}
+		// Removed fields leave blank lines behind.
+		var lines []string
+		for _, line := range strings.Split(buf.String(), "\n") {
+			if strings.TrimSpace(line) != "" {
+				lines = append(lines, line)
+			}
+		}
+		decls[name] = strings.Join(lines, "\n")
+	})
+	var sds []*internal.SymbolDecl
+	for name, decl := range decls {
+		sds = append(sds, &internal.SymbolDecl{Name: name, Decl: decl})
+	}
+	sort.Slice(sds, func(i, j int) bool { return sds[i].Name < sds[j].Name })
+	return sds
+}
+
+// forEachExportedDecl calls f with the name and the API-only declaration of
+// each exported function, type and method in the non-test files of goFiles;
+// see exportedSymbolDecls. The declarations passed to f share parts of the
+// AST of goFiles, and must not be modified.
+func forEachExportedDecl(goFiles map[string]*ast.File, f func(name string, decl ast.Decl)) {
+	for name, file := range goFiles {
+		if strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		for _, decl := range file.Decls {
+			switch d := decl.(type) {
+			case *ast.FuncDecl:
+				if !d.Name.IsExported() {
+					continue
+				}
+				fd := *d
+				fd.Doc = nil
+				fd.Body = nil
+				if d.Recv == nil {
+					f(d.Name.Name, &fd)
+				} else if recv := receiverTypeName(d.Recv); ast.IsExported(recv) {
+					f(recv+"."+d.Name.Name, &fd)
+				}
+			case *ast.GenDecl:
+				if d.Tok != token.TYPE {
+					continue
+				}
+				for _, spec := range d.Specs {
+					ts := spec.(*ast.TypeSpec)
+					if !ts.Name.IsExported() {
+						continue
+					}
+					c := *ts
+					c.Doc = nil
+					c.Comment = nil
+					c.Type = exportedType(ts.Type)
+					f(ts.Name.Name, &ast.GenDecl{TokPos: d.TokPos, Tok: token.TYPE, Specs: []ast.Spec{&c}})
+				}
+			}
+		}
+	}
+}
+
+// exportedType returns a copy of typ without the unexported fields of a
+// struct type or the unexported methods of an interface type. Other types are
+// returned unchanged.
+func exportedType(typ ast.Expr) ast.Expr {
+	switch t := typ.(type) {
+	case *ast.StructType:
+		c := *t
+		c.Fields = exportedFields(t.Fields, false)
+		return &c
+	case *ast.InterfaceType:
+		c := *t
+		c.Methods = exportedFields(t.Methods, true)
+		return &c
+	}
+	return typ
+}
+
+// exportedFields returns a copy of fl with only its exported fields, or
+// methods. Embedded types are kept if they are exported, or if keepEmbedded
+// is true.
+func exportedFields(fl *ast.FieldList, keepEmbedded bool) *ast.FieldList {
+	if fl == nil {
+		return nil
+	}
+	c := *fl
+	c.List = nil
+	for _, field := range fl.List {
+		if len(field.Names) == 0 {
+			if keepEmbedded || ast.IsExported(embeddedTypeName(field.Type)) {
+				c.List = append(c.List, &ast.Field{Type: field.Type, Tag: field.Tag})
+			}
+			continue
+		}
+		var names []*ast.Ident
+		for _, n := range field.Names {
+			if n.IsExported() {
+				names = append(names, n)
+			}
+		}
+		if len(names) > 0 {
+			c.List = append(c.List, &ast.Field{Names: names, Type: field.Type, Tag: field.Tag})
+		}
+	}
+	return &c
+}
+
+// embeddedTypeName returns the name of the embedded type typ, as in "T" for
+// "*pkg.T", or the empty string if it is not a named type.
+func embeddedTypeName(typ ast.Expr) string {
+	if star, ok := typ.(*ast.StarExpr); ok {
+		typ = star.X
+	}
+	switch t := typ.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.SelectorExpr:
+		return t.Sel.Name
+	}
+	return ""
+}
+
+// receiverTypeName returns the name of the type of the receiver recv, as in
+// "T" for "(t *T)", or the empty string if it has none.
+func receiverTypeName(recv *ast.FieldList) string {
+	if len(recv.List) == 0 {
+		return ""
+	}
+	typ := recv.List[0].Type
+	if star, ok := typ.(*ast.StarExpr); ok {
+		typ = star.X
+	}
+	if id, ok := typ.(*ast.Ident); ok {
+		return id.Name
+	}
+	return ""
+}
@@ -0,0 +1,111 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fetch
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+)
+
+func TestExportedSymbols(t *testing.T) {
+	files := map[string]string{
+		"a.go": `package p
+
+type T int
+
+func (T) M()   {}
+func (*T) PM() {}
+func (T) m()   {}
+
+type t int
+
+func (t) M() {}
+
+func F() {}
+func f() {}
+
+const C = 1
+
+var V = 2
+`,
+		"b.go": `package p
+
+type (
+	U struct{}
+	u struct{}
+)
+
+func NewU() *U { return nil }
+`,
+		"p_test.go": `package p
+
+func TestX() {}
+`,
+	}
+	fset := token.NewFileSet()
+	goFiles := map[string]*ast.File{}
+	for name, src := range files {
+		f, err := parser.ParseFile(fset, name, src, parser.ParseComments)
+		if err != nil {
+			t.Fatal(err)
+		}
+		goFiles[name] = f
+	}
+	want := []string{"F", "NewU", "T", "T.M", "T.PM", "U"}
+	if diff := cmp.Diff(want, exportedSymbols(goFiles)); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestExportedSymbolDecls(t *testing.T) {
+	const src = `package p
+
+// T is a type.
+type T struct {
+	A, b int
+	c    string
+	io.Reader
+	*bytes.Buffer
+	unexported
+}
+
+// M is a method.
+func (t *T) M(x int) error {
+	return nil
+}
+
+func (t T) m() {}
+
+type (
+	I interface {
+		io.Writer
+		N()
+		n()
+	}
+	u int
+)
+
+func F() {}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "a.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*internal.SymbolDecl{
+		{Name: "F", Decl: "func F()"},
+		{Name: "I", Decl: "type I interface {\n\tio.Writer\n\tN()\n}"},
+		{Name: "T", Decl: "type T struct {\n\tA int\n\tio.Reader\n\t*bytes.Buffer\n}"},
+		{Name: "T.M", Decl: "func (t *T) M(x int) error"},
+	}
+	if diff := cmp.Diff(want, exportedSymbolDecls(fset, map[string]*ast.File{"a.go": f})); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fetch
+
+import (
+	"go/doc"
+	"path"
+	"strings"
+
+	"github.com/russross/blackfriday/v2"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/stdlib"
+)
+
+// inferSynopses sets a synopsis for each package in pkgs that has no doc
+// comment, using the first paragraph of the README in the package's directory
+// or, failing that, of the README at the module root, or the description in
+// the module metadata. Synopses set this way are marked as inferred. Standard
+// library packages are left alone.
+func inferSynopses(modulePath string, pkgs []*goPackage, readmes []*internal.Readme, md *internal.ModuleMetadata) {
+	if modulePath == stdlib.ModulePath {
+		return
+	}
+	readmeLookup := map[string]*internal.Readme{}
+	for _, r := range readmes {
+		readmeLookup[path.Join(modulePath, path.Dir(r.Filepath))] = r
+	}
+	for _, p := range pkgs {
+		if p.synopsis != "" {
+			continue
+		}
+		for _, dir := range []string{p.path, modulePath} {
+			r, ok := readmeLookup[dir]
+			if !ok {
+				continue
+			}
+			if s := readmeSynopsis(r); s != "" {
+				p.synopsis = s
+				p.synopsisInferred = true
+				break
+			}
+		}
+		if p.synopsis == "" && md != nil && md.Description != "" {
+			p.synopsis = doc.Synopsis(md.Description)
+			p.synopsisInferred = true
+		}
+	}
+}
+
+// readmeSynopsis returns the first sentence of the first paragraph of text in
+// the README, or the empty string if there is none. Headings, images (usually
+// badges), code blocks and HTML are skipped.
+func readmeSynopsis(r *internal.Readme) string {
+	ext := strings.ToLower(path.Ext(r.Filepath))
+	if ext != ".md" && ext != ".markdown" {
+		// Treat the README as plain text.
+		for _, para := range strings.Split(strings.ReplaceAll(r.Contents, "\r\n", "\n"), "\n\n") {
+			if s := doc.Synopsis(para); s != "" {
+				return s
+			}
+		}
+		return ""
+	}
+	parser := blackfriday.New(blackfriday.WithExtensions(blackfriday.CommonExtensions))
+	root := parser.Parse([]byte(r.Contents))
+	var synopsis string
+	root.Walk(func(n *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		if !entering {
+			return blackfriday.GoToNext
+		}
+		switch n.Type {
+		case blackfriday.Heading, blackfriday.Image, blackfriday.CodeBlock, blackfriday.HTMLBlock, blackfriday.Table:
+			return blackfriday.SkipChildren
+		case blackfriday.Paragraph:
+			if s := doc.Synopsis(markdownText(n)); s != "" {
+				synopsis = s
+				return blackfriday.Terminate
+			}
+			return blackfriday.SkipChildren
+		}
+		return blackfriday.GoToNext
+	})
+	return synopsis
+}
+
+// markdownText returns the text of the inline nodes under n, omitting images
+// and HTML.
+func markdownText(n *blackfriday.Node) string {
+	var b strings.Builder
+	n.Walk(func(c *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		if !entering {
+			return blackfriday.GoToNext
+		}
+		switch c.Type {
+		case blackfriday.Image, blackfriday.HTMLSpan:
+			return blackfriday.SkipChildren
+		case blackfriday.Softbreak, blackfriday.Hardbreak:
+			b.WriteByte(' ')
+		default:
+			b.Write(c.Literal)
+		}
+		return blackfriday.GoToNext
+	})
+	return b.String()
+}
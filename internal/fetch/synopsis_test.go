@@ -0,0 +1,81 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fetch
+
+import (
+	"testing"
+
+	"golang.org/x/pkgsite/internal"
+)
+
+func TestReadmeSynopsis(t *testing.T) {
+	for _, test := range []struct {
+		name, filepath, contents, want string
+	}{
+		{
+			name:     "markdown skips headings and badges",
+			filepath: "README.md",
+			contents: "# mod\n\n[![Build](https://ci.example.com/badge.svg)](https://ci.example.com)\n\n" +
+				"Package mod does **useful** things\nwith [links](https://example.com). More text.\n",
+			want: "Package mod does useful things with links.",
+		},
+		{
+			name:     "markdown skips code and html",
+			filepath: "README.markdown",
+			contents: "<p align=\"center\"><img src=\"logo.png\"></p>\n\n```\ngo get example.com/mod\n```\n\nA fast parser.\n",
+			want:     "A fast parser.",
+		},
+		{
+			name:     "plain text",
+			filepath: "README",
+			contents: "\n\nA plain\nREADME. Second sentence.\n\nSecond paragraph.\n",
+			want:     "A plain README.",
+		},
+		{
+			name:     "no text",
+			filepath: "README.md",
+			contents: "# Title\n\n![logo](logo.png)\n",
+			want:     "",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got := readmeSynopsis(&internal.Readme{Filepath: test.filepath, Contents: test.contents})
+			if got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestInferSynopses(t *testing.T) {
+	const modulePath = "example.com/mod"
+	pkgs := []*goPackage{
+		{path: modulePath + "/documented", synopsis: "Package documented is documented."},
+		{path: modulePath + "/own"},
+		{path: modulePath + "/root"},
+		{path: modulePath + "/described"},
+	}
+	readmes := []*internal.Readme{
+		{Filepath: "README.md", Contents: "The module README."},
+		{Filepath: "own/README.md", Contents: "The package README."},
+	}
+	inferSynopses(modulePath, pkgs[:3], readmes, nil)
+	inferSynopses(modulePath, pkgs[3:], nil, &internal.ModuleMetadata{Description: "A useful module. It does things."})
+	for _, want := range []struct {
+		synopsis string
+		inferred bool
+	}{
+		{"Package documented is documented.", false},
+		{"The package README.", true},
+		{"The module README.", true},
+		{"A useful module.", true},
+	} {
+		p := pkgs[0]
+		pkgs = pkgs[1:]
+		if p.synopsis != want.synopsis || p.synopsisInferred != want.inferred {
+			t.Errorf("%s: got (%q, %t), want (%q, %t)", p.path, p.synopsis, p.synopsisInferred, want.synopsis, want.inferred)
+		}
+	}
+}
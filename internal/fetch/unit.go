@@ -62,11 +62,21 @@ func moduleUnits(modulePath, version string,
 			dir.Name = pkg.name
 			dir.Imports = pkg.imports
 			dir.Documentation = &internal.Documentation{
-				GOOS:     pkg.goos,
-				GOARCH:   pkg.goarch,
-				Synopsis: pkg.synopsis,
-				HTML:     pkg.documentationHTML,
-				Source:   pkg.source,
+				GOOS:             pkg.goos,
+				GOARCH:           pkg.goarch,
+				Synopsis:         pkg.synopsis,
+				SynopsisInferred: pkg.synopsisInferred,
+				Kind:             pkg.kind,
+				HTML:             pkg.documentationHTML,
+				Parts:            pkg.documentationParts,
+				Source:           pkg.source,
+				Text:             pkg.documentationText,
+				Markdown:         pkg.documentationMarkdown,
+				Symbols:          pkg.symbols,
+				SymbolDecls:      pkg.symbolDecls,
+				BuildContexts:    pkg.buildContexts,
+				DocHash:          pkg.docHash,
+				HasTests:         pkg.hasTests,
 			}
 		}
 		units = append(units, dir)
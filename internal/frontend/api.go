@@ -0,0 +1,305 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/godoc/dochtml"
+	"golang.org/x/pkgsite/internal/licenses"
+	"golang.org/x/pkgsite/internal/postgres"
+)
+
+// apiModule is the response to a request for
+// "/api/v1/module/<module-path>[@<version>]".
+type apiModule struct {
+	Path              string          `json:"path"`
+	Version           string          `json:"version"`
+	CommitTime        time.Time       `json:"commit_time"`
+	RepositoryURL     string          `json:"repository_url,omitempty"`
+	IsRedistributable bool            `json:"is_redistributable"`
+	Deprecation       *apiDeprecation `json:"deprecation,omitempty"`
+	Licenses          []*apiLicense   `json:"licenses"`
+	Readme            *apiReadme      `json:"readme,omitempty"`
+}
+
+// apiPackage is the response to a request for
+// "/api/v1/package/<path>[@<version>]".
+type apiPackage struct {
+	Path              string        `json:"path"`
+	Name              string        `json:"name"`
+	Synopsis          string        `json:"synopsis"`
+	ModulePath        string        `json:"module_path"`
+	Version           string        `json:"version"`
+	CommitTime        time.Time     `json:"commit_time"`
+	IsRedistributable bool          `json:"is_redistributable"`
+	Licenses          []*apiLicense `json:"licenses"`
+	Imports           []string      `json:"imports"`
+	Readme            *apiReadme    `json:"readme,omitempty"`
+}
+
+// apiVersion is an element of the response to a request for
+// "/api/v1/versions/<path>".
+type apiVersion struct {
+	ModulePath string    `json:"module_path"`
+	Version    string    `json:"version"`
+	CommitTime time.Time `json:"commit_time"`
+}
+
+type apiDeprecation struct {
+	Message   string `json:"message"`
+	Successor string `json:"successor,omitempty"`
+}
+
+type apiLicense struct {
+	Types    []string `json:"types"`
+	FilePath string   `json:"file_path"`
+}
+
+type apiReadme struct {
+	FilePath string `json:"file_path"`
+	Contents string `json:"contents"`
+}
+
+// serveAPI serves version 1 of the JSON API. It expects paths of the form
+// "/api/v1/<endpoint>/<path>[@<version>]", where <path> is formed as for
+// details pages and <endpoint> is one of:
+//
+//	module    information about a module, including its licenses and README
+//	package   information about a package, including its synopsis, licenses,
+//	          imports and README
+//	versions  the versions of the modules that contain the path, newest
+//	          first; the version suffix is not allowed
+//	dependencies
+//	          the requirements of the go.mod file of a module, with links
+//	          to their pages
+//	outline   the outline of the documentation of a package: its sections,
+//	          functions and types, with their anchors
+//
+// READMEs are omitted for units that are not redistributable.
+func (s *Server) serveAPI(w http.ResponseWriter, r *http.Request, ds internal.DataSource) (err error) {
+	defer derrors.Wrap(&err, "serveAPI(%q)", r.URL.Path)
+
+	if r.Method != http.MethodGet {
+		return &serverError{status: http.StatusMethodNotAllowed}
+	}
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/api/v1/"), "/", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return &serverError{status: http.StatusNotFound}
+	}
+	endpoint, urlPath := parts[0], "/"+parts[1]
+	var resp interface{}
+	switch endpoint {
+	case "module", "package", "outline":
+		resp, err = apiUnit(r, ds, endpoint, urlPath)
+	case "versions":
+		resp, err = apiVersions(r, ds, urlPath)
+	case "dependencies":
+		resp, err = apiDependencies(r, ds, urlPath)
+	default:
+		return &serverError{status: http.StatusNotFound}
+	}
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(resp)
+}
+
+// apiUnit returns the response to the module, package or outline endpoint
+// for urlPath.
+func apiUnit(r *http.Request, ds internal.DataSource, endpoint, urlPath string) (interface{}, error) {
+	urlInfo, err := extractURLPathInfo(urlPath)
+	if err != nil {
+		return nil, &serverError{status: http.StatusBadRequest, err: err}
+	}
+	ctx := r.Context()
+	if err := validatePathAndVersion(ctx, ds, urlInfo.fullPath, urlInfo.requestedVersion); err != nil {
+		return nil, err
+	}
+	um, err := ds.GetUnitMeta(ctx, urlInfo.fullPath, urlInfo.modulePath, urlInfo.requestedVersion)
+	if err != nil {
+		if errors.Is(err, derrors.NotFound) {
+			return nil, &serverError{status: http.StatusNotFound, err: err}
+		}
+		return nil, err
+	}
+	switch {
+	case endpoint == "module" && um.Path != um.ModulePath:
+		return nil, &serverError{
+			status:       http.StatusBadRequest,
+			responseText: fmt.Sprintf("%s is not a module", um.Path),
+		}
+	case (endpoint == "package" || endpoint == "outline") && !um.IsPackage():
+		return nil, &serverError{
+			status:       http.StatusBadRequest,
+			responseText: fmt.Sprintf("%s is not a package", um.Path),
+		}
+	}
+	unit, err := ds.GetUnit(ctx, um, internal.WithReadme|internal.WithDocumentation|internal.WithImports)
+	if err != nil {
+		return nil, err
+	}
+	switch endpoint {
+	case "module":
+		return newAPIModule(unit), nil
+	case "outline":
+		return apiOutline(ctx, unit)
+	}
+	return newAPIPackage(unit), nil
+}
+
+// apiOutline returns the outline of the documentation of the package u,
+// rendered from its source.
+func apiOutline(ctx context.Context, u *internal.Unit) (*dochtml.Outline, error) {
+	outline, err := renderOutline(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	if outline == nil {
+		return nil, &serverError{
+			status:       http.StatusNotFound,
+			responseText: fmt.Sprintf("the documentation of %s is not available", u.Path),
+		}
+	}
+	return outline, nil
+}
+
+// apiVersions returns the response to the versions endpoint for urlPath.
+func apiVersions(r *http.Request, ds internal.DataSource, urlPath string) (interface{}, error) {
+	db, ok := ds.(*postgres.DB)
+	if !ok {
+		return nil, proxydatasourceNotSupportedErr()
+	}
+	urlInfo, err := extractURLPathInfo(urlPath)
+	if err != nil {
+		return nil, &serverError{status: http.StatusBadRequest, err: err}
+	}
+	if urlInfo.requestedVersion != internal.LatestVersion {
+		return nil, &serverError{
+			status:       http.StatusBadRequest,
+			responseText: "the versions endpoint does not accept a version",
+		}
+	}
+	infos, err := db.GetVersionsForPath(r.Context(), urlInfo.fullPath)
+	if err != nil {
+		return nil, err
+	}
+	if len(infos) == 0 {
+		return nil, &serverError{status: http.StatusNotFound}
+	}
+	versions := []*apiVersion{}
+	for _, mi := range infos {
+		versions = append(versions, &apiVersion{
+			ModulePath: mi.ModulePath,
+			Version:    mi.Version,
+			CommitTime: mi.CommitTime,
+		})
+	}
+	return versions, nil
+}
+
+// apiDependencies returns the response to the dependencies endpoint for
+// urlPath, which must be the path of a module.
+func apiDependencies(r *http.Request, ds internal.DataSource, urlPath string) (interface{}, error) {
+	urlInfo, err := extractURLPathInfo(urlPath)
+	if err != nil {
+		return nil, &serverError{status: http.StatusBadRequest, err: err}
+	}
+	ctx := r.Context()
+	if err := validatePathAndVersion(ctx, ds, urlInfo.fullPath, urlInfo.requestedVersion); err != nil {
+		return nil, err
+	}
+	um, err := ds.GetUnitMeta(ctx, urlInfo.fullPath, urlInfo.modulePath, urlInfo.requestedVersion)
+	if err != nil {
+		if errors.Is(err, derrors.NotFound) {
+			return nil, &serverError{status: http.StatusNotFound, err: err}
+		}
+		return nil, err
+	}
+	if um.Path != um.ModulePath {
+		return nil, &serverError{
+			status:       http.StatusBadRequest,
+			responseText: fmt.Sprintf("%s is not a module", um.Path),
+		}
+	}
+	deps, err := fetchModuleDependencies(ctx, ds, um.ModulePath, um.Version)
+	if errors.Is(err, derrors.NotFound) {
+		return nil, &serverError{
+			status:       http.StatusNotFound,
+			responseText: fmt.Sprintf("the requirements of %s@%s have not been recorded", um.ModulePath, um.Version),
+			err:          err,
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return deps, nil
+}
+
+func newAPIModule(u *internal.Unit) *apiModule {
+	m := &apiModule{
+		Path:              u.ModulePath,
+		Version:           u.Version,
+		CommitTime:        u.CommitTime,
+		IsRedistributable: u.IsRedistributable,
+		Licenses:          newAPILicenses(u.Licenses),
+		Readme:            newAPIReadme(u),
+	}
+	if u.SourceInfo != nil {
+		m.RepositoryURL = u.SourceInfo.RepoURL()
+	}
+	if u.Deprecation != nil {
+		m.Deprecation = &apiDeprecation{
+			Message:   u.Deprecation.Message,
+			Successor: u.Deprecation.Successor,
+		}
+	}
+	return m
+}
+
+func newAPIPackage(u *internal.Unit) *apiPackage {
+	p := &apiPackage{
+		Path:              u.Path,
+		Name:              u.Name,
+		ModulePath:        u.ModulePath,
+		Version:           u.Version,
+		CommitTime:        u.CommitTime,
+		IsRedistributable: u.IsRedistributable,
+		Licenses:          newAPILicenses(u.Licenses),
+		Imports:           u.Imports,
+		Readme:            newAPIReadme(u),
+	}
+	if p.Imports == nil {
+		p.Imports = []string{}
+	}
+	if u.Documentation != nil {
+		p.Synopsis = u.Documentation.Synopsis
+	}
+	return p
+}
+
+func newAPILicenses(lics []*licenses.Metadata) []*apiLicense {
+	ls := []*apiLicense{}
+	for _, l := range lics {
+		ls = append(ls, &apiLicense{Types: l.Types, FilePath: l.FilePath})
+	}
+	return ls
+}
+
+func newAPIReadme(u *internal.Unit) *apiReadme {
+	if u.Readme == nil || !u.IsRedistributable {
+		return nil
+	}
+	return &apiReadme{FilePath: u.Readme.Filepath, Contents: u.Readme.Contents}
+}
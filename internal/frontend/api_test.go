@@ -0,0 +1,185 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/apierror"
+	"golang.org/x/pkgsite/internal/postgres"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestServeAPI(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer postgres.ResetTestDB(testDB, t)
+
+	for _, v := range []string{"v1.0.0", "v1.1.0"} {
+		m := sample.LegacyModule(sample.ModulePath, v, sample.Suffix)
+		m.Units[1].Imports = []string{"context", "example.com/ext"}
+		m.Requirements = []*internal.ModuleRequirement{
+			{ModulePath: "example.com/ext", Version: "v1.2.0"},
+			{ModulePath: "example.com/ind", Version: "v0.1.0", Indirect: true},
+		}
+		if err := testDB.InsertModule(ctx, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	licenses := []*apiLicense{{Types: []string{"MIT"}, FilePath: "LICENSE"}}
+	readme := &apiReadme{FilePath: sample.ReadmeFilePath, Contents: sample.ReadmeContents}
+	opts := []cmp.Option{
+		cmpopts.IgnoreFields(apiModule{}, "CommitTime"),
+		cmpopts.IgnoreFields(apiPackage{}, "CommitTime"),
+		cmpopts.IgnoreFields(apiVersion{}, "CommitTime"),
+	}
+	_, handler, _ := newTestServer(t, nil)
+	for _, test := range []struct {
+		url        string
+		wantStatus int
+		wantCode   string      // code of the error response
+		got        interface{} // pointer to decode the response into
+		want       interface{}
+	}{
+		{
+			url:        "/api/v1/module/" + sample.ModulePath + "@v1.0.0",
+			wantStatus: http.StatusOK,
+			got:        &apiModule{},
+			want: &apiModule{
+				Path:              sample.ModulePath,
+				Version:           "v1.0.0",
+				RepositoryURL:     sample.RepositoryURL,
+				IsRedistributable: true,
+				Licenses:          licenses,
+				Readme:            readme,
+			},
+		},
+		{
+			url:        "/api/v1/package/" + sample.PackagePath,
+			wantStatus: http.StatusOK,
+			got:        &apiPackage{},
+			want: &apiPackage{
+				Path:              sample.PackagePath,
+				Name:              sample.PackageName,
+				Synopsis:          sample.Synopsis,
+				ModulePath:        sample.ModulePath,
+				Version:           "v1.1.0",
+				IsRedistributable: true,
+				Licenses:          licenses,
+				Imports:           []string{"context", "example.com/ext"},
+			},
+		},
+		{
+			url:        "/api/v1/versions/" + sample.PackagePath,
+			wantStatus: http.StatusOK,
+			got:        &[]*apiVersion{},
+			want: &[]*apiVersion{
+				{ModulePath: sample.ModulePath, Version: "v1.1.0"},
+				{ModulePath: sample.ModulePath, Version: "v1.0.0"},
+			},
+		},
+		{
+			url:        "/api/v1/dependencies/" + sample.ModulePath + "@v1.0.0",
+			wantStatus: http.StatusOK,
+			got:        &[]*Dependency{},
+			want: &[]*Dependency{
+				{ModulePath: "example.com/ext", Version: "v1.2.0", URL: "/example.com/ext@v1.2.0"},
+				{ModulePath: "example.com/ind", Version: "v0.1.0", Indirect: true, URL: "/example.com/ind@v0.1.0"},
+			},
+		},
+		{
+			url:        "/api/v1/dependencies/" + sample.PackagePath,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "bad_request",
+		},
+		{
+			url:        "/api/v1/module/" + sample.PackagePath,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "bad_request",
+		},
+		{
+			url:        "/api/v1/package/" + sample.ModulePath,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "bad_request",
+		},
+		{
+			url:        "/api/v1/versions/" + sample.PackagePath + "@v1.0.0",
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "bad_request",
+		},
+		{
+			url:        "/api/v1/package/example.com/unknown",
+			wantStatus: http.StatusNotFound,
+			wantCode:   "not_found",
+		},
+		{
+			// The sample package has no documentation source.
+			url:        "/api/v1/outline/" + sample.PackagePath,
+			wantStatus: http.StatusNotFound,
+			wantCode:   "not_found",
+		},
+		{
+			url:        "/api/v1/outline/" + sample.ModulePath,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "bad_request",
+		},
+		{
+			url:        "/api/v1/symbols/" + sample.PackagePath,
+			wantStatus: http.StatusNotFound,
+			wantCode:   "not_found",
+		},
+	} {
+		t.Run(test.url, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest("GET", test.url, nil))
+			res := w.Result()
+			if res.StatusCode != test.wantStatus {
+				t.Fatalf("got status %d, want %d", res.StatusCode, test.wantStatus)
+			}
+			if got, want := res.Header.Get("Content-Type"), "application/json"; got != want {
+				t.Errorf("Content-Type = %q, want %q", got, want)
+			}
+			if test.wantStatus != http.StatusOK {
+				var resp apierror.Response
+				if err := json.NewDecoder(res.Body).Decode(&resp); err != nil {
+					t.Fatal(err)
+				}
+				if resp.Code != test.wantCode || resp.Retryable {
+					t.Errorf("got error code %q, retryable %t; want %q, not retryable", resp.Code, resp.Retryable, test.wantCode)
+				}
+				return
+			}
+			if err := json.NewDecoder(res.Body).Decode(test.got); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.want, test.got, opts...); diff != "" {
+				t.Errorf("mismatch (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNewAPIReadme(t *testing.T) {
+	u := &internal.Unit{
+		UnitMeta: internal.UnitMeta{IsRedistributable: false},
+		Readme:   &internal.Readme{Filepath: "README.md", Contents: "secret"},
+	}
+	if got := newAPIReadme(u); got != nil {
+		t.Errorf("newAPIReadme for non-redistributable unit = %+v, want nil", got)
+	}
+	u.IsRedistributable = true
+	want := &apiReadme{FilePath: "README.md", Contents: "secret"}
+	if diff := cmp.Diff(want, newAPIReadme(u)); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/pkgsite/internal/log"
+	"golang.org/x/pkgsite/internal/postgres"
+)
+
+const (
+	// workerLoadMaxAge is how recently a worker must have reported its load
+	// for the report to count. Workers report every 30 seconds.
+	workerLoadMaxAge = 2 * time.Minute
+
+	// backpressureCheckInterval is how long the result of checking the
+	// workers' load is reused, so that a burst of fetch requests does not
+	// query the database for each one.
+	backpressureCheckInterval = 15 * time.Second
+
+	// fetchRetryAfter is the value of the Retry-After header, in seconds, sent
+	// when a fetch is refused because the workers are overloaded.
+	fetchRetryAfter = 60
+)
+
+var backpressure struct {
+	mu         sync.Mutex
+	overloaded bool
+	checked    time.Time
+}
+
+// workersOverloaded reports whether the workers have signaled that they are
+// too busy to take on more fetches. If the signal cannot be read, it reports
+// false, so that fetches are not refused because of a database problem.
+func workersOverloaded(ctx context.Context, db *postgres.DB) bool {
+	backpressure.mu.Lock()
+	if time.Since(backpressure.checked) < backpressureCheckInterval {
+		overloaded := backpressure.overloaded
+		backpressure.mu.Unlock()
+		return overloaded
+	}
+	// Claim the check before querying, without holding the lock, so that
+	// other requests meanwhile use the previous result instead of waiting or
+	// querying too.
+	backpressure.checked = time.Now()
+	backpressure.mu.Unlock()
+
+	overloaded, err := db.WorkersOverloaded(ctx, workerLoadMaxAge)
+	if err != nil {
+		log.Errorf(ctx, "workersOverloaded: %v", err)
+		return false
+	}
+	backpressure.mu.Lock()
+	backpressure.overloaded = overloaded
+	backpressure.mu.Unlock()
+	return overloaded
+}
@@ -8,31 +8,80 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/go-redis/redis/v7"
+	"github.com/golang/groupcache/lru"
+	"golang.org/x/pkgsite/internal"
 	"golang.org/x/pkgsite/internal/complete"
 	"golang.org/x/pkgsite/internal/derrors"
 	"golang.org/x/pkgsite/internal/log"
+	"golang.org/x/pkgsite/internal/postgres"
 )
 
-// handleAutoCompletion handles requests for /autocomplete?q=<input prefix>, by
-// querying redis sorted sets indexing package paths.
-func (s *Server) handleAutoCompletion(w http.ResponseWriter, r *http.Request) {
+const (
+	// defaultCompletions is the number of completions returned for a request
+	// that does not say how many it wants.
+	defaultCompletions = 5
+
+	// maxCompletions is the largest number of completions that a request can
+	// ask for.
+	maxCompletions = 20
+
+	// maxCompletionInputLength is the length of the longest input that is
+	// completed. Nothing is completed for longer inputs.
+	maxCompletionInputLength = 200
+
+	// completionCacheSize is the number of inputs whose completions from the
+	// database are cached in memory.
+	completionCacheSize = 10000
+
+	// completionCacheTTL is how long completions from the database are
+	// cached, so that new packages eventually appear in them.
+	completionCacheTTL = 10 * time.Minute
+)
+
+// serveAutoCompletion handles requests for /autocomplete?q=<input
+// prefix>[&n=<count>], by returning as JSON the n (default 5, at most 20)
+// packages that best complete q. It queries the redis sorted sets indexing
+// package paths if they are available, and otherwise the database, whose
+// results are cached in memory.
+func (s *Server) serveAutoCompletion(w http.ResponseWriter, r *http.Request, ds internal.DataSource) (err error) {
+	defer derrors.Wrap(&err, "serveAutoCompletion(%q)", r.URL.RawQuery)
+
 	ctx := r.Context()
-	var completions []*complete.Completion
-	if s.cmplClient != nil {
-		var err error
-		q := r.FormValue("q")
-		completions, err = doCompletion(r.Context(), s.cmplClient, strings.ToLower(q), 5)
-		if err != nil {
-			code := http.StatusInternalServerError
-			http.Error(w, http.StatusText(code), code)
-			return
+	q := strings.ToLower(strings.TrimSpace(r.FormValue("q")))
+	n := defaultCompletions
+	if v := r.FormValue("n"); v != "" {
+		n, err = strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return &serverError{
+				status:       http.StatusBadRequest,
+				responseText: fmt.Sprintf("invalid number of completions %q", v),
+			}
 		}
+		if n > maxCompletions {
+			n = maxCompletions
+		}
+	}
+	var completions []*complete.Completion
+	switch {
+	case q == "" || len(q) > maxCompletionInputLength:
+		// Nothing to complete.
+	case s.cmplClient != nil:
+		completions, err = doCompletion(ctx, s.cmplClient, q, n)
+	default:
+		completions, err = s.completeFromDB(ctx, ds, q, n)
+	}
+	if err != nil {
+		return err
 	}
 	if completions == nil {
 		// autocomplete.js complains if the JSON returned by this endpoint is null,
@@ -42,12 +91,82 @@ func (s *Server) handleAutoCompletion(w http.ResponseWriter, r *http.Request) {
 	}
 	response, err := json.Marshal(completions)
 	if err != nil {
-		log.Errorf(ctx, "error marshalling completion: json.Marshal: %v", err)
+		return err
 	}
 	w.Header().Set("Content-Type", "application/json")
 	if _, err := io.Copy(w, bytes.NewReader(response)); err != nil {
 		log.Errorf(ctx, "Error copying json buffer to ResponseWriter: %v", err)
 	}
+	return nil
+}
+
+// completeFromDB returns the n packages that best complete q according to
+// the database, using s.completionCache. There are no completions if ds is
+// not a database.
+func (s *Server) completeFromDB(ctx context.Context, ds internal.DataSource, q string, n int) ([]*complete.Completion, error) {
+	db, ok := ds.(*postgres.DB)
+	if !ok {
+		return nil, nil
+	}
+	completions, ok := s.completionCache.get(q)
+	if !ok {
+		// Cache as many completions as any request can ask for, so that
+		// requests for different numbers share the entry.
+		var err error
+		completions, err = db.GetPackageCompletions(ctx, q, maxCompletions)
+		if err != nil {
+			return nil, err
+		}
+		s.completionCache.add(q, completions)
+	}
+	if len(completions) > n {
+		completions = completions[:n]
+	}
+	return completions, nil
+}
+
+// completionCache is an in-memory LRU cache of completions by input. Its
+// entries expire, so that changes to the data they come from are eventually
+// seen. It is safe for concurrent use.
+type completionCache struct {
+	ttl time.Duration
+
+	mu    sync.Mutex
+	cache *lru.Cache
+}
+
+type completionCacheEntry struct {
+	completions []*complete.Completion
+	expires     time.Time
+}
+
+// newCompletionCache returns a completionCache that holds up to size
+// entries for ttl each.
+func newCompletionCache(size int, ttl time.Duration) *completionCache {
+	return &completionCache{ttl: ttl, cache: lru.New(size)}
+}
+
+// get returns the completions of q, if they are cached and have not expired.
+func (c *completionCache) get(q string) ([]*complete.Completion, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	v, ok := c.cache.Get(q)
+	if !ok {
+		return nil, false
+	}
+	e := v.(completionCacheEntry)
+	if !time.Now().Before(e.expires) {
+		c.cache.Remove(q)
+		return nil, false
+	}
+	return e.completions, true
+}
+
+// add caches the completions of q.
+func (c *completionCache) add(q string, completions []*complete.Completion) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cache.Add(q, completionCacheEntry{completions: completions, expires: time.Now().Add(c.ttl)})
 }
 
 // scoredCompletion wraps Completions with a relevancy score, so that they can
@@ -7,6 +7,7 @@ package frontend
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/alicebob/miniredis/v2"
 	"github.com/go-redis/redis/v7"
@@ -93,3 +94,31 @@ func TestNextPrefix(t *testing.T) {
 		}
 	}
 }
+
+func TestCompletionCache(t *testing.T) {
+	cs := []*complete.Completion{{PackagePath: "errors"}}
+
+	c := newCompletionCache(1, time.Hour)
+	if _, ok := c.get("err"); ok {
+		t.Fatal("got completions from an empty cache")
+	}
+	c.add("err", cs)
+	got, ok := c.get("err")
+	if !ok {
+		t.Fatal("cached completions not found")
+	}
+	if diff := cmp.Diff(cs, got); diff != "" {
+		t.Errorf("mismatch (-want +got)\n%s", diff)
+	}
+	// The cache holds a single entry, so adding another evicts the first.
+	c.add("fmt", nil)
+	if _, ok := c.get("err"); ok {
+		t.Error("got completions that should have been evicted")
+	}
+
+	c = newCompletionCache(1, 0)
+	c.add("err", cs)
+	if _, ok := c.get("err"); ok {
+		t.Error("got expired completions")
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/postgres"
+)
+
+// serveDependency reports as JSON whether a module version transitively
+// requires another module, according to the dependency index computed by
+// the worker. It expects paths of the form
+// "/depends/<module-path>[@<version>]?on=<module-path>".
+func (s *Server) serveDependency(w http.ResponseWriter, r *http.Request, ds internal.DataSource) (err error) {
+	defer derrors.Wrap(&err, "serveDependency(%q)", r.URL.Path)
+
+	if r.Method != http.MethodGet {
+		return &serverError{status: http.StatusMethodNotAllowed}
+	}
+	db, ok := ds.(*postgres.DB)
+	if !ok {
+		return proxydatasourceNotSupportedErr()
+	}
+	on := strings.TrimSpace(r.FormValue("on"))
+	if on == "" {
+		return &serverError{
+			status:       http.StatusBadRequest,
+			responseText: `missing "on" query parameter: the path of the dependency`,
+		}
+	}
+	urlInfo, err := extractURLPathInfo(strings.TrimPrefix(r.URL.Path, "/depends"))
+	if err != nil {
+		return &serverError{status: http.StatusBadRequest, err: err}
+	}
+	ctx := r.Context()
+	if err := validatePathAndVersion(ctx, ds, urlInfo.fullPath, urlInfo.requestedVersion); err != nil {
+		return err
+	}
+	um, err := ds.GetUnitMeta(ctx, urlInfo.fullPath, urlInfo.modulePath, urlInfo.requestedVersion)
+	if err != nil {
+		if errors.Is(err, derrors.NotFound) {
+			return &serverError{status: http.StatusNotFound, err: err}
+		}
+		return err
+	}
+	if um.Path != um.ModulePath {
+		return &serverError{
+			status:       http.StatusBadRequest,
+			responseText: fmt.Sprintf("%s is not a module", um.Path),
+		}
+	}
+	dep, err := db.GetModuleDependency(ctx, um.ModulePath, um.Version, on)
+	if err != nil {
+		if errors.Is(err, derrors.NotFound) {
+			return &serverError{
+				status:       http.StatusNotFound,
+				responseText: fmt.Sprintf("the dependencies of %s@%s have not been indexed yet", um.ModulePath, um.Version),
+				err:          err,
+			}
+		}
+		return err
+	}
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(dep)
+}
+
+// DependenciesDetails contains the requirements of the go.mod file of a
+// module version, for the dependencies tab.
+type DependenciesDetails struct {
+	ModulePath string
+	// Direct are the requirements that packages of the module import, and
+	// Indirect the ones marked "// indirect".
+	Direct, Indirect []*Dependency
+	// Unknown reports that the requirements of the module version were not
+	// recorded when it was processed.
+	Unknown bool
+}
+
+// A Dependency is a module required by another, with a link to its page at
+// the required version.
+type Dependency struct {
+	ModulePath string `json:"module_path"`
+	Version    string `json:"version"`
+	Indirect   bool   `json:"indirect"`
+	URL        string `json:"url"`
+}
+
+// fetchModuleDependencies returns the requirements of the module version
+// modulePath@version, with links to them. It returns an error wrapping
+// derrors.NotFound if they are not recorded.
+func fetchModuleDependencies(ctx context.Context, ds internal.DataSource, modulePath, version string) ([]*Dependency, error) {
+	db, ok := ds.(*postgres.DB)
+	if !ok {
+		return nil, proxydatasourceNotSupportedErr()
+	}
+	reqs, err := db.GetModuleRequirements(ctx, modulePath, version)
+	if err != nil {
+		return nil, err
+	}
+	deps := []*Dependency{}
+	for _, r := range reqs {
+		deps = append(deps, &Dependency{
+			ModulePath: r.ModulePath,
+			Version:    r.Version,
+			Indirect:   r.Indirect,
+			URL:        constructPackageURL(r.ModulePath, r.ModulePath, linkVersion(r.Version, r.ModulePath)),
+		})
+	}
+	return deps, nil
+}
+
+// fetchDependenciesDetails returns the requirements of the module of um, for
+// the dependencies tab.
+func fetchDependenciesDetails(ctx context.Context, ds internal.DataSource, um *internal.UnitMeta) (*DependenciesDetails, error) {
+	details := &DependenciesDetails{ModulePath: um.ModulePath}
+	deps, err := fetchModuleDependencies(ctx, ds, um.ModulePath, um.Version)
+	if errors.Is(err, derrors.NotFound) {
+		details.Unknown = true
+		return details, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range deps {
+		if d.Indirect {
+			details.Indirect = append(details.Indirect, d)
+		} else {
+			details.Direct = append(details.Direct, d)
+		}
+	}
+	return details, nil
+}
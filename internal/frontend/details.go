@@ -22,7 +22,9 @@ import (
 	"golang.org/x/pkgsite/internal/derrors"
 	"golang.org/x/pkgsite/internal/experiment"
 	"golang.org/x/pkgsite/internal/log"
+	"golang.org/x/pkgsite/internal/middleware"
 	"golang.org/x/pkgsite/internal/postgres"
+	"golang.org/x/pkgsite/internal/preferences"
 	"golang.org/x/pkgsite/internal/stdlib"
 )
 
@@ -61,6 +63,10 @@ type DetailsPage struct {
 	// For example, if the latest version of /my.module/pkg is version v1.5.2,
 	// the canonical url for that path would be /my.module@v1.5.2/pkg
 	CanonicalURLPath string
+
+	// Feedback is the form for reporting a problem with the documentation.
+	// It is only set on the documentation tab of package pages.
+	Feedback *FeedbackForm
 }
 
 const (
@@ -90,7 +96,10 @@ var (
 // serveDetails handles requests for package/directory/module details pages. It
 // expects paths of the form "[/mod]/<module-path>[@<version>?tab=<tab>]".
 // stdlib module pages are handled at "/std", and requests to "/mod/std" will
-// be redirected to that path.
+// be redirected to that path. Paths of the form
+// "/mod/<module-path>@<version>...<version>" compare two versions of a
+// module; see serveVersionDiff. Shortcuts like "/x/tools", and bare package
+// names with one match, redirect to the unit they name.
 func (s *Server) serveDetails(w http.ResponseWriter, r *http.Request, ds internal.DataSource) (err error) {
 	if r.Method != http.MethodGet {
 		return &serverError{status: http.StatusMethodNotAllowed}
@@ -110,6 +119,16 @@ func (s *Server) serveDetails(w http.ResponseWriter, r *http.Request, ds interna
 		http.Redirect(w, r, "/std", http.StatusMovedPermanently)
 		return nil
 	}
+	if p := shortcutPath(r.URL.Path); p != "" {
+		if r.URL.RawQuery != "" {
+			p += "?" + r.URL.RawQuery
+		}
+		http.Redirect(w, r, p, http.StatusFound)
+		return nil
+	}
+	if isVersionDiffPath(r.URL.Path) {
+		return s.serveVersionDiff(w, r, ds)
+	}
 
 	urlInfo, err := extractURLPathInfo(r.URL.Path)
 	if err != nil {
@@ -118,6 +137,7 @@ func (s *Server) serveDetails(w http.ResponseWriter, r *http.Request, ds interna
 			err:    err,
 		}
 	}
+	ds = dataSourceAfterFetch(r, ds, urlInfo.fullPath)
 	ctx := r.Context()
 	// If page statistics are enabled, use the "exp" query param to adjust
 	// the active experiments.
@@ -129,6 +149,10 @@ func (s *Server) serveDetails(w http.ResponseWriter, r *http.Request, ds interna
 		return err
 	}
 	recordVersionTypeMetric(ctx, urlInfo.requestedVersion)
+	if u := movedModuleURL(ctx, ds, r, urlInfo); u != "" {
+		http.Redirect(w, r, u, http.StatusPermanentRedirect)
+		return nil
+	}
 
 	urlInfo.resolvedVersion = urlInfo.requestedVersion
 	um, err := ds.GetUnitMeta(ctx, urlInfo.fullPath, urlInfo.modulePath, urlInfo.requestedVersion)
@@ -136,6 +160,10 @@ func (s *Server) serveDetails(w http.ResponseWriter, r *http.Request, ds interna
 		if !errors.Is(err, derrors.NotFound) {
 			return err
 		}
+		if p := packagePathWithName(ctx, ds, urlInfo); p != "" {
+			http.Redirect(w, r, "/"+p, http.StatusFound)
+			return nil
+		}
 		return s.servePathNotFoundPage(w, r, ds, urlInfo.fullPath, urlInfo.requestedVersion)
 	}
 	if urlInfo.isModule && um.ModulePath != urlInfo.fullPath {
@@ -144,6 +172,7 @@ func (s *Server) serveDetails(w http.ResponseWriter, r *http.Request, ds interna
 
 	urlInfo.modulePath = um.ModulePath
 	urlInfo.resolvedVersion = um.Version
+	middleware.RecordCachedModule(ctx, um.ModulePath)
 	if urlInfo.requestedVersion == internal.MasterVersion {
 		// Since path@master is a moving target, we don't want it to be stale.
 		// As a result, we enqueue every request of path@master to the frontend
@@ -160,7 +189,7 @@ func (s *Server) serveDetails(w http.ResponseWriter, r *http.Request, ds interna
 			}
 		}()
 	}
-	if experiment.IsActive(ctx, internal.ExperimentUnitPage) {
+	if experiment.IsActive(ctx, internal.ExperimentUnitPage) && !preferences.FromContext(ctx).ClassicLayout {
 		return s.serveUnitPage(ctx, w, r, ds, um, urlInfo.requestedVersion)
 	}
 	return s.serveDetailsPage(w, r, ds, um, urlInfo)
@@ -55,7 +55,7 @@ func (s *Server) serveDirectoryPage(ctx context.Context, w http.ResponseWriter,
 		CommitTime:        um.CommitTime,
 		IsRedistributable: um.IsRedistributable,
 	}
-	header := createDirectoryHeader(um.Path, mi, um.Licenses)
+	header := createDirectoryHeader(ctx, um.Path, mi, um.Licenses)
 	if requestedVersion == internal.LatestVersion {
 		header.URL = constructDirectoryURL(um.Path, um.ModulePath, internal.LatestVersion)
 	}
@@ -108,14 +108,14 @@ func fetchDirectoryDetails(ctx context.Context, ds internal.DataSource, um *inte
 		if !errors.Is(err, derrors.NotFound) {
 			return nil, err
 		}
-		header := createDirectoryHeader(um.Path, mi, um.Licenses)
+		header := createDirectoryHeader(ctx, um.Path, mi, um.Licenses)
 		return &Directory{DirectoryHeader: *header}, nil
 	}
 	nestedModules, err := ds.GetNestedModules(ctx, um.Path)
 	if err != nil {
 		return nil, err
 	}
-	return createDirectory(um.Path, mi, u.Subdirectories, nestedModules, um.Licenses, includeDirPath)
+	return createDirectory(ctx, um.Path, mi, applyHideInternal(ctx, um.Path, u.Subdirectories), nestedModules, um.Licenses, includeDirPath)
 }
 
 // createDirectory constructs a *Directory for the given dirPath.
@@ -127,14 +127,14 @@ func fetchDirectoryDetails(ctx context.Context, ds internal.DataSource, um *inte
 // the module path. However, on the package and directory view's
 // "Subdirectories" tab, we do not want to include packages whose import paths
 // are the same as the dirPath.
-func createDirectory(dirPath string, mi *internal.ModuleInfo, pkgMetas []*internal.PackageMeta, nestedModules []*internal.ModuleInfo,
+func createDirectory(ctx context.Context, dirPath string, mi *internal.ModuleInfo, pkgMetas []*internal.PackageMeta, nestedModules []*internal.ModuleInfo,
 	licmetas []*licenses.Metadata, includeDirPath bool) (_ *Directory, err error) {
 	var packages []*Package
 	for _, pm := range pkgMetas {
 		if !includeDirPath && pm.Path == dirPath {
 			continue
 		}
-		newPkg, err := createPackage(pm, mi, false)
+		newPkg, err := createPackage(ctx, pm, mi, false)
 		if err != nil {
 			return nil, err
 		}
@@ -145,8 +145,8 @@ func createDirectory(dirPath string, mi *internal.ModuleInfo, pkgMetas []*intern
 		}
 		packages = append(packages, newPkg)
 	}
-	sort.Slice(packages, func(i, j int) bool { return packages[i].Path < packages[j].Path })
-	header := createDirectoryHeader(dirPath, mi, licmetas)
+	sort.Slice(packages, func(i, j int) bool { return internal.PathLess(packages[i].Path, packages[j].Path) })
+	header := createDirectoryHeader(ctx, dirPath, mi, licmetas)
 
 	return &Directory{
 		DirectoryHeader: *header,
@@ -155,8 +155,8 @@ func createDirectory(dirPath string, mi *internal.ModuleInfo, pkgMetas []*intern
 	}, nil
 }
 
-func createDirectoryHeader(dirPath string, mi *internal.ModuleInfo, licmetas []*licenses.Metadata) (_ *DirectoryHeader) {
-	mod := createModule(mi, licmetas, false)
+func createDirectoryHeader(ctx context.Context, dirPath string, mi *internal.ModuleInfo, licmetas []*licenses.Metadata) (_ *DirectoryHeader) {
+	mod := createModule(ctx, mi, licmetas, false)
 	return &DirectoryHeader{
 		Module: *mod,
 		Path:   dirPath,
@@ -38,7 +38,7 @@ func TestFetchDirectoryDetails(t *testing.T) {
 				fullPath = path.Join(modulePath, suffix)
 			}
 			pm := sample.PackageMeta(fullPath)
-			pkg, err := createPackage(pm, mi, false)
+			pkg, err := createPackage(ctx, pm, mi, false)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -54,7 +54,7 @@ func TestFetchDirectoryDetails(t *testing.T) {
 			wantPkgs = append(wantPkgs, pkg)
 		}
 
-		mod := createModule(mi, sample.LicenseMetadata, false)
+		mod := createModule(ctx, mi, sample.LicenseMetadata, false)
 		want := &Directory{
 			DirectoryHeader: DirectoryHeader{
 				Module: *mod,
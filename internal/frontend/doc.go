@@ -7,16 +7,23 @@ package frontend
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"net/url"
 	"path"
 	"strings"
 	"time"
 
 	"github.com/google/safehtml"
+	"github.com/google/safehtml/uncheckedconversions"
+	"golang.org/x/mod/semver"
 	"golang.org/x/pkgsite/internal"
 	"golang.org/x/pkgsite/internal/derrors"
 	"golang.org/x/pkgsite/internal/experiment"
 	"golang.org/x/pkgsite/internal/godoc"
+	"golang.org/x/pkgsite/internal/godoc/dochtml"
 	"golang.org/x/pkgsite/internal/log"
+	"golang.org/x/pkgsite/internal/postgres"
+	"golang.org/x/pkgsite/internal/preferences"
 	"golang.org/x/pkgsite/internal/stdlib"
 )
 
@@ -25,6 +32,9 @@ type DocumentationDetails struct {
 	GOOS          string
 	GOARCH        string
 	Documentation safehtml.HTML
+	// Outline is the outline of the documentation. It is only set by
+	// renderOutline.
+	Outline *dochtml.Outline
 }
 
 // fetchDocumentationDetails returns a DocumentationDetails.
@@ -33,8 +43,8 @@ func fetchDocumentationDetails(ctx context.Context, ds internal.DataSource, um *
 	if err != nil {
 		return nil, err
 	}
-	if experiment.IsActive(ctx, internal.ExperimentFrontendRenderDoc) && len(u.Documentation.Source) > 0 {
-		dd, err := renderDoc(ctx, u)
+	if rendersDoc(ctx, u) {
+		dd, err := renderDoc(ctx, u, godoc.HTMLOptions{})
 		if err != nil {
 			log.Errorf(ctx, "render doc failed: %v", err)
 			// Fall through to use stored doc.
@@ -49,51 +59,392 @@ func fetchDocumentationDetails(ctx context.Context, ds internal.DataSource, um *
 	}, nil
 }
 
-func renderDoc(ctx context.Context, u *internal.Unit) (_ *DocumentationDetails, err error) {
+// rendersDoc reports whether the frontend renders the documentation of u
+// from its source, rather than serving the stored HTML.
+func rendersDoc(ctx context.Context, u *internal.Unit) bool {
+	if len(u.Documentation.Source) == 0 {
+		return false
+	}
+	// The worker drops the HTML of documentation that isn't viewed, and
+	// doesn't store it at all with the source-only-doc experiment, but keeps
+	// its source, so that it can be rendered here instead.
+	return experiment.IsActive(ctx, internal.ExperimentFrontendRenderDoc) || u.Documentation.HTML.String() == ""
+}
+
+// allDeclsRequested reports whether r asks for documentation that includes
+// unexported declarations, with the query parameter m=all.
+func allDeclsRequested(r *http.Request) bool {
+	return experiment.IsActive(r.Context(), internal.ExperimentUnexportedDocs) && r.FormValue("m") == "all"
+}
+
+// docHTMLOptions returns the options for rendering the documentation of u
+// on its unit page, with unexported declarations if allDecls is true.
+func (s *Server) docHTMLOptions(ctx context.Context, ds internal.DataSource, u *internal.Unit, allDecls bool) godoc.HTMLOptions {
+	if !rendersDoc(ctx, u) {
+		return godoc.HTMLOptions{}
+	}
+	opts := godoc.HTMLOptions{
+		SinceVersions: sinceVersions(ctx, ds, u),
+		AllDecls:      allDecls,
+		EditorURL:     editorURLFunc(ctx, u),
+	}
+	if s.docSectionLimit > 0 {
+		opts.SectionLimit = s.docSectionLimit
+		opts.SectionURL = docSectionURLFunc(u, allDecls)
+	}
+	return opts
+}
+
+// editorURLFunc returns a function that builds the URL that opens a file of
+// the module of u in the editor preferred by the user, or nil if there is
+// none.
+func editorURLFunc(ctx context.Context, u *internal.Unit) func(file string, line int) safehtml.URL {
+	prefs := preferences.FromContext(ctx)
+	if prefs.Editor == "" || u.ModulePath == stdlib.ModulePath {
+		return nil
+	}
+	return func(file string, line int) safehtml.URL {
+		// The URL template was validated when the preferences were saved:
+		// its scheme is not one that browsers run, like javascript:, and
+		// the values substituted into it are escaped. It only appears on
+		// the pages of the user who chose it.
+		return uncheckedconversions.URLFromStringKnownToSatisfyTypeContract(
+			prefs.EditorLink(u.ModulePath, u.Version, file, line))
+	}
+}
+
+// sinceVersions returns the versions in which the functions, types and
+// methods of u were added, formatted for display, or nil if they are not
+// recorded.
+func sinceVersions(ctx context.Context, ds internal.DataSource, u *internal.Unit) map[string]string {
+	if !experiment.IsActive(ctx, internal.ExperimentSymbolHistory) {
+		return nil
+	}
+	db, ok := ds.(*postgres.DB)
+	if !ok {
+		return nil
+	}
+	history, err := db.GetSymbolHistory(ctx, u.Path, u.ModulePath)
+	if err != nil {
+		log.Errorf(ctx, "sinceVersions: %v", err)
+		return nil
+	}
+	return displaySinceVersions(history, u.ModulePath)
+}
+
+// A BuildContextLink links to the documentation of a unit in one build
+// context.
+type BuildContextLink struct {
+	Name    string // GOOS/GOARCH
+	URL     string
+	Current bool
+}
+
+// The query parameters that select the build context of the documentation
+// on the unit page, as in ?GOOS=windows&GOARCH=amd64.
+const (
+	goosParam   = "GOOS"
+	goarchParam = "GOARCH"
+)
+
+// requestedBuildContext returns the build context given by the GOOS and
+// GOARCH query parameters of r, if the build-context-docs experiment is active
+// and it differs from the one of the documentation of u.
+func requestedBuildContext(r *http.Request, u *internal.Unit) (goos, goarch string, ok bool) {
+	if !experiment.IsActive(r.Context(), internal.ExperimentBuildContextDocs) || u.Documentation == nil {
+		return "", "", false
+	}
+	goos, goarch = r.FormValue(goosParam), r.FormValue(goarchParam)
+	if goos == "" || goarch == "" || (goos == u.Documentation.GOOS && goarch == u.Documentation.GOARCH) {
+		return "", "", false
+	}
+	return goos, goarch, true
+}
+
+// canonicalBuildContextURL returns the URL that a request for u should be
+// redirected to so that its build context query parameters are in canonical
+// form, or the empty string if they already are. In canonical form, the
+// parameters are spelled GOOS and GOARCH, their values are lower case, and
+// they are left out for the default build context of the documentation, or
+// if only one of them is given. The lower-case goos and goarch parameters
+// that the unit page used to link to are accepted too.
+func canonicalBuildContextURL(r *http.Request, u *internal.Unit) string {
+	if !experiment.IsActive(r.Context(), internal.ExperimentBuildContextDocs) {
+		return ""
+	}
+	q := r.URL.Query()
+	get := func(names ...string) string {
+		for _, n := range names {
+			if v := q.Get(n); v != "" {
+				return v
+			}
+		}
+		return ""
+	}
+	goos := strings.ToLower(strings.TrimSpace(get(goosParam, "goos")))
+	goarch := strings.ToLower(strings.TrimSpace(get(goarchParam, "goarch")))
+	var wantGOOS, wantGOARCH string
+	if goos != "" && goarch != "" && u.Documentation != nil &&
+		(goos != u.Documentation.GOOS || goarch != u.Documentation.GOARCH) {
+		wantGOOS, wantGOARCH = goos, goarch
+	}
+	if q.Get("goos") == "" && q.Get("goarch") == "" &&
+		q.Get(goosParam) == wantGOOS && q.Get(goarchParam) == wantGOARCH {
+		return ""
+	}
+	for _, n := range []string{goosParam, goarchParam, "goos", "goarch"} {
+		q.Del(n)
+	}
+	return buildContextURL(r.URL.Path, wantGOOS, wantGOARCH, q)
+}
+
+// buildContextURL returns urlPath with the query parameters in q and those
+// that select the build context given by goos and goarch, which are left out
+// if goos is empty.
+func buildContextURL(urlPath, goos, goarch string, q url.Values) string {
+	v := url.Values{}
+	for k, vs := range q {
+		v[k] = vs
+	}
+	if goos != "" {
+		v.Set(goosParam, goos)
+		v.Set(goarchParam, goarch)
+	}
+	if len(v) == 0 {
+		return urlPath
+	}
+	return urlPath + "?" + v.Encode()
+}
+
+// docBuildContextLinks returns links to the documentation of u in the build
+// contexts in which its text differs, served at urlPath. goos and goarch are
+// the build context of the documentation that is displayed, if it is not the
+// default one.
+func docBuildContextLinks(ctx context.Context, ds internal.DataSource, u *internal.Unit, urlPath, goos, goarch string) []*BuildContextLink {
+	if !experiment.IsActive(ctx, internal.ExperimentBuildContextDocs) {
+		return nil
+	}
+	db, ok := ds.(*postgres.DB)
+	if !ok {
+		return nil
+	}
+	docs, err := db.GetBuildContextDocs(ctx, u.Path, u.ModulePath, u.Version)
+	if err != nil {
+		log.Errorf(ctx, "docBuildContextLinks: %v", err)
+		return nil
+	}
+	return buildContextLinks(docs, u.Documentation.GOOS, u.Documentation.GOARCH, urlPath, goos, goarch)
+}
+
+// buildContextLinks returns links to the documentation in the default build
+// context, given by defaultGOOS and defaultGOARCH, followed by the build
+// contexts of docs whose documentation differs from it, or nil if there are
+// none. The link for goos and goarch, or for the default build context if
+// they are empty, is marked as current.
+func buildContextLinks(docs []*internal.BuildContextDoc, defaultGOOS, defaultGOARCH, urlPath, goos, goarch string) []*BuildContextLink {
+	var defaultHash string
+	for _, d := range docs {
+		if d.GOOS == defaultGOOS && d.GOARCH == defaultGOARCH {
+			defaultHash = d.Hash
+		}
+	}
+	if defaultHash == "" {
+		return nil
+	}
+	if goos == "" && goarch == "" {
+		goos, goarch = defaultGOOS, defaultGOARCH
+	}
+	links := []*BuildContextLink{{
+		Name:    defaultGOOS + "/" + defaultGOARCH,
+		URL:     urlPath,
+		Current: goos == defaultGOOS && goarch == defaultGOARCH,
+	}}
+	for _, d := range docs {
+		if d.Hash == defaultHash {
+			continue
+		}
+		links = append(links, &BuildContextLink{
+			Name:    d.GOOS + "/" + d.GOARCH,
+			URL:     buildContextURL(urlPath, d.GOOS, d.GOARCH, nil),
+			Current: goos == d.GOOS && goarch == d.GOARCH,
+		})
+	}
+	if len(links) == 1 {
+		return nil
+	}
+	return links
+}
+
+// renderBuildContextDoc renders the documentation of u in the build context
+// given by goos and goarch, from the source stored for it. It returns an
+// error with derrors.NotFound in its chain if the documentation in that build
+// context is the same as in the default one, since no source is stored then.
+func renderBuildContextDoc(ctx context.Context, ds internal.DataSource, u *internal.Unit, goos, goarch string) (_ safehtml.HTML, err error) {
+	defer derrors.Wrap(&err, "renderBuildContextDoc(%q, %q)", goos, goarch)
+	db, ok := ds.(*postgres.DB)
+	if !ok {
+		return safehtml.HTML{}, proxydatasourceNotSupportedErr()
+	}
+	src, err := db.GetBuildContextSource(ctx, u.Path, u.ModulePath, u.Version, goos, goarch)
+	if err != nil {
+		return safehtml.HTML{}, err
+	}
+	doc := *u.Documentation
+	doc.GOOS, doc.GOARCH, doc.Source = goos, goarch, src
+	bu := *u
+	bu.Documentation = &doc
+	dd, err := renderDoc(ctx, &bu, godoc.HTMLOptions{})
+	if err != nil {
+		return safehtml.HTML{}, err
+	}
+	return dd.Documentation, nil
+}
+
+// displaySinceVersions returns the entries of history, which maps symbols to
+// the versions in which they were added, that are worth displaying, with
+// the versions formatted for display. As for Go 1.0 in the standard library,
+// the symbols that were added in the earliest version known are left out.
+func displaySinceVersions(history map[string]string, modulePath string) map[string]string {
+	var earliest string
+	for _, v := range history {
+		if earliest == "" || semver.Compare(v, earliest) < 0 {
+			earliest = v
+		}
+	}
+	m := map[string]string{}
+	for name, v := range history {
+		if v != earliest {
+			m[name] = displayVersion(v, modulePath)
+		}
+	}
+	return m
+}
+
+// renderDoc renders the documentation of u from its source, with the given
+// options. Renderings that are the same for every user are cached in
+// renderedDocs.
+func renderDoc(ctx context.Context, u *internal.Unit, opts godoc.HTMLOptions) (_ *DocumentationDetails, err error) {
 	defer derrors.Wrap(&err, "renderDoc")
+	key, cacheable := docCacheKey(ctx, u, opts)
+	if cacheable {
+		if dd, ok := renderedDocs.get(key); ok {
+			return dd, nil
+		}
+	}
 	start := time.Now()
 	docPkg, err := godoc.DecodePackage(u.Documentation.Source)
 	if err != nil {
 		return nil, err
 	}
-	modInfo := &godoc.ModuleInfo{
+	innerPath, modInfo := docRenderArgs(u)
+	html, err := docPkg.RenderHTML(ctx, innerPath, u.SourceInfo, modInfo, opts)
+	if err != nil {
+		return nil, err
+	}
+	log.Infof(ctx, "rendered doc for %s@%s in %s", u.Path, u.Version, time.Since(start))
+	dd := &DocumentationDetails{
+		GOOS:          docPkg.GOOS,
+		GOARCH:        docPkg.GOARCH,
+		Documentation: html,
+	}
+	if cacheable {
+		renderedDocs.add(key, dd)
+	}
+	return dd, nil
+}
+
+// renderOutline returns the outline of the documentation of u, rendered from
+// its source, or nil if u has no documentation source. Outlines are cached in
+// renderedOutlines.
+func renderOutline(ctx context.Context, u *internal.Unit) (_ *dochtml.Outline, err error) {
+	defer derrors.Wrap(&err, "renderOutline")
+	if u.Documentation == nil || len(u.Documentation.Source) == 0 {
+		return nil, nil
+	}
+	key, _ := docCacheKey(ctx, u, godoc.HTMLOptions{})
+	if dd, ok := renderedOutlines.get(key); ok {
+		return dd.Outline, nil
+	}
+	docPkg, err := godoc.DecodePackage(u.Documentation.Source)
+	if err != nil {
+		return nil, err
+	}
+	innerPath, modInfo := docRenderArgs(u)
+	outline, err := docPkg.RenderOutline(ctx, innerPath, u.SourceInfo, modInfo)
+	if err != nil {
+		return nil, err
+	}
+	renderedOutlines.add(key, &DocumentationDetails{
+		GOOS:    docPkg.GOOS,
+		GOARCH:  docPkg.GOARCH,
+		Outline: outline,
+	})
+	return outline, nil
+}
+
+// renderDocSection renders the whole given section of the documentation of u
+// from its source, with the given options.
+func renderDocSection(ctx context.Context, u *internal.Unit, section godoc.DocSection, opts godoc.HTMLOptions) (_ safehtml.HTML, err error) {
+	defer derrors.Wrap(&err, "renderDocSection(%q)", section)
+	docPkg, err := godoc.DecodePackage(u.Documentation.Source)
+	if err != nil {
+		return safehtml.HTML{}, err
+	}
+	innerPath, modInfo := docRenderArgs(u)
+	return docPkg.RenderSection(ctx, innerPath, u.SourceInfo, modInfo, section, opts)
+}
+
+// docRenderArgs returns the path of u within its module and the module
+// information needed to render its documentation.
+func docRenderArgs(u *internal.Unit) (innerPath string, modInfo *godoc.ModuleInfo) {
+	modInfo = &godoc.ModuleInfo{
 		ModulePath:      u.ModulePath,
 		ResolvedVersion: u.Version,
 		ModulePackages:  nil, // will be provided by docPkg
 	}
-	var innerPath string
 	if u.ModulePath == stdlib.ModulePath {
 		innerPath = u.Path
 	} else if u.Path != u.ModulePath {
 		innerPath = u.Path[len(u.ModulePath)+1:]
 	}
-	_, _, html, err := docPkg.Render(ctx, innerPath, u.SourceInfo, modInfo, "", "")
-	if err != nil {
-		return nil, err
+	return innerPath, modInfo
+}
+
+// docSectionURLFunc returns a function that builds the URL at which a whole
+// section of the documentation of u is served, with unexported declarations
+// if allDecls is true.
+func docSectionURLFunc(u *internal.Unit, allDecls bool) func(godoc.DocSection) string {
+	return func(section godoc.DocSection) string {
+		url := fmt.Sprintf("/doc-section/%s@%s?section=%s", u.Path, linkVersion(u.Version, u.ModulePath), section)
+		if allDecls {
+			url += "&m=all"
+		}
+		return url
 	}
-	log.Infof(ctx, "rendered doc for %s@%s in %s", u.Path, u.Version, time.Since(start))
-	return &DocumentationDetails{
-		GOOS:          docPkg.GOOS,
-		GOARCH:        docPkg.GOARCH,
-		Documentation: html,
-	}, nil
 }
 
-// sourceFiles returns the .go files for a package.
-func sourceFiles(u *internal.Unit) ([]*File, error) {
+// sourceFiles returns the .go files for a package, with links to open them
+// in the editor the user prefers, if any.
+func sourceFiles(ctx context.Context, u *internal.Unit) ([]*File, error) {
 	docPkg, err := godoc.DecodePackage(u.Documentation.Source)
 	if err != nil {
 		return nil, err
 	}
+	editorURL := editorURLFunc(ctx, u)
 	var files []*File
 	for _, f := range docPkg.Files {
 		if strings.HasSuffix(f.Name, "_test.go") {
 			continue
 		}
-		files = append(files, &File{
+		file := path.Join(internal.Suffix(u.Path, u.ModulePath), f.Name)
+		sf := &File{
 			Name: f.Name,
-			URL:  u.SourceInfo.FileURL(path.Join(internal.Suffix(u.Path, u.ModulePath), f.Name)),
-		})
+			URL:  u.SourceInfo.FileURL(file),
+		}
+		if editorURL != nil {
+			sf.EditorURL = editorURL(file, 1)
+		}
+		files = append(files, sf)
 	}
 	return files, nil
 }
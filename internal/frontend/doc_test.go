@@ -5,9 +5,15 @@
 package frontend
 
 import (
+	"context"
 	"fmt"
+	"net/http/httptest"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/safehtml/uncheckedconversions"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/experiment"
 	"golang.org/x/pkgsite/internal/stdlib"
 	"golang.org/x/pkgsite/internal/testing/sample"
 )
@@ -42,3 +48,195 @@ func TestFileSource(t *testing.T) {
 		})
 	}
 }
+
+func TestDocSectionURLFunc(t *testing.T) {
+	for _, tc := range []struct {
+		path, modulePath, version, want string
+	}{
+		{
+			path:       sample.ModulePath + "/foo",
+			modulePath: sample.ModulePath,
+			version:    sample.VersionString,
+			want:       fmt.Sprintf("/doc-section/%s/foo@%s?section=types", sample.ModulePath, sample.VersionString),
+		},
+		{
+			path:       "net/http",
+			modulePath: stdlib.ModulePath,
+			version:    "v1.13.0",
+			want:       "/doc-section/net/http@go1.13?section=types",
+		},
+	} {
+		u := &internal.Unit{UnitMeta: internal.UnitMeta{Path: tc.path, ModulePath: tc.modulePath, Version: tc.version}}
+		if got := docSectionURLFunc(u, false)("types"); got != tc.want {
+			t.Errorf("%s@%s: got %q, want %q", tc.path, tc.version, got, tc.want)
+		}
+		if got, want := docSectionURLFunc(u, true)("types"), tc.want+"&m=all"; got != want {
+			t.Errorf("%s@%s, allDecls: got %q, want %q", tc.path, tc.version, got, want)
+		}
+	}
+}
+
+func TestAllDeclsRequested(t *testing.T) {
+	expCtx := experiment.NewContext(context.Background(), internal.ExperimentUnexportedDocs)
+	for _, test := range []struct {
+		ctx  context.Context
+		url  string
+		want bool
+	}{
+		{expCtx, "/a.com/m", false},
+		{expCtx, "/a.com/m?m=all", true},
+		{expCtx, "/a.com/m?m=other", false},
+		{context.Background(), "/a.com/m?m=all", false},
+	} {
+		r := httptest.NewRequest("GET", test.url, nil).WithContext(test.ctx)
+		if got := allDeclsRequested(r); got != test.want {
+			t.Errorf("%s: got %t, want %t", test.url, got, test.want)
+		}
+	}
+}
+
+func TestRendersDoc(t *testing.T) {
+	expCtx := experiment.NewContext(context.Background(), internal.ExperimentFrontendRenderDoc)
+	for _, test := range []struct {
+		name   string
+		ctx    context.Context
+		source []byte
+		html   string
+		want   bool
+	}{
+		{"experiment", expCtx, []byte("src"), "<p>doc</p>", true},
+		{"no source", expCtx, nil, "<p>doc</p>", false},
+		{"stored HTML", context.Background(), []byte("src"), "<p>doc</p>", false},
+		{"pruned HTML", context.Background(), []byte("src"), "", true},
+		{"nothing", context.Background(), nil, "", false},
+	} {
+		u := &internal.Unit{Documentation: &internal.Documentation{
+			Source: test.source,
+			HTML:   uncheckedconversions.HTMLFromStringKnownToSatisfyTypeContract(test.html),
+		}}
+		if got := rendersDoc(test.ctx, u); got != test.want {
+			t.Errorf("%s: got %t, want %t", test.name, got, test.want)
+		}
+	}
+}
+
+func TestDisplaySinceVersions(t *testing.T) {
+	history := map[string]string{
+		"F":   "v1.0.0",
+		"T":   "v1.0.0",
+		"T.M": "v1.2.0",
+		"G":   "v1.10.0",
+	}
+	want := map[string]string{
+		"T.M": "v1.2.0",
+		"G":   "v1.10.0",
+	}
+	if diff := cmp.Diff(want, displaySinceVersions(history, sample.ModulePath)); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	history = map[string]string{"Open": "v1.0.0", "ReadDir": "v1.16.0"}
+	want = map[string]string{"ReadDir": "go1.16"}
+	if diff := cmp.Diff(want, displaySinceVersions(history, stdlib.ModulePath)); diff != "" {
+		t.Errorf("stdlib mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestBuildContextLinks(t *testing.T) {
+	docs := []*internal.BuildContextDoc{
+		{GOOS: "darwin", GOARCH: "amd64", Hash: "h2"},
+		{GOOS: "js", GOARCH: "wasm", Hash: "h1"},
+		{GOOS: "linux", GOARCH: "amd64", Hash: "h1"},
+		{GOOS: "windows", GOARCH: "amd64", Hash: "h3"},
+	}
+	const urlPath = "/a.com/m"
+	for _, test := range []struct {
+		name         string
+		docs         []*internal.BuildContextDoc
+		goos, goarch string
+		want         []*BuildContextLink
+	}{
+		{
+			name: "default",
+			docs: docs,
+			want: []*BuildContextLink{
+				{Name: "linux/amd64", URL: urlPath, Current: true},
+				{Name: "darwin/amd64", URL: urlPath + "?GOARCH=amd64&GOOS=darwin"},
+				{Name: "windows/amd64", URL: urlPath + "?GOARCH=amd64&GOOS=windows"},
+			},
+		},
+		{
+			name:   "other",
+			docs:   docs,
+			goos:   "darwin",
+			goarch: "amd64",
+			want: []*BuildContextLink{
+				{Name: "linux/amd64", URL: urlPath},
+				{Name: "darwin/amd64", URL: urlPath + "?GOARCH=amd64&GOOS=darwin", Current: true},
+				{Name: "windows/amd64", URL: urlPath + "?GOARCH=amd64&GOOS=windows"},
+			},
+		},
+		{
+			name: "same",
+			docs: docs[1:3],
+			want: nil,
+		},
+		{
+			name: "none",
+			docs: nil,
+			want: nil,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got := buildContextLinks(test.docs, "linux", "amd64", urlPath, test.goos, test.goarch)
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRequestedBuildContext(t *testing.T) {
+	expCtx := experiment.NewContext(context.Background(), internal.ExperimentBuildContextDocs)
+	u := &internal.Unit{Documentation: &internal.Documentation{GOOS: "linux", GOARCH: "amd64"}}
+	for _, test := range []struct {
+		ctx    context.Context
+		url    string
+		wantOK bool
+	}{
+		{expCtx, "/a.com/m", false},
+		{expCtx, "/a.com/m?GOOS=darwin&GOARCH=amd64", true},
+		{expCtx, "/a.com/m?GOOS=linux&GOARCH=amd64", false},
+		{expCtx, "/a.com/m?GOOS=darwin", false},
+		{context.Background(), "/a.com/m?GOOS=darwin&GOARCH=amd64", false},
+	} {
+		r := httptest.NewRequest("GET", test.url, nil).WithContext(test.ctx)
+		if _, _, ok := requestedBuildContext(r, u); ok != test.wantOK {
+			t.Errorf("%s: got %t, want %t", test.url, ok, test.wantOK)
+		}
+	}
+}
+
+func TestCanonicalBuildContextURL(t *testing.T) {
+	expCtx := experiment.NewContext(context.Background(), internal.ExperimentBuildContextDocs)
+	u := &internal.Unit{Documentation: &internal.Documentation{GOOS: "linux", GOARCH: "amd64"}}
+	for _, test := range []struct {
+		ctx  context.Context
+		url  string
+		want string
+	}{
+		{expCtx, "/a.com/m", ""},
+		{expCtx, "/a.com/m?tab=imports", ""},
+		{expCtx, "/a.com/m?GOOS=windows&GOARCH=amd64", ""},
+		{expCtx, "/a.com/m?goos=windows&goarch=amd64", "/a.com/m?GOARCH=amd64&GOOS=windows"},
+		{expCtx, "/a.com/m?GOOS=Windows&GOARCH=AMD64&tab=imports", "/a.com/m?GOARCH=amd64&GOOS=windows&tab=imports"},
+		{expCtx, "/a.com/m?GOOS=linux&GOARCH=amd64", "/a.com/m"},
+		{expCtx, "/a.com/m?GOOS=windows&m=all", "/a.com/m?m=all"},
+		{context.Background(), "/a.com/m?goos=windows&goarch=amd64", ""},
+	} {
+		r := httptest.NewRequest("GET", test.url, nil).WithContext(test.ctx)
+		if got := canonicalBuildContextURL(r, u); got != test.want {
+			t.Errorf("%s: got %q, want %q", test.url, got, test.want)
+		}
+	}
+}
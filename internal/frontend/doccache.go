@@ -0,0 +1,100 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/groupcache/lru"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/experiment"
+	"golang.org/x/pkgsite/internal/godoc"
+)
+
+const (
+	// docCacheSize is the number of renderings of documentation that are
+	// cached in memory.
+	docCacheSize = 500
+
+	// docCacheTTL is how long renderings of documentation are cached. The
+	// source of the documentation of a module version never changes, but
+	// the versions in which its symbols were added can.
+	docCacheTTL = time.Hour
+)
+
+// renderedDocs caches the documentation rendered by renderDoc.
+var renderedDocs = newDocCache(docCacheSize, docCacheTTL)
+
+// renderedOutlines caches the outlines rendered by renderOutline.
+var renderedOutlines = newDocCache(docCacheSize, docCacheTTL)
+
+// docCache is an in-memory LRU cache of documentation rendered from its
+// source. Its entries expire, so that changes to the data used to render
+// them are eventually seen. It is safe for concurrent use.
+type docCache struct {
+	ttl time.Duration
+
+	mu    sync.Mutex
+	cache *lru.Cache
+}
+
+type docCacheEntry struct {
+	dd      *DocumentationDetails
+	expires time.Time
+}
+
+// newDocCache returns a docCache that holds up to size entries for ttl each.
+func newDocCache(size int, ttl time.Duration) *docCache {
+	return &docCache{ttl: ttl, cache: lru.New(size)}
+}
+
+// get returns the documentation cached under key, if it has not expired.
+func (c *docCache) get(key string) (*DocumentationDetails, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	v, ok := c.cache.Get(key)
+	if !ok {
+		return nil, false
+	}
+	e := v.(docCacheEntry)
+	if !time.Now().Before(e.expires) {
+		c.cache.Remove(key)
+		return nil, false
+	}
+	return e.dd, true
+}
+
+// add caches dd under key.
+func (c *docCache) add(key string, dd *DocumentationDetails) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cache.Add(key, docCacheEntry{dd: dd, expires: time.Now().Add(c.ttl)})
+}
+
+// docCacheKey returns the key under which the documentation of u rendered
+// with opts is cached. It reports false if the rendering must not be cached,
+// because it differs for each user.
+//
+// The key covers everything the rendering depends on: the unit and its
+// build context, the options, and the active experiments, some of which
+// change how documentation is rendered.
+func docCacheKey(ctx context.Context, u *internal.Unit, opts godoc.HTMLOptions) (string, bool) {
+	if opts.EditorURL != nil {
+		return "", false
+	}
+	exps := experiment.FromContext(ctx).Active()
+	sort.Strings(exps)
+	// fmt prints maps sorted by key.
+	k := fmt.Sprintf("%s@%s %s/%s all=%t limit=%d since=%v exps=%s",
+		u.Path, u.Version, u.Documentation.GOOS, u.Documentation.GOARCH,
+		opts.AllDecls, opts.SectionLimit, opts.SinceVersions, strings.Join(exps, ","))
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(k))), true
+}
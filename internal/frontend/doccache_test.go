@@ -0,0 +1,85 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/safehtml"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/experiment"
+	"golang.org/x/pkgsite/internal/godoc"
+)
+
+func TestDocCacheKey(t *testing.T) {
+	ctx := context.Background()
+	u := &internal.Unit{
+		UnitMeta: internal.UnitMeta{
+			Path:       "m.com/p",
+			ModulePath: "m.com",
+			Version:    "v1.0.0",
+		},
+		Documentation: &internal.Documentation{GOOS: "linux", GOARCH: "amd64"},
+	}
+	key := func(ctx context.Context, u *internal.Unit, opts godoc.HTMLOptions) string {
+		t.Helper()
+		k, ok := docCacheKey(ctx, u, opts)
+		if !ok {
+			t.Fatalf("docCacheKey(%+v): not cacheable", opts)
+		}
+		return k
+	}
+
+	base := key(ctx, u, godoc.HTMLOptions{})
+	if got := key(ctx, u, godoc.HTMLOptions{}); got != base {
+		t.Errorf("keys for the same rendering differ: %q, %q", base, got)
+	}
+
+	windows := *u
+	windows.Documentation = &internal.Documentation{GOOS: "windows", GOARCH: "amd64"}
+	for name, k := range map[string]string{
+		"version":       key(ctx, &internal.Unit{UnitMeta: internal.UnitMeta{Path: "m.com/p", Version: "v1.1.0"}, Documentation: u.Documentation}, godoc.HTMLOptions{}),
+		"build context": key(ctx, &windows, godoc.HTMLOptions{}),
+		"all decls":     key(ctx, u, godoc.HTMLOptions{AllDecls: true}),
+		"section limit": key(ctx, u, godoc.HTMLOptions{SectionLimit: 10}),
+		"since":         key(ctx, u, godoc.HTMLOptions{SinceVersions: map[string]string{"F": "v1.0.0"}}),
+		"experiments":   key(experiment.NewContext(ctx, internal.ExperimentCollapseDeprecated), u, godoc.HTMLOptions{}),
+	} {
+		if k == base {
+			t.Errorf("%s: got the same key as the default rendering", name)
+		}
+	}
+
+	editor := godoc.HTMLOptions{EditorURL: func(string, int) safehtml.URL { return safehtml.URL{} }}
+	if _, ok := docCacheKey(ctx, u, editor); ok {
+		t.Error("rendering with an editor URL is cacheable")
+	}
+}
+
+func TestDocCache(t *testing.T) {
+	dd := &DocumentationDetails{GOOS: "linux", GOARCH: "amd64"}
+
+	c := newDocCache(1, time.Hour)
+	if _, ok := c.get("a"); ok {
+		t.Fatal("got documentation from an empty cache")
+	}
+	c.add("a", dd)
+	if got, ok := c.get("a"); !ok || got != dd {
+		t.Fatalf("got %v, %t; want the cached documentation", got, ok)
+	}
+	// The cache holds a single entry, so adding another evicts the first.
+	c.add("b", dd)
+	if _, ok := c.get("a"); ok {
+		t.Error("got documentation that should have been evicted")
+	}
+
+	c = newDocCache(1, 0)
+	c.add("a", dd)
+	if _, ok := c.get("a"); ok {
+		t.Error("got expired documentation")
+	}
+}
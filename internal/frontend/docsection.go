@@ -0,0 +1,85 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/godoc"
+	"golang.org/x/pkgsite/internal/middleware"
+)
+
+// serveDocSection serves the HTML of a whole section of the documentation of
+// a package, which the unit page truncates when it holds more than
+// docSectionLimit declarations. It expects paths of the form
+// "/doc-section/<path>[@<version>]?section=<section>", where section is one of
+// "constants", "variables", "functions" and "types".
+func (s *Server) serveDocSection(w http.ResponseWriter, r *http.Request, ds internal.DataSource) (err error) {
+	defer derrors.Wrap(&err, "serveDocSection(%q)", r.URL.Path)
+
+	if r.Method != http.MethodGet {
+		return &serverError{status: http.StatusMethodNotAllowed}
+	}
+	section := godoc.DocSection(r.FormValue("section"))
+	if !isDocSection(section) {
+		return &serverError{
+			status:       http.StatusBadRequest,
+			responseText: fmt.Sprintf("unknown documentation section %q", section),
+		}
+	}
+	urlInfo, err := extractURLPathInfo(strings.TrimPrefix(r.URL.Path, "/doc-section"))
+	if err != nil {
+		return &serverError{status: http.StatusBadRequest, err: err}
+	}
+	ctx := r.Context()
+	if err := validatePathAndVersion(ctx, ds, urlInfo.fullPath, urlInfo.requestedVersion); err != nil {
+		return err
+	}
+	um, err := ds.GetUnitMeta(ctx, urlInfo.fullPath, urlInfo.modulePath, urlInfo.requestedVersion)
+	if err != nil {
+		if errors.Is(err, derrors.NotFound) {
+			return &serverError{status: http.StatusNotFound, err: err}
+		}
+		return err
+	}
+	middleware.RecordCachedModule(ctx, um.ModulePath)
+	u, err := ds.GetUnit(ctx, um, internal.WithDocumentation)
+	if err != nil {
+		return err
+	}
+	if !u.IsRedistributable || u.Documentation == nil || len(u.Documentation.Source) == 0 {
+		return &serverError{
+			status:       http.StatusNotFound,
+			responseText: fmt.Sprintf("no documentation source for %s@%s", u.Path, u.Version),
+		}
+	}
+	opts := godoc.HTMLOptions{
+		SinceVersions: sinceVersions(ctx, ds, u),
+		AllDecls:      allDeclsRequested(r),
+		EditorURL:     editorURLFunc(ctx, u),
+	}
+	html, err := renderDocSection(ctx, u, section, opts)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, err = io.WriteString(w, html.String())
+	return err
+}
+
+func isDocSection(section godoc.DocSection) bool {
+	for _, s := range godoc.DocSections {
+		if s == section {
+			return true
+		}
+	}
+	return false
+}
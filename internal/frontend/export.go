@@ -0,0 +1,271 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/log"
+	"golang.org/x/pkgsite/internal/postgres"
+)
+
+// exportFlushInterval is the number of rows written between flushes of the
+// response, so that clients see data as it is read from the database.
+const exportFlushInterval = 100
+
+// serveExport serves the full contents of the imports or imported-by tab of
+// a package as CSV or JSON. It expects paths of the form
+// "/export/<tab>/<path>[@<version>]?format=<csv|json>", where <tab> is
+// "imports" or "importedby" and <path> is formed as for details pages.
+func (s *Server) serveExport(w http.ResponseWriter, r *http.Request, ds internal.DataSource) (err error) {
+	defer derrors.Wrap(&err, "serveExport(%q)", r.URL.Path)
+
+	if r.Method != http.MethodGet {
+		return &serverError{status: http.StatusMethodNotAllowed}
+	}
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/export/"), "/", 2)
+	if len(parts) != 2 || (parts[0] != "imports" && parts[0] != "importedby") {
+		return &serverError{status: http.StatusNotFound}
+	}
+	tab := parts[0]
+	format := r.FormValue("format")
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "json" {
+		return &serverError{
+			status:       http.StatusBadRequest,
+			responseText: fmt.Sprintf("unsupported format %q", format),
+		}
+	}
+	urlInfo, err := extractURLPathInfo("/" + parts[1])
+	if err != nil {
+		return &serverError{status: http.StatusBadRequest, err: err}
+	}
+	ctx := r.Context()
+	if err := validatePathAndVersion(ctx, ds, urlInfo.fullPath, urlInfo.requestedVersion); err != nil {
+		return err
+	}
+	um, err := ds.GetUnitMeta(ctx, urlInfo.fullPath, urlInfo.modulePath, urlInfo.requestedVersion)
+	if err != nil {
+		if errors.Is(err, derrors.NotFound) {
+			return &serverError{status: http.StatusNotFound, err: err}
+		}
+		return err
+	}
+	if !um.IsPackage() {
+		return &serverError{
+			status:       http.StatusBadRequest,
+			responseText: fmt.Sprintf("%s is not a package", um.Path),
+		}
+	}
+
+	var (
+		columns []string
+		rows    func(emit func(row ...string) error) error
+	)
+	switch tab {
+	case "imports":
+		details, err := fetchImportsDetails(ctx, ds, um.Path, um.ModulePath, um.Version)
+		if err != nil {
+			return err
+		}
+		columns = []string{"path", "kind"}
+		rows = func(emit func(...string) error) error {
+			for _, group := range []struct {
+				kind  string
+				paths []string
+			}{
+				{"std", details.StdLib},
+				{"module", details.InternalImports},
+				{"external", details.ExternalImports},
+			} {
+				for _, p := range group.paths {
+					if err := emit(p, group.kind); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		}
+	case "importedby":
+		db, ok := ds.(*postgres.DB)
+		if !ok {
+			return proxydatasourceNotSupportedErr()
+		}
+		columns = []string{"path"}
+		rows = func(emit func(...string) error) error {
+			return db.StreamImportedBy(ctx, um.Path, um.ModulePath, func(p string) error {
+				return emit(p)
+			})
+		}
+	}
+
+	filename := fmt.Sprintf("%s-%s.%s", strings.ReplaceAll(um.Path, "/", "_"), tab, format)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	} else {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	return writeExport(ctx, w, format, columns, rows)
+}
+
+// writeExport writes the rows of an export to w in the given format. If
+// reading or writing the rows fails before anything has been sent, it
+// returns the error, so that an error page can be served instead. After
+// that, it aborts the response, so that the client doesn't mistake the
+// truncated export for a complete one.
+func writeExport(ctx context.Context, w http.ResponseWriter, format string, columns []string, rows func(emit func(row ...string) error) error) error {
+	cw := &countingWriter{w: w}
+	var ew exportWriter
+	if format == "csv" {
+		ew = newCSVExportWriter(cw, columns)
+	} else {
+		ew = newJSONExportWriter(cw, columns)
+	}
+	flusher, _ := w.(http.Flusher)
+	n := 0
+	err := rows(func(row ...string) error {
+		if err := ew.write(row); err != nil {
+			return err
+		}
+		n++
+		if n%exportFlushInterval == 0 {
+			if err := ew.flush(); err != nil {
+				return err
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		return nil
+	})
+	if err == nil {
+		err = ew.close()
+	}
+	if err == nil {
+		return nil
+	}
+	if cw.n == 0 {
+		w.Header().Del("Content-Disposition")
+		w.Header().Del("Content-Type")
+		return err
+	}
+	// The response has already started, so we cannot serve an error page.
+	log.Errorf(ctx, "writeExport: wrote %d rows: %v", n, err)
+	panic(http.ErrAbortHandler)
+}
+
+// countingWriter is an io.Writer that counts the bytes written to w.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(b []byte) (int, error) {
+	n, err := c.w.Write(b)
+	c.n += int64(n)
+	return n, err
+}
+
+// An exportWriter writes rows of an export to a response.
+type exportWriter interface {
+	write(row []string) error
+	flush() error
+	close() error
+}
+
+type csvExportWriter struct {
+	w *csv.Writer
+}
+
+func newCSVExportWriter(w io.Writer, columns []string) *csvExportWriter {
+	cw := csv.NewWriter(w)
+	// Errors are reported by flush.
+	_ = cw.Write(columns)
+	return &csvExportWriter{w: cw}
+}
+
+func (c *csvExportWriter) write(row []string) error {
+	return c.w.Write(row)
+}
+
+func (c *csvExportWriter) flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvExportWriter) close() error {
+	return c.flush()
+}
+
+// jsonExportWriter writes a JSON array of objects, one per row, whose keys
+// are the column names.
+type jsonExportWriter struct {
+	w       *bufio.Writer
+	columns []string
+	n       int
+	err     error
+}
+
+func newJSONExportWriter(w io.Writer, columns []string) *jsonExportWriter {
+	bw := bufio.NewWriter(w)
+	// Errors are reported by the first write or flush.
+	_, err := bw.WriteString("[")
+	return &jsonExportWriter{w: bw, columns: columns, err: err}
+}
+
+func (j *jsonExportWriter) write(row []string) error {
+	if j.err != nil {
+		return j.err
+	}
+	obj := map[string]string{}
+	for i, c := range j.columns {
+		obj[c] = row[i]
+	}
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	sep := ",\n"
+	if j.n == 0 {
+		sep = "\n"
+	}
+	j.n++
+	if _, j.err = j.w.WriteString(sep); j.err != nil {
+		return j.err
+	}
+	_, j.err = j.w.Write(data)
+	return j.err
+}
+
+func (j *jsonExportWriter) flush() error {
+	if j.err != nil {
+		return j.err
+	}
+	j.err = j.w.Flush()
+	return j.err
+}
+
+func (j *jsonExportWriter) close() error {
+	if j.err != nil {
+		return j.err
+	}
+	if _, j.err = j.w.WriteString("\n]\n"); j.err != nil {
+		return j.err
+	}
+	return j.flush()
+}
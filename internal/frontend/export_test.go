@@ -0,0 +1,122 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal/postgres"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestServeExport(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer postgres.ResetTestDB(testDB, t)
+
+	m := sample.LegacyModule(sample.ModulePath, sample.VersionString, sample.Suffix)
+	m.Units[1].Imports = []string{"context", sample.ModulePath + "/other", "example.com/ext"}
+	if err := testDB.InsertModule(ctx, m); err != nil {
+		t.Fatal(err)
+	}
+	importer := sample.LegacyModule("example.com/importer", "v1.0.0", "a")
+	importer.Units[1].Imports = []string{sample.PackagePath}
+	if err := testDB.InsertModule(ctx, importer); err != nil {
+		t.Fatal(err)
+	}
+
+	_, handler, _ := newTestServer(t, nil)
+	for _, test := range []struct {
+		url        string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			url:        "/export/imports/" + sample.PackagePath + "@" + sample.VersionString,
+			wantStatus: http.StatusOK,
+			wantBody: "path,kind\n" +
+				"context,std\n" +
+				sample.ModulePath + "/other,module\n" +
+				"example.com/ext,external\n",
+		},
+		{
+			url:        "/export/importedby/" + sample.PackagePath + "?format=json",
+			wantStatus: http.StatusOK,
+			wantBody:   "[\n" + `{"path":"example.com/importer/a"}` + "\n]\n",
+		},
+		{
+			url:        "/export/imports/" + sample.PackagePath + "?format=xml",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			url:        "/export/licenses/" + sample.PackagePath,
+			wantStatus: http.StatusNotFound,
+		},
+	} {
+		t.Run(test.url, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest("GET", test.url, nil))
+			res := w.Result()
+			if res.StatusCode != test.wantStatus {
+				t.Fatalf("got status %d, want %d", res.StatusCode, test.wantStatus)
+			}
+			if test.wantStatus != http.StatusOK {
+				return
+			}
+			body, err := ioutil.ReadAll(res.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.wantBody, string(body)); diff != "" {
+				t.Errorf("mismatch (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestWriteExportError(t *testing.T) {
+	errRows := errors.New("rows failed")
+	// failAfter returns rows that emit n rows and then fail.
+	failAfter := func(n int) func(func(...string) error) error {
+		return func(emit func(...string) error) error {
+			for i := 0; i < n; i++ {
+				if err := emit(fmt.Sprintf("example.com/importer%d", i)); err != nil {
+					return err
+				}
+			}
+			return errRows
+		}
+	}
+	for _, format := range []string{"csv", "json"} {
+		t.Run(format, func(t *testing.T) {
+			// Nothing has been sent, so the error is returned.
+			w := httptest.NewRecorder()
+			err := writeExport(context.Background(), w, format, []string{"path"}, failAfter(1))
+			if !errors.Is(err, errRows) {
+				t.Errorf("got error %v, want %v", err, errRows)
+			}
+			if w.Body.Len() != 0 {
+				t.Errorf("wrote %q, want nothing", w.Body.String())
+			}
+
+			// Rows have been flushed, so the response is aborted.
+			defer func() {
+				if e := recover(); e != http.ErrAbortHandler {
+					t.Errorf("recovered %v, want http.ErrAbortHandler", e)
+				}
+			}()
+			w = httptest.NewRecorder()
+			writeExport(context.Background(), w, format, []string{"path"}, failAfter(2*exportFlushInterval))
+			t.Error("writeExport returned, want panic")
+		})
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/mod/module"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/middleware"
+	"golang.org/x/pkgsite/internal/postgres"
+	"golang.org/x/pkgsite/internal/stdlib"
+)
+
+// maxFeedEntries is the number of versions in the feed of a module.
+const maxFeedEntries = 20
+
+// atomFeed is an Atom feed, as described in RFC 4287.
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Links   []atomLink  `xml:"link"`
+	Updated string      `xml:"updated"`
+	Author  atomAuthor  `xml:"author"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomEntry struct {
+	Title     string   `xml:"title"`
+	ID        string   `xml:"id"`
+	Link      atomLink `xml:"link"`
+	Published string   `xml:"published"`
+	Updated   string   `xml:"updated"`
+	Summary   string   `xml:"summary,omitempty"`
+}
+
+// serveFeed serves an Atom feed of the versions of a module that were
+// indexed most recently, for requests to /feed/<path>. The path may be that
+// of any unit in the module. Each entry links to the module version and has
+// its commit time and the synopsis of the package at the module root.
+func (s *Server) serveFeed(w http.ResponseWriter, r *http.Request, ds internal.DataSource) (err error) {
+	defer derrors.Wrap(&err, "serveFeed(%q)", r.URL.Path)
+
+	db, ok := ds.(*postgres.DB)
+	if !ok {
+		return proxydatasourceNotSupportedErr()
+	}
+	ctx := r.Context()
+	path := strings.TrimPrefix(r.URL.Path, "/feed/")
+	if path != stdlib.ModulePath && module.CheckImportPath(path) != nil {
+		return &serverError{
+			status:       http.StatusBadRequest,
+			responseText: fmt.Sprintf("invalid path %q", path),
+		}
+	}
+	um, err := ds.GetUnitMeta(ctx, path, internal.UnknownModulePath, internal.LatestVersion)
+	if err != nil {
+		if errors.Is(err, derrors.NotFound) {
+			return &serverError{status: http.StatusNotFound, err: err}
+		}
+		return err
+	}
+	middleware.RecordCachedModule(ctx, um.ModulePath)
+	entries, err := db.GetModuleFeed(ctx, um.ModulePath, maxFeedEntries)
+	if err != nil {
+		return err
+	}
+	feed := newAtomFeed("https://"+r.Host, um, entries)
+	w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+	return writeAtomFeed(w, feed)
+}
+
+// newAtomFeed returns the feed of the versions in entries of the module of
+// um, with links to the site at siteURL.
+func newAtomFeed(siteURL string, um *internal.UnitMeta, entries []*postgres.FeedEntry) *atomFeed {
+	modulePath := um.ModulePath
+	// A feed without entries is as new as the latest version of the module.
+	updated := um.CommitTime
+	if len(entries) > 0 {
+		updated = entries[0].IndexedAt
+	}
+	feed := &atomFeed{
+		Title: "New versions of " + modulePath,
+		ID:    siteURL + "/feed/" + modulePath,
+		Links: []atomLink{
+			{Rel: "self", Href: siteURL + "/feed/" + modulePath},
+			{Rel: "alternate", Href: siteURL + "/" + modulePath + "?tab=versions"},
+		},
+		Updated: atomTime(updated),
+		Author:  atomAuthor{Name: siteURL},
+	}
+	for _, e := range entries {
+		url := siteURL + constructPackageURL(e.ModulePath, e.ModulePath, linkVersion(e.Version, e.ModulePath))
+		feed.Entries = append(feed.Entries, atomEntry{
+			Title:     e.ModulePath + " " + displayVersion(e.Version, e.ModulePath),
+			ID:        url,
+			Link:      atomLink{Href: url},
+			Published: atomTime(e.CommitTime),
+			Updated:   atomTime(e.IndexedAt),
+			Summary:   e.Synopsis,
+		})
+	}
+	return feed
+}
+
+// atomTime formats t as an Atom date, in UTC.
+func atomTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// writeAtomFeed writes feed to w as an XML document.
+func writeAtomFeed(w io.Writer, feed *atomFeed) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(feed); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,58 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/postgres"
+)
+
+func TestAtomFeed(t *testing.T) {
+	commit := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+	indexed := time.Date(2020, 10, 2, 8, 30, 0, 0, time.FixedZone("EST", -5*3600))
+	um := &internal.UnitMeta{Path: "example.com/mod/pkg", ModulePath: "example.com/mod", CommitTime: commit}
+	entries := []*postgres.FeedEntry{
+		{ModulePath: "example.com/mod", Version: "v1.2.0", CommitTime: commit, IndexedAt: indexed, Synopsis: "Package mod <does> things."},
+	}
+
+	var b strings.Builder
+	if err := writeAtomFeed(&b, newAtomFeed("https://pkg.go.dev", um, entries)); err != nil {
+		t.Fatal(err)
+	}
+	want := `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>New versions of example.com/mod</title>
+  <id>https://pkg.go.dev/feed/example.com/mod</id>
+  <link rel="self" href="https://pkg.go.dev/feed/example.com/mod"></link>
+  <link rel="alternate" href="https://pkg.go.dev/example.com/mod?tab=versions"></link>
+  <updated>2020-10-02T13:30:00Z</updated>
+  <author>
+    <name>https://pkg.go.dev</name>
+  </author>
+  <entry>
+    <title>example.com/mod v1.2.0</title>
+    <id>https://pkg.go.dev/example.com/mod@v1.2.0</id>
+    <link href="https://pkg.go.dev/example.com/mod@v1.2.0"></link>
+    <published>2020-10-01T12:00:00Z</published>
+    <updated>2020-10-02T13:30:00Z</updated>
+    <summary>Package mod &lt;does&gt; things.</summary>
+  </entry>
+</feed>
+`
+	if diff := cmp.Diff(want, b.String()); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+
+	// A module without release versions has an empty feed.
+	feed := newAtomFeed("https://pkg.go.dev", um, nil)
+	if len(feed.Entries) != 0 || feed.Updated != "2020-10-01T12:00:00Z" {
+		t.Errorf("empty feed: got %d entries, updated %s", len(feed.Entries), feed.Updated)
+	}
+}
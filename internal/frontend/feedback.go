@@ -0,0 +1,132 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/log"
+	"golang.org/x/pkgsite/internal/postgres"
+)
+
+// Limits on the fields of a feedback report.
+const (
+	maxFeedbackTextLength = 2000
+	maxFeedbackPathLength = 500
+)
+
+// FeedbackForm contains the data for the form with which users report a
+// problem with a documentation page.
+type FeedbackForm struct {
+	Path    string
+	Version string
+	// URL is the path of the page, which the user is sent back to after
+	// the report is stored.
+	URL string
+	// TraceID is the ID of the trace of the request that rendered the page.
+	TraceID    string
+	Categories []internal.FeedbackCategory
+}
+
+// newFeedbackForm returns the feedback form for the page at urlPath, which
+// is about the package path at version.
+func newFeedbackForm(ctx context.Context, path, version, urlPath string) *FeedbackForm {
+	return &FeedbackForm{
+		Path:       path,
+		Version:    version,
+		URL:        urlPath,
+		TraceID:    log.TraceID(ctx),
+		Categories: internal.FeedbackCategories,
+	}
+}
+
+// serveFeedback stores a problem report sent with the feedback form, and
+// redirects back to the page it is about. It handles POST requests to
+// /feedback.
+func (s *Server) serveFeedback(w http.ResponseWriter, r *http.Request, ds internal.DataSource) (err error) {
+	defer derrors.Wrap(&err, "serveFeedback")
+
+	if r.Method != http.MethodPost {
+		return &serverError{status: http.StatusMethodNotAllowed}
+	}
+	db, ok := ds.(*postgres.DB)
+	if !ok {
+		return proxydatasourceNotSupportedErr()
+	}
+	report, returnURL, err := parseFeedbackReport(r)
+	if err != nil {
+		return &serverError{status: http.StatusBadRequest, responseText: err.Error()}
+	}
+	// The "website" field is hidden from users, so only bots fill it in.
+	// Pretend that their reports were stored.
+	if r.FormValue("website") == "" {
+		if _, err := db.InsertFeedbackReport(r.Context(), report); err != nil {
+			return err
+		}
+	}
+	http.Redirect(w, r, returnURL+"#feedback-sent", http.StatusSeeOther)
+	return nil
+}
+
+// parseFeedbackReport returns the report sent with the feedback form in r,
+// and the URL of the page to return to.
+func parseFeedbackReport(r *http.Request) (_ *internal.FeedbackReport, returnURL string, err error) {
+	report := &internal.FeedbackReport{
+		Path:     strings.TrimSpace(r.FormValue("path")),
+		Version:  strings.TrimSpace(r.FormValue("version")),
+		Category: internal.FeedbackCategory(r.FormValue("category")),
+		Text:     strings.TrimSpace(r.FormValue("text")),
+		TraceID:  r.FormValue("trace_id"),
+	}
+	if report.Path == "" || len(report.Path) > maxFeedbackPathLength || len(report.Version) > maxFeedbackPathLength {
+		return nil, "", fmt.Errorf("invalid path or version")
+	}
+	valid := false
+	for _, c := range internal.FeedbackCategories {
+		if report.Category == c {
+			valid = true
+		}
+	}
+	if !valid {
+		return nil, "", fmt.Errorf("invalid category %q", report.Category)
+	}
+	if report.Text == "" {
+		return nil, "", fmt.Errorf("please describe the problem")
+	}
+	if utf8.RuneCountInString(report.Text) > maxFeedbackTextLength {
+		return nil, "", fmt.Errorf("the description is longer than %d characters", maxFeedbackTextLength)
+	}
+	if len(report.TraceID) > maxFeedbackPathLength {
+		report.TraceID = ""
+	}
+	returnURL = r.FormValue("url")
+	if !isLocalURL(returnURL) {
+		returnURL = "/"
+	}
+	return report, returnURL, nil
+}
+
+// isLocalURL reports whether u is the path of a page on this site, so that it
+// is safe to redirect to.
+func isLocalURL(u string) bool {
+	if !strings.HasPrefix(u, "/") || strings.HasPrefix(u, "//") {
+		return false
+	}
+	// Browsers ignore some characters and treat backslashes as slashes, so
+	// that "/\evil.com" is another host.
+	for _, r := range u {
+		if r == '\\' || unicode.IsControl(r) || unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
+}
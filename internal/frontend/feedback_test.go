@@ -0,0 +1,106 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+)
+
+func TestParseFeedbackReport(t *testing.T) {
+	valid := url.Values{
+		"path":     {"a.com/m/p"},
+		"version":  {"v1.2.3"},
+		"url":      {"/a.com/m/p?tab=doc"},
+		"trace_id": {"abc"},
+		"category": {"rendering"},
+		"text":     {" The example is cut off. "},
+	}
+	with := func(key, value string) url.Values {
+		v := url.Values{}
+		for k, vs := range valid {
+			v[k] = vs
+		}
+		v.Set(key, value)
+		return v
+	}
+
+	for _, test := range []struct {
+		name       string
+		form       url.Values
+		want       *internal.FeedbackReport
+		wantReturn string
+		wantErr    bool
+	}{
+		{
+			name: "valid",
+			form: valid,
+			want: &internal.FeedbackReport{
+				Path:     "a.com/m/p",
+				Version:  "v1.2.3",
+				Category: internal.FeedbackCategoryRendering,
+				Text:     "The example is cut off.",
+				TraceID:  "abc",
+			},
+			wantReturn: "/a.com/m/p?tab=doc",
+		},
+		{
+			name: "other host",
+			form: with("url", "//evil.com"),
+			want: &internal.FeedbackReport{
+				Path:     "a.com/m/p",
+				Version:  "v1.2.3",
+				Category: internal.FeedbackCategoryRendering,
+				Text:     "The example is cut off.",
+				TraceID:  "abc",
+			},
+			wantReturn: "/",
+		},
+		{name: "no path", form: with("path", ""), wantErr: true},
+		{name: "bad category", form: with("category", "praise"), wantErr: true},
+		{name: "no text", form: with("text", "  "), wantErr: true},
+		{name: "long text", form: with("text", strings.Repeat("x", maxFeedbackTextLength+1)), wantErr: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/feedback", strings.NewReader(test.form.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			got, gotReturn, err := parseFeedbackReport(r)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("got error %v, want error: %t", err, test.wantErr)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+			if gotReturn != test.wantReturn {
+				t.Errorf("got return URL %q, want %q", gotReturn, test.wantReturn)
+			}
+		})
+	}
+}
+
+func TestIsLocalURL(t *testing.T) {
+	for _, test := range []struct {
+		in   string
+		want bool
+	}{
+		{"/", true},
+		{"/a.com/m@v1.0.0/p?tab=doc", true},
+		{"", false},
+		{"a.com/m", false},
+		{"https://evil.com", false},
+		{"//evil.com", false},
+		{`/\evil.com`, false},
+		{"/\t/evil.com", false},
+	} {
+		if got := isLocalURL(test.in); got != test.want {
+			t.Errorf("isLocalURL(%q) = %t, want %t", test.in, got, test.want)
+		}
+	}
+}
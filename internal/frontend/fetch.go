@@ -9,6 +9,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"sync"
@@ -23,6 +24,7 @@ import (
 	"golang.org/x/pkgsite/internal/derrors"
 	"golang.org/x/pkgsite/internal/fetch"
 	"golang.org/x/pkgsite/internal/log"
+	"golang.org/x/pkgsite/internal/middleware"
 	"golang.org/x/pkgsite/internal/postgres"
 	"golang.org/x/pkgsite/internal/proxy"
 	"golang.org/x/pkgsite/internal/source"
@@ -40,6 +42,10 @@ var (
 	fetchTimeout                = 30 * time.Second
 	pollEvery                   = 1 * time.Second
 
+	// errWorkersOverloaded indicates that a module was not enqueued to be
+	// fetched, because the workers have signaled that they are saturated.
+	errWorkersOverloaded = errors.New("workers overloaded")
+
 	// keyFetchStatus is a census tag for frontend fetch status types.
 	keyFetchStatus = tag.MustNewKey("frontend-fetch.status")
 	// frontendFetchLatency holds observed latency in individual
@@ -102,12 +108,49 @@ func (s *Server) serveFetch(w http.ResponseWriter, r *http.Request, ds internal.
 		return &serverError{status: http.StatusBadRequest}
 	}
 	status, responseText := s.fetchAndPoll(r.Context(), ds, urlInfo.modulePath, urlInfo.fullPath, urlInfo.requestedVersion)
+	if status == http.StatusServiceUnavailable {
+		w.Header().Set("Retry-After", strconv.Itoa(fetchRetryAfter))
+	}
 	if status != http.StatusOK {
 		return &serverError{status: status, responseText: responseText}
 	}
+	setFetchedCookie(w, urlInfo.fullPath)
 	return nil
 }
 
+// fetchedCookie names the path that the browser has just fetched. The page
+// that it goes to next reads the path from the primary, since the replica
+// may not have it yet.
+const fetchedCookie = "pkgsite-fetched"
+
+// setFetchedCookie tells the browser that fullPath was just fetched, for as
+// long as the replica may lag behind the primary.
+func setFetchedCookie(w http.ResponseWriter, fullPath string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     fetchedCookie,
+		Value:    url.QueryEscape(fullPath),
+		Path:     "/",
+		MaxAge:   int(postgres.MaxReplicaLag / time.Second),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// dataSourceAfterFetch returns the data source from which to serve the page
+// of fullPath: the primary of ds, if the browser has just fetched fullPath,
+// and ds otherwise.
+func dataSourceAfterFetch(r *http.Request, ds internal.DataSource, fullPath string) internal.DataSource {
+	db, ok := ds.(*postgres.DB)
+	if !ok {
+		return ds
+	}
+	c, err := r.Cookie(fetchedCookie)
+	if err != nil || c.Value != url.QueryEscape(fullPath) {
+		return ds
+	}
+	return db.Primary()
+}
+
 type fetchResult struct {
 	modulePath string
 	goModPath  string
@@ -130,8 +173,10 @@ func (s *Server) fetchAndPoll(ctx context.Context, ds internal.DataSource, modul
 		return http.StatusBadRequest, http.StatusText(http.StatusBadRequest)
 	}
 
-	// Generate all possible module paths for the fullPath.
-	db := ds.(*postgres.DB)
+	// Generate all possible module paths for the fullPath. Read from the
+	// primary, since the replica may not have the module yet once it has
+	// been fetched.
+	db := ds.(*postgres.DB).Primary()
 	modulePaths, err := modulePathsToFetch(ctx, db, fullPath, modulePath)
 	if err != nil {
 		var serr *serverError
@@ -140,6 +185,13 @@ func (s *Server) fetchAndPoll(ctx context.Context, ds internal.DataSource, modul
 		}
 		return http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError)
 	}
+	// Charge the client's fetch quota once for the request, however many
+	// module paths it may cause to be fetched.
+	if !middleware.AllowFetch(ctx, fullPath, requestedVersion) {
+		return http.StatusTooManyRequests,
+			fmt.Sprintf("You have asked us to fetch too many modules in the last hour, so we can't fetch “%s” now. Please try again later.",
+				displayPath(fullPath, requestedVersion))
+	}
 	results := s.checkPossibleModulePaths(ctx, db, fullPath, requestedVersion, modulePaths, true)
 	return fetchRequestStatusAndResponseText(results, fullPath, requestedVersion)
 }
@@ -147,7 +199,8 @@ func (s *Server) fetchAndPoll(ctx context.Context, ds internal.DataSource, modul
 // checkPossibleModulePaths checks all modulePaths at the requestedVersion, to see
 // if the fullPath exists. For each module path, it first checks version_map to
 // see if we already attempted to fetch the module. If not, and shouldQueue is
-// true, it will enqueue the module to the frontend task queue to be fetched.
+// true, it will enqueue the module to the frontend task queue to be fetched,
+// unless the workers have signaled that they are overloaded.
 // checkPossibleModulePaths will then poll the database for each module path,
 // until a result is returned or the request times out. If shouldQueue is false,
 // it will return the fetchResult, regardless of what the status is.
@@ -174,7 +227,14 @@ func (s *Server) checkPossibleModulePaths(ctx context.Context, db *postgres.DB,
 				return
 			}
 			// A row for this modulePath and requestedVersion combination does not
-			// exist in version_map. Enqueue the module version to be fetched.
+			// exist in version_map. Enqueue the module version to be fetched,
+			// unless doing so would pile more work onto saturated workers.
+			if workersOverloaded(ctx, db) {
+				fr.status = http.StatusServiceUnavailable
+				fr.err = errWorkersOverloaded
+				results[i] = fr
+				return
+			}
 			if _, err := s.queue.ScheduleFetch(ctx, modulePath, requestedVersion, "", s.taskIDChangeInterval); err != nil {
 				fr.err = err
 				fr.status = http.StatusInternalServerError
@@ -203,7 +263,10 @@ func (s *Server) checkPossibleModulePaths(ctx context.Context, db *postgres.DB,
 // contain that information. The status and responseText will be displayed to the
 // user.
 func fetchRequestStatusAndResponseText(results []*fetchResult, fullPath, requestedVersion string) (int, string) {
-	var moduleMatchingPathPrefix string
+	var (
+		moduleMatchingPathPrefix string
+		overloaded               bool
+	)
 	for _, fr := range results {
 		switch fr.status {
 		// Results are in order of longest module path first. Once an
@@ -235,6 +298,17 @@ func fetchRequestStatusAndResponseText(results []*fetchResult, fullPath, request
 		if errors.Is(fr.err, errPathDoesNotExistInModule) && moduleMatchingPathPrefix == "" {
 			moduleMatchingPathPrefix = fr.modulePath
 		}
+		// The workers were too busy to fetch this module path. A shorter
+		// module path may still have the package, so keep looking.
+		if fr.status == http.StatusServiceUnavailable {
+			overloaded = true
+		}
+	}
+	if overloaded {
+		// We could not check every module path that might hold fullPath.
+		return http.StatusServiceUnavailable,
+			fmt.Sprintf("We're too busy to fetch “%s” right now. Please try again in a few minutes.",
+				displayPath(fullPath, requestedVersion))
 	}
 	if moduleMatchingPathPrefix != "" {
 		// TODO(https://golang.org/issue/40306): Make the link clickable.
@@ -7,13 +7,16 @@ package frontend
 import (
 	"context"
 	"net/http"
+	"net/http/httptest"
 	"strconv"
 	"testing"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/database"
 	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/postgres"
 	"golang.org/x/pkgsite/internal/proxy"
 	"golang.org/x/pkgsite/internal/testing/sample"
 	"golang.org/x/pkgsite/internal/testing/testhelper"
@@ -170,6 +173,75 @@ func TestFetchPathAlreadyExists(t *testing.T) {
 	}
 }
 
+func TestFetchWorkersOverloaded(t *testing.T) {
+	defer func() { backpressure.checked = time.Time{} }()
+	ctx, cancel := context.WithTimeout(context.Background(), testFetchTimeout)
+	defer cancel()
+
+	s, _, teardown := newTestServer(t, testModulesForProxy)
+	defer teardown()
+	if err := testDB.UpsertWorkerLoad(ctx, &internal.WorkerLoad{InstanceID: "worker", Overloaded: true}); err != nil {
+		t.Fatal(err)
+	}
+	backpressure.checked = time.Time{}
+	got, _ := s.fetchAndPoll(ctx, s.getDataSource(ctx), testModulePath, testModulePath, testSemver)
+	if got != http.StatusServiceUnavailable {
+		t.Errorf("fetchAndPoll with overloaded workers: %d; want = %d", got, http.StatusServiceUnavailable)
+	}
+}
+
+func TestFetchRequestStatusOverloaded(t *testing.T) {
+	const fullPath = "example.com/mod/pkg"
+	results := []*fetchResult{
+		{modulePath: "example.com/mod/pkg", status: http.StatusServiceUnavailable, err: errWorkersOverloaded},
+		{modulePath: "example.com/mod", status: http.StatusNotFound, err: errPathDoesNotExistInModule},
+	}
+	got, _ := fetchRequestStatusAndResponseText(results, fullPath, internal.LatestVersion)
+	if got != http.StatusServiceUnavailable {
+		t.Errorf("got %d, want %d", got, http.StatusServiceUnavailable)
+	}
+	// A shorter module path that has the package wins.
+	results[1] = &fetchResult{modulePath: "example.com/mod", status: http.StatusOK}
+	got, _ = fetchRequestStatusAndResponseText(results, fullPath, internal.LatestVersion)
+	if got != http.StatusOK {
+		t.Errorf("got %d, want %d", got, http.StatusOK)
+	}
+}
+
+func TestDataSourceAfterFetch(t *testing.T) {
+	const fullPath = "example.com/mod/pkg"
+	db := postgres.New(nil)
+	db.SetReplica(&database.DB{})
+
+	w := httptest.NewRecorder()
+	setFetchedCookie(w, fullPath)
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("got %d cookies, want 1", len(cookies))
+	}
+	for _, test := range []struct {
+		name        string
+		cookie      *http.Cookie
+		path        string
+		wantPrimary bool
+	}{
+		{"no cookie", nil, fullPath, false},
+		{"fetched", cookies[0], fullPath, true},
+		{"other path", cookies[0], "example.com/mod", false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/"+test.path, nil)
+			if test.cookie != nil {
+				r.AddCookie(test.cookie)
+			}
+			got := dataSourceAfterFetch(r, db, test.path)
+			if gotPrimary := got != internal.DataSource(db); gotPrimary != test.wantPrimary {
+				t.Errorf("got primary %t, want %t", gotPrimary, test.wantPrimary)
+			}
+		})
+	}
+}
+
 func TestCandidateModulePaths(t *testing.T) {
 	maxPathsToFetch = 7
 	for _, test := range []struct {
@@ -0,0 +1,157 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/postgres"
+	"golang.org/x/pkgsite/internal/stdlib"
+)
+
+// fetchEventsTimeout is how long a stream of fetch events lasts at most. It
+// is shorter than the frontend's request timeout, so that the stream ends
+// cleanly; the browser then reconnects to follow a fetch that takes longer.
+var fetchEventsTimeout = 45 * time.Second
+
+// fetchEvent is the data of a server-sent event that reports the progress
+// of a fetch.
+type fetchEvent struct {
+	Stage   internal.FetchStage `json:"stage"`
+	Message string              `json:"message"`
+}
+
+// serveFetchEvents streams the progress of the fetch of a path and version
+// as server-sent events. It expects GET requests for
+// "/fetch/events/<path>[@<version>]", with the path and version of a request
+// to serveFetch, which starts the fetch. Each event is a fetchEvent in JSON,
+// sent when the stage of the fetch changes. The stream ends after an event
+// with the stage internal.FetchDone or internal.FetchError, the latter with
+// the message that serveFetch would respond with, or after
+// fetchEventsTimeout.
+func (s *Server) serveFetchEvents(w http.ResponseWriter, r *http.Request, ds internal.DataSource) (err error) {
+	defer derrors.Wrap(&err, "serveFetchEvents(%q)", r.URL.Path)
+
+	db, ok := ds.(*postgres.DB)
+	if !ok {
+		return proxydatasourceNotSupportedErr()
+	}
+	if r.Method != http.MethodGet {
+		return &serverError{status: http.StatusMethodNotAllowed}
+	}
+	urlInfo, err := extractURLPathInfo(strings.TrimPrefix(r.URL.Path, "/fetch/events"))
+	if err != nil {
+		return &serverError{status: http.StatusBadRequest}
+	}
+	if !isSupportedVersion(urlInfo.fullPath, urlInfo.requestedVersion) ||
+		(stdlib.Contains(urlInfo.fullPath) && urlInfo.requestedVersion == internal.LatestVersion) {
+		return &serverError{status: http.StatusBadRequest}
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return errors.New("response writer does not support streaming")
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), fetchEventsTimeout)
+	defer cancel()
+	modulePaths, err := modulePathsToFetch(ctx, db, urlInfo.fullPath, urlInfo.modulePath)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	ticker := time.NewTicker(pollEvery)
+	defer ticker.Stop()
+	var last fetchEvent
+	for {
+		ev := s.fetchProgress(ctx, db, urlInfo.fullPath, urlInfo.requestedVersion, modulePaths)
+		if ctx.Err() != nil {
+			// The stream timed out, or the client went away. The fetch may
+			// still be in progress.
+			return nil
+		}
+		if ev != last {
+			if err := writeFetchEvent(w, ev); err != nil {
+				return err
+			}
+			flusher.Flush()
+			last = ev
+		}
+		if ev.Stage == internal.FetchDone || ev.Stage == internal.FetchError {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// fetchProgress reports the progress of fetching fullPath at
+// requestedVersion from the module paths that might hold it. Until version_map
+// has the result for every module path, the stage is the furthest that the
+// worker has got with any of them, according to the fetch_stages table.
+func (s *Server) fetchProgress(ctx context.Context, db *postgres.DB, fullPath, requestedVersion string, modulePaths []string) fetchEvent {
+	results := make([]*fetchResult, len(modulePaths))
+	stage := internal.FetchQueued
+	pending := false
+	for i, modulePath := range modulePaths {
+		results[i] = checkForPath(ctx, db, fullPath, modulePath, requestedVersion, s.taskIDChangeInterval)
+		if results[i].status != statusNotFoundInVersionMap {
+			continue
+		}
+		pending = true
+		st, updatedAt, err := db.GetFetchStage(ctx, modulePath, requestedVersion)
+		if err != nil || time.Since(updatedAt) > s.taskIDChangeInterval {
+			// Either the fetch hasn't started, or the stage is left over
+			// from a fetch that didn't finish.
+			continue
+		}
+		if st == internal.FetchProcessing || (st == internal.FetchDownloading && stage == internal.FetchQueued) {
+			stage = st
+		}
+	}
+	if pending {
+		return fetchEvent{Stage: stage, Message: fetchStageMessage(stage, fullPath, requestedVersion)}
+	}
+	status, responseText := fetchRequestStatusAndResponseText(results, fullPath, requestedVersion)
+	if status != http.StatusOK {
+		return fetchEvent{Stage: internal.FetchError, Message: responseText}
+	}
+	return fetchEvent{Stage: internal.FetchDone}
+}
+
+// fetchStageMessage returns the message shown to users while the fetch of
+// fullPath at requestedVersion is at stage.
+func fetchStageMessage(stage internal.FetchStage, fullPath, requestedVersion string) string {
+	p := displayPath(fullPath, requestedVersion)
+	switch stage {
+	case internal.FetchDownloading:
+		return fmt.Sprintf("Downloading “%s”…", p)
+	case internal.FetchProcessing:
+		return fmt.Sprintf("Processing “%s”…", p)
+	default:
+		return fmt.Sprintf("Waiting to fetch “%s”…", p)
+	}
+}
+
+// writeFetchEvent writes ev to w as a server-sent event.
+func writeFetchEvent(w http.ResponseWriter, ev fetchEvent) error {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "data: %s\n\n", data)
+	return err
+}
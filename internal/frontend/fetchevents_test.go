@@ -0,0 +1,88 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestServeFetchEvents(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer func(d time.Duration) { fetchEventsTimeout = d }(fetchEventsTimeout)
+	fetchEventsTimeout = 100 * time.Millisecond
+
+	_, handler, teardown := newTestServer(t, nil)
+	defer teardown()
+	if err := testDB.InsertModule(ctx, sample.LegacyModule(testModulePath, testSemver, "bar/foo")); err != nil {
+		t.Fatal(err)
+	}
+	if err := testDB.UpsertVersionMap(ctx, &internal.VersionMap{
+		ModulePath:       testModulePath,
+		RequestedVersion: testSemver,
+		ResolvedVersion:  testSemver,
+		GoModPath:        testModulePath,
+		Status:           http.StatusOK,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := testDB.UpdateFetchStage(ctx, "example.com/pending", "v1.0.0", internal.FetchProcessing); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		name, url  string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "done",
+			url:        "/fetch/events/" + testModulePath + "/bar/foo@" + testSemver,
+			wantStatus: http.StatusOK,
+			wantBody:   `data: {"stage":"done","message":""}` + "\n\n",
+		},
+		{
+			name:       "processing",
+			url:        "/fetch/events/example.com/pending@v1.0.0",
+			wantStatus: http.StatusOK,
+			wantBody:   `data: {"stage":"processing","message":"Processing “example.com/pending@v1.0.0”…"}` + "\n\n",
+		},
+		{
+			name:       "queued",
+			url:        "/fetch/events/example.com/unknown@v1.0.0",
+			wantStatus: http.StatusOK,
+			wantBody:   `data: {"stage":"queued","message":"Waiting to fetch “example.com/unknown@v1.0.0”…"}` + "\n\n",
+		},
+		{
+			name:       "bad version",
+			url:        "/fetch/events/example.com/unknown@not-a-version",
+			wantStatus: http.StatusBadRequest,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest("GET", test.url, nil))
+			if w.Code != test.wantStatus {
+				t.Fatalf("got status %d, want %d", w.Code, test.wantStatus)
+			}
+			if test.wantStatus != http.StatusOK {
+				return
+			}
+			if got, want := w.Header().Get("Content-Type"), "text/event-stream"; got != want {
+				t.Errorf("Content-Type = %q, want %q", got, want)
+			}
+			if got := w.Body.String(); got != test.wantBody {
+				t.Errorf("body = %q, want %q", got, test.wantBody)
+			}
+		})
+	}
+}
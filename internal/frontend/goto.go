@@ -0,0 +1,121 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/log"
+	"golang.org/x/pkgsite/internal/postgres"
+)
+
+// shortcutPath returns the path that a details URL path written with a
+// shortcut redirects to, or the empty string if urlPath has no shortcut. The
+// only shortcut is "x/" for "golang.org/x/", as in "/x/tools" or
+// "/mod/x/net@v0.1.0".
+func shortcutPath(urlPath string) string {
+	for _, prefix := range []string{"/mod/", "/"} {
+		if rest := strings.TrimPrefix(urlPath, prefix); rest != urlPath {
+			if strings.HasPrefix(rest, "x/") && len(rest) > len("x/") {
+				return prefix + "golang.org/" + rest
+			}
+			return ""
+		}
+	}
+	return ""
+}
+
+// packagePathWithName returns the path of the only package whose name is
+// info.fullPath, if info is the latest version of a bare name, like "/yaml".
+// It returns the empty string if there is no such package or there are
+// several, or if ds is not a database.
+func packagePathWithName(ctx context.Context, ds internal.DataSource, info *urlPathInfo) string {
+	db, ok := ds.(*postgres.DB)
+	if !ok || info.isModule || info.requestedVersion != internal.LatestVersion {
+		return ""
+	}
+	// A name that is the path of a package in the standard library was
+	// found before this is called.
+	name := info.fullPath
+	if strings.ContainsAny(name, "/.") {
+		return ""
+	}
+	paths, err := db.GetPackagePathsWithName(ctx, name, 2)
+	if err != nil {
+		log.Errorf(ctx, "packagePathWithName(%q): %v", name, err)
+		return ""
+	}
+	if len(paths) != 1 {
+		return ""
+	}
+	return paths[0]
+}
+
+// parsePastedLine parses a line of Go source or of a go.mod file that
+// names a package or module, and returns the path it names and the version
+// it requires, or the empty string if it has none. The line may be an
+// import declaration or spec, like
+//
+//	import "github.com/a/b"
+//	yaml "gopkg.in/yaml.v2"
+//
+// or a require directive, or a line of a require block, like
+//
+//	require github.com/a/b v1.2.3
+//	github.com/a/b v1.2.3 // indirect
+//
+// It reports whether the line is one of these.
+func parsePastedLine(line string) (path, version string, ok bool) {
+	fields := strings.Fields(line)
+	for i, f := range fields {
+		if strings.HasPrefix(f, "//") {
+			fields = fields[:i]
+			break
+		}
+	}
+	if len(fields) == 0 {
+		return "", "", false
+	}
+	switch fields[0] {
+	case "import":
+		return parseImportSpec(fields[1:])
+	case "require":
+		return parseRequireSpec(fields[1:])
+	}
+	if path, _, ok := parseImportSpec(fields); ok {
+		return path, "", true
+	}
+	return parseRequireSpec(fields)
+}
+
+// parseImportSpec parses the fields of an import spec: a quoted path,
+// optionally preceded by a name.
+func parseImportSpec(fields []string) (path, version string, ok bool) {
+	if len(fields) == 2 {
+		fields = fields[1:]
+	}
+	if len(fields) != 1 {
+		return "", "", false
+	}
+	path, err := strconv.Unquote(fields[0])
+	if err != nil || module.CheckImportPath(path) != nil {
+		return "", "", false
+	}
+	return path, "", true
+}
+
+// parseRequireSpec parses the fields of a require spec: a module path
+// followed by a semantic version.
+func parseRequireSpec(fields []string) (path, version string, ok bool) {
+	if len(fields) != 2 || !semver.IsValid(fields[1]) || module.CheckPath(fields[0]) != nil {
+		return "", "", false
+	}
+	return fields[0], fields[1], true
+}
@@ -0,0 +1,55 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import "testing"
+
+func TestShortcutPath(t *testing.T) {
+	for _, test := range []struct {
+		in, want string
+	}{
+		{"/x/tools", "/golang.org/x/tools"},
+		{"/x/tools/go/packages@v0.1.0", "/golang.org/x/tools/go/packages@v0.1.0"},
+		{"/mod/x/net", "/mod/golang.org/x/net"},
+		{"/x/", ""},
+		{"/xerrors", ""},
+		{"/std", ""},
+		{"/golang.org/x/tools", ""},
+		{"/mod/golang.org/x/tools", ""},
+	} {
+		if got := shortcutPath(test.in); got != test.want {
+			t.Errorf("shortcutPath(%q) = %q, want %q", test.in, got, test.want)
+		}
+	}
+}
+
+func TestParsePastedLine(t *testing.T) {
+	for _, test := range []struct {
+		in                    string
+		wantPath, wantVersion string
+		wantOK                bool
+	}{
+		{`import "github.com/a/b"`, "github.com/a/b", "", true},
+		{`import yaml "gopkg.in/yaml.v2"`, "gopkg.in/yaml.v2", "", true},
+		{"import _ `net/http/pprof`", "net/http/pprof", "", true},
+		{`"fmt"`, "fmt", "", true},
+		{`. "github.com/a/b" // for tests`, "github.com/a/b", "", true},
+		{"require github.com/a/b v1.2.3", "github.com/a/b", "v1.2.3", true},
+		{"	github.com/a/b v0.0.0-20200101000000-abcdefabcdef // indirect", "github.com/a/b", "v0.0.0-20200101000000-abcdefabcdef", true},
+		{"import (", "", "", false},
+		{`import "not a path"`, "", "", false},
+		{"require github.com/a/b latest", "", "", false},
+		{"yaml v1.2.3", "", "", false},
+		{"github.com/a/b", "", "", false},
+		{"http json", "", "", false},
+		{"", "", "", false},
+	} {
+		gotPath, gotVersion, gotOK := parsePastedLine(test.in)
+		if gotPath != test.wantPath || gotVersion != test.wantVersion || gotOK != test.wantOK {
+			t.Errorf("parsePastedLine(%q) = %q, %q, %t; want %q, %q, %t",
+				test.in, gotPath, gotVersion, gotOK, test.wantPath, test.wantVersion, test.wantOK)
+		}
+	}
+}
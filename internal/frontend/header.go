@@ -5,6 +5,7 @@
 package frontend
 
 import (
+	"context"
 	"fmt"
 	"path"
 	"strings"
@@ -13,6 +14,7 @@ import (
 	"golang.org/x/mod/module"
 	"golang.org/x/pkgsite/internal"
 	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/i18n"
 	"golang.org/x/pkgsite/internal/licenses"
 	"golang.org/x/pkgsite/internal/middleware"
 	"golang.org/x/pkgsite/internal/stdlib"
@@ -35,7 +37,7 @@ type Module struct {
 	DisplayVersion    string
 	LinkVersion       string
 	ModulePath        string
-	CommitTime        string
+	CommitTime        DisplayTime
 	IsRedistributable bool
 	URL               string // relative to this site
 	LatestURL         string // link with latest-version placeholder, relative to this site
@@ -48,7 +50,7 @@ type Module struct {
 // latestRequested indicates whether the user requested the latest
 // version of the package. If so, the returned Package.URL will have the
 // structure /<path> instead of /<path>@<version>.
-func createPackage(pkg *internal.PackageMeta, mi *internal.ModuleInfo, latestRequested bool) (_ *Package, err error) {
+func createPackage(ctx context.Context, pkg *internal.PackageMeta, mi *internal.ModuleInfo, latestRequested bool) (_ *Package, err error) {
 	defer derrors.Wrap(&err, "createPackage(%v, %v, %t)", pkg, mi, latestRequested)
 
 	var modLicenses []*licenses.Metadata
@@ -58,7 +60,7 @@ func createPackage(pkg *internal.PackageMeta, mi *internal.ModuleInfo, latestReq
 		}
 	}
 
-	m := createModule(mi, modLicenses, latestRequested)
+	m := createModule(ctx, mi, modLicenses, latestRequested)
 	urlVersion := m.LinkVersion
 	if latestRequested {
 		urlVersion = internal.LatestVersion
@@ -79,7 +81,7 @@ func createPackage(pkg *internal.PackageMeta, mi *internal.ModuleInfo, latestReq
 // latestRequested indicates whether the user requested the latest
 // version of the package. If so, the returned Module.URL will have the
 // structure /<path> instead of /<path>@<version>.
-func createModule(mi *internal.ModuleInfo, licmetas []*licenses.Metadata, latestRequested bool) *Module {
+func createModule(ctx context.Context, mi *internal.ModuleInfo, licmetas []*licenses.Metadata, latestRequested bool) *Module {
 	urlVersion := linkVersion(mi.Version, mi.ModulePath)
 	if latestRequested {
 		urlVersion = internal.LatestVersion
@@ -88,7 +90,7 @@ func createModule(mi *internal.ModuleInfo, licmetas []*licenses.Metadata, latest
 		DisplayVersion:    displayVersion(mi.Version, mi.ModulePath),
 		LinkVersion:       linkVersion(mi.Version, mi.ModulePath),
 		ModulePath:        mi.ModulePath,
-		CommitTime:        elapsedTime(mi.CommitTime),
+		CommitTime:        newDisplayTime(ctx, mi.CommitTime),
 		IsRedistributable: mi.IsRedistributable,
 		Licenses:          transformLicenseMetadata(licmetas),
 		URL:               constructModuleURL(mi.ModulePath, urlVersion),
@@ -162,19 +164,17 @@ type link struct {
 //
 // See TestBreadcrumbPath for examples.
 func breadcrumbPath(pkgPath, modPath, requestedVersion string) breadcrumb {
+	return existingBreadcrumbPath(pkgPath, modPath, requestedVersion, nil)
+}
+
+// existingBreadcrumbPath is like breadcrumbPath, but if existing is non-nil,
+// only the parents of pkgPath in existing are linked to. Other parents link
+// to their closest parent in existing, or to nothing if there is none.
+func existingBreadcrumbPath(pkgPath, modPath, requestedVersion string, existing map[string]bool) breadcrumb {
 	if pkgPath == stdlib.ModulePath {
 		return breadcrumb{Current: "Standard library"}
 	}
-	// Obtain successive prefixes of pkgPath, stopping at modPath,
-	// or for the stdlib, at the end.
-	minLen := len(modPath) - 1
-	if modPath == stdlib.ModulePath {
-		minLen = 1
-	}
-	var dirs []string
-	for dir := pkgPath; len(dir) > minLen && len(path.Dir(dir)) < len(dir); dir = path.Dir(dir) {
-		dirs = append(dirs, dir)
-	}
+	dirs := breadcrumbDirs(pkgPath, modPath)
 	// Construct the path elements of the result.
 	// They will be in reverse order of dirs.
 	// The first dir is the current page. If it is the only one, leave it
@@ -187,9 +187,15 @@ func breadcrumbPath(pkgPath, modPath, requestedVersion string) breadcrumb {
 	// Make all the other parts into links.
 	b.Links = make([]link, len(dirs)-1)
 	for i := 1; i < len(dirs); i++ {
-		href := "/" + dirs[i]
-		if requestedVersion != internal.LatestVersion {
-			href += "@" + linkVersion(requestedVersion, modPath)
+		var href string
+		for _, dir := range dirs[i:] {
+			if existing == nil || existing[dir] {
+				href = "/" + dir
+				if requestedVersion != internal.LatestVersion {
+					href += "@" + linkVersion(requestedVersion, modPath)
+				}
+				break
+			}
 		}
 		el := dirs[i]
 		if i != len(dirs)-1 {
@@ -202,6 +208,21 @@ func breadcrumbPath(pkgPath, modPath, requestedVersion string) breadcrumb {
 	return b
 }
 
+// breadcrumbDirs returns the successive prefixes of pkgPath that make up its
+// breadcrumb, starting with pkgPath and stopping at modPath, or for the
+// standard library, at the end.
+func breadcrumbDirs(pkgPath, modPath string) []string {
+	minLen := len(modPath) - 1
+	if modPath == stdlib.ModulePath {
+		minLen = 1
+	}
+	var dirs []string
+	for dir := pkgPath; len(dir) > minLen && len(path.Dir(dir)) < len(dir); dir = path.Dir(dir) {
+		dirs = append(dirs, dir)
+	}
+	return dirs
+}
+
 // moduleHTMLTitle constructs the <title> contents, for tabs in the browser.
 func moduleHTMLTitle(modulePath string) string {
 	if modulePath == stdlib.ModulePath {
@@ -210,28 +231,69 @@ func moduleHTMLTitle(modulePath string) string {
 	return modulePath + " module"
 }
 
+// packageKindLabel returns a label for packages of kind k that declare
+// nothing, or the empty string for other packages.
+func packageKindLabel(k internal.PackageKind) string {
+	switch k {
+	case internal.PackageKindDocOnly:
+		return "documentation-only package"
+	case internal.PackageKindExampleOnly:
+		return "example-only package"
+	default:
+		return ""
+	}
+}
+
+// DisplayTime is a time formatted for display.
+type DisplayTime struct {
+	// Relative is the time relative to now, as returned by elapsedTime.
+	Relative string
+	// Absolute is the time in UTC, in RFC 3339 format, for a tooltip and
+	// the datetime attribute of a <time> element.
+	Absolute string
+}
+
+// String returns the relative time.
+func (t DisplayTime) String() string {
+	return t.Relative
+}
+
+// newDisplayTime returns a DisplayTime for date, formatted for the language
+// in ctx.
+func newDisplayTime(ctx context.Context, date time.Time) DisplayTime {
+	return DisplayTime{
+		Relative: elapsedTime(ctx, date),
+		Absolute: date.UTC().Format(time.RFC3339),
+	}
+}
+
 // elapsedTime takes a date and returns returns human-readable,
 // relative timestamps based on the following rules:
 // (1) 'X hours ago' when X < 6
 // (2) 'today' between 6 hours and 1 day ago
 // (3) 'Y days ago' when Y < 6
-// (4) A date formatted like "Jan 2, 2006" for anything further back
-func elapsedTime(date time.Time) string {
+// (4) A date formatted like "Jan 2, 2006" for anything further back, or
+// like "2006-01-02" if the language in ctx is not English
+func elapsedTime(ctx context.Context, date time.Time) string {
+	p := i18n.Printer(ctx)
 	elapsedHours := int(time.Since(date).Hours())
 	if elapsedHours == 1 {
-		return "1 hour ago"
+		return p.Sprintf("1 hour ago")
 	} else if elapsedHours < 6 {
-		return fmt.Sprintf("%d hours ago", elapsedHours)
+		return p.Sprintf("%d hours ago", elapsedHours)
 	}
 
 	elapsedDays := elapsedHours / 24
 	if elapsedDays < 1 {
-		return "today"
+		return p.Sprintf("today")
 	} else if elapsedDays == 1 {
-		return "1 day ago"
+		return p.Sprintf("1 day ago")
 	} else if elapsedDays < 6 {
-		return fmt.Sprintf("%d days ago", elapsedDays)
+		return p.Sprintf("%d days ago", elapsedDays)
 	}
 
+	if i18n.FromContext(ctx) != i18n.Default {
+		return date.Format("2006-01-02")
+	}
 	return date.Format("Jan _2, 2006")
 }
@@ -5,6 +5,7 @@
 package frontend
 
 import (
+	"context"
 	"fmt"
 	"testing"
 	"time"
@@ -12,8 +13,10 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/safehtml"
 	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/i18n"
 	"golang.org/x/pkgsite/internal/middleware"
 	"golang.org/x/pkgsite/internal/testing/sample"
+	"golang.org/x/text/language"
 )
 
 func samplePackage(mutators ...func(*Package)) *Package {
@@ -24,7 +27,7 @@ func samplePackage(mutators ...func(*Package)) *Package {
 		Module: Module{
 			DisplayVersion:    sample.VersionString,
 			LinkVersion:       sample.VersionString,
-			CommitTime:        "0 hours ago",
+			CommitTime:        DisplayTime{"0 hours ago", sample.CommitTime.UTC().Format(time.RFC3339)},
 			ModulePath:        sample.ModulePath,
 			IsRedistributable: true,
 			Licenses:          transformLicenseMetadata(sample.LicenseMetadata),
@@ -82,7 +85,7 @@ func TestElapsedTime(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			elapsedTime := elapsedTime(tc.date)
+			elapsedTime := elapsedTime(context.Background(), tc.date)
 
 			if elapsedTime != tc.elapsedTime {
 				t.Errorf("elapsedTime(%q) = %s, want %s", tc.date, elapsedTime, tc.elapsedTime)
@@ -91,6 +94,16 @@ func TestElapsedTime(t *testing.T) {
 	}
 }
 
+func TestNewDisplayTime(t *testing.T) {
+	date := time.Date(2020, 3, 4, 5, 6, 7, 0, time.FixedZone("EST", -5*3600))
+	ctx := i18n.NewContext(context.Background(), language.German)
+	got := newDisplayTime(ctx, date)
+	want := DisplayTime{Relative: "2020-03-04", Absolute: "2020-03-04T10:06:07Z"}
+	if got != want {
+		t.Errorf("newDisplayTime(%v) = %+v, want %+v", date, got, want)
+	}
+}
+
 func TestCreatePackage(t *testing.T) {
 	vpkg := func(modulePath, suffix, name string) *internal.LegacyVersionedPackage {
 		vp := &internal.LegacyVersionedPackage{
@@ -150,7 +163,7 @@ func TestCreatePackage(t *testing.T) {
 	} {
 		t.Run(tc.label, func(t *testing.T) {
 			pm := packageMetaFromLegacyPackage(&tc.pkg.LegacyPackage)
-			got, err := createPackage(pm, &tc.pkg.ModuleInfo, tc.linkVersion)
+			got, err := createPackage(context.Background(), pm, &tc.pkg.ModuleInfo, tc.linkVersion)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -251,6 +264,40 @@ func TestBreadcrumbPath(t *testing.T) {
 	}
 }
 
+func TestExistingBreadcrumbPath(t *testing.T) {
+	existing := map[string]bool{
+		"example.com":           true,
+		"example.com/a":         true,
+		"example.com/a/b/c/d/e": true,
+	}
+	got := existingBreadcrumbPath("example.com/a/b/c/d/e", "example.com", "v1.0.0", existing)
+	want := breadcrumb{
+		Current: "e",
+		Links: []link{
+			{"/example.com@v1.0.0", "example.com"},
+			{"/example.com/a@v1.0.0", "a"},
+			{"/example.com/a@v1.0.0", "b"},
+			{"/example.com/a@v1.0.0", "c"},
+			{"/example.com/a@v1.0.0", "d"},
+		},
+		CopyData: "example.com/a/b/c/d/e",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+
+	// Without any existing parent, there are no links.
+	got = existingBreadcrumbPath("example.com/a/b", "example.com", internal.LatestVersion, map[string]bool{})
+	want = breadcrumb{
+		Current:  "b",
+		Links:    []link{{"", "example.com"}, {"", "a"}},
+		CopyData: "example.com/a/b",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("no parents: mismatch (-want, +got):\n%s", diff)
+	}
+}
+
 // packageMetaFromLegacyPackage returns a PackageMeta based on data from a
 // LegacyPackage.
 func packageMetaFromLegacyPackage(pkg *internal.LegacyPackage) *internal.PackageMeta {
@@ -6,9 +6,15 @@ package frontend
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 
 	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/i18n"
 	"golang.org/x/pkgsite/internal/postgres"
 	"golang.org/x/pkgsite/internal/stdlib"
 )
@@ -66,20 +72,54 @@ func fetchImportsDetails(ctx context.Context, ds internal.DataSource, pkgPath, m
 type ImportedByDetails struct {
 	ModulePath string
 
-	// ImportedBy is the collection of packages that import the
-	// given package and are not part of the same module.
+	// ImportedBy is the collection of packages on the current page that
+	// import the given package and are not part of the same module.
 	// They are organized into a tree of sections by prefix.
 	ImportedBy []*Section
 
-	Total        int  // number of packages in ImportedBy
+	Total        int  // number of packages that import the given package
 	TotalIsExact bool // if false, then there may be more than Total
+
+	// Pagination divides the importers into pages, with the packages that
+	// are imported most first; see postgres.GetImportedByPage.
+	Pagination keysetPagination
 }
 
 const importedByLimit = 20001
 
-// etchImportedByDetails fetches importers for the package version specified by
-// path and version from the database and returns a ImportedByDetails.
-func fetchImportedByDetails(ctx context.Context, ds internal.DataSource, pkgPath, modulePath string) (*ImportedByDetails, error) {
+// importedByPageSize is the default number of importers on a page of the
+// imported-by tab.
+const importedByPageSize = 1000
+
+// fetchImportedByCount returns the number of packages that import pkgPath,
+// formatted for the unit header. It uses the count that the worker computes
+// for packages in search_documents, which is cheap to read, and otherwise
+// counts the importers up to importedByLimit.
+func fetchImportedByCount(ctx context.Context, db *postgres.DB, pkgPath, modulePath string) (string, error) {
+	n, err := db.GetImportedByCount(ctx, pkgPath)
+	if err == nil {
+		return i18n.FormatCount(ctx, n), nil
+	}
+	if !errors.Is(err, derrors.NotFound) {
+		return "", err
+	}
+	importedBy, err := db.GetImportedBy(ctx, pkgPath, modulePath, importedByLimit)
+	if err != nil {
+		return "", err
+	}
+	// If we reached the query limit, then we don't know the total
+	// and we'll indicate that with a '+'. For example, if the limit
+	// is 101 and we get 101 results, then we'll show '100+ Imported by'.
+	if len(importedBy) == importedByLimit {
+		return i18n.FormatCount(ctx, len(importedBy)-1) + "+", nil
+	}
+	return i18n.FormatCount(ctx, len(importedBy)), nil
+}
+
+// fetchImportedByDetails fetches importers for the package version specified by
+// path and version from the database and returns a ImportedByDetails for the
+// page of them described by params.
+func fetchImportedByDetails(ctx context.Context, ds internal.DataSource, pkgPath, modulePath string, params keysetParams) (*ImportedByDetails, error) {
 	db, ok := ds.(*postgres.DB)
 	if !ok {
 		// The proxydatasource does not support the imported by page.
@@ -93,17 +133,55 @@ func fetchImportedByDetails(ctx context.Context, ds internal.DataSource, pkgPath
 	// If we reached the query limit, then we don't know the total.
 	// Say so, and show one less than the limit.
 	// For example, if the limit is 101 and we get 101 results, then we'll
-	// say there are more than 100, and show the first 100.
+	// say there are more than 100.
+	total := len(importedBy)
 	totalIsExact := true
-	if len(importedBy) == importedByLimit {
-		importedBy = importedBy[:len(importedBy)-1]
+	if total == importedByLimit {
+		total--
 		totalIsExact = false
 	}
-	sections := Sections(importedBy, nextPrefixAccount)
+	// Ask for one more importer than fits on the page, to learn whether
+	// there is a next page.
+	importers, err := db.GetImportedByPage(ctx, pkgPath, modulePath, parseImporterKey(params.after), params.limit+1)
+	if err != nil {
+		return nil, err
+	}
+	var nextAfter string
+	if len(importers) > params.limit {
+		importers = importers[:params.limit]
+		nextAfter = importerKey(importers[len(importers)-1])
+	}
+	var page []string
+	for _, imp := range importers {
+		page = append(page, imp.Path)
+	}
+	// Sections must be built from sorted lines.
+	sort.Slice(page, func(i, j int) bool { return internal.PathLess(page[i], page[j]) })
 	return &ImportedByDetails{
 		ModulePath:   modulePath,
-		ImportedBy:   sections,
-		Total:        len(importedBy),
+		ImportedBy:   Sections(page, nextPrefixAccount),
+		Total:        total,
 		TotalIsExact: totalIsExact,
+		Pagination:   newKeysetPagination(params, len(page), nextAfter),
 	}, nil
 }
+
+// importerKey returns the "after" parameter for the page of importers that
+// follows imp.
+func importerKey(imp *postgres.Importer) string {
+	return fmt.Sprintf("%d:%s", imp.NumImportedBy, imp.Path)
+}
+
+// parseImporterKey parses an "after" parameter returned by importerKey. It
+// returns nil, for the first page, if key is empty or invalid.
+func parseImporterKey(key string) *postgres.Importer {
+	parts := strings.SplitN(key, ":", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return nil
+	}
+	n, err := strconv.Atoi(parts[0])
+	if err != nil || n < 0 {
+		return nil
+	}
+	return &postgres.Importer{Path: parts[1], NumImportedBy: n}
+}
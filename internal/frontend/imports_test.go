@@ -6,10 +6,12 @@ package frontend
 
 import (
 	"context"
+	"net/http/httptest"
 	"path"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"golang.org/x/pkgsite/internal"
 	"golang.org/x/pkgsite/internal/postgres"
 	"golang.org/x/pkgsite/internal/testing/sample"
@@ -106,15 +108,20 @@ func TestFetchImportedByDetails(t *testing.T) {
 	}
 
 	for _, tc := range []struct {
+		name        string
 		pkg         *internal.LegacyPackage
+		query       string
 		wantDetails *ImportedByDetails
+		wantNext    string
 	}{
 		{
+			name:        "no importers",
 			pkg:         pkg3,
 			wantDetails: &ImportedByDetails{TotalIsExact: true},
 		},
 		{
-			pkg: pkg2,
+			name: "one importer",
+			pkg:  pkg2,
 			wantDetails: &ImportedByDetails{
 				ImportedBy:   []*Section{{Prefix: pkg3.Path, NumLines: 0}},
 				Total:        1,
@@ -122,7 +129,8 @@ func TestFetchImportedByDetails(t *testing.T) {
 			},
 		},
 		{
-			pkg: pkg1,
+			name: "two importers",
+			pkg:  pkg1,
 			wantDetails: &ImportedByDetails{
 				ImportedBy: []*Section{
 					{Prefix: pkg2.Path, NumLines: 0},
@@ -132,21 +140,70 @@ func TestFetchImportedByDetails(t *testing.T) {
 				TotalIsExact: true,
 			},
 		},
+		{
+			name:  "first page",
+			pkg:   pkg1,
+			query: "?limit=1",
+			wantDetails: &ImportedByDetails{
+				ImportedBy:   []*Section{{Prefix: pkg2.Path, NumLines: 0}},
+				Total:        2,
+				TotalIsExact: true,
+			},
+			wantNext: "0:" + pkg2.Path,
+		},
+		{
+			name:  "second page",
+			pkg:   pkg1,
+			query: "?limit=1&after=0:" + pkg2.Path,
+			wantDetails: &ImportedByDetails{
+				ImportedBy:   []*Section{{Prefix: pkg3.Path, NumLines: 0}},
+				Total:        2,
+				TotalIsExact: true,
+			},
+		},
 	} {
-		t.Run(tc.pkg.Path, func(t *testing.T) {
+		t.Run(tc.name, func(t *testing.T) {
 			otherVersion := newModule(path.Dir(tc.pkg.Path), tc.pkg)
 			otherVersion.Version = "v1.0.5"
 			pkg := otherVersion.Units[1]
-			got, err := fetchImportedByDetails(ctx, testDB, pkg.Path, pkg.ModulePath)
+			r := httptest.NewRequest("GET", "/"+pkg.Path+tc.query, nil)
+			got, err := fetchImportedByDetails(ctx, testDB, pkg.Path, pkg.ModulePath, newKeysetParams(r, importedByPageSize))
 			if err != nil {
 				t.Fatalf("fetchImportedByDetails(ctx, db, %q) = %v err = %v, want %v",
 					tc.pkg.Path, got, err, tc.wantDetails)
 			}
 
+			if got.Pagination.NextAfter != tc.wantNext {
+				t.Errorf("got next page after %q, want %q", got.Pagination.NextAfter, tc.wantNext)
+			}
 			tc.wantDetails.ModulePath = pkg.ModulePath
-			if diff := cmp.Diff(tc.wantDetails, got); diff != "" {
+			if diff := cmp.Diff(tc.wantDetails, got, cmpopts.IgnoreFields(ImportedByDetails{}, "Pagination")); diff != "" {
 				t.Errorf("fetchImportedByDetails(ctx, db, %q) mismatch (-want +got):\n%s", tc.pkg.Path, diff)
 			}
 		})
 	}
 }
+
+func TestParseImporterKey(t *testing.T) {
+	for _, test := range []struct {
+		key  string
+		want *postgres.Importer
+	}{
+		{"", nil},
+		{"example.com/a", nil},
+		{"x:example.com/a", nil},
+		{"-1:example.com/a", nil},
+		{"3:", nil},
+		{"3:example.com/a", &postgres.Importer{Path: "example.com/a", NumImportedBy: 3}},
+	} {
+		got := parseImporterKey(test.key)
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("parseImporterKey(%q) mismatch (-want +got):\n%s", test.key, diff)
+		}
+		if got != nil {
+			if key := importerKey(got); key != test.key {
+				t.Errorf("importerKey(parseImporterKey(%q)) = %q", test.key, key)
+			}
+		}
+	}
+}
@@ -7,19 +7,48 @@ package frontend
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/google/safehtml"
 	"golang.org/x/pkgsite/internal"
 	"golang.org/x/pkgsite/internal/licenses"
+	"golang.org/x/pkgsite/internal/postgres"
 )
 
 // License contains information used for a single license section.
 type License struct {
 	*licenses.License
+	Anchor  safehtml.Identifier
+	Source  string
+	Lines   []LicenseLine
+	Matches []LicenseMatch
+	// Packages holds the packages at or below the unit that the license
+	// applies to.
+	Packages []LicensePackage
+}
+
+// LicensePackage is a package that a license applies to.
+type LicensePackage struct {
+	Path string
+	URL  string
+}
+
+// LicenseLine is a single line of a license file, with an anchor so that
+// it can be linked to.
+type LicenseLine struct {
+	Anchor safehtml.Identifier
+	Text   string
+}
+
+// LicenseMatch describes where in a license file the license detector found
+// a license.
+type LicenseMatch struct {
+	Type   string
+	Line   int // 1-based
 	Anchor safehtml.Identifier
-	Source string
 }
 
 // LicensesDetails contains license information for a package or module.
@@ -32,20 +61,55 @@ type LicensesDetails struct {
 type LicenseMetadata struct {
 	Type   string
 	Anchor safehtml.Identifier
+	// FilePath is the path of the license file, relative to the module root.
+	FilePath string
+	// Scope is the directory whose packages the license applies to, relative
+	// to the module root. It is "." for licenses at the module root.
+	Scope string
 }
 
 // fetchLicensesDetails fetches license data for the package version specified by
 // path and version from the database and returns a LicensesDetails.
+// At the root of a module, every license in the module is included, even
+// those that apply only to some of its packages.
 func fetchLicensesDetails(ctx context.Context, ds internal.DataSource, um *internal.UnitMeta) (*LicensesDetails, error) {
-	u, err := ds.GetUnit(ctx, um, internal.WithLicenses)
+	u, err := ds.GetUnit(ctx, um, internal.WithLicenses|internal.WithSubdirectories)
 	if err != nil {
 		return nil, err
 	}
-	return &LicensesDetails{Licenses: transformLicenses(um.ModulePath, um.Version, u.LicenseContents)}, nil
+	lics := u.LicenseContents
+	if db, ok := ds.(*postgres.DB); ok && um.Path == um.ModulePath {
+		lics, err = db.GetLicensesInModule(ctx, um.ModulePath, um.Version)
+		if err != nil {
+			return nil, err
+		}
+	}
+	ls := transformLicenses(um.ModulePath, um.Version, lics)
+	addLicensePackages(ls, um.ModulePath, um.Version, u.Subdirectories)
+	return &LicensesDetails{Licenses: ls}, nil
+}
+
+// addLicensePackages sets the Packages of each license to the packages in
+// pkgs, from the given module version, that it applies to, in the order of
+// pkgs.
+func addLicensePackages(lics []License, modulePath, version string, pkgs []*internal.PackageMeta) {
+	for i := range lics {
+		for _, pkg := range pkgs {
+			for _, md := range pkg.Licenses {
+				if md.FilePath == lics[i].FilePath {
+					lics[i].Packages = append(lics[i].Packages, LicensePackage{
+						Path: pkg.Path,
+						URL:  constructPackageURL(pkg.Path, modulePath, linkVersion(version, modulePath)),
+					})
+					break
+				}
+			}
+		}
+	}
 }
 
 // transformLicenses transforms licenses.License into a License
-// by adding an anchor field.
+// by adding an anchor field, and anchors for each line of its contents.
 func transformLicenses(modulePath, requestedVersion string, dbLicenses []*licenses.License) []License {
 	licenses := make([]License, len(dbLicenses))
 	var filePaths []string
@@ -54,16 +118,65 @@ func transformLicenses(modulePath, requestedVersion string, dbLicenses []*licens
 	}
 	anchors := licenseAnchors(filePaths)
 	for i, l := range dbLicenses {
+		// Compute the matches before removing carriage returns, since the
+		// match offsets refer to the original contents.
+		matches := licenseMatches(anchors[i], l)
 		l.Contents = bytes.ReplaceAll(l.Contents, []byte("\r"), nil)
 		licenses[i] = License{
 			Anchor:  anchors[i],
 			License: l,
 			Source:  fileSource(modulePath, requestedVersion, l.FilePath),
+			Lines:   licenseLines(anchors[i], l.Contents),
+			Matches: matches,
 		}
 	}
 	return licenses
 }
 
+// licenseLines splits contents into lines, giving each an anchor derived
+// from the anchor of the license.
+func licenseLines(anchor safehtml.Identifier, contents []byte) []LicenseLine {
+	text := strings.TrimSuffix(string(contents), "\n")
+	if text == "" {
+		return nil
+	}
+	var lines []LicenseLine
+	for i, line := range strings.Split(text, "\n") {
+		lines = append(lines, LicenseLine{
+			Anchor: licenseLineAnchor(anchor, i+1),
+			Text:   line,
+		})
+	}
+	return lines
+}
+
+// licenseMatches returns the lines of l at which the license detector found
+// a license.
+func licenseMatches(anchor safehtml.Identifier, l *licenses.License) []LicenseMatch {
+	if l.Metadata == nil {
+		return nil
+	}
+	var matches []LicenseMatch
+	for _, m := range l.Coverage.Match {
+		if m.Start < 0 || m.Start > len(l.Contents) {
+			continue
+		}
+		line := bytes.Count(l.Contents[:m.Start], []byte("\n")) + 1
+		matches = append(matches, LicenseMatch{
+			Type:   m.Name,
+			Line:   line,
+			Anchor: licenseLineAnchor(anchor, line),
+		})
+	}
+	return matches
+}
+
+// licenseLineAnchor returns the anchor for line n of the license with the
+// given anchor.
+func licenseLineAnchor(anchor safehtml.Identifier, n int) safehtml.Identifier {
+	return safehtml.IdentifierFromConstantPrefix("lic", fmt.Sprintf("%s-L%d", strings.TrimPrefix(anchor.String(), "lic-"), n))
+}
+
 // transformLicenseMetadata transforms licenses.Metadata into a LicenseMetadata
 // by adding an anchor field.
 func transformLicenseMetadata(dbLicenses []*licenses.Metadata) []LicenseMetadata {
@@ -77,8 +190,10 @@ func transformLicenseMetadata(dbLicenses []*licenses.Metadata) []LicenseMetadata
 		anchor := anchors[i]
 		for _, typ := range l.Types {
 			mds = append(mds, LicenseMetadata{
-				Type:   typ,
-				Anchor: anchor,
+				Type:     typ,
+				Anchor:   anchor,
+				FilePath: l.FilePath,
+				Scope:    l.Scope(),
 			})
 		}
 	}
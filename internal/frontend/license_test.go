@@ -12,6 +12,7 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/licensecheck"
 	"github.com/google/safehtml"
 	"golang.org/x/pkgsite/internal"
 	"golang.org/x/pkgsite/internal/licenses"
@@ -43,6 +44,37 @@ func TestLicenseAnchors(t *testing.T) {
 	}
 }
 
+func TestLicenseLinesAndMatches(t *testing.T) {
+	contents := "Copyright\r\n\r\nPermission is hereby granted\r\n"
+	l := &licenses.License{
+		Metadata: &licenses.Metadata{
+			Types:    []string{"MIT"},
+			FilePath: "sub/LICENSE",
+			Coverage: licensecheck.Coverage{
+				Match: []licensecheck.Match{{Name: "MIT", Start: strings.Index(contents, "Permission")}},
+			},
+		},
+		Contents: []byte(contents),
+	}
+	got := transformLicenses(sample.ModulePath, "v1.2.3", []*licenses.License{l})[0]
+	var gotLines []string
+	for _, line := range got.Lines {
+		gotLines = append(gotLines, line.Anchor.String()+" "+line.Text)
+	}
+	wantLines := []string{"lic-0-L1 Copyright", "lic-0-L2 ", "lic-0-L3 Permission is hereby granted"}
+	if diff := cmp.Diff(wantLines, gotLines); diff != "" {
+		t.Errorf("lines mismatch (-want +got):\n%s", diff)
+	}
+	if len(got.Matches) != 1 || got.Matches[0].Line != 3 || got.Matches[0].Anchor.String() != "lic-0-L3" {
+		t.Errorf("got matches %+v, want one match at line 3", got.Matches)
+	}
+
+	mds := transformLicenseMetadata([]*licenses.Metadata{l.Metadata})
+	if len(mds) != 1 || mds[0].FilePath != "sub/LICENSE" || mds[0].Scope != "sub" {
+		t.Errorf("got metadata %+v, want FilePath sub/LICENSE, Scope sub", mds)
+	}
+}
+
 func TestFetchLicensesDetails(t *testing.T) {
 	testModule := sample.LegacyModule(sample.ModulePath, "v1.2.3", "A/B")
 	stdlibModule := sample.LegacyModule(stdlib.ModulePath, "v1.13.0", "cmd/go")
@@ -93,13 +125,17 @@ func TestFetchLicensesDetails(t *testing.T) {
 		err                                 error
 		name, fullPath, modulePath, version string
 		want                                []*licenses.License
+		// packages are the packages that each license applies to.
+		packages []string
 	}{
 		{
+			// Every license in the module is shown at its root.
 			name:       "module root",
 			fullPath:   sample.ModulePath,
 			modulePath: sample.ModulePath,
 			version:    testModule.Version,
-			want:       []*licenses.License{testModule.Licenses[1]},
+			want:       testModule.Licenses,
+			packages:   []string{sample.ModulePath + "/A/B"},
 		},
 		{
 			name:       "package without license",
@@ -107,6 +143,7 @@ func TestFetchLicensesDetails(t *testing.T) {
 			modulePath: sample.ModulePath,
 			version:    testModule.Version,
 			want:       []*licenses.License{testModule.Licenses[1]},
+			packages:   []string{sample.ModulePath + "/A/B"},
 		},
 		{
 			name:       "package with additional license",
@@ -114,6 +151,7 @@ func TestFetchLicensesDetails(t *testing.T) {
 			modulePath: sample.ModulePath,
 			version:    testModule.Version,
 			want:       testModule.Licenses,
+			packages:   []string{sample.ModulePath + "/A/B"},
 		},
 		{
 			name:       "stdlib directory",
@@ -121,6 +159,7 @@ func TestFetchLicensesDetails(t *testing.T) {
 			modulePath: stdlib.ModulePath,
 			version:    stdlibModule.Version,
 			want:       stdlibModule.Licenses,
+			packages:   []string{"cmd/go"},
 		},
 		{
 			name:       "stdlib package",
@@ -128,6 +167,7 @@ func TestFetchLicensesDetails(t *testing.T) {
 			modulePath: stdlib.ModulePath,
 			version:    stdlibModule.Version,
 			want:       stdlibModule.Licenses,
+			packages:   []string{"cmd/go"},
 		},
 		{
 			name:       "stdlib module",
@@ -135,6 +175,7 @@ func TestFetchLicensesDetails(t *testing.T) {
 			modulePath: stdlib.ModulePath,
 			version:    stdlibModule.Version,
 			want:       stdlibModule.Licenses,
+			packages:   []string{"cmd/go"},
 		},
 		{
 			name:       "module with CRLF line terminators",
@@ -142,11 +183,20 @@ func TestFetchLicensesDetails(t *testing.T) {
 			modulePath: crlfPath,
 			version:    crlfModule.Version,
 			want:       crlfModule.Licenses,
+			packages:   []string{crlfPath + "/A"},
 		},
 	} {
 		t.Run(test.name, func(t *testing.T) {
 			wantDetails := &LicensesDetails{Licenses: transformLicenses(
 				test.modulePath, test.version, test.want)}
+			for i := range wantDetails.Licenses {
+				for _, p := range test.packages {
+					wantDetails.Licenses[i].Packages = append(wantDetails.Licenses[i].Packages, LicensePackage{
+						Path: p,
+						URL:  constructPackageURL(p, test.modulePath, linkVersion(test.version, test.modulePath)),
+					})
+				}
+			}
 			got, err := fetchLicensesDetails(ctx, testDB, &internal.UnitMeta{
 				Path:       test.fullPath,
 				ModulePath: test.modulePath,
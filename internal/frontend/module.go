@@ -22,7 +22,7 @@ func (s *Server) serveModulePage(ctx context.Context, w http.ResponseWriter, r *
 		CommitTime:        um.CommitTime,
 		IsRedistributable: um.IsRedistributable,
 	}
-	modHeader := createModule(mi, um.Licenses, requestedVersion == internal.LatestVersion)
+	modHeader := createModule(ctx, mi, um.Licenses, requestedVersion == internal.LatestVersion)
 	tab := r.FormValue("tab")
 	settings, ok := moduleTabLookup[tab]
 	if !ok {
@@ -0,0 +1,190 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/google/safehtml"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/experiment"
+	"golang.org/x/pkgsite/internal/godoc"
+	"golang.org/x/pkgsite/internal/middleware"
+)
+
+// maxModuleDocPackages is the largest number of packages whose documentation
+// is shown on one module documentation page.
+const maxModuleDocPackages = 500
+
+// ModuleDocPage contains data for the module documentation template, which
+// shows the documentation of every package in a module version.
+type ModuleDocPage struct {
+	basePage
+	ModulePath     string
+	DisplayVersion string
+	ModuleURL      string // URL of the module's details page
+	Packages       []*ModuleDocPackage
+}
+
+// ModuleDocPackage is the documentation of a package on the module
+// documentation page.
+type ModuleDocPackage struct {
+	Path          string
+	Name          string
+	Synopsis      string
+	URL           string              // URL of the package's details page
+	ID            safehtml.Identifier // ID of the package's section
+	Index         []*ModuleDocEntry
+	Documentation safehtml.HTML
+}
+
+// ModuleDocEntry is a declaration in the combined index.
+type ModuleDocEntry struct {
+	Name string // like "Buffer.Len"
+	Kind string // "function", "type" or "method"
+	Href string // link to the declaration within the page
+}
+
+// serveModuleDoc serves the documentation of all packages in a module
+// version on a single page. It expects paths of the form
+// "/moddoc/<module-path>[@<version>]".
+func (s *Server) serveModuleDoc(w http.ResponseWriter, r *http.Request, ds internal.DataSource) (err error) {
+	defer derrors.Wrap(&err, "serveModuleDoc(%q)", r.URL.Path)
+
+	ctx := r.Context()
+	if !experiment.IsActive(ctx, internal.ExperimentModuleDoc) {
+		return &serverError{status: http.StatusNotFound}
+	}
+	urlInfo, err := extractURLPathInfo(strings.TrimPrefix(r.URL.Path, "/moddoc"))
+	if err != nil {
+		return &serverError{status: http.StatusBadRequest, err: err}
+	}
+	if err := validatePathAndVersion(ctx, ds, urlInfo.fullPath, urlInfo.requestedVersion); err != nil {
+		return err
+	}
+	um, err := ds.GetUnitMeta(ctx, urlInfo.fullPath, urlInfo.modulePath, urlInfo.requestedVersion)
+	if err != nil {
+		if errors.Is(err, derrors.NotFound) {
+			return &serverError{status: http.StatusNotFound, err: err}
+		}
+		return err
+	}
+	if um.Path != um.ModulePath {
+		return &serverError{
+			status:       http.StatusBadRequest,
+			responseText: fmt.Sprintf("%s is not a module", um.Path),
+		}
+	}
+	middleware.RecordCachedModule(ctx, um.ModulePath)
+	pkgs, err := fetchModuleDocPackages(ctx, ds, um)
+	if err != nil {
+		return err
+	}
+	page := &ModuleDocPage{
+		basePage:       s.newBasePage(r, fmt.Sprintf("%s documentation", um.ModulePath)),
+		ModulePath:     um.ModulePath,
+		DisplayVersion: displayVersion(um.Version, um.ModulePath),
+		ModuleURL:      constructModuleURL(um.ModulePath, linkVersion(um.Version, um.ModulePath)),
+		Packages:       pkgs,
+	}
+	s.servePage(ctx, w, "module_doc.tmpl", page)
+	return nil
+}
+
+// fetchModuleDocPackages returns the documentation of the packages in the
+// module of um, sorted by path.
+func fetchModuleDocPackages(ctx context.Context, ds internal.DataSource, um *internal.UnitMeta) (_ []*ModuleDocPackage, err error) {
+	defer derrors.Wrap(&err, "fetchModuleDocPackages(%q, %q)", um.ModulePath, um.Version)
+
+	u, err := ds.GetUnit(ctx, um, internal.WithSubdirectories)
+	if err != nil {
+		return nil, err
+	}
+	if len(u.Subdirectories) > maxModuleDocPackages {
+		return nil, &serverError{
+			status: http.StatusBadRequest,
+			responseText: fmt.Sprintf("%s has %d packages, more than can be shown on one page (%d)",
+				um.ModulePath, len(u.Subdirectories), maxModuleDocPackages),
+		}
+	}
+	var pkgs []*ModuleDocPackage
+	for _, pm := range u.Subdirectories {
+		pum := &internal.UnitMeta{
+			Path:              pm.Path,
+			Name:              pm.Name,
+			ModulePath:        um.ModulePath,
+			Version:           um.Version,
+			CommitTime:        um.CommitTime,
+			IsRedistributable: pm.IsRedistributable,
+			Licenses:          pm.Licenses,
+		}
+		pu, err := ds.GetUnit(ctx, pum, internal.WithDocumentation)
+		if err != nil {
+			return nil, err
+		}
+		p, err := newModuleDocPackage(ctx, pu)
+		if err != nil {
+			return nil, err
+		}
+		pkgs = append(pkgs, p)
+	}
+	sort.Slice(pkgs, func(i, j int) bool { return pkgs[i].Path < pkgs[j].Path })
+	return pkgs, nil
+}
+
+// newModuleDocPackage returns the documentation of the package u, with its
+// IDs changed so that they are unique on the page.
+func newModuleDocPackage(ctx context.Context, u *internal.Unit) (_ *ModuleDocPackage, err error) {
+	id := moduleDocID(u.Path)
+	prefix := id.String()
+	p := &ModuleDocPackage{
+		Path: u.Path,
+		Name: u.Name,
+		URL:  constructPackageURL(u.Path, u.ModulePath, linkVersion(u.Version, u.ModulePath)),
+		ID:   id,
+	}
+	if u.Documentation == nil || !u.IsRedistributable {
+		return p, nil
+	}
+	p.Synopsis = u.Documentation.Synopsis
+	docHTML := getHTML(ctx, u, godoc.HTMLOptions{})
+	body, err := godoc.Parse(docHTML, godoc.BodySection)
+	if err != nil {
+		return nil, err
+	}
+	if body.String() == "" {
+		// The documentation was rendered without the unit page templates.
+		body = docHTML
+	}
+	for _, d := range godoc.Declarations(body) {
+		p.Index = append(p.Index, &ModuleDocEntry{
+			Name: d.ID,
+			Kind: d.Kind,
+			Href: "#" + prefix + "-" + d.ID,
+		})
+	}
+	p.Documentation, err = godoc.PrefixIDs(body, prefix)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// nonIDCharRx matches the characters of an import path that cannot be used in
+// an ID prefix.
+var nonIDCharRx = regexp.MustCompile(`[^A-Za-z0-9_-]`)
+
+// moduleDocID returns the ID of the section for the package with the given
+// import path on the module documentation page.
+func moduleDocID(pkgPath string) safehtml.Identifier {
+	return safehtml.IdentifierFromConstantPrefix("pkg", nonIDCharRx.ReplaceAllString(pkgPath, "_"))
+}
@@ -0,0 +1,80 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/safehtml/uncheckedconversions"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/godoc/dochtml"
+)
+
+func TestModuleDocID(t *testing.T) {
+	for _, test := range []struct {
+		path, want string
+	}{
+		{"net/http", "pkg-net_http"},
+		{"github.com/a/b-c/d_e", "pkg-github_com_a_b-c_d_e"},
+		{"gopkg.in/yaml.v2", "pkg-gopkg_in_yaml_v2"},
+	} {
+		if got := moduleDocID(test.path).String(); got != test.want {
+			t.Errorf("moduleDocID(%q) = %q, want %q", test.path, got, test.want)
+		}
+	}
+}
+
+func TestNewModuleDocPackage(t *testing.T) {
+	docHTML := dochtml.IdentifierBodyStart +
+		`<h4 tabindex="-1" id="New" data-kind="function" class="Documentation-functionHeader">func New <a href="#New">¶</a></h4>` +
+		`<h4 tabindex="-1" id="T" data-kind="type" class="Documentation-typeHeader">type T <a href="#T">¶</a></h4>` +
+		dochtml.IdentifierBodyEnd
+	u := &internal.Unit{
+		UnitMeta: internal.UnitMeta{
+			Path:              "example.com/mod/p",
+			Name:              "p",
+			ModulePath:        "example.com/mod",
+			Version:           "v1.2.3",
+			IsRedistributable: true,
+		},
+		Documentation: &internal.Documentation{
+			Synopsis: "Package p does things.",
+			HTML:     uncheckedconversions.HTMLFromStringKnownToSatisfyTypeContract(docHTML),
+		},
+	}
+	got, err := newModuleDocPackage(context.Background(), u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "/example.com/mod@v1.2.3/p"; got.URL != want {
+		t.Errorf("URL = %q, want %q", got.URL, want)
+	}
+	wantIndex := []*ModuleDocEntry{
+		{Name: "New", Kind: "function", Href: "#pkg-example_com_mod_p-New"},
+		{Name: "T", Kind: "type", Href: "#pkg-example_com_mod_p-T"},
+	}
+	if diff := cmp.Diff(wantIndex, got.Index); diff != "" {
+		t.Errorf("index mismatch (-want +got):\n%s", diff)
+	}
+	wantDoc := dochtml.IdentifierBodyStart +
+		`<h4 tabindex="-1" id="pkg-example_com_mod_p-New" data-kind="function" class="Documentation-functionHeader">func New <a href="#pkg-example_com_mod_p-New">¶</a></h4>` +
+		`<h4 tabindex="-1" id="pkg-example_com_mod_p-T" data-kind="type" class="Documentation-typeHeader">type T <a href="#pkg-example_com_mod_p-T">¶</a></h4>` +
+		dochtml.IdentifierBodyEnd
+	if diff := cmp.Diff(wantDoc, got.Documentation.String()); diff != "" {
+		t.Errorf("documentation mismatch (-want +got):\n%s", diff)
+	}
+
+	// The documentation of packages that are not redistributable is not shown.
+	u.IsRedistributable = false
+	got, err = newModuleDocPackage(context.Background(), u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Documentation.String() != "" || got.Index != nil {
+		t.Errorf("got documentation for a non-redistributable package")
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"golang.org/x/pkgsite/internal/log"
+)
+
+// noIndexRoutes are the prefixes of the URL paths that are followed by the
+// path of a unit, other than "/" itself.
+var noIndexRoutes = []string{"/badge/", "/depends/", "/doc-section/", "/feed/", "/moddoc/"}
+
+// NoIndexFunc returns a function for middleware.NoIndex that reports whether
+// a request is for a page about a path for which isNoIndex returns true, so
+// that search engines are asked not to index it. If isNoIndex fails, the
+// page may be indexed.
+func NoIndexFunc(isNoIndex func(ctx context.Context, path string) (bool, error)) func(*http.Request) bool {
+	return func(r *http.Request) bool {
+		path := noIndexPath(r.URL.Path)
+		if path == "" {
+			return false
+		}
+		ni, err := isNoIndex(r.Context(), path)
+		if err != nil {
+			log.Errorf(r.Context(), "NoIndexFunc(%q): %v", r.URL.Path, err)
+			return false
+		}
+		return ni
+	}
+}
+
+// noIndexPath returns the unit path, possibly with a version, that the URL
+// path is about, or the empty string if there is none.
+func noIndexPath(urlPath string) string {
+	for _, route := range noIndexRoutes {
+		if strings.HasPrefix(urlPath, route) {
+			return strings.TrimPrefix(urlPath, route)
+		}
+	}
+	return strings.TrimPrefix(urlPath, "/")
+}
@@ -0,0 +1,39 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNoIndexFunc(t *testing.T) {
+	noIndex := NoIndexFunc(func(_ context.Context, path string) (bool, error) {
+		if path == "broken" {
+			return false, errors.New("bad")
+		}
+		return strings.HasPrefix(path, "corp.example.com/"), nil
+	})
+	for _, test := range []struct {
+		url  string
+		want bool
+	}{
+		{"/corp.example.com/mod@v1.0.0/pkg", true},
+		{"/corp.example.com/mod?tab=versions", true},
+		{"/feed/corp.example.com/mod", true},
+		{"/doc-section/corp.example.com/mod@v1.0.0?section=Functions", true},
+		{"/github.com/corp.example.com/mod", false},
+		{"/search?q=corp.example.com/", false},
+		{"/", false},
+		{"/broken", false},
+	} {
+		if got := noIndex(httptest.NewRequest("GET", test.url, nil)); got != test.want {
+			t.Errorf("%s: got %t, want %t", test.url, got, test.want)
+		}
+	}
+}
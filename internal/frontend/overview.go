@@ -12,6 +12,7 @@ import (
 	"net/url"
 	"path"
 	"path/filepath"
+	"regexp"
 	"strings"
 
 	"github.com/google/safehtml"
@@ -24,6 +25,7 @@ import (
 	"golang.org/x/pkgsite/internal"
 	"golang.org/x/pkgsite/internal/derrors"
 	"golang.org/x/pkgsite/internal/source"
+	"golang.org/x/pkgsite/internal/stdlib"
 )
 
 // OverviewDetails contains all of the data that the readme template
@@ -36,6 +38,31 @@ type OverviewDetails struct {
 	ReadMeSource     string
 	Redistributable  bool
 	RepositoryURL    string
+	// Module summarizes the module on the overview of a module whose root
+	// directory is not a package, if a summary was assembled when it was
+	// fetched.
+	Module *ModuleOverviewDetails
+}
+
+// ModuleOverviewDetails summarizes a module whose root directory is not a
+// package: how to install it, its licenses and its packages.
+type ModuleOverviewDetails struct {
+	InstallCommand string // like "go get example.com/m/..."
+	LicenseTypes   []string
+	LicensesURL    string
+	Packages       []*ModuleOverviewPackage
+	// MorePackages is the number of packages that are not listed. They are
+	// all on the page at PackagesURL.
+	MorePackages int
+	PackagesURL  string
+}
+
+// ModuleOverviewPackage is a package listed in a ModuleOverviewDetails.
+type ModuleOverviewPackage struct {
+	Suffix    string // path relative to the module path
+	URL       string
+	Synopsis  string
+	IsCommand bool
 }
 
 // fetchOverviewDetails uses the given version to fetch an OverviewDetails.
@@ -56,7 +83,40 @@ func fetchOverviewDetails(ctx context.Context, ds internal.DataSource, um *inter
 		IsRedistributable: um.IsRedistributable,
 		SourceInfo:        u.SourceInfo,
 	}
-	return constructOverviewDetails(ctx, mi, readme, u.IsRedistributable, versionedLinks)
+	od, err := constructOverviewDetails(ctx, mi, readme, u.IsRedistributable, versionedLinks)
+	if err != nil {
+		return nil, err
+	}
+	if um.IsModule() && !um.IsPackage() && um.Metadata != nil && um.Metadata.Overview != nil {
+		lv := internal.LatestVersion
+		if versionedLinks {
+			lv = linkVersion(um.Version, um.ModulePath)
+		}
+		od.Module = moduleOverviewDetails(um.ModulePath, lv, um.Metadata.Overview)
+	}
+	return od, nil
+}
+
+// moduleOverviewDetails returns the ModuleOverviewDetails for the module at
+// modulePath with the overview o. Its links are to linkVersion.
+func moduleOverviewDetails(modulePath, linkVersion string, o *internal.ModuleOverview) *ModuleOverviewDetails {
+	moduleURL := constructModuleURL(modulePath, linkVersion)
+	d := &ModuleOverviewDetails{
+		InstallCommand: fmt.Sprintf("go get %s/...", modulePath),
+		LicenseTypes:   o.LicenseTypes,
+		LicensesURL:    moduleURL + "?tab=licenses",
+		MorePackages:   o.NumPackages - len(o.Packages),
+		PackagesURL:    moduleURL + "?tab=packages",
+	}
+	for _, p := range o.Packages {
+		d.Packages = append(d.Packages, &ModuleOverviewPackage{
+			Suffix:    internal.Suffix(p.Path, modulePath),
+			URL:       constructPackageURL(p.Path, modulePath, linkVersion),
+			Synopsis:  p.Synopsis,
+			IsCommand: p.IsCommand,
+		})
+	}
+	return d
 }
 
 // constructOverviewDetails uses the given module version and readme to
@@ -102,6 +162,13 @@ func fetchPackageOverviewDetails(ctx context.Context, ds internal.DataSource, um
 //
 // It is exported to support external testing.
 func ReadmeHTML(ctx context.Context, mi *internal.ModuleInfo, readme *internal.Readme) (_ safehtml.HTML, err error) {
+	return readmeHTML(ctx, mi, readme, nil)
+}
+
+// readmeHTML is like ReadmeHTML, but links to directories of the module
+// whose paths are in packagePaths go to the pages of those packages, instead
+// of to their source.
+func readmeHTML(ctx context.Context, mi *internal.ModuleInfo, readme *internal.Readme, packagePaths map[string]bool) (_ safehtml.HTML, err error) {
 	defer derrors.Wrap(&err, "readmeHTML(%s@%s)", mi.ModulePath, mi.Version)
 	if readme == nil || readme.Contents == "" {
 		return safehtml.HTML{}, nil
@@ -124,10 +191,42 @@ func ReadmeHTML(ctx context.Context, mi *internal.ModuleInfo, readme *internal.R
 	b := &bytes.Buffer{}
 	contents := bytes.ReplaceAll([]byte(readme.Contents), []byte("\r"), nil)
 	rootNode := parser.Parse(contents)
-	var walkErr error
+	var (
+		walkErr    error
+		headings   []*readmeHeading
+		headingIDs = map[string]bool{}
+	)
 	rootNode.Walk(func(node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
 		switch node.Type {
+		case blackfriday.Heading:
+			if node.HeadingID == "" {
+				break
+			}
+			if entering {
+				// Make the ID unique here, rather than leaving it to the
+				// renderer, so that the anchor and the table of contents
+				// can link to it.
+				node.HeadingID = uniqueHeadingID(headingIDs, node.HeadingID)
+				if node.Level <= maxReadmeTOCLevel {
+					headings = append(headings, &readmeHeading{
+						Level: node.Level,
+						ID:    node.HeadingID,
+						Text:  headingText(node),
+					})
+				}
+			} else {
+				// The ID is made of letters, digits, '-' and '_', so it
+				// needs no escaping.
+				fmt.Fprintf(b, `<a class="%s" href="#%s" title="Link to this heading">¶</a>`,
+					readmeHeadingAnchorClass, node.HeadingID)
+			}
 		case blackfriday.Image, blackfriday.Link:
+			if node.Type == blackfriday.Link {
+				if d := translatePackageLink(string(node.LinkData.Destination), mi, readme, packagePaths); d != "" {
+					node.LinkData.Destination = []byte(d)
+					break
+				}
+			}
 			useRaw := node.Type == blackfriday.Image
 			if d := translateRelativeLink(string(node.LinkData.Destination), mi.SourceInfo, useRaw, readme); d != "" {
 				node.LinkData.Destination = []byte(d)
@@ -145,7 +244,64 @@ func ReadmeHTML(ctx context.Context, mi *internal.ModuleInfo, readme *internal.R
 	if walkErr != nil {
 		return safehtml.HTML{}, walkErr
 	}
-	return sanitizeHTML(b), nil
+	h := sanitizeHTML(b)
+	if len(headings) < minReadmeTOCHeadings {
+		return h, nil
+	}
+	toc, err := readmeTOCTemplate.ExecuteToHTML(headings)
+	if err != nil {
+		return safehtml.HTML{}, err
+	}
+	return safehtml.HTMLConcat(toc, h), nil
+}
+
+const (
+	// minReadmeTOCHeadings is the smallest number of headings of a README
+	// for which a table of contents is displayed.
+	minReadmeTOCHeadings = 4
+	// maxReadmeTOCLevel is the level of the smallest headings in the table
+	// of contents.
+	maxReadmeTOCLevel = 3
+	// readmeHeadingAnchorClass is the class of the links that follow
+	// README headings.
+	readmeHeadingAnchorClass = "Readme-headingAnchor"
+)
+
+// readmeHeading is a heading of a README, listed in its table of contents.
+type readmeHeading struct {
+	Level int
+	ID    string
+	Text  string
+}
+
+// readmeTOCTemplate renders the table of contents of a README: a
+// collapsible list of its headings.
+var readmeTOCTemplate = template.Must(template.New("").Parse(
+	`<details class="Readme-toc"><summary>Contents</summary><ul class="Readme-tocList">` +
+		`{{range .}}<li class="Readme-tocItem Readme-tocItem--level{{.Level}}"><a href="#{{.ID}}">{{.Text}}</a></li>{{end}}` +
+		`</ul></details>`))
+
+// uniqueHeadingID returns id, or id followed by a number if id is in ids,
+// and adds the result to ids.
+func uniqueHeadingID(ids map[string]bool, id string) string {
+	u := id
+	for i := 1; ids[u]; i++ {
+		u = fmt.Sprintf("%s-%d", id, i)
+	}
+	ids[u] = true
+	return u
+}
+
+// headingText returns the text of the heading node, without markup.
+func headingText(heading *blackfriday.Node) string {
+	var b strings.Builder
+	heading.Walk(func(node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		if entering && (node.Type == blackfriday.Text || node.Type == blackfriday.Code) {
+			b.Write(node.Literal)
+		}
+		return blackfriday.GoToNext
+	})
+	return strings.TrimSpace(b.String())
 }
 
 // sanitizeHTML reads HTML from r and sanitizes it to ensure it is safe.
@@ -162,6 +318,8 @@ func sanitizeHTML(r io.Reader) safehtml.HTML {
 	p.AllowAttrs("width", "align").OnElements("img")
 	p.AllowAttrs("width", "align").OnElements("div")
 	p.AllowAttrs("width", "align").OnElements("p")
+	// Allow the class of the anchors that readmeHTML adds to headings.
+	p.AllowAttrs("class").Matching(regexp.MustCompile("^" + readmeHeadingAnchorClass + "$")).OnElements("a")
 	s := p.SanitizeReader(r).String()
 	// Trust that bluemonday properly sanitizes the HTML.
 	return uncheckedconversions.HTMLFromStringKnownToSatisfyTypeContract(s)
@@ -197,6 +355,41 @@ func translateRelativeLink(dest string, info *source.Info, useRaw bool, readme *
 	return info.FileURL(destPath)
 }
 
+// translatePackageLink converts a relative link to a directory of the module
+// that contains a package to the URL of the package's page, at the module's
+// version. It returns the empty string if dest is not such a link, or if
+// packagePaths, the paths of the packages in the module, does not contain
+// the directory.
+//
+// README files often link to the packages of their module; following those
+// links keeps users on the site, instead of sending them to the source.
+func translatePackageLink(dest string, mi *internal.ModuleInfo, readme *internal.Readme, packagePaths map[string]bool) string {
+	if len(packagePaths) == 0 || mi.ModulePath == stdlib.ModulePath {
+		return ""
+	}
+	destURL, err := url.Parse(dest)
+	if err != nil || destURL.IsAbs() || destURL.Host != "" || destURL.Path == "" {
+		return ""
+	}
+	// Paths are relative to the README location.
+	dir := path.Join(path.Dir(readme.Filepath), path.Clean(strings.TrimSpace(destURL.Path)))
+	if dir == ".." || strings.HasPrefix(dir, "../") {
+		return ""
+	}
+	fullPath := mi.ModulePath
+	if dir != "." {
+		fullPath = path.Join(mi.ModulePath, dir)
+	}
+	if !packagePaths[fullPath] {
+		return ""
+	}
+	u := constructPackageURL(fullPath, mi.ModulePath, linkVersion(mi.Version, mi.ModulePath))
+	if destURL.Fragment != "" {
+		u += "#" + destURL.Fragment
+	}
+	return u
+}
+
 // trimmedEscapedPath trims surrounding whitespace from u's path, then returns it escaped.
 func trimmedEscapedPath(u *url.URL) string {
 	u.Path = strings.TrimSpace(u.Path)
@@ -61,6 +61,32 @@ func TestFetchOverviewDetails(t *testing.T) {
 	}
 }
 
+func TestModuleOverviewDetails(t *testing.T) {
+	const modulePath = "example.com/m"
+	o := &internal.ModuleOverview{
+		Packages: []*internal.PackageOverview{
+			{Path: modulePath + "/a", Synopsis: "Package a is a."},
+			{Path: modulePath + "/cmd/tool", IsCommand: true},
+		},
+		NumPackages:  5,
+		LicenseTypes: []string{"MIT"},
+	}
+	want := &ModuleOverviewDetails{
+		InstallCommand: "go get example.com/m/...",
+		LicenseTypes:   []string{"MIT"},
+		LicensesURL:    "/mod/example.com/m@v1.2.0?tab=licenses",
+		Packages: []*ModuleOverviewPackage{
+			{Suffix: "a", URL: "/example.com/m@v1.2.0/a", Synopsis: "Package a is a."},
+			{Suffix: "cmd/tool", URL: "/example.com/m@v1.2.0/cmd/tool", IsCommand: true},
+		},
+		MorePackages: 3,
+		PackagesURL:  "/mod/example.com/m@v1.2.0?tab=packages",
+	}
+	if diff := cmp.Diff(want, moduleOverviewDetails(modulePath, "v1.2.0", o)); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
 func TestPackageOverviewDetails(t *testing.T) {
 	for _, test := range []struct {
 		name           string
@@ -308,7 +334,7 @@ func TestReadmeHTML(t *testing.T) {
 				Filepath: "README.md",
 				Contents: "<img src=\"resources/logoSmall.png\" />\n\n# Heading\n",
 			},
-			want: `<p><img src="https://github.com/some/repo/raw/v1.2.3/resources/logoSmall.png"/></p>` + "\n\n" + `<h1 id="heading">Heading</h1>`,
+			want: `<p><img src="https://github.com/some/repo/raw/v1.2.3/resources/logoSmall.png"/></p>` + "\n\n" + `<h1 id="heading">Heading` + headingAnchor("heading") + `</h1>`,
 		},
 		{
 			name: "image link in embedded HTML with surrounding p tag",
@@ -317,7 +343,7 @@ func TestReadmeHTML(t *testing.T) {
 				Filepath: "README.md",
 				Contents: "<p align=\"center\"><img src=\"foo.png\" /></p>\n\n# Heading",
 			},
-			want: `<p align="center"><img src="https://github.com/some/repo/raw/v1.2.3/foo.png"/></p>` + "\n\n" + `<h1 id="heading">Heading</h1>`,
+			want: `<p align="center"><img src="https://github.com/some/repo/raw/v1.2.3/foo.png"/></p>` + "\n\n" + `<h1 id="heading">Heading` + headingAnchor("heading") + `</h1>`,
 		},
 		{
 			name: "image link in embedded HTML with surrounding div",
@@ -326,7 +352,7 @@ func TestReadmeHTML(t *testing.T) {
 				Filepath: "README.md",
 				Contents: "<div align=\"center\"><img src=\"foo.png\" /></div>\n\n# Heading",
 			},
-			want: `<div align="center"><img src="https://github.com/some/repo/raw/v1.2.3/foo.png"/></div>` + "\n\n" + `<h1 id="heading">Heading</h1>`,
+			want: `<div align="center"><img src="https://github.com/some/repo/raw/v1.2.3/foo.png"/></div>` + "\n\n" + `<h1 id="heading">Heading` + headingAnchor("heading") + `</h1>`,
 		},
 		{
 			name: "image link with bad URL",
@@ -338,7 +364,7 @@ func TestReadmeHTML(t *testing.T) {
 				Filepath: "README.md",
 				Contents: "<div align=\"center\"><img src=\"foo.png\" /></div>\n\n# Heading",
 			},
-			want: `<div align="center"><img src="https://github.com/some/%3Cscript%3E/raw/v1.2.3/foo.png"/></div>` + "\n\n" + `<h1 id="heading">Heading</h1>`,
+			want: `<div align="center"><img src="https://github.com/some/%3Cscript%3E/raw/v1.2.3/foo.png"/></div>` + "\n\n" + `<h1 id="heading">Heading` + headingAnchor("heading") + `</h1>`,
 		},
 		{
 			name: "body has more than one child",
@@ -373,6 +399,45 @@ func TestReadmeHTML(t *testing.T) {
 	}
 }
 
+func TestReadmeHTMLPackageLinks(t *testing.T) {
+	ctx := context.Background()
+	mi := &internal.ModuleInfo{
+		ModulePath: "github.com/some/repo",
+		Version:    "v1.2.3",
+		SourceInfo: source.NewGitHubInfo("https://github.com/some/repo", "", "v1.2.3"),
+	}
+	packagePaths := map[string]bool{
+		"github.com/some/repo/a":   true,
+		"github.com/some/repo/a/b": true,
+	}
+	readme := &internal.Readme{
+		Filepath: "a/README.md",
+		Contents: "[b](b) [b section](./b/#usage) [a](.) [docs](../docs) [file](b/b.go) [other](../x)",
+	}
+	got, err := readmeHTML(ctx, mi, readme, packagePaths)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<p><a href="/github.com/some/repo@v1.2.3/a/b" rel="nofollow">b</a>` +
+		` <a href="/github.com/some/repo@v1.2.3/a/b#usage" rel="nofollow">b section</a>` +
+		` <a href="/github.com/some/repo@v1.2.3/a" rel="nofollow">a</a>` +
+		` <a href="https://github.com/some/repo/blob/v1.2.3/docs" rel="nofollow">docs</a>` +
+		` <a href="https://github.com/some/repo/blob/v1.2.3/a/b/b.go" rel="nofollow">file</a>` +
+		` <a href="https://github.com/some/repo/blob/v1.2.3/x" rel="nofollow">other</a></p>`
+	if diff := cmp.Diff(want, strings.TrimSpace(got.String())); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	// Without package paths, links go to the source.
+	got, err = ReadmeHTML(ctx, mi, readme)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(got.String(), `href="/github.com`) {
+		t.Errorf("ReadmeHTML linked to a package page: %s", got)
+	}
+}
+
 func TestTrimmedEscapedPath(t *testing.T) {
 	for _, test := range []struct {
 		in, want string
@@ -412,3 +477,52 @@ func TestPackageSubdir(t *testing.T) {
 		}
 	}
 }
+
+// headingAnchor returns the link that readmeHTML adds to the heading with
+// the given ID.
+func headingAnchor(id string) string {
+	return `<a class="Readme-headingAnchor" href="#` + id + `" title="Link to this heading" rel="nofollow">¶</a>`
+}
+
+func TestReadmeHTMLTableOfContents(t *testing.T) {
+	ctx := context.Background()
+	mi := &internal.ModuleInfo{
+		ModulePath: "github.com/some/repo",
+		Version:    "v1.2.3",
+		SourceInfo: source.NewGitHubInfo("https://github.com/some/repo", "", "v1.2.3"),
+	}
+	readme := &internal.Readme{
+		Filepath: "README.md",
+		Contents: "# Repo\n\n## Install\n\n## Usage\n\n### The `Run` *function*\n\n#### Details\n\n## Usage\n",
+	}
+	got, err := ReadmeHTML(ctx, mi, readme)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<details class="Readme-toc"><summary>Contents</summary><ul class="Readme-tocList">` +
+		`<li class="Readme-tocItem Readme-tocItem--level1"><a href="#repo">Repo</a></li>` +
+		`<li class="Readme-tocItem Readme-tocItem--level2"><a href="#install">Install</a></li>` +
+		`<li class="Readme-tocItem Readme-tocItem--level2"><a href="#usage">Usage</a></li>` +
+		`<li class="Readme-tocItem Readme-tocItem--level3"><a href="#the-run-function">The Run function</a></li>` +
+		`<li class="Readme-tocItem Readme-tocItem--level2"><a href="#usage-1">Usage</a></li>` +
+		`</ul></details>` +
+		`<h1 id="repo">Repo` + headingAnchor("repo") + "</h1>\n\n" +
+		`<h2 id="install">Install` + headingAnchor("install") + "</h2>\n\n" +
+		`<h2 id="usage">Usage` + headingAnchor("usage") + "</h2>\n\n" +
+		`<h3 id="the-run-function">The <code>Run</code> <em>function</em>` + headingAnchor("the-run-function") + "</h3>\n\n" +
+		`<h4 id="details">Details` + headingAnchor("details") + "</h4>\n\n" +
+		`<h2 id="usage-1">Usage` + headingAnchor("usage-1") + "</h2>\n"
+	if diff := cmp.Diff(want, got.String()); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	// A short README has no table of contents.
+	readme.Contents = "# Repo\n\n## Install\n"
+	got, err = ReadmeHTML(ctx, mi, readme)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(got.String(), "Readme-toc") {
+		t.Errorf("short README has a table of contents: %s", got)
+	}
+}
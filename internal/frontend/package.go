@@ -53,7 +53,7 @@ func (s *Server) servePackagePage(ctx context.Context,
 		CommitTime:        um.CommitTime,
 		IsRedistributable: um.IsRedistributable,
 	}
-	pkgHeader, err := createPackage(&internal.PackageMeta{
+	pkgHeader, err := createPackage(ctx, &internal.PackageMeta{
 		Path:              um.Path,
 		Licenses:          um.Licenses,
 		IsRedistributable: um.IsRedistributable,
@@ -103,6 +103,9 @@ func (s *Server) servePackagePage(ctx context.Context,
 			pkgHeader.Module.LinkVersion),
 	}
 	page.basePage.AllowWideContent = settings.Name == tabDoc
+	if settings.Name == tabDoc {
+		page.Feedback = newFeedbackForm(ctx, um.Path, um.Version, r.URL.RequestURI())
+	}
 	s.servePage(ctx, w, settings.TemplateName, page)
 	return nil
 }
@@ -72,23 +72,89 @@ func (p paginationParams) offset() int {
 
 // newPaginationParams extracts pagination params from the request.
 func newPaginationParams(r *http.Request, defaultLimit int) paginationParams {
-	positiveParam := func(key string, dflt int) (val int) {
-		var err error
-		if a := r.FormValue(key); a != "" {
-			val, err = strconv.Atoi(a)
-			if err != nil {
-				log.Errorf(r.Context(), "strconv.Atoi(%q) for page: %v", a, err)
-			}
-		}
-		if val < 1 {
-			val = dflt
+	return paginationParams{
+		baseURL: r.URL,
+		page:    positiveParam(r, "page", 1),
+		limit:   positiveParam(r, "limit", defaultLimit),
+	}
+}
+
+// positiveParam returns the value of the query parameter key of r, or dflt
+// if it is missing or not a positive integer.
+func positiveParam(r *http.Request, key string, dflt int) (val int) {
+	var err error
+	if a := r.FormValue(key); a != "" {
+		val, err = strconv.Atoi(a)
+		if err != nil {
+			log.Errorf(r.Context(), "strconv.Atoi(%q) for %s: %v", a, key, err)
 		}
-		return val
 	}
-	return paginationParams{
+	if val < 1 {
+		val = dflt
+	}
+	return val
+}
+
+// keysetPagination holds information related to the display of results that
+// are paginated by key rather than by offset: each page except the first
+// starts after the last result of the page before, which is identified by
+// the "after" query parameter. Unlike with pagination, the results on a page
+// don't shift when results before it are added or removed, but only the
+// first and next pages can be linked to.
+type keysetPagination struct {
+	baseURL     *url.URL
+	ResultCount int    // number of results on this page
+	IsFirst     bool   // whether this is the first page
+	NextAfter   string // "after" parameter of the next page, or empty on the last page
+}
+
+// FirstURL constructs a URL that displays the first page.
+func (p keysetPagination) FirstURL() string {
+	return p.urlAfter("")
+}
+
+// NextURL constructs a URL that displays the next page.
+func (p keysetPagination) NextURL() string {
+	return p.urlAfter(p.NextAfter)
+}
+
+func (p keysetPagination) urlAfter(after string) string {
+	u := *p.baseURL
+	q := u.Query()
+	if after == "" {
+		q.Del("after")
+	} else {
+		q.Set("after", after)
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// keysetParams holds keyset pagination parameters extracted from the request.
+type keysetParams struct {
+	baseURL *url.URL
+	after   string // the key of the result before the page, or empty for the first page
+	limit   int    // the maximum number of results to display on the page
+}
+
+// newKeysetParams extracts keyset pagination params from the request.
+func newKeysetParams(r *http.Request, defaultLimit int) keysetParams {
+	return keysetParams{
 		baseURL: r.URL,
-		page:    positiveParam("page", 1),
-		limit:   positiveParam("limit", defaultLimit),
+		after:   r.FormValue("after"),
+		limit:   positiveParam(r, "limit", defaultLimit),
+	}
+}
+
+// newKeysetPagination constructs a keysetPagination for a page of
+// resultCount results. nextAfter is the key of the last of them if there
+// are more results, and empty otherwise.
+func newKeysetPagination(params keysetParams, resultCount int, nextAfter string) keysetPagination {
+	return keysetPagination{
+		baseURL:     params.baseURL,
+		ResultCount: resultCount,
+		IsFirst:     params.after == "",
+		NextAfter:   nextAfter,
 	}
 }
 
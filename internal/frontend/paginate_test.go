@@ -5,6 +5,7 @@
 package frontend
 
 import (
+	"net/http/httptest"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -197,3 +198,25 @@ func TestPagesToDisplay(t *testing.T) {
 		})
 	}
 }
+
+func TestKeysetPagination(t *testing.T) {
+	r := httptest.NewRequest("GET", "/example.com/pkg?tab=importedby&after=3:example.com/a&limit=2", nil)
+	params := newKeysetParams(r, 10)
+	if params.after != "3:example.com/a" || params.limit != 2 {
+		t.Fatalf("newKeysetParams: got after %q, limit %d", params.after, params.limit)
+	}
+	p := newKeysetPagination(params, 2, "0:example.com/b")
+	if p.IsFirst {
+		t.Error("IsFirst = true, want false")
+	}
+	for _, test := range []struct {
+		name, got, want string
+	}{
+		{"FirstURL", p.FirstURL(), "/example.com/pkg?limit=2&tab=importedby"},
+		{"NextURL", p.NextURL(), "/example.com/pkg?after=0%3Aexample.com%2Fb&limit=2&tab=importedby"},
+	} {
+		if test.got != test.want {
+			t.Errorf("%s = %q, want %q", test.name, test.got, test.want)
+		}
+	}
+}
@@ -5,18 +5,52 @@
 package frontend
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"strconv"
+	"strings"
+	"sync"
 
+	"github.com/golang/groupcache/lru"
 	"go.opencensus.io/stats"
 	"go.opencensus.io/stats/view"
 	"go.opencensus.io/tag"
 	"golang.org/x/pkgsite/internal/log"
+	"golang.org/x/pkgsite/internal/requestid"
 )
 
-// playgroundURL is the playground endpoint used for share links.
-const playgroundURL = "https://play.golang.org"
+// defaultPlaygroundURL is the playground used for share links, unless
+// ServerConfig.PlaygroundURL says otherwise.
+const defaultPlaygroundURL = "https://play.golang.org"
+
+const (
+	// maxSnippetSize is the size of the largest snippet that can be shared,
+	// which is also the playground's limit.
+	maxSnippetSize = 64 << 10
+
+	// shareCacheSize is the number of share IDs of snippets that are
+	// cached in memory.
+	shareCacheSize = 1000
+)
+
+// playgroundClient is the client for requests to the playground. It passes
+// on the ID of the request being served.
+var playgroundClient = &http.Client{Transport: &requestid.Transport{}}
+
+// playgroundPost posts a snippet to the share endpoint of a playground.
+// Tests replace it to avoid making requests.
+var playgroundPost = func(ctx context.Context, shareURL string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, shareURL, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	return playgroundClient.Do(req)
+}
 
 var (
 	keyPlaygroundShareStatus = tag.MustNewKey("playground.share.status")
@@ -35,49 +69,107 @@ var (
 	}
 )
 
-// handlePlay handles requests that mirror play.golang.org/share.
-func (s *Server) handlePlay(w http.ResponseWriter, r *http.Request) {
-	makeFetchPlayRequest(w, r, playgroundURL)
+// handlePlayShare handles POST requests to /play/share, which mirror
+// <playground>/share: the body is a snippet, and the response is its share
+// ID. Serving them on the same origin as the page spares browsers a
+// cross-origin request to the playground. Share IDs are cached, since
+// examples are shared again and again.
+func (s *Server) handlePlayShare(w http.ResponseWriter, r *http.Request) {
+	makeFetchPlayRequest(w, r, s.playgroundURL, s.shareCache)
+}
+
+// handlePlayRedirect redirects /play/p/<id> to the snippet with that share
+// ID on the playground, so that pages need not know which playground is
+// used.
+func (s *Server) handlePlayRedirect(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/play/p/")
+	if id == "" || strings.ContainsAny(id, "/?#") {
+		httpErrorStatus(w, http.StatusNotFound)
+		return
+	}
+	http.Redirect(w, r, s.playgroundURL+"/p/"+id, http.StatusFound)
 }
 
 func httpErrorStatus(w http.ResponseWriter, status int) {
 	http.Error(w, http.StatusText(status), status)
 }
 
-func makeFetchPlayRequest(w http.ResponseWriter, r *http.Request, pgURL string) {
+func makeFetchPlayRequest(w http.ResponseWriter, r *http.Request, pgURL string, cache *shareCache) {
 	ctx := r.Context()
 	if r.Method != http.MethodPost {
 		httpErrorStatus(w, http.StatusMethodNotAllowed)
 		return
 	}
-	req, err := http.NewRequest("POST", pgURL+"/share", r.Body)
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxSnippetSize))
 	if err != nil {
-		log.Errorf(ctx, "ERROR share error: %v", err)
-		httpErrorStatus(w, http.StatusInternalServerError)
+		httpErrorStatus(w, http.StatusRequestEntityTooLarge)
 		return
 	}
-	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
-	req = req.WithContext(r.Context())
-	resp, err := http.DefaultClient.Do(req)
+	key := sha256.Sum256(body)
+	if id, ok := cache.get(key); ok {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if _, err := io.WriteString(w, id); err != nil {
+			log.Errorf(ctx, "ERROR writing shareId: %v", err)
+		}
+		return
+	}
+	resp, err := playgroundPost(ctx, pgURL+"/share", bytes.NewReader(body))
 	if err != nil {
 		log.Errorf(ctx, "ERROR share error: %v", err)
 		httpErrorStatus(w, http.StatusInternalServerError)
 		return
 	}
+	defer resp.Body.Close()
 	stats.RecordWithTags(r.Context(),
 		[]tag.Mutator{tag.Upsert(keyPlaygroundShareStatus, strconv.Itoa(resp.StatusCode))},
 		playgroundShareStatus.M(int64(resp.StatusCode)),
 	)
-	copyHeader := func(k string) {
-		if v := resp.Header.Get(k); v != "" {
-			w.Header().Set(k, v)
-		}
+	id, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxSnippetSize))
+	if err != nil {
+		log.Errorf(ctx, "ERROR reading shareId: %v", err)
+		httpErrorStatus(w, http.StatusBadGateway)
+		return
+	}
+	if resp.StatusCode == http.StatusOK && len(id) > 0 {
+		cache.add(key, string(id))
+	}
+	if v := resp.Header.Get("Content-Type"); v != "" {
+		w.Header().Set("Content-Type", v)
 	}
-	copyHeader("Content-Type")
-	copyHeader("Content-Length")
-	defer resp.Body.Close()
 	w.WriteHeader(resp.StatusCode)
-	if _, err := io.Copy(w, resp.Body); err != nil {
+	if _, err := w.Write(id); err != nil {
 		log.Errorf(ctx, "ERROR writing shareId: %v", err)
 	}
 }
+
+// shareCache is an in-memory LRU cache of the share IDs of snippets, by the
+// SHA-256 hash of the snippet. A snippet's share ID never changes, so the
+// entries don't expire. It is safe for concurrent use.
+type shareCache struct {
+	mu    sync.Mutex
+	cache *lru.Cache
+}
+
+// newShareCache returns a shareCache that holds up to size entries.
+func newShareCache(size int) *shareCache {
+	return &shareCache{cache: lru.New(size)}
+}
+
+// get returns the share ID of the snippet with the given hash, if it is
+// cached.
+func (c *shareCache) get(key [sha256.Size]byte) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	v, ok := c.cache.Get(key)
+	if !ok {
+		return "", false
+	}
+	return v.(string), true
+}
+
+// add caches the share ID of the snippet with the given hash.
+func (c *shareCache) add(key [sha256.Size]byte, id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cache.Add(key, id)
+}
@@ -5,7 +5,9 @@
 package frontend
 
 import (
+	"context"
 	"flag"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
@@ -19,7 +21,7 @@ var playground = flag.Bool("playground", false, "Make a request to https://play.
 const testShareID = "arbitraryShareID"
 
 func TestPlaygroundShare(t *testing.T) {
-	pgURL := playgroundURL
+	pgURL := defaultPlaygroundURL
 	if !*playground {
 		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			if r.Method != http.MethodPost {
@@ -86,7 +88,7 @@ func main() {
 			}
 			req.Header.Set("Content-Type", "text/plain; charset=utf-8")
 			w := httptest.NewRecorder()
-			makeFetchPlayRequest(w, req, tc.pgURL)
+			makeFetchPlayRequest(w, req, tc.pgURL, newShareCache(10))
 
 			res := w.Result()
 			if got, want := res.StatusCode, tc.code; got != want {
@@ -109,3 +111,72 @@ func main() {
 		})
 	}
 }
+
+func TestPlaygroundShareCache(t *testing.T) {
+	var posts int
+	defer func(orig func(context.Context, string, io.Reader) (*http.Response, error)) { playgroundPost = orig }(playgroundPost)
+	playgroundPost = func(_ context.Context, shareURL string, body io.Reader) (*http.Response, error) {
+		posts++
+		if want := "https://play.example.com/share"; shareURL != want {
+			t.Errorf("got share URL %q, want %q", shareURL, want)
+		}
+		b, err := ioutil.ReadAll(body)
+		if err != nil {
+			return nil, err
+		}
+		w := httptest.NewRecorder()
+		if string(b) == "fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return w.Result(), nil
+		}
+		fmt.Fprintf(w, "id-%d", posts)
+		return w.Result(), nil
+	}
+
+	cache := newShareCache(10)
+	share := func(body string) (int, string) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		makeFetchPlayRequest(w, httptest.NewRequest(http.MethodPost, "/play/share", strings.NewReader(body)),
+			"https://play.example.com", cache)
+		return w.Code, w.Body.String()
+	}
+	for _, test := range []struct {
+		body      string
+		wantCode  int
+		wantID    string
+		wantPosts int
+	}{
+		{"package a", http.StatusOK, "id-1", 1},
+		{"package b", http.StatusOK, "id-2", 2},
+		{"package a", http.StatusOK, "id-1", 2}, // cached
+		{"fail", http.StatusInternalServerError, "", 3},
+		{"fail", http.StatusInternalServerError, "", 4}, // failures aren't cached
+		{strings.Repeat("x", maxSnippetSize+1), http.StatusRequestEntityTooLarge, "", 4},
+	} {
+		code, id := share(test.body)
+		if code != test.wantCode || (test.wantID != "" && id != test.wantID) || posts != test.wantPosts {
+			t.Errorf("%.10q: got %d, %q, %d posts; want %d, %q, %d posts",
+				test.body, code, id, posts, test.wantCode, test.wantID, test.wantPosts)
+		}
+	}
+}
+
+func TestPlaygroundRedirect(t *testing.T) {
+	s := &Server{playgroundURL: "https://play.example.com"}
+	for _, test := range []struct {
+		path         string
+		wantCode     int
+		wantLocation string
+	}{
+		{"/play/p/abc-12_3", http.StatusFound, "https://play.example.com/p/abc-12_3"},
+		{"/play/p/", http.StatusNotFound, ""},
+		{"/play/p/a/b", http.StatusNotFound, ""},
+	} {
+		w := httptest.NewRecorder()
+		s.handlePlayRedirect(w, httptest.NewRequest(http.MethodGet, test.path, nil))
+		if w.Code != test.wantCode || w.Header().Get("Location") != test.wantLocation {
+			t.Errorf("%s: got %d, %q; want %d, %q", test.path, w.Code, w.Header().Get("Location"), test.wantCode, test.wantLocation)
+		}
+	}
+}
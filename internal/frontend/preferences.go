@@ -0,0 +1,125 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/postgres"
+	"golang.org/x/pkgsite/internal/preferences"
+)
+
+// PreferencesPage contains the data for the page on which signed-in users
+// set their display preferences.
+type PreferencesPage struct {
+	basePage
+	User        string
+	Preferences *preferences.Preferences
+	Themes      []string
+	Editors     []string
+	// Saved reports whether the preferences were just saved.
+	Saved bool
+}
+
+// servePreferences serves the preferences page of the signed-in user on GET,
+// and stores the preferences sent with its form on POST. It handles requests
+// to /preferences.
+func (s *Server) servePreferences(w http.ResponseWriter, r *http.Request, ds internal.DataSource) (err error) {
+	defer derrors.Wrap(&err, "servePreferences")
+
+	db, ok := ds.(*postgres.DB)
+	if !ok {
+		return proxydatasourceNotSupportedErr()
+	}
+	ctx := r.Context()
+	user := preferences.UserFromContext(ctx)
+	if user == "" {
+		return &serverError{status: http.StatusUnauthorized, responseText: "sign in to store preferences"}
+	}
+	switch r.Method {
+	case http.MethodGet:
+		_, saved := r.URL.Query()["saved"]
+		page := &PreferencesPage{
+			basePage:    s.newBasePage(r, "Preferences"),
+			User:        user,
+			Preferences: preferences.FromContext(ctx),
+			Themes:      preferences.Themes,
+			Editors:     preferences.Editors,
+			Saved:       saved,
+		}
+		s.servePage(ctx, w, "preferences.tmpl", page)
+		return nil
+	case http.MethodPost:
+		p, err := parsePreferences(r)
+		if err != nil {
+			return &serverError{status: http.StatusBadRequest, responseText: err.Error()}
+		}
+		if err := db.UpsertUserPreferences(ctx, user, p); err != nil {
+			return err
+		}
+		http.Redirect(w, r, "/preferences?saved", http.StatusSeeOther)
+		return nil
+	default:
+		return &serverError{status: http.StatusMethodNotAllowed}
+	}
+}
+
+// parsePreferences returns the preferences sent with the form on the
+// preferences page.
+func parsePreferences(r *http.Request) (*preferences.Preferences, error) {
+	field := func(name string) string {
+		return strings.ToLower(strings.TrimSpace(r.FormValue(name)))
+	}
+	p := &preferences.Preferences{
+		GOOS:          field("goos"),
+		GOARCH:        field("goarch"),
+		Theme:         field("theme"),
+		ClassicLayout: r.FormValue("classic_layout") != "",
+		HideInternal:  r.FormValue("hide_internal") != "",
+		Editor:        field("editor"),
+	}
+	// Paths and URL templates are case-sensitive.
+	if p.Editor != "" {
+		p.ModuleRoot = strings.TrimSpace(r.FormValue("module_root"))
+	}
+	if p.Editor == "custom" {
+		p.EditorURL = strings.TrimSpace(r.FormValue("editor_url"))
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// applyHideInternal returns pkgs, without the packages that are internal
+// relative to dirPath if the user prefers to hide them. Packages below an
+// internal directory that contains dirPath are kept, so that the directory
+// listings of internal packages are not empty.
+func applyHideInternal(ctx context.Context, dirPath string, pkgs []*internal.PackageMeta) []*internal.PackageMeta {
+	if !preferences.FromContext(ctx).HideInternal {
+		return pkgs
+	}
+	var shown []*internal.PackageMeta
+	for _, pm := range pkgs {
+		if !isInternalSuffix(internal.Suffix(pm.Path, dirPath)) {
+			shown = append(shown, pm)
+		}
+	}
+	return shown
+}
+
+// isInternalSuffix reports whether the path suffix has an "internal" element.
+func isInternalSuffix(suffix string) bool {
+	for _, e := range strings.Split(suffix, "/") {
+		if e == "internal" {
+			return true
+		}
+	}
+	return false
+}
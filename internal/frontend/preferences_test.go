@@ -0,0 +1,105 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"context"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/preferences"
+)
+
+func TestParsePreferences(t *testing.T) {
+	for _, test := range []struct {
+		form url.Values
+		want *preferences.Preferences // nil if invalid
+	}{
+		{
+			url.Values{},
+			&preferences.Preferences{},
+		},
+		{
+			url.Values{"goos": {" Windows "}, "goarch": {"amd64"}, "theme": {"dark"}, "hide_internal": {"on"}},
+			&preferences.Preferences{GOOS: "windows", GOARCH: "amd64", Theme: "dark", HideInternal: true},
+		},
+		{
+			url.Values{"classic_layout": {"on"}},
+			&preferences.Preferences{ClassicLayout: true},
+		},
+		{
+			url.Values{"editor": {"VSCode"}, "module_root": {" /Users/Gopher/go/pkg/mod "}, "editor_url": {"x://{path}"}},
+			&preferences.Preferences{Editor: "vscode", ModuleRoot: "/Users/Gopher/go/pkg/mod"},
+		},
+		{
+			url.Values{"editor": {"custom"}, "module_root": {"/mod"}, "editor_url": {"subl://open?url=file://{path}&line={line}"}},
+			&preferences.Preferences{Editor: "custom", ModuleRoot: "/mod", EditorURL: "subl://open?url=file://{path}&line={line}"},
+		},
+		{url.Values{"editor": {"vscode"}}, nil},
+		{url.Values{"theme": {"blue"}}, nil},
+		{url.Values{"goos": {"linux"}}, nil},
+	} {
+		r := httptest.NewRequest("POST", "/preferences", strings.NewReader(test.form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		got, err := parsePreferences(r)
+		if test.want == nil {
+			if err == nil {
+				t.Errorf("%v: got %+v, want error", test.form, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%v: %v", test.form, err)
+		}
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("%v: mismatch (-want +got):\n%s", test.form, diff)
+		}
+	}
+}
+
+func TestApplyHideInternal(t *testing.T) {
+	pkgs := []*internal.PackageMeta{
+		{Path: "m.com/a"},
+		{Path: "m.com/a/internal"},
+		{Path: "m.com/a/internal/b"},
+		{Path: "m.com/a/b/internal/c"},
+		{Path: "m.com/a/internals"},
+	}
+	paths := func(pms []*internal.PackageMeta) []string {
+		var ps []string
+		for _, pm := range pms {
+			ps = append(ps, pm.Path)
+		}
+		return ps
+	}
+	ctx := context.Background()
+	if got := applyHideInternal(ctx, "m.com/a", pkgs); len(got) != len(pkgs) {
+		t.Errorf("without preference: got %v, want all packages", paths(got))
+	}
+
+	ctx = preferences.NewContext(ctx, "gopher@example.com", &preferences.Preferences{HideInternal: true})
+	for _, test := range []struct {
+		dirPath string
+		want    []string
+	}{
+		{"m.com/a", []string{"m.com/a", "m.com/a/internals"}},
+		{"m.com/a/internal", []string{"m.com/a/internal", "m.com/a/internal/b"}},
+	} {
+		var in []*internal.PackageMeta
+		for _, pm := range pkgs {
+			if pm.Path == test.dirPath || strings.HasPrefix(pm.Path, test.dirPath+"/") {
+				in = append(in, pm)
+			}
+		}
+		got := paths(applyHideInternal(ctx, test.dirPath, in))
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("%s: mismatch (-want +got):\n%s", test.dirPath, diff)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/experiment"
+	"golang.org/x/pkgsite/internal/log"
+	"golang.org/x/pkgsite/internal/postgres"
+	"golang.org/x/pkgsite/internal/stdlib"
+)
+
+// movedModuleURL returns the URL that a request for the unit page described
+// by info should be redirected to because its module moved to a new path, or
+// the empty string if it should not be redirected.
+//
+// Only requests for the latest version are redirected, so that the pages of
+// the versions published before the move can still be viewed.
+func movedModuleURL(ctx context.Context, ds internal.DataSource, r *http.Request, info *urlPathInfo) string {
+	if !experiment.IsActive(ctx, internal.ExperimentModuleRedirects) {
+		return ""
+	}
+	if info.requestedVersion != internal.LatestVersion || stdlib.Contains(info.fullPath) {
+		return ""
+	}
+	db, ok := ds.(*postgres.DB)
+	if !ok {
+		return ""
+	}
+	redirect, err := db.GetModulePathRedirect(ctx, info.fullPath)
+	if err != nil {
+		if !errors.Is(err, derrors.NotFound) {
+			log.Errorf(ctx, "movedModuleURL(%q): %v", info.fullPath, err)
+		}
+		return ""
+	}
+	u := "/" + redirect.Path(info.fullPath)
+	if info.isModule {
+		u = "/mod" + u
+	}
+	if r.URL.RawQuery != "" {
+		u += "?" + r.URL.RawQuery
+	}
+	return u
+}
@@ -0,0 +1,82 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/mod/semver"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/log"
+	"golang.org/x/pkgsite/internal/postgres"
+	"golang.org/x/pkgsite/internal/preferences"
+)
+
+// serveRefresh enqueues a module version to be processed again by the
+// worker, whether or not it has been processed before. It expects a POST
+// request for "/fetch/refresh/<path>@<version>", where <path> is a path in
+// the module and <version> is a semantic version that has already been
+// fetched. The user must be signed in.
+//
+// Unlike serveFetch, it does not wait for the module version to be
+// processed.
+func (s *Server) serveRefresh(w http.ResponseWriter, r *http.Request, ds internal.DataSource) (err error) {
+	defer derrors.Wrap(&err, "serveRefresh(%q)", r.URL.Path)
+
+	db, ok := ds.(*postgres.DB)
+	if !ok {
+		return proxydatasourceNotSupportedErr()
+	}
+	if r.Method != http.MethodPost {
+		return &serverError{status: http.StatusMethodNotAllowed}
+	}
+	ctx := r.Context()
+	user := preferences.UserFromContext(ctx)
+	if user == "" {
+		return &serverError{
+			status:       http.StatusUnauthorized,
+			responseText: "sign in to refresh a module",
+		}
+	}
+	urlInfo, err := extractURLPathInfo(strings.TrimPrefix(r.URL.Path, "/fetch/refresh"))
+	if err != nil {
+		return &serverError{status: http.StatusBadRequest, err: err}
+	}
+	if !semver.IsValid(urlInfo.requestedVersion) {
+		return &serverError{
+			status:       http.StatusBadRequest,
+			responseText: "a semantic version is required to refresh a module",
+		}
+	}
+	um, err := db.GetUnitMeta(ctx, urlInfo.fullPath, urlInfo.modulePath, urlInfo.requestedVersion)
+	if err != nil {
+		if errors.Is(err, derrors.NotFound) {
+			return &serverError{
+				status:       http.StatusNotFound,
+				responseText: fmt.Sprintf("%s@%s has not been fetched", urlInfo.fullPath, urlInfo.requestedVersion),
+				err:          err,
+			}
+		}
+		return err
+	}
+	// A new suffix gives the task a name that the queue hasn't seen, so it
+	// isn't discarded as a duplicate of the task that processed the module
+	// version before.
+	suffix := "refresh-" + strconv.FormatInt(time.Now().Unix(), 10)
+	if _, err := s.queue.ScheduleFetch(ctx, um.ModulePath, um.Version, suffix, s.taskIDChangeInterval); err != nil {
+		return err
+	}
+	log.Infof(ctx, "serveRefresh: %s enqueued %s@%s", user, um.ModulePath, um.Version)
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusAccepted)
+	fmt.Fprintf(w, "%s@%s will be processed again shortly\n", um.ModulePath, um.Version)
+	return nil
+}
@@ -0,0 +1,88 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/pkgsite/internal/preferences"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestServeRefresh(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	_, handler, teardown := newTestServer(t, testModulesForProxy)
+	defer teardown()
+	m := sample.LegacyModule(testModulePath, testSemver, "bar/foo")
+	if err := testDB.InsertModule(ctx, m); err != nil {
+		t.Fatal(err)
+	}
+
+	signedIn := preferences.NewContext(ctx, "gopher@example.com", &preferences.Preferences{})
+	for _, test := range []struct {
+		name       string
+		method     string
+		url        string
+		ctx        context.Context
+		wantStatus int
+	}{
+		{
+			name:       "module",
+			method:     http.MethodPost,
+			url:        "/fetch/refresh/" + testModulePath + "@" + testSemver,
+			ctx:        signedIn,
+			wantStatus: http.StatusAccepted,
+		},
+		{
+			name:       "package",
+			method:     http.MethodPost,
+			url:        "/fetch/refresh/" + testModulePath + "/bar/foo@" + testSemver,
+			ctx:        signedIn,
+			wantStatus: http.StatusAccepted,
+		},
+		{
+			name:       "not signed in",
+			method:     http.MethodPost,
+			url:        "/fetch/refresh/" + testModulePath + "@" + testSemver,
+			ctx:        ctx,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "get",
+			method:     http.MethodGet,
+			url:        "/fetch/refresh/" + testModulePath + "@" + testSemver,
+			ctx:        signedIn,
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:       "latest",
+			method:     http.MethodPost,
+			url:        "/fetch/refresh/" + testModulePath,
+			ctx:        signedIn,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "not fetched",
+			method:     http.MethodPost,
+			url:        "/fetch/refresh/" + testModulePath + "@v1.0.0",
+			ctx:        signedIn,
+			wantStatus: http.StatusNotFound,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(test.method, test.url, nil).WithContext(test.ctx)
+			handler.ServeHTTP(w, r)
+			if got := w.Result().StatusCode; got != test.wantStatus {
+				t.Errorf("got status %d, want %d", got, test.wantStatus)
+			}
+		})
+	}
+}
@@ -6,16 +6,20 @@ package frontend
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math"
 	"net/http"
+	"net/url"
 	"path"
 	"strings"
 
 	"github.com/google/safehtml/template"
 	"golang.org/x/pkgsite/internal"
 	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/experiment"
+	"golang.org/x/pkgsite/internal/i18n"
 	"golang.org/x/pkgsite/internal/log"
 	"golang.org/x/pkgsite/internal/postgres"
 )
@@ -28,42 +32,69 @@ type SearchPage struct {
 	basePage
 	Pagination pagination
 	Results    []*SearchResult
+	// Facets are links that narrow or widen the results by a filter.
+	Facets []*SearchFacet
+}
+
+// SearchFacet is a link that adds a filter to the search query, or removes
+// it if Active.
+type SearchFacet struct {
+	Label  string
+	Count  string
+	URL    string
+	Active bool
 }
 
 // SearchResult contains data needed to display a single search result.
 type SearchResult struct {
-	Name           string
-	PackagePath    string
-	ModulePath     string
-	Synopsis       string
-	DisplayVersion string
-	Licenses       []string
-	CommitTime     string
-	NumImportedBy  uint64
-	Approximate    bool
-}
-
-// fetchSearchPage fetches data matching the search query from the database and
-// returns a SearchPage.
-func fetchSearchPage(ctx context.Context, db *postgres.DB, query string, pageParams paginationParams) (*SearchPage, error) {
+	Name        string
+	PackagePath string
+	ModulePath  string
+	Synopsis    string
+	// SynopsisInferred reports whether Synopsis was derived from a README,
+	// so that it can be labeled as such.
+	SynopsisInferred bool
+	DisplayVersion   string
+	Licenses         []string
+	CommitTime       DisplayTime
+	NumImportedBy    string
+	Approximate      bool
+	// KindLabel describes a package that declares nothing; see
+	// packageKindLabel.
+	KindLabel string
+	// ForkOf is the path of the standard library package that the package
+	// is a copy of, if any.
+	ForkOf string
+	// SameModule lists other matching packages of the module, when results
+	// are grouped by module.
+	SameModule []*SearchResult
+	// NumMoreSameModule is the number of matching packages of the module
+	// that are in neither SameModule nor the result itself. MoreURL, if set,
+	// is a search for all of them.
+	NumMoreSameModule int
+	MoreURL           string
+}
+
+// fetchSearchPage fetches data matching the search query and filters from the
+// database and returns a SearchPage.
+func fetchSearchPage(ctx context.Context, db *postgres.DB, query string, filters *postgres.SearchFilters, pageParams paginationParams) (*SearchPage, error) {
 	maxResultCount := maxSearchOffset + pageParams.limit
-	dbresults, err := db.Search(ctx, query, pageParams.limit, pageParams.offset(), maxResultCount)
+	dbresults, err := searchFunc(ctx, db)(ctx, query, filters, pageParams.limit, pageParams.offset(), maxResultCount)
 	if err != nil {
 		return nil, err
 	}
 
 	var results []*SearchResult
 	for _, r := range dbresults {
-		results = append(results, &SearchResult{
-			Name:           r.Name,
-			PackagePath:    r.PackagePath,
-			ModulePath:     r.ModulePath,
-			Synopsis:       r.Synopsis,
-			DisplayVersion: displayVersion(r.Version, r.ModulePath),
-			Licenses:       r.Licenses,
-			CommitTime:     elapsedTime(r.CommitTime),
-			NumImportedBy:  r.NumImportedBy,
-		})
+		sr := newSearchResult(ctx, r)
+		for _, s := range r.SameModule {
+			sr.SameModule = append(sr.SameModule, newSearchResult(ctx, s))
+		}
+		sr.NumMoreSameModule = r.NumSameModule - len(r.SameModule)
+		if sr.NumMoreSameModule > 0 && experiment.IsActive(ctx, internal.ExperimentSearchFilters) {
+			sr.MoreURL = "/search?" + url.Values{"q": {query + " module:" + r.ModulePath}}.Encode()
+		}
+		results = append(results, sr)
 	}
 
 	var (
@@ -88,6 +119,31 @@ func fetchSearchPage(ctx context.Context, db *postgres.DB, query string, pagePar
 	}, nil
 }
 
+// searchFunc returns the method of db that performs a search: one that
+// groups results by module if the search-grouping experiment is active.
+func searchFunc(ctx context.Context, db *postgres.DB) func(ctx context.Context, q string, filters *postgres.SearchFilters, limit, offset, maxResultCount int) ([]*internal.SearchResult, error) {
+	if experiment.IsActive(ctx, internal.ExperimentSearchGrouping) {
+		return db.SearchGroupedByModule
+	}
+	return db.SearchWithFilters
+}
+
+func newSearchResult(ctx context.Context, r *internal.SearchResult) *SearchResult {
+	return &SearchResult{
+		Name:             r.Name,
+		PackagePath:      r.PackagePath,
+		ModulePath:       r.ModulePath,
+		Synopsis:         r.Synopsis,
+		SynopsisInferred: r.SynopsisInferred,
+		KindLabel:        packageKindLabel(r.Kind),
+		ForkOf:           r.ForkOf,
+		DisplayVersion:   displayVersion(r.Version, r.ModulePath),
+		Licenses:         r.Licenses,
+		CommitTime:       newDisplayTime(ctx, r.CommitTime),
+		NumImportedBy:    i18n.FormatCount(ctx, int(r.NumImportedBy)),
+	}
+}
+
 // approximateNumber returns an approximation of the estimate, calibrated by
 // the statistical estimate of standard error.
 // i.e., a number that isn't misleading when we say '1-10 of approximately N
@@ -134,7 +190,8 @@ func (s *Server) serveSearch(w http.ResponseWriter, r *http.Request, ds internal
 	query := searchQuery(r)
 	if len(query) > maxSearchQueryLength {
 		return &serverError{
-			status: http.StatusBadRequest,
+			status:       http.StatusBadRequest,
+			responseText: "search query too long",
 			epage: &errorPage{
 				messageTemplate: template.MakeTrustedTemplate(
 					`<h3 class="Error-message">Search query too long.</h3>`),
@@ -148,7 +205,8 @@ func (s *Server) serveSearch(w http.ResponseWriter, r *http.Request, ds internal
 	pageParams := newPaginationParams(r, defaultSearchLimit)
 	if pageParams.offset() > maxSearchOffset {
 		return &serverError{
-			status: http.StatusBadRequest,
+			status:       http.StatusBadRequest,
+			responseText: "search page number too large",
 			epage: &errorPage{
 				messageTemplate: template.MakeTrustedTemplate(
 					`<h3 class="Error-message">Search page number too large.</h3>`),
@@ -157,7 +215,8 @@ func (s *Server) serveSearch(w http.ResponseWriter, r *http.Request, ds internal
 	}
 	if pageParams.limit > maxSearchPageSize {
 		return &serverError{
-			status: http.StatusBadRequest,
+			status:       http.StatusBadRequest,
+			responseText: "search page size too large",
 			epage: &errorPage{
 				messageTemplate: template.MakeTrustedTemplate(
 					`<h3 class="Error-message">Search page size too large.</h3>`),
@@ -165,26 +224,98 @@ func (s *Server) serveSearch(w http.ResponseWriter, r *http.Request, ds internal
 		}
 	}
 
-	if path := searchRequestRedirectPath(ctx, ds, query); path != "" {
-		http.Redirect(w, r, path, http.StatusFound)
-		return nil
+	text, filters, err := searchFilters(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	if r.FormValue("format") == "json" {
+		return serveSearchJSON(w, r, db, text, filters, pageParams)
 	}
-	page, err := fetchSearchPage(ctx, db, query, pageParams)
+	if filters.IsZero() {
+		if path := searchRequestRedirectPath(ctx, ds, query); path != "" {
+			http.Redirect(w, r, path, http.StatusFound)
+			return nil
+		}
+	}
+	page, err := fetchSearchPage(ctx, db, text, filters, pageParams)
 	if err != nil {
 		return fmt.Errorf("fetchSearchPage(ctx, db, %q): %v", query, err)
 	}
+	if experiment.IsActive(ctx, internal.ExperimentSearchFilters) {
+		page.Facets = searchFacets(ctx, db, query, text, filters)
+	}
 	page.basePage = s.newBasePage(r, query)
 	s.servePage(ctx, w, "search.tmpl", page)
 	return nil
 }
 
+// searchJSONResult is a search result as served by serveSearchJSON.
+type searchJSONResult struct {
+	PackagePath string                        `json:"package_path"`
+	ModulePath  string                        `json:"module_path"`
+	Version     string                        `json:"version"`
+	Synopsis    string                        `json:"synopsis"`
+	Score       float64                       `json:"score"`
+	Explanation []*internal.SearchScoreFactor `json:"explanation,omitempty"`
+	// SameModule holds other matching packages of the module, when results
+	// are grouped by module.
+	SameModule []*searchJSONResult `json:"same_module,omitempty"`
+}
+
+// serveSearchJSON serves the results of a search as JSON. It handles
+// /search?q=<query>&format=json. If the explain parameter is set, each result
+// lists the factors of its score, to help debug the ranking of results.
+func serveSearchJSON(w http.ResponseWriter, r *http.Request, db *postgres.DB, query string, filters *postgres.SearchFilters, pageParams paginationParams) error {
+	ctx := r.Context()
+	maxResultCount := maxSearchOffset + pageParams.limit
+	dbresults, err := searchFunc(ctx, db)(ctx, query, filters, pageParams.limit, pageParams.offset(), maxResultCount)
+	if err != nil {
+		return err
+	}
+	if r.FormValue("explain") != "" {
+		if err := db.ExplainSearchResults(ctx, query, dbresults); err != nil {
+			return err
+		}
+	}
+	results := []*searchJSONResult{}
+	for _, r := range dbresults {
+		jr := newSearchJSONResult(r)
+		for _, s := range r.SameModule {
+			jr.SameModule = append(jr.SameModule, newSearchJSONResult(s))
+		}
+		results = append(results, jr)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(results)
+}
+
+func newSearchJSONResult(r *internal.SearchResult) *searchJSONResult {
+	return &searchJSONResult{
+		PackagePath: r.PackagePath,
+		ModulePath:  r.ModulePath,
+		Version:     r.Version,
+		Synopsis:    r.Synopsis,
+		Score:       r.Score,
+		Explanation: r.Explanation,
+	}
+}
+
 // searchRequestRedirectPath returns the path that a search request should be
 // redirected to, or the empty string if there is no such path. If the user
 // types an existing package path into the search bar, we will redirect the
 // user to the details page. Standard library packages that only contain one
 // element (such as fmt, errors, etc.) will not redirect, to allow users to
-// search by those terms.
+// search by those terms. A pasted import declaration or go.mod require line
+// redirects to the package or module version it names, and "x/" is a
+// shortcut for "golang.org/x/".
 func searchRequestRedirectPath(ctx context.Context, ds internal.DataSource, query string) string {
+	if p, v, ok := parsePastedLine(query); ok {
+		if v == "" {
+			v = internal.LatestVersion
+		}
+		return unitRedirectPath(ctx, ds, p, v)
+	}
 	urlSchemeIdx := strings.Index(query, "://")
 	if urlSchemeIdx > -1 {
 		query = query[urlSchemeIdx+3:]
@@ -193,20 +324,106 @@ func searchRequestRedirectPath(ctx context.Context, ds internal.DataSource, quer
 	if !strings.Contains(requestedPath, "/") {
 		return ""
 	}
-	um, err := ds.GetUnitMeta(ctx, requestedPath, internal.UnknownModulePath, internal.LatestVersion)
+	if strings.HasPrefix(requestedPath, "x/") {
+		requestedPath = "golang.org/" + requestedPath
+	}
+	return unitRedirectPath(ctx, ds, requestedPath, internal.LatestVersion)
+}
+
+// unitRedirectPath returns the path of the page of the unit with the given
+// path and version, or the empty string if there is no such unit.
+func unitRedirectPath(ctx context.Context, ds internal.DataSource, fullPath, requestedVersion string) string {
+	um, err := ds.GetUnitMeta(ctx, fullPath, internal.UnknownModulePath, requestedVersion)
 	if err != nil {
 		if !errors.Is(err, derrors.NotFound) {
-			log.Errorf(ctx, "searchRequestRedirectPath(%q): %v", requestedPath, err)
+			log.Errorf(ctx, "searchRequestRedirectPath(%q): %v", fullPath, err)
 		}
 		return ""
 	}
-	if um.IsPackage() || um.ModulePath != requestedPath {
-		return fmt.Sprintf("/%s", requestedPath)
+	p := fullPath
+	if requestedVersion != internal.LatestVersion {
+		p += "@" + requestedVersion
 	}
-	return fmt.Sprintf("/mod/%s", requestedPath)
+	if um.IsPackage() || um.ModulePath != fullPath {
+		return "/" + p
+	}
+	return "/mod/" + p
 }
 
 // searchQuery extracts a search query from the request.
 func searchQuery(r *http.Request) string {
 	return strings.TrimSpace(r.FormValue("q"))
 }
+
+// searchFilters splits query into the text to search for and its filters,
+// if the search-filters experiment is active. Otherwise the whole query is
+// text.
+func searchFilters(ctx context.Context, query string) (string, *postgres.SearchFilters, error) {
+	if !experiment.IsActive(ctx, internal.ExperimentSearchFilters) {
+		return query, nil, nil
+	}
+	badRequest := func(msg string) error {
+		return &serverError{
+			status:       http.StatusBadRequest,
+			responseText: msg,
+			epage: &errorPage{
+				messageTemplate: template.MakeTrustedTemplate(
+					`<h3 class="Error-message">{{.}}</h3><p class="Error-message">See <a href="/search-help">Search help</a> for the filters you can use.</p>`),
+				MessageData: msg,
+			},
+		}
+	}
+	text, filters, err := postgres.ParseSearchQuery(query)
+	if err != nil {
+		return "", nil, badRequest(err.Error())
+	}
+	if text == "" {
+		return "", nil, badRequest("Search for some text as well as filters.")
+	}
+	return text, filters, nil
+}
+
+// searchFacets returns the facets of the results of a search for text with
+// filters, which were parsed from query. It logs errors and returns nil,
+// since the results can be shown without the facets.
+func searchFacets(ctx context.Context, db *postgres.DB, query, text string, filters *postgres.SearchFilters) []*SearchFacet {
+	sf, err := db.GetSearchFacets(ctx, text, filters)
+	if err != nil {
+		log.Errorf(ctx, "searchFacets(%q): %v", query, err)
+		return nil
+	}
+	var facets []*SearchFacet
+	add := func(label, filter string, count int) {
+		f := newSearchFacet(ctx, query, label, filter, count)
+		if count > 0 || f.Active {
+			facets = append(facets, f)
+		}
+	}
+	for _, l := range sf.Licenses {
+		add(l.Type, "license:"+l.Type, l.Count)
+	}
+	add("Redistributable", "is:redistributable", sf.Redistributable)
+	add("Standard library", "is:stdlib", sf.Stdlib)
+	add("Has tests", "has:tests", sf.HasTests)
+	add("Not deprecated", "exclude:deprecated", sf.Total-sf.Deprecated)
+	return facets
+}
+
+// newSearchFacet returns a facet that adds filter to query, or removes it if
+// query already contains it.
+func newSearchFacet(ctx context.Context, query, label, filter string, count int) *SearchFacet {
+	f := &SearchFacet{Label: label, Count: i18n.FormatCount(ctx, count)}
+	var words []string
+	for _, w := range strings.Fields(query) {
+		if strings.EqualFold(w, filter) {
+			f.Active = true
+			continue
+		}
+		words = append(words, w)
+	}
+	if !f.Active {
+		words = append(words, filter)
+	}
+	f.URL = "/search?" + url.Values{"q": {strings.Join(words, " ")}}.Encode()
+	return f
+}
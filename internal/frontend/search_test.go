@@ -6,11 +6,13 @@ package frontend
 
 import (
 	"context"
+	"net/http"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/experiment"
 	"golang.org/x/pkgsite/internal/licenses"
 	"golang.org/x/pkgsite/internal/postgres"
 	"golang.org/x/pkgsite/internal/testing/sample"
@@ -125,8 +127,8 @@ func TestFetchSearchPage(t *testing.T) {
 						Synopsis:       moduleBar.Packages()[0].Documentation.Synopsis,
 						DisplayVersion: moduleBar.Version,
 						Licenses:       []string{"MIT"},
-						CommitTime:     elapsedTime(moduleBar.CommitTime),
-						NumImportedBy:  0,
+						CommitTime:     newDisplayTime(ctx, moduleBar.CommitTime),
+						NumImportedBy:  "0",
 					},
 				},
 			},
@@ -152,15 +154,15 @@ func TestFetchSearchPage(t *testing.T) {
 						Synopsis:       moduleFoo.Packages()[0].Documentation.Synopsis,
 						DisplayVersion: moduleFoo.Version,
 						Licenses:       []string{"MIT"},
-						CommitTime:     elapsedTime(moduleFoo.CommitTime),
-						NumImportedBy:  0,
+						CommitTime:     newDisplayTime(ctx, moduleFoo.CommitTime),
+						NumImportedBy:  "0",
 					},
 				},
 			},
 		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
-			got, err := fetchSearchPage(ctx, testDB, tc.query, paginationParams{limit: 20, page: 1})
+			got, err := fetchSearchPage(ctx, testDB, tc.query, nil, paginationParams{limit: 20, page: 1})
 			if err != nil {
 				t.Fatalf("fetchSearchPage(db, %q): %v", tc.query, err)
 			}
@@ -200,6 +202,51 @@ func TestApproximateNumber(t *testing.T) {
 	}
 }
 
+func TestSearchFilters(t *testing.T) {
+	query := "yaml license:MIT"
+	text, filters, err := searchFilters(context.Background(), query)
+	if err != nil || text != query || filters != nil {
+		t.Errorf("experiment off: got %q, %+v, %v; want %q, nil, nil", text, filters, err, query)
+	}
+
+	ctx := experiment.NewContext(context.Background(), internal.ExperimentSearchFilters)
+	text, filters, err = searchFilters(ctx, query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (&postgres.SearchFilters{Licenses: []string{"MIT"}}); text != "yaml" || !cmp.Equal(filters, want) {
+		t.Errorf("got %q, %+v; want %q, %+v", text, filters, "yaml", want)
+	}
+	for _, q := range []string{"yaml is:popular", "license:MIT"} {
+		_, _, err := searchFilters(ctx, q)
+		if serr, ok := err.(*serverError); !ok || serr.status != http.StatusBadRequest {
+			t.Errorf("%q: got error %v, want a Bad Request serverError", q, err)
+		}
+	}
+}
+
+func TestNewSearchFacet(t *testing.T) {
+	ctx := context.Background()
+	for _, test := range []struct {
+		query, filter string
+		want          *SearchFacet
+	}{
+		{
+			"yaml", "license:MIT",
+			&SearchFacet{Label: "L", Count: "1,234", URL: "/search?q=yaml+license%3AMIT"},
+		},
+		{
+			"yaml LICENSE:mit has:tests", "license:MIT",
+			&SearchFacet{Label: "L", Count: "1,234", URL: "/search?q=yaml+has%3Atests", Active: true},
+		},
+	} {
+		got := newSearchFacet(ctx, test.query, "L", test.filter, 1234)
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("%q, %q: mismatch (-want +got):\n%s", test.query, test.filter, diff)
+		}
+	}
+}
+
 func TestSearchRequestRedirectPath(t *testing.T) {
 	// Experiments need to be set in the context, for DB work, and as
 	// a middleware, for request handling.
@@ -232,6 +279,11 @@ func TestSearchRequestRedirectPath(t *testing.T) {
 		{"std does not redirect", "std", ""},
 		{"non-existent path does not redirect", "github.com/non-existent", ""},
 		{"trim URL scheme from query", "https://golang.org/x/tools", "/mod/golang.org/x/tools"},
+		{"x shortcut", "x/tools/internal/lsp", "/golang.org/x/tools/internal/lsp"},
+		{"import declaration", `import "golang.org/x/tools/internal/lsp"`, "/golang.org/x/tools/internal/lsp"},
+		{"import of stdlib package does redirect", `import "fmt"`, "/fmt"},
+		{"require line", "require golang.org/x/tools " + sample.VersionString, "/mod/golang.org/x/tools@" + sample.VersionString},
+		{"require line of unknown version does not redirect", "golang.org/x/tools v9.9.9 // indirect", ""},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			if got := searchRequestRedirectPath(ctx, testDB, tc.query); got != tc.want {
@@ -19,12 +19,17 @@ import (
 	"github.com/go-redis/redis/v7"
 	"github.com/google/safehtml/template"
 	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/apierror"
+	"golang.org/x/pkgsite/internal/config"
 	"golang.org/x/pkgsite/internal/derrors"
 	"golang.org/x/pkgsite/internal/experiment"
+	"golang.org/x/pkgsite/internal/i18n"
 	"golang.org/x/pkgsite/internal/licenses"
 	"golang.org/x/pkgsite/internal/log"
 	"golang.org/x/pkgsite/internal/middleware"
+	"golang.org/x/pkgsite/internal/preferences"
 	"golang.org/x/pkgsite/internal/queue"
+	"golang.org/x/text/language"
 )
 
 // Server can be installed to serve the go discovery frontend.
@@ -34,7 +39,10 @@ type Server struct {
 	queue         queue.Queue
 	// cmplClient is a redis client that has access to the "completions" sorted
 	// set.
-	cmplClient           *redis.Client
+	cmplClient *redis.Client
+	// completionCache caches completions from the database, for when there
+	// is no cmplClient.
+	completionCache      *completionCache
 	taskIDChangeInterval time.Duration
 	staticPath           template.TrustedSource
 	thirdPartyPath       string
@@ -44,9 +52,23 @@ type Server struct {
 	appVersionLabel      string
 	googleTagManagerID   string
 	serveStats           bool
-
-	mu        sync.Mutex // Protects all fields below
-	templates map[string]*template.Template
+	exportQuota          config.QuotaSettings
+	feedbackQuota        config.QuotaSettings
+	refreshQuota         config.QuotaSettings
+	searchQuota          config.QuotaSettings
+	fetchQuota           config.QuotaSettings
+	staticQuota          config.QuotaSettings
+	fetchModuleQuota     config.FetchQuotaSettings
+	quotaClient          *redis.Client
+	playgroundURL        string
+	playgroundQuota      config.QuotaSettings
+	shareCache           *shareCache
+	docSectionLimit      int
+
+	mu sync.Mutex // Protects all fields below
+	// templates holds the parsed templates for each language that has
+	// translations; see i18n.Languages.
+	templates map[language.Tag]map[string]*template.Template
 }
 
 // ServerConfig contains everything needed by a Server.
@@ -63,13 +85,41 @@ type ServerConfig struct {
 	AppVersionLabel      string
 	GoogleTagManagerID   string
 	ServeStats           bool
+	// ExportQuota limits requests to the /export/ endpoints.
+	ExportQuota config.QuotaSettings
+	// FeedbackQuota limits requests to the /feedback endpoint.
+	FeedbackQuota config.QuotaSettings
+	// RefreshQuota limits requests to the /fetch/refresh/ endpoint.
+	RefreshQuota config.QuotaSettings
+	// SearchQuota limits requests to the /search endpoint.
+	SearchQuota config.QuotaSettings
+	// FetchQuota limits requests to the /fetch/ endpoint.
+	FetchQuota config.QuotaSettings
+	// StaticQuota limits requests for static files.
+	StaticQuota config.QuotaSettings
+	// FetchModuleQuota limits the module versions that each client can have
+	// the /fetch/ endpoint fetch.
+	FetchModuleQuota config.FetchQuotaSettings
+	// QuotaClient, if non-nil, is a redis client that stores the token
+	// buckets of the quotas, so that they are shared by all instances.
+	// Otherwise they are kept in memory.
+	QuotaClient *redis.Client
+	// PlaygroundURL is the playground that /play/share shares snippets
+	// with. If empty, it is https://play.golang.org.
+	PlaygroundURL string
+	// PlaygroundQuota limits requests to the /play/share endpoint.
+	PlaygroundQuota config.QuotaSettings
+	// DocSectionLimit, if positive, is the largest number of declarations
+	// displayed in each section of documentation rendered by the frontend.
+	// The rest of a section is loaded from /doc-section/ on demand.
+	DocSectionLimit int
 }
 
 // NewServer creates a new Server for the given database and template directory.
 func NewServer(scfg ServerConfig) (_ *Server, err error) {
 	defer derrors.Wrap(&err, "NewServer(...)")
 	templateDir := template.TrustedSourceJoin(scfg.StaticPath, template.TrustedSourceFromConstant("html"))
-	ts, err := parsePageTemplates(templateDir)
+	ts, err := parseLocalizedTemplates(templateDir)
 	if err != nil {
 		return nil, fmt.Errorf("error parsing templates: %v", err)
 	}
@@ -77,6 +127,7 @@ func NewServer(scfg ServerConfig) (_ *Server, err error) {
 		getDataSource:        scfg.DataSourceGetter,
 		queue:                scfg.Queue,
 		cmplClient:           scfg.CompletionClient,
+		completionCache:      newCompletionCache(completionCacheSize, completionCacheTTL),
 		staticPath:           scfg.StaticPath,
 		thirdPartyPath:       scfg.ThirdPartyPath,
 		templateDir:          templateDir,
@@ -86,6 +137,21 @@ func NewServer(scfg ServerConfig) (_ *Server, err error) {
 		appVersionLabel:      scfg.AppVersionLabel,
 		googleTagManagerID:   scfg.GoogleTagManagerID,
 		serveStats:           scfg.ServeStats,
+		exportQuota:          scfg.ExportQuota,
+		feedbackQuota:        scfg.FeedbackQuota,
+		refreshQuota:         scfg.RefreshQuota,
+		searchQuota:          scfg.SearchQuota,
+		fetchQuota:           scfg.FetchQuota,
+		staticQuota:          scfg.StaticQuota,
+		fetchModuleQuota:     scfg.FetchModuleQuota,
+		quotaClient:          scfg.QuotaClient,
+		playgroundURL:        scfg.PlaygroundURL,
+		playgroundQuota:      scfg.PlaygroundQuota,
+		shareCache:           newShareCache(shareCacheSize),
+		docSectionLimit:      scfg.DocSectionLimit,
+	}
+	if s.playgroundURL == "" {
+		s.playgroundURL = defaultPlaygroundURL
 	}
 	errorPageBytes, err := s.renderErrorPage(context.Background(), http.StatusInternalServerError, "error.tmpl", nil)
 	if err != nil {
@@ -100,21 +166,71 @@ func NewServer(scfg ServerConfig) (_ *Server, err error) {
 // cache.
 func (s *Server) Install(handle func(string, http.Handler), redisClient *redis.Client, authValues []string) {
 	var (
-		detailHandler http.Handler = s.errorHandler(s.serveDetails)
-		fetchHandler  http.Handler = s.errorHandler(s.serveFetch)
-		searchHandler http.Handler = s.errorHandler(s.serveSearch)
+		detailHandler     http.Handler = s.errorHandler(s.serveDetails)
+		fetchHandler      http.Handler = s.errorHandler(s.serveFetch)
+		refreshHandler    http.Handler = s.errorHandler(s.serveRefresh)
+		searchHandler     http.Handler = s.errorHandler(s.serveSearch)
+		exportHandler     http.Handler = s.errorHandler(s.serveExport)
+		modDocHandler     http.Handler = s.errorHandler(s.serveModuleDoc)
+		feedbackHandler   http.Handler = s.errorHandler(s.serveFeedback)
+		docSectionHandler http.Handler = s.errorHandler(s.serveDocSection)
+		feedHandler       http.Handler = s.errorHandler(s.serveFeed)
+		// The preferences page differs for each user, so it is never cached.
+		preferencesHandler http.Handler = s.errorHandler(s.servePreferences)
+		playShareHandler   http.Handler = http.HandlerFunc(s.handlePlayShare)
+		staticHandler      http.Handler = http.StripPrefix("/static/", http.FileServer(http.Dir(s.staticPath.String())))
+		thirdPartyHandler  http.Handler = http.StripPrefix("/third_party", http.FileServer(http.Dir(s.thirdPartyPath)))
+		faviconHandler     http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.ServeFile(w, r, fmt.Sprintf("%s/img/favicon.ico", http.Dir(s.staticPath.String())))
+		})
 	)
+	// quota returns a middleware that limits requests with the quota given
+	// by settings, if it has a positive rate.
+	quota := func(name string, settings config.QuotaSettings) middleware.Middleware {
+		if settings.QPS <= 0 {
+			return middleware.Identity()
+		}
+		return middleware.Quota(name, settings, s.quotaClient)
+	}
+	exportHandler = quota("export", s.exportQuota)(exportHandler)
+	feedbackHandler = quota("feedback", s.feedbackQuota)(feedbackHandler)
+	refreshHandler = quota("refresh", s.refreshQuota)(refreshHandler)
+	playShareHandler = quota("playground", s.playgroundQuota)(playShareHandler)
+	fetchHandler = quota("fetch", s.fetchQuota)(fetchHandler)
+	fetchHandler = middleware.FetchQuota(s.fetchModuleQuota, s.quotaClient)(fetchHandler)
+	// Static files share one budget.
+	staticQuota := quota("static", s.staticQuota)
+	staticHandler = staticQuota(staticHandler)
+	thirdPartyHandler = staticQuota(thirdPartyHandler)
+	faviconHandler = staticQuota(faviconHandler)
 	if redisClient != nil {
-		detailHandler = middleware.Cache("details", redisClient, detailsTTL, authValues)(detailHandler)
-		searchHandler = middleware.Cache("search", redisClient, middleware.TTL(defaultTTL), authValues)(searchHandler)
-	}
-	handle("/static/", http.StripPrefix("/static/", http.FileServer(http.Dir(s.staticPath.String()))))
-	handle("/third_party/", http.StripPrefix("/third_party", http.FileServer(http.Dir(s.thirdPartyPath))))
-	handle("/favicon.ico", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		http.ServeFile(w, r, fmt.Sprintf("%s/img/favicon.ico", http.Dir(s.staticPath.String())))
-	}))
+		detailHandler = middleware.Cache("details", redisClient, detailsTTL, staleTTL, authValues)(detailHandler)
+		searchHandler = middleware.Cache("search", redisClient, middleware.TTL(defaultTTL), staleTTL, authValues)(searchHandler)
+		modDocHandler = middleware.Cache("moddoc", redisClient, moduleDocTTL, staleTTL, authValues)(modDocHandler)
+		docSectionHandler = middleware.Cache("docsection", redisClient, docSectionTTL, staleTTL, authValues)(docSectionHandler)
+		feedHandler = middleware.Cache("feed", redisClient, middleware.TTL(defaultTTL), staleTTL, authValues)(feedHandler)
+	}
+	// Searches served from the cache count against the quota too.
+	searchHandler = quota("search", s.searchQuota)(searchHandler)
+	handle("/static/", staticHandler)
+	handle("/third_party/", thirdPartyHandler)
+	handle("/favicon.ico", faviconHandler)
 	handle("/fetch/", fetchHandler)
-	handle("/play/", http.HandlerFunc(s.handlePlay))
+	handle("/fetch/refresh/", refreshHandler)
+	handle("/fetch/events/", s.errorHandler(s.serveFetchEvents))
+	handle("/export/", exportHandler)
+	handle("/moddoc/", modDocHandler)
+	handle("/feedback", feedbackHandler)
+	handle("/depends/", s.jsonErrorHandler(s.serveDependency))
+	handle("/api/v1/", s.jsonErrorHandler(s.serveAPI))
+	handle("/doc-section/", docSectionHandler)
+	handle("/feed/", feedHandler)
+	handle("/preferences", preferencesHandler)
+	handle("/status", s.jsonErrorHandler(s.serveModuleStatus))
+	// Older pages share snippets with a POST to /play/.
+	handle("/play/", playShareHandler)
+	handle("/play/share", playShareHandler)
+	handle("/play/p/", http.HandlerFunc(s.handlePlayRedirect))
 	handle("/pkg/", http.HandlerFunc(s.handlePackageDetailsRedirect))
 	handle("/search", searchHandler)
 	handle("/search-help", s.staticPageHandler("search_help.tmpl", "Search Help - go.dev"))
@@ -126,12 +242,19 @@ func (s *Server) Install(handle func(string, http.Handler), redisClient *redis.C
 		handle("/detail-stats/",
 			middleware.Stats()(http.StripPrefix("/detail-stats", s.errorHandler(s.serveDetails))))
 	}
-	handle("/autocomplete", http.HandlerFunc(s.handleAutoCompletion))
+	handle("/autocomplete", s.jsonErrorHandler(s.serveAutoCompletion))
 	handle("/robots.txt", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
 		http.ServeContent(w, r, "", time.Time{}, strings.NewReader(`User-agent: *
 Disallow: /search?*
 Disallow: /fetch/*
+Disallow: /export/*
+Disallow: /moddoc/*
+Disallow: /feedback
+Disallow: /depends/*
+Disallow: /api/*
+Disallow: /doc-section/*
+Disallow: /preferences
 `))
 	}))
 }
@@ -145,6 +268,9 @@ const (
 	shortTTL = 10 * time.Minute
 	// longTTL is used when details content is essentially static.
 	longTTL = 24 * time.Hour
+	// staleTTL is how long a page is still served from the cache after it
+	// expires, while it is rendered again in the background.
+	staleTTL = 1 * time.Hour
 )
 
 // detailsTTL assigns the cache TTL for package detail requests.
@@ -152,6 +278,16 @@ func detailsTTL(r *http.Request) time.Duration {
 	return detailsTTLForPath(r.Context(), r.URL.Path, r.FormValue("tab"))
 }
 
+// moduleDocTTL assigns the cache TTL for module documentation requests.
+func moduleDocTTL(r *http.Request) time.Duration {
+	return detailsTTLForPath(r.Context(), strings.TrimPrefix(r.URL.Path, "/moddoc"), "")
+}
+
+// docSectionTTL assigns the cache TTL for documentation section requests.
+func docSectionTTL(r *http.Request) time.Duration {
+	return detailsTTLForPath(r.Context(), strings.TrimPrefix(r.URL.Path, "/doc-section"), "")
+}
+
 func detailsTTLForPath(ctx context.Context, urlPath, tab string) time.Duration {
 	if urlPath == "/" {
 		return defaultTTL
@@ -226,6 +362,18 @@ type basePage struct {
 	// AllowWideContent indicates whether the content should be displayed in a
 	// way that’s amenable to wider viewports.
 	AllowWideContent bool
+
+	// Theme is the color theme preferred by the signed-in user, or empty for
+	// the default theme.
+	Theme string
+
+	// NoIndex reports whether search engines are asked not to index the
+	// page.
+	NoIndex bool
+
+	// Nonce is the nonce of the page's inline scripts, which the
+	// SecureHeaders middleware substitutes for the placeholder.
+	Nonce string
 }
 
 // licensePolicyPage is used to generate the static license policy page.
@@ -257,6 +405,9 @@ func (s *Server) newBasePage(r *http.Request, title string) basePage {
 		DevMode:            s.devMode,
 		AppVersionLabel:    s.appVersionLabel,
 		GoogleTagManagerID: s.googleTagManagerID,
+		Theme:              preferences.FromContext(r.Context()).Theme,
+		NoIndex:            middleware.IsNoIndex(r.Context()),
+		Nonce:              middleware.NoncePlaceholder,
 	}
 }
 
@@ -311,8 +462,20 @@ func (s *Server) errorHandler(f func(w http.ResponseWriter, r *http.Request, ds
 	}
 }
 
-func (s *Server) serveError(w http.ResponseWriter, r *http.Request, err error) {
-	ctx := r.Context()
+// jsonErrorHandler is like errorHandler, for endpoints that respond with
+// JSON. Their errors are JSON too; see serveJSONError.
+func (s *Server) jsonErrorHandler(f func(w http.ResponseWriter, r *http.Request, ds internal.DataSource) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ds := s.getDataSource(r.Context())
+		if err := f(w, r, ds); err != nil {
+			s.serveJSONError(w, r, err)
+		}
+	}
+}
+
+// toServerError logs err and returns it as a serverError with a response
+// text.
+func toServerError(ctx context.Context, err error) *serverError {
 	var serr *serverError
 	if !errors.As(err, &serr) {
 		serr = &serverError{status: http.StatusInternalServerError, err: err}
@@ -325,6 +488,16 @@ func (s *Server) serveError(w http.ResponseWriter, r *http.Request, err error) {
 	if serr.responseText == "" {
 		serr.responseText = http.StatusText(serr.status)
 	}
+	return serr
+}
+
+func (s *Server) serveError(w http.ResponseWriter, r *http.Request, err error) {
+	if r.URL.Query().Get("format") == "json" {
+		// The search and export endpoints respond with JSON if asked to.
+		s.serveJSONError(w, r, err)
+		return
+	}
+	serr := toServerError(r.Context(), err)
 	if r.Method == http.MethodPost {
 		http.Error(w, serr.responseText, serr.status)
 		return
@@ -332,6 +505,13 @@ func (s *Server) serveError(w http.ResponseWriter, r *http.Request, err error) {
 	s.serveErrorPage(w, r, serr.status, serr.epage)
 }
 
+// serveJSONError writes err as an apierror.Response, so that API clients can
+// tell errors apart and know whether to retry.
+func (s *Server) serveJSONError(w http.ResponseWriter, r *http.Request, err error) {
+	serr := toServerError(r.Context(), err)
+	apierror.Write(w, serr.status, serr.responseText, serr.err)
+}
+
 func (s *Server) serveErrorPage(w http.ResponseWriter, r *http.Request, status int, page *errorPage) {
 	template := "error.tmpl"
 	if page != nil {
@@ -363,7 +543,7 @@ func (s *Server) serveErrorPage(w http.ResponseWriter, r *http.Request, status i
 
 // renderErrorPage executes error.tmpl with the given errorPage
 func (s *Server) renderErrorPage(ctx context.Context, status int, templateName string, page *errorPage) ([]byte, error) {
-	statusInfo := fmt.Sprintf("%d %s", status, http.StatusText(status))
+	statusInfo := fmt.Sprintf("%d %s", status, i18n.Printer(ctx).Sprintf(http.StatusText(status)))
 	if page == nil {
 		page = &errorPage{}
 	}
@@ -379,8 +559,9 @@ func (s *Server) renderErrorPage(ctx context.Context, status int, templateName s
 	if templateName == "" {
 		templateName = "error.tmpl"
 	}
+	page.Nonce = middleware.NoncePlaceholder
 
-	etmpl, err := s.findTemplate(templateName)
+	etmpl, err := s.findTemplate(ctx, templateName)
 	if err != nil {
 		return nil, err
 	}
@@ -412,24 +593,31 @@ func (s *Server) servePage(ctx context.Context, w http.ResponseWriter, templateN
 
 // renderPage executes the given templateName with page.
 func (s *Server) renderPage(ctx context.Context, templateName string, page interface{}) ([]byte, error) {
-	tmpl, err := s.findTemplate(templateName)
+	tmpl, err := s.findTemplate(ctx, templateName)
 	if err != nil {
 		return nil, err
 	}
 	return executeTemplate(ctx, templateName, tmpl, page)
 }
 
-func (s *Server) findTemplate(templateName string) (*template.Template, error) {
+// findTemplate returns the template with the given name, translated into the
+// language in ctx if there are translations for it and into i18n.Default
+// otherwise.
+func (s *Server) findTemplate(ctx context.Context, templateName string) (*template.Template, error) {
 	if s.devMode {
 		s.mu.Lock()
 		defer s.mu.Unlock()
 		var err error
-		s.templates, err = parsePageTemplates(s.templateDir)
+		s.templates, err = parseLocalizedTemplates(s.templateDir)
 		if err != nil {
 			return nil, fmt.Errorf("error parsing templates: %v", err)
 		}
 	}
-	tmpl := s.templates[templateName]
+	ts, ok := s.templates[i18n.FromContext(ctx)]
+	if !ok {
+		ts = s.templates[i18n.Default]
+	}
+	tmpl := ts[templateName]
 	if tmpl == nil {
 		return nil, fmt.Errorf("BUG: s.templates[%q] not found", templateName)
 	}
@@ -445,12 +633,34 @@ func executeTemplate(ctx context.Context, templateName string, tmpl *template.Te
 	return buf.Bytes(), nil
 }
 
+// parseLocalizedTemplates parses the html templates contained in the given
+// base directory once for each language that has translations.
+func parseLocalizedTemplates(base template.TrustedSource) (map[language.Tag]map[string]*template.Template, error) {
+	templates := make(map[language.Tag]map[string]*template.Template)
+	for _, tag := range i18n.Languages() {
+		ts, err := parsePageTemplatesFor(base, tag)
+		if err != nil {
+			return nil, err
+		}
+		templates[tag] = ts
+	}
+	return templates, nil
+}
+
 // parsePageTemplates parses html templates contained in the given base
-// directory in order to generate a map of Name->*template.Template.
+// directory in order to generate a map of Name->*template.Template, with
+// text in i18n.Default.
+func parsePageTemplates(base template.TrustedSource) (map[string]*template.Template, error) {
+	return parsePageTemplatesFor(base, i18n.Default)
+}
+
+// parsePageTemplatesFor parses html templates contained in the given base
+// directory in order to generate a map of Name->*template.Template, with
+// text translated into the given language by the T template function.
 //
 // Separate templates are used so that certain contextual functions (e.g.
 // templateName) can be bound independently for each page.
-func parsePageTemplates(base template.TrustedSource) (map[string]*template.Template, error) {
+func parsePageTemplatesFor(base template.TrustedSource, tag language.Tag) (map[string]*template.Template, error) {
 	tsc := template.TrustedSourceFromConstant
 	join := template.TrustedSourceJoin
 
@@ -460,9 +670,13 @@ func parsePageTemplates(base template.TrustedSource) (map[string]*template.Templ
 		{tsc("fetch.tmpl")},
 		{tsc("index.tmpl")},
 		{tsc("license_policy.tmpl")},
+		{tsc("module_doc.tmpl")},
+		{tsc("version_diff.tmpl")},
 		{tsc("search.tmpl")},
 		{tsc("search_help.tmpl")},
+		{tsc("preferences.tmpl")},
 		{tsc("unit_details.tmpl"), tsc("unit.tmpl")},
+		{tsc("unit_dependencies.tmpl"), tsc("unit.tmpl")},
 		{tsc("unit_importedby.tmpl"), tsc("unit.tmpl")},
 		{tsc("unit_imports.tmpl"), tsc("unit.tmpl")},
 		{tsc("unit_licenses.tmpl"), tsc("unit.tmpl")},
@@ -477,6 +691,7 @@ func parsePageTemplates(base template.TrustedSource) (map[string]*template.Templ
 		{tsc("not_implemented.tmpl"), tsc("details.tmpl")},
 	}
 
+	p := i18n.PrinterFor(tag)
 	templates := make(map[string]*template.Template)
 	for _, set := range htmlSets {
 		t, err := template.New("base.tmpl").Funcs(template.FuncMap{
@@ -490,6 +705,13 @@ func parsePageTemplates(base template.TrustedSource) (map[string]*template.Templ
 			"commaseparate": func(s []string) string {
 				return strings.Join(s, ", ")
 			},
+			// T translates the text of the site's chrome. Its first argument
+			// is a key in the i18n catalog, which is also a format string
+			// for the remaining arguments.
+			"T": func(key string, args ...interface{}) string {
+				return p.Sprintf(key, args...)
+			},
+			"lang": func() string { return tag.String() },
 		}).ParseFilesFromTrustedSources(join(base, tsc("base.tmpl")))
 		if err != nil {
 			return nil, fmt.Errorf("ParseFiles: %v", err)
@@ -21,6 +21,7 @@ import (
 	"golang.org/x/net/html"
 	"golang.org/x/pkgsite/internal"
 	"golang.org/x/pkgsite/internal/experiment"
+	"golang.org/x/pkgsite/internal/i18n"
 	"golang.org/x/pkgsite/internal/middleware"
 	"golang.org/x/pkgsite/internal/postgres"
 	"golang.org/x/pkgsite/internal/proxy"
@@ -29,6 +30,7 @@ import (
 	"golang.org/x/pkgsite/internal/testing/htmlcheck"
 	"golang.org/x/pkgsite/internal/testing/pagecheck"
 	"golang.org/x/pkgsite/internal/testing/sample"
+	"golang.org/x/text/language"
 )
 
 const testTimeout = 5 * time.Second
@@ -1057,6 +1059,35 @@ func TestTagRoute(t *testing.T) {
 	}
 }
 
+func TestLocalizedTemplates(t *testing.T) {
+	s, err := NewServer(ServerConfig{
+		StaticPath: template.TrustedSourceFromConstant("../../content/static"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		tag  language.Tag
+		want []string
+	}{
+		{language.English, []string{`lang="en"`, "404 Not Found", "Discover Packages"}},
+		{language.German, []string{`lang="de"`, "404 Nicht gefunden", "Pakete entdecken"}},
+		// There are no Japanese translations, so the page is in English.
+		{language.Japanese, []string{`lang="en"`, "404 Not Found", "Discover Packages"}},
+	} {
+		ctx := i18n.NewContext(context.Background(), test.tag)
+		page, err := s.renderErrorPage(ctx, http.StatusNotFound, "", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, w := range test.want {
+			if !strings.Contains(string(page), w) {
+				t.Errorf("%s: page does not contain %q", test.tag, w)
+			}
+		}
+	}
+}
+
 func newTestServer(t *testing.T, proxyModules []*proxy.Module, experimentNames ...string) (*Server, http.Handler, func()) {
 	t.Helper()
 	proxyClient, teardown := proxy.SetupTestClient(t, proxyModules)
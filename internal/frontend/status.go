@@ -0,0 +1,234 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/mod/module"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/postgres"
+	"golang.org/x/pkgsite/internal/stdlib"
+)
+
+const (
+	// maxStatusModules is the largest number of module versions that can be
+	// asked about in a single status request.
+	maxStatusModules = 100
+
+	// maxStatusWait is the longest that a status request can wait for module
+	// versions to be processed. It is shorter than the request timeout set in
+	// cmd/frontend, so that there is time to write the response.
+	maxStatusWait = 45 * time.Second
+
+	// maxStatusRequestBytes limits the size of a status request body.
+	maxStatusRequestBytes = 64 * 1024
+)
+
+// Values of moduleStatus.Status.
+const (
+	// moduleStatusAvailable means that the module version has been processed
+	// and its documentation can be served.
+	moduleStatusAvailable = "available"
+	// moduleStatusUnknown means that the module version has not been
+	// processed yet.
+	moduleStatusUnknown = "unknown"
+	// moduleStatusPending means that processing the module version failed,
+	// but may succeed when it is retried or reprocessed; Code and Error say
+	// why it failed.
+	moduleStatusPending = "pending"
+	// moduleStatusFailed means that the module version was processed, but
+	// cannot be served; Code and Error say why.
+	moduleStatusFailed = "failed"
+)
+
+// moduleStatus is the processing status of a single module version, as
+// reported by serveModuleStatus.
+type moduleStatus struct {
+	ModulePath      string `json:"module_path"`
+	Version         string `json:"version"`
+	Status          string `json:"status"`
+	ResolvedVersion string `json:"resolved_version,omitempty"`
+	Code            int    `json:"code,omitempty"`
+	Error           string `json:"error,omitempty"`
+}
+
+// serveModuleStatus reports the processing status of a list of module
+// versions, so that release pipelines can wait for documentation to be
+// available before announcing a release.
+//
+// The request must be a POST whose body is a JSON array of strings of the
+// form "<module>@<version>". The response is a JSON array of moduleStatus
+// values, in the same order. If the "wait" query parameter is set to a
+// duration such as "30s", the request blocks until no module version has
+// status "unknown" or "pending", or until the duration (capped at
+// maxStatusWait) has passed, and then reports the current status.
+//
+// serveModuleStatus does not request that module versions be fetched; use
+// the /fetch endpoint or the proxy for that.
+func (s *Server) serveModuleStatus(w http.ResponseWriter, r *http.Request, ds internal.DataSource) (err error) {
+	defer derrors.Wrap(&err, "serveModuleStatus(%q)", r.URL.Path)
+
+	db, ok := ds.(*postgres.DB)
+	if !ok {
+		return proxydatasourceNotSupportedErr()
+	}
+	if r.Method != http.MethodPost {
+		return &serverError{status: http.StatusMethodNotAllowed}
+	}
+	var wait time.Duration
+	if v := r.URL.Query().Get("wait"); v != "" {
+		wait, err = time.ParseDuration(v)
+		if err != nil || wait < 0 {
+			return &serverError{
+				status:       http.StatusBadRequest,
+				responseText: fmt.Sprintf("invalid wait duration %q", v),
+			}
+		}
+		if wait > maxStatusWait {
+			wait = maxStatusWait
+		}
+	}
+	var args []string
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxStatusRequestBytes)).Decode(&args); err != nil {
+		return &serverError{
+			status:       http.StatusBadRequest,
+			responseText: "request body must be a JSON array of module@version strings",
+			err:          err,
+		}
+	}
+	mvs, err := parseModuleVersions(args)
+	if err != nil {
+		return &serverError{status: http.StatusBadRequest, responseText: err.Error(), err: err}
+	}
+
+	ctx := r.Context()
+	deadline := time.Now().Add(wait)
+	var statuses []*moduleStatus
+	for {
+		statuses, err = getModuleStatuses(ctx, db, mvs)
+		if err != nil {
+			return err
+		}
+		if !anyUnsettled(statuses) || !time.Now().Before(deadline) {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(pollEvery):
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	return json.NewEncoder(w).Encode(statuses)
+}
+
+// parseModuleVersions parses strings of the form "<module>@<version>".
+func parseModuleVersions(args []string) ([]module.Version, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("no module versions provided")
+	}
+	if len(args) > maxStatusModules {
+		return nil, fmt.Errorf("too many module versions: %d > %d", len(args), maxStatusModules)
+	}
+	var mvs []module.Version
+	for _, arg := range args {
+		i := strings.LastIndex(arg, "@")
+		if i < 0 {
+			return nil, fmt.Errorf("%q is not of the form module@version", arg)
+		}
+		mv := module.Version{Path: arg[:i], Version: arg[i+1:]}
+		if mv.Path != stdlib.ModulePath {
+			if err := module.CheckPath(mv.Path); err != nil {
+				return nil, fmt.Errorf("%q: %v", arg, err)
+			}
+		}
+		if !isSupportedVersion(mv.Path, mv.Version) {
+			return nil, fmt.Errorf("%q: unsupported version", arg)
+		}
+		mvs = append(mvs, mv)
+	}
+	return mvs, nil
+}
+
+// getModuleStatuses returns the status of each module version in mvs, in the
+// same order.
+func getModuleStatuses(ctx context.Context, db *postgres.DB, mvs []module.Version) (_ []*moduleStatus, err error) {
+	defer derrors.Wrap(&err, "getModuleStatuses(ctx, db, %d module versions)", len(mvs))
+
+	var paths, versions []string
+	for _, mv := range mvs {
+		paths = append(paths, mv.Path)
+		versions = append(versions, mv.Version)
+	}
+	vms, err := db.GetVersionMaps(ctx, paths, versions)
+	if err != nil {
+		return nil, err
+	}
+	vmLookup := map[module.Version]*internal.VersionMap{}
+	for _, vm := range vms {
+		vmLookup[module.Version{Path: vm.ModulePath, Version: vm.RequestedVersion}] = vm
+	}
+	var statuses []*moduleStatus
+	for _, mv := range mvs {
+		statuses = append(statuses, versionMapStatus(mv, vmLookup[mv]))
+	}
+	return statuses, nil
+}
+
+// versionMapStatus returns the status of mv given its version_map entry,
+// which is nil if there is none.
+func versionMapStatus(mv module.Version, vm *internal.VersionMap) *moduleStatus {
+	ms := &moduleStatus{
+		ModulePath: mv.Path,
+		Version:    mv.Version,
+		Status:     moduleStatusUnknown,
+	}
+	if vm == nil {
+		return ms
+	}
+	ms.ResolvedVersion = vm.ResolvedVersion
+	ms.Code = vm.Status
+	switch vm.Status {
+	case http.StatusOK,
+		derrors.ToStatus(derrors.HasIncompletePackages),
+		derrors.ToStatus(derrors.ReprocessStatusOK),
+		derrors.ToStatus(derrors.ReprocessHasIncompletePackages):
+		ms.Status = moduleStatusAvailable
+	default:
+		ms.Error = vm.Error
+		if retryableStatus(vm.Status) {
+			ms.Status = moduleStatusPending
+		} else {
+			ms.Status = moduleStatusFailed
+		}
+	}
+	return ms
+}
+
+// retryableStatus reports whether a module version whose version_map status
+// is code may still become available: the error was transient (5xx), the
+// module version is waiting to be reprocessed, or the proxy didn't have it
+// yet (404), as happens right after a release.
+func retryableStatus(code int) bool {
+	return code == http.StatusNotFound || code >= 500
+}
+
+// anyUnsettled reports whether any of statuses may still change to available.
+func anyUnsettled(statuses []*moduleStatus) bool {
+	for _, s := range statuses {
+		if s.Status == moduleStatusUnknown || s.Status == moduleStatusPending {
+			return true
+		}
+	}
+	return false
+}
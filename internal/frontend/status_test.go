@@ -0,0 +1,99 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/mod/module"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/postgres"
+)
+
+func TestParseModuleVersions(t *testing.T) {
+	for _, test := range []struct {
+		args    []string
+		wantErr bool
+	}{
+		{[]string{"example.com/mod@v1.2.3", "std@v1.15.0", "example.com/mod@latest"}, false},
+		{nil, true},
+		{[]string{"example.com/mod"}, true},
+		{[]string{"example.com/mod@v1.x"}, true},
+		{[]string{"notapath@v1.0.0"}, true},
+		{make([]string, maxStatusModules+1), true},
+	} {
+		_, err := parseModuleVersions(test.args)
+		if (err != nil) != test.wantErr {
+			t.Errorf("parseModuleVersions(%q): got error %v, want error: %t", test.args, err, test.wantErr)
+		}
+	}
+}
+
+func TestVersionMapStatus(t *testing.T) {
+	mv := module.Version{Path: "example.com/m", Version: "v1.0.0"}
+	for _, test := range []struct {
+		code int
+		want string
+	}{
+		{http.StatusOK, moduleStatusAvailable},
+		{derrors.ToStatus(derrors.ReprocessStatusOK), moduleStatusAvailable},
+		{derrors.ToStatus(derrors.BadModule), moduleStatusFailed},
+		{derrors.ToStatus(derrors.AlternativeModule), moduleStatusFailed},
+		{http.StatusNotFound, moduleStatusPending},
+		{http.StatusInternalServerError, moduleStatusPending},
+		{derrors.ToStatus(derrors.ReprocessBadModule), moduleStatusPending},
+	} {
+		got := versionMapStatus(mv, &internal.VersionMap{Status: test.code})
+		if got.Status != test.want {
+			t.Errorf("status %d: got %q, want %q", test.code, got.Status, test.want)
+		}
+	}
+}
+
+func TestServeModuleStatus(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer postgres.ResetTestDB(testDB, t)
+
+	for _, vm := range []*internal.VersionMap{
+		{ModulePath: "example.com/ok", RequestedVersion: "v1.0.0", ResolvedVersion: "v1.0.0", Status: http.StatusOK},
+		{ModulePath: "example.com/bad", RequestedVersion: "v1.0.0", Status: 490, Error: "bad module"},
+	} {
+		if err := testDB.UpsertVersionMap(ctx, vm); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, handler, _ := newTestServer(t, nil)
+	body := `["example.com/ok@v1.0.0", "example.com/bad@v1.0.0", "example.com/new@v1.0.0"]`
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/status?wait=0s", strings.NewReader(body))
+	// curl -d sends this content type, which must not make the handler
+	// parse the body as a form.
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("got status %d, want %d", w.Code, http.StatusOK)
+	}
+	var got []*moduleStatus
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	want := []*moduleStatus{
+		{ModulePath: "example.com/ok", Version: "v1.0.0", Status: moduleStatusAvailable, ResolvedVersion: "v1.0.0", Code: 200},
+		{ModulePath: "example.com/bad", Version: "v1.0.0", Status: moduleStatusFailed, Code: 490, Error: "bad module"},
+		{ModulePath: "example.com/new", Version: "v1.0.0", Status: moduleStatusUnknown},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+}
@@ -145,6 +145,7 @@ const (
 	tabVersions       = "versions"
 	tabImports        = "imports"
 	tabImportedBy     = "importedby"
+	tabDependencies   = "dependencies"
 	tabLicenses       = "licenses"
 )
 
@@ -164,7 +165,9 @@ func fetchDetailsForPackage(r *http.Request, tab string, ds internal.DataSource,
 	case tabImports:
 		return fetchImportsDetails(ctx, ds, um.Path, um.ModulePath, um.Version)
 	case tabImportedBy:
-		return fetchImportedByDetails(ctx, ds, um.Path, um.ModulePath)
+		return fetchImportedByDetails(ctx, ds, um.Path, um.ModulePath, newKeysetParams(r, importedByPageSize))
+	case tabDependencies:
+		return fetchDependenciesDetails(ctx, ds, um)
 	case tabLicenses:
 		return fetchLicensesDetails(ctx, ds, um)
 	}
@@ -6,7 +6,10 @@ package frontend
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
 	"path"
 	"sort"
 	"strconv"
@@ -17,9 +20,11 @@ import (
 	"golang.org/x/pkgsite/internal/derrors"
 	"golang.org/x/pkgsite/internal/experiment"
 	"golang.org/x/pkgsite/internal/godoc"
+	"golang.org/x/pkgsite/internal/godoc/dochtml"
 	"golang.org/x/pkgsite/internal/log"
 	"golang.org/x/pkgsite/internal/middleware"
 	"golang.org/x/pkgsite/internal/postgres"
+	"golang.org/x/pkgsite/internal/preferences"
 	"golang.org/x/pkgsite/internal/stdlib"
 )
 
@@ -36,6 +41,10 @@ type UnitPage struct {
 	// the unit.
 	Subdirectories []*Subdirectory
 
+	// DirectoryListing holds the immediate subdirectories of a module root
+	// or directory. If it is set, it is shown instead of Subdirectories.
+	DirectoryListing []*DirectoryListingEntry
+
 	// Breadcrumb contains data used to render breadcrumb UI elements.
 	Breadcrumb breadcrumb
 
@@ -55,7 +64,7 @@ type UnitPage struct {
 	Licenses []LicenseMetadata
 
 	// Elapsed time since this version was committed.
-	LastCommitTime string
+	LastCommitTime DisplayTime
 
 	// The version string formatted for display.
 	DisplayVersion string
@@ -102,14 +111,67 @@ type UnitPage struct {
 	DocOutline    safehtml.HTML
 	MobileOutline safehtml.HTML
 
+	// Outline is the outline of the documentation, from which the
+	// documentation sidebar is rendered. If it is nil, DocOutline is shown
+	// instead.
+	Outline *dochtml.Outline
+
+	// DocParts links to the pages of the documentation, if it was split
+	// because it was too large to display on one page.
+	DocParts []*DocPart
+
+	// KindLabel describes a package that declares nothing, like
+	// "documentation-only package". It is empty for other packages.
+	KindLabel string
+
+	// AllDecls reports whether the documentation includes unexported
+	// declarations.
+	AllDecls bool
+
+	// AllDeclsToggleURL links to the documentation with unexported
+	// declarations if AllDecls is false, and without them otherwise. It is
+	// empty if the documentation can't be shown with them.
+	AllDeclsToggleURL string
+
+	// BuildContext is the GOOS/GOARCH that the documentation was rendered
+	// for. It is set only when that differs from the build context that the
+	// signed-in user prefers.
+	BuildContext string
+
+	// DocBuildContexts links to the documentation in each build context in
+	// which its text differs, if there are several.
+	DocBuildContexts []*BuildContextLink
+
+	// MainURL links to the main page of the unit, and TabURLs maps the name
+	// of each tab to its URL. They keep the build context of the
+	// documentation selected with the GOOS and GOARCH query parameters, so
+	// that it is still displayed after switching tabs.
+	MainURL string
+	TabURLs map[string]string
+
 	// SourceFiles contains .go files for the package.
 	SourceFiles []*File
+
+	// Feedback is the form for reporting a problem with the documentation.
+	// It is nil for units that are not packages.
+	Feedback *FeedbackForm
+}
+
+// DocPart is a link to one page of documentation that was split because it
+// was too large.
+type DocPart struct {
+	Title   string
+	URL     string
+	Current bool // whether this is the page being displayed
 }
 
 // File is a source file for a package.
 type File struct {
 	Name string
 	URL  string
+	// EditorURL opens the file in the editor the user prefers. It is the
+	// zero URL if they have none.
+	EditorURL safehtml.URL
 }
 
 // NestedModule is a nested module relative to the path of a given unit.
@@ -127,6 +189,17 @@ type Subdirectory struct {
 	Synopsis string
 }
 
+// DirectoryListingEntry is an immediate subdirectory of a given unit. This
+// content is used in the Directories section of the unit page.
+type DirectoryListingEntry struct {
+	Suffix            string // suffix after the unit path
+	URL               string
+	IsPackage         bool
+	Synopsis          string
+	IsRedistributable bool
+	Licenses          []LicenseMetadata
+}
+
 var (
 	unitTabs = []TabSettings{
 		{
@@ -152,6 +225,12 @@ var (
 			DisplayName:       "Imported By",
 			TemplateName:      "unit_importedby.tmpl",
 		},
+		{
+			Name:              tabDependencies,
+			AlwaysShowDetails: true,
+			DisplayName:       "Dependencies",
+			TemplateName:      "unit_dependencies.tmpl",
+		},
 		{
 			Name:         tabLicenses,
 			DisplayName:  "Licenses",
@@ -167,6 +246,20 @@ func init() {
 	}
 }
 
+// unitTabURLs returns the URL of each tab of the unit page at urlPath, keeping
+// the build context given by goos and goarch.
+func unitTabURLs(urlPath, goos, goarch string) map[string]string {
+	urls := make(map[string]string, len(unitTabs))
+	for _, t := range unitTabs {
+		var q url.Values
+		if t.Name != tabDetails {
+			q = url.Values{"tab": {t.Name}}
+		}
+		urls[t.Name] = buildContextURL(urlPath, goos, goarch, q)
+	}
+	return urls
+}
+
 // serveUnitPage serves a unit page for a path using the paths,
 // modules, documentation, readmes, licenses, and package_imports tables.
 func (s *Server) serveUnitPage(ctx context.Context, w http.ResponseWriter, r *http.Request,
@@ -176,21 +269,38 @@ func (s *Server) serveUnitPage(ctx context.Context, w http.ResponseWriter, r *ht
 	if err != nil {
 		return err
 	}
+	if u := canonicalBuildContextURL(r, unit); u != "" {
+		http.Redirect(w, r, u, http.StatusFound)
+		return nil
+	}
 
 	// importedByCount is not supported when using a datasource proxy.
 	importedByCount := "0"
 	db, ok := ds.(*postgres.DB)
 	if ok {
-		importedBy, err := db.GetImportedBy(ctx, unit.Path, unit.ModulePath, importedByLimit)
+		importedByCount, err = fetchImportedByCount(ctx, db, unit.Path, unit.ModulePath)
 		if err != nil {
 			return err
 		}
-		// If we reached the query limit, then we don't know the total
-		// and we'll indicate that with a '+'. For example, if the limit
-		// is 101 and we get 101 results, then we'll show '100+ Imported by'.
-		importedByCount = strconv.Itoa(len(importedBy))
-		if len(importedBy) == importedByLimit {
-			importedByCount = strconv.Itoa(len(importedBy)-1) + "+"
+	}
+
+	// Record that the module version was viewed, so that its documentation
+	// is kept by the worker's retention policy. That is not worth failing
+	// the page for.
+	if ok {
+		if err := db.RecordModuleView(ctx, unit.ModulePath, unit.Version); err != nil {
+			log.Errorf(ctx, "serveUnitPage: %v", err)
+		}
+	}
+
+	// Find out which parents of the unit exist at its version, so that the
+	// breadcrumb doesn't link to missing ones. Without a database, all of
+	// them are linked to.
+	var existingDirs map[string]bool
+	if ok {
+		existingDirs, err = db.GetExistingPaths(ctx, breadcrumbDirs(unit.Path, unit.ModulePath), unit.ModulePath, unit.Version)
+		if err != nil {
+			return err
 		}
 	}
 
@@ -198,22 +308,79 @@ func (s *Server) serveUnitPage(ctx context.Context, w http.ResponseWriter, r *ht
 	if err != nil {
 		return err
 	}
-	subdirectories := getSubdirectories(&unit.UnitMeta, unit.Subdirectories)
+	subdirectories := getSubdirectories(&unit.UnitMeta, applyHideInternal(ctx, unit.Path, unit.Subdirectories))
 	if err != nil {
 		return err
 	}
+	// Module roots and directories list their immediate subdirectories, as
+	// a file system would.
+	var listing []*DirectoryListingEntry
+	if ok && (unit.Path == unit.ModulePath || !unit.IsPackage()) {
+		entries, err := db.GetDirectoryListing(ctx, unit.Path, unit.ModulePath, unit.Version)
+		if err != nil {
+			return err
+		}
+		listing = getDirectoryListing(ctx, &unit.UnitMeta, entries)
+	}
 
-	readme, err := readmeContent(ctx, unit)
+	// Links in the README to packages of the module go to their pages.
+	var packagePaths map[string]bool
+	if ok && unit.Readme != nil && experiment.IsActive(ctx, internal.ExperimentReadmePackageLinks) {
+		packagePaths, err = db.GetPackagePaths(ctx, unit.ModulePath, unit.Version)
+		if err != nil {
+			return err
+		}
+	}
+	readme, err := readmeContent(ctx, unit, packagePaths)
 	if err != nil {
 		return err
 	}
 
 	var (
 		docBody, docOutline, mobileOutline safehtml.HTML
+		docParts                           []*DocPart
+		outline                            *dochtml.Outline
 		files                              []*File
+		kindLabel, buildContext            string
+		allDecls, allDeclsAvailable        bool
+		docBuildContexts                   []*BuildContextLink
 	)
+	goos, goarch, otherContext := requestedBuildContext(r, unit)
 	if unit.Documentation != nil {
-		docHTML := getHTML(ctx, unit)
+		kindLabel = packageKindLabel(unit.Documentation.Kind)
+		docBuildContexts = docBuildContextLinks(ctx, ds, unit, r.URL.Path, goos, goarch)
+		// The notice for a preferred build context says that the documentation
+		// is only available in its own, so it is left out if there are others.
+		if pref := preferences.FromContext(ctx).BuildContext(); pref != "" && docBuildContexts == nil {
+			if bc := unit.Documentation.GOOS + "/" + unit.Documentation.GOARCH; bc != pref {
+				buildContext = bc
+			}
+		}
+		var docHTML safehtml.HTML
+		if otherContext {
+			docHTML, err = renderBuildContextDoc(ctx, ds, unit, goos, goarch)
+			if errors.Is(err, derrors.NotFound) {
+				return &serverError{status: http.StatusNotFound, err: err}
+			}
+		} else {
+			allDeclsAvailable = experiment.IsActive(ctx, internal.ExperimentUnexportedDocs) && rendersDoc(ctx, unit)
+			allDecls = allDeclsAvailable && allDeclsRequested(r)
+			hopts := s.docHTMLOptions(ctx, ds, unit, allDecls)
+			docHTML, docParts, err = documentationPart(ctx, r, ds, unit, hopts)
+			// The outline only describes the whole documentation of the
+			// exported declarations, so it can't be used for a part of the
+			// documentation or for the unexported declarations.
+			if err == nil && docParts == nil && !hopts.AllDecls && hopts.SectionLimit == 0 {
+				outline, err = renderOutline(ctx, unit)
+				if err != nil {
+					log.Errorf(ctx, "renderOutline(%q): %v", unit.Path, err)
+					outline, err = nil, nil
+				}
+			}
+		}
+		if err != nil {
+			return err
+		}
 		// TODO: Deprecate godoc.Parse. The sidenav and body can
 		// either be rendered using separate functions, or all this content can
 		// be passed to the template via the UnitPage struct.
@@ -233,7 +400,7 @@ func (s *Server) serveUnitPage(ctx context.Context, w http.ResponseWriter, r *ht
 		}
 		mobileOutline = m
 
-		files, err = sourceFiles(unit)
+		files, err = sourceFiles(ctx, unit)
 		if err != nil {
 			return err
 		}
@@ -257,14 +424,15 @@ func (s *Server) serveUnitPage(ctx context.Context, w http.ResponseWriter, r *ht
 	canShowDetails := unit.IsRedistributable || tabSettings.AlwaysShowDetails
 	_, expandReadme := r.URL.Query()["readme"]
 	page := UnitPage{
-		basePage:       basePage,
-		Unit:           unit,
-		Subdirectories: subdirectories,
-		NestedModules:  nestedModules,
-		Breadcrumb:     displayBreadcrumb(unit, requestedVersion),
-		Title:          title,
-		Tabs:           unitTabs,
-		SelectedTab:    tabSettings,
+		basePage:         basePage,
+		Unit:             unit,
+		Subdirectories:   subdirectories,
+		DirectoryListing: listing,
+		NestedModules:    nestedModules,
+		Breadcrumb:       displayBreadcrumb(unit, requestedVersion, existingDirs),
+		Title:            title,
+		Tabs:             unitTabs,
+		SelectedTab:      tabSettings,
 		URLPath: constructPackageURL(
 			unit.Path,
 			unit.ModulePath,
@@ -276,7 +444,7 @@ func (s *Server) serveUnitPage(ctx context.Context, w http.ResponseWriter, r *ht
 			linkVersion(unit.Version, unit.ModulePath),
 		),
 		Licenses:        transformLicenseMetadata(unit.Licenses),
-		LastCommitTime:  elapsedTime(unit.CommitTime),
+		LastCommitTime:  newDisplayTime(ctx, unit.CommitTime),
 		DisplayVersion:  displayVersion(unit.Version, unit.ModulePath),
 		LinkVersion:     linkVersion(unit.Version, unit.ModulePath),
 		LatestURL:       constructPackageURL(unit.Path, unit.ModulePath, middleware.LatestMinorVersionPlaceholder),
@@ -286,12 +454,31 @@ func (s *Server) serveUnitPage(ctx context.Context, w http.ResponseWriter, r *ht
 		Readme:          readme,
 		ExpandReadme:    expandReadme,
 		DocOutline:      docOutline,
+		Outline:         outline,
 		DocBody:         docBody,
+		DocParts:        docParts,
 		SourceFiles:     files,
 		MobileOutline:   mobileOutline,
 		ImportedByCount: importedByCount,
+		KindLabel:       kindLabel,
+		BuildContext:    buildContext,
+		AllDecls:        allDecls,
 	}
 
+	page.MainURL = buildContextURL(page.URLPath, goos, goarch, nil)
+	page.TabURLs = unitTabURLs(page.URLPath, goos, goarch)
+	if tab == tabDetails {
+		page.DocBuildContexts = docBuildContexts
+	}
+	if tab == tabDetails && allDeclsAvailable {
+		page.AllDeclsToggleURL = r.URL.Path + "?m=all"
+		if allDecls {
+			page.AllDeclsToggleURL = r.URL.Path
+		}
+	}
+	if tab == tabDetails && unit.IsPackage() {
+		page.Feedback = newFeedbackForm(ctx, unit.Path, unit.Version, r.URL.RequestURI())
+	}
 	if tab != tabDetails {
 		packageDetails, err := fetchDetailsForPackage(r, tab, ds, &unit.UnitMeta)
 		if err != nil {
@@ -304,9 +491,60 @@ func (s *Server) serveUnitPage(ctx context.Context, w http.ResponseWriter, r *ht
 	return nil
 }
 
-func getHTML(ctx context.Context, u *internal.Unit) safehtml.HTML {
-	if experiment.IsActive(ctx, internal.ExperimentFrontendRenderDoc) && len(u.Documentation.Source) > 0 {
-		dd, err := renderDoc(ctx, u)
+// documentationPart returns the documentation HTML of the unit. If the
+// documentation was split because it was too large, it returns the part
+// selected by the "docpart" query parameter, the first one by default, along
+// with links to all the parts. Otherwise, opts are passed to getHTML.
+func documentationPart(ctx context.Context, r *http.Request, ds internal.DataSource, u *internal.Unit, opts godoc.HTMLOptions) (_ safehtml.HTML, _ []*DocPart, err error) {
+	parts := u.Documentation.Parts
+	// Documentation with unexported declarations is rendered whole, since
+	// only the stored documentation is split.
+	if len(parts) == 0 || opts.AllDecls {
+		return getHTML(ctx, u, opts), nil, nil
+	}
+	n := 0
+	if s := r.FormValue("docpart"); s != "" {
+		n, err = strconv.Atoi(s)
+		if err != nil || n < 0 || n > len(parts) {
+			return safehtml.HTML{}, nil, &serverError{status: http.StatusBadRequest}
+		}
+	}
+	links := []*DocPart{{Title: "Overview", URL: r.URL.Path, Current: n == 0}}
+	for i, p := range parts {
+		links = append(links, &DocPart{
+			Title:   p.Title,
+			URL:     fmt.Sprintf("%s?docpart=%d", r.URL.Path, i+1),
+			Current: n == i+1,
+		})
+	}
+	if n == 0 {
+		// The stored documentation is used even if the frontend renders
+		// documentation, since rendering would not split it.
+		return u.Documentation.HTML, links, nil
+	}
+	db, ok := ds.(*postgres.DB)
+	if !ok {
+		// Only documentation from the database is split.
+		return safehtml.HTML{}, nil, proxydatasourceNotSupportedErr()
+	}
+	h, err := db.GetDocumentationPart(ctx, u.Path, u.ModulePath, u.Version, n)
+	if errors.Is(err, derrors.NotFound) {
+		return safehtml.HTML{}, nil, &serverError{status: http.StatusNotFound, err: err}
+	}
+	if err != nil {
+		return safehtml.HTML{}, nil, err
+	}
+	return h, links, nil
+}
+
+// getHTML returns the documentation HTML of u, rendered from its source if
+// the frontend-render-doc experiment is active, with the given options. The
+// stored documentation was rendered without them; in particular, only
+// documentation rendered by the frontend is truncated, since the rest of a
+// section is rendered from the source as well.
+func getHTML(ctx context.Context, u *internal.Unit, opts godoc.HTMLOptions) safehtml.HTML {
+	if rendersDoc(ctx, u) {
+		dd, err := renderDoc(ctx, u, opts)
 		if err != nil {
 			log.Errorf(ctx, "render doc failed: %v", err)
 			// Fall through to use stored doc.
@@ -330,11 +568,12 @@ func moduleInfo(unit *internal.Unit) *internal.ModuleInfo {
 	}
 }
 
-// readmeContent renders the readme to html.
-func readmeContent(ctx context.Context, unit *internal.Unit) (safehtml.HTML, error) {
+// readmeContent renders the readme to html. Links in it to the packages
+// whose paths are in packagePaths go to their pages; see readmeHTML.
+func readmeContent(ctx context.Context, unit *internal.Unit, packagePaths map[string]bool) (safehtml.HTML, error) {
 	if unit.IsRedistributable && unit.Readme != nil {
 		mi := moduleInfo(unit)
-		readme, err := ReadmeHTML(ctx, mi, unit.Readme)
+		readme, err := readmeHTML(ctx, mi, unit.Readme, packagePaths)
 		if err != nil {
 			return safehtml.HTML{}, err
 		}
@@ -361,9 +600,10 @@ func pageInfo(unit *internal.Unit) (title string, pageType string) {
 }
 
 // displayBreadcrumbs appends additional breadcrumb links for display
-// to those for the given unit.
-func displayBreadcrumb(unit *internal.Unit, requestedVersion string) breadcrumb {
-	bc := breadcrumbPath(unit.Path, unit.ModulePath, requestedVersion)
+// to those for the given unit. If existingDirs is non-nil, only the parents
+// of the unit in it are linked to; see existingBreadcrumbPath.
+func displayBreadcrumb(unit *internal.Unit, requestedVersion string, existingDirs map[string]bool) breadcrumb {
+	bc := existingBreadcrumbPath(unit.Path, unit.ModulePath, requestedVersion, existingDirs)
 	if unit.ModulePath == stdlib.ModulePath && unit.Path != stdlib.ModulePath {
 		bc.Links = append([]link{{Href: "/std", Body: "Standard library"}}, bc.Links...)
 	}
@@ -392,6 +632,29 @@ func getNestedModules(ctx context.Context, ds internal.DataSource, um *internal.
 	return mods, nil
 }
 
+// getDirectoryListing returns the directory listing of the unit um, given
+// the entries for its immediate subdirectories. Internal directories are left
+// out if the user prefers to hide them.
+func getDirectoryListing(ctx context.Context, um *internal.UnitMeta, entries []*internal.DirectoryEntry) []*DirectoryListingEntry {
+	hideInternal := preferences.FromContext(ctx).HideInternal
+	var listing []*DirectoryListingEntry
+	for _, e := range entries {
+		suffix := internal.Suffix(e.Path, um.Path)
+		if hideInternal && isInternalSuffix(suffix) {
+			continue
+		}
+		listing = append(listing, &DirectoryListingEntry{
+			Suffix:            suffix,
+			URL:               constructPackageURL(e.Path, um.ModulePath, linkVersion(um.Version, um.ModulePath)),
+			IsPackage:         e.Name != "",
+			Synopsis:          e.Synopsis,
+			IsRedistributable: e.IsRedistributable,
+			Licenses:          transformLicenseMetadata(e.Licenses),
+		})
+	}
+	return listing
+}
+
 func getSubdirectories(um *internal.UnitMeta, pkgs []*internal.PackageMeta) []*Subdirectory {
 	var sdirs []*Subdirectory
 	for _, pm := range pkgs {
@@ -404,6 +667,6 @@ func getSubdirectories(um *internal.UnitMeta, pkgs []*internal.PackageMeta) []*S
 			Synopsis: pm.Synopsis,
 		})
 	}
-	sort.Slice(sdirs, func(i, j int) bool { return sdirs[i].Suffix < sdirs[j].Suffix })
+	sort.Slice(sdirs, func(i, j int) bool { return internal.PathLess(sdirs[i].Suffix, sdirs[j].Suffix) })
 	return sdirs
 }
@@ -5,12 +5,24 @@
 package frontend
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/safehtml"
+	"github.com/google/safehtml/template"
 	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/godoc"
+	"golang.org/x/pkgsite/internal/licenses"
 	"golang.org/x/pkgsite/internal/postgres"
+	"golang.org/x/pkgsite/internal/preferences"
 	"golang.org/x/pkgsite/internal/stdlib"
 	"golang.org/x/pkgsite/internal/testing/sample"
 )
@@ -127,3 +139,172 @@ func TestGetNestedModules(t *testing.T) {
 		})
 	}
 }
+
+func TestDocumentationPartLinks(t *testing.T) {
+	ctx := context.Background()
+	u := &internal.Unit{
+		UnitMeta: internal.UnitMeta{Path: "a.com/m/p", ModulePath: "a.com/m", Version: "v1.0.0"},
+		Documentation: &internal.Documentation{
+			HTML: safehtml.HTMLEscaped("overview"),
+			Parts: []*internal.DocumentationPart{
+				{Title: "A – M"},
+				{Title: "N – Z"},
+			},
+		},
+	}
+
+	r := httptest.NewRequest("GET", "/a.com/m/p", nil)
+	h, links, err := documentationPart(ctx, r, nil, u, godoc.HTMLOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := h.String(), "overview"; got != want {
+		t.Errorf("got HTML %q, want %q", got, want)
+	}
+	want := []*DocPart{
+		{Title: "Overview", URL: "/a.com/m/p", Current: true},
+		{Title: "A – M", URL: "/a.com/m/p?docpart=1"},
+		{Title: "N – Z", URL: "/a.com/m/p?docpart=2"},
+	}
+	if diff := cmp.Diff(want, links); diff != "" {
+		t.Errorf("links mismatch (-want +got):\n%s", diff)
+	}
+
+	for _, param := range []string{"3", "-1", "x"} {
+		r := httptest.NewRequest("GET", "/a.com/m/p?docpart="+param, nil)
+		_, _, err := documentationPart(ctx, r, nil, u, godoc.HTMLOptions{})
+		var serr *serverError
+		if !errors.As(err, &serr) || serr.status != http.StatusBadRequest {
+			t.Errorf("docpart=%s: got error %v, want status 400", param, err)
+		}
+	}
+}
+
+func TestUnitTabURLs(t *testing.T) {
+	got := unitTabURLs("/a.com/m/p", "windows", "amd64")
+	want := map[string]string{
+		tabDetails:      "/a.com/m/p?GOARCH=amd64&GOOS=windows",
+		tabVersions:     "/a.com/m/p?GOARCH=amd64&GOOS=windows&tab=versions",
+		tabImports:      "/a.com/m/p?GOARCH=amd64&GOOS=windows&tab=imports",
+		tabImportedBy:   "/a.com/m/p?GOARCH=amd64&GOOS=windows&tab=importedby",
+		tabDependencies: "/a.com/m/p?GOARCH=amd64&GOOS=windows&tab=dependencies",
+		tabLicenses:     "/a.com/m/p?GOARCH=amd64&GOOS=windows&tab=licenses",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+	if got, want := unitTabURLs("/a.com/m/p", "", "")[tabImports], "/a.com/m/p?tab=imports"; got != want {
+		t.Errorf("default build context: got %q, want %q", got, want)
+	}
+}
+
+func TestGetDirectoryListing(t *testing.T) {
+	um := &internal.UnitMeta{Path: "a.com/m/dir", ModulePath: "a.com/m", Version: "v1.0.0"}
+	mit := []*licenses.Metadata{{Types: []string{"MIT"}, FilePath: "LICENSE"}}
+	entries := []*internal.DirectoryEntry{
+		{Path: "a.com/m/dir/internal", IsRedistributable: true},
+		{Path: "a.com/m/dir/p", Name: "p", Synopsis: "Package p does things.", IsRedistributable: true, Licenses: mit},
+		{Path: "a.com/m/dir/q", Name: "q"},
+	}
+	internalEntry := &DirectoryListingEntry{
+		Suffix:            "internal",
+		URL:               "/a.com/m@v1.0.0/dir/internal",
+		IsRedistributable: true,
+	}
+	p := &DirectoryListingEntry{
+		Suffix:            "p",
+		URL:               "/a.com/m@v1.0.0/dir/p",
+		IsPackage:         true,
+		Synopsis:          "Package p does things.",
+		IsRedistributable: true,
+		Licenses:          transformLicenseMetadata(mit),
+	}
+	q := &DirectoryListingEntry{
+		Suffix:    "q",
+		URL:       "/a.com/m@v1.0.0/dir/q",
+		IsPackage: true,
+	}
+
+	ctx := context.Background()
+	opt := cmp.AllowUnexported(safehtml.Identifier{})
+	got := getDirectoryListing(ctx, um, entries)
+	if diff := cmp.Diff([]*DirectoryListingEntry{internalEntry, p, q}, got, opt); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	ctx = preferences.NewContext(ctx, "gopher@example.com", &preferences.Preferences{HideInternal: true})
+	got = getDirectoryListing(ctx, um, entries)
+	if diff := cmp.Diff([]*DirectoryListingEntry{p, q}, got, opt); diff != "" {
+		t.Errorf("hiding internal: mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestDocOutlineTemplate(t *testing.T) {
+	const src = `
+// Package p is a package.
+package p
+
+// F is a function.
+func F() {}
+
+// T is a type.
+type T int
+
+// NewT returns a T.
+func NewT() T { return 0 }
+
+// M is a method.
+func (T) M(x int) {}
+
+// U is a type without methods.
+type U int
+
+// BUG(jba): this is a bug.
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	docPkg := godoc.NewPackage(fset, "linux", "amd64", nil)
+	docPkg.AddFile(f, true)
+	source, err := docPkg.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	u := &internal.Unit{
+		UnitMeta: internal.UnitMeta{
+			Path:       "example.com/p",
+			ModulePath: "example.com/p",
+			Version:    "v1.0.0",
+		},
+		Documentation: &internal.Documentation{GOOS: "linux", GOARCH: "amd64", Source: source},
+	}
+	outline, err := renderOutline(context.Background(), u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	templates, err := parsePageTemplates(template.TrustedSourceFromConstant("../../content/static/html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := templates["unit_details.tmpl"].ExecuteTemplate(&buf, "doc_outline", outline); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	for _, want := range []string{
+		`href="#pkg-overview"`,
+		`href="#F" title="F()"`,
+		`data-aria-owns="nav.group.T"`,
+		`<ul role="group" id="nav.group.T">`,
+		`href="#NewT" title="NewT()"`,
+		`href="#T.M" title="M(x)"`,
+		`<a href="#U" role="treeitem" aria-level="2" tabindex="-1">type U</a>`,
+		`href="#pkg-note-BUG"`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in\n%s", want, got)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/semver"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/experiment"
+	"golang.org/x/pkgsite/internal/postgres"
+)
+
+// VersionDiffPage contains data for the version comparison template, which
+// shows how the exported API of a module changed between two versions.
+type VersionDiffPage struct {
+	basePage
+	ModulePath  string
+	FromVersion string // display version of the older version
+	ToVersion   string // display version of the newer version
+	FromURL     string // URL of the module page at the older version
+	ToURL       string // URL of the module page at the newer version
+	// Packages holds the packages whose API changed, sorted by path.
+	Packages []*PackageDiff
+}
+
+// PackageDiff describes how the API of a package changed between two
+// versions of its module.
+type PackageDiff struct {
+	Path string
+	URL  string // URL of the package page at the newer version, if it has it
+	// Status is "added" or "removed" if the package is only in the newer or
+	// older version, and empty otherwise.
+	Status  string
+	Added   []*internal.SymbolDecl
+	Removed []*internal.SymbolDecl
+	Changed []*SymbolChange
+}
+
+// A SymbolChange is an exported symbol whose declaration changed.
+type SymbolChange struct {
+	Name string
+	From string // declaration in the older version
+	To   string // declaration in the newer version
+}
+
+// isVersionDiffPath reports whether urlPath is a request for a version
+// comparison page, of the form "/mod/<module-path>@<version>...<version>".
+func isVersionDiffPath(urlPath string) bool {
+	if !strings.HasPrefix(urlPath, "/mod/") {
+		return false
+	}
+	i := strings.LastIndex(urlPath, "@")
+	return i >= 0 && strings.Contains(urlPath[i+1:], "...")
+}
+
+// serveVersionDiff serves a page that compares the exported functions, types
+// and methods of the packages in two versions of a module. It expects paths
+// of the form "/mod/<module-path>@<from-version>...<to-version>", where both
+// versions are semantic versions.
+func (s *Server) serveVersionDiff(w http.ResponseWriter, r *http.Request, ds internal.DataSource) (err error) {
+	defer derrors.Wrap(&err, "serveVersionDiff(%q)", r.URL.Path)
+
+	ctx := r.Context()
+	if !experiment.IsActive(ctx, internal.ExperimentAPIDiff) {
+		return &serverError{status: http.StatusNotFound}
+	}
+	db, ok := ds.(*postgres.DB)
+	if !ok {
+		return proxydatasourceNotSupportedErr()
+	}
+	modulePath, from, to, err := parseVersionDiffPath(r.URL.Path)
+	if err != nil {
+		return &serverError{
+			status:       http.StatusBadRequest,
+			responseText: err.Error(),
+		}
+	}
+	fromPkgs, fromSymbols, err := moduleAPI(ctx, db, modulePath, from)
+	if err != nil {
+		return err
+	}
+	toPkgs, toSymbols, err := moduleAPI(ctx, db, modulePath, to)
+	if err != nil {
+		return err
+	}
+	toLink := linkVersion(to, modulePath)
+	pkgs := diffModuleAPI(fromPkgs, toPkgs, fromSymbols, toSymbols)
+	for _, p := range pkgs {
+		if p.Status != "removed" {
+			p.URL = constructPackageURL(p.Path, modulePath, toLink)
+		}
+	}
+	fromDisplay, toDisplay := displayVersion(from, modulePath), displayVersion(to, modulePath)
+	page := &VersionDiffPage{
+		basePage:    s.newBasePage(r, fmt.Sprintf("%s %s...%s", modulePath, fromDisplay, toDisplay)),
+		ModulePath:  modulePath,
+		FromVersion: fromDisplay,
+		ToVersion:   toDisplay,
+		FromURL:     constructModuleURL(modulePath, linkVersion(from, modulePath)),
+		ToURL:       constructModuleURL(modulePath, toLink),
+		Packages:    pkgs,
+	}
+	s.servePage(ctx, w, "version_diff.tmpl", page)
+	return nil
+}
+
+// parseVersionDiffPath returns the module path and the two versions in
+// urlPath, which must satisfy isVersionDiffPath.
+func parseVersionDiffPath(urlPath string) (modulePath, from, to string, err error) {
+	rest := strings.TrimPrefix(urlPath, "/mod/")
+	i := strings.LastIndex(rest, "@")
+	modulePath = strings.TrimSuffix(rest[:i], "/")
+	parts := strings.SplitN(rest[i+1:], "...", 2)
+	from, to = parts[0], parts[1]
+	if modulePath == "" {
+		return "", "", "", errors.New("a module path is required to compare versions")
+	}
+	if !semver.IsValid(from) || !semver.IsValid(to) {
+		return "", "", "", fmt.Errorf("versions to compare must be semantic versions, as in /mod/%s@v1.0.0...v1.1.0", modulePath)
+	}
+	return modulePath, from, to, nil
+}
+
+// moduleAPI returns the paths of the packages in the module version
+// modulePath@version, and the declarations of their exported symbols; see
+// postgres.DB.GetModuleSymbols. It returns a serverError if the module
+// version does not exist, or if its API was not recorded.
+func moduleAPI(ctx context.Context, db *postgres.DB, modulePath, version string) (pkgs map[string]bool, symbols map[string]map[string]string, err error) {
+	if _, err := db.GetModuleInfo(ctx, modulePath, version); err != nil {
+		if errors.Is(err, derrors.NotFound) {
+			return nil, nil, &serverError{
+				status:       http.StatusNotFound,
+				responseText: fmt.Sprintf("%s@%s was not found", modulePath, version),
+				err:          err,
+			}
+		}
+		return nil, nil, err
+	}
+	pkgs, err = db.GetPackagePaths(ctx, modulePath, version)
+	if err != nil {
+		return nil, nil, err
+	}
+	symbols, err = db.GetModuleSymbols(ctx, modulePath, version)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(pkgs) > 0 && len(symbols) == 0 {
+		return nil, nil, &serverError{
+			status:       http.StatusNotFound,
+			responseText: fmt.Sprintf("the API of %s@%s is not available for comparison", modulePath, version),
+		}
+	}
+	return pkgs, symbols, nil
+}
+
+// diffModuleAPI compares the APIs of two versions of a module, given by the
+// sets of their package paths and the declarations of the symbols of those
+// packages, keyed by package path and symbol name. It returns the packages
+// whose API changed, sorted by path.
+func diffModuleAPI(fromPkgs, toPkgs map[string]bool, fromSymbols, toSymbols map[string]map[string]string) []*PackageDiff {
+	paths := map[string]bool{}
+	for p := range fromPkgs {
+		paths[p] = true
+	}
+	for p := range toPkgs {
+		paths[p] = true
+	}
+	var diffs []*PackageDiff
+	for path := range paths {
+		d := &PackageDiff{Path: path}
+		switch {
+		case !fromPkgs[path]:
+			d.Status = "added"
+		case !toPkgs[path]:
+			d.Status = "removed"
+		}
+		from, to := fromSymbols[path], toSymbols[path]
+		for name, decl := range to {
+			fromDecl, ok := from[name]
+			switch {
+			case !ok:
+				d.Added = append(d.Added, &internal.SymbolDecl{Name: name, Decl: decl})
+			case fromDecl != decl:
+				d.Changed = append(d.Changed, &SymbolChange{Name: name, From: fromDecl, To: decl})
+			}
+		}
+		for name, decl := range from {
+			if _, ok := to[name]; !ok {
+				d.Removed = append(d.Removed, &internal.SymbolDecl{Name: name, Decl: decl})
+			}
+		}
+		if d.Status == "" && len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0 {
+			continue
+		}
+		sortSymbolDecls(d.Added)
+		sortSymbolDecls(d.Removed)
+		sort.Slice(d.Changed, func(i, j int) bool { return d.Changed[i].Name < d.Changed[j].Name })
+		diffs = append(diffs, d)
+	}
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Path < diffs[j].Path })
+	return diffs
+}
+
+func sortSymbolDecls(sds []*internal.SymbolDecl) {
+	sort.Slice(sds, func(i, j int) bool { return sds[i].Name < sds[j].Name })
+}
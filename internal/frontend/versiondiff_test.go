@@ -0,0 +1,152 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package frontend
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/experiment"
+	"golang.org/x/pkgsite/internal/postgres"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestParseVersionDiffPath(t *testing.T) {
+	for _, test := range []struct {
+		path                 string
+		wantDiff             bool
+		wantModule, from, to string
+		wantErr              bool
+	}{
+		{path: "/mod/example.com/m@v1.0.0", wantDiff: false},
+		{path: "/example.com/m@v1.0.0...v1.1.0", wantDiff: false},
+		{path: "/mod/example.com/m@v1.0.0...v1.1.0", wantDiff: true, wantModule: "example.com/m", from: "v1.0.0", to: "v1.1.0"},
+		{path: "/mod/example.com/m@v1.0.0...master", wantDiff: true, wantErr: true},
+		{path: "/mod/@v1.0.0...v1.1.0", wantDiff: true, wantErr: true},
+	} {
+		t.Run(test.path, func(t *testing.T) {
+			if got := isVersionDiffPath(test.path); got != test.wantDiff {
+				t.Fatalf("isVersionDiffPath = %t, want %t", got, test.wantDiff)
+			}
+			if !test.wantDiff {
+				return
+			}
+			modulePath, from, to, err := parseVersionDiffPath(test.path)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("got error %v, want error: %t", err, test.wantErr)
+			}
+			if modulePath != test.wantModule || from != test.from || to != test.to {
+				t.Errorf("got (%q, %q, %q), want (%q, %q, %q)", modulePath, from, to, test.wantModule, test.from, test.to)
+			}
+		})
+	}
+}
+
+func TestDiffModuleAPI(t *testing.T) {
+	fromPkgs := map[string]bool{"m/a": true, "m/old": true, "m/same": true}
+	toPkgs := map[string]bool{"m/a": true, "m/new": true, "m/same": true}
+	fromSymbols := map[string]map[string]string{
+		"m/a":    {"F": "func F()", "G": "func G()", "T": "type T int"},
+		"m/old":  {"X": "func X()"},
+		"m/same": {"S": "type S struct{}"},
+	}
+	toSymbols := map[string]map[string]string{
+		"m/a":    {"F": "func F(int)", "H": "func H()", "T": "type T int"},
+		"m/new":  {"Y": "func Y()"},
+		"m/same": {"S": "type S struct{}"},
+	}
+	want := []*PackageDiff{
+		{
+			Path:    "m/a",
+			Added:   []*internal.SymbolDecl{{Name: "H", Decl: "func H()"}},
+			Removed: []*internal.SymbolDecl{{Name: "G", Decl: "func G()"}},
+			Changed: []*SymbolChange{{Name: "F", From: "func F()", To: "func F(int)"}},
+		},
+		{
+			Path:   "m/new",
+			Status: "added",
+			Added:  []*internal.SymbolDecl{{Name: "Y", Decl: "func Y()"}},
+		},
+		{
+			Path:    "m/old",
+			Status:  "removed",
+			Removed: []*internal.SymbolDecl{{Name: "X", Decl: "func X()"}},
+		},
+	}
+	got := diffModuleAPI(fromPkgs, toPkgs, fromSymbols, toSymbols)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+}
+
+func TestServeVersionDiff(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	ctx = experiment.NewContext(ctx, internal.ExperimentAPIDiff)
+	defer postgres.ResetTestDB(testDB, t)
+
+	for _, v := range []struct {
+		version string
+		decl    string
+	}{
+		{"v1.0.0", "func F()"},
+		{"v1.1.0", "func F(x int)"},
+	} {
+		m := sample.LegacyModule(sample.ModulePath, v.version, sample.Suffix)
+		for _, u := range m.Units {
+			if u.Documentation != nil {
+				u.Documentation.SymbolDecls = []*internal.SymbolDecl{{Name: "F", Decl: v.decl}}
+			}
+		}
+		if err := testDB.InsertModule(ctx, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, handler, _ := newTestServer(t, nil, internal.ExperimentAPIDiff)
+	for _, test := range []struct {
+		url        string
+		wantStatus int
+		want       []string
+	}{
+		{
+			url:        "/mod/" + sample.ModulePath + "@v1.0.0...v1.1.0",
+			wantStatus: http.StatusOK,
+			want:       []string{"func F()", "func F(x int)"},
+		},
+		{
+			url:        "/mod/" + sample.ModulePath + "@v1.0.0...v1.2.0",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			url:        "/mod/" + sample.ModulePath + "@v1.0.0...latest",
+			wantStatus: http.StatusBadRequest,
+		},
+	} {
+		t.Run(test.url, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest("GET", test.url, nil))
+			res := w.Result()
+			if res.StatusCode != test.wantStatus {
+				t.Fatalf("got status %d, want %d", res.StatusCode, test.wantStatus)
+			}
+			body, err := ioutil.ReadAll(res.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range test.want {
+				if !strings.Contains(string(body), want) {
+					t.Errorf("body does not contain %q", want)
+				}
+			}
+		})
+	}
+}
@@ -30,6 +30,10 @@ type VersionsDetails struct {
 	// OtherModules is the slice of VersionLists with a different module path
 	// from the current package.
 	OtherModules []*VersionList
+
+	// Deprecation is the deprecation of the current module, according to the
+	// go.mod file of its latest version, or nil if it is not deprecated.
+	Deprecation *internal.Deprecation
 }
 
 // VersionListKey identifies a version list on the versions tab. We have a
@@ -55,10 +59,14 @@ type VersionList struct {
 // VersionSummary holds data required to format the version link on the
 // versions tab.
 type VersionSummary struct {
-	CommitTime string
+	CommitTime DisplayTime
 	// Link to this version, for use in the anchor href.
 	Link    string
 	Version string
+	// Retracted reports whether the latest version of the module retracts
+	// this version, with RetractionRationale as the reason, if it gives one.
+	Retracted           bool
+	RetractionRationale string
 }
 
 func fetchVersionsDetails(ctx context.Context, ds internal.DataSource, fullPath, modulePath string) (*VersionsDetails, error) {
@@ -82,7 +90,7 @@ func fetchVersionsDetails(ctx context.Context, ds internal.DataSource, fullPath,
 		}
 		return constructPackageURL(versionPath, mi.ModulePath, linkVersion(mi.Version, mi.ModulePath))
 	}
-	return buildVersionDetails(modulePath, versions, linkify), nil
+	return buildVersionDetails(ctx, modulePath, versions, linkify), nil
 }
 
 func fetchModuleVersionsDetails(ctx context.Context, ds internal.DataSource, modulePath string) (*VersionsDetails, error) {
@@ -98,7 +106,7 @@ func fetchModuleVersionsDetails(ctx context.Context, ds internal.DataSource, mod
 	linkify := func(m *internal.ModuleInfo) string {
 		return constructModuleURL(m.ModulePath, linkVersion(m.Version, m.ModulePath))
 	}
-	return buildVersionDetails(modulePath, versions, linkify), nil
+	return buildVersionDetails(ctx, modulePath, versions, linkify), nil
 }
 
 // pathInVersion constructs the full import path of the package corresponding
@@ -125,7 +133,7 @@ func pathInVersion(v1Path string, mi *internal.ModuleInfo) string {
 // versions tab, organizing major versions into those that have the same module
 // path as the package version under consideration, and those that don't.  The
 // given versions MUST be sorted first by module path and then by semver.
-func buildVersionDetails(currentModulePath string, modInfos []*internal.ModuleInfo, linkify func(v *internal.ModuleInfo) string) *VersionsDetails {
+func buildVersionDetails(ctx context.Context, currentModulePath string, modInfos []*internal.ModuleInfo, linkify func(v *internal.ModuleInfo) string) *VersionsDetails {
 
 	// lists organizes versions by VersionListKey. Note that major version isn't
 	// sufficient as a key: there are packages contained in the same major
@@ -136,6 +144,7 @@ func buildVersionDetails(currentModulePath string, modInfos []*internal.ModuleIn
 	// seenLists tracks the order in which we encounter entries of each version
 	// list. We want to preserve this order.
 	var seenLists []VersionListKey
+	latest := latestModuleInfos(modInfos)
 	for _, mi := range modInfos {
 		// Try to resolve the most appropriate major version for this version. If
 		// we detect a +incompatible version (when the path version does not match
@@ -163,9 +172,16 @@ func buildVersionDetails(currentModulePath string, modInfos []*internal.ModuleIn
 		key := VersionListKey{ModulePath: mi.ModulePath, Major: major}
 		vs := &VersionSummary{
 			Link:       linkify(mi),
-			CommitTime: elapsedTime(mi.CommitTime),
+			CommitTime: newDisplayTime(ctx, mi.CommitTime),
 			Version:    linkVersion(mi.Version, mi.ModulePath),
 		}
+		for _, r := range latest[mi.ModulePath].Retractions {
+			if r.Covers(mi.Version) {
+				vs.Retracted = true
+				vs.RetractionRationale = r.Rationale
+				break
+			}
+		}
 		if _, ok := lists[key]; !ok {
 			seenLists = append(seenLists, key)
 		}
@@ -173,6 +189,9 @@ func buildVersionDetails(currentModulePath string, modInfos []*internal.ModuleIn
 	}
 
 	var details VersionsDetails
+	if mi := latest[currentModulePath]; mi != nil {
+		details.Deprecation = mi.Deprecation
+	}
 	for _, key := range seenLists {
 		vl := &VersionList{
 			VersionListKey: key,
@@ -187,6 +206,25 @@ func buildVersionDetails(currentModulePath string, modInfos []*internal.ModuleIn
 	return &details
 }
 
+// latestModuleInfos returns the latest of modInfos for each module path,
+// whose go.mod file says which versions of the module are retracted and
+// whether it is deprecated. As with the go command, the latest version is
+// the highest release version, or the highest version if there are no
+// releases. modInfos must be sorted as for buildVersionDetails.
+func latestModuleInfos(modInfos []*internal.ModuleInfo) map[string]*internal.ModuleInfo {
+	latest := map[string]*internal.ModuleInfo{}
+	isRelease := map[string]bool{}
+	for _, mi := range modInfos {
+		vt, err := version.ParseType(mi.Version)
+		release := err == nil && vt == version.TypeRelease
+		if _, ok := latest[mi.ModulePath]; !ok || (release && !isRelease[mi.ModulePath]) {
+			latest[mi.ModulePath] = mi
+			isRelease[mi.ModulePath] = release
+		}
+	}
+	return latest
+}
+
 // formatVersion formats a more readable representation of the given version
 // string. On any parsing error, it simply returns the input unmodified.
 //
@@ -7,6 +7,7 @@ package frontend
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"golang.org/x/pkgsite/internal"
@@ -18,7 +19,7 @@ import (
 var (
 	modulePath1 = "test.com/module"
 	modulePath2 = "test.com/module/v2"
-	commitTime  = "0 hours ago"
+	commitTime  = DisplayTime{"0 hours ago", sample.CommitTime.UTC().Format(time.RFC3339)}
 )
 
 func sampleModule(modulePath, version string, versionType version.Type, packages ...*internal.LegacyPackage) *internal.Module {
@@ -127,6 +128,34 @@ func TestFetchModuleVersionDetails(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "retracted and deprecated",
+			info: info1,
+			modules: []*internal.Module{
+				sampleModule(modulePath1, "v1.2.1", version.TypeRelease),
+				func() *internal.Module {
+					// Not the latest version, so its retractions don't apply.
+					m := sampleModule(modulePath1, "v1.2.3", version.TypeRelease)
+					m.Retractions = []*internal.Retraction{{Low: "v1.2.3", High: "v1.2.3"}}
+					return m
+				}(),
+				func() *internal.Module {
+					m := sampleModule(modulePath1, "v1.3.0", version.TypeRelease)
+					m.Retractions = []*internal.Retraction{{Low: "v1.2.0", High: "v1.2.1", Rationale: "Broken build."}}
+					m.Deprecation = &internal.Deprecation{Message: "use test.com/module/v2.", Successor: modulePath2}
+					return m
+				}(),
+			},
+			wantDetails: func() *VersionsDetails {
+				l := makeList("test.com/module", "v1", []string{"v1.3.0", "v1.2.3", "v1.2.1"})
+				l.Versions[2].Retracted = true
+				l.Versions[2].RetractionRationale = "Broken build."
+				return &VersionsDetails{
+					ThisModule:  []*VersionList{l},
+					Deprecation: &internal.Deprecation{Message: "use test.com/module/v2.", Successor: modulePath2},
+				}
+			}(),
+		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			defer postgres.ResetTestDB(testDB, t)
@@ -275,6 +304,29 @@ func TestFetchPackageVersionsDetails(t *testing.T) {
 	}
 }
 
+func TestLatestModuleInfos(t *testing.T) {
+	var modInfos []*internal.ModuleInfo
+	for _, mv := range []struct{ modulePath, version string }{
+		{modulePath2, "v2.1.0-beta.1"},
+		{modulePath2, "v2.0.0"},
+		{modulePath1, "v1.0.1-0.20200101000000-abcdefabcdef"},
+		{modulePath1, "v1.0.0-rc.1"},
+	} {
+		modInfos = append(modInfos, sample.ModuleInfo(mv.modulePath, mv.version))
+	}
+	got := map[string]string{}
+	for path, mi := range latestModuleInfos(modInfos) {
+		got[path] = mi.Version
+	}
+	want := map[string]string{
+		modulePath2: "v2.0.0",
+		modulePath1: "v1.0.1-0.20200101000000-abcdefabcdef",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
 func TestPathInVersion(t *testing.T) {
 	tests := []struct {
 		v1Path, modulePath, want string
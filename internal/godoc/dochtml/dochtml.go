@@ -30,6 +30,7 @@ import (
 	"golang.org/x/pkgsite/internal/experiment"
 	"golang.org/x/pkgsite/internal/godoc/dochtml/internal/render"
 	"golang.org/x/pkgsite/internal/godoc/internal/doc"
+	"golang.org/x/pkgsite/internal/stdlib"
 )
 
 var (
@@ -47,6 +48,17 @@ type ModuleInfo struct {
 	ModulePackages map[string]bool
 }
 
+// An ExcludedFile is a file of a package that build constraints leave out of
+// its documentation.
+type ExcludedFile struct {
+	// Name is the name of the file.
+	Name string
+	// Constraints are the build constraint lines of the file, like
+	// "// +build windows". If there are none, the file is excluded by its
+	// name, like "x_windows.go".
+	Constraints []string
+}
+
 // RenderOptions are options for Render.
 type RenderOptions struct {
 	// FileLinkFunc optionally specifies a function that
@@ -57,12 +69,72 @@ type RenderOptions struct {
 	// string to indicate that a given file should not be linked.
 	FileLinkFunc   func(file string) (url string)
 	SourceLinkFunc func(ast.Node) string
+	// UsesLinkFunc optionally specifies a function that returns a URL
+	// where the uses of a declaration can be found. defParts are the parts
+	// of the declaration's name, as in ["Buffer", "Len"] for a method.
+	// As with FileLinkFunc, the empty string means there is no link.
+	UsesLinkFunc func(defParts []string) (url string)
+	// EditorLinkFunc optionally specifies a function that returns a URL
+	// that opens the source of a declaration in the reader's editor, like
+	// a vscode: URL. It is displayed next to the source link. The zero URL
+	// means there is no link.
+	EditorLinkFunc func(ast.Node) safehtml.URL
 	// ModInfo optionally specifies information about the module the package
 	// belongs to in order to render module-related documentation.
 	ModInfo *ModuleInfo
 	Limit   int64 // If zero, a default limit of 10 megabytes is used.
+	// CollapseDeprecated moves deprecated functions, types and methods out
+	// of the main list in the index, into a collapsed "Deprecated" section
+	// at its end.
+	CollapseDeprecated bool
+	// DisableHighlighting turns off the syntax highlighting of declarations
+	// and code, leaving only comments highlighted.
+	DisableHighlighting bool
+	// FoldValuesAfter, if positive, is the number of specs of a constant or
+	// variable group displayed before the rest of the group is folded into
+	// an expandable element. Folded specs are rendered as plain source code,
+	// which makes large generated groups much smaller.
+	FoldValuesAfter int
+	// SinceVersions optionally maps the names of functions, types and
+	// methods, the latter as "Type.Method", to the version of the module
+	// in which they were added. The version is displayed next to the
+	// declaration, like the "Go 1.x" notes of the standard library.
+	SinceVersions map[string]string
+	// Outline, if non-nil, is filled in with the outline of the rendered
+	// documentation, for building navigation outside of the HTML.
+	Outline *Outline
+	// SectionLimit, if positive, is the largest number of declarations
+	// displayed in each of the constants, variables, functions and types
+	// sections; see truncateSections. The rest of a longer section is
+	// replaced by a link to SectionURLFunc(section), where RenderSection
+	// is expected to serve the whole section. Sections are only truncated
+	// with the unit page template.
+	SectionLimit   int
+	SectionURLFunc func(section Section) (url string)
+	// NoteMarkers lists the markers of the notes that are displayed in the
+	// Notes section, like "BUG", "TODO" or "DEPRECATED". If nil, only bugs
+	// are displayed.
+	NoteMarkers []string
+	// Sizes, if non-nil, is filled in with a breakdown of the size of the
+	// rendered HTML. If the HTML is too large, it accounts for what was
+	// rendered before the limit was reached.
+	Sizes *SizeBreakdown
+	// Embeds optionally maps the names of package-level variables to the
+	// files that //go:embed directives embed in them. They are listed after
+	// the declarations of the variables, linked with FileLinkFunc.
+	Embeds map[string]*Embed
+	// BuildContext is the build context of the documentation, like
+	// "linux/amd64", and ExcludedFiles lists the files of the package that
+	// its build constraints leave out. If there are any, they are shown in
+	// a "Build constraints" box after the overview.
+	BuildContext  string
+	ExcludedFiles []*ExcludedFile
 }
 
+// defaultNoteMarkers are the markers of the notes displayed when
+// RenderOptions.NoteMarkers is nil.
+var defaultNoteMarkers = []string{"BUG"}
+
 // Render renders package documentation HTML for the
 // provided file set and package.
 //
@@ -75,6 +147,77 @@ func Render(ctx context.Context, fset *token.FileSet, p *doc.Package, opt Render
 		opt.Limit = 10 * megabyte
 	}
 
+	p = preparePackage(p, opt.NoteMarkers)
+	tmpl, r := newTemplate(ctx, fset, p, opt)
+
+	if experiment.IsActive(ctx, internal.ExperimentUnitPage) {
+		if p.Doc == "" &&
+			len(p.Examples) == 0 &&
+			len(p.Consts) == 0 &&
+			len(p.Vars) == 0 &&
+			len(p.Types) == 0 &&
+			len(p.Funcs) == 0 {
+			return safehtml.HTML{}, nil
+		}
+	}
+
+	exs := collectExamples(p)
+	if opt.Outline != nil {
+		o, err := buildOutline(p, exs, r.ShortSynopsis)
+		if err != nil {
+			return safehtml.HTML{}, err
+		}
+		*opt.Outline = *o
+	}
+	data := newTemplateData(p, exs, opt)
+	if opt.SectionLimit > 0 {
+		data.Shown, data.Truncated = truncateSections(p, opt.SectionLimit, opt.SectionURLFunc)
+	}
+	return executeToHTMLWithLimit(tmpl, data, opt.Limit, opt.Sizes)
+}
+
+// templateData is the data passed to the documentation template.
+type templateData struct {
+	RootURL string
+	*doc.Package
+	Examples *examples
+	NoteIDs  map[string]safehtml.Identifier
+	// Deprecated holds the index entries for deprecated identifiers,
+	// and Collapsed their IDs, if they are shown in a separate section.
+	Deprecated []*deprecatedEntry
+	Collapsed  map[string]bool
+	// Shown holds the declarations displayed in the constants, variables,
+	// functions and types sections, and Truncated describes the sections
+	// from which some were left out, keyed by section. The index lists
+	// all declarations.
+	Shown     *doc.Package
+	Truncated map[string]*truncatedSection
+	// BuildContext and ExcludedFiles describe the files left out by build
+	// constraints.
+	BuildContext  string
+	ExcludedFiles []*ExcludedFile
+}
+
+func newTemplateData(p *doc.Package, exs *examples, opt RenderOptions) *templateData {
+	data := &templateData{
+		RootURL:  "/pkg",
+		Package:  p,
+		Examples: exs,
+		NoteIDs:  buildNoteIDs(p.Notes),
+		Shown:    p,
+	}
+	if opt.CollapseDeprecated {
+		data.Deprecated, data.Collapsed = collectDeprecated(p)
+	}
+	data.BuildContext = opt.BuildContext
+	data.ExcludedFiles = opt.ExcludedFiles
+	return data
+}
+
+// preparePackage returns a copy of p with the declarations that are not
+// displayed removed: all of them for commands, and the notes whose markers
+// are not in noteMarkers, or are not bugs if noteMarkers is nil.
+func preparePackage(p *doc.Package, noteMarkers []string) *doc.Package {
 	// Make a copy to avoid modifying caller's *doc.Package.
 	p2 := *p
 	p = &p2
@@ -90,15 +233,25 @@ func Render(ctx context.Context, fset *token.FileSet, p *doc.Package, opt Render
 		p.Examples = nil
 	}
 
-	// Remove everything from the notes section that is not a bug. This
-	// includes TODOs and other arbitrary notes.
-	for k := range p.Notes {
-		if k == "BUG" {
-			continue
+	// Keep only the notes with the given markers. The map is copied, since
+	// it is shared with the caller's *doc.Package.
+	if noteMarkers == nil {
+		noteMarkers = defaultNoteMarkers
+	}
+	notes := map[string][]*doc.Note{}
+	for _, m := range noteMarkers {
+		if ns := p.Notes[m]; len(ns) > 0 {
+			notes[m] = ns
 		}
-		delete(p.Notes, k)
 	}
+	p.Notes = notes
+	return p
+}
 
+// newTemplate returns the template that renders the documentation of p,
+// with the functions that render its declarations and links, along with the
+// renderer that those functions use.
+func newTemplate(ctx context.Context, fset *token.FileSet, p *doc.Package, opt RenderOptions) (*template.Template, *render.Renderer) {
 	r := render.New(ctx, fset, p, &render.Options{
 		PackageURL: func(path string) string {
 			// Use the same module version for imported packages that belong to
@@ -109,7 +262,10 @@ func Render(ctx context.Context, fset *token.FileSet, p *doc.Package, opt Render
 			}
 			return "/" + versionedPath
 		},
-		DisableHotlinking: true,
+		ModulePackages:      modulePackagePaths(opt.ModInfo),
+		DisableHotlinking:   true,
+		DisableHighlighting: opt.DisableHighlighting,
+		FoldValuesAfter:     opt.FoldValuesAfter,
 	})
 
 	fileLink := func(name string) safehtml.HTML {
@@ -118,20 +274,50 @@ func Render(ctx context.Context, fset *token.FileSet, p *doc.Package, opt Render
 	sourceLink := func(name string, node ast.Node) safehtml.HTML {
 		return linkHTML(name, opt.SourceLinkFunc(node), "Documentation-source")
 	}
-
-	if experiment.IsActive(ctx, internal.ExperimentUnitPage) {
-		if p.Doc == "" &&
-			len(p.Examples) == 0 &&
-			len(p.Consts) == 0 &&
-			len(p.Vars) == 0 &&
-			len(p.Types) == 0 &&
-			len(p.Funcs) == 0 {
-			return safehtml.HTML{}, nil
+	usesLink := func(defParts ...string) safehtml.HTML {
+		if opt.UsesLinkFunc == nil {
+			return safehtml.HTML{}
+		}
+		u := opt.UsesLinkFunc(defParts)
+		if u == "" {
+			return safehtml.HTML{}
+		}
+		return linkHTML("Uses", u, "Documentation-uses")
+	}
+	editorLink := func(node ast.Node) safehtml.HTML {
+		if opt.EditorLinkFunc == nil {
+			return safehtml.HTML{}
 		}
+		u := opt.EditorLinkFunc(node)
+		if u.String() == "" {
+			return safehtml.HTML{}
+		}
+		return render.ExecuteToHTML(editorLinkTemplate, u)
+	}
+	noteSourceLink := func(n *doc.Note) safehtml.HTML {
+		if opt.SourceLinkFunc == nil {
+			return safehtml.HTML{}
+		}
+		u := opt.SourceLinkFunc(noteNode{n})
+		if u == "" {
+			return safehtml.HTML{}
+		}
+		return linkHTML("View Source", u, "Documentation-noteSource")
+	}
+	embeds := func(names []string) safehtml.HTML {
+		return embedHTML(opt.Embeds, names, func(file string) safehtml.HTML {
+			return linkHTML(file, opt.FileLinkFunc(file), "Documentation-embedFile")
+		})
+	}
+	sinceVersion := func(defParts ...string) safehtml.HTML {
+		v := opt.SinceVersions[strings.Join(defParts, ".")]
+		if v == "" {
+			return safehtml.HTML{}
+		}
+		return render.ExecuteToHTML(sinceVersionTemplate, v)
 	}
 
-	h := htmlPackage(ctx)
-	tmpl := template.Must(h.Clone()).Funcs(map[string]interface{}{
+	funcs := map[string]interface{}{
 		"render_short_synopsis": r.ShortSynopsis,
 		"render_synopsis":       r.Synopsis,
 		"render_doc":            r.DocHTML,
@@ -139,26 +325,32 @@ func Render(ctx context.Context, fset *token.FileSet, p *doc.Package, opt Render
 		"render_code":           r.CodeHTML,
 		"file_link":             fileLink,
 		"source_link":           sourceLink,
-	})
-	data := struct {
-		RootURL string
-		*doc.Package
-		Examples *examples
-		NoteIDs  map[string]safehtml.Identifier
-	}{
-		RootURL:  "/pkg",
-		Package:  p,
-		Examples: collectExamples(p),
-		NoteIDs:  buildNoteIDs(p.Notes),
+		"uses_link":             usesLink,
+		"editor_link":           editorLink,
+		"since_version":         sinceVersion,
+		"note_source_link":      noteSourceLink,
+		"embeds":                embeds,
+	}
+	if opt.Sizes != nil {
+		*opt.Sizes = SizeBreakdown{}
+		opt.Sizes.instrument(funcs, r)
 	}
-	return executeToHTMLWithLimit(tmpl, data, opt.Limit)
+	h := htmlPackage(ctx)
+	tmpl := template.Must(h.Clone()).Funcs(funcs)
+	return tmpl, r
 }
 
 // executeToHTMLWithLimit executes tmpl on data and returns the result as a safehtml.HTML.
 // It returns an error if the size of the result exceeds limit.
-func executeToHTMLWithLimit(tmpl *template.Template, data interface{}, limit int64) (safehtml.HTML, error) {
+// If sizes is non-nil, the total size of the result and that of its index are
+// recorded in it.
+func executeToHTMLWithLimit(tmpl *template.Template, data interface{}, limit int64, sizes *SizeBreakdown) (safehtml.HTML, error) {
 	buf := &limitBuffer{B: new(bytes.Buffer), Remain: limit}
 	err := tmpl.Execute(buf, data)
+	if sizes != nil {
+		sizes.Total = int64(buf.B.Len())
+		sizes.Index = indexSize(buf.B.String())
+	}
 	if buf.Remain < 0 {
 		return safehtml.HTML{}, fmt.Errorf("dochtml.Render: %w", ErrTooLarge)
 	} else if err != nil {
@@ -170,9 +362,26 @@ func executeToHTMLWithLimit(tmpl *template.Template, data interface{}, limit int
 	return uncheckedconversions.HTMLFromStringKnownToSatisfyTypeContract(buf.B.String()), nil
 }
 
+// noteNode makes a note an ast.Node, so that RenderOptions.SourceLinkFunc
+// can link to it.
+type noteNode struct {
+	*doc.Note
+}
+
+func (n noteNode) Pos() token.Pos { return n.Note.Pos }
+func (n noteNode) End() token.Pos { return n.Note.End }
+
 // linkHTML returns an HTML-formatted name linked to the given URL.
 // The class argument is the class of the 'a' tag.
 // If url is the empty string, the name is not linked.
+var sinceVersionTemplate = template.Must(template.New("sinceVersion").Parse(
+	`<span class="Documentation-sinceVersion" title="Added in {{.}}">{{.}}</span>`))
+
+// editorLinkTemplate is executed with a safehtml.URL, since the URLs of
+// editors have schemes that the template would otherwise reject.
+var editorLinkTemplate = template.Must(template.New("editorLink").Parse(
+	`<a class="Documentation-editor" href="{{.}}" title="Open in editor">Open in editor</a>`))
+
 func linkHTML(name, url, class string) safehtml.HTML {
 	if url == "" {
 		return safehtml.HTMLEscaped(name)
@@ -180,6 +389,51 @@ func linkHTML(name, url, class string) safehtml.HTML {
 	return render.ExecuteToHTML(render.LinkTemplate, render.Link{Class: class, Href: url, Text: name})
 }
 
+// deprecatedEntry is an entry in the "Deprecated" section of the index.
+type deprecatedEntry struct {
+	ID     string // ID of the declaration, as in "Func" or "Type.Method"
+	Name   string
+	Decl   ast.Decl
+	IsType bool
+}
+
+// collectDeprecated returns the index entries for the functions, types and
+// methods of p whose documentation says they are deprecated, along with the
+// set of their IDs. The functions and methods of a deprecated type are not
+// listed separately.
+func collectDeprecated(p *doc.Package) ([]*deprecatedEntry, map[string]bool) {
+	var entries []*deprecatedEntry
+	add := func(id, name string, decl ast.Decl, isType bool) {
+		entries = append(entries, &deprecatedEntry{ID: id, Name: name, Decl: decl, IsType: isType})
+	}
+	for _, f := range p.Funcs {
+		if render.IsDeprecated(f.Doc) {
+			add(f.Name, f.Name, f.Decl, false)
+		}
+	}
+	for _, t := range p.Types {
+		if render.IsDeprecated(t.Doc) {
+			add(t.Name, t.Name, t.Decl, true)
+			continue
+		}
+		for _, f := range t.Funcs {
+			if render.IsDeprecated(f.Doc) {
+				add(f.Name, f.Name, f.Decl, false)
+			}
+		}
+		for _, m := range t.Methods {
+			if render.IsDeprecated(m.Doc) {
+				add(t.Name+"."+m.Name, m.Name, m.Decl, false)
+			}
+		}
+	}
+	ids := map[string]bool{}
+	for _, e := range entries {
+		ids[e.ID] = true
+	}
+	return entries, ids
+}
+
 // examples is an internal representation of all package examples.
 type examples struct {
 	List []*example            // sorted by ParentID
@@ -286,6 +540,24 @@ func buildNoteIDs(notes map[string][]*doc.Note) map[string]safehtml.Identifier {
 	return ids
 }
 
+// modulePackagePaths returns the import paths of the packages in the module,
+// in sorted order. The standard library's packages are listed in
+// modInfo.ModulePackages with a "std/" prefix, which is removed.
+func modulePackagePaths(modInfo *ModuleInfo) []string {
+	if modInfo == nil {
+		return nil
+	}
+	var paths []string
+	for p := range modInfo.ModulePackages {
+		if modInfo.ModulePath == stdlib.ModulePath {
+			p = strings.TrimPrefix(p, stdlib.ModulePath+"/")
+		}
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
 // versionedPkgPath transforms package paths to contain the same version as the
 // current module if the package belongs to the module. As a special case,
 // versionedPkgPath will not add versions to standard library packages.
@@ -7,6 +7,7 @@ package dochtml
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"go/ast"
 	"go/parser"
 	"go/token"
@@ -18,6 +19,8 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"golang.org/x/net/html"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/experiment"
 	"golang.org/x/pkgsite/internal/godoc/internal/doc"
 	"golang.org/x/pkgsite/internal/testing/htmlcheck"
 )
@@ -45,6 +48,13 @@ func TestRender(t *testing.T) {
 		testIDsAndKinds(t, htmlDoc)
 	})
 
+	for _, id := range []string{"const-T", "const-CG3", "var-VG1"} {
+		checker := htmlcheck.In("span#"+id, htmlcheck.HasAttr("id", id))
+		if err := checker(htmlDoc); err != nil {
+			t.Errorf("group %s: %v", id, err)
+		}
+	}
+
 	checker := htmlcheck.In(".Documentation-note",
 		htmlcheck.In("h2", htmlcheck.HasAttr("id", "pkg-note-BUG")),
 		htmlcheck.In("a", htmlcheck.HasHref("#pkg-note-BUG")))
@@ -53,6 +63,317 @@ func TestRender(t *testing.T) {
 	}
 }
 
+func TestRenderNoteMarkers(t *testing.T) {
+	ctx := experiment.NewContext(context.Background(), internal.ExperimentUnitPage)
+	fset, d := mustLoadPackage("everydecl")
+	for _, test := range []struct {
+		markers  []string
+		wantTODO bool
+	}{
+		{nil, false},
+		{[]string{"BUG", "TODO"}, true},
+	} {
+		rawDoc, err := Render(ctx, fset, d, RenderOptions{
+			FileLinkFunc:   func(string) string { return "file" },
+			SourceLinkFunc: func(ast.Node) string { return "src" },
+			NoteMarkers:    test.markers,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		htmlDoc, err := html.Parse(strings.NewReader(rawDoc.String()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		checker := htmlcheck.In(".Documentation-note",
+			htmlcheck.In("h3", htmlcheck.HasAttr("id", "pkg-note-BUG")),
+			htmlcheck.In("a.Documentation-noteSource", htmlcheck.HasHref("src")))
+		if err := checker(htmlDoc); err != nil {
+			t.Errorf("%v: BUG note: %v", test.markers, err)
+		}
+		gotTODO := strings.Contains(rawDoc.String(), `id="pkg-note-TODO"`)
+		if gotTODO != test.wantTODO {
+			t.Errorf("%v: got TODO note %t, want %t", test.markers, gotTODO, test.wantTODO)
+		}
+	}
+	// The notes of the caller's package are left alone.
+	if len(d.Notes["TODO"]) == 0 {
+		t.Error("TODO notes were removed from the package")
+	}
+}
+
+func TestRenderOutline(t *testing.T) {
+	ctx := experiment.NewContext(context.Background(), internal.ExperimentUnitPage)
+	fset, d := mustLoadPackage("everydecl")
+
+	var outline Outline
+	rawDoc, err := Render(ctx, fset, d, RenderOptions{
+		FileLinkFunc:   func(string) string { return "file" },
+		SourceLinkFunc: func(ast.Node) string { return "src" },
+		Outline:        &outline,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	htmlDoc, err := html.Parse(strings.NewReader(rawDoc.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var gotSections []string
+	for _, s := range outline.Sections {
+		gotSections = append(gotSections, s.Title)
+	}
+	wantSections := []string{"Overview", "Index", "Constants", "Variables", "Functions", "Types", "Notes"}
+	if diff := cmp.Diff(wantSections, gotSections); diff != "" {
+		t.Errorf("sections mismatch (-want +got):\n%s", diff)
+	}
+	wantFuncs := []*OutlineFunc{{Name: "F", Anchor: "F", Synopsis: "F()"}}
+	if diff := cmp.Diff(wantFuncs, outline.Funcs); diff != "" {
+		t.Errorf("funcs mismatch (-want +got):\n%s", diff)
+	}
+	var gotT *OutlineType
+	for _, ot := range outline.Types {
+		if ot.Name == "T" {
+			gotT = ot
+		}
+	}
+	wantT := &OutlineType{
+		Name:    "T",
+		Anchor:  "T",
+		Funcs:   []*OutlineFunc{{Name: "TF", Anchor: "TF", Synopsis: "TF()"}},
+		Methods: []*OutlineFunc{{Name: "M", Anchor: "T.M", Synopsis: "M()"}},
+	}
+	if diff := cmp.Diff(wantT, gotT); diff != "" {
+		t.Errorf("type T mismatch (-want +got):\n%s", diff)
+	}
+
+	// Every anchor in the outline must be the ID of an element.
+	ids := map[string]bool{}
+	walk(htmlDoc, func(n *html.Node) {
+		if id := attr(n, "id"); id != "" {
+			ids[id] = true
+		}
+	})
+	var anchors []string
+	for _, s := range outline.Sections {
+		anchors = append(anchors, s.Anchor)
+	}
+	for _, f := range outline.Funcs {
+		anchors = append(anchors, f.Anchor)
+	}
+	for _, ot := range outline.Types {
+		anchors = append(anchors, ot.Anchor)
+		for _, f := range append(ot.Funcs, ot.Methods...) {
+			anchors = append(anchors, f.Anchor)
+		}
+	}
+	if len(outline.Notes) == 0 {
+		t.Error("no notes in the outline")
+	}
+	for _, n := range outline.Notes {
+		anchors = append(anchors, n.Anchor)
+	}
+	for _, a := range anchors {
+		if !ids[a] {
+			t.Errorf("no element with id %q", a)
+		}
+	}
+}
+
+func TestRenderUsesLinks(t *testing.T) {
+	ctx := experiment.NewContext(context.Background(), internal.ExperimentUnitPage)
+	fset, d := mustLoadPackage("everydecl")
+
+	rawDoc, err := Render(ctx, fset, d, RenderOptions{
+		FileLinkFunc:   func(string) string { return "file" },
+		SourceLinkFunc: func(ast.Node) string { return "src" },
+		UsesLinkFunc: func(defParts []string) string {
+			if defParts[0] == "S1" {
+				return ""
+			}
+			return "/uses/" + strings.Join(defParts, "/")
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := rawDoc.String()
+	for _, want := range []string{
+		`<a class="Documentation-uses" href="/uses/F">Uses</a>`,
+		`<a class="Documentation-uses" href="/uses/T">Uses</a>`,
+		`<a class="Documentation-uses" href="/uses/TF">Uses</a>`,
+		`<a class="Documentation-uses" href="/uses/T/M">Uses</a>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %s", want)
+		}
+	}
+	if strings.Contains(got, `href="/uses/S1"`) {
+		t.Error("got a link for S1, whose URL is empty")
+	}
+}
+
+func TestRenderSinceVersions(t *testing.T) {
+	ctx := experiment.NewContext(context.Background(), internal.ExperimentUnitPage)
+	fset, d := mustLoadPackage("everydecl")
+
+	rawDoc, err := Render(ctx, fset, d, RenderOptions{
+		FileLinkFunc:   func(string) string { return "file" },
+		SourceLinkFunc: func(ast.Node) string { return "src" },
+		SinceVersions: map[string]string{
+			"F":   "v1.1.0",
+			"TF":  "v1.2.0",
+			"T.M": "v1.3.0",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := rawDoc.String()
+	for _, want := range []string{
+		`<span class="Documentation-sinceVersion" title="Added in v1.1.0">v1.1.0</span></h4>`,
+		`<span class="Documentation-sinceVersion" title="Added in v1.2.0">v1.2.0</span></h4>`,
+		`<span class="Documentation-sinceVersion" title="Added in v1.3.0">v1.3.0</span></h4>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %s", want)
+		}
+	}
+	if n := strings.Count(got, "Documentation-sinceVersion"); n != 3 {
+		t.Errorf("got %d versions, want 3", n)
+	}
+}
+
+func TestRenderEmbeds(t *testing.T) {
+	ctx := experiment.NewContext(context.Background(), internal.ExperimentUnitPage)
+	fset, d := mustLoadPackage("everydecl")
+
+	var many []string
+	for i := 0; i < maxEmbedFiles+2; i++ {
+		many = append(many, fmt.Sprintf("static/f%d.css", i))
+	}
+	rawDoc, err := Render(ctx, fset, d, RenderOptions{
+		FileLinkFunc:   func(file string) string { return "/src/" + file },
+		SourceLinkFunc: func(ast.Node) string { return "src" },
+		Embeds: map[string]*Embed{
+			"V":  {Patterns: []string{"hello.txt"}, Files: []string{"hello.txt"}},
+			"VT": {Patterns: []string{"static", "*.css"}, Files: many},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := rawDoc.String()
+	for _, want := range []string{
+		`<div class="Documentation-embed">Embeds <code>hello.txt</code>: <a class="Documentation-embedFile" href="/src/hello.txt">hello.txt</a></div>`,
+		`<div class="Documentation-embed">Embeds <code>static</code> <code>*.css</code>: <a class="Documentation-embedFile" href="/src/static/f0.css">static/f0.css</a>,`,
+		`href="/src/static/f9.css">static/f9.css</a> and 2 more</div>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %s", want)
+		}
+	}
+	if n := strings.Count(got, "Documentation-embed\""); n != 2 {
+		t.Errorf("got %d embeds, want 2", n)
+	}
+}
+
+func TestRenderExcludedFiles(t *testing.T) {
+	ctx := experiment.NewContext(context.Background(), internal.ExperimentUnitPage)
+	fset, d := mustLoadPackage("everydecl")
+
+	rawDoc, err := Render(ctx, fset, d, RenderOptions{
+		FileLinkFunc:   func(file string) string { return "/src/" + file },
+		SourceLinkFunc: func(ast.Node) string { return "src" },
+		BuildContext:   "linux/amd64",
+		ExcludedFiles: []*ExcludedFile{
+			{Name: "p_windows.go"},
+			{Name: "wasm.go", Constraints: []string{"// +build js,wasm"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := rawDoc.String()
+	for _, want := range []string{
+		`<summary>Build constraints</summary>`,
+		`This documentation is for linux/amd64.`,
+		`<li><a class="Documentation-file" href="/src/p_windows.go">p_windows.go</a>: excluded by its name</li>`,
+		`<li><a class="Documentation-file" href="/src/wasm.go">wasm.go</a>: <code>// +build js,wasm</code></li>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %s", want)
+		}
+	}
+
+	rawDoc, err = Render(ctx, fset, d, RenderOptions{
+		FileLinkFunc:   func(file string) string { return "/src/" + file },
+		SourceLinkFunc: func(ast.Node) string { return "src" },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(rawDoc.String(), "Documentation-buildConstraints") {
+		t.Error("got a build constraints box without excluded files")
+	}
+}
+
+func TestRenderDeprecated(t *testing.T) {
+	ctx := experiment.NewContext(context.Background(), internal.ExperimentUnitPage)
+	fset, d := mustLoadPackage("deprecated")
+
+	rawDoc, err := Render(ctx, fset, d, RenderOptions{
+		FileLinkFunc:       func(string) string { return "file" },
+		SourceLinkFunc:     func(ast.Node) string { return "src" },
+		CollapseDeprecated: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	htmlDoc, err := html.Parse(strings.NewReader(rawDoc.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checker := htmlcheck.In(".Documentation-overview",
+		htmlcheck.In("p.Documentation-deprecated", htmlcheck.HasText("^Deprecated: use another package.")))
+	if err := checker(htmlDoc); err != nil {
+		t.Errorf("package: %v", err)
+	}
+	checker = htmlcheck.In(".Documentation-types",
+		htmlcheck.In(".Documentation-deprecatedComment", htmlcheck.HasText("Deprecated: use A.")))
+	if err := checker(htmlDoc); err != nil {
+		t.Errorf("field: %v", err)
+	}
+
+	var index, deprecated []string
+	walk(htmlDoc, func(n *html.Node) {
+		if n.Type != html.ElementNode || n.Data != "a" {
+			return
+		}
+		href := attr(n, "href")
+		for p := n.Parent; p != nil; p = p.Parent {
+			switch attr(p, "class") {
+			case "Documentation-indexDeprecated":
+				deprecated = append(deprecated, href)
+				return
+			case "Documentation-index":
+				index = append(index, href)
+				return
+			}
+		}
+	})
+	wantIndex := []string{"#pkg-index", "#F", "#T", "#NewT", "#T.M"}
+	if diff := cmp.Diff(wantIndex, index); diff != "" {
+		t.Errorf("index mismatch (-want +got):\n%s", diff)
+	}
+	wantDeprecated := []string{"#Old", "#OldT", "#T.OldM"}
+	if diff := cmp.Diff(wantDeprecated, deprecated); diff != "" {
+		t.Errorf("deprecated mismatch (-want +got):\n%s", diff)
+	}
+}
+
 func TestExampleRender(t *testing.T) {
 	ctx := context.Background()
 	fset, d := mustLoadPackage("example_test")
@@ -60,6 +381,8 @@ func TestExampleRender(t *testing.T) {
 	rawDoc, err := Render(ctx, fset, d, RenderOptions{
 		FileLinkFunc:   func(string) string { return "file" },
 		SourceLinkFunc: func(ast.Node) string { return "src" },
+		// Highlighting is tested in the render package.
+		DisableHighlighting: true,
 	})
 	if err != nil {
 		t.Fatal(err)
@@ -357,6 +680,12 @@ func testIDsAndKinds(t *testing.T, htmlDoc *html.Node) {
 	want := []attrs{
 		{"C", "constant"},
 		{"CT", "constant"},
+		{"CG1", "constant"},
+		{"CG2", "constant"},
+		{"CG3", "constant"},
+		{"CG4", "constant"},
+		{"VG1", "variable"},
+		{"VG2", "variable"},
 		{"F", "function"},
 		{"TF", "function"},
 		{"T.M", "method"},
@@ -0,0 +1,58 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dochtml
+
+import (
+	"github.com/google/safehtml"
+	"github.com/google/safehtml/template"
+	"golang.org/x/pkgsite/internal/godoc/dochtml/internal/render"
+)
+
+// An Embed describes the files that //go:embed directives embed in a
+// package-level variable.
+type Embed struct {
+	// Patterns are the patterns of the directives, in order.
+	Patterns []string
+	// Files are the paths of the embedded files relative to the package
+	// directory, sorted.
+	Files []string
+}
+
+// maxEmbedFiles is the largest number of embedded files listed next to a
+// variable.
+const maxEmbedFiles = 10
+
+var embedTemplate = template.Must(template.New("embed").Parse(
+	`<div class="Documentation-embed">Embeds` +
+		`{{range .Patterns}} <code>{{.}}</code>{{end}}` +
+		`{{if .Files}}:{{range $i, $f := .Files}}{{if $i}},{{end}} {{$f}}{{end}}` +
+		`{{if .More}} and {{.More}} more{{end}}{{end}}</div>`))
+
+// embedHTML returns the HTML that describes the files embedded in any of the
+// variables with the given names, or nothing if there are none. fileLink
+// returns the link to an embedded file.
+func embedHTML(embeds map[string]*Embed, names []string, fileLink func(string) safehtml.HTML) safehtml.HTML {
+	for _, name := range names {
+		e := embeds[name]
+		if e == nil {
+			continue
+		}
+		data := struct {
+			Patterns []string
+			Files    []safehtml.HTML
+			More     int
+		}{Patterns: e.Patterns}
+		files := e.Files
+		if len(files) > maxEmbedFiles {
+			data.More = len(files) - maxEmbedFiles
+			files = files[:maxEmbedFiles]
+		}
+		for _, f := range files {
+			data.Files = append(data.Files, fileLink(f))
+		}
+		return render.ExecuteToHTML(embedTemplate, data)
+	}
+	return safehtml.HTML{}
+}
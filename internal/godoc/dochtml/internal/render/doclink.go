@@ -0,0 +1,162 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package render
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"golang.org/x/pkgsite/internal/godoc/internal/doc"
+)
+
+/*
+This logic resolves doc links: text in square brackets in a doc comment that
+refers to a declaration or a package. The supported forms are
+
+	[Name], [Name.Method]           a declaration in the package being rendered
+	[pkg], [pkg.Name]               a package, or a declaration in one, where pkg
+	                                is the name of an imported package or of a
+	                                package in the same module
+	[import/path], [import/path.Name]  any package with a slash in its path
+
+Any of them may start with a "*", as in [*bytes.Buffer]. Unlike the
+hotlinking in identifierResolver.toHTML, doc links are written on purpose, so
+they are linked even when hotlinking is disabled.
+*/
+
+// docLinkRx matches a possible doc link. It is loose; docLinks.resolve
+// decides whether the text refers to something.
+const docLinkRx = `\[\*?[\pL_][\pL_0-9./\-]*\]`
+
+// majorVersionRx matches the last element of a package path that is a major
+// version suffix, like "v2".
+var majorVersionRx = regexp.MustCompile(`^v[2-9][0-9]*$`)
+
+// docLinks holds what is needed to resolve doc links.
+type docLinks struct {
+	// importPath is the import path of the package being rendered.
+	importPath string
+
+	// pkgPaths maps the names of packages that doc links may refer to
+	// without a path, to their import paths.
+	//
+	// E.g., pkgPaths["json"] == "encoding/json"
+	pkgPaths map[string]string // map[name]pkgPath
+}
+
+// newDocLinks returns the docLinks for pkg. Packages imported by pkg and
+// packages in modulePackages may be referred to by name. Names that are
+// ambiguous among the module packages are dropped; imports take precedence
+// over them, and the names in pids take precedence over both.
+func newDocLinks(pkg *doc.Package, pids *packageIDs, modulePackages []string) *docLinks {
+	dl := &docLinks{importPath: pkg.ImportPath, pkgPaths: map[string]string{}}
+	ambiguous := map[string]bool{}
+	for _, p := range modulePackages {
+		name := defaultPackageName(p)
+		if q, ok := dl.pkgPaths[name]; ok && q != p {
+			ambiguous[name] = true
+		}
+		dl.pkgPaths[name] = p
+	}
+	for name := range ambiguous {
+		delete(dl.pkgPaths, name)
+	}
+	for _, p := range pkg.Imports {
+		dl.pkgPaths[defaultPackageName(p)] = p
+	}
+	for name, p := range pids.impPaths {
+		dl.pkgPaths[name] = p
+	}
+	dl.pkgPaths[pkg.Name] = pkg.ImportPath
+	return dl
+}
+
+// defaultPackageName returns the name that a package with the given import
+// path is most likely to have: its last path element, skipping a major
+// version suffix.
+func defaultPackageName(pkgPath string) string {
+	name := path.Base(pkgPath)
+	if majorVersionRx.MatchString(name) {
+		if dir := path.Dir(pkgPath); dir != "." {
+			name = path.Base(dir)
+		}
+	}
+	if i := strings.IndexByte(name, '.'); i >= 0 {
+		name = name[:i] // E.g., "gopkg.in/yaml.v2" => "yaml"
+	}
+	return strings.Replace(name, "-", "_", -1)
+}
+
+// resolve returns the package path and the identifier within it that the
+// doc link text refers to. The text does not include the brackets. It
+// returns false if the text does not refer to anything it knows of.
+func (dl *docLinks) resolve(text string, pids *packageIDs) (pkgPath, id string, ok bool) {
+	text = strings.TrimPrefix(text, "*")
+	if strings.HasSuffix(text, ".") || strings.Contains(text, "..") {
+		return "", "", false
+	}
+	if i := strings.LastIndexByte(text, '/'); i >= 0 {
+		// [import/path] or [import/path.Name].
+		pkgPath, id = text, ""
+		if j := strings.IndexByte(text[i:], '.'); j >= 0 {
+			pkgPath, id = text[:i+j], text[i+j+1:]
+		}
+		if !isDocLinkID(id) {
+			return "", "", false
+		}
+		return dl.localPath(pkgPath), id, true
+	}
+	if strings.Contains(text, "-") {
+		return "", "", false
+	}
+	// A declaration in this package, like [Name] or [Name.Method].
+	if pids.pkgIDs[pids.name][text] {
+		return "", text, true
+	}
+	parts := strings.SplitN(text, ".", 2)
+	p, ok := dl.pkgPaths[parts[0]]
+	if !ok {
+		return "", "", false
+	}
+	if len(parts) == 1 {
+		return dl.localPath(p), "", true
+	}
+	if p == dl.importPath && !pids.pkgIDs[pids.name][parts[1]] {
+		return "", "", false
+	}
+	if ids, ok := pids.pkgIDs[parts[0]]; ok && !ids[parts[1]] {
+		// We know the declarations of the package, and this isn't one.
+		return "", "", false
+	}
+	return dl.localPath(p), parts[1], true
+}
+
+// localPath returns the empty string if pkgPath is the package being
+// rendered, so that links into it are only anchors.
+func (dl *docLinks) localPath(pkgPath string) string {
+	if pkgPath == dl.importPath {
+		return ""
+	}
+	return pkgPath
+}
+
+// isDocLinkID reports whether id may follow a package path in a doc link:
+// empty, or one or two exported identifiers separated by a dot.
+func isDocLinkID(id string) bool {
+	if id == "" {
+		return true
+	}
+	parts := strings.Split(id, ".")
+	if len(parts) > 2 {
+		return false
+	}
+	for _, p := range parts {
+		if p == "" || !isExported(p) || strings.ContainsAny(p, "/-") {
+			return false
+		}
+	}
+	return true
+}
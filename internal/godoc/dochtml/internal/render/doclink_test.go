@@ -0,0 +1,73 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package render
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/safehtml"
+	"github.com/google/safehtml/testconversions"
+	"golang.org/x/pkgsite/internal/godoc/internal/doc"
+)
+
+func TestDocLinks(t *testing.T) {
+	r := New(context.Background(), nil, pkgTar, &Options{
+		RelatedPackages: []*doc.Package{pkgTime},
+		PackageURL:      func(path string) string { return "/pkg/" + path },
+		ModulePackages:  []string{"archive/tar", "archive/zip", "a/util", "b/util", "x/yaml/v2"},
+		// Doc links are resolved even with hotlinking disabled.
+		DisableHotlinking: true,
+	})
+	for _, test := range []struct {
+		doc, want string
+	}{
+		{"See [Writer].", `See <a href="#Writer">Writer</a>.`},
+		{"See [Writer.WriteHeader].", `See <a href="#Writer.WriteHeader">Writer.WriteHeader</a>.`},
+		{"See [*Header].", `See <a href="#Header">*Header</a>.`},
+		{"See [tar.Reader].", `See <a href="#Reader">tar.Reader</a>.`},
+		{"Returns [io.EOF].", `Returns <a href="/pkg/io#EOF">io.EOF</a>.`},
+		{"See [zip].", `See <a href="/pkg/archive/zip">zip</a>.`},
+		{"See [zip.Writer].", `See <a href="/pkg/archive/zip#Writer">zip.Writer</a>.`},
+		{"See [yaml.Node].", `See <a href="/pkg/x/yaml/v2#Node">yaml.Node</a>.`},
+		{"See [encoding/json.Marshal].", `See <a href="/pkg/encoding/json#Marshal">encoding/json.Marshal</a>.`},
+		{"See [encoding/json].", `See <a href="/pkg/encoding/json">encoding/json</a>.`},
+		{"See [archive/tar.Reader].", `See <a href="#Reader">archive/tar.Reader</a>.`},
+		{"See [time.Duration.String].", `See <a href="/pkg/time#Duration.String">time.Duration.String</a>.`},
+		// Unresolved references are left alone.
+		{"See [time.NoExist].", `See [time.NoExist].`},
+		{"See [tar.NoExist].", `See [tar.NoExist].`},
+		{"See [util.Helper].", `See [util.Helper].`}, // ambiguous
+		{"See [NoExist].", `See [NoExist].`},
+		{"See [encoding/json.marshal].", `See [encoding/json.marshal].`},
+		{"Use a[i] to index.", `Use a[i] to index.`},
+		{"Use m[Writer] to index.", `Use m[Writer] to index.`},
+		{"Bare URLs like https://golang.org are linked.", `Bare URLs like <a href="https://golang.org">https://golang.org</a> are linked.`},
+		{"As in RFC 7230, section 2.1.", `As in <a href="https://rfc-editor.org/rfc/rfc7230.html#section-2.1">RFC 7230, section 2.1</a>.`},
+	} {
+		got := r.declHTML(test.doc, nil).Doc
+		want := testconversions.MakeHTMLForTest("<p>" + test.want + "\n</p>")
+		if diff := cmp.Diff(want, got, cmp.AllowUnexported(safehtml.HTML{})); diff != "" {
+			t.Errorf("%q: mismatch (-want +got)\n%s", test.doc, diff)
+		}
+	}
+}
+
+func TestDefaultPackageName(t *testing.T) {
+	for _, test := range []struct {
+		path, want string
+	}{
+		{"fmt", "fmt"},
+		{"encoding/json", "json"},
+		{"github.com/a/b/v3", "b"},
+		{"gopkg.in/yaml.v2", "yaml"},
+		{"github.com/a/go-foo", "go_foo"},
+	} {
+		if got := defaultPackageName(test.path); got != test.want {
+			t.Errorf("defaultPackageName(%q) = %q, want %q", test.path, got, test.want)
+		}
+	}
+}
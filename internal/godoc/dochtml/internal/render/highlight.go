@@ -0,0 +1,99 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package render
+
+import (
+	"go/parser"
+	"go/scanner"
+	"go/token"
+
+	"github.com/google/safehtml"
+	safetemplate "github.com/google/safehtml/template"
+)
+
+/*
+This logic highlights Go source code by wrapping some of its tokens in spans:
+
+	<span class="keyword">  keywords, like "func" and "range"
+	<span class="string">   string and rune literals
+	<span class="number">   integer, floating-point and imaginary literals
+	<span class="comment">  comments
+
+Declarations and example code are always Go. Preformatted blocks in doc
+comments are highlighted only if they parse as Go, since they are often
+shell sessions or program output.
+*/
+
+// tokenClass returns the class of the span that highlights tok, or the empty
+// string if tok is not highlighted. Comments are handled by the callers.
+func tokenClass(tok token.Token) string {
+	switch {
+	case tok.IsKeyword():
+		return "keyword"
+	case tok == token.STRING || tok == token.CHAR:
+		return "string"
+	case tok == token.INT || tok == token.FLOAT || tok == token.IMAG:
+		return "number"
+	default:
+		return ""
+	}
+}
+
+type highlightSpan struct {
+	Class string
+	Text  string
+}
+
+var highlightTemplate = safetemplate.Must(safetemplate.New("highlight").Parse(`<span class="{{.Class}}">{{.Text}}</span>`))
+
+// highlightHTML returns text wrapped in a span of the given class.
+func highlightHTML(class, text string) safehtml.HTML {
+	return ExecuteToHTML(highlightTemplate, highlightSpan{Class: class, Text: text})
+}
+
+// highlightGo returns src as HTML, with its tokens highlighted.
+func highlightGo(src string) safehtml.HTML {
+	var htmls []safehtml.HTML
+	var lastOffset int // last src offset copied to htmls
+	var s scanner.Scanner
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+	s.Init(file, []byte(src), nil, scanner.ScanComments)
+	for {
+		p, tok, lit := s.Scan()
+		offset := file.Offset(p)
+		htmls = append(htmls, safehtml.HTMLEscaped(src[lastOffset:offset]))
+		lastOffset = offset
+		if tok == token.EOF {
+			break
+		}
+		class := tokenClass(tok)
+		if tok == token.COMMENT {
+			class = "comment"
+		}
+		if class != "" {
+			htmls = append(htmls, highlightHTML(class, lit))
+			lastOffset += len(lit)
+		}
+	}
+	htmls = append(htmls, safehtml.HTMLEscaped(src[lastOffset:]))
+	return safehtml.HTMLConcat(htmls...)
+}
+
+// isGoCode reports whether src is a Go source file, a list of declarations
+// or a list of statements.
+func isGoCode(src string) bool {
+	fset := token.NewFileSet()
+	for _, s := range []string{
+		src,
+		"package p\n" + src,
+		"package p\nfunc _() {\n" + src + "\n}",
+	} {
+		if _, err := parser.ParseFile(fset, "", s, parser.ParseComments); err == nil {
+			return true
+		}
+	}
+	return false
+}
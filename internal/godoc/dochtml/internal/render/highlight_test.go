@@ -0,0 +1,97 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package render
+
+import (
+	"context"
+	"go/ast"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal/godoc/internal/doc"
+)
+
+func TestHighlightGo(t *testing.T) {
+	src := "for i := 0; i < 10; i++ {\n\tfmt.Println(\"<b>\", 'x') // print\n}\n"
+	want := `<span class="keyword">for</span> i := <span class="number">0</span>; i &lt; <span class="number">10</span>; i++ {` + "\n" +
+		"\tfmt.Println(<span class=\"string\">&#34;&lt;b&gt;&#34;</span>, <span class=\"string\">&#39;x&#39;</span>) <span class=\"comment\">// print</span>\n}\n"
+	if diff := cmp.Diff(want, highlightGo(src).String()); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestIsGoCode(t *testing.T) {
+	for _, test := range []struct {
+		src  string
+		want bool
+	}{
+		{"package main\n\nfunc main() {}", true},
+		{"type T struct{ X int }", true},
+		{"x := f(1)\nfmt.Println(x)", true},
+		{"$ go get example.com/mod", false},
+		{"Output:\n\t1 2 3", false},
+		{"if you want more, ask", false},
+	} {
+		if got := isGoCode(test.src); got != test.want {
+			t.Errorf("isGoCode(%q) = %t, want %t", test.src, got, test.want)
+		}
+	}
+}
+
+func TestHighlighting(t *testing.T) {
+	fset := token.NewFileSet()
+	file := mustParse(t, fset, "p.go", `package p
+
+// F returns the length of s.
+//
+//	n := F("abc")
+func F(s string) int { return len(s) }
+`)
+	pkg, err := doc.NewFromFiles(fset, []*ast.File{file}, "example.com/p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := pkg.Funcs[0]
+	ex := &doc.Example{Code: &ast.BasicLit{Kind: token.STRING, Value: `"x"`}}
+
+	for _, test := range []struct {
+		name    string
+		disable bool
+		want    []string
+		notWant []string
+	}{
+		{
+			name: "enabled",
+			want: []string{
+				`<span class="keyword">func</span> F`,
+				`<span class="string">&#34;abc&#34;</span>`,
+				`<pre class="Documentation-exampleCode">` + "\n" + `<span class="string">&#34;x&#34;</span>`,
+			},
+		},
+		{
+			name:    "disabled",
+			disable: true,
+			notWant: []string{`class="keyword"`, `class="string"`},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			r := New(context.Background(), fset, pkg, &Options{DisableHighlighting: test.disable})
+			out := r.DeclHTML(f.Doc, f.Decl)
+			got := out.Doc.String() + out.Decl.String() + r.CodeHTML(ex).String()
+			for _, w := range test.want {
+				if !strings.Contains(got, w) {
+					t.Errorf("output does not contain %q:\n%s", w, got)
+				}
+			}
+			for _, w := range test.notWant {
+				if strings.Contains(got, w) {
+					t.Errorf("output contains %q:\n%s", w, got)
+				}
+			}
+		})
+	}
+}
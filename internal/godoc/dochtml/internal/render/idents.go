@@ -7,6 +7,8 @@ package render
 import (
 	"go/ast"
 	"go/token"
+	"path"
+	"strconv"
 	"strings"
 	"unicode"
 	"unicode/utf8"
@@ -83,6 +85,14 @@ type packageIDs struct {
 
 	// topLevelDecls is the set of all AST declarations for the this package.
 	topLevelDecls map[interface{}]bool // map[T]bool where T is *ast.FuncDecl | *ast.GenDecl | *ast.TypeSpec | *ast.ValueSpec
+
+	// imports maps the names that the packages imported by this package are
+	// assumed to have to their import paths. It is used to link qualified
+	// identifiers whose package name could not be resolved from the AST.
+	// Names that more than one import could have are omitted.
+	//
+	// E.g., imports["foo"] == "github.com/x/go-foo"
+	imports map[string]string // map[name]pkgPath
 }
 
 // newPackageIDs returns a packageIDs that collects all top-level identifiers
@@ -93,6 +103,7 @@ func newPackageIDs(pkg *doc.Package, related ...*doc.Package) *packageIDs {
 		impPaths:      make(map[string]string),
 		pkgIDs:        make(map[string]map[string]bool),
 		topLevelDecls: make(map[interface{}]bool),
+		imports:       make(map[string]string),
 	}
 
 	// Collect top-level declaration IDs for pkg and related packages.
@@ -109,6 +120,21 @@ func newPackageIDs(pkg *doc.Package, related ...*doc.Package) *packageIDs {
 		})
 	}
 
+	// Collect the assumed names of imported packages.
+	ambiguous := map[string]bool{}
+	for _, path := range pkg.Imports {
+		name := assumedPackageName(path)
+		if name == "" || ambiguous[name] {
+			continue
+		}
+		if _, ok := pids.imports[name]; ok {
+			delete(pids.imports, name)
+			ambiguous[name] = true
+			continue
+		}
+		pids.imports[name] = path
+	}
+
 	// Collect AST objects for accurate linking of Go source code.
 	forEachPackageDecl(pkg, func(decl ast.Decl) {
 		pids.topLevelDecls[decl] = true
@@ -355,6 +381,31 @@ func nodeName(n ast.Node) (string, *ast.Ident) {
 	}
 }
 
+// assumedPackageName returns the name that the package with the given import
+// path is assumed to have, following the conventions of goimports: the last
+// element of the path, skipping a major version element like "v2", without a
+// "go-" prefix, and truncated at the first character that cannot appear in an
+// identifier.
+//
+// E.g., assumedPackageName("github.com/x/go-foo/v2") == "foo"
+func assumedPackageName(importPath string) string {
+	base := path.Base(importPath)
+	if strings.HasPrefix(base, "v") {
+		if _, err := strconv.Atoi(base[1:]); err == nil {
+			if dir := path.Dir(importPath); dir != "." {
+				base = path.Base(dir)
+			}
+		}
+	}
+	base = strings.TrimPrefix(base, "go-")
+	if i := strings.IndexFunc(base, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	}); i >= 0 {
+		base = base[:i]
+	}
+	return base
+}
+
 func isExported(id string) bool {
 	r, _ := utf8.DecodeRuneInString(id)
 	return unicode.IsUpper(r)
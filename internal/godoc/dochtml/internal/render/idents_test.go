@@ -131,3 +131,22 @@ func findDecl(pkg *doc.Package, id string) ast.Decl {
 	}
 	return nil
 }
+
+func TestAssumedPackageName(t *testing.T) {
+	for _, test := range []struct {
+		path, want string
+	}{
+		{"fmt", "fmt"},
+		{"net/http", "http"},
+		{"github.com/x/go-foo", "foo"},
+		{"github.com/x/foo-go", "foo"},
+		{"github.com/x/foo.go", "foo"},
+		{"github.com/x/foo/v2", "foo"},
+		{"gopkg.in/yaml.v2", "yaml"},
+		{"v2", "v2"},
+	} {
+		if got := assumedPackageName(test.path); got != test.want {
+			t.Errorf("assumedPackageName(%q) = %q, want %q", test.path, got, test.want)
+		}
+	}
+}
@@ -56,7 +56,7 @@ const (
 )
 
 var (
-	matchRx     = regexp.MustCompile(urlRx + `|` + rfcRx + `|` + qualIdentRx)
+	matchRx     = regexp.MustCompile(urlRx + `|` + rfcRx + `|` + docLinkRx + `|` + qualIdentRx)
 	badAnchorRx = regexp.MustCompile(`[^a-zA-Z0-9]`)
 )
 
@@ -68,6 +68,8 @@ type docData struct {
 type docElement struct {
 	IsHeading   bool
 	IsPreformat bool
+	// IsDeprecated is set for a paragraph that starts with "Deprecated:".
+	IsDeprecated bool
 	// for paragraph and preformat
 	Body safehtml.HTML
 	// for heading
@@ -84,6 +86,8 @@ var docTmpl = safetemplate.Must(safetemplate.New("").Parse(`
     </h3>
   {{else if .IsPreformat -}}
     <pre>{{.Body}}</pre>
+  {{- else if .IsDeprecated -}}
+    <p class="Documentation-deprecated">{{.Body}}</p>
   {{- else -}}
     <p>{{.Body}}</p>
   {{- end -}}
@@ -99,9 +103,14 @@ func (r *Renderer) declHTML(doc string, decl ast.Decl) (out struct{ Doc, Decl sa
 			switch blk := blk.(type) {
 			case *paragraph:
 				el.Body = r.linesToHTML(blk.lines, idr)
+				el.IsDeprecated = blk.isDeprecated()
 			case *preformat:
 				el.IsPreformat = true
-				el.Body = r.linesToHTML(blk.lines, nil)
+				if src := strings.Join(blk.lines, "\n") + "\n"; !r.disableHighlight && isGoCode(src) {
+					el.Body = highlightGo(src)
+				} else {
+					el.Body = r.linesToHTML(blk.lines, nil)
+				}
 			case *heading:
 				el.IsHeading = true
 				el.Title = blk.title
@@ -113,14 +122,27 @@ func (r *Renderer) declHTML(doc string, decl ast.Decl) (out struct{ Doc, Decl sa
 		out.Doc = ExecuteToHTML(docTmpl, docData{Elements: els, DisablePermalinks: r.disablePermalinks})
 	}
 	if decl != nil {
-		out.Decl = safehtml.HTMLConcat(
+		shown, folded := r.formatDeclHTML(decl, idr)
+		htmls := []safehtml.HTML{
 			safetemplate.MustParseAndExecuteToHTML("<pre>\n"),
-			r.formatDeclHTML(decl, idr),
-			safetemplate.MustParseAndExecuteToHTML("</pre>\n"))
+			shown,
+			safetemplate.MustParseAndExecuteToHTML("</pre>\n"),
+		}
+		if folded.String() != "" {
+			n := len(decl.(*ast.GenDecl).Specs) - r.foldValuesAfter
+			htmls = append(htmls,
+				ExecuteToHTML(foldStartTemplate, n),
+				folded,
+				safetemplate.MustParseAndExecuteToHTML("</pre>\n</details>\n"))
+		}
+		out.Decl = safehtml.HTMLConcat(htmls...)
 	}
 	return out
 }
 
+var foldStartTemplate = safetemplate.Must(safetemplate.New("foldStart").Parse(
+	`<details class="Documentation-valueFold"><summary>{{.}} more</summary><pre>` + "\n"))
+
 func (r *Renderer) linesToHTML(lines []string, idr *identifierResolver) safehtml.HTML {
 	newline := safehtml.HTMLEscaped("\n")
 	htmls := make([]safehtml.HTML, 0, 2*len(lines))
@@ -157,19 +179,19 @@ func (r *Renderer) codeHTML(ex *doc.Example) safehtml.HTML {
 	if err != nil {
 		log.Errorf(r.ctx, "Error converting *doc.Example into string: %v", err)
 	}
-	return codeHTML(codeStr)
+	return codeHTML(codeStr, !r.disableHighlight)
 }
 
 type codeElement struct {
-	Text    string
-	Comment bool
+	Text  string
+	Class string // class of the span around Text, if any
 }
 
 var codeTmpl = safetemplate.Must(safetemplate.New("").Parse(`
 <pre class="Documentation-exampleCode">
 {{range .}}
-  {{- if .Comment -}}
-    <span class="comment">{{.Text}}</span>
+  {{- if .Class -}}
+    <span class="{{.Class}}">{{.Text}}</span>
   {{- else -}}
     {{.Text}}
   {{- end -}}
@@ -177,7 +199,9 @@ var codeTmpl = safetemplate.Must(safetemplate.New("").Parse(`
 </pre>
 `))
 
-func codeHTML(src string) safehtml.HTML {
+// codeHTML formats example code. Comments are always highlighted, other
+// tokens only if highlight is true.
+func codeHTML(src string, highlight bool) safehtml.HTML {
 	var els []codeElement
 	// If code is an *ast.BlockStmt, then trim the braces.
 	var indent string
@@ -204,8 +228,12 @@ scan:
 		offset := file.Offset(p) // current offset into source file
 		prev := src[lastOffset:offset]
 		prev = strings.Replace(prev, indent, "\n", -1)
-		els = append(els, codeElement{prev, false})
+		els = append(els, codeElement{prev, ""})
 		lastOffset = offset
+		var class string
+		if highlight {
+			class = tokenClass(tok)
+		}
 		switch tok {
 		case token.EOF:
 			break scan
@@ -214,12 +242,17 @@ scan:
 				outputOffset = len(els)
 			}
 			lit = strings.Replace(lit, indent, "\n", -1)
-			els = append(els, codeElement{lit, true})
+			els = append(els, codeElement{lit, "comment"})
 			lastOffset += len(lit)
 		case token.STRING:
 			// Avoid replacing indents in multi-line string literals.
-			els = append(els, codeElement{lit, false})
+			els = append(els, codeElement{lit, class})
 			lastOffset += len(lit)
+		default:
+			if class != "" {
+				els = append(els, codeElement{lit, class})
+				lastOffset += len(lit)
+			}
 		}
 	}
 
@@ -234,11 +267,13 @@ scan:
 }
 
 // formatLineHTML formats the line as HTML-annotated text.
-// URLs and Go identifiers are linked to corresponding declarations.
+// URLs, RFCs and doc links are linked, and Go identifiers are linked to
+// corresponding declarations.
 func (r *Renderer) formatLineHTML(line string, idr *identifierResolver) safehtml.HTML {
 	var htmls []safehtml.HTML
 	var lastChar, nextChar byte
 	var numQuotes int
+	afterWord := false // whether the previous match ended where this one starts
 
 	addLink := func(href, text string) {
 		htmls = append(htmls, ExecuteToHTML(LinkTemplate, Link{Href: href, Text: text}))
@@ -254,6 +289,7 @@ func (r *Renderer) formatLineHTML(line string, idr *identifierResolver) safehtml
 			htmls = append(htmls, safehtml.HTMLEscaped(nonWord))
 			lastChar = nonWord[len(nonWord)-1]
 			numQuotes += countQuotes(nonWord)
+			afterWord = false
 		}
 		if m1 > m0 {
 			word := line[m0:m1]
@@ -272,6 +308,22 @@ func (r *Renderer) formatLineHTML(line string, idr *identifierResolver) safehtml
 			// TODO: Should we provide hotlinks for related packages?
 
 			switch {
+			case strings.HasPrefix(word, "["):
+				// Doc links like "[io.Reader]" are written on purpose, so
+				// they are linked even if hotlinking is disabled. Text that
+				// does not resolve, like the "[i]" in "a[i]", is formatted as
+				// if it were not in brackets.
+				text := word[1 : len(word)-1]
+				if !afterWord && validPrefix && validSuffix && idr != nil {
+					if pkgPath, id, ok := r.docLinks.resolve(text, idr.packageIDs); ok {
+						addLink(idr.toURL(pkgPath, id), text)
+						break
+					}
+				}
+				htmls = append(htmls,
+					safehtml.HTMLEscaped("["),
+					r.formatLineHTML(text, idr),
+					safehtml.HTMLEscaped("]"))
 			case strings.Contains(word, "://"):
 				// Forbid closing brackets without prior opening brackets.
 				// See https://golang.org/issue/22285.
@@ -316,6 +368,7 @@ func (r *Renderer) formatLineHTML(line string, idr *identifierResolver) safehtml
 				htmls = append(htmls, safehtml.HTMLEscaped(word))
 			}
 			numQuotes += countQuotes(word)
+			afterWord = true
 		}
 		line = line[m1:]
 	}
@@ -340,19 +393,34 @@ func countQuotes(s string) int {
 
 // formatDeclHTML formats the decl as HTML-annotated source code for the
 // provided decl. Type identifiers are linked to corresponding declarations.
-func (r *Renderer) formatDeclHTML(decl ast.Decl, idr *identifierResolver) safehtml.HTML {
+//
+// If decl is a constant or variable group with more than r.foldValuesAfter
+// specs, the lines of the remaining specs are returned separately in folded,
+// as plain source code with anchors.
+func (r *Renderer) formatDeclHTML(decl ast.Decl, idr *identifierResolver) (out, folded safehtml.HTML) {
 	// Generate all anchor points and links for the given decl.
 	anchorPointsMap := generateAnchorPoints(decl)
 	anchorLinksMap := generateAnchorLinks(idr, decl)
 
+	// foldIdent is the first identifier of the first folded spec, if any.
+	var foldIdent *ast.Ident
+	if gd, ok := decl.(*ast.GenDecl); ok && r.foldValuesAfter > 0 &&
+		(gd.Tok == token.CONST || gd.Tok == token.VAR) && len(gd.Specs) > r.foldValuesAfter {
+		foldIdent = gd.Specs[r.foldValuesAfter].(*ast.ValueSpec).Names[0]
+	}
+
 	// Convert the maps (keyed by *ast.Ident) to slices of idKinds or URLs.
 	//
 	// This relies on the ast.Inspect and scanner.Scanner both
 	// visiting *ast.Ident and token.IDENT nodes in the same order.
 	var anchorPoints []idKind
 	var anchorLinks []string
+	foldIdx := -1 // index of foldIdent in anchorPoints and anchorLinks
 	ast.Inspect(decl, func(node ast.Node) bool {
 		if id, ok := node.(*ast.Ident); ok {
+			if id == foldIdent {
+				foldIdx = len(anchorPoints)
+			}
 			anchorPoints = append(anchorPoints, anchorPointsMap[id])
 			anchorLinks = append(anchorLinks, anchorLinksMap[id])
 		}
@@ -382,8 +450,10 @@ func (r *Renderer) formatDeclHTML(decl ast.Decl, idr *identifierResolver) safeht
 
 	// Scan through the source code, appropriately annotating it with HTML spans
 	// for comments, and HTML links and anchors for relevant identifiers.
-	var idIdx int      // current index in anchorPoints and anchorLinks
-	var lastOffset int // last src offset copied to output buffer
+	var idIdx int         // current index in anchorPoints and anchorLinks
+	foldLine := -1        // first folded line
+	var lastOffset int    // last src offset copied to output buffer
+	var inDeprecated bool // in a comment paragraph that starts with "Deprecated:"
 	var s scanner.Scanner
 	s.Init(file, src, nil, scanner.ScanComments)
 scan:
@@ -409,12 +479,28 @@ scan:
 			break scan
 		case token.COMMENT:
 			tokType = commentType
+			// Mark the comments on deprecated struct fields and interface
+			// methods, up to the end of the "Deprecated:" paragraph.
+			switch text := strings.TrimSpace(strings.TrimPrefix(lit, "//")); {
+			case strings.HasPrefix(text, deprecatedPrefix):
+				inDeprecated = true
+			case text == "":
+				inDeprecated = false
+			}
+			start := safetemplate.MustParseAndExecuteToHTML(`<span class="comment">`)
+			if inDeprecated {
+				start = safetemplate.MustParseAndExecuteToHTML(`<span class="comment Documentation-deprecatedComment">`)
+			}
 			htmlLines[line] = append(htmlLines[line],
-				safetemplate.MustParseAndExecuteToHTML(`<span class="comment">`),
+				start,
 				r.formatLineHTML(lit, idr),
 				safetemplate.MustParseAndExecuteToHTML(`</span>`))
 			lastOffset += len(lit)
 		case token.IDENT:
+			inDeprecated = false
+			if idIdx == foldIdx {
+				foldLine = line
+			}
 			if idIdx < len(anchorPoints) && anchorPoints[idIdx].ID.String() != "" {
 				anchorLines[line] = append(anchorLines[line], anchorPoints[idIdx])
 			}
@@ -423,6 +509,11 @@ scan:
 				lastOffset += len(lit)
 			}
 			idIdx++
+		default:
+			if class := tokenClass(tok); class != "" && !r.disableHighlight {
+				htmlLines[line] = append(htmlLines[line], highlightHTML(class, lit))
+				lastOffset += len(lit)
+			}
 		}
 		for i := strings.Count(strings.TrimSuffix(lit, "\n"), "\n"); i >= 0; i-- {
 			lineTypes[line+i] |= tokType
@@ -441,9 +532,27 @@ scan:
 		}
 	}
 
+	// Fold the doc comment of the first folded spec along with it.
+	if foldLine >= 0 {
+		for foldLine > 0 && lineTypes[foldLine-1] == commentType {
+			foldLine--
+		}
+	}
+	srcLines := strings.SplitAfter(string(src), "\n")
+
 	// Emit anchor IDs and data-kind attributes for each relevant line.
-	var htmls []safehtml.HTML
+	var htmls, foldedHTMLs []safehtml.HTML
+	if gd, ok := decl.(*ast.GenDecl); ok {
+		if id := r.groupID(gd); id.String() != "" {
+			htmls = append(htmls, ExecuteToHTML(groupAnchorTemplate, id))
+		}
+	}
 	for line, iks := range anchorLines {
+		fold := foldLine >= 0 && line >= foldLine
+		dst := &htmls
+		if fold {
+			dst = &foldedHTMLs
+		}
 		for _, ik := range iks {
 			// Attributes for types and functions are handled in the template
 			// that generates the full documentation HTML.
@@ -455,15 +564,23 @@ scan:
 			if fd, ok := decl.(*ast.FuncDecl); ok && fd.Recv != nil {
 				continue
 			}
-			htmls = append(htmls, ExecuteToHTML(anchorTemplate, ik))
+			*dst = append(*dst, ExecuteToHTML(anchorTemplate, ik))
+		}
+		if fold {
+			*dst = append(*dst, safehtml.HTMLEscaped(srcLines[line]))
+		} else {
+			*dst = append(*dst, htmlLines[line]...)
 		}
-		htmls = append(htmls, htmlLines[line]...)
 	}
-	return safehtml.HTMLConcat(htmls...)
+	return safehtml.HTMLConcat(htmls...), safehtml.HTMLConcat(foldedHTMLs...)
 }
 
 var anchorTemplate = safetemplate.Must(safetemplate.New("anchor").Parse(`<span id="{{.ID}}" data-kind="{{.Kind}}"></span>`))
 
+// groupAnchorTemplate has no data-kind attribute, because a group is not an
+// identifier and should not be listed with them.
+var groupAnchorTemplate = safetemplate.Must(safetemplate.New("groupAnchor").Parse(`<span id="{{.}}"></span>`))
+
 // declVisitor is used to walk over the AST and trim large string
 // literals and arrays before package documentation is rendered.
 // Comments are added to Comments to indicate that a part of the
@@ -535,6 +652,71 @@ func ValidateGoDottedExpr(s string) {
 	}
 }
 
+// groupID returns the ID of the anchor for decl if it is a parenthesized
+// group of constants or variables, and the empty identifier otherwise.
+//
+// The ID is "const-" or "var-" followed by the name of the type shared by all
+// the names in the group, like "const-Weekday". If they have no type in
+// common, or if an earlier group already has that ID, the name of the first
+// exported identifier of the group is used instead, like "var-ErrShortWrite".
+// Unlike a position, neither changes when the declarations around the group
+// are reordered or new ones are added to it.
+func (r *Renderer) groupID(decl *ast.GenDecl) safehtml.Identifier {
+	if !decl.Lparen.IsValid() || (decl.Tok != token.CONST && decl.Tok != token.VAR) {
+		return safehtml.Identifier{}
+	}
+	prefix := "const"
+	if decl.Tok == token.VAR {
+		prefix = "var"
+	}
+	name := groupTypeName(decl)
+	if name == "" || r.groupIDs[prefix+"-"+name] {
+		name = ""
+		for _, sp := range decl.Specs {
+			for _, n := range sp.(*ast.ValueSpec).Names {
+				if name == "" && n.IsExported() {
+					name = n.Name
+				}
+			}
+		}
+	}
+	if name == "" {
+		return safehtml.Identifier{}
+	}
+	ValidateGoDottedExpr(name)
+	id := prefix + "-" + name
+	if r.groupIDs == nil {
+		r.groupIDs = map[string]bool{}
+	}
+	r.groupIDs[id] = true
+	return legacyconversions.RiskilyAssumeIdentifier(id)
+}
+
+// groupTypeName returns the name of the type of every value in the group
+// decl, or the empty string if they do not all have the same type declared
+// in the package. A constant without a type or a value repeats the type of
+// the previous one, as in an iota sequence.
+func groupTypeName(decl *ast.GenDecl) string {
+	var name, prev string
+	for i, sp := range decl.Specs {
+		vs := sp.(*ast.ValueSpec)
+		typ := ""
+		switch {
+		case vs.Type != nil:
+			if id, ok := vs.Type.(*ast.Ident); ok {
+				typ = id.Name
+			}
+		case decl.Tok == token.CONST && len(vs.Values) == 0:
+			typ = prev
+		}
+		if typ == "" || (i > 0 && typ != name) {
+			return ""
+		}
+		name, prev = typ, typ
+	}
+	return name
+}
+
 // generateAnchorPoints returns a mapping of *ast.Ident objects to the
 // qualified ID that should be set as an anchor point, as well as the kind
 // of identifer, used in the data-kind attribute.
@@ -550,6 +732,11 @@ func generateAnchorPoints(decl ast.Decl) map[*ast.Ident]idKind {
 					kind = "variable"
 				}
 				for _, name := range sp.(*ast.ValueSpec).Names {
+					// Blank identifiers cannot be referred to, and there may
+					// be several of them.
+					if name.Name == "_" {
+						continue
+					}
 					m[name] = idKind{SafeGoID(name.Name), kind}
 				}
 			case token.TYPE:
@@ -616,16 +803,12 @@ func generateAnchorLinks(idr *identifierResolver, decl ast.Decl) map[*ast.Ident]
 		case *ast.SelectorExpr:
 			// Package qualified identifier (e.g., "io.EOF").
 			if prefix, _ := node.X.(*ast.Ident); prefix != nil {
-				if obj := prefix.Obj; obj != nil && obj.Kind == ast.Pkg {
-					if spec, _ := obj.Decl.(*ast.ImportSpec); spec != nil {
-						if path, err := strconv.Unquote(spec.Path.Value); err == nil {
-							// Register two links, one for the package
-							// and one for the qualified identifier.
-							m[prefix] = idr.toURL(path, "")
-							m[node.Sel] = idr.toURL(path, node.Sel.Name)
-							return false
-						}
-					}
+				if path := importPath(idr, prefix); path != "" {
+					// Register two links, one for the package
+					// and one for the qualified identifier.
+					m[prefix] = idr.toURL(path, "")
+					m[node.Sel] = idr.toURL(path, node.Sel.Name)
+					return false
 				}
 			}
 		case *ast.Ident:
@@ -651,3 +834,26 @@ func generateAnchorLinks(idr *identifierResolver, decl ast.Decl) map[*ast.Ident]
 	})
 	return m
 }
+
+// importPath returns the import path of the package that the identifier
+// prefix, used as the package qualifier of a selector expression, refers to,
+// or the empty string if it doesn't refer to an imported package.
+//
+// The AST resolves the qualifier when the package name can be derived from
+// the import path. Otherwise the qualifier is unresolved, and it is looked
+// up among the names that the imported packages are assumed to have.
+func importPath(idr *identifierResolver, prefix *ast.Ident) string {
+	obj := prefix.Obj
+	if obj == nil {
+		return idr.imports[prefix.Name]
+	}
+	if obj.Kind != ast.Pkg {
+		return ""
+	}
+	if spec, _ := obj.Decl.(*ast.ImportSpec); spec != nil {
+		if path, err := strconv.Unquote(spec.Path.Value); err == nil {
+			return path
+		}
+	}
+	return ""
+}
@@ -123,7 +123,7 @@ func TestDeclHTML(t *testing.T) {
 			name:   "const",
 			symbol: "Nanosecond",
 			want: `<pre>
-const (
+<span id="const-Nanosecond"></span>const (
 <span id="Nanosecond" data-kind="constant"></span>	Nanosecond  <a href="#Duration">Duration</a> = 1
 <span id="Microsecond" data-kind="constant"></span>	Microsecond          = 1000 * <a href="#Nanosecond">Nanosecond</a>
 <span id="Millisecond" data-kind="constant"></span>	Millisecond          = 1000 * <a href="#Microsecond">Microsecond</a> <span class="comment">// comment</span>
@@ -178,6 +178,138 @@ func After(d <a href="#Duration">Duration</a>) &lt;-chan <a href="#Time">Time</a
 	}
 }
 
+func TestGroupID(t *testing.T) {
+	const src = `package p
+
+type Weekday int
+
+const (
+	Sunday Weekday = iota
+	Monday
+)
+
+const (
+	Saturday Weekday = 6
+	Unknown  Weekday = -1
+)
+
+const (
+	x   = 1
+	Max = 2
+	Min = 3
+)
+
+var (
+	ErrA = error(nil)
+	ErrB Weekday
+)
+
+var (
+	_ = 1
+	y = 2
+)
+
+const Single = 1
+`
+	want := []string{"", "const-Weekday", "const-Saturday", "const-Max", "var-ErrA", "", ""}
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &Renderer{}
+	var got []string
+	for _, d := range f.Decls {
+		got = append(got, r.groupID(d.(*ast.GenDecl)).String())
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got)\n%s", diff)
+	}
+}
+
+func TestDeclHTMLImports(t *testing.T) {
+	const src = `package p
+
+import (
+	"context"
+	"example.com/go-bar"
+	"example.com/foo/v2"
+	"gopkg.in/yaml.v2"
+)
+
+func F(context.Context, *bar.Bar, foo.Foo, yaml.Node) {}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg, err := doc.NewFromFiles(fset, []*ast.File{f}, "example.com/p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := New(context.Background(), fset, pkg, nil)
+	got := r.declHTML("", declForName(t, pkg, "F")).Decl.String()
+	for _, want := range []string{
+		`<a href="/context">context</a>.<a href="/context#Context">Context</a>`,
+		`<a href="/example.com/go-bar">bar</a>.<a href="/example.com/go-bar#Bar">Bar</a>`,
+		`<a href="/example.com/foo/v2">foo</a>.<a href="/example.com/foo/v2#Foo">Foo</a>`,
+		`<a href="/gopkg.in/yaml.v2">yaml</a>.<a href="/gopkg.in/yaml.v2#Node">Node</a>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %s in\n%s", want, got)
+		}
+	}
+}
+
+func TestDeclHTMLFold(t *testing.T) {
+	const src = `package p
+
+type Code int
+
+const (
+	A Code = iota
+	B
+	// C is the third code.
+	C
+	D
+)
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg, err := doc.NewFromFiles(fset, []*ast.File{f}, "example.com/p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := New(context.Background(), fset, pkg, &Options{FoldValuesAfter: 2})
+	got := r.declHTML("", declForName(t, pkg, "A")).Decl.String()
+	want := `<pre>
+<span id="const-Code"></span><span class="keyword">const</span> (
+<span id="A" data-kind="constant"></span>	A <a href="#Code">Code</a> = <a href="/builtin#iota">iota</a>
+<span id="B" data-kind="constant"></span>	B
+</pre>
+<details class="Documentation-valueFold"><summary>2 more</summary><pre>
+	// C is the third code.
+<span id="C" data-kind="constant"></span>	C
+<span id="D" data-kind="constant"></span>	D
+)</pre>
+</details>
+`
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got)\n%s", diff)
+	}
+
+	// Groups with no more specs than the limit are not folded.
+	r = New(context.Background(), fset, pkg, &Options{FoldValuesAfter: 4})
+	if got := r.declHTML("", declForName(t, pkg, "A")).Decl.String(); strings.Contains(got, "<details") {
+		t.Errorf("group of 4 folded after 4:\n%s", got)
+	}
+}
+
 func declForName(t *testing.T, pkg *doc.Package, symbol string) ast.Decl {
 
 	inVals := func(vals []*doc.Value) ast.Decl {
@@ -280,7 +412,7 @@ b := 1
 `,
 		},
 	} {
-		out := codeHTML(test.in)
+		out := codeHTML(test.in, false)
 		got := strings.TrimSpace(string(out.String()))
 		want := strings.TrimSpace(test.want)
 		if got != want {
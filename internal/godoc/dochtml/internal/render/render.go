@@ -32,10 +32,17 @@ var (
 type Renderer struct {
 	fset              *token.FileSet
 	pids              *packageIDs
+	docLinks          *docLinks
 	packageURL        func(string) string
 	disableHotlinking bool
 	disablePermalinks bool
+	disableHighlight  bool
+	foldValuesAfter   int
 	ctx               context.Context
+
+	// groupIDs holds the IDs of the const and var groups rendered so far,
+	// so that they are unique.
+	groupIDs map[string]bool
 }
 
 type Options struct {
@@ -52,6 +59,13 @@ type Options struct {
 	// Only relevant for HTML formatting.
 	PackageURL func(pkgPath string) (url string)
 
+	// ModulePackages is a list of the paths of the packages in the same
+	// module as the given package. Doc links like "[pkg.Name]" may refer to
+	// them by name.
+	//
+	// Only relevant for HTML formatting.
+	ModulePackages []string
+
 	// DisableHotlinking turns off hotlinking behavior.
 	//
 	// Only relevant for HTML formatting.
@@ -61,13 +75,30 @@ type Options struct {
 	//
 	// Only relevant for HTML formatting.
 	DisablePermalinks bool
+
+	// DisableHighlighting turns off the syntax highlighting of declarations,
+	// example code and preformatted Go code in doc comments.
+	//
+	// Only relevant for HTML formatting.
+	DisableHighlighting bool
+
+	// FoldValuesAfter, if positive, is the number of specs of a constant or
+	// variable group after which the rest of the group is folded into an
+	// expandable element. The folded specs are rendered without links or
+	// highlighting, to keep large generated groups small.
+	//
+	// Only relevant for HTML formatting.
+	FoldValuesAfter int
 }
 
 func New(ctx context.Context, fset *token.FileSet, pkg *doc.Package, opts *Options) *Renderer {
 	var others []*doc.Package
 	var packageURL func(string) string
+	var modulePackages []string
 	var disableHotlinking bool
 	var disablePermalinks bool
+	var disableHighlight bool
+	var foldValuesAfter int
 	if opts != nil {
 		if len(opts.RelatedPackages) > 0 {
 			others = opts.RelatedPackages
@@ -75,16 +106,22 @@ func New(ctx context.Context, fset *token.FileSet, pkg *doc.Package, opts *Optio
 		if opts.PackageURL != nil {
 			packageURL = opts.PackageURL
 		}
+		modulePackages = opts.ModulePackages
 		disableHotlinking = opts.DisableHotlinking
 		disablePermalinks = opts.DisablePermalinks
+		disableHighlight = opts.DisableHighlighting
+		foldValuesAfter = opts.FoldValuesAfter
 	}
 	pids := newPackageIDs(pkg, others...)
 	return &Renderer{
 		fset:              fset,
 		pids:              pids,
+		docLinks:          newDocLinks(pkg, pids, modulePackages),
 		packageURL:        packageURL,
 		disableHotlinking: disableHotlinking,
 		disablePermalinks: disablePermalinks,
+		disableHighlight:  disableHighlight,
+		foldValuesAfter:   foldValuesAfter,
 	}
 }
 
@@ -118,7 +155,10 @@ func (r *Renderer) Synopsis(n ast.Node) string {
 //
 // This returns formatted HTML with:
 //	<p>                elements for plain documentation text
-//	<pre>              elements for preformatted text
+//	<p class="Documentation-deprecated">
+//	                   elements for paragraphs that start with "Deprecated:"
+//	<pre>              elements for preformatted text; if the text is Go code,
+//	                   it is highlighted as described for CodeHTML
 //	<h3 id="hdr-XXX">  elements for headings with the "id" attribute
 //	<a href="XXX">     elements for URL hyperlinks
 //
@@ -135,9 +175,16 @@ func (r *Renderer) DocHTML(doc string) safehtml.HTML {
 // This formats declaration HTML with:
 //	<pre>                       element wrapping the entire declaration
 //	<span id="X" data-kind="K"> elements for many top-level declarations
+//	<span id="const-X">         elements for groups of constants or variables
 //	<span class="comment">      elements for every Go comment
+//	<span class="keyword">      elements for keywords, unless highlighting is disabled
+//	<span class="string">       elements for string and rune literals, likewise
+//	<span class="number">       elements for numeric literals, likewise
 //	<a href="XXX">              elements for URL hyperlinks
 //
+// Comments on struct fields and interface methods that are part of a
+// "Deprecated:" paragraph also have the class Documentation-deprecatedComment.
+//
 // DeclHTML is intended for top-level package declarations.
 func (r *Renderer) DeclHTML(doc string, decl ast.Decl) (out struct{ Doc, Decl safehtml.HTML }) {
 	// This returns an anonymous struct instead of multiple return values since
@@ -155,12 +202,31 @@ func (r *Renderer) DeclHTML(doc string, decl ast.Decl) (out struct{ Doc, Decl sa
 // This returns formatted HTML with:
 //	<pre>                   element wrapping entire block
 //	<span class="comment">  elements for every Go comment
+//	<span class="keyword">  elements for keywords, unless highlighting is disabled
+//	<span class="string">   elements for string and rune literals, likewise
+//	<span class="number">   elements for numeric literals, likewise
 //
 // CodeHTML is intended for use with example code snippets.
 func (r *Renderer) CodeHTML(ex *doc.Example) safehtml.HTML {
 	return r.codeHTML(ex)
 }
 
+// deprecatedPrefix starts a paragraph of documentation that says that the
+// identifier is deprecated, and what to use instead.
+const deprecatedPrefix = "Deprecated:"
+
+// IsDeprecated reports whether doc has a paragraph that starts with
+// "Deprecated:", which by convention marks the documented identifier as
+// deprecated.
+func IsDeprecated(doc string) bool {
+	for _, blk := range docToBlocks(doc) {
+		if p, ok := blk.(*paragraph); ok && p.isDeprecated() {
+			return true
+		}
+	}
+	return false
+}
+
 // block is (*heading | *paragraph | *preformat).
 type block interface{}
 
@@ -177,6 +243,11 @@ type (
 	}
 )
 
+// isDeprecated reports whether p is a "Deprecated:" paragraph.
+func (p *paragraph) isDeprecated() bool {
+	return len(p.lines) > 0 && strings.HasPrefix(p.lines[0], deprecatedPrefix)
+}
+
 // docToBlocks converts doc string into list of blocks.
 //
 // Heading block is a non-blank line, surrounded by blank lines
@@ -214,3 +214,20 @@ func TestDocToBlocks(t *testing.T) {
 		}
 	}
 }
+
+func TestIsDeprecated(t *testing.T) {
+	for _, test := range []struct {
+		doc  string
+		want bool
+	}{
+		{"F does things.", false},
+		{"F does things.\n\nDeprecated: use G.", true},
+		{"Deprecated: use G.\n", true},
+		{"F does things. Deprecated: use G.", false},
+		{"F does things.\n\n\tDeprecated: in code.\n", false},
+	} {
+		if got := IsDeprecated(test.doc); got != test.want {
+			t.Errorf("IsDeprecated(%q) = %t, want %t", test.doc, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dochtml
+
+import (
+	"go/ast"
+	"sort"
+
+	"github.com/google/safehtml"
+	"golang.org/x/pkgsite/internal/godoc/dochtml/internal/render"
+	"golang.org/x/pkgsite/internal/godoc/internal/doc"
+)
+
+// An Outline describes the structure of the documentation produced by
+// Render: its sections and the declarations in them, along with the IDs of
+// the elements they correspond to. It has the same contents as the sidebar
+// outline of the unit page, which the frontend renders from it, and can be
+// encoded as JSON.
+type Outline struct {
+	Sections []*OutlineSection `json:"sections"`
+	Funcs    []*OutlineFunc    `json:"funcs,omitempty"`
+	Types    []*OutlineType    `json:"types,omitempty"`
+	// Notes are the parts of the Notes section, one for each marker, like
+	// "BUGs".
+	Notes []*OutlineSection `json:"notes,omitempty"`
+}
+
+// Section returns the section of o whose heading has the given ID, or nil if
+// there is none.
+func (o *Outline) Section(anchor string) *OutlineSection {
+	for _, s := range o.Sections {
+		if s.Anchor == anchor {
+			return s
+		}
+	}
+	return nil
+}
+
+// An OutlineSection is a top-level section of the documentation, like
+// "Overview" or "Functions".
+type OutlineSection struct {
+	Title  string `json:"title"`
+	Anchor string `json:"anchor"` // ID of the section's heading
+}
+
+// An OutlineFunc is a function or method.
+type OutlineFunc struct {
+	Name     string `json:"name"`
+	Anchor   string `json:"anchor"`
+	Synopsis string `json:"synopsis"` // as in the sidebar, e.g. "NewBuffer(buf)"
+}
+
+// An OutlineType is a type, along with the functions that return it and its
+// methods.
+type OutlineType struct {
+	Name    string         `json:"name"`
+	Anchor  string         `json:"anchor"`
+	Funcs   []*OutlineFunc `json:"funcs,omitempty"`
+	Methods []*OutlineFunc `json:"methods,omitempty"`
+}
+
+// GroupID returns the ID of the element of the sidebar that holds the
+// functions and methods of t.
+func (t *OutlineType) GroupID() safehtml.Identifier {
+	return render.SafeGoID("nav.group." + t.Name)
+}
+
+// buildOutline returns the outline of the documentation for p, which must
+// already have been prepared for rendering. The sections are the ones the
+// templates emit, in the same order.
+func buildOutline(p *doc.Package, exs *examples, synopsis func(ast.Node) (string, error)) (*Outline, error) {
+	o := &Outline{}
+	addSection := func(title, anchor string) {
+		o.Sections = append(o.Sections, &OutlineSection{Title: title, Anchor: anchor})
+	}
+	outlineFunc := func(name, anchor string, decl ast.Node) (*OutlineFunc, error) {
+		s, err := synopsis(decl)
+		if err != nil {
+			return nil, err
+		}
+		return &OutlineFunc{Name: name, Anchor: anchor, Synopsis: s}, nil
+	}
+
+	if p.Doc != "" || len(exs.Map[""]) > 0 {
+		addSection("Overview", "pkg-overview")
+	}
+	if len(p.Consts) > 0 || len(p.Vars) > 0 || len(p.Funcs) > 0 || len(p.Types) > 0 {
+		addSection("Index", "pkg-index")
+		if len(exs.List) > 0 {
+			addSection("Examples", "pkg-examples")
+		}
+		addSection("Constants", "pkg-constants")
+		addSection("Variables", "pkg-variables")
+		addSection("Functions", "pkg-functions")
+		addSection("Types", "pkg-types")
+	}
+	if len(p.Notes) > 0 {
+		addSection("Notes", "pkg-notes")
+		var markers []string
+		for m := range p.Notes {
+			markers = append(markers, m)
+		}
+		sort.Strings(markers)
+		for _, m := range markers {
+			o.Notes = append(o.Notes, &OutlineSection{Title: m + "s", Anchor: "pkg-note-" + m})
+		}
+	}
+
+	for _, f := range p.Funcs {
+		of, err := outlineFunc(f.Name, f.Name, f.Decl)
+		if err != nil {
+			return nil, err
+		}
+		o.Funcs = append(o.Funcs, of)
+	}
+	for _, t := range p.Types {
+		ot := &OutlineType{Name: t.Name, Anchor: t.Name}
+		for _, f := range t.Funcs {
+			of, err := outlineFunc(f.Name, f.Name, f.Decl)
+			if err != nil {
+				return nil, err
+			}
+			ot.Funcs = append(ot.Funcs, of)
+		}
+		for _, m := range t.Methods {
+			of, err := outlineFunc(m.Name, t.Name+"."+m.Name, m.Decl)
+			if err != nil {
+				return nil, err
+			}
+			ot.Methods = append(ot.Methods, of)
+		}
+		o.Types = append(o.Types, ot)
+	}
+	return o, nil
+}
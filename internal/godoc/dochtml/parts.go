@@ -0,0 +1,120 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dochtml
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go/token"
+
+	"github.com/google/safehtml"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/godoc/internal/doc"
+)
+
+// MaxParts is the largest number of parts that RenderParts splits
+// documentation into.
+const MaxParts = 50
+
+// A Part is one page of documentation that was split because it was too
+// large to be rendered as a whole.
+type Part struct {
+	// Title describes the contents of the part: "Overview" for the first
+	// one, and the names of the first and last top-level functions or types
+	// for the others.
+	Title string
+	HTML  safehtml.HTML
+}
+
+// RenderParts renders package documentation HTML like Render, but splits it
+// into parts that each fit in opt.Limit. The first part holds the package
+// comment, constants, variables, bugs and package examples; the others hold
+// runs of consecutive top-level functions and types, each with its
+// associated declarations, methods and examples.
+//
+// If the first part, or a single function or type, does not fit in the limit,
+// or more than MaxParts parts would be needed, an error with ErrTooLarge in
+// its chain is returned. opt.Outline is ignored.
+func RenderParts(ctx context.Context, fset *token.FileSet, p *doc.Package, opt RenderOptions) (_ []*Part, err error) {
+	defer derrors.Wrap(&err, "dochtml.RenderParts")
+	opt.Outline = nil
+
+	overview := *p
+	overview.Funcs = nil
+	overview.Types = nil
+	h, err := Render(ctx, fset, &overview, opt)
+	if err != nil {
+		return nil, err
+	}
+	parts := []*Part{{Title: "Overview", HTML: h}}
+	if p.Name == "main" {
+		// Only the package comment is shown for commands.
+		return parts, nil
+	}
+
+	var items []partItem
+	for _, f := range p.Funcs {
+		items = append(items, partItem{name: f.Name, fun: f})
+	}
+	for _, t := range p.Types {
+		items = append(items, partItem{name: t.Name, typ: t})
+	}
+
+	// Render all items as one part, and split the ones that are too large
+	// in halves until they fit.
+	var renderItems func([]partItem) error
+	renderItems = func(items []partItem) error {
+		if len(items) == 0 {
+			return nil
+		}
+		q := *p
+		q.Doc = ""
+		q.Consts = nil
+		q.Vars = nil
+		q.Notes = nil
+		q.Examples = nil
+		q.Funcs = nil
+		q.Types = nil
+		for _, it := range items {
+			if it.fun != nil {
+				q.Funcs = append(q.Funcs, it.fun)
+			} else {
+				q.Types = append(q.Types, it.typ)
+			}
+		}
+		h, err := Render(ctx, fset, &q, opt)
+		if errors.Is(err, ErrTooLarge) && len(items) > 1 {
+			mid := len(items) / 2
+			if err := renderItems(items[:mid]); err != nil {
+				return err
+			}
+			return renderItems(items[mid:])
+		}
+		if err != nil {
+			return err
+		}
+		if len(parts) == MaxParts {
+			return fmt.Errorf("more than %d parts: %w", MaxParts, ErrTooLarge)
+		}
+		title := items[0].name
+		if len(items) > 1 {
+			title += " – " + items[len(items)-1].name
+		}
+		parts = append(parts, &Part{Title: title, HTML: h})
+		return nil
+	}
+	if err := renderItems(items); err != nil {
+		return nil, err
+	}
+	return parts, nil
+}
+
+// A partItem is a top-level function or type.
+type partItem struct {
+	name string
+	fun  *doc.Func
+	typ  *doc.Type
+}
@@ -0,0 +1,80 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dochtml
+
+import (
+	"context"
+	"errors"
+	"go/ast"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/net/html"
+)
+
+func TestRenderParts(t *testing.T) {
+	ctx := context.Background()
+	opt := RenderOptions{
+		FileLinkFunc:   func(string) string { return "file" },
+		SourceLinkFunc: func(ast.Node) string { return "src" },
+	}
+	kinds := func(h string) map[string]string {
+		t.Helper()
+		n, err := html.Parse(strings.NewReader(h))
+		if err != nil {
+			t.Fatal(err)
+		}
+		m := map[string]string{}
+		walk(n, func(n *html.Node) {
+			if kind := attr(n, "data-kind"); kind != "" {
+				m[attr(n, "id")] = kind
+			}
+		})
+		return m
+	}
+
+	fset, d := mustLoadPackage("everydecl")
+	whole, err := Render(ctx, fset, d, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := kinds(whole.String())
+
+	// Rendering the whole documentation first, as when it turns out to be
+	// too large, doesn't affect the parts.
+	opt.Limit = int64(len(whole.String()) / 2)
+	parts, err := RenderParts(ctx, fset, d, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parts) < 3 {
+		t.Fatalf("got %d parts, want at least 3", len(parts))
+	}
+	if parts[0].Title != "Overview" {
+		t.Errorf("first part title: got %q, want Overview", parts[0].Title)
+	}
+	got := map[string]string{}
+	for _, p := range parts {
+		if n := int64(len(p.HTML.String())); n > opt.Limit {
+			t.Errorf("part %q: size %d exceeds limit %d", p.Title, n, opt.Limit)
+		}
+		for id, kind := range kinds(p.HTML.String()) {
+			if _, ok := got[id]; ok {
+				t.Errorf("%s appears in more than one part", id)
+			}
+			got[id] = kind
+		}
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("declarations mismatch (-whole +parts):\n%s", diff)
+	}
+
+	fset, d = mustLoadPackage("everydecl")
+	opt.Limit = 100
+	if _, err := RenderParts(ctx, fset, d, opt); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("tiny limit: got error %v, want ErrTooLarge", err)
+	}
+}
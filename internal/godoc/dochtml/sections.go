@@ -0,0 +1,158 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dochtml
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/token"
+
+	"github.com/google/safehtml"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/godoc/internal/doc"
+)
+
+// A Section is one of the sections of the documentation that can be
+// truncated with RenderOptions.SectionLimit.
+type Section string
+
+const (
+	SectionConstants Section = "constants"
+	SectionVariables Section = "variables"
+	SectionFunctions Section = "functions"
+	SectionTypes     Section = "types"
+)
+
+// Sections lists the sections that can be truncated, in the order in which
+// they appear in the documentation.
+var Sections = []Section{SectionConstants, SectionVariables, SectionFunctions, SectionTypes}
+
+// truncatedSection describes a section from which declarations were left out.
+type truncatedSection struct {
+	Name  Section
+	Total int    // number of declarations in the whole section
+	URL   string // where the whole section can be loaded
+}
+
+// truncateSections returns a copy of p in which each of the constants,
+// variables, functions and types sections holds at most limit declarations,
+// along with a description of the sections that were truncated, keyed by
+// section. In the constants and variables sections, each name counts as one
+// declaration, and a group of them may be cut; in the others, a function or
+// a type, along with its methods and associated declarations, counts as one.
+func truncateSections(p *doc.Package, limit int, urlFunc func(Section) string) (*doc.Package, map[string]*truncatedSection) {
+	truncated := map[string]*truncatedSection{}
+	add := func(s Section, total int) {
+		var u string
+		if urlFunc != nil {
+			u = urlFunc(s)
+		}
+		truncated[string(s)] = &truncatedSection{Name: s, Total: total, URL: u}
+	}
+
+	shown := *p
+	var total int
+	if shown.Consts, total = truncateValues(p.Consts, limit); total > limit {
+		add(SectionConstants, total)
+	}
+	if shown.Vars, total = truncateValues(p.Vars, limit); total > limit {
+		add(SectionVariables, total)
+	}
+	if len(p.Funcs) > limit {
+		shown.Funcs = p.Funcs[:limit]
+		add(SectionFunctions, len(p.Funcs))
+	}
+	if len(p.Types) > limit {
+		shown.Types = p.Types[:limit]
+		add(SectionTypes, len(p.Types))
+	}
+	return &shown, truncated
+}
+
+// truncateValues returns the leading values of vals that declare at most
+// limit names in all, cutting the group that crosses the limit, along with
+// the number of names that vals declare.
+func truncateValues(vals []*doc.Value, limit int) ([]*doc.Value, int) {
+	var total int
+	for _, v := range vals {
+		total += len(v.Names)
+	}
+	if total <= limit {
+		return vals, total
+	}
+	var (
+		shown []*doc.Value
+		n     int
+	)
+	for _, v := range vals {
+		if n+len(v.Names) <= limit {
+			shown = append(shown, v)
+			n += len(v.Names)
+			continue
+		}
+		if v2 := truncateValue(v, limit-n); v2 != nil {
+			shown = append(shown, v2)
+		}
+		break
+	}
+	return shown, total
+}
+
+// truncateValue returns a copy of v whose declaration keeps the leading
+// specs that declare at most limit names, or nil if there are none.
+func truncateValue(v *doc.Value, limit int) *doc.Value {
+	decl := *v.Decl
+	decl.Specs = nil
+	var names []string
+	for _, spec := range v.Decl.Specs {
+		vs := spec.(*ast.ValueSpec)
+		if len(names)+len(vs.Names) > limit {
+			break
+		}
+		decl.Specs = append(decl.Specs, spec)
+		for _, id := range vs.Names {
+			names = append(names, id.Name)
+		}
+	}
+	if len(decl.Specs) == 0 {
+		return nil
+	}
+	if decl.Rparen.IsValid() {
+		// Close the group right after the last spec that is kept.
+		decl.Rparen = decl.Specs[len(decl.Specs)-1].End()
+	}
+	v2 := *v
+	v2.Decl = &decl
+	v2.Names = names
+	return &v2
+}
+
+// RenderSection renders the HTML of the whole given section of the package
+// documentation: the contents of the section element that Render, with a
+// SectionLimit, truncates. Options are as for Render.
+func RenderSection(ctx context.Context, fset *token.FileSet, p *doc.Package, section Section, opt RenderOptions) (_ safehtml.HTML, err error) {
+	defer derrors.Wrap(&err, "dochtml.RenderSection(%q)", section)
+	if opt.Limit == 0 {
+		const megabyte = 1000 * 1000
+		opt.Limit = 10 * megabyte
+	}
+	var known bool
+	for _, s := range Sections {
+		known = known || s == section
+	}
+	if !known {
+		return safehtml.HTML{}, fmt.Errorf("unknown section %q", section)
+	}
+
+	p = preparePackage(p, opt.NoteMarkers)
+	tmpl, _ := newTemplate(ctx, fset, p, opt)
+	t := tmpl.Lookup("section-" + string(section))
+	if t == nil {
+		// The legacy template has no separate sections.
+		return safehtml.HTML{}, fmt.Errorf("no template for section %q", section)
+	}
+	return executeToHTMLWithLimit(t, newTemplateData(p, collectExamples(p), opt), opt.Limit, opt.Sizes)
+}
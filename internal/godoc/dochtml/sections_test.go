@@ -0,0 +1,111 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dochtml
+
+import (
+	"context"
+	"go/ast"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/net/html"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/experiment"
+)
+
+func TestRenderSectionLimit(t *testing.T) {
+	ctx := experiment.NewContext(context.Background(), internal.ExperimentUnitPage)
+	opt := RenderOptions{
+		FileLinkFunc:   func(string) string { return "file" },
+		SourceLinkFunc: func(ast.Node) string { return "src" },
+	}
+	ids := func(h string) map[string]bool {
+		t.Helper()
+		n, err := html.Parse(strings.NewReader(h))
+		if err != nil {
+			t.Fatal(err)
+		}
+		m := map[string]bool{}
+		walk(n, func(n *html.Node) {
+			if attr(n, "data-kind") != "" {
+				m[attr(n, "id")] = true
+			}
+		})
+		return m
+	}
+	moreLinks := func(h string) map[string]string {
+		t.Helper()
+		n, err := html.Parse(strings.NewReader(h))
+		if err != nil {
+			t.Fatal(err)
+		}
+		m := map[string]string{}
+		walk(n, func(n *html.Node) {
+			if attr(n, "class") == "js-docSectionMore" {
+				m[attr(n, "data-section")] = attr(n, "href")
+			}
+		})
+		return m
+	}
+
+	fset, d := mustLoadPackage("everydecl")
+	whole, err := Render(ctx, fset, d, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ids(whole.String())
+
+	opt.SectionLimit = 1
+	opt.SectionURLFunc = func(s Section) string { return "/section/" + string(s) }
+	fset, d = mustLoadPackage("everydecl")
+	truncated, err := Render(ctx, fset, d, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The constant group is cut after its first constant.
+	got := ids(truncated.String())
+	for _, id := range []string{"CG3", "VG1", "F", "I1"} {
+		if !got[id] {
+			t.Errorf("%s is not displayed", id)
+		}
+	}
+	for _, id := range []string{"CG4", "C", "VG2", "V", "I2", "S1", "S2", "T"} {
+		if got[id] {
+			t.Errorf("%s is displayed, want it left out", id)
+		}
+	}
+	wantLinks := map[string]string{
+		"constants": "/section/constants",
+		"variables": "/section/variables",
+		"types":     "/section/types",
+	}
+	if diff := cmp.Diff(wantLinks, moreLinks(truncated.String())); diff != "" {
+		t.Errorf("links to whole sections mismatch (-want +got):\n%s", diff)
+	}
+
+	// Together, the truncated documentation and the whole sections display
+	// every declaration.
+	for _, s := range Sections {
+		fset, d = mustLoadPackage("everydecl")
+		h, err := RenderSection(ctx, fset, d, s, opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if links := moreLinks(h.String()); len(links) > 0 {
+			t.Errorf("section %s: got links %v, want none", s, links)
+		}
+		for id := range ids(h.String()) {
+			got[id] = true
+		}
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("declarations mismatch (-whole +sections):\n%s", diff)
+	}
+
+	if _, err := RenderSection(ctx, fset, d, "bogus", opt); err == nil {
+		t.Error("unknown section: got nil error")
+	}
+}
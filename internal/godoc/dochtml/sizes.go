@@ -0,0 +1,100 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dochtml
+
+import (
+	"go/ast"
+	"strings"
+
+	"github.com/google/safehtml"
+	"golang.org/x/pkgsite/internal/godoc/dochtml/internal/render"
+	"golang.org/x/pkgsite/internal/godoc/internal/doc"
+)
+
+// A SizeBreakdown accounts for the bytes of rendered documentation HTML by
+// what they display, to inform limits on its size like
+// RenderOptions.Limit. All sizes are in bytes.
+type SizeBreakdown struct {
+	Total int64
+	// Index is the size of the index of the documentation, including the
+	// list of examples, and of the outlines in the side navigation.
+	Index int64
+	// Declarations is the size of the source of declarations.
+	Declarations int64
+	// Comments is the size of doc comments: those of the package, of
+	// declarations, of examples and of notes.
+	Comments int64
+	// Examples is the size of the code of examples.
+	Examples int64
+}
+
+// Other returns the size of what the other fields don't account for, like
+// headings, links, example output and the markup around sections.
+func (s *SizeBreakdown) Other() int64 {
+	return s.Total - s.Index - s.Declarations - s.Comments - s.Examples
+}
+
+// Add adds the sizes of t to those of s.
+func (s *SizeBreakdown) Add(t *SizeBreakdown) {
+	s.Total += t.Total
+	s.Index += t.Index
+	s.Declarations += t.Declarations
+	s.Comments += t.Comments
+	s.Examples += t.Examples
+}
+
+// instrument replaces the template functions in funcs that render
+// declarations, comments and example code with ones that also add the size
+// of their output to s.
+func (s *SizeBreakdown) instrument(funcs map[string]interface{}, r *render.Renderer) {
+	funcs["render_doc"] = func(text string) safehtml.HTML {
+		h := r.DocHTML(text)
+		s.Comments += int64(len(h.String()))
+		return h
+	}
+	funcs["render_decl"] = func(text string, decl ast.Decl) (out struct{ Doc, Decl safehtml.HTML }) {
+		out = r.DeclHTML(text, decl)
+		s.Comments += int64(len(out.Doc.String()))
+		s.Declarations += int64(len(out.Decl.String()))
+		return out
+	}
+	funcs["render_code"] = func(ex *doc.Example) safehtml.HTML {
+		h := r.CodeHTML(ex)
+		s.Examples += int64(len(h.String()))
+		return h
+	}
+}
+
+// indexStarts maps the start of each element of the documentation HTML that
+// holds an index or an outline to its end.
+var indexStarts = map[string]string{
+	IdentifierSidenavStart:                     IdentifierSidenavEnd,
+	IdentifierSidenavMobileStart:               IdentifierSidenavEnd,
+	`<section class="Documentation-index">`:    `</section>`,
+	`<section class="Documentation-examples">`: `</section>`,
+}
+
+// indexSize returns the size of the elements of html that hold an index or
+// an outline. None of them is nested in another.
+func indexSize(html string) int64 {
+	var n int64
+	for start, end := range indexStarts {
+		for s := html; ; {
+			i := strings.Index(s, start)
+			if i < 0 {
+				break
+			}
+			s = s[i:]
+			j := strings.Index(s, end)
+			if j < 0 {
+				n += int64(len(s))
+				break
+			}
+			n += int64(j + len(end))
+			s = s[j+len(end):]
+		}
+	}
+	return n
+}
@@ -0,0 +1,105 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dochtml
+
+import (
+	"context"
+	"errors"
+	"go/ast"
+	"testing"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/experiment"
+)
+
+func TestSizeBreakdown(t *testing.T) {
+	ctx := experiment.NewContext(context.Background(), internal.ExperimentUnitPage)
+	fset, d := mustLoadPackage("everydecl")
+	var sizes SizeBreakdown
+	html, err := Render(ctx, fset, d, RenderOptions{
+		FileLinkFunc:   func(string) string { return "file" },
+		SourceLinkFunc: func(ast.Node) string { return "src" },
+		Sizes:          &sizes,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := sizes.Total, int64(len(html.String())); got != want {
+		t.Errorf("Total = %d, want %d", got, want)
+	}
+	for name, n := range map[string]int64{
+		"Index":        sizes.Index,
+		"Declarations": sizes.Declarations,
+		"Comments":     sizes.Comments,
+		"Other":        sizes.Other(),
+	} {
+		if n <= 0 {
+			t.Errorf("%s = %d, want a positive size", name, n)
+		}
+	}
+
+	// Rendering without sizes gives the same HTML.
+	html2, err := Render(ctx, fset, d, RenderOptions{
+		FileLinkFunc:   func(string) string { return "file" },
+		SourceLinkFunc: func(ast.Node) string { return "src" },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if html2.String() != html.String() {
+		t.Error("HTML differs when sizes are recorded")
+	}
+
+	// When the documentation is too large, the sizes account for what was
+	// rendered.
+	limit := sizes.Total / 2
+	_, err = Render(ctx, fset, d, RenderOptions{
+		FileLinkFunc:   func(string) string { return "file" },
+		SourceLinkFunc: func(ast.Node) string { return "src" },
+		Limit:          limit,
+		Sizes:          &sizes,
+	})
+	if !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("got %v, want ErrTooLarge", err)
+	}
+	if sizes.Total <= 0 || sizes.Total > limit {
+		t.Errorf("too large: Total = %d, want between 0 and %d", sizes.Total, limit)
+	}
+}
+
+// BenchmarkRender renders the documentation of a package with every kind of
+// declaration, and reports how its size breaks down.
+func BenchmarkRender(b *testing.B) {
+	ctx := experiment.NewContext(context.Background(), internal.ExperimentUnitPage)
+	fset, d := mustLoadPackage("everydecl")
+	for _, withSizes := range []bool{false, true} {
+		name := "plain"
+		if withSizes {
+			name = "sizes"
+		}
+		b.Run(name, func(b *testing.B) {
+			var sizes SizeBreakdown
+			opt := RenderOptions{
+				FileLinkFunc:   func(string) string { return "file" },
+				SourceLinkFunc: func(ast.Node) string { return "src" },
+			}
+			if withSizes {
+				opt.Sizes = &sizes
+			}
+			for i := 0; i < b.N; i++ {
+				if _, err := Render(ctx, fset, d, opt); err != nil {
+					b.Fatal(err)
+				}
+			}
+			if withSizes {
+				b.ReportMetric(float64(sizes.Total), "B/doc")
+				b.ReportMetric(float64(sizes.Index), "index-B/doc")
+				b.ReportMetric(float64(sizes.Declarations), "decl-B/doc")
+				b.ReportMetric(float64(sizes.Comments), "comment-B/doc")
+				b.ReportMetric(float64(sizes.Examples), "example-B/doc")
+			}
+		})
+	}
+}
@@ -27,7 +27,7 @@ func htmlPackage(ctx context.Context) *template.Template {
 }
 
 const (
-	tmplHTML = `{{- "" -}}` + tmplSidenav + tmplBody + tmplExample
+	tmplHTML = `{{- "" -}}` + tmplSidenav + tmplBody + tmplSections + tmplExample
 
 	// legacyTmplHTML should not be edited.
 	legacyTmplHTML = `{{- "" -}}` + legacyTmplSidenav + legacyTmplBody + tmplExample
@@ -49,6 +49,11 @@ var tmpl = map[string]interface{}{
 	"render_code":           (*render.Renderer)(nil).CodeHTML,
 	"file_link":             func() string { return "" },
 	"source_link":           func() string { return "" },
+	"uses_link":             func() string { return "" },
+	"editor_link":           func() string { return "" },
+	"since_version":         func() string { return "" },
+	"note_source_link":      func(*doc.Note) string { return "" },
+	"embeds":                func([]string) string { return "" },
 	"play_url":              func(*doc.Example) string { return "" },
 	"safe_id":               render.SafeGoID,
 }
@@ -20,6 +20,20 @@ const tmplBody = `
 	</section>
 {{- end -}}
 
+{{- with .ExcludedFiles -}}
+	<details class="Documentation-buildConstraints">
+		<summary>Build constraints</summary>{{"\n" -}}
+		<p>This documentation is for {{$.BuildContext}}. Build constraints leave out these files:</p>{{"\n" -}}
+		<ul>{{"\n" -}}
+		{{- range . -}}
+			<li>{{file_link .Name}}:
+			{{- range .Constraints}} <code>{{.}}</code>{{else}} excluded by its name{{end -}}
+			</li>{{"\n" -}}
+		{{- end -}}
+		</ul>
+	</details>
+{{- end -}}
+
 
 {{- if or .Consts .Vars .Funcs .Types -}}
 	<section class="Documentation-index">
@@ -29,29 +43,44 @@ const tmplBody = `
 			{{- if .Vars -}}<li class="Documentation-indexVariables"><a href="#pkg-variables">Variables</a></li>{{"\n"}}{{- end -}}
 
 			{{- range .Funcs -}}
+			{{- if not (index $.Collapsed .Name) -}}
 			<li class="Documentation-indexFunction">
 				<a href="#{{.Name}}">{{render_synopsis .Decl}}</a>
 			</li>{{"\n"}}
 			{{- end -}}
+			{{- end -}}
 
 			{{- range .Types -}}
 				{{- $tname := .Name -}}
+				{{- if not (index $.Collapsed $tname) -}}
 				<li class="Documentation-indexType"><a href="#{{$tname}}">type {{$tname}}</a></li>{{"\n"}}
 				{{- with .Funcs -}}
 					<li><ul class="Documentation-indexTypeFunctions">{{"\n" -}}
-					{{range .}}<li><a href="#{{.Name}}">{{render_synopsis .Decl}}</a></li>{{"\n"}}{{end}}
+					{{range .}}{{if not (index $.Collapsed .Name)}}<li><a href="#{{.Name}}">{{render_synopsis .Decl}}</a></li>{{"\n"}}{{end}}{{end}}
 					</ul></li>{{"\n" -}}
 				{{- end -}}
 				{{- with .Methods -}}
 					<li><ul class="Documentation-indexTypeMethods">{{"\n" -}}
-					{{range .}}<li><a href="#{{$tname}}.{{.Name}}">{{render_synopsis .Decl}}</a></li>{{"\n"}}{{end}}
+					{{range .}}{{if not (index $.Collapsed (printf "%s.%s" $tname .Name))}}<li><a href="#{{$tname}}.{{.Name}}">{{render_synopsis .Decl}}</a></li>{{"\n"}}{{end}}{{end}}
 					</ul></li>{{"\n" -}}
 				{{- end -}}
+				{{- end -}}
 			{{- end -}}
 
 			{{- range $marker, $item := .Notes -}}
 			<li class="Documentation-indexNote"><a href="#pkg-note-{{$marker}}">{{$marker}}s</a></li>
 			{{- end -}}
+
+			{{- with .Deprecated -}}
+			<li class="Documentation-indexDeprecated">
+				<details>
+					<summary>Deprecated</summary>
+					<ul>{{"\n" -}}
+					{{range .}}<li><a href="#{{.ID}}">{{if .IsType}}type {{.Name}}{{else}}{{render_synopsis .Decl}}{{end}}</a></li>{{"\n"}}{{end}}
+					</ul>
+				</details>
+			</li>{{"\n"}}
+			{{- end -}}
 		</ul>{{"\n" -}}
 	</section>
 
@@ -68,39 +97,89 @@ const tmplBody = `
 
 	<h3 tabindex="-1" id="pkg-constants" class="Documentation-constantsHeader">Constants <a href="#pkg-constants">¶</a></h3>{{"\n"}}
 	<section class="Documentation-constants">
+	{{- template "section-constants" . -}}
+	</section>
+
+	<h3 tabindex="-1" id="pkg-variables" class="Documentation-variablesHeader">Variables <a href="#pkg-variables">¶</a></h3>{{"\n"}}
+	<section class="Documentation-variables">
+	{{- template "section-variables" . -}}
+	</section>
+
+	<h3 tabindex="-1" id="pkg-functions" class="Documentation-functionsHeader">Functions <a href="#pkg-functions">¶</a></h3>{{"\n"}}
+	<section class="Documentation-functions">
+	{{- template "section-functions" . -}}
+	</section>
+
+	<h3 tabindex="-1" id="pkg-types" class="Documentation-typesHeader">Types <a href="#pkg-types">¶</a></h3>{{"\n"}}
+	<section class="Documentation-types">
+	{{- template "section-types" . -}}
+	</section>
+{{- end -}}
+
+{{- if .Notes -}}
+	<h3 tabindex="-1" id="pkg-notes" class="Documentation-notesHeader">Notes <a href="#pkg-notes">¶</a></h3>{{"\n"}}
+	<section class="Documentation-notes">
+		{{- range $marker, $content := .Notes -}}
+		<div class="Documentation-note">
+			<h3 tabindex="-1" id="{{index $.NoteIDs $marker}}" class="Documentation-noteHeader">{{$marker}}s <a href="#pkg-note-{{$marker}}">¶</a></h3>
+			<ul class="Documentation-noteList" style="padding-left: 20px; list-style: initial;">{{"\n" -}}
+			{{- range $v := $content -}}
+				<li style="margin: 6px 0 6px 0;">{{render_doc $v.Body}}{{note_source_link $v}}</li>
+			{{- end -}}
+			</ul>{{"\n" -}}
+		</div>
+		{{- end -}}
+	</section>
+{{- end -}}
+` + IdentifierBodyEnd + ` {{/* End documentation content container */}}
+`
+
+// tmplSections holds the contents of the sections of the documentation that
+// can be truncated, so that RenderSection can render them separately.
+const tmplSections = `
+{{- define "section-constants" -}}
 	{{- if .Consts -}}
-		{{- range .Consts -}}
+		{{- range .Shown.Consts -}}
 			{{- $out := render_decl .Doc .Decl -}}
 			{{- $out.Decl -}}
 			{{- $out.Doc -}}
 			{{"\n"}}
 		{{- end -}}
+		{{- with index .Truncated "constants" -}}
+		<div class="Documentation-more">
+			<a class="js-docSectionMore" href="{{.URL}}" data-section="{{.Name}}">Show all {{.Total}} {{.Name}}</a>
+		</div>
+		{{- end -}}
 	{{- else -}}
 	  	<div class="Documentation-empty">There are no constants in this package.</div>
 	{{- end -}}
-	</section>
+{{- end -}}
 
-	<h3 tabindex="-1" id="pkg-variables" class="Documentation-variablesHeader">Variables <a href="#pkg-variables">¶</a></h3>{{"\n"}}
-	<section class="Documentation-variables">
+{{- define "section-variables" -}}
 	{{- if .Vars -}}
-		{{- range .Vars -}}
+		{{- range .Shown.Vars -}}
 			{{- $out := render_decl .Doc .Decl -}}
 			{{- $out.Decl -}}
+			{{- embeds .Names -}}
 			{{- $out.Doc -}}
 			{{"\n"}}
 		{{- end -}}
+		{{- with index .Truncated "variables" -}}
+		<div class="Documentation-more">
+			<a class="js-docSectionMore" href="{{.URL}}" data-section="{{.Name}}">Show all {{.Total}} {{.Name}}</a>
+		</div>
+		{{- end -}}
 	{{- else -}}
 		<div class="Documentation-empty">There are no variables in this package.</div>
 	{{- end -}}
-	</section>
+{{- end -}}
 
-	<h3 tabindex="-1" id="pkg-functions" class="Documentation-functionsHeader">Functions <a href="#pkg-functions">¶</a></h3>{{"\n"}}
-	<section class="Documentation-functions">
+{{- define "section-functions" -}}
 	{{- if .Funcs -}}
-        {{- range .Funcs -}}
+        {{- range .Shown.Funcs -}}
         <div class="Documentation-function">
             {{- $id := safe_id .Name -}}
-            <h4 tabindex="-1" id="{{$id}}" data-kind="function" class="Documentation-functionHeader">func {{source_link .Name .Decl}} <a href="#{{$id}}">¶</a></h4>{{"\n"}}
+            <h4 tabindex="-1" id="{{$id}}" data-kind="function" class="Documentation-functionHeader">func {{source_link .Name .Decl}} <a href="#{{$id}}">¶</a>{{editor_link .Decl}}{{uses_link .Name}}{{since_version .Name}}</h4>{{"\n"}}
             {{- $out := render_decl .Doc .Decl -}}
             {{- $out.Decl -}}
             {{- $out.Doc -}}
@@ -108,19 +187,23 @@ const tmplBody = `
             {{- template "example" (index $.Examples.Map .Name) -}}
         </div>
         {{- end -}}
+		{{- with index .Truncated "functions" -}}
+		<div class="Documentation-more">
+			<a class="js-docSectionMore" href="{{.URL}}" data-section="{{.Name}}">Show all {{.Total}} {{.Name}}</a>
+		</div>
+		{{- end -}}
 	{{- else -}}
 		<div class="Documentation-empty">There are no functions in this package.</div>
 	{{- end -}}
-	</section>
+{{- end -}}
 
-	<h3 tabindex="-1" id="pkg-types" class="Documentation-typesHeader">Types <a href="#pkg-types">¶</a></h3>{{"\n"}}
-	<section class="Documentation-types">
+{{- define "section-types" -}}
 	{{- if .Types -}}
-		{{- range .Types -}}
+		{{- range .Shown.Types -}}
 		<div class="Documentation-type">
 			{{- $tname := .Name -}}
 			{{- $id := safe_id .Name -}}
-			<h4 tabindex="-1" id="{{$id}}" data-kind="type" class="Documentation-typeHeader">type {{source_link .Name .Decl}} <a href="#{{$id}}">¶</a></h4>{{"\n"}}
+			<h4 tabindex="-1" id="{{$id}}" data-kind="type" class="Documentation-typeHeader">type {{source_link .Name .Decl}} <a href="#{{$id}}">¶</a>{{editor_link .Decl}}{{uses_link .Name}}{{since_version .Name}}</h4>{{"\n"}}
 			{{- $out := render_decl .Doc .Decl -}}
 			{{- $out.Decl -}}
 			{{- $out.Doc -}}
@@ -140,6 +223,7 @@ const tmplBody = `
 			<div class="Documentation-typeVariable">
 				{{- $out := render_decl .Doc .Decl -}}
 				{{- $out.Decl -}}
+				{{- embeds .Names -}}
 				{{- $out.Doc -}}
 				{{"\n"}}
 			</div>
@@ -148,7 +232,7 @@ const tmplBody = `
 			{{- range .Funcs -}}
 			<div class="Documentation-typeFunc">
 				{{- $id := safe_id .Name -}}
-				<h4 tabindex="-1" id="{{$id}}" data-kind="function" class="Documentation-typeFuncHeader">func {{source_link .Name .Decl}} <a href="#{{$id}}">¶</a></h4>{{"\n"}}
+				<h4 tabindex="-1" id="{{$id}}" data-kind="function" class="Documentation-typeFuncHeader">func {{source_link .Name .Decl}} <a href="#{{$id}}">¶</a>{{editor_link .Decl}}{{uses_link .Name}}{{since_version .Name}}</h4>{{"\n"}}
 				{{- $out := render_decl .Doc .Decl -}}
 				{{- $out.Decl -}}
 				{{- $out.Doc -}}
@@ -161,7 +245,7 @@ const tmplBody = `
 			<div class="Documentation-typeMethod">
 				{{- $name := (printf "%s.%s" $tname .Name) -}}
 				{{- $id := (safe_id $name) -}}
-				<h4 tabindex="-1" id="{{$id}}" data-kind="method" class="Documentation-typeMethodHeader">func ({{.Recv}}) {{source_link .Name .Decl}} <a href="#{{$id}}">¶</a></h4>{{"\n"}}
+				<h4 tabindex="-1" id="{{$id}}" data-kind="method" class="Documentation-typeMethodHeader">func ({{.Recv}}) {{source_link .Name .Decl}} <a href="#{{$id}}">¶</a>{{editor_link .Decl}}{{uses_link $tname .Name}}{{since_version $tname .Name}}</h4>{{"\n"}}
 				{{- $out := render_decl .Doc .Decl -}}
 				{{- $out.Decl -}}
 				{{- $out.Doc -}}
@@ -171,26 +255,13 @@ const tmplBody = `
 			{{- end -}}
 		</div>
 		{{- end -}}
+		{{- with index .Truncated "types" -}}
+		<div class="Documentation-more">
+			<a class="js-docSectionMore" href="{{.URL}}" data-section="{{.Name}}">Show all {{.Total}} {{.Name}}</a>
+		</div>
+		{{- end -}}
 	{{- else -}}
 		<div class="Documentation-empty">There are no types in this package.</div>
 	{{- end -}}
-	</section>
 {{- end -}}
-
-{{- if .Notes -}}
-	<h3 tabindex="-1" id="pkg-notes" class="Documentation-notesHeader">Notes <a href="#pkg-notes">¶</a></h3>{{"\n"}}
-	<section class="Documentation-notes">
-		{{- range $marker, $content := .Notes -}}
-		<div class="Documentation-note">
-			<h3 tabindex="-1" id="{{index $.NoteIDs $marker}}" class="Documentation-noteHeader">{{$marker}}s <a href="#pkg-note-{{$marker}}">¶</a></h3>
-			<ul class="Documentation-noteList" style="padding-left: 20px; list-style: initial;">{{"\n" -}}
-			{{- range $v := $content -}}
-				<li style="margin: 6px 0 6px 0;">{{render_doc $v.Body}}</li>
-			{{- end -}}
-			</ul>{{"\n" -}}
-		</div>
-		{{- end -}}
-	</section>
-{{- end -}}
-` + IdentifierBodyEnd + ` {{/* End documentation content container */}}
 `
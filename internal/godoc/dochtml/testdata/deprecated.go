@@ -0,0 +1,46 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package deprecated has deprecated declarations.
+//
+// Deprecated: use another package.
+package deprecated
+
+// F is a function.
+func F() {}
+
+// Old is an old function.
+//
+// Deprecated: use F.
+func Old() {}
+
+// T is a type.
+type T struct {
+	// A is a field.
+	A int
+
+	// B is an old field.
+	//
+	// Deprecated: use A.
+	B int
+}
+
+// NewT returns a T.
+func NewT() T { return T{} }
+
+// M is a method.
+func (T) M() {}
+
+// OldM is an old method.
+//
+// Deprecated: use M.
+func (T) OldM() {}
+
+// OldT is an old type.
+//
+// Deprecated: use T.
+type OldT int
+
+// NewOldT returns an OldT.
+func NewOldT() OldT { return 0 }
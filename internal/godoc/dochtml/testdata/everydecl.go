@@ -25,7 +25,26 @@ const CT T = 3
 // typeVariable
 var VT T
 
+// typeConstantGroup
+const (
+	CG1 T = iota
+	CG2
+)
+
+// constantGroup
+const (
+	CG3 = 1
+	CG4 = 2
+)
+
+// variableGroup
+var (
+	VG1 = 1
+	VG2 = 2
+)
+
 // typeFunc
+// TODO(uid): this verifies that notes with other markers can be rendered
 func TF() T { return T(0) }
 
 // method
@@ -0,0 +1,121 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docmd
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type blockKind int
+
+const (
+	paragraphBlock blockKind = iota
+	headingBlock
+	preformatBlock
+)
+
+// A block is a paragraph, a heading or a preformatted block of a doc
+// comment.
+type block struct {
+	kind  blockKind
+	lines []string // without trailing newlines or, if preformatted, common indent
+}
+
+// docToBlocks splits the doc comment text into blocks, following the rules
+// of go/doc: paragraphs are separated by blank lines, indented lines are
+// preformatted, and a paragraph of a single line that looks like a title,
+// between two other paragraphs, is a heading.
+func docToBlocks(text string) []*block {
+	var blocks []*block
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	for i := 0; i < len(lines); {
+		switch {
+		case isBlank(lines[i]):
+			i++
+		case isIndented(lines[i]):
+			j := i
+			for j < len(lines) && (isIndented(lines[j]) || isBlank(lines[j])) {
+				j++
+			}
+			for isBlank(lines[j-1]) {
+				j--
+			}
+			blocks = append(blocks, &block{kind: preformatBlock, lines: unindent(lines[i:j])})
+			i = j
+		default:
+			j := i
+			for j < len(lines) && !isBlank(lines[j]) && !isIndented(lines[j]) {
+				j++
+			}
+			blocks = append(blocks, &block{kind: paragraphBlock, lines: lines[i:j]})
+			i = j
+		}
+	}
+
+	// A heading must be preceded and followed by a paragraph.
+	for i := 1; i+1 < len(blocks); i++ {
+		b := blocks[i]
+		if b.kind == paragraphBlock && len(b.lines) == 1 && isHeading(b.lines[0]) &&
+			blocks[i-1].kind == paragraphBlock && blocks[i+1].kind == paragraphBlock {
+			b.kind = headingBlock
+		}
+	}
+	return blocks
+}
+
+func isBlank(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
+
+func isIndented(line string) bool {
+	return len(line) > 0 && (line[0] == ' ' || line[0] == '\t')
+}
+
+// unindent removes the longest common indent of the non-blank lines.
+func unindent(lines []string) []string {
+	var prefix string
+	for i, l := range lines {
+		if isBlank(l) {
+			continue
+		}
+		indent := l[:len(l)-len(strings.TrimLeft(l, " \t"))]
+		if i == 0 || len(indent) < len(prefix) {
+			prefix = indent
+		}
+	}
+	out := make([]string, len(lines))
+	for i, l := range lines {
+		out[i] = strings.TrimPrefix(l, prefix)
+	}
+	return out
+}
+
+// isHeading reports whether line could be a heading: it starts with an
+// upper case letter, ends with a letter or digit and contains no other
+// punctuation than an apostrophe followed by an s.
+func isHeading(line string) bool {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return false
+	}
+	if r, _ := utf8.DecodeRuneInString(line); !unicode.IsLetter(r) || !unicode.IsUpper(r) {
+		return false
+	}
+	if r, _ := utf8.DecodeLastRuneInString(line); !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+		return false
+	}
+	if strings.ContainsAny(line, ",.;:!?+*/=()[]{}_^°&§~%#@<\">\\") {
+		return false
+	}
+	for i := strings.IndexByte(line, '\''); i >= 0; i = strings.IndexByte(line, '\'') {
+		if i+1 >= len(line) || line[i+1] != 's' || (i+2 < len(line) && line[i+2] != ' ') {
+			return false
+		}
+		line = line[i+2:]
+	}
+	return true
+}
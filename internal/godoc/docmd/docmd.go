@@ -0,0 +1,217 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package docmd renders Go package documentation into Markdown.
+package docmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/printer"
+	"go/token"
+	"regexp"
+	"strings"
+
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/godoc/internal/doc"
+)
+
+// ErrTooLarge is returned when the rendered documentation exceeds the limit
+// given in the RenderOptions.
+var ErrTooLarge = errors.New("rendered documentation Markdown size exceeded the specified limit")
+
+// RenderOptions are options for Render.
+type RenderOptions struct {
+	Limit int64 // If zero, a default limit of 10 megabytes is used.
+}
+
+// Render renders the documentation of p, whose declarations are in fset, as
+// Markdown. The sections and their order are the same as in the HTML
+// documentation; as there, only the package comment is shown for commands.
+//
+// If the rendered documentation size exceeds the specified limit, an error
+// with ErrTooLarge in its chain is returned.
+func Render(fset *token.FileSet, p *doc.Package, opt RenderOptions) (_ string, err error) {
+	defer derrors.Wrap(&err, "docmd.Render")
+	if opt.Limit == 0 {
+		const megabyte = 1000 * 1000
+		opt.Limit = 10 * megabyte
+	}
+
+	r := &renderer{fset: fset}
+	fmt.Fprintf(&r.buf, "# package %s\n\n", p.Name)
+	r.code(fmt.Sprintf("import %q\n", p.ImportPath), "go")
+	r.doc(p.Doc)
+	if p.Name != "main" {
+		if len(p.Consts) > 0 {
+			r.heading(2, "Constants")
+			r.values(p.Consts)
+		}
+		if len(p.Vars) > 0 {
+			r.heading(2, "Variables")
+			r.values(p.Vars)
+		}
+		if len(p.Funcs) > 0 {
+			r.heading(2, "Functions")
+			for _, f := range p.Funcs {
+				r.heading(3, "func "+f.Name)
+				r.decl(f.Decl, f.Doc)
+			}
+		}
+		if len(p.Types) > 0 {
+			r.heading(2, "Types")
+			for _, t := range p.Types {
+				r.heading(3, "type "+t.Name)
+				r.decl(t.Decl, t.Doc)
+				r.values(t.Consts)
+				r.values(t.Vars)
+				for _, f := range t.Funcs {
+					r.heading(4, "func "+f.Name)
+					r.decl(f.Decl, f.Doc)
+				}
+				for _, m := range t.Methods {
+					r.heading(4, fmt.Sprintf("func (%s) %s", m.Recv, m.Name))
+					r.decl(m.Decl, m.Doc)
+				}
+			}
+		}
+	}
+	if bugs := p.Notes["BUG"]; len(bugs) > 0 {
+		r.heading(2, "Bugs")
+		for _, n := range bugs {
+			r.doc(n.Body)
+		}
+	}
+	if err := r.err; err != nil {
+		return "", err
+	}
+	if int64(r.buf.Len()) > opt.Limit {
+		return "", fmt.Errorf("%d bytes: %w", r.buf.Len(), ErrTooLarge)
+	}
+	return r.buf.String(), nil
+}
+
+// A renderer accumulates the Markdown of the documentation.
+type renderer struct {
+	fset *token.FileSet
+	buf  bytes.Buffer
+	err  error // first error from printing a declaration
+}
+
+func (r *renderer) heading(level int, text string) {
+	fmt.Fprintf(&r.buf, "%s %s\n\n", strings.Repeat("#", level), escape(text))
+}
+
+// code writes text as a fenced code block in the given language.
+func (r *renderer) code(text, lang string) {
+	// The fence must be longer than any run of backquotes in the text.
+	fence := "```"
+	for strings.Contains(text, fence) {
+		fence += "`"
+	}
+	fmt.Fprintf(&r.buf, "%s%s\n%s%s\n\n", fence, lang, text, fence)
+}
+
+func (r *renderer) values(vals []*doc.Value) {
+	for _, v := range vals {
+		r.decl(v.Decl, v.Doc)
+	}
+}
+
+// decl writes the source of decl followed by its doc comment. Only the
+// signature of a function is written.
+func (r *renderer) decl(decl ast.Decl, text string) {
+	var buf bytes.Buffer
+	if err := printDecl(&buf, r.fset, decl); err != nil && r.err == nil {
+		r.err = err
+	}
+	r.code(buf.String(), "go")
+	r.doc(text)
+}
+
+// doc writes the doc comment text as Markdown: headings become level 3
+// headings, as in the HTML documentation, and preformatted blocks become
+// code blocks.
+func (r *renderer) doc(text string) {
+	for _, b := range docToBlocks(text) {
+		switch b.kind {
+		case headingBlock:
+			r.heading(3, b.lines[0])
+		case paragraphBlock:
+			for i, l := range b.lines {
+				r.buf.WriteString(escape(l))
+				if i < len(b.lines)-1 {
+					r.buf.WriteString("\n")
+				}
+			}
+			r.buf.WriteString("\n\n")
+		case preformatBlock:
+			r.code(strings.Join(b.lines, "\n")+"\n", "")
+		}
+	}
+}
+
+// printDecl writes the source of decl to buf, without its doc comment or,
+// for a function, its body.
+func printDecl(buf *bytes.Buffer, fset *token.FileSet, decl ast.Decl) error {
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		d2 := *d
+		d2.Doc = nil
+		d2.Body = nil
+		decl = &d2
+	case *ast.GenDecl:
+		d2 := *d
+		d2.Doc = nil
+		decl = &d2
+	}
+	p := printer.Config{Mode: printer.UseSpaces | printer.TabIndent, Tabwidth: 4}
+	if err := p.Fprint(buf, fset, decl); err != nil {
+		return err
+	}
+	buf.WriteString("\n")
+	return nil
+}
+
+// markdownSpecial holds the characters that are escaped in text, so that
+// they are not interpreted as Markdown.
+const markdownSpecial = "\\`*_[]<>#|"
+
+// escape escapes the characters of text that have a meaning in Markdown.
+// Words that contain "://" are left alone, so that URLs are still
+// recognized as links.
+func escape(text string) string {
+	var b strings.Builder
+	for i, word := range strings.Split(text, " ") {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		if strings.Contains(word, "://") {
+			b.WriteString(word)
+			continue
+		}
+		for _, c := range word {
+			if strings.ContainsRune(markdownSpecial, c) {
+				b.WriteByte('\\')
+			}
+			b.WriteRune(c)
+		}
+	}
+	s := b.String()
+	// A line that starts with a list marker would become a list item, and
+	// one that consists of "=" or "-" would make the previous line a heading.
+	if m := listMarkerRx.FindStringIndex(s); m != nil {
+		s = s[:m[1]-2] + "\\" + s[m[1]-2:]
+	} else if setextRx.MatchString(s) {
+		s = "\\" + s
+	}
+	return s
+}
+
+var (
+	listMarkerRx = regexp.MustCompile(`^([-+]|[0-9]+[.)]) `)
+	setextRx     = regexp.MustCompile(`^(=+|-+)\s*$`)
+)
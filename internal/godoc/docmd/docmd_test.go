@@ -0,0 +1,111 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docmd
+
+import (
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal/godoc/internal/doc"
+)
+
+const src = `// Package p is for *testing*.
+//
+// Usage
+//
+// Use it like this:
+//
+//	p.F()
+//
+// See https://example.com/a_b for more.
+package p
+
+// Max is the maximum.
+const Max = 10
+
+// F does something.
+func F() {}
+
+// T is a type.
+type T int
+
+// M is a method.
+func (t *T) M(x int) string { return "" }
+`
+
+const want = "# package p\n\n" +
+	"```go\nimport \"example.com/p\"\n```\n\n" +
+	"Package p is for \\*testing\\*.\n\n" +
+	"### Usage\n\n" +
+	"Use it like this:\n\n" +
+	"```\np.F()\n```\n\n" +
+	"See https://example.com/a_b for more.\n\n" +
+	"## Constants\n\n" +
+	"```go\nconst Max = 10\n```\n\n" +
+	"Max is the maximum.\n\n" +
+	"## Functions\n\n" +
+	"### func F\n\n" +
+	"```go\nfunc F()\n```\n\n" +
+	"F does something.\n\n" +
+	"## Types\n\n" +
+	"### type T\n\n" +
+	"```go\ntype T int\n```\n\n" +
+	"T is a type.\n\n" +
+	"#### func (\\*T) M\n\n" +
+	"```go\nfunc (t *T) M(x int) string\n```\n\n" +
+	"M is a method.\n\n"
+
+func mustLoadPackage(t *testing.T, src string) (*token.FileSet, *doc.Package) {
+	t.Helper()
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := doc.NewFromFiles(fset, []*ast.File{f}, "example.com/p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fset, p
+}
+
+func TestRender(t *testing.T) {
+	fset, p := mustLoadPackage(t, src)
+	got, err := Render(fset, p, RenderOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestRenderTooLarge(t *testing.T) {
+	fset, p := mustLoadPackage(t, src)
+	_, err := Render(fset, p, RenderOptions{Limit: 10})
+	if !errors.Is(err, ErrTooLarge) {
+		t.Errorf("got error %v, want ErrTooLarge", err)
+	}
+}
+
+func TestEscape(t *testing.T) {
+	for _, test := range []struct {
+		in, want string
+	}{
+		{"a_b and [c]", `a\_b and \[c\]`},
+		{"see http://x.com/a_b", "see http://x.com/a_b"},
+		{"- not a list", `\- not a list`},
+		{"1. not a list", `1\. not a list`},
+		{"===", `\===`},
+	} {
+		if got := escape(test.in); got != test.want {
+			t.Errorf("escape(%q) = %q, want %q", test.in, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package doctext renders Go package documentation into plain text, in the
+// format of "go doc -all", for terminal clients.
+package doctext
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	stddoc "go/doc"
+	"go/printer"
+	"go/token"
+
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/godoc/internal/doc"
+)
+
+// ErrTooLarge is returned when the rendered documentation exceeds the limit
+// given in the RenderOptions.
+var ErrTooLarge = errors.New("rendered documentation text size exceeded the specified limit")
+
+const (
+	indent     = "    "
+	codeIndent = "    \t"
+	lineWidth  = 80
+)
+
+// RenderOptions are options for Render.
+type RenderOptions struct {
+	Limit int64 // If zero, a default limit of 10 megabytes is used.
+}
+
+// Render renders the documentation of p, whose declarations are in fset, as
+// plain text. As with the HTML documentation, only the package comment is
+// shown for commands.
+//
+// If the rendered documentation size exceeds the specified limit, an error
+// with ErrTooLarge in its chain is returned.
+func Render(fset *token.FileSet, p *doc.Package, opt RenderOptions) (_ string, err error) {
+	defer derrors.Wrap(&err, "doctext.Render")
+	if opt.Limit == 0 {
+		const megabyte = 1000 * 1000
+		opt.Limit = 10 * megabyte
+	}
+
+	r := &renderer{fset: fset}
+	fmt.Fprintf(&r.buf, "package %s // import %q\n\n", p.Name, p.ImportPath)
+	if p.Doc != "" {
+		r.doc(p.Doc, "", "\t")
+		r.buf.WriteString("\n")
+	}
+	if p.Name != "main" {
+		if len(p.Consts) > 0 {
+			r.section("CONSTANTS")
+			r.values(p.Consts)
+		}
+		if len(p.Vars) > 0 {
+			r.section("VARIABLES")
+			r.values(p.Vars)
+		}
+		if len(p.Funcs) > 0 {
+			r.section("FUNCTIONS")
+			r.funcs(p.Funcs)
+		}
+		if len(p.Types) > 0 {
+			r.section("TYPES")
+			for _, t := range p.Types {
+				r.decl(t.Decl, t.Doc)
+				r.values(t.Consts)
+				r.values(t.Vars)
+				r.funcs(t.Funcs)
+				r.funcs(t.Methods)
+			}
+		}
+	}
+	if bugs := p.Notes["BUG"]; len(bugs) > 0 {
+		r.section("BUGS")
+		for _, n := range bugs {
+			r.doc(n.Body, "", "\t")
+			r.buf.WriteString("\n")
+		}
+	}
+	if err := r.err; err != nil {
+		return "", err
+	}
+	if int64(r.buf.Len()) > opt.Limit {
+		return "", fmt.Errorf("%d bytes: %w", r.buf.Len(), ErrTooLarge)
+	}
+	return r.buf.String(), nil
+}
+
+// A renderer accumulates the text of the documentation.
+type renderer struct {
+	fset *token.FileSet
+	buf  bytes.Buffer
+	err  error // first error from printing a declaration
+}
+
+func (r *renderer) section(title string) {
+	fmt.Fprintf(&r.buf, "%s\n\n", title)
+}
+
+// doc writes the doc comment text, wrapped and indented.
+func (r *renderer) doc(text, prefix, codePrefix string) {
+	if text == "" {
+		return
+	}
+	stddoc.ToText(&r.buf, text, prefix, codePrefix, lineWidth)
+}
+
+func (r *renderer) values(vals []*doc.Value) {
+	for _, v := range vals {
+		r.decl(v.Decl, v.Doc)
+	}
+}
+
+func (r *renderer) funcs(funcs []*doc.Func) {
+	for _, f := range funcs {
+		r.decl(f.Decl, f.Doc)
+	}
+}
+
+// decl writes the source of decl followed by its doc comment, indented.
+// Only the signature of a function is written.
+func (r *renderer) decl(decl ast.Decl, text string) {
+	if err := printDecl(&r.buf, r.fset, decl); err != nil && r.err == nil {
+		r.err = err
+	}
+	r.doc(text, indent, codeIndent)
+	r.buf.WriteString("\n")
+}
+
+// printDecl writes the source of decl to buf, without its doc comment or,
+// for a function, its body.
+func printDecl(buf *bytes.Buffer, fset *token.FileSet, decl ast.Decl) error {
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		d2 := *d
+		d2.Doc = nil
+		d2.Body = nil
+		decl = &d2
+	case *ast.GenDecl:
+		d2 := *d
+		d2.Doc = nil
+		decl = &d2
+	}
+	p := printer.Config{Mode: printer.UseSpaces | printer.TabIndent, Tabwidth: 4}
+	if err := p.Fprint(buf, fset, decl); err != nil {
+		return err
+	}
+	buf.WriteString("\n")
+	return nil
+}
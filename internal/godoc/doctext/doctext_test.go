@@ -0,0 +1,124 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package doctext
+
+import (
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal/godoc/internal/doc"
+)
+
+const src = `// Package p is for testing.
+// Use it like this:
+//
+//	p.F()
+package p
+
+// Max is the maximum.
+const Max = 10
+
+var (
+	// ErrX is an error.
+	ErrX = errors.New("x")
+)
+
+// F does something.
+func F() {}
+
+// T is a type.
+type T struct {
+	A int // the A field
+	b int
+}
+
+// NewT returns a T.
+func NewT() *T { return nil }
+
+// M is a method.
+func (t *T) M(x int) string { return "" }
+
+// BUG(jba): it is buggy.
+`
+
+const want = `package p // import "example.com/p"
+
+Package p is for testing. Use it like this:
+
+	p.F()
+
+CONSTANTS
+
+const Max = 10
+    Max is the maximum.
+
+VARIABLES
+
+var (
+	// ErrX is an error.
+	ErrX = errors.New("x")
+)
+
+FUNCTIONS
+
+func F()
+    F does something.
+
+TYPES
+
+type T struct {
+	A int // the A field
+	// contains filtered or unexported fields
+}
+    T is a type.
+
+func NewT() *T
+    NewT returns a T.
+
+func (t *T) M(x int) string
+    M is a method.
+
+BUGS
+
+it is buggy.
+
+`
+
+func mustLoadPackage(t *testing.T, src string) (*token.FileSet, *doc.Package) {
+	t.Helper()
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := doc.NewFromFiles(fset, []*ast.File{f}, "example.com/p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fset, p
+}
+
+func TestRender(t *testing.T) {
+	fset, p := mustLoadPackage(t, src)
+	got, err := Render(fset, p, RenderOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestRenderTooLarge(t *testing.T) {
+	fset, p := mustLoadPackage(t, src)
+	_, err := Render(fset, p, RenderOptions{Limit: 10})
+	if !errors.Is(err, ErrTooLarge) {
+		t.Errorf("got error %v, want ErrTooLarge", err)
+	}
+}
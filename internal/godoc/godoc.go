@@ -17,17 +17,40 @@ var ErrTooLarge = dochtml.ErrTooLarge
 
 type ModuleInfo = dochtml.ModuleInfo
 
+// An Embed describes the files embedded in a package-level variable.
+type Embed = dochtml.Embed
+
+// An ExcludedFile is a file left out of the documentation by build
+// constraints.
+type ExcludedFile = dochtml.ExcludedFile
+
+// A SizeBreakdown accounts for the bytes of documentation HTML.
+type SizeBreakdown = dochtml.SizeBreakdown
+
+// A DocSection is a section of the documentation that can be truncated.
+type DocSection = dochtml.Section
+
+// DocSections lists the sections of the documentation that can be truncated.
+var DocSections = dochtml.Sections
+
 // A Package contains package-level information needed to render Go documentation.
 type Package struct {
 	Fset *token.FileSet
 	gobPackage
 	renderCalled bool
+	sizes        *SizeBreakdown
 }
 
 type gobPackage struct { // fields that can be directly gob-encoded
 	GOOS, GOARCH       string
 	Files              []*File
 	ModulePackagePaths map[string]bool
+	// Embeds maps the names of package-level variables to the files
+	// embedded in them.
+	Embeds map[string]*Embed
+	// ExcludedFiles are the files of the package that the build context
+	// excludes.
+	ExcludedFiles []*ExcludedFile
 }
 
 // A File contains everything needed about a source file to render documentation.
@@ -72,3 +72,55 @@ func findHTML(docHTML safehtml.HTML, identifier string) (_ safehtml.HTML, err er
 	s := regexp.MustCompile(reg).FindString(docHTML.String())
 	return uncheckedconversions.HTMLFromStringKnownToSatisfyTypeContract(s), nil
 }
+
+var (
+	// idAttrRx matches element IDs and links to them within the page.
+	idAttrRx = regexp.MustCompile(`\s(id|href)="(#?)([^"]*)"`)
+
+	// declHeaderRx matches the headers of declarations in the body section.
+	declHeaderRx = regexp.MustCompile(`\sid="([^"]*)" data-kind="(function|type|method)"`)
+
+	// idPrefixRx matches the prefixes that can be passed to PrefixIDs.
+	idPrefixRx = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+)
+
+// PrefixIDs returns docHTML with every element ID, and every link to an ID
+// within the page, prefixed by prefix and a hyphen, so that the
+// documentation of several packages can be shown on one page. The prefix may
+// only contain letters, digits, '-' and '_'.
+func PrefixIDs(docHTML safehtml.HTML, prefix string) (_ safehtml.HTML, err error) {
+	defer derrors.Wrap(&err, "PrefixIDs(docHTML, %q)", prefix)
+	if !idPrefixRx.MatchString(prefix) {
+		return safehtml.HTML{}, fmt.Errorf("invalid prefix: %w", derrors.InvalidArgument)
+	}
+	s := idAttrRx.ReplaceAllStringFunc(docHTML.String(), func(m string) string {
+		sm := idAttrRx.FindStringSubmatch(m)
+		attr, hash, id := sm[1], sm[2], sm[3]
+		if attr == "href" && hash == "" {
+			// A link to another page.
+			return m
+		}
+		return fmt.Sprintf(` %s="%s%s-%s"`, attr, hash, prefix, id)
+	})
+	// This is safe because only the values of id and href attributes, which
+	// were already safe, are modified, by adding characters that need no
+	// escaping.
+	return uncheckedconversions.HTMLFromStringKnownToSatisfyTypeContract(s), nil
+}
+
+// A Declaration is a function, type or method whose documentation has a
+// header in the body section.
+type Declaration struct {
+	ID   string // ID of the header, like "Buffer.Len"
+	Kind string // "function", "type" or "method"
+}
+
+// Declarations returns the declarations documented in the body section of
+// docHTML, in the order they appear.
+func Declarations(docHTML safehtml.HTML) []*Declaration {
+	var decls []*Declaration
+	for _, sm := range declHeaderRx.FindAllStringSubmatch(docHTML.String(), -1) {
+		decls = append(decls, &Declaration{ID: sm[1], Kind: sm[2]})
+	}
+	return decls
+}
@@ -45,3 +45,35 @@ func TestParse(t *testing.T) {
 		}
 	}
 }
+
+func TestPrefixIDs(t *testing.T) {
+	docHTML := uncheckedconversions.HTMLFromStringKnownToSatisfyTypeContract(
+		`<h4 tabindex="-1" id="Buffer.Len" data-kind="method">func (b *Buffer) Len <a href="#Buffer.Len">¶</a></h4>` +
+			`<a href="/io#Reader">io.Reader</a>`)
+	got, err := PrefixIDs(docHTML, "bytes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<h4 tabindex="-1" id="bytes-Buffer.Len" data-kind="method">func (b *Buffer) Len <a href="#bytes-Buffer.Len">¶</a></h4>` +
+		`<a href="/io#Reader">io.Reader</a>`
+	if diff := cmp.Diff(want, got.String()); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := PrefixIDs(docHTML, `x" onclick="y`); err == nil {
+		t.Error("got nil error for an invalid prefix, want error")
+	}
+}
+
+func TestDeclarations(t *testing.T) {
+	got := Declarations(uncheckedconversions.HTMLFromStringKnownToSatisfyTypeContract(quoteDocHTML))
+	want := []*Declaration{
+		{ID: "Glass", Kind: "function"},
+		{ID: "Go", Kind: "function"},
+		{ID: "Hello", Kind: "function"},
+		{ID: "Opt", Kind: "function"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -6,16 +6,23 @@ package godoc
 
 import (
 	"context"
+	"crypto/sha256"
 	"errors"
 	"fmt"
 	"go/ast"
+	"net/url"
 	"path"
 	"sort"
+	"strings"
 
 	"github.com/google/safehtml"
 	"github.com/google/safehtml/template"
+	"golang.org/x/pkgsite/internal"
 	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/experiment"
 	"golang.org/x/pkgsite/internal/godoc/dochtml"
+	"golang.org/x/pkgsite/internal/godoc/docmd"
+	"golang.org/x/pkgsite/internal/godoc/doctext"
 	"golang.org/x/pkgsite/internal/godoc/internal/doc"
 	"golang.org/x/pkgsite/internal/source"
 	"golang.org/x/pkgsite/internal/stdlib"
@@ -25,6 +32,7 @@ const (
 	megabyte               = 1000 * 1000
 	maxImportsPerPackage   = 1000
 	docTooLargeReplacement = `<p>Documentation is too large to display.</p>`
+	docTooLargeText        = "Documentation is too large to display.\n"
 )
 
 // MaxDocumentationHTML is a limit on the rendered documentation HTML size.
@@ -35,11 +43,55 @@ const (
 // It is a variable for testing.
 var MaxDocumentationHTML = 20 * megabyte
 
+// MaxDocumentationPartHTML is a limit on the size of each part of
+// documentation that is split because it exceeds MaxDocumentationHTML.
+//
+// It is a variable for testing.
+var MaxDocumentationPartHTML = 5 * megabyte
+
+// foldValuesAfter is the number of specs of a constant or variable group
+// that are displayed before the rest is folded. Large generated enums are
+// the main reason that documentation exceeds MaxDocumentationHTML.
+const foldValuesAfter = 100
+
+// SourcegraphURL is the base URL of the Sourcegraph instance that "Uses"
+// links next to declarations point to. If it is empty, no such links are
+// rendered. It is set from the configuration at startup.
+var SourcegraphURL string
+
 var noDocTemplate = template.Must(template.New("").Parse(`<p>No documentation for GOOS/GOARCH {{.}}</p>`))
 
+// A DocFormat is a format, other than HTML, in which documentation can be
+// rendered.
+type DocFormat string
+
+const (
+	// DocFormatText is the plain text format of "go doc -all".
+	DocFormatText DocFormat = "text"
+	// DocFormatMarkdown is Markdown.
+	DocFormatMarkdown DocFormat = "markdown"
+)
+
 // Render renders the documentation for the package.
 // Rendering destroys p's AST; do not call any methods of p after it returns.
 func (p *Package) Render(ctx context.Context, innerPath string, sourceInfo *source.Info, modInfo *ModuleInfo, goos, goarch string) (synopsis string, imports []string, html safehtml.HTML, err error) {
+	synopsis, imports, html, _, _, err = p.RenderFormats(ctx, innerPath, sourceInfo, modInfo, goos, goarch, nil)
+	return synopsis, imports, html, err
+}
+
+// RenderFormats is like Render, but also renders the documentation in each
+// of the given formats. The results are in other, keyed by format.
+//
+// If the split-large-doc experiment is active and the documentation HTML is
+// too large, it is split: html is then the first part, and parts holds the
+// others.
+//
+// If the doc-size-breakdown experiment is active, the size of the
+// documentation HTML is accounted for; see SizeBreakdown.
+//
+// Rendering destroys p's AST; do not call any methods of p after it returns,
+// other than SizeBreakdown.
+func (p *Package) RenderFormats(ctx context.Context, innerPath string, sourceInfo *source.Info, modInfo *ModuleInfo, goos, goarch string, formats []DocFormat) (synopsis string, imports []string, html safehtml.HTML, parts []*internal.DocumentationPart, other map[DocFormat]string, err error) {
 	// This is mostly copied from internal/fetch/fetch.go.
 	defer derrors.Wrap(&err, "godoc.Package.Render(%q, %q, %q, %q, %q)", modInfo.ModulePath, modInfo.ResolvedVersion, innerPath, goos, goarch)
 
@@ -49,10 +101,201 @@ func (p *Package) Render(ctx context.Context, innerPath string, sourceInfo *sour
 	if (goos != "" && goos != p.GOOS) || (goarch != "" && goarch != p.GOARCH) {
 		html, err := noDocTemplate.ExecuteToHTML(goos + "/" + goarch)
 		if err != nil {
-			return "", nil, safehtml.HTML{}, err
+			return "", nil, safehtml.HTML{}, nil, nil, err
+		}
+		return "No documentation.", nil, html, nil, nil, errors.New("no doc")
+	}
+	d, err := p.docPackage(innerPath, modInfo, false)
+	if err != nil {
+		return "", nil, safehtml.HTML{}, nil, nil, err
+	}
+	importPath := d.ImportPath
+
+	// Process package imports.
+	if len(d.Imports) > maxImportsPerPackage {
+		return "", nil, safehtml.HTML{}, nil, nil, fmt.Errorf("%d imports found package %q; exceeds limit %d for maxImportsPerPackage", len(d.Imports), importPath, maxImportsPerPackage)
+	}
+
+	// Render the other formats first, since rendering HTML modifies the AST.
+	if len(formats) > 0 {
+		other = map[DocFormat]string{}
+	}
+	for _, f := range formats {
+		var (
+			s   string
+			err error
+		)
+		switch f {
+		case DocFormatText:
+			s, err = doctext.Render(p.Fset, d, doctext.RenderOptions{Limit: int64(MaxDocumentationHTML)})
+			if errors.Is(err, doctext.ErrTooLarge) {
+				s, err = docTooLargeText, nil
+			}
+		case DocFormatMarkdown:
+			s, err = docmd.Render(p.Fset, d, docmd.RenderOptions{Limit: int64(MaxDocumentationHTML)})
+			if errors.Is(err, docmd.ErrTooLarge) {
+				s, err = docTooLargeText, nil
+			}
+		default:
+			err = fmt.Errorf("unknown documentation format %q", f)
+		}
+		if err != nil {
+			return "", nil, safehtml.HTML{}, nil, nil, err
+		}
+		other[f] = s
+	}
+
+	// Render documentation HTML.
+	opts := p.htmlOptions(ctx, innerPath, sourceInfo, modInfo, importPath)
+	if experiment.IsActive(ctx, internal.ExperimentDocSizeBreakdown) {
+		p.sizes = &SizeBreakdown{}
+		opts.Sizes = p.sizes
+	}
+	docHTML, err := dochtml.Render(ctx, p.Fset, d, opts)
+	if errors.Is(err, ErrTooLarge) && experiment.IsActive(ctx, internal.ExperimentSplitLargeDoc) {
+		opts.Limit = int64(MaxDocumentationPartHTML)
+		// Keep the sizes of the documentation as a whole.
+		opts.Sizes = nil
+		dparts, perr := dochtml.RenderParts(ctx, p.Fset, d, opts)
+		if perr == nil {
+			docHTML = dparts[0].HTML
+			for _, dp := range dparts[1:] {
+				parts = append(parts, &internal.DocumentationPart{Title: dp.Title, HTML: dp.HTML})
+			}
+			err = nil
+		} else if !errors.Is(perr, ErrTooLarge) {
+			return "", nil, safehtml.HTML{}, nil, nil, fmt.Errorf("dochtml.RenderParts: %v", perr)
 		}
-		return "No documentation.", nil, html, errors.New("no doc")
 	}
+	if errors.Is(err, ErrTooLarge) {
+		docHTML = template.MustParseAndExecuteToHTML(docTooLargeReplacement)
+	} else if err != nil {
+		return "", nil, safehtml.HTML{}, nil, nil, fmt.Errorf("dochtml.Render: %v", err)
+	}
+	return doc.Synopsis(d.Doc), d.Imports, docHTML, parts, other, err
+}
+
+// SizeBreakdown returns how the bytes of the documentation HTML rendered by
+// RenderFormats break down, or nil if they were not accounted for. If the
+// documentation was too large, it accounts for what was rendered before the
+// limit was reached.
+func (p *Package) SizeBreakdown() *SizeBreakdown {
+	return p.sizes
+}
+
+// HTMLOptions are options for rendering the documentation HTML of a package
+// when it is served.
+type HTMLOptions struct {
+	// SectionLimit, if positive, is the largest number of declarations
+	// displayed in each of the constants, variables, functions and types
+	// sections. The rest of a longer section is replaced by a link to
+	// SectionURL(section), which is expected to serve the result of
+	// RenderSection.
+	SectionLimit int
+	SectionURL   func(DocSection) string
+	// SinceVersions optionally maps the names of functions, types and
+	// methods, the latter as "Type.Method", to the version in which they
+	// were added, to be displayed next to their declarations.
+	SinceVersions map[string]string
+	// AllDecls includes unexported declarations in the documentation, like
+	// the m=all mode of godoc. Unexported functions are shown only if the
+	// package source was stored with them, which it isn't when unused AST
+	// nodes are removed.
+	AllDecls bool
+	// EditorURL optionally returns a URL that opens the given line of a
+	// file in the reader's editor. file is relative to the module root.
+	// The links are displayed next to the source links of declarations.
+	EditorURL func(file string, line int) safehtml.URL
+}
+
+// RenderHTML renders the documentation HTML for the package like Render,
+// with the given options. If the documentation is too large, an error with
+// ErrTooLarge in its chain is returned.
+//
+// Rendering destroys p's AST; do not call any methods of p after it returns.
+func (p *Package) RenderHTML(ctx context.Context, innerPath string, sourceInfo *source.Info, modInfo *ModuleInfo, hopts HTMLOptions) (_ safehtml.HTML, err error) {
+	defer derrors.Wrap(&err, "godoc.Package.RenderHTML(%q, %q, %q)", modInfo.ModulePath, modInfo.ResolvedVersion, innerPath)
+	p.renderCalled = true
+
+	d, err := p.docPackage(innerPath, modInfo, hopts.AllDecls)
+	if err != nil {
+		return safehtml.HTML{}, err
+	}
+	opts := p.htmlOptions(ctx, innerPath, sourceInfo, modInfo, d.ImportPath)
+	opts.SectionLimit = hopts.SectionLimit
+	opts.SectionURLFunc = hopts.SectionURL
+	opts.SinceVersions = hopts.SinceVersions
+	opts.EditorLinkFunc = p.editorLinkFunc(innerPath, hopts.EditorURL)
+	return dochtml.Render(ctx, p.Fset, d, opts)
+}
+
+// RenderSection renders the HTML of the whole given section of the
+// documentation for the package, which RenderHTML may have truncated.
+// hopts.SectionLimit is ignored.
+//
+// Rendering destroys p's AST; do not call any methods of p after it returns.
+func (p *Package) RenderSection(ctx context.Context, innerPath string, sourceInfo *source.Info, modInfo *ModuleInfo, section DocSection, hopts HTMLOptions) (_ safehtml.HTML, err error) {
+	defer derrors.Wrap(&err, "godoc.Package.RenderSection(%q, %q, %q, %q)", modInfo.ModulePath, modInfo.ResolvedVersion, innerPath, section)
+	p.renderCalled = true
+
+	d, err := p.docPackage(innerPath, modInfo, hopts.AllDecls)
+	if err != nil {
+		return safehtml.HTML{}, err
+	}
+	opts := p.htmlOptions(ctx, innerPath, sourceInfo, modInfo, d.ImportPath)
+	opts.SinceVersions = hopts.SinceVersions
+	opts.EditorLinkFunc = p.editorLinkFunc(innerPath, hopts.EditorURL)
+	return dochtml.RenderSection(ctx, p.Fset, d, section, opts)
+}
+
+// RenderOutline returns the outline of the documentation of the package, as
+// rendered by RenderHTML with no options, for clients that build their own
+// navigation.
+//
+// Rendering destroys p's AST; do not call any methods of p after it returns.
+func (p *Package) RenderOutline(ctx context.Context, innerPath string, sourceInfo *source.Info, modInfo *ModuleInfo) (_ *dochtml.Outline, err error) {
+	defer derrors.Wrap(&err, "godoc.Package.RenderOutline(%q, %q, %q)", modInfo.ModulePath, modInfo.ResolvedVersion, innerPath)
+	p.renderCalled = true
+
+	d, err := p.docPackage(innerPath, modInfo, false)
+	if err != nil {
+		return nil, err
+	}
+	opts := p.htmlOptions(ctx, innerPath, sourceInfo, modInfo, d.ImportPath)
+	opts.Outline = &dochtml.Outline{}
+	// The outline is complete even if the HTML is too large.
+	if _, err := dochtml.Render(ctx, p.Fset, d, opts); err != nil && !errors.Is(err, dochtml.ErrTooLarge) {
+		return nil, err
+	}
+	return opts.Outline, nil
+}
+
+// TextHash returns a hash of the documentation of the package rendered as
+// plain text, which identifies the documentation regardless of how it is
+// displayed. Packages whose documentation differs only in their source
+// layout, or in their unexported declarations, have the same hash.
+//
+// Computing the hash destroys p's AST; do not call any methods of p after it
+// returns.
+func (p *Package) TextHash(innerPath string, modInfo *ModuleInfo) (_ string, err error) {
+	defer derrors.Wrap(&err, "godoc.Package.TextHash(%q, %q, %q)", modInfo.ModulePath, modInfo.ResolvedVersion, innerPath)
+	p.renderCalled = true
+
+	d, err := p.docPackage(innerPath, modInfo, false)
+	if err != nil {
+		return "", err
+	}
+	text, err := doctext.Render(p.Fset, d, doctext.RenderOptions{Limit: int64(MaxDocumentationHTML)})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(text))), nil
+}
+
+// docPackage computes the documentation of the package at innerPath in the
+// module described by modInfo, filling in modInfo.ModulePackages if unset.
+// If allDecls is true, unexported declarations are included.
+func (p *Package) docPackage(innerPath string, modInfo *ModuleInfo, allDecls bool) (*doc.Package, error) {
 	importPath := path.Join(modInfo.ModulePath, innerPath)
 	if modInfo.ModulePath == stdlib.ModulePath {
 		importPath = innerPath
@@ -73,7 +316,7 @@ func (p *Package) Render(ctx context.Context, innerPath string, sourceInfo *sour
 
 	// Compute package documentation.
 	var m doc.Mode
-	if noFiltering {
+	if noFiltering || allDecls {
 		m |= doc.AllDecls
 	}
 	var allGoFiles []*ast.File
@@ -82,7 +325,7 @@ func (p *Package) Render(ctx context.Context, innerPath string, sourceInfo *sour
 	}
 	d, err := doc.NewFromFiles(p.Fset, allGoFiles, importPath, m)
 	if err != nil {
-		return "", nil, safehtml.HTML{}, fmt.Errorf("doc.NewFromFiles: %v", err)
+		return nil, fmt.Errorf("doc.NewFromFiles: %v", err)
 	}
 
 	if d.ImportPath != importPath {
@@ -96,13 +339,12 @@ func (p *Package) Render(ctx context.Context, innerPath string, sourceInfo *sour
 		}
 		sort.Slice(d.Funcs, func(i, j int) bool { return d.Funcs[i].Name < d.Funcs[j].Name })
 	}
+	return d, nil
+}
 
-	// Process package imports.
-	if len(d.Imports) > maxImportsPerPackage {
-		return "", nil, safehtml.HTML{}, fmt.Errorf("%d imports found package %q; exceeds limit %d for maxImportsPerPackage", len(d.Imports), importPath, maxImportsPerPackage)
-	}
-
-	// Render documentation HTML.
+// htmlOptions returns the options for rendering the documentation HTML of
+// the package with the given import path.
+func (p *Package) htmlOptions(ctx context.Context, innerPath string, sourceInfo *source.Info, modInfo *ModuleInfo, importPath string) dochtml.RenderOptions {
 	sourceLinkFunc := func(n ast.Node) string {
 		if sourceInfo == nil {
 			return ""
@@ -120,16 +362,53 @@ func (p *Package) Render(ctx context.Context, innerPath string, sourceInfo *sour
 		return sourceInfo.FileURL(path.Join(innerPath, filename))
 	}
 
-	docHTML, err := dochtml.Render(ctx, p.Fset, d, dochtml.RenderOptions{
-		FileLinkFunc:   fileLinkFunc,
-		SourceLinkFunc: sourceLinkFunc,
-		ModInfo:        modInfo,
-		Limit:          int64(MaxDocumentationHTML),
-	})
-	if errors.Is(err, ErrTooLarge) {
-		docHTML = template.MustParseAndExecuteToHTML(docTooLargeReplacement)
-	} else if err != nil {
-		return "", nil, safehtml.HTML{}, fmt.Errorf("dochtml.Render: %v", err)
+	var usesLinkFunc func([]string) string
+	if SourcegraphURL != "" {
+		usesLinkFunc = sourcegraphUsesLinkFunc(SourcegraphURL, importPath, sourceInfo.RepoURL())
+	}
+
+	return dochtml.RenderOptions{
+		FileLinkFunc:       fileLinkFunc,
+		SourceLinkFunc:     sourceLinkFunc,
+		UsesLinkFunc:       usesLinkFunc,
+		ModInfo:            modInfo,
+		Limit:              int64(MaxDocumentationHTML),
+		CollapseDeprecated: experiment.IsActive(ctx, internal.ExperimentCollapseDeprecated),
+		FoldValuesAfter:    foldValuesAfter,
+		Embeds:             p.Embeds,
+		BuildContext:       p.GOOS + "/" + p.GOARCH,
+		ExcludedFiles:      p.ExcludedFiles,
+	}
+}
+
+// editorLinkFunc returns a function that builds the editor URL of the source
+// of a node with editorURL, or nil if editorURL is nil.
+func (p *Package) editorLinkFunc(innerPath string, editorURL func(string, int) safehtml.URL) func(ast.Node) safehtml.URL {
+	if editorURL == nil {
+		return nil
+	}
+	return func(n ast.Node) safehtml.URL {
+		pos := p.Fset.Position(n.Pos())
+		if pos.Line == 0 { // invalid Position
+			return safehtml.URL{}
+		}
+		return editorURL(path.Join(innerPath, pos.Filename), pos.Line)
+	}
+}
+
+// sourcegraphUsesLinkFunc returns a function that builds the URL of the page
+// of the Sourcegraph instance at baseURL that lists the references to a
+// declaration in the package importPath. The declaration is given by its
+// parts, like ["Buffer", "Len"] for the method Buffer.Len.
+func sourcegraphUsesLinkFunc(baseURL, importPath, repoURL string) func(defParts []string) string {
+	return func(defParts []string) string {
+		v := url.Values{
+			"def": []string{strings.Join(defParts, "/")},
+			"pkg": []string{importPath},
+		}
+		if repoURL != "" {
+			v.Set("repo", repoURL)
+		}
+		return strings.TrimSuffix(baseURL, "/") + "/-/godoc/refs?" + v.Encode()
 	}
-	return doc.Synopsis(d.Doc), d.Imports, docHTML, err
 }
@@ -6,11 +6,16 @@ package godoc
 
 import (
 	"context"
+	"fmt"
 	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/safehtml"
+	"github.com/google/safehtml/uncheckedconversions"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/experiment"
 	"golang.org/x/pkgsite/internal/source"
 	"golang.org/x/pkgsite/internal/testing/sample"
 )
@@ -81,3 +86,130 @@ func TestRender(t *testing.T) {
 	}
 	check(p2)
 }
+
+func TestSourcegraphUsesLinkFunc(t *testing.T) {
+	for _, test := range []struct {
+		baseURL, repoURL string
+		defParts         []string
+		want             string
+	}{
+		{
+			baseURL:  "https://sourcegraph.com",
+			repoURL:  "https://github.com/a/b",
+			defParts: []string{"Buffer", "Len"},
+			want:     "https://sourcegraph.com/-/godoc/refs?def=Buffer%2FLen&pkg=github.com%2Fa%2Fb%2Fc&repo=https%3A%2F%2Fgithub.com%2Fa%2Fb",
+		},
+		{
+			baseURL:  "https://sourcegraph.example.com/",
+			defParts: []string{"F"},
+			want:     "https://sourcegraph.example.com/-/godoc/refs?def=F&pkg=github.com%2Fa%2Fb%2Fc",
+		},
+	} {
+		got := sourcegraphUsesLinkFunc(test.baseURL, "github.com/a/b/c", test.repoURL)(test.defParts)
+		if got != test.want {
+			t.Errorf("%s, %v: got %q, want %q", test.baseURL, test.defParts, got, test.want)
+		}
+	}
+}
+
+func TestRenderHTMLAllDecls(t *testing.T) {
+	ctx := context.Background()
+	mi := &ModuleInfo{ModulePath: sample.ModulePath, ResolvedVersion: sample.VersionString}
+	for _, test := range []struct {
+		allDecls bool
+		want     bool
+	}{
+		{false, false},
+		{true, true},
+	} {
+		p, err := packageForDir(filepath.Join("testdata", "p"), false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		html, err := p.RenderHTML(ctx, "p", nil, mi, HTMLOptions{AllDecls: test.allDecls})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, id := range []string{"unexp", "us"} {
+			if got := strings.Contains(html.String(), `id="`+id+`"`); got != test.want {
+				t.Errorf("AllDecls = %t: has %q = %t, want %t", test.allDecls, id, got, test.want)
+			}
+		}
+	}
+}
+
+func TestRenderHTMLEditorURL(t *testing.T) {
+	// Editor links are only in the unit page layout.
+	ctx := experiment.NewContext(context.Background(), internal.ExperimentUnitPage)
+	mi := &ModuleInfo{ModulePath: sample.ModulePath, ResolvedVersion: sample.VersionString}
+	p, err := packageForDir(filepath.Join("testdata", "p"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	editorURL := func(file string, line int) safehtml.URL {
+		return uncheckedconversions.URLFromStringKnownToSatisfyTypeContract(fmt.Sprintf("vscode://file/mod/%s:%d", file, line))
+	}
+	html, err := p.RenderHTML(ctx, "p", nil, mi, HTMLOptions{EditorURL: editorURL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<a class="Documentation-editor" href="vscode://file/mod/p/`
+	if !strings.Contains(html.String(), want) {
+		t.Errorf("RenderHTML does not contain %q", want)
+	}
+}
+
+func TestRenderOutline(t *testing.T) {
+	ctx := context.Background()
+	mi := &ModuleInfo{ModulePath: sample.ModulePath, ResolvedVersion: sample.VersionString}
+	p, err := packageForDir(filepath.Join("testdata", "p"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o, err := p.RenderOutline(ctx, "p", nil, mi)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, f := range o.Funcs {
+		got = append(got, f.Name)
+	}
+	for _, typ := range o.Types {
+		got = append(got, typ.Name)
+	}
+	if want := []string{"F", "I", "S1", "S2", "T"}; !cmp.Equal(got, want) {
+		t.Errorf("got funcs and types %q, want %q", got, want)
+	}
+}
+
+func TestTextHash(t *testing.T) {
+	mi := &ModuleInfo{ModulePath: sample.ModulePath, ResolvedVersion: sample.VersionString}
+	hash := func(removeNodes, dropDecls bool) string {
+		t.Helper()
+		p, err := packageForDir(filepath.Join("testdata", "p"), removeNodes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dropDecls {
+			// Keep only the package clause and doc comment of p.go.
+			for _, f := range p.Files {
+				if filepath.Base(f.Name) == "p.go" {
+					f.AST.Decls = nil
+				}
+			}
+		}
+		h, err := p.TextHash("p", mi)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+
+	want := hash(false, false)
+	if got := hash(true, false); got != want {
+		t.Errorf("with unused AST nodes removed: got %s, want %s", got, want)
+	}
+	if got := hash(false, true); got == want {
+		t.Errorf("with declarations dropped: got the same hash %s", got)
+	}
+}
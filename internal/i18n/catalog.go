@@ -0,0 +1,147 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package i18n
+
+import (
+	"fmt"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/message/catalog"
+)
+
+// translations holds the translations of the text of the site's chrome:
+// navigation, tab names, labels and error messages. Documentation, READMEs
+// and other content that comes from modules is never translated.
+//
+// The keys are the English text, as passed to a Printer or to the T
+// template function. They are also format strings, so a key may contain
+// verbs like %d for its arguments. A message that is missing for a language
+// is displayed in English.
+var translations = map[language.Tag]map[string]string{
+	language.German: {
+		// Navigation and footer.
+		"Why Go":               "Warum Go",
+		"Getting Started":      "Erste Schritte",
+		"Discover Packages":    "Pakete entdecken",
+		"About":                "Über",
+		"Copyright":            "Urheberrecht",
+		"Terms of Service":     "Nutzungsbedingungen",
+		"Open navigation.":     "Navigation öffnen.",
+		"Close navigation.":    "Navigation schließen.",
+		"Search for a package": "Nach einem Paket suchen",
+
+		// Tabs.
+		"Doc":            "Dokumentation",
+		"Overview":       "Übersicht",
+		"Subdirectories": "Unterverzeichnisse",
+		"Packages":       "Pakete",
+		"Versions":       "Versionen",
+		"Imports":        "Importe",
+		"Imported By":    "Importiert von",
+		"Dependencies":   "Abhängigkeiten",
+		"Licenses":       "Lizenzen",
+
+		// Unit header.
+		"Version %s":       "Version %s",
+		"Latest":           "Neueste",
+		"Go to latest":     "Zur neuesten Version",
+		"None detected":    "Keine gefunden",
+		"not legal advice": "keine Rechtsberatung",
+		"Imported by":      "Importiert von",
+		"Go to main page":  "Zur Hauptseite",
+
+		// Relative times.
+		"1 hour ago":   "vor 1 Stunde",
+		"%d hours ago": "vor %d Stunden",
+		"today":        "heute",
+		"1 day ago":    "vor 1 Tag",
+		"%d days ago":  "vor %d Tagen",
+
+		// Error pages.
+		"Bad Request":           "Ungültige Anfrage",
+		"Not Found":             "Nicht gefunden",
+		"Internal Server Error": "Interner Serverfehler",
+		"Service Unavailable":   "Dienst nicht verfügbar",
+	},
+	language.French: {
+		// Navigation and footer.
+		"Why Go":               "Pourquoi Go",
+		"Getting Started":      "Premiers pas",
+		"Discover Packages":    "Découvrir des paquets",
+		"About":                "À propos",
+		"Copyright":            "Droits d'auteur",
+		"Terms of Service":     "Conditions d'utilisation",
+		"Open navigation.":     "Ouvrir la navigation.",
+		"Close navigation.":    "Fermer la navigation.",
+		"Search for a package": "Rechercher un paquet",
+
+		// Tabs.
+		"Doc":            "Documentation",
+		"Overview":       "Aperçu",
+		"Subdirectories": "Sous-répertoires",
+		"Packages":       "Paquets",
+		"Versions":       "Versions",
+		"Imports":        "Importations",
+		"Imported By":    "Importé par",
+		"Dependencies":   "Dépendances",
+		"Licenses":       "Licences",
+
+		// Unit header.
+		"Version %s":       "Version %s",
+		"Latest":           "Dernière",
+		"Go to latest":     "Aller à la dernière version",
+		"None detected":    "Aucune détectée",
+		"not legal advice": "pas un avis juridique",
+		"Imported by":      "Importé par",
+		"Go to main page":  "Aller à la page principale",
+
+		// Relative times.
+		"1 hour ago":   "il y a 1 heure",
+		"%d hours ago": "il y a %d heures",
+		"today":        "aujourd'hui",
+		"1 day ago":    "il y a 1 jour",
+		"%d days ago":  "il y a %d jours",
+
+		// Error pages.
+		"Bad Request":           "Requête incorrecte",
+		"Not Found":             "Introuvable",
+		"Internal Server Error": "Erreur interne du serveur",
+		"Service Unavailable":   "Service indisponible",
+	},
+}
+
+// cat is the catalog of translations used by Printers.
+var cat = newCatalog(translations)
+
+func newCatalog(translations map[language.Tag]map[string]string) catalog.Catalog {
+	b := catalog.NewBuilder(catalog.Fallback(Default))
+	for tag, msgs := range translations {
+		for key, msg := range msgs {
+			if err := b.SetString(tag, key, msg); err != nil {
+				panic(fmt.Sprintf("i18n: translation of %q into %s: %v", key, tag, err))
+			}
+		}
+	}
+	return b
+}
+
+// Languages returns the languages that have translations, starting with
+// Default. Text is displayed in Default for any other supported language.
+func Languages() []language.Tag {
+	tags := []language.Tag{Default}
+	for _, tag := range supported[1:] {
+		if _, ok := translations[tag]; ok {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// PrinterFor returns a message.Printer that formats values and translates
+// messages for the given language.
+func PrinterFor(tag language.Tag) *message.Printer {
+	return message.NewPrinter(tag, message.Catalog(cat))
+}
@@ -0,0 +1,81 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package i18n chooses the language that pages are rendered for, and formats
+// values for display in it.
+//
+// Text on pkgsite is written in English. The text of the site's chrome is
+// translated into the languages that have entries in the catalog in
+// catalog.go, and numbers are formatted according to the conventions of the
+// chosen language. Documentation and other content from modules is displayed
+// as-is.
+package i18n
+
+import (
+	"context"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// Default is the language used when a request does not ask for a supported
+// one.
+var Default = language.English
+
+// supported are the languages that pages may be rendered for. The first is
+// the default.
+var supported = []language.Tag{
+	Default,
+	language.German,
+	language.Spanish,
+	language.French,
+	language.Japanese,
+	language.Korean,
+	language.BrazilianPortuguese,
+	language.Russian,
+	language.SimplifiedChinese,
+}
+
+var matcher = language.NewMatcher(supported)
+
+// Match returns the supported language that best matches the value of an
+// Accept-Language header.
+func Match(acceptLanguage string) language.Tag {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return Default
+	}
+	_, i, conf := matcher.Match(tags...)
+	if conf == language.No {
+		return Default
+	}
+	return supported[i]
+}
+
+type contextKey struct{}
+
+// NewContext returns a context that holds the given language.
+func NewContext(ctx context.Context, tag language.Tag) context.Context {
+	return context.WithValue(ctx, contextKey{}, tag)
+}
+
+// FromContext returns the language stored in ctx, or Default if there is
+// none.
+func FromContext(ctx context.Context) language.Tag {
+	if tag, ok := ctx.Value(contextKey{}).(language.Tag); ok {
+		return tag
+	}
+	return Default
+}
+
+// Printer returns a message.Printer for the language stored in ctx.
+func Printer(ctx context.Context) *message.Printer {
+	return PrinterFor(FromContext(ctx))
+}
+
+// FormatCount formats n for the language stored in ctx, with digits grouped
+// as in "1,234".
+func FormatCount(ctx context.Context, n int) string {
+	return Printer(ctx).Sprintf("%d", n)
+}
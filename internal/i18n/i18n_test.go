@@ -0,0 +1,88 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package i18n
+
+import (
+	"context"
+	"testing"
+
+	"golang.org/x/text/language"
+)
+
+func TestMatch(t *testing.T) {
+	for _, test := range []struct {
+		header string
+		want   language.Tag
+	}{
+		{"", Default},
+		{"garbage;;q=x", Default},
+		{"en-US,en;q=0.9", language.English},
+		{"de-CH, de;q=0.9, en;q=0.8", language.German},
+		{"fr;q=0.5, ja", language.Japanese},
+		{"xx", Default},
+	} {
+		if got := Match(test.header); got != test.want {
+			t.Errorf("Match(%q) = %s, want %s", test.header, got, test.want)
+		}
+	}
+}
+
+func TestFormatCount(t *testing.T) {
+	ctx := context.Background()
+	for _, test := range []struct {
+		tag  language.Tag
+		n    int
+		want string
+	}{
+		{Default, 12, "12"},
+		{Default, 1234, "1,234"},
+		{language.German, 1234567, "1.234.567"},
+	} {
+		if got := FormatCount(NewContext(ctx, test.tag), test.n); got != test.want {
+			t.Errorf("FormatCount(%s, %d) = %q, want %q", test.tag, test.n, got, test.want)
+		}
+	}
+	if got := FormatCount(ctx, 1234); got != "1,234" {
+		t.Errorf("FormatCount with no language = %q, want %q", got, "1,234")
+	}
+}
+
+func TestPrinterTranslates(t *testing.T) {
+	for _, test := range []struct {
+		tag  language.Tag
+		want string
+	}{
+		{Default, "3 days ago"},
+		{language.German, "vor 3 Tagen"},
+		{language.French, "il y a 3 jours"},
+		// Japanese has no translations, so the English text is used.
+		{language.Japanese, "3 days ago"},
+	} {
+		if got := PrinterFor(test.tag).Sprintf("%d days ago", 3); got != test.want {
+			t.Errorf("%s: got %q, want %q", test.tag, got, test.want)
+		}
+	}
+}
+
+func TestTranslationsComplete(t *testing.T) {
+	if got := Languages(); len(got) != len(translations)+1 || got[0] != Default {
+		t.Errorf("Languages() = %v, want Default followed by the %d translated languages", got, len(translations))
+	}
+	// Every language should translate the same messages, so that none is
+	// left partly in English.
+	keys := translations[language.German]
+	for tag, msgs := range translations {
+		for key := range keys {
+			if _, ok := msgs[key]; !ok {
+				t.Errorf("%s: missing translation of %q", tag, key)
+			}
+		}
+		for key := range msgs {
+			if _, ok := keys[key]; !ok {
+				t.Errorf("%s: %q is not translated into %s", tag, key, language.German)
+			}
+		}
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"golang.org/x/net/context/ctxhttp"
 	"golang.org/x/pkgsite/internal"
 	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/log"
 )
 
 // A Client is used by the worker service to communicate with the module index.
@@ -47,7 +48,7 @@ func New(rawurl string) (_ *Client, err error) {
 
 func (c *Client) pollURL(since time.Time, limit int) string {
 	values := url.Values{}
-	values.Set("since", since.Format(time.RFC3339))
+	values.Set("since", since.Format(time.RFC3339Nano))
 	if limit > 0 {
 		values.Set("limit", strconv.Itoa(limit))
 	}
@@ -57,14 +58,87 @@ func (c *Client) pollURL(since time.Time, limit int) string {
 // GetVersions queries the index for new versions.
 func (c *Client) GetVersions(ctx context.Context, since time.Time, limit int) (_ []*internal.IndexVersion, err error) {
 	defer derrors.Wrap(&err, "index.Client.GetVersions(ctx, %s, %d)", since, limit)
+	return c.getPage(ctx, since, limit)
+}
+
+// streamPageSize is the number of versions Stream requests at a time. It is
+// the most that the module index returns for a single request. It is mutable
+// for testing purposes.
+var streamPageSize = 2000
+
+// Stream calls f on each version published to the index at or after since, in
+// the order the index reports them, requesting further pages from the index
+// until it is exhausted or f has been called limit times. A limit of zero or
+// less means no limit. It stops early if f returns an error or ctx is done,
+// and returns that error.
+func (c *Client) Stream(ctx context.Context, since time.Time, limit int, f func(*internal.IndexVersion) error) (err error) {
+	defer derrors.Wrap(&err, "index.Client.Stream(ctx, %s, %d)", since, limit)
+
+	pageSize := streamPageSize
+	if limit > 0 && limit < pageSize {
+		pageSize = limit
+	}
+	// Each page starts at the timestamp of the last version of the previous
+	// page, so versions with that timestamp may appear twice.
+	seen := map[string]bool{}
+	total := 0
+	for {
+		versions, err := c.getPage(ctx, since, pageSize)
+		if err != nil {
+			return err
+		}
+		n := 0
+		for _, v := range versions {
+			key := v.Path + "@" + v.Version
+			if v.Timestamp.Before(since) {
+				continue
+			}
+			if v.Timestamp.Equal(since) {
+				if seen[key] {
+					continue
+				}
+			} else {
+				since = v.Timestamp
+				seen = map[string]bool{}
+			}
+			seen[key] = true
+			n++
+			if err := f(v); err != nil {
+				return err
+			}
+			total++
+			if total == limit {
+				return nil
+			}
+		}
+		if len(versions) < pageSize {
+			return nil
+		}
+		if n == 0 {
+			// The whole page has the same timestamp, and we have seen it
+			// all. Move past it, at the risk of missing some versions, rather
+			// than asking for the same page forever.
+			log.Warningf(ctx, "index.Client.Stream: more than %d versions at %s", pageSize, since)
+			since = since.Add(time.Nanosecond)
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+}
 
+// getPage returns up to limit versions published to the index at or after
+// since.
+func (c *Client) getPage(ctx context.Context, since time.Time, limit int) (_ []*internal.IndexVersion, err error) {
 	u := c.pollURL(since, limit)
 	r, err := ctxhttp.Get(ctx, c.httpClient, u)
 	if err != nil {
 		return nil, fmt.Errorf("ctxhttp.Get(ctx, nil, %q): %v", u, err)
 	}
 	defer r.Body.Close()
-
+	if r.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ctxhttp.Get(ctx, nil, %q): %s", u, r.Status)
+	}
 	var versions []*internal.IndexVersion
 	dec := json.NewDecoder(r.Body)
 
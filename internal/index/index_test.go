@@ -6,6 +6,7 @@ package index
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -59,3 +60,63 @@ func TestGetVersions(t *testing.T) {
 		})
 	}
 }
+
+func TestStream(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	defer func(n int) { streamPageSize = n }(streamPageSize)
+	streamPageSize = 2
+
+	t0 := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	versions := []*internal.IndexVersion{
+		{Path: "a.com/m", Version: "v1.0.0", Timestamp: t0},
+		{Path: "b.com/m", Version: "v1.0.0", Timestamp: t0.Add(time.Second)},
+		{Path: "c.com/m", Version: "v1.0.0", Timestamp: t0.Add(time.Second)},
+		{Path: "d.com/m", Version: "v1.0.0", Timestamp: t0.Add(2 * time.Second)},
+		{Path: "e.com/m", Version: "v1.0.0", Timestamp: t0.Add(3 * time.Second)},
+	}
+	client, teardown := SetupTestIndex(t, versions)
+	defer teardown()
+
+	for _, test := range []struct {
+		name  string
+		since time.Time
+		limit int
+		want  []*internal.IndexVersion
+	}{
+		{"all", time.Time{}, 0, versions},
+		{"since", t0.Add(time.Second), 0, versions[1:]},
+		{"none", t0.Add(time.Hour), 0, nil},
+		{"limit", time.Time{}, 3, versions[:3]},
+		{"small limit", t0.Add(time.Second), 1, versions[1:2]},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var got []*internal.IndexVersion
+			if err := client.Stream(ctx, test.since, test.limit, func(v *internal.IndexVersion) error {
+				got = append(got, v)
+				return nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("stop", func(t *testing.T) {
+		errStop := errors.New("stop")
+		n := 0
+		err := client.Stream(ctx, time.Time{}, 0, func(*internal.IndexVersion) error {
+			n++
+			if n == 3 {
+				return errStop
+			}
+			return nil
+		})
+		if !errors.Is(err, errStop) || n != 3 {
+			t.Errorf("got (%v, %d), want (%v, 3)", err, n, errStop)
+		}
+	})
+}
@@ -9,14 +9,16 @@ import (
 	"net/http"
 	"strconv"
 	"testing"
+	"time"
 
 	"golang.org/x/pkgsite/internal"
 	"golang.org/x/pkgsite/internal/testing/testhelper"
 )
 
 // SetupTestIndex creates a module index for testing using the given version
-// map for data.  It returns a function for tearing down the index server after
-// the test is completed, and a Client for interacting with the test index.
+// map for data, which should be sorted by timestamp.  It returns a function
+// for tearing down the index server after the test is completed, and a Client
+// for interacting with the test index.
 func SetupTestIndex(t *testing.T, versions []*internal.IndexVersion) (*Client, func()) {
 	t.Helper()
 
@@ -30,9 +32,25 @@ func SetupTestIndex(t *testing.T, versions []*internal.IndexVersion) (*Client, f
 					t.Fatalf("error parsing limit parameter: %v", err)
 				}
 			}
+			var since time.Time
+			if sinceParam := r.FormValue("since"); sinceParam != "" {
+				var err error
+				since, err = time.Parse(time.RFC3339, sinceParam)
+				if err != nil {
+					t.Fatalf("error parsing since parameter: %v", err)
+				}
+			}
 			w.Header().Set("Content-Type", "application/json")
-			for i := 0; i < limit && i < len(versions); i++ {
-				json.NewEncoder(w).Encode(versions[i])
+			n := 0
+			for _, v := range versions {
+				if n == limit {
+					break
+				}
+				if v.Timestamp.Before(since) {
+					continue
+				}
+				json.NewEncoder(w).Encode(v)
+				n++
 			}
 		}))
 
@@ -23,6 +23,8 @@ type LegacyPackage struct {
 	Path              string
 	Name              string
 	Synopsis          string
+	SynopsisInferred  bool // Synopsis was derived from a README, not a doc comment
+	Kind              PackageKind
 	IsRedistributable bool
 	Licenses          []*licenses.Metadata // metadata of applicable licenses
 	Imports           []string
@@ -66,6 +66,14 @@ type Metadata struct {
 	Coverage licensecheck.Coverage
 }
 
+// Scope returns the '/'-separated directory to which the license applies,
+// relative to the contents directory: the directory containing the license
+// file, along with its subdirectories. It is "." for licenses at the module
+// root.
+func (m *Metadata) Scope() string {
+	return path.Dir(m.FilePath)
+}
+
 // A License is a classified license file path and its contents.
 type License struct {
 	*Metadata
@@ -283,7 +291,9 @@ func (d *Detector) AllLicenses() []*License {
 
 // PackageInfo reports whether the package at dir, a directory relative to the
 // module root, is redistributable. It also returns all the licenses that apply
-// to the package.
+// to the package, ordered by scope from the package's directory up to the
+// module root, so that they describe the chain of directories whose licenses
+// apply to the package.
 func (d *Detector) PackageInfo(dir string) (isRedistributable bool, lics []*License) {
 	cleanDir := filepath.ToSlash(filepath.Clean(dir))
 	if path.IsAbs(cleanDir) || strings.HasPrefix(cleanDir, "..") {
@@ -299,6 +309,14 @@ func (d *Detector) PackageInfo(dir string) (isRedistributable bool, lics []*Lice
 			lics = append(lics, plics...)
 		}
 	}
+	// Order the licenses from the innermost scope outwards.
+	sort.Slice(lics, func(i, j int) bool {
+		si, sj := lics[i].Scope(), lics[j].Scope()
+		if si != sj {
+			return len(si) > len(sj)
+		}
+		return lics[i].FilePath < lics[j].FilePath
+	})
 	// A package is redistributable if its module is, and if other licenses on
 	// the path to the root are redistributable. Note that this is not the same
 	// as asking if the module licenses plus the package licenses are
@@ -654,6 +654,13 @@ func TestPackageInfo(t *testing.T) {
 			if diff := cmp.Diff(test.wantMetas, gotMetas, opts...); diff != "" {
 				t.Errorf("mismatch (-want +got):\n%s", diff)
 			}
+			// Each license's scope must enclose the scope of the one before it.
+			for i := 1; i < len(gotMetas); i++ {
+				inner, outer := gotMetas[i-1].Scope(), gotMetas[i].Scope()
+				if outer != "." && !strings.HasPrefix(inner+"/", outer+"/") {
+					t.Errorf("license scopes out of order: %q before %q", inner, outer)
+				}
+			}
 		})
 	}
 }
@@ -8,10 +8,14 @@ import (
 	"bytes"
 	"compress/gzip"
 	"context"
+	"encoding/binary"
 	"fmt"
 	"io"
 	"net/http"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-redis/redis/v7"
@@ -20,7 +24,11 @@ import (
 	"go.opencensus.io/stats/view"
 	"go.opencensus.io/tag"
 	"golang.org/x/pkgsite/internal/config"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/experiment"
+	"golang.org/x/pkgsite/internal/i18n"
 	"golang.org/x/pkgsite/internal/log"
+	"golang.org/x/pkgsite/internal/preferences"
 )
 
 var (
@@ -91,6 +99,7 @@ type cache struct {
 	client     *redis.Client
 	delegate   http.Handler
 	expirer    Expirer
+	stale      time.Duration
 }
 
 // An Expirer computes the TTL that should be used when caching a page.
@@ -106,11 +115,13 @@ func TTL(ttl time.Duration) Expirer {
 // Cache returns a new Middleware that caches every request.
 // The name of the cache is used only for metrics.
 // The expirer is a func that is used to map a new request to its TTL.
+// For the duration stale after a page expires, it is still served from the
+// cache, while it is rendered again in the background.
 // authHeader is the header key used by the cache to know that a
 // request should bypass the cache.
 // authValues is the set of values that could be set on the authHeader in
 // order to bypass the cache.
-func Cache(name string, client *redis.Client, expirer Expirer, authValues []string) Middleware {
+func Cache(name string, client *redis.Client, expirer Expirer, stale time.Duration, authValues []string) Middleware {
 	return func(h http.Handler) http.Handler {
 		return &cache{
 			name:       name,
@@ -118,6 +129,7 @@ func Cache(name string, client *redis.Client, expirer Expirer, authValues []stri
 			client:     client,
 			delegate:   h,
 			expirer:    expirer,
+			stale:      stale,
 		}
 	}
 }
@@ -132,36 +144,121 @@ func (c *cache) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 	ctx := r.Context()
-	key := r.URL.String()
+	key := cacheKey(r)
 	start := time.Now()
-	reader, hit := c.get(ctx, key)
+	reader, fresh, hit := c.get(ctx, key)
 	recordCacheResult(ctx, c.name, hit, time.Since(start))
 	if hit {
+		if !fresh {
+			c.revalidate(r, key)
+		}
 		if _, err := io.Copy(w, reader); err != nil {
 			log.Errorf(ctx, "error copying zip bytes: %v", err)
 		}
 		return
 	}
+	c.serveAndPut(w, r, key)
+}
+
+// cacheKey returns the key of the response to r. Requests for the same page
+// with query parameters in a different order share a key, while the same
+// page is cached separately for each language, set of user preferences and
+// set of active experiments, since it is rendered differently for each.
+func cacheKey(r *http.Request) string {
+	ctx := r.Context()
+	key := r.URL.Path
+	if q := r.URL.Query(); len(q) > 0 {
+		// Encode sorts the parameters by name.
+		key += "?" + q.Encode()
+	}
+	if tag := i18n.FromContext(ctx); tag != i18n.Default {
+		key += " lang=" + tag.String()
+	}
+	if k := preferences.FromContext(ctx).CacheKey(); k != "" {
+		key += " prefs=" + k
+	}
+	if exps := experiment.FromContext(ctx).Active(); len(exps) > 0 {
+		sort.Strings(exps)
+		key += " exp=" + strings.Join(exps, ",")
+	}
+	return key
+}
+
+// serveAndPut serves r with the delegate, and caches the response if it is
+// successful.
+func (c *cache) serveAndPut(w http.ResponseWriter, r *http.Request, key string) {
+	ctx := r.Context()
+	modules := &cachedModules{}
+	r = r.WithContext(context.WithValue(ctx, cachedModulesKey{}, modules))
 	rec := newRecorder(w)
 	c.delegate.ServeHTTP(rec, r)
 	if rec.bufErr == nil && (rec.statusCode == 0 || rec.statusCode == http.StatusOK) {
 		ttl := c.expirer(r)
 		if testMode {
-			c.put(ctx, key, rec, ttl)
+			c.put(ctx, key, rec, ttl, modules.paths)
 		} else {
-			go c.put(ctx, key, rec, ttl)
+			go c.put(ctx, key, rec, ttl, modules.paths)
 		}
 	}
 }
 
-func (c *cache) get(ctx context.Context, key string) (io.Reader, bool) {
+// revalidate renders the page of r again in the background, and caches it
+// under key. So that a popular page is rendered once however many requests
+// it gets, it does nothing if the page is already being rendered.
+func (c *cache) revalidate(r *http.Request, key string) {
+	ctx := r.Context()
+	lockCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	ok, err := c.client.WithContext(lockCtx).SetNX(revalidationKeyPrefix+key, 1, revalidationTimeout).Result()
+	if err != nil {
+		log.Infof(ctx, "cache revalidate(%q): %v", key, err)
+		recordCacheError(ctx, c.name, "SETNX")
+		return
+	}
+	if !ok {
+		return
+	}
+	// The request's context is canceled once it has been served, so render
+	// the page with one that keeps its values but not its cancelation.
+	bctx, cancel := context.WithTimeout(detachedContext{ctx}, revalidationTimeout)
+	r = r.Clone(bctx)
+	f := func() {
+		defer cancel()
+		c.serveAndPut(&discardResponseWriter{header: http.Header{}}, r, key)
+		if err := c.client.WithContext(bctx).Del(revalidationKeyPrefix + key).Err(); err != nil {
+			log.Warningf(ctx, "cache revalidate(%q): %v", key, err)
+		}
+	}
+	if testMode {
+		f()
+	} else {
+		go f()
+	}
+}
+
+const (
+	// revalidationKeyPrefix is the prefix of the key that is set while a
+	// stale page is rendered again.
+	revalidationKeyPrefix = "revalidate "
+	// revalidationTimeout bounds the time it takes to render a stale page
+	// again.
+	revalidationTimeout = 1 * time.Minute
+	// moduleKeyPrefix is the prefix of the keys of the sets of cache keys
+	// whose responses show a module. Cache keys themselves start with "/".
+	moduleKeyPrefix = "module "
+)
+
+// get returns a reader of the cached response for key, and whether the
+// response is still fresh. The last result reports whether the response was
+// in the cache.
+func (c *cache) get(ctx context.Context, key string) (_ io.Reader, fresh, hit bool) {
 	// Set a short timeout for redis requests, so that we can quickly
 	// fall back to un-cached serving if redis is unavailable.
 	getCtx, cancelGet := context.WithTimeout(ctx, 100*time.Millisecond)
 	defer cancelGet()
 	val, err := c.client.WithContext(getCtx).Get(key).Bytes()
 	if err == redis.Nil {
-		return nil, false
+		return nil, false, false
 	}
 	if err != nil {
 		select {
@@ -171,32 +268,130 @@ func (c *cache) get(ctx context.Context, key string) (io.Reader, bool) {
 			log.Infof(ctx, "cache get(%q): %v", key, err)
 		}
 		recordCacheError(ctx, c.name, "GET")
-		return nil, false
+		return nil, false, false
+	}
+	// The value is the time until which the response is fresh, in Unix
+	// seconds, followed by the gzipped response.
+	if len(val) < 8 {
+		log.Errorf(ctx, "cache: value of %q is too short", key)
+		recordCacheError(ctx, c.name, "UNZIP")
+		return nil, false, false
 	}
-	zr, err := gzip.NewReader(bytes.NewReader(val))
+	freshUntil := time.Unix(int64(binary.BigEndian.Uint64(val)), 0)
+	zr, err := gzip.NewReader(bytes.NewReader(val[8:]))
 	if err != nil {
 		log.Errorf(ctx, "cache: gzip.NewReader: %v", err)
 		recordCacheError(ctx, c.name, "UNZIP")
-		return nil, false
+		return nil, false, false
 	}
-	return zr, true
+	return zr, time.Now().Before(freshUntil), true
 }
 
-func (c *cache) put(ctx context.Context, key string, rec *cacheRecorder, ttl time.Duration) {
+// put caches the response recorded by rec under key. It is fresh for ttl,
+// and stays in the cache for the stale period of c after that. It also
+// records key in the sets of the given modules, so that it can be
+// invalidated when they change.
+func (c *cache) put(ctx context.Context, key string, rec *cacheRecorder, ttl time.Duration, modulePaths []string) {
 	if err := rec.zipWriter.Close(); err != nil {
 		log.Errorf(ctx, "cache: error closing zip for %q: %v", key, err)
 		return
 	}
 	log.Infof(ctx, "caching response of length %d for %s", rec.buf.Len(), key)
+	val := make([]byte, 8, 8+rec.buf.Len())
+	binary.BigEndian.PutUint64(val, uint64(time.Now().Add(ttl).Unix()))
+	val = append(val, rec.buf.Bytes()...)
+	expiration := ttl + c.stale
+
 	setCtx, cancelSet := context.WithTimeout(context.Background(), 1*time.Second)
 	defer cancelSet()
-	_, err := c.client.WithContext(setCtx).Set(key, rec.buf.Bytes(), ttl).Result()
+	_, err := c.client.WithContext(setCtx).TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.Set(key, val, expiration)
+		for _, p := range modulePaths {
+			mkey := moduleKeyPrefix + p
+			pipe.SAdd(mkey, key)
+			// The set needs to last as long as any of its keys.
+			pipe.Expire(mkey, maxModuleKeyTTL)
+		}
+		return nil
+	})
 	if err != nil {
 		recordCacheError(ctx, c.name, "SET")
 		log.Warningf(ctx, "cache set %q: %v", key, err)
 	}
 }
 
+// maxModuleKeyTTL is how long a set of the cache keys of a module lasts after
+// it was last added to. It must be at least the longest time that a response
+// is cached.
+const maxModuleKeyTTL = 7 * 24 * time.Hour
+
+type cachedModulesKey struct{}
+
+// cachedModules holds the paths of the modules that a response shows.
+type cachedModules struct {
+	mu    sync.Mutex
+	paths []string
+}
+
+// RecordCachedModule records that the response being served for the request
+// with ctx shows the module with the given path, so that it is removed from
+// the cache by InvalidateModuleCache when a version of the module is
+// inserted or deleted. It does nothing if the response is not being cached.
+func RecordCachedModule(ctx context.Context, modulePath string) {
+	m, ok := ctx.Value(cachedModulesKey{}).(*cachedModules)
+	if !ok {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.paths = append(m.paths, modulePath)
+}
+
+// InvalidateModuleCache removes from the cache the responses that show the
+// module with the given path, as recorded by RecordCachedModule. It returns
+// the number of responses removed.
+func InvalidateModuleCache(ctx context.Context, client *redis.Client, modulePath string) (_ int, err error) {
+	defer derrors.Wrap(&err, "InvalidateModuleCache(%q)", modulePath)
+
+	client = client.WithContext(ctx)
+	mkey := moduleKeyPrefix + modulePath
+	keys, err := client.SMembers(mkey).Result()
+	if err != nil {
+		return 0, err
+	}
+	// Responses are cached for at most maxModuleKeyTTL, so some keys may no
+	// longer exist; deleting those does no harm.
+	n, err := client.Del(append(keys, mkey)...).Result()
+	if err != nil {
+		return 0, err
+	}
+	if n > 0 && len(keys) > 0 {
+		// Don't count the set itself.
+		n--
+	}
+	return int(n), nil
+}
+
+// detachedContext is a context with the values of its parent, which is
+// never canceled.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool)         { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}               { return nil }
+func (detachedContext) Err() error                          { return nil }
+func (c detachedContext) Value(key interface{}) interface{} { return c.parent.Value(key) }
+
+// discardResponseWriter is an http.ResponseWriter that discards the response.
+type discardResponseWriter struct {
+	header http.Header
+}
+
+func (w *discardResponseWriter) Header() http.Header         { return w.header }
+func (w *discardResponseWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w *discardResponseWriter) WriteHeader(int)             {}
+
 func newRecorder(w http.ResponseWriter) *cacheRecorder {
 	buf := &bytes.Buffer{}
 	zw := gzip.NewWriter(buf)
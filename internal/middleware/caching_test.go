@@ -5,6 +5,7 @@
 package middleware
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -18,6 +19,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"go.opencensus.io/stats/view"
 	"golang.org/x/pkgsite/internal/config"
+	"golang.org/x/pkgsite/internal/experiment"
 )
 
 func TestCache(t *testing.T) {
@@ -44,7 +46,7 @@ func TestCache(t *testing.T) {
 
 	c := redis.NewClient(&redis.Options{Addr: s.Addr()})
 	mux := http.NewServeMux()
-	mux.Handle("/A", Cache("A", c, TTL(1*time.Minute), []string{"yes"})(handler))
+	mux.Handle("/A", Cache("A", c, TTL(1*time.Minute), 0, []string{"yes"})(handler))
 	mux.Handle("/B", handler)
 	ts := httptest.NewServer(mux)
 	view.Register(CacheResultCount)
@@ -178,3 +180,81 @@ func TestCache(t *testing.T) {
 		}
 	}
 }
+
+func TestCacheStaleWhileRevalidate(t *testing.T) {
+	testMode = true
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	c := redis.NewClient(&redis.Options{Addr: s.Addr()})
+
+	var n int
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n++
+		RecordCachedModule(r.Context(), "example.com/m")
+		fmt.Fprint(w, n)
+	})
+	// Pages expire at once, but stay in the cache for a minute after that.
+	ts := httptest.NewServer(Cache("A", c, TTL(0), time.Minute, nil)(handler))
+	defer ts.Close()
+
+	get := func(want string) {
+		t.Helper()
+		resp, err := ts.Client().Get(ts.URL + "/A")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(body) != want {
+			t.Errorf("got body %q, want %q", body, want)
+		}
+	}
+	get("1")
+	// The stale page is served, and rendered again for the next request.
+	get("1")
+	get("2")
+
+	got, err := InvalidateModuleCache(context.Background(), c, "example.com/m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != 1 {
+		t.Errorf("InvalidateModuleCache removed %d pages, want 1", got)
+	}
+	get("4")
+
+	// Once the stale period is over, the page is rendered again before it is
+	// served.
+	s.FastForward(2 * time.Minute)
+	get("5")
+}
+
+func TestCacheKey(t *testing.T) {
+	key := func(target string, exps ...string) string {
+		r := httptest.NewRequest("GET", target, nil)
+		if len(exps) > 0 {
+			r = r.WithContext(experiment.NewContext(r.Context(), exps...))
+		}
+		return cacheKey(r)
+	}
+	for _, test := range []struct {
+		target string
+		exps   []string
+		want   string
+	}{
+		{"/p", nil, "/p"},
+		{"/p?tab=doc&b=1", nil, "/p?b=1&tab=doc"},
+		{"/p?b=1&tab=doc", nil, "/p?b=1&tab=doc"},
+		{"/search?q=a+b", []string{"y", "x"}, "/search?q=a+b exp=x,y"},
+	} {
+		if got := key(test.target, test.exps...); got != test.want {
+			t.Errorf("cacheKey(%q, %v) = %q, want %q", test.target, test.exps, got, test.want)
+		}
+	}
+}
@@ -6,6 +6,8 @@ package middleware
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"hash/fnv"
 	"net/http"
@@ -19,7 +21,22 @@ import (
 	"golang.org/x/pkgsite/internal/log"
 )
 
-const experimentQueryParamKey = "experiment"
+const (
+	experimentQueryParamKey = "experiment"
+
+	// experimentBucketHeader is a request header whose value, if present,
+	// determines the experiments that a request is enrolled in, instead of
+	// the experimentBucketCookie or the IP address. It lets clients other
+	// than browsers, and tests, see the site consistently.
+	experimentBucketHeader = "X-Pkgsite-Bucket"
+
+	// experimentBucketCookie is the cookie that holds a random identifier of
+	// a browser, so that its requests are enrolled in the same experiments
+	// even when its IP address changes.
+	experimentBucketCookie = "pkgsite-bucket"
+
+	experimentBucketCookieMaxAge = 365 * 24 * 60 * 60 // a year, in seconds
+)
 
 // A Reporter sends errors to the Error-Reporting service.
 type Reporter interface {
@@ -61,6 +78,7 @@ func NewExperimenter(ctx context.Context, pollEvery time.Duration, getter Experi
 func Experiment(e *Experimenter) Middleware {
 	return func(h http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r = e.assignBucket(w, r)
 			r2 := e.setExperimentsForRequest(r)
 			h.ServeHTTP(w, r2)
 		})
@@ -81,8 +99,56 @@ func (e *Experimenter) Experiments() []*internal.Experiment {
 	return exps
 }
 
+// assignBucket sets the experimentBucketCookie to a new random identifier
+// if a request has neither it nor the experimentBucketHeader, and some
+// experiment is rolled out to only part of the requests. It returns a request
+// with the cookie, so that the experiments of the first request from a
+// browser are the same as those of the following ones.
+func (e *Experimenter) assignBucket(w http.ResponseWriter, r *http.Request) *http.Request {
+	if r.Header.Get(experimentBucketHeader) != "" {
+		return r
+	}
+	if _, err := r.Cookie(experimentBucketCookie); err == nil {
+		return r
+	}
+	if !e.hasPartialRollout() {
+		return r
+	}
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		// Fall back to the IP address.
+		log.Errorf(r.Context(), "assignBucket: %v", err)
+		return r
+	}
+	c := &http.Cookie{
+		Name:     experimentBucketCookie,
+		Value:    hex.EncodeToString(id),
+		Path:     "/",
+		MaxAge:   experimentBucketCookieMaxAge,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+	http.SetCookie(w, c)
+	r2 := r.Clone(r.Context())
+	r2.AddCookie(c)
+	return r2
+}
+
+// hasPartialRollout reports whether some experiment is rolled out to some
+// requests but not all.
+func (e *Experimenter) hasPartialRollout() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, exp := range e.snapshot {
+		if exp.Rollout > 0 && exp.Rollout < 100 {
+			return true
+		}
+	}
+	return false
+}
+
 // setExperimentsForRequest sets the experiments for a given request.
-// Experiments should be stable for a given IP address.
+// Experiments should be stable for a given bucket; see bucketKey.
 func (e *Experimenter) setExperimentsForRequest(r *http.Request) *http.Request {
 	e.mu.Lock()
 	defer e.mu.Unlock()
@@ -139,10 +205,10 @@ func (e *Experimenter) loadNextSnapshot(ctx context.Context) (err error) {
 }
 
 // shouldSetExperiment reports whether a given request should be enrolled in
-// the experiment, based on the ip. e.Name, and e.Rollout.
+// the experiment, based on its bucket key, e.Name, and e.Rollout.
 //
-// Requests from empty ip addresses are never enrolled.
-// All requests from the same IP will be enrolled in the same set of
+// Requests with an empty bucket key are never enrolled.
+// All requests with the same bucket key will be enrolled in the same set of
 // experiments.
 func shouldSetExperiment(r *http.Request, e *internal.Experiment) bool {
 	if e.Rollout == 0 {
@@ -151,11 +217,24 @@ func shouldSetExperiment(r *http.Request, e *internal.Experiment) bool {
 	if e.Rollout >= 100 {
 		return true
 	}
-	ip := ipKey(r.Header.Get("X-Forwarded-For"))
-	if ip == "" {
+	key := bucketKey(r)
+	if key == "" {
 		return false
 	}
 	h := fnv.New32a()
-	fmt.Fprintf(h, "%s %s", ip, e.Name)
+	fmt.Fprintf(h, "%s %s", key, e.Name)
 	return uint(h.Sum32())%100 < e.Rollout
 }
+
+// bucketKey returns the key that determines which experiments r is enrolled
+// in: the value of the experimentBucketHeader, or else of the
+// experimentBucketCookie, or else the IP address of the client.
+func bucketKey(r *http.Request) string {
+	if b := r.Header.Get(experimentBucketHeader); b != "" {
+		return "header " + b
+	}
+	if c, err := r.Cookie(experimentBucketCookie); err == nil && c.Value != "" {
+		return "cookie " + c.Value
+	}
+	return ipKey(r.Header.Get("X-Forwarded-For"))
+}
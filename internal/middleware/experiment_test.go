@@ -123,3 +123,63 @@ func TestShouldSetExperiment(t *testing.T) {
 		})
 	}
 }
+
+func TestExperimentBuckets(t *testing.T) {
+	ctx := context.Background()
+	const testFeature = "test-feature"
+	getter := func(context.Context) ([]*internal.Experiment, error) {
+		return []*internal.Experiment{{Name: testFeature, Rollout: 50}}, nil
+	}
+	experimenter, err := NewExperimenter(ctx, time.Hour, getter, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var active bool
+	handler := Experiment(experimenter)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		active = experiment.IsActive(r.Context(), testFeature)
+	}))
+
+	// A request without a bucket is given a cookie, and later requests with
+	// the cookie are enrolled in the same experiments.
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != experimentBucketCookie || cookies[0].Value == "" {
+		t.Fatalf("got cookies %v, want a %s cookie", cookies, experimentBucketCookie)
+	}
+	first := active
+	for i := 0; i < 10; i++ {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("X-Forwarded-For", fmt.Sprintf("10.0.0.%d", i))
+		req.AddCookie(cookies[0])
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if active != first {
+			t.Fatalf("request %d with the same cookie: got active %t, want %t", i, active, first)
+		}
+		if len(w.Result().Cookies()) != 0 {
+			t.Errorf("request %d with a cookie: got new cookies", i)
+		}
+	}
+
+	// The bucket header takes precedence over the cookie, and is not
+	// replaced by one.
+	var inExperiment int
+	const numBuckets = 1000
+	for i := 0; i < numBuckets; i++ {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set(experimentBucketHeader, strconv.Itoa(i))
+		req.AddCookie(cookies[0])
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if active {
+			inExperiment++
+		}
+		if len(w.Result().Cookies()) != 0 {
+			t.Fatalf("request with a bucket header: got cookies")
+		}
+	}
+	if inExperiment == 0 || inExperiment == numBuckets {
+		t.Errorf("%d of %d buckets enrolled in an experiment with rollout 50", inExperiment, numBuckets)
+	}
+}
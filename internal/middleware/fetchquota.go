@@ -0,0 +1,277 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+	"github.com/golang/groupcache/lru"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	"golang.org/x/pkgsite/internal/config"
+	"golang.org/x/pkgsite/internal/log"
+)
+
+var (
+	keyFetchQuotaResult = tag.MustNewKey("fetch-quota.result")
+	fetchQuotaResults   = stats.Int64(
+		"go-discovery/fetch-quota/result_count",
+		"The result of a fetch quota check.",
+		stats.UnitDimensionless,
+	)
+	// FetchQuotaResultCount is a counter of fetch quota checks, by whether
+	// the fetch was allowed, rejected, or not limited, as for trusted
+	// clients.
+	FetchQuotaResultCount = &view.View{
+		Name:        "go-discovery/fetch-quota/result_count",
+		Measure:     fetchQuotaResults,
+		Aggregation: view.Count(),
+		Description: "fetch quota results, by allowed, rejected or unlimited",
+		TagKeys:     []tag.Key{keyFetchQuotaResult},
+	}
+)
+
+// fetchQuotaWindow is the period over which fetches are counted.
+const fetchQuotaWindow = time.Hour
+
+type fetchQuotaKey struct{}
+
+// fetchQuota counts the module versions that the client of a request has
+// caused to be fetched.
+type fetchQuota struct {
+	store  fetchQuotaStore
+	client string
+}
+
+// FetchQuota returns a Middleware that limits the number of distinct module
+// versions that each client may cause the worker to fetch in an hour, to
+// settings.ModulesPerHour. Fetching a module version that the client has
+// already fetched in the hour doesn't count again. Clients are told apart as
+// by Quota. Clients whose IP address is in settings.Allowlist, or that send
+// one of settings.AuthValues in the config.BypassQuotaAuthHeader header, are
+// not limited.
+//
+// The middleware doesn't limit requests itself: code that is about to cause
+// a fetch calls AllowFetch to find out whether it may.
+//
+// If client is nil, information is kept in an LRU cache of size
+// settings.MaxEntries. Otherwise it is kept in redis, so that the limits are
+// shared by all instances of the server.
+func FetchQuota(settings config.FetchQuotaSettings, client *redis.Client) Middleware {
+	var store fetchQuotaStore
+	if client == nil {
+		store = &memoryFetchQuotaStore{cache: lru.New(settings.MaxEntries), limit: settings.ModulesPerHour}
+	} else {
+		store = &redisFetchQuotaStore{client: client, limit: settings.ModulesPerHour}
+	}
+	allowlist := parseAllowlist(settings.Allowlist)
+
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := clientIP(r, settings.TrustedProxies)
+			if settings.ModulesPerHour <= 0 || trustedClient(r, ip, settings.AuthValues, allowlist) {
+				h.ServeHTTP(w, r)
+				return
+			}
+			key := quotaKey(r, settings.APIKeys, ip)
+			if key == "" {
+				// As with Quota, fail open if we don't know the client.
+				h.ServeHTTP(w, r)
+				return
+			}
+			q := &fetchQuota{store: store, client: key}
+			h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), fetchQuotaKey{}, q)))
+		})
+	}
+}
+
+// AllowFetch reports whether the client of the request with ctx may cause
+// path at version to be fetched, and counts it against the client's quota if
+// so. Path may be a module path, or the path of a package whose module is
+// not yet known; the module versions fetched to find it count once. It always
+// reports true if FetchQuota didn't limit the request.
+func AllowFetch(ctx context.Context, path, version string) bool {
+	q, ok := ctx.Value(fetchQuotaKey{}).(*fetchQuota)
+	if !ok {
+		recordFetchQuotaMetric(ctx, "unlimited")
+		return true
+	}
+	allowed, err := q.store.add(ctx, q.client, path+"@"+version, time.Now())
+	if err != nil {
+		// Fail open if the store is unavailable.
+		log.Warningf(ctx, "AllowFetch(%q, %q): %v", path, version, err)
+		allowed = true
+	}
+	if !allowed {
+		log.Infof(ctx, "fetch quota: rejected fetch of %s@%s by %s", path, version, q.client)
+		recordFetchQuotaMetric(ctx, "rejected")
+		return false
+	}
+	recordFetchQuotaMetric(ctx, "allowed")
+	return true
+}
+
+func recordFetchQuotaMetric(ctx context.Context, result string) {
+	stats.RecordWithTags(ctx, []tag.Mutator{
+		tag.Upsert(keyFetchQuotaResult, result),
+	}, fetchQuotaResults.M(1))
+}
+
+// parseAllowlist parses the IP addresses and CIDR ranges in list. It logs
+// and skips the ones it can't parse.
+func parseAllowlist(list []string) []*net.IPNet {
+	var nets []*net.IPNet
+	for _, s := range list {
+		if !strings.Contains(s, "/") {
+			if ip := net.ParseIP(s); ip != nil {
+				bits := 8 * len(ip)
+				if ip4 := ip.To4(); ip4 != nil {
+					ip, bits = ip4, 32
+				}
+				nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+				continue
+			}
+		}
+		_, n, err := net.ParseCIDR(s)
+		if err != nil {
+			log.Errorf(context.Background(), "fetch quota allowlist: %v", err)
+			continue
+		}
+		nets = append(nets, n)
+	}
+	return nets
+}
+
+// trustedClient reports whether the client of r, at address clientIP, is
+// exempt from the fetch quota, because it sent one of authValues or its IP
+// address is in allowlist.
+func trustedClient(r *http.Request, clientIP string, authValues []string, allowlist []*net.IPNet) bool {
+	if authVal := r.Header.Get(config.BypassQuotaAuthHeader); authVal != "" {
+		for _, v := range authValues {
+			if authVal == v {
+				return true
+			}
+		}
+	}
+	ip := net.ParseIP(clientIP)
+	if ip == nil {
+		return false
+	}
+	for _, n := range allowlist {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP returns the IP address of the client of r. Each of the
+// trustedProxies in front of the server appends the address it received the
+// request from to the X-Forwarded-For header, so the client's address is the
+// entry before the last trustedProxies. Earlier entries are sent by the
+// client, so it could claim to be anyone. If there are no more entries than
+// proxies, clientIP returns the first; if there is no header, it returns the
+// address of the connection.
+func clientIP(r *http.Request, trustedProxies int) string {
+	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+		hops := strings.Split(xff, ",")
+		i := len(hops) - 1 - trustedProxies
+		if i < 0 {
+			i = 0
+		}
+		return strings.TrimSpace(hops[i])
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// A fetchQuotaStore keeps the module versions that each client has fetched
+// in the current window.
+type fetchQuotaStore interface {
+	// add adds the module version mv to those of client in the window that
+	// contains now, and reports whether that keeps them within the limit.
+	// If it doesn't, mv is not added.
+	add(ctx context.Context, client, mv string, now time.Time) (bool, error)
+}
+
+// memoryFetchQuotaStore is a fetchQuotaStore that keeps the module versions
+// in memory.
+type memoryFetchQuotaStore struct {
+	mu    sync.Mutex
+	cache *lru.Cache // client to *fetchWindow
+	limit int
+}
+
+type fetchWindow struct {
+	start time.Time
+	mvs   map[string]bool
+}
+
+func (s *memoryFetchQuotaStore) add(_ context.Context, client, mv string, now time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	start := now.Truncate(fetchQuotaWindow)
+	var w *fetchWindow
+	if v, ok := s.cache.Get(client); ok && v.(*fetchWindow).start.Equal(start) {
+		w = v.(*fetchWindow)
+	} else {
+		w = &fetchWindow{start: start, mvs: map[string]bool{}}
+		s.cache.Add(client, w)
+	}
+	if w.mvs[mv] {
+		return true, nil
+	}
+	if len(w.mvs) >= s.limit {
+		return false, nil
+	}
+	w.mvs[mv] = true
+	return true, nil
+}
+
+// redisFetchQuotaStore is a fetchQuotaStore that keeps the module versions in
+// redis, in a set for each client and window.
+type redisFetchQuotaStore struct {
+	client *redis.Client
+	limit  int
+}
+
+func (s *redisFetchQuotaStore) add(ctx context.Context, client, mv string, now time.Time) (_ bool, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	c := s.client.WithContext(ctx)
+	start := now.Truncate(fetchQuotaWindow)
+	key := fmt.Sprintf("fetch-quota %s %d", client, start.Unix())
+	var (
+		added *redis.IntCmd
+		count *redis.IntCmd
+	)
+	if _, err := c.TxPipelined(func(pipe redis.Pipeliner) error {
+		added = pipe.SAdd(key, mv)
+		count = pipe.SCard(key)
+		pipe.ExpireAt(key, start.Add(fetchQuotaWindow))
+		return nil
+	}); err != nil {
+		return false, err
+	}
+	if added.Val() == 0 || count.Val() <= int64(s.limit) {
+		return true, nil
+	}
+	// Adding mv took the client over its limit, so take it back out.
+	if err := c.SRem(key, mv).Err(); err != nil {
+		return false, err
+	}
+	return false, nil
+}
@@ -0,0 +1,109 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v7"
+	"golang.org/x/pkgsite/internal/config"
+)
+
+func TestFetchQuota(t *testing.T) {
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	for _, client := range []*redis.Client{nil, redis.NewClient(&redis.Options{Addr: s.Addr()})} {
+		t.Run(fmt.Sprintf("redis=%t", client != nil), func(t *testing.T) {
+			mw := FetchQuota(config.FetchQuotaSettings{
+				ModulesPerHour: 2,
+				MaxEntries:     10,
+				Allowlist:      []string{"10.0.0.0/8", "192.168.1.1", "bad"},
+				TrustedProxies: 1,
+			}, client)
+			// The handler reports whether the fetch of the module version in
+			// the path is allowed.
+			ts := httptest.NewServer(mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if !AllowFetch(r.Context(), r.URL.Path[1:], "v1.0.0") {
+					w.WriteHeader(http.StatusTooManyRequests)
+				}
+			})))
+			defer ts.Close()
+
+			for _, test := range []struct {
+				ip, module string
+				want       int
+			}{
+				{"1.2.3.4", "a", http.StatusOK},
+				{"1.2.3.4", "b", http.StatusOK},
+				// A third module is too many.
+				{"1.2.3.4", "c", http.StatusTooManyRequests},
+				// A module already fetched doesn't count again.
+				{"1.2.3.4", "a", http.StatusOK},
+				// Other clients have quotas of their own.
+				{"5.6.7.8", "c", http.StatusOK},
+				// Trusted clients are not limited.
+				{"10.1.2.3", "a", http.StatusOK},
+				{"10.1.2.3", "b", http.StatusOK},
+				{"10.1.2.3", "c", http.StatusOK},
+				{"192.168.1.1", "c", http.StatusOK},
+				{"1.2.3.4", "c", http.StatusTooManyRequests},
+				// Only the address added by the load balancer is trusted;
+				// the client can send any others.
+				{"10.1.2.3, 1.2.3.4", "c", http.StatusTooManyRequests},
+				{"1.2.3.4, 10.1.2.3", "d", http.StatusOK},
+			} {
+				req, err := http.NewRequest("GET", ts.URL+"/"+test.module, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				// The load balancer adds the client's address, and then its
+				// own.
+				req.Header.Set("X-Forwarded-For", test.ip+", 35.191.1.1")
+				res, err := ts.Client().Do(req)
+				if err != nil {
+					t.Fatal(err)
+				}
+				res.Body.Close()
+				if res.StatusCode != test.want {
+					t.Errorf("%s fetching %s: got %d, want %d", test.ip, test.module, res.StatusCode, test.want)
+				}
+			}
+		})
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	for _, test := range []struct {
+		xff            string
+		trustedProxies int
+		want           string
+	}{
+		{"", 1, "192.0.2.1"},
+		{"1.2.3.4", 0, "1.2.3.4"},
+		{"1.2.3.4, 35.191.1.1", 1, "1.2.3.4"},
+		{"6.6.6.6, 1.2.3.4, 35.191.1.1", 1, "1.2.3.4"},
+		{"6.6.6.6, 1.2.3.4, 35.191.1.1", 0, "35.191.1.1"},
+		{"6.6.6.6, 1.2.3.4, 10.0.0.1, 35.191.1.1", 2, "1.2.3.4"},
+		// Too few entries: return the first.
+		{"1.2.3.4", 1, "1.2.3.4"},
+	} {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = "192.0.2.1:1234"
+		if test.xff != "" {
+			r.Header.Set("X-Forwarded-For", test.xff)
+		}
+		if got := clientIP(r, test.trustedProxies); got != test.want {
+			t.Errorf("clientIP(%q, %d) = %q, want %q", test.xff, test.trustedProxies, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,314 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/logging"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/log"
+)
+
+// rotatedTimeFormat is the format of the timestamp appended to the name of a
+// rotated log file. Names with it sort in the order the files were rotated.
+const rotatedTimeFormat = "20060102T150405.000000000"
+
+// FileLoggerOptions configures a FileLogger.
+type FileLoggerOptions struct {
+	// MaxSize is the size in bytes beyond which the log file is rotated. If
+	// it is zero, the file is not rotated because of its size.
+	MaxSize int64
+	// MaxAge is how long the log file is written to before it is rotated. If
+	// it is zero, the file is not rotated because of its age.
+	MaxAge time.Duration
+	// MaxBackups is the number of rotated files that are kept. If it is zero,
+	// all of them are kept.
+	MaxBackups int
+	// Compress determines whether rotated files are compressed with gzip.
+	Compress bool
+}
+
+// A FileLogger is a Logger that writes request logs to a file, one JSON
+// object per line. It is meant for deployments that are not on GCP.
+//
+// When the file grows too large or too old, it is renamed by appending a
+// timestamp, optionally compressed, and a new file is started.
+type FileLogger struct {
+	path string
+	opts FileLoggerOptions
+	now  func() time.Time // for testing
+
+	mu     sync.Mutex
+	f      *os.File
+	size   int64
+	opened time.Time
+
+	wg   sync.WaitGroup // tracks calls to cleanUp
+	bgMu sync.Mutex     // serializes calls to cleanUp
+}
+
+// NewFileLogger returns a FileLogger that appends to the file at path,
+// creating it and its directory if necessary.
+func NewFileLogger(path string, opts FileLoggerOptions) (_ *FileLogger, err error) {
+	defer derrors.Wrap(&err, "NewFileLogger(%q)", path)
+	l := &FileLogger{path: path, opts: opts, now: time.Now}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// fileLogEntry is the JSON form of a request log entry. It omits the client's
+// IP address and the request headers, to avoid logging PII.
+type fileLogEntry struct {
+	Time      time.Time `json:"time"`
+	Severity  string    `json:"severity"`
+	Trace     string    `json:"trace,omitempty"`
+	Message   string    `json:"message"`
+	Method    string    `json:"method,omitempty"`
+	URL       string    `json:"url,omitempty"`
+	Status    int       `json:"status,omitempty"`
+	LatencyMS float64   `json:"latencyMs,omitempty"`
+	UserAgent string    `json:"userAgent,omitempty"`
+}
+
+// newFileLogEntry returns the fileLogEntry for entry. If entry has no
+// timestamp, it uses the time returned by now.
+func newFileLogEntry(entry logging.Entry, now func() time.Time) fileLogEntry {
+	e := fileLogEntry{
+		Time:     entry.Timestamp,
+		Severity: entry.Severity.String(),
+		Trace:    entry.Trace,
+		Message:  fmt.Sprint(entry.Payload),
+	}
+	if e.Time.IsZero() {
+		e.Time = now()
+	}
+	if hr := entry.HTTPRequest; hr != nil {
+		e.Status = hr.Status
+		e.LatencyMS = float64(hr.Latency) / float64(time.Millisecond)
+		if r := hr.Request; r != nil {
+			e.Method = r.Method
+			e.URL = r.URL.String()
+			e.UserAgent = r.UserAgent()
+		}
+	}
+	return e
+}
+
+// Log implements the Logger interface. Errors writing to the file are
+// reported in the process's log, since there is no caller to return them to.
+func (l *FileLogger) Log(entry logging.Entry) {
+	line, err := json.Marshal(newFileLogEntry(entry, l.now))
+	if err != nil {
+		log.Errorf(context.Background(), "FileLogger: %v", err)
+		return
+	}
+	line = append(line, '\n')
+	if err := l.write(line); err != nil {
+		log.Errorf(context.Background(), "FileLogger: %v", err)
+	}
+}
+
+func (l *FileLogger) write(line []byte) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.f == nil {
+		return fmt.Errorf("%s: logger is closed", l.path)
+	}
+	var rotateErr error
+	if l.shouldRotate(int64(len(line))) {
+		// If rotating fails, keep writing to the current file, so that no
+		// entries are lost, and try again on the next write.
+		rotateErr = l.rotate()
+	}
+	n, err := l.f.Write(line)
+	l.size += int64(n)
+	if err != nil {
+		return err
+	}
+	return rotateErr
+}
+
+// shouldRotate reports whether the file must be rotated before n more bytes
+// are written to it. A file is never rotated while empty, so a single large
+// entry does not rotate it on every write.
+func (l *FileLogger) shouldRotate(n int64) bool {
+	if l.size == 0 {
+		return false
+	}
+	if l.opts.MaxSize > 0 && l.size+n > l.opts.MaxSize {
+		return true
+	}
+	return l.opts.MaxAge > 0 && l.now().Sub(l.opened) >= l.opts.MaxAge
+}
+
+// open opens the log file for appending. l.mu must be held, or l must not yet
+// be shared.
+func (l *FileLogger) open() error {
+	f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	l.f = f
+	l.size = info.Size()
+	l.opened = l.now()
+	return nil
+}
+
+// rotate renames the current log file and starts a new one. Old files are
+// cleaned up in the background. The current file stays open until the new
+// one has been opened, so if rotate fails, l can still be written to. l.mu
+// must be held.
+func (l *FileLogger) rotate() (err error) {
+	defer derrors.Wrap(&err, "rotate")
+	rotated := l.path + "." + l.now().UTC().Format(rotatedTimeFormat)
+	if err := os.Rename(l.path, rotated); err != nil {
+		return err
+	}
+	old := l.f
+	if err := l.open(); err != nil {
+		// Entries go to the renamed file until a later rotation succeeds.
+		return err
+	}
+	if err := old.Close(); err != nil {
+		log.Errorf(context.Background(), "FileLogger: closing %s: %v", rotated, err)
+	}
+	l.wg.Add(1)
+	go func() {
+		defer l.wg.Done()
+		if err := l.cleanUp(); err != nil {
+			log.Errorf(context.Background(), "FileLogger: %v", err)
+		}
+	}()
+	return nil
+}
+
+// cleanUp removes the oldest rotated files, so that at most MaxBackups
+// remain, and then compresses the remaining ones if necessary. It looks at
+// all rotated files rather than the one just rotated, so that concurrent
+// calls and files left by an earlier process are handled.
+func (l *FileLogger) cleanUp() (err error) {
+	defer derrors.Wrap(&err, "cleanUp")
+	l.bgMu.Lock()
+	defer l.bgMu.Unlock()
+	backups, err := l.backups()
+	if err != nil {
+		return err
+	}
+	if l.opts.MaxBackups > 0 {
+		for len(backups) > l.opts.MaxBackups {
+			if err := os.Remove(backups[0]); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			backups = backups[1:]
+		}
+	}
+	if l.opts.Compress {
+		for _, b := range backups {
+			if strings.HasSuffix(b, ".gz") {
+				continue
+			}
+			if err := compressFile(b); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// backups returns the rotated log files, oldest first. A file that is being
+// compressed is counted once.
+func (l *FileLogger) backups() ([]string, error) {
+	matches, err := filepath.Glob(l.path + ".*")
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	var backups []string
+	for _, m := range matches {
+		if strings.HasSuffix(m, ".tmp") {
+			continue
+		}
+		name := strings.TrimSuffix(m, ".gz")
+		if _, err := time.Parse(rotatedTimeFormat, strings.TrimPrefix(name, l.path+".")); err != nil {
+			continue
+		}
+		if !seen[name] {
+			seen[name] = true
+			backups = append(backups, m)
+		}
+	}
+	sort.Strings(backups)
+	return backups, nil
+}
+
+// Close closes the log file, after waiting for rotated files to be
+// compressed.
+func (l *FileLogger) Close() error {
+	l.mu.Lock()
+	f := l.f
+	l.f = nil
+	l.mu.Unlock()
+	l.wg.Wait()
+	if f == nil {
+		return nil
+	}
+	return f.Close()
+}
+
+// compressFile replaces the file at path with a gzipped copy named path.gz.
+func compressFile(path string) (err error) {
+	defer derrors.Wrap(&err, "compressFile(%q)", path)
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	tmp := path + ".gz.tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			out.Close()
+			os.Remove(tmp)
+		}
+	}()
+	zw := gzip.NewWriter(out)
+	if _, err := io.Copy(zw, in); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path+".gz"); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
@@ -0,0 +1,204 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/logging"
+)
+
+func TestFileLogger(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filelogger")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "frontend.log")
+	l, err := NewFileLogger(path, FileLoggerOptions{MaxSize: 200, MaxBackups: 2, Compress: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2020, 7, 1, 0, 0, 0, 0, time.UTC)
+	l.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+
+	r := httptest.NewRequest("GET", "/example.com/pkg", nil)
+	r.RemoteAddr = "10.1.2.3:4567"
+	for i := 0; i < 10; i++ {
+		l.Log(logging.Entry{
+			HTTPRequest: &logging.HTTPRequest{Request: r, Status: 200, Latency: 5 * time.Millisecond},
+			Payload:     "request end",
+			Severity:    logging.Info,
+		})
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	scan := bufio.NewScanner(f)
+	if !scan.Scan() {
+		t.Fatal("current log file is empty")
+	}
+	var got fileLogEntry
+	if err := json.Unmarshal(scan.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Method != "GET" || got.URL != "/example.com/pkg" || got.Status != 200 ||
+		got.LatencyMS != 5 || got.Severity != "Info" || got.Message != "request end" {
+		t.Errorf("got %+v", got)
+	}
+	if strings.Contains(scan.Text(), "10.1.2.3") {
+		t.Errorf("log line %q contains the client IP", scan.Text())
+	}
+
+	backups, err := filepath.Glob(path + ".*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 2 {
+		t.Fatalf("got backups %v, want 2", backups)
+	}
+	for _, b := range backups {
+		if !strings.HasSuffix(b, ".gz") {
+			t.Errorf("backup %s is not compressed", b)
+			continue
+		}
+		bf, err := os.Open(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		zr, err := gzip.NewReader(bf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := ioutil.ReadAll(zr)
+		bf.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(data), `"message":"request end"`) {
+			t.Errorf("backup %s: got %q", b, data)
+		}
+	}
+}
+
+func TestFileLoggerMaxAge(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filelogger")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "worker.log")
+	l, err := NewFileLogger(path, FileLoggerOptions{MaxAge: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2020, 7, 1, 0, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return now }
+	l.opened = now
+
+	l.Log(logging.Entry{Payload: "one"})
+	l.Log(logging.Entry{Payload: "two"})
+	now = now.Add(2 * time.Hour)
+	l.Log(logging.Entry{Payload: "three"})
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	backups, err := filepath.Glob(path + ".*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 1 {
+		t.Fatalf("got backups %v, want 1", backups)
+	}
+	data, err := ioutil.ReadFile(backups[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(string(data), "\n"); got != 2 {
+		t.Errorf("got %d lines in rotated file, want 2", got)
+	}
+	data, err = ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"message":"three"`) {
+		t.Errorf("current file: got %q", data)
+	}
+}
+
+func TestFileLoggerRotateError(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filelogger")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "worker.log")
+	l, err := NewFileLogger(path, FileLoggerOptions{MaxAge: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2020, 7, 1, 0, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return now }
+	l.opened = now
+
+	if err := l.write([]byte("one\n")); err != nil {
+		t.Fatal(err)
+	}
+	// Make renaming the file fail, by putting a non-empty directory where it
+	// would go.
+	now = now.Add(2 * time.Hour)
+	blocker := path + "." + now.Format(rotatedTimeFormat)
+	if err := os.MkdirAll(filepath.Join(blocker, "x"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.write([]byte("two\n")); err == nil {
+		t.Error("write succeeded, want rotation error")
+	}
+	if err := os.RemoveAll(blocker); err != nil {
+		t.Fatal(err)
+	}
+	// The logger still works, and rotates on the next write.
+	if err := l.write([]byte("three\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for file, want := range map[string]string{
+		blocker: "one\ntwo\n",
+		path:    "three\n",
+	} {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := string(data); got != want {
+			t.Errorf("%s: got %q, want %q", file, got, want)
+		}
+	}
+}
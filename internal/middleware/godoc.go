@@ -104,16 +104,34 @@ func godoc(u *url.URL) string {
 }
 
 // capturingResponseWriter is an http.ResponseWriter that captures
-// the body for later processing.
+// the body for later processing, unless the response is flushed.
 type capturingResponseWriter struct {
 	http.ResponseWriter
-	buf bytes.Buffer
+	buf         bytes.Buffer
+	passThrough bool
 }
 
 func (c *capturingResponseWriter) Write(b []byte) (int, error) {
+	if c.passThrough {
+		return c.ResponseWriter.Write(b)
+	}
 	return c.buf.Write(b)
 }
 
+// Flush writes what has been captured, and stops capturing, so that
+// responses that are streamed, like server-sent events, are not held back.
+// Such responses don't contain GodocURLPlaceholder.
+func (c *capturingResponseWriter) Flush() {
+	if !c.passThrough {
+		c.passThrough = true
+		c.ResponseWriter.Write(c.buf.Bytes())
+		c.buf.Reset()
+	}
+	if f, ok := c.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
 func (c *capturingResponseWriter) bytes() []byte {
 	return c.buf.Bytes()
 }
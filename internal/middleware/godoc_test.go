@@ -101,6 +101,22 @@ func TestGodocURL(t *testing.T) {
 	}
 }
 
+func TestGodocURLFlush(t *testing.T) {
+	// A streamed response reaches the client as soon as it is flushed.
+	rec := httptest.NewRecorder()
+	GodocURL()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("data: 1\n\n"))
+		w.(http.Flusher).Flush()
+		if !rec.Flushed || rec.Body.String() != "data: 1\n\n" {
+			t.Errorf("after Flush: flushed = %t, body = %q", rec.Flushed, rec.Body.String())
+		}
+		w.Write([]byte("data: 2\n\n"))
+	})).ServeHTTP(rec, httptest.NewRequest("GET", "/fetch/events/example.com/pkg", nil))
+	if got, want := rec.Body.String(), "data: 1\n\ndata: 2\n\n"; got != want {
+		t.Errorf("body = %q, want %q", got, want)
+	}
+}
+
 func TestGodoc(t *testing.T) {
 	testCases := []struct {
 		from, to string
@@ -0,0 +1,73 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/logging"
+	"golang.org/x/pkgsite/internal/log"
+)
+
+// JSONLoggerOptions configures a JSONLogger.
+type JSONLoggerOptions struct {
+	// MinSeverity is the lowest severity of the entries that are written.
+	// Entries of lower severity are dropped.
+	MinSeverity logging.Severity
+	// SampleRate is the fraction of successful requests, those with status
+	// 200 and no more than Info severity, that are written. The others are
+	// always written. If it is zero, all of them are written.
+	SampleRate float64
+}
+
+// A JSONLogger is a Logger that writes one JSON object per line for each
+// request, with the fields of a FileLogger entry, when it ends. It is meant
+// for running locally, where the entries can be filtered and queried with
+// tools like jq, as LocalLogger's cannot.
+type JSONLogger struct {
+	opts   JSONLoggerOptions
+	now    func() time.Time // for testing
+	sample func() float64   // for testing
+
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewJSONLogger returns a JSONLogger that writes to w.
+func NewJSONLogger(w io.Writer, opts JSONLoggerOptions) *JSONLogger {
+	return &JSONLogger{opts: opts, now: time.Now, sample: rand.Float64, w: w}
+}
+
+// Log implements the Logger interface. Entries for the start of a request,
+// which have no status, are dropped, so that there is one line per request.
+func (l *JSONLogger) Log(entry logging.Entry) {
+	if hr := entry.HTTPRequest; hr != nil && hr.Status == 0 {
+		return
+	}
+	if entry.Severity < l.opts.MinSeverity {
+		return
+	}
+	e := newFileLogEntry(entry, l.now)
+	if l.opts.SampleRate > 0 && e.Status == http.StatusOK && entry.Severity <= logging.Info && l.sample() >= l.opts.SampleRate {
+		return
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		log.Errorf(context.Background(), "JSONLogger: %v", err)
+		return
+	}
+	line = append(line, '\n')
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.w.Write(line); err != nil {
+		log.Errorf(context.Background(), "JSONLogger: %v", err)
+	}
+}
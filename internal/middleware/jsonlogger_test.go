@@ -0,0 +1,82 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/logging"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestJSONLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewJSONLogger(&buf, JSONLoggerOptions{MinSeverity: logging.Info, SampleRate: 0.5})
+	now := time.Date(2020, 11, 1, 0, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return now }
+	// Sample the first successful request in and the second out.
+	samples := []float64{0.2, 0.7}
+	l.sample = func() float64 {
+		s := samples[0]
+		samples = samples[1:]
+		return s
+	}
+
+	lg := RequestLog(l)
+	for _, test := range []struct {
+		path   string
+		status int
+	}{
+		{"/healthz", http.StatusOK}, // Debug: dropped
+		{"/a", http.StatusOK},       // sampled in
+		{"/b", http.StatusOK},       // sampled out
+		{"/c", http.StatusNotFound},
+		{"/d", http.StatusInternalServerError},
+	} {
+		h := lg(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(test.status)
+		}))
+		r := httptest.NewRequest("GET", test.path, nil)
+		r.Header.Set("User-Agent", "test")
+		r.Header.Set("X-Cloud-Trace-Context", "trace")
+		h.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	var got []fileLogEntry
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var e fileLogEntry
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("%q: %v", line, err)
+		}
+		e.LatencyMS = 0
+		got = append(got, e)
+	}
+	entry := func(path string, status int, severity string) fileLogEntry {
+		return fileLogEntry{
+			Time:      now,
+			Severity:  severity,
+			Trace:     "trace",
+			Message:   "request end",
+			Method:    "GET",
+			URL:       path,
+			Status:    status,
+			UserAgent: "test",
+		}
+	}
+	want := []fileLogEntry{
+		entry("/a", http.StatusOK, "Info"),
+		entry("/c", http.StatusNotFound, "Info"),
+		entry("/d", http.StatusInternalServerError, "Error"),
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
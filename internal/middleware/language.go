@@ -0,0 +1,25 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"net/http"
+
+	"golang.org/x/pkgsite/internal/i18n"
+)
+
+// Language returns a Middleware that chooses the language to render the
+// response in from the request's Accept-Language header, and stores it in the
+// request context for i18n.FromContext. It must come before caching, since the
+// cache key depends on the language.
+func Language() Middleware {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Language")
+			tag := i18n.Match(r.Header.Get("Accept-Language"))
+			h.ServeHTTP(w, r.WithContext(i18n.NewContext(r.Context(), tag)))
+		})
+	}
+}
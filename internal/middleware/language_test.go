@@ -0,0 +1,40 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/pkgsite/internal/i18n"
+	"golang.org/x/text/language"
+)
+
+func TestLanguage(t *testing.T) {
+	var got language.Tag
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = i18n.FromContext(r.Context())
+	})
+	mw := Language()(handler)
+	for _, test := range []struct {
+		header string
+		want   language.Tag
+	}{
+		{"", i18n.Default},
+		{"de-DE,de;q=0.9", language.German},
+	} {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Accept-Language", test.header)
+		w := httptest.NewRecorder()
+		mw.ServeHTTP(w, r)
+		if got != test.want {
+			t.Errorf("%q: got language %s, want %s", test.header, got, test.want)
+		}
+		if v := w.Header().Get("Vary"); v != "Accept-Language" {
+			t.Errorf("%q: got Vary %q, want Accept-Language", test.header, v)
+		}
+	}
+}
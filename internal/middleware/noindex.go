@@ -0,0 +1,37 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"context"
+	"net/http"
+)
+
+type noIndexKey struct{}
+
+// NoIndex returns a Middleware that asks search engines not to index the
+// responses to requests for which noIndex returns true, by setting the
+// X-Robots-Tag header. It also records the decision in the request context,
+// so that HTML pages can add a robots meta tag; see IsNoIndex.
+func NoIndex(noIndex func(*http.Request) bool) Middleware {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !noIndex(r) {
+				h.ServeHTTP(w, r)
+				return
+			}
+			w.Header().Set("X-Robots-Tag", "noindex")
+			ctx := context.WithValue(r.Context(), noIndexKey{}, true)
+			h.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// IsNoIndex reports whether the NoIndex middleware asked search engines not
+// to index the response to the request with the given context.
+func IsNoIndex(ctx context.Context) bool {
+	v, _ := ctx.Value(noIndexKey{}).(bool)
+	return v
+}
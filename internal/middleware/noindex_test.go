@@ -0,0 +1,42 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNoIndex(t *testing.T) {
+	mw := NoIndex(func(r *http.Request) bool {
+		return strings.HasPrefix(r.URL.Path, "/corp.example.com/")
+	})
+	var gotNoIndex bool
+	h := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotNoIndex = IsNoIndex(r.Context())
+	}))
+	for _, test := range []struct {
+		path string
+		want bool
+	}{
+		{"/corp.example.com/mod", true},
+		{"/github.com/a/b", false},
+	} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", test.path, nil))
+		if gotNoIndex != test.want {
+			t.Errorf("%s: IsNoIndex = %t, want %t", test.path, gotNoIndex, test.want)
+		}
+		wantHeader := ""
+		if test.want {
+			wantHeader = "noindex"
+		}
+		if got := w.Header().Get("X-Robots-Tag"); got != wantHeader {
+			t.Errorf("%s: X-Robots-Tag = %q, want %q", test.path, got, wantHeader)
+		}
+	}
+}
@@ -11,12 +11,17 @@ import (
 )
 
 // Panic returns a middleware that executes panicHandler on any panic
-// originating from the delegate handler.
+// originating from the delegate handler, other than http.ErrAbortHandler.
 func Panic(panicHandler http.Handler) Middleware {
 	return func(h http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			defer func() {
 				if e := recover(); e != nil {
+					if e == http.ErrAbortHandler {
+						// The handler aborted the response on purpose, so
+						// let the server close the connection.
+						panic(e)
+					}
 					log.Errorf(r.Context(), "middleware.Panic: %v", e)
 					panicHandler.ServeHTTP(w, r)
 				}
@@ -0,0 +1,44 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/log"
+	"golang.org/x/pkgsite/internal/preferences"
+)
+
+// UserPreferences returns a Middleware that identifies the signed-in user from
+// the given request header, which must be set by an authenticating proxy, and
+// stores the user and their preferences, obtained with get, in the request
+// context for preferences.FromContext. Requests without the header are passed
+// on unchanged. It must come before caching, since the cache key depends on
+// the preferences.
+func UserPreferences(header string, get func(ctx context.Context, userID string) (*preferences.Preferences, error)) Middleware {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", header)
+			userID := r.Header.Get(header)
+			if userID == "" {
+				h.ServeHTTP(w, r)
+				return
+			}
+			ctx := r.Context()
+			p, err := get(ctx, userID)
+			if err != nil {
+				if !errors.Is(err, derrors.NotFound) {
+					// Render with the default preferences rather than fail.
+					log.Errorf(ctx, "UserPreferences: %v", err)
+				}
+				p = &preferences.Preferences{}
+			}
+			h.ServeHTTP(w, r.WithContext(preferences.NewContext(ctx, userID, p)))
+		})
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/preferences"
+)
+
+func TestUserPreferences(t *testing.T) {
+	const header = "X-User"
+	stored := map[string]*preferences.Preferences{
+		"dark@example.com": {Theme: "dark"},
+	}
+	get := func(_ context.Context, userID string) (*preferences.Preferences, error) {
+		if userID == "broken@example.com" {
+			return nil, errors.New("database is down")
+		}
+		if p, ok := stored[userID]; ok {
+			return p, nil
+		}
+		return nil, derrors.NotFound
+	}
+	var (
+		gotUser  string
+		gotPrefs *preferences.Preferences
+	)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUser = preferences.UserFromContext(r.Context())
+		gotPrefs = preferences.FromContext(r.Context())
+	})
+	mw := UserPreferences(header, get)(handler)
+	for _, test := range []struct {
+		user, wantTheme string
+	}{
+		{"", ""},
+		{"dark@example.com", "dark"},
+		{"new@example.com", ""},
+		{"broken@example.com", ""},
+	} {
+		r := httptest.NewRequest("GET", "/", nil)
+		if test.user != "" {
+			r.Header.Set(header, test.user)
+		}
+		w := httptest.NewRecorder()
+		mw.ServeHTTP(w, r)
+		if gotUser != test.user {
+			t.Errorf("%q: got user %q", test.user, gotUser)
+		}
+		if gotPrefs.Theme != test.wantTheme {
+			t.Errorf("%q: got theme %q, want %q", test.user, gotPrefs.Theme, test.wantTheme)
+		}
+		if v := w.Header().Get("Vary"); v != header {
+			t.Errorf("%q: got Vary %q, want %s", test.user, v, header)
+		}
+	}
+}
@@ -6,12 +6,16 @@ package middleware
 
 import (
 	"context"
+	"crypto/sha256"
+	"fmt"
 	"net"
 	"net/http"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/go-redis/redis/v7"
 	"github.com/golang/groupcache/lru"
 	"go.opencensus.io/stats"
 	"go.opencensus.io/stats/view"
@@ -38,45 +42,70 @@ var (
 	}
 )
 
-// Quota implements a simple IP-based rate limiter. Each set of incoming IP
-// addresses with the same low-order byte gets qps requests per second, with the
-// given burst.
-// Information is kept in an LRU cache of size maxEntries.
+// Quota implements a token-bucket rate limiter. Each client gets qps
+// requests per second, with the given burst. Clients are told apart by the
+// API key in the config.APIKeyHeader header, if it is one of the keys in
+// settings, and otherwise by their IP address, which is the entry of the
+// X-Forwarded-For header before the settings.TrustedProxies added by load
+// balancers; each set of IP addresses with the same low-order byte counts as
+// one client.
 //
-// If a request is disallowed, a 429 (TooManyRequests) will be served.
-func Quota(settings config.QuotaSettings) Middleware {
-	var mu sync.Mutex
-	cache := lru.New(settings.MaxEntries)
+// If client is nil, information is kept in an LRU cache of size maxEntries.
+// Otherwise it is kept in redis, under keys that begin with the given name,
+// so that the budgets are shared by all instances of the server.
+//
+// If a request is disallowed, a 429 (TooManyRequests) will be served, with a
+// Retry-After header giving the number of seconds until it will be allowed.
+func Quota(name string, settings config.QuotaSettings, client *redis.Client) Middleware {
+	var store quotaStore
+	if client == nil {
+		store = newMemoryQuotaStore(settings)
+	} else {
+		store = &redisQuotaStore{
+			client: client,
+			prefix: "quota " + name + " ",
+			qps:    settings.QPS,
+			burst:  settings.Burst,
+		}
+	}
 
 	return func(h http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
 			authVal := r.Header.Get(config.BypassQuotaAuthHeader)
 			for _, wantVal := range settings.AuthValues {
 				if authVal == wantVal {
-					recordQuotaMetric(r.Context(), "accepted")
-					log.Infof(r.Context(), "Quota: accepting %q", authVal)
+					recordQuotaMetric(ctx, "accepted")
+					log.Infof(ctx, "Quota: accepting %q", authVal)
 					h.ServeHTTP(w, r)
 					return
 				}
 			}
 
-			key := ipKey(r.Header.Get("X-Forwarded-For"))
+			key := quotaKey(r, settings.APIKeys, clientIP(r, settings.TrustedProxies))
 			// key is empty if we couldn't parse an IP, or there is no IP.
 			// Fail open in this case: allow serving.
-			var limiter *rate.Limiter
+			var (
+				blocked    bool
+				retryAfter time.Duration
+			)
 			if key != "" {
-				mu.Lock()
-				if v, ok := cache.Get(key); ok {
-					limiter = v.(*rate.Limiter)
-				} else {
-					limiter = rate.NewLimiter(rate.Limit(settings.QPS), settings.Burst)
-					cache.Add(key, limiter)
+				allowed, wait, err := store.allow(ctx, key, time.Now())
+				if err != nil {
+					// Fail open if the store is unavailable, too.
+					log.Warningf(ctx, "Quota(%q): %v", name, err)
+					allowed = true
 				}
-				mu.Unlock()
+				blocked = !allowed
+				retryAfter = wait
 			}
-			blocked := limiter != nil && !limiter.Allow()
-			recordQuotaMetric(r.Context(), strconv.FormatBool(blocked))
+			recordQuotaMetric(ctx, strconv.FormatBool(blocked))
 			if blocked && settings.RecordOnly != nil && !*settings.RecordOnly {
+				secs := int((retryAfter + time.Second - 1) / time.Second)
+				if secs < 1 {
+					secs = 1
+				}
+				w.Header().Set("Retry-After", strconv.Itoa(secs))
 				const tmr = http.StatusTooManyRequests
 				http.Error(w, http.StatusText(tmr), tmr)
 				return
@@ -86,6 +115,127 @@ func Quota(settings config.QuotaSettings) Middleware {
 	}
 }
 
+// quotaKey returns the key that identifies the client of r: a hash of its API
+// key, if it is one of apiKeys, or else the IP block of clientIP, its address
+// as returned by the function of that name. It returns the empty string if
+// neither is known.
+func quotaKey(r *http.Request, apiKeys []string, clientIP string) string {
+	if k := r.Header.Get(config.APIKeyHeader); k != "" {
+		for _, ak := range apiKeys {
+			if k == ak {
+				// Keep the key itself out of memory dumps and redis.
+				return fmt.Sprintf("key:%x", sha256.Sum256([]byte(k)))
+			}
+		}
+	}
+	ip := ipKey(clientIP)
+	if ip == "" {
+		return ""
+	}
+	return "ip:" + ip
+}
+
+// A quotaStore keeps the token buckets of clients.
+type quotaStore interface {
+	// allow takes a token from the bucket of the client with the given key
+	// at time now, and reports whether there was one. If there wasn't, it
+	// also returns how long it will be until there is.
+	allow(ctx context.Context, key string, now time.Time) (bool, time.Duration, error)
+}
+
+// memoryQuotaStore is a quotaStore that keeps the buckets in memory.
+type memoryQuotaStore struct {
+	mu    sync.Mutex
+	cache *lru.Cache
+	qps   int
+	burst int
+}
+
+func newMemoryQuotaStore(settings config.QuotaSettings) *memoryQuotaStore {
+	return &memoryQuotaStore{
+		cache: lru.New(settings.MaxEntries),
+		qps:   settings.QPS,
+		burst: settings.Burst,
+	}
+}
+
+func (s *memoryQuotaStore) allow(_ context.Context, key string, now time.Time) (bool, time.Duration, error) {
+	s.mu.Lock()
+	var limiter *rate.Limiter
+	if v, ok := s.cache.Get(key); ok {
+		limiter = v.(*rate.Limiter)
+	} else {
+		limiter = rate.NewLimiter(rate.Limit(s.qps), s.burst)
+		s.cache.Add(key, limiter)
+	}
+	s.mu.Unlock()
+
+	res := limiter.ReserveN(now, 1)
+	if !res.OK() {
+		// The burst is zero: no request is ever allowed.
+		return false, time.Second, nil
+	}
+	if d := res.DelayFrom(now); d > 0 {
+		res.CancelAt(now)
+		return false, d, nil
+	}
+	return true, 0, nil
+}
+
+// redisQuotaStore is a quotaStore that keeps the buckets in redis.
+type redisQuotaStore struct {
+	client *redis.Client
+	prefix string
+	qps    int
+	burst  int
+}
+
+// takeTokenScript takes a token from the bucket in KEYS[1], a hash of the
+// number of tokens and the time they were counted, in milliseconds, given
+// the rate, burst and current time in ARGV. It returns whether there was a
+// token and, if not, the number of milliseconds until there is one. The
+// bucket expires once it would be full again.
+var takeTokenScript = redis.NewScript(`
+local rate, burst, now = tonumber(ARGV[1]), tonumber(ARGV[2]), tonumber(ARGV[3])
+local b = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens, ts = tonumber(b[1]), tonumber(b[2])
+if tokens == nil or ts == nil then
+  tokens, ts = burst, now
+end
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate / 1000)
+local allowed, wait = 0, 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+else
+  wait = math.ceil((1 - tokens) * 1000 / rate)
+end
+redis.call('HMSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst * 1000 / rate) + 1000)
+return {allowed, wait}
+`)
+
+func (s *redisQuotaStore) allow(ctx context.Context, key string, now time.Time) (_ bool, _ time.Duration, err error) {
+	if s.qps <= 0 {
+		return false, time.Second, nil
+	}
+	// As with the cache, don't hold up serving if redis is slow.
+	ctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	nowMillis := now.UnixNano() / int64(time.Millisecond)
+	v, err := takeTokenScript.Run(s.client.WithContext(ctx), []string{s.prefix + key}, s.qps, s.burst, nowMillis).Result()
+	if err != nil {
+		return false, 0, err
+	}
+	vals, ok := v.([]interface{})
+	if !ok || len(vals) != 2 {
+		return false, 0, fmt.Errorf("unexpected result %v from token script", v)
+	}
+	allowed, _ := vals[0].(int64)
+	wait, _ := vals[1].(int64)
+	return allowed == 1, time.Duration(wait) * time.Millisecond, nil
+}
+
 func recordQuotaMetric(ctx context.Context, blocked string) {
 	stats.RecordWithTags(ctx, []tag.Mutator{
 		tag.Upsert(keyQuotaBlocked, blocked),
@@ -5,19 +5,22 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"strconv"
 	"testing"
 	"time"
 
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v7"
 	"github.com/google/go-cmp/cmp"
 	"go.opencensus.io/stats/view"
 	"golang.org/x/pkgsite/internal/config"
 )
 
 func TestQuota(t *testing.T) {
-	mw := Quota(config.QuotaSettings{QPS: 1, Burst: 2, MaxEntries: 1, RecordOnly: boolptr(false)})
+	mw := Quota("test", config.QuotaSettings{QPS: 1, Burst: 2, MaxEntries: 1, RecordOnly: boolptr(false), TrustedProxies: 1}, nil)
 	var npass int
 	h := func(w http.ResponseWriter, r *http.Request) {
 		npass++
@@ -35,7 +38,7 @@ func TestQuota(t *testing.T) {
 			if err != nil {
 				t.Fatal(err)
 			}
-			req.Header.Add("X-Forwarded-For", "1.2.3.4, and more")
+			req.Header.Add("X-Forwarded-For", "1.2.3.4, 35.191.1.1")
 			res, err := c.Do(req)
 			if err != nil {
 				t.Fatalf("%s: %v", msg, err)
@@ -72,7 +75,7 @@ func TestQuota(t *testing.T) {
 
 func TestQuotaRecordOnly(t *testing.T) {
 	// Like TestQuota, but with in RecordOnly mode nothing is actually blocked.
-	mw := Quota(config.QuotaSettings{QPS: 1, Burst: 2, MaxEntries: 1, RecordOnly: boolptr(true)})
+	mw := Quota("test", config.QuotaSettings{QPS: 1, Burst: 2, MaxEntries: 1, RecordOnly: boolptr(true), TrustedProxies: 1}, nil)
 	npass := 0
 	h := func(w http.ResponseWriter, r *http.Request) {
 		npass++
@@ -89,7 +92,7 @@ func TestQuotaRecordOnly(t *testing.T) {
 		if err != nil {
 			t.Fatal(err)
 		}
-		req.Header.Add("X-Forwarded-For", "1.2.3.4, and more")
+		req.Header.Add("X-Forwarded-For", "1.2.3.4, 35.191.1.1")
 		res, err := c.Do(req)
 		if err != nil {
 			t.Fatal(err)
@@ -108,7 +111,7 @@ func TestQuotaRecordOnly(t *testing.T) {
 
 func TestQuotaBadKey(t *testing.T) {
 	// Verify that invalid IP addresses are not blocked.
-	mw := Quota(config.QuotaSettings{QPS: 1, Burst: 2, MaxEntries: 1, RecordOnly: boolptr(true)})
+	mw := Quota("test", config.QuotaSettings{QPS: 1, Burst: 2, MaxEntries: 1, RecordOnly: boolptr(true), TrustedProxies: 1}, nil)
 	npass := 0
 	h := func(w http.ResponseWriter, r *http.Request) {
 		npass++
@@ -125,7 +128,7 @@ func TestQuotaBadKey(t *testing.T) {
 		if err != nil {
 			t.Fatal(err)
 		}
-		req.Header.Add("X-Forwarded-For", "not.a.valid.ip, and more")
+		req.Header.Add("X-Forwarded-For", "not.a.valid.ip, 35.191.1.1")
 		res, err := c.Do(req)
 		if err != nil {
 			t.Fatal(err)
@@ -178,3 +181,94 @@ func TestIPKey(t *testing.T) {
 }
 
 func boolptr(b bool) *bool { return &b }
+
+func TestQuotaStores(t *testing.T) {
+	s, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	client := redis.NewClient(&redis.Options{Addr: s.Addr()})
+
+	settings := config.QuotaSettings{QPS: 2, Burst: 2, MaxEntries: 10}
+	for _, test := range []struct {
+		name  string
+		store quotaStore
+	}{
+		{"memory", newMemoryQuotaStore(settings)},
+		{"redis", &redisQuotaStore{client: client, prefix: "quota test ", qps: settings.QPS, burst: settings.Burst}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			now := time.Now()
+			check := func(key string, at time.Duration, wantAllowed bool, wantWait time.Duration) {
+				t.Helper()
+				allowed, wait, err := test.store.allow(ctx, key, now.Add(at))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if allowed != wantAllowed || wait != wantWait {
+					t.Errorf("allow(%q, %v) = %t, %v; want %t, %v", key, at, allowed, wait, wantAllowed, wantWait)
+				}
+			}
+			check("a", 0, true, 0)
+			check("a", 0, true, 0)
+			// The bucket is empty, and gets a token every half second.
+			check("a", 0, false, 500*time.Millisecond)
+			check("a", 100*time.Millisecond, false, 400*time.Millisecond)
+			// Other clients have buckets of their own.
+			check("b", 100*time.Millisecond, true, 0)
+			check("a", 500*time.Millisecond, true, 0)
+			check("a", 500*time.Millisecond, false, 500*time.Millisecond)
+		})
+	}
+}
+
+func TestQuotaRetryAfter(t *testing.T) {
+	const apiKey = "secret"
+	mw := Quota("test", config.QuotaSettings{
+		QPS:        1,
+		Burst:      1,
+		MaxEntries: 10,
+		RecordOnly: boolptr(false),
+		APIKeys:    []string{apiKey},
+	}, nil)
+	ts := httptest.NewServer(mw(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})))
+	defer ts.Close()
+
+	get := func(key string) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest("GET", ts.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Add("X-Forwarded-For", "1.2.3.4")
+		if key != "" {
+			req.Header.Add(config.APIKeyHeader, key)
+		}
+		res, err := ts.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		return res
+	}
+	for _, test := range []struct {
+		key            string
+		wantStatus     int
+		wantRetryAfter string
+	}{
+		{"", http.StatusOK, ""},
+		{"", http.StatusTooManyRequests, "1"},
+		// An unknown key doesn't get a budget of its own.
+		{"unknown", http.StatusTooManyRequests, "1"},
+		{apiKey, http.StatusOK, ""},
+		{apiKey, http.StatusTooManyRequests, "1"},
+	} {
+		res := get(test.key)
+		if res.StatusCode != test.wantStatus || res.Header.Get("Retry-After") != test.wantRetryAfter {
+			t.Errorf("key %q: got status %d, Retry-After %q; want %d, %q",
+				test.key, res.StatusCode, res.Header.Get("Retry-After"), test.wantStatus, test.wantRetryAfter)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"net/http"
+
+	"golang.org/x/pkgsite/internal/log"
+	"golang.org/x/pkgsite/internal/requestid"
+)
+
+// RequestID returns a Middleware that identifies each request by the ID in
+// its X-Request-ID header, or by a new one if it has none or it is
+// malformed. The ID is stored in the request context for requestid.FromContext
+// and added as a label to log entries, and the response header is set to it,
+// so that a failure seen by a user can be found in the logs. It should come
+// first, so that all other middleware sees the ID.
+func RequestID() Middleware {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(requestid.Header)
+			if !requestid.Valid(id) {
+				id = requestid.New()
+			}
+			w.Header().Set(requestid.Header, id)
+			ctx := requestid.NewContext(r.Context(), id)
+			ctx = log.NewContextWithLabel(ctx, "requestID", id)
+			h.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/pkgsite/internal/requestid"
+)
+
+func TestRequestID(t *testing.T) {
+	var got string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = requestid.FromContext(r.Context())
+	})
+	mw := RequestID()(handler)
+	for _, test := range []struct {
+		header  string
+		wantNew bool
+	}{
+		{"abc-123", false},
+		{"", true},
+		{"bad id */", true},
+	} {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set(requestid.Header, test.header)
+		w := httptest.NewRecorder()
+		mw.ServeHTTP(w, r)
+		if !requestid.Valid(got) {
+			t.Errorf("%q: got invalid request ID %q", test.header, got)
+		}
+		if (got != test.header) != test.wantNew {
+			t.Errorf("%q: got request ID %q, want new: %t", test.header, got, test.wantNew)
+		}
+		if h := w.Header().Get(requestid.Header); h != got {
+			t.Errorf("%q: got response header %q, want %q", test.header, h, got)
+		}
+	}
+}
@@ -16,7 +16,9 @@ import (
 	"golang.org/x/pkgsite/internal/log"
 )
 
-// Logger is the interface used to write request logs to GCP.
+// Logger is the interface used to write request logs. On GCP they are
+// written to Stackdriver; elsewhere, to a FileLogger, a JSONLogger or a
+// LocalLogger.
 type Logger interface {
 	Log(logging.Entry)
 }
@@ -101,6 +103,14 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
+// Flush implements http.Flusher, so that streamed responses are not held
+// back.
+func (rw *responseWriter) Flush() {
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
 func translateStatus(code int) int {
 	if code == 0 {
 		return http.StatusOK
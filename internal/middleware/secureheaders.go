@@ -5,61 +5,100 @@
 package middleware
 
 import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
+
+	"golang.org/x/pkgsite/internal/log"
 )
 
-var scriptHashes = []string{
-	// From content/static/html/base.tmpl
-	"'sha256-CgM7SjnSbDyuIteS+D1CQuSnzyKwL0qtXLU6ZW2hB+g='",
-	"'sha256-qPGTOKPn+niRiNKQIEX0Ktwuj+D+iPQWIxnlhPicw58='",
-	"'sha256-LIQd8c4GSueKwR3q2fz3AB92cOdy2Ld7ox8pfvMPHns='",
-	"'sha256-dwce5DnVX7uk6fdvvNxQyLTH/cJrTMDK6zzrdKwdwcg='",
-	// From content/static/html/pages/badge.tmpl
-	"'sha256-T7xOt6cgLji3rhOWyKK7t5XKv8+LASQwOnHiHHy8Kwk='",
-	// From content/static/html/pages/details.tmpl
-	"'sha256-EWdCQW4XtY7zS2MZgs76+2EhMbqpaPtC+9EPGnbHBtM='",
-	// From content/static/html/pages/fetch.tmpl
-	"'sha256-1J6DWwTWs/QDZ2+ORDuUQCibmFnXXaNXYOtc0Jk6VU4='",
-	// From content/static/html/worker/index.tmpl
-	"'sha256-y5EX2GR3tCwSK0/kmqZnsWVeBROA8tA75L+I+woljOE='",
-	// From content/static/html/pages/pkg_doc.tmpl
-	"'sha256-91GG/273d2LdEV//lJMbTodGN501OuKZKYYphui+wDQ='",
-	"'sha256-32pObeU1KY/YOSORAAjek9Hs5q6IpyYCK2QnF08OwiY='",
-	"'sha256-uQODpjQEw2CWPIl6zEmpUU1uULk5RYVCofnBw59UOOw='",
-	// From content/static/html/pages/unit.tmpl
-	"'sha256-hsHIJwO1h0Vzwa75j0l07kUfQ7MEZGI/HlSPB/8leZ0='",
-	// From content/static/html/pages/unit_details.tmpl
-	"'sha256-CFun5NgnYeEpye8qcbQPq5Ycwavi4IXuZiIzSMNqRUw='",
-	"'sha256-IHdniK/yZ8URNA2OYbc4R7BssOAe3/dFrSQW7PxEEfM='",
-	"'sha256-MBIVDkCvJUTM2/rxXDRYO9B+ovOUGLVJOww8fxur+LU='",
+// NoncePlaceholder should be used as the value of the nonce attribute of
+// every inline script in rendered content. It is substituted for the nonce of
+// the request by the SecureHeaders middleware, so that pages can be cached
+// with it.
+const NoncePlaceholder = "$$GODISCOVERYNONCE$$"
+
+// SecureHeadersOptions configures SecureHeaders.
+type SecureHeadersOptions struct {
+	// CSP specifies whether to set the Content-Security-Policy header.
+	CSP bool
+	// ReferrerPolicy is the value of the Referrer-Policy header. If it is
+	// empty, the header is not set.
+	ReferrerPolicy string
+	// HSTSMaxAge is how long browsers should only connect to the site over
+	// HTTPS, as told by the Strict-Transport-Security header. If it is zero,
+	// the header is not set, as for deployments served over plain HTTP.
+	HSTSMaxAge time.Duration
 }
 
 // SecureHeaders adds a content-security-policy and other security-related
 // headers to all responses.
-func SecureHeaders(enableCSP bool) Middleware {
+//
+// The content-security-policy only allows inline scripts with a nonce that
+// is generated for each request. Templates render NoncePlaceholder as the
+// nonce, and SecureHeaders replaces it in the body of the response, so it
+// must come before any caching.
+func SecureHeaders(opts SecureHeadersOptions) Middleware {
 	return func(h http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			csp := []string{
-				// Disallow plugin content: pkg.go.dev does not use it.
-				"object-src 'none'",
-				// Disallow <base> URIs, which prevents attackers from changing the
-				// locations of scripts loaded from relative URLs. The site doesn’t have
-				// a <base> tag anyway.
-				"base-uri 'none'",
-				fmt.Sprintf("script-src 'unsafe-inline' 'strict-dynamic' https: http: %s",
-					strings.Join(scriptHashes, " ")),
+			nonce, err := generateNonce()
+			if err != nil {
+				log.Errorf(r.Context(), "SecureHeaders: generateNonce(): %v", err)
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
 			}
-			if enableCSP {
-				w.Header().Set("Content-Security-Policy", strings.Join(csp, "; "))
+			if opts.CSP {
+				w.Header().Set("Content-Security-Policy", contentSecurityPolicy(nonce))
 			}
 			// Don't allow frame embedding.
 			w.Header().Set("X-Frame-Options", "deny")
 			// Prevent MIME sniffing.
 			w.Header().Set("X-Content-Type-Options", "nosniff")
+			if opts.ReferrerPolicy != "" {
+				w.Header().Set("Referrer-Policy", opts.ReferrerPolicy)
+			}
+			if opts.HSTSMaxAge > 0 {
+				w.Header().Set("Strict-Transport-Security", fmt.Sprintf("max-age=%d", int64(opts.HSTSMaxAge/time.Second)))
+			}
 
-			h.ServeHTTP(w, r)
+			crw := &capturingResponseWriter{ResponseWriter: w}
+			h.ServeHTTP(crw, r)
+			body := bytes.ReplaceAll(crw.bytes(), []byte(NoncePlaceholder), []byte(nonce))
+			if _, err := w.Write(body); err != nil {
+				log.Errorf(r.Context(), "SecureHeaders, writing: %v", err)
+			}
 		})
 	}
 }
+
+// contentSecurityPolicy returns the value of the Content-Security-Policy
+// header for a response whose inline scripts have the given nonce.
+func contentSecurityPolicy(nonce string) string {
+	return strings.Join([]string{
+		// Disallow plugin content: pkg.go.dev does not use it.
+		"object-src 'none'",
+		// Disallow <base> URIs, which prevents attackers from changing the
+		// locations of scripts loaded from relative URLs. The site doesn’t have
+		// a <base> tag anyway.
+		"base-uri 'none'",
+		// Browsers that support nonces ignore 'unsafe-inline' and the
+		// schemes, which are only there for older ones. With
+		// 'strict-dynamic', scripts loaded by a script with the nonce, as
+		// with loadScript in base.tmpl, are allowed too.
+		fmt.Sprintf("script-src 'nonce-%s' 'unsafe-inline' 'strict-dynamic' https: http:", nonce),
+	}, "; ")
+}
+
+// generateNonce returns a random value for the nonce of a
+// content-security-policy.
+func generateNonce() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
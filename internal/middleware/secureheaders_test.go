@@ -5,31 +5,83 @@
 package middleware
 
 import (
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"regexp"
 	"testing"
+	"time"
 )
 
 func TestSecureHeaders(t *testing.T) {
-	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
-	enableCSP := true
-	mw := SecureHeaders(enableCSP)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<script nonce="` + NoncePlaceholder + `">`))
+	})
+	mw := SecureHeaders(SecureHeadersOptions{
+		CSP:            true,
+		ReferrerPolicy: "strict-origin-when-cross-origin",
+		HSTSMaxAge:     24 * time.Hour,
+	})
 	ts := httptest.NewServer(mw(handler))
 	defer ts.Close()
+
+	nonceRE := regexp.MustCompile(`'nonce-([^']+)'`)
+	get := func() (nonce string) {
+		t.Helper()
+		resp, err := ts.Client().Get(ts.URL)
+		if err != nil {
+			t.Fatalf("GET returned error %v", err)
+		}
+		defer resp.Body.Close()
+		// Test that the expected headers are set.
+		for header, want := range map[string]string{
+			"referrer-policy":           "strict-origin-when-cross-origin",
+			"strict-transport-security": "max-age=86400",
+			"x-frame-options":           "deny",
+			"x-content-type-options":    "nosniff",
+		} {
+			if got := resp.Header.Get(header); got != want {
+				t.Errorf("GET returned %s %q, want %q", header, got, want)
+			}
+		}
+		csp := resp.Header.Get("content-security-policy")
+		m := nonceRE.FindStringSubmatch(csp)
+		if m == nil {
+			t.Fatalf("content-security-policy %q has no nonce", csp)
+		}
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := string(body), `<script nonce="`+m[1]+`">`; got != want {
+			t.Errorf("body = %q, want %q", got, want)
+		}
+		return m[1]
+	}
+	if n1, n2 := get(), get(); n1 == n2 {
+		t.Errorf("got the same nonce %q for two requests", n1)
+	}
+}
+
+func TestSecureHeadersDisabled(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	ts := httptest.NewServer(SecureHeaders(SecureHeadersOptions{})(handler))
+	defer ts.Close()
 	resp, err := ts.Client().Get(ts.URL)
 	if err != nil {
-		t.Errorf("GET returned error %v", err)
+		t.Fatalf("GET returned error %v", err)
 	}
 	defer resp.Body.Close()
-	// Test that the expected headers are set.
-	expectedHeaders := []string{
+	for _, header := range []string{
 		"content-security-policy",
-		"x-frame-options",
-		"x-content-type-options",
-	}
-	for _, header := range expectedHeaders {
-		if got := resp.Header.Get(header); got == "" {
-			t.Errorf("GET returned empty %s", header)
+		"referrer-policy",
+		"strict-transport-security",
+	} {
+		if got := resp.Header.Get(header); got != "" {
+			t.Errorf("GET returned %s %q, want none", header, got)
 		}
 	}
+	if got := resp.Header.Get("x-content-type-options"); got != "nosniff" {
+		t.Errorf("GET returned x-content-type-options %q, want nosniff", got)
+	}
 }
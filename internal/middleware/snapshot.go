@@ -0,0 +1,240 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/config"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/log"
+)
+
+const (
+	// maxSnapshotBodyBytes is the most of a response body that is recorded
+	// in a snapshot.
+	maxSnapshotBodyBytes = 1 << 20
+
+	// snapshotRecordTimeout bounds the time spent recording one snapshot.
+	snapshotRecordTimeout = 10 * time.Second
+)
+
+// sensitiveHeaders are removed from snapshots. Client IP addresses are
+// removed too, since they are not needed to reproduce a page.
+var sensitiveHeaders = []string{
+	config.APIKeyHeader,
+	"Authorization",
+	"Cookie",
+	"Proxy-Authorization",
+	"Set-Cookie",
+	"X-Forwarded-For",
+	"X-Real-Ip",
+}
+
+// snapshotQueryParams are the query parameters whose values are kept in
+// snapshots. They only choose which view of a page is shown. The values of
+// other parameters, which may hold search terms, tokens or keys, are
+// redacted.
+var snapshotQueryParams = map[string]bool{
+	"limit": true,
+	"m":     true,
+	"page":  true,
+	"tab":   true,
+}
+
+// SnapshotRuleGetter is the signature of a function that gets the active
+// snapshot rules.
+type SnapshotRuleGetter func(context.Context) ([]*internal.SnapshotRule, error)
+
+// SnapshotRecorder is the signature of a function that stores a snapshot.
+type SnapshotRecorder func(context.Context, *internal.ResponseSnapshot) error
+
+// A Snapshotter holds the active snapshot rules, which it regularly reloads
+// in the background.
+type Snapshotter struct {
+	getRules   SnapshotRuleGetter
+	record     SnapshotRecorder
+	appVersion string
+
+	mu    sync.Mutex
+	rules []*internal.SnapshotRule
+}
+
+// NewSnapshotter returns a Snapshotter that loads rules using getRules every
+// pollEvery, and stores snapshots using record.
+func NewSnapshotter(ctx context.Context, pollEvery time.Duration, getRules SnapshotRuleGetter, record SnapshotRecorder, appVersion string) (_ *Snapshotter, err error) {
+	defer derrors.Wrap(&err, "middleware.NewSnapshotter")
+	s := &Snapshotter{getRules: getRules, record: record, appVersion: appVersion}
+	if err := s.loadRules(ctx); err != nil {
+		return nil, err
+	}
+	go func() {
+		ticker := time.NewTicker(pollEvery)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				ctx2, cancel := context.WithTimeout(ctx, pollEvery)
+				if err := s.loadRules(ctx2); err != nil {
+					// Keep using the rules we have.
+					log.Error(ctx, err)
+				}
+				cancel()
+			}
+		}
+	}()
+	return s, nil
+}
+
+func (s *Snapshotter) loadRules(ctx context.Context) (err error) {
+	defer derrors.Wrap(&err, "loadRules")
+	rules, err := s.getRules(ctx)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.rules = rules
+	s.mu.Unlock()
+	return nil
+}
+
+// ruleFor returns the first unexpired rule that matches the request path and
+// whose sample includes this request, or nil if there is none.
+func (s *Snapshotter) ruleFor(r *http.Request) *internal.SnapshotRule {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for _, rule := range s.rules {
+		if now.After(rule.ExpiresAt) {
+			continue
+		}
+		if ok, _ := path.Match(rule.PathPattern, r.URL.Path); !ok {
+			continue
+		}
+		if rand.Float64() < rule.SampleRate {
+			return rule
+		}
+	}
+	return nil
+}
+
+// Snapshot returns a Middleware that records requests and their responses,
+// as directed by the rules in s. Recording happens after the response has
+// been written, so it does not delay the response. Credentials, client
+// addresses and most query values are left out of snapshots; see
+// sensitiveHeaders and snapshotQueryParams. If userHeader is not empty, it
+// names a header holding the user's identity, which is not recorded.
+func Snapshot(s *Snapshotter, userHeader string) Middleware {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rule := s.ruleFor(r)
+			if rule == nil {
+				h.ServeHTTP(w, r)
+				return
+			}
+			sw := &snapshotResponseWriter{ResponseWriter: w}
+			h.ServeHTTP(sw, r)
+			snap := &internal.ResponseSnapshot{
+				RuleID:         rule.ID,
+				Method:         r.Method,
+				URL:            sanitizeURL(r.URL),
+				RequestHeader:  sanitizeHeader(r.Header, userHeader),
+				Status:         translateStatus(sw.status),
+				ResponseHeader: sanitizeHeader(w.Header(), userHeader),
+				ResponseBody:   sw.body.Bytes(),
+				BodyTruncated:  sw.truncated,
+				AppVersion:     s.appVersion,
+			}
+			go func() {
+				ctx, cancel := context.WithTimeout(context.Background(), snapshotRecordTimeout)
+				defer cancel()
+				if err := s.record(ctx, snap); err != nil {
+					log.Errorf(ctx, "recording snapshot of %s: %v", snap.URL, err)
+				}
+			}()
+		})
+	}
+}
+
+// sanitizeURL returns u as a string, with the values of its query parameters
+// other than snapshotQueryParams redacted.
+func sanitizeURL(u *url.URL) string {
+	if u.RawQuery == "" {
+		return u.String()
+	}
+	q := u.Query()
+	for k, vs := range q {
+		if snapshotQueryParams[k] {
+			continue
+		}
+		for i := range vs {
+			vs[i] = "REDACTED"
+		}
+	}
+	u2 := *u
+	u2.RawQuery = q.Encode()
+	return u2.String()
+}
+
+// sanitizeHeader returns a copy of h without sensitive headers, the user
+// header, or any headers that hold values used to authenticate to pkgsite.
+func sanitizeHeader(h http.Header, userHeader string) http.Header {
+	h = h.Clone()
+	for _, k := range sensitiveHeaders {
+		h.Del(k)
+	}
+	if userHeader != "" {
+		h.Del(userHeader)
+	}
+	for k := range h {
+		if strings.HasPrefix(k, "X-Go-Discovery-Auth") {
+			delete(h, k)
+		}
+	}
+	return h
+}
+
+// snapshotResponseWriter passes a response through, while remembering its
+// status and the start of its body.
+type snapshotResponseWriter struct {
+	http.ResponseWriter
+	status    int
+	body      bytes.Buffer
+	truncated bool
+}
+
+func (w *snapshotResponseWriter) WriteHeader(code int) {
+	w.status = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *snapshotResponseWriter) Write(b []byte) (int, error) {
+	if n := maxSnapshotBodyBytes - w.body.Len(); n < len(b) {
+		w.body.Write(b[:n])
+		w.truncated = true
+	} else {
+		w.body.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher, so that streamed responses are not held
+// back.
+func (w *snapshotResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
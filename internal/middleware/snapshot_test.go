@@ -0,0 +1,100 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/config"
+)
+
+func TestSnapshot(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	rules := []*internal.SnapshotRule{
+		{ID: 1, PathPattern: "/expired/*", SampleRate: 1, ExpiresAt: time.Now().Add(-time.Minute)},
+		{ID: 2, PathPattern: "/never/*", SampleRate: 0, ExpiresAt: time.Now().Add(time.Hour)},
+		{ID: 3, PathPattern: "/*/pkg", SampleRate: 1, ExpiresAt: time.Now().Add(time.Hour)},
+	}
+	getRules := func(context.Context) ([]*internal.SnapshotRule, error) { return rules, nil }
+	snaps := make(chan *internal.ResponseSnapshot, 10)
+	record := func(_ context.Context, s *internal.ResponseSnapshot) error {
+		snaps <- s
+		return nil
+	}
+	s, err := NewSnapshotter(ctx, time.Hour, getRules, record, "v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Header().Set("Set-Cookie", "secret")
+		w.WriteHeader(http.StatusTeapot)
+		w.Write([]byte("hello"))
+	})
+	mw := Snapshot(s, "X-User")(handler)
+
+	for _, p := range []string{"/expired/x", "/never/x", "/a/b/pkg"} {
+		mw.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", p, nil))
+	}
+	req := httptest.NewRequest("GET", "/example.com/pkg?tab=doc&token=secret", nil)
+	req.Header.Set("Accept", "text/html")
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("Cookie", "secret")
+	req.Header.Set("X-Go-Discovery-Auth-Token", "secret")
+	req.Header.Set("X-User", "gopher@example.com")
+	req.Header.Set(config.APIKeyHeader, "secret")
+	w := httptest.NewRecorder()
+	mw.ServeHTTP(w, req)
+	if w.Code != http.StatusTeapot || w.Body.String() != "hello" {
+		t.Fatalf("got response %d %q, want it passed through", w.Code, w.Body.String())
+	}
+
+	select {
+	case got := <-snaps:
+		want := &internal.ResponseSnapshot{
+			RuleID:         3,
+			Method:         "GET",
+			URL:            "/example.com/pkg?tab=doc&token=REDACTED",
+			RequestHeader:  http.Header{"Accept": {"text/html"}},
+			Status:         http.StatusTeapot,
+			ResponseHeader: http.Header{"Content-Type": {"text/plain"}},
+			ResponseBody:   []byte("hello"),
+			AppVersion:     "v1",
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("mismatch (-want, +got):\n%s", diff)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for snapshot")
+	}
+	select {
+	case got := <-snaps:
+		t.Errorf("got unexpected snapshot of %s", got.URL)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestSnapshotTruncatesBody(t *testing.T) {
+	w := &snapshotResponseWriter{ResponseWriter: httptest.NewRecorder()}
+	big := []byte(strings.Repeat("x", maxSnapshotBodyBytes-1))
+	w.Write(big)
+	w.Write([]byte("yz"))
+	if !w.truncated {
+		t.Error("got truncated = false, want true")
+	}
+	if got := w.body.Len(); got != maxSnapshotBodyBytes {
+		t.Errorf("got %d bytes, want %d", got, maxSnapshotBodyBytes)
+	}
+}
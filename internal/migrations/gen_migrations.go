@@ -0,0 +1,64 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build ignore
+// +build ignore
+
+// This file generates migrations.gen.go.
+// It builds a map from the SQL files in the top-level "migrations" directory.
+// Run by a "go:generate" comment in migrations.go.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+)
+
+const outfile = "migrations.gen.go"
+
+func main() {
+	files, err := filepath.Glob(filepath.Join("..", "..", "migrations", "*.sql"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(files) == 0 {
+		log.Fatal("no files")
+	}
+
+	out := new(bytes.Buffer)
+
+	fmt.Fprint(out, `
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Code generated by gen_migrations.go; DO NOT EDIT.
+
+package migrations
+
+// files maps the names of the migration files to their contents.
+var files = map[string]string{
+`)
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Fprintf(out, "\t%q: %q,\n", filepath.Base(file), data)
+	}
+	fmt.Fprintf(out, "}\n")
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := ioutil.WriteFile(outfile, src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
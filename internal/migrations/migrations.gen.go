@@ -0,0 +1,137 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Code generated by gen_migrations.go; DO NOT EDIT.
+
+package migrations
+
+// files maps the names of the migration files to their contents.
+var files = map[string]string{
+	"000001_initial_schema_from_pg_dump.down.sql":                          "-- Copyright 2019 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nDROP TABLE\n    modules,\n    packages,\n    imports,\n    imports_unique,\n    licenses,\n    excluded_prefixes,\n    module_version_states,\n    search_documents,\n    alternative_module_paths,\n    experiments,\n    package_version_states,\n    version_map;\n\nDROP FUNCTION\n    hll_hash,\n    hll_zeros,\n    popular_search,\n    popular_search_go_mod,\n    trigger_modify_updated_at,\n    trigger_modify_packages_tsv_parent_directories,\n    trigger_modify_search_documents_tsv_parent_directories,\n    to_tsvector_parent_directories;\n\nDROP TYPE\n    version_type,\n    search_result;\n\nDROP TEXT SEARCH CONFIGURATION golang;\n",
+	"000001_initial_schema_from_pg_dump.up.sql":                            "-- Copyright 2019 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n--\n-- This schema migration was created by dumping the DB schema\n-- as of commit bc820754c5d2bce5c3cdb66515656afb5885a440.\n\nSET statement_timeout = 0;\nSET lock_timeout = 0;\nSET idle_in_transaction_session_timeout = 0;\nSET client_encoding = 'UTF8';\nSET standard_conforming_strings = on;\nSET check_function_bodies = on;\nSET xmloption = content;\nSET client_min_messages = warning;\nSET row_security = off;\n\nCREATE FUNCTION trigger_modify_updated_at() RETURNS trigger\n    LANGUAGE plpgsql\n    AS $$\nBEGIN\n  NEW.updated_at = NOW();\n  RETURN NEW;\nEND;\n$$;\nCOMMENT ON FUNCTION trigger_modify_updated_at IS\n'FUNCTION trigger_modify_updated_at sets the value of a column named updated_at to the current timestamp. This is used by the versions, packages, and search_documents tables as a trigger to set the value of updated_at.';\n\nCREATE FUNCTION to_tsvector_parent_directories(package_path text, module_path text) RETURNS tsvector\n    LANGUAGE plpgsql PARALLEL SAFE\n    AS $$\n  DECLARE\n    current_directory TEXT;\n    parent_directories TEXT;\n    sub_path TEXT;\n    sub_directories TEXT[][];\n  BEGIN\n    IF package_path = module_path THEN\n      RETURN module_path::tsvector;\n    END IF;\n\n    IF module_path = 'std' THEN\n      sub_path := package_path;\n    ELSE\n      sub_path := substr(package_path, length(module_path) + 2);\n      current_directory := module_path;\n      parent_directories := module_path;\n    END IF;\n\n    sub_directories := regexp_split_to_array(sub_path, '/');\n    FOR i IN 1..cardinality(sub_directories) LOOP\n      IF current_directory IS NULL THEN\n\tcurrent_directory := sub_directories[i];\n      ELSE\n        current_directory := COALESCE(current_directory, '') || '/' || sub_directories[i];\n      END IF;\n      parent_directories = COALESCE(parent_directories, '') || ' ' || current_directory;\n    END LOOP;\n    RETURN parent_directories::tsvector;\nEND;\n$$;\nCOMMENT ON FUNCTION to_tsvector_parent_directories IS\n'FUNCTION to_tsvector_parent_directories computes all directories that exist between module_path and package_path, inclusive of both module_path and package_path. Return the result as a tsvector.';\n\nCREATE TYPE version_type AS ENUM (\n    'release',\n    'prerelease',\n    'pseudo'\n);\nCOMMENT ON TYPE version_type IS\n'ENUM version_type specifies the version types expected for a given module version.';\n\nCREATE TABLE modules (\n    module_path text NOT NULL,\n    version text NOT NULL,\n    commit_time timestamp with time zone NOT NULL,\n    series_path text NOT NULL,\n    version_type version_type NOT NULL,\n    readme_file_path text,\n    readme_contents text,\n    source_info jsonb,\n    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,\n    updated_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,\n    sort_version text NOT NULL,\n    redistributable boolean NOT NULL,\n    has_go_mod boolean,\n    PRIMARY KEY (module_path, version)\n);\nCOMMENT ON TABLE modules IS\n'TABLE modules contains modules at a specific semantic version.';\nCOMMENT ON COLUMN modules.sort_version IS\n'COLUMN sort_version holds the version in a form suitable for use in ORDER BY.';\nCOMMENT ON COLUMN modules.redistributable IS\n'COLUMN redistributable says whether the module is redistributable.';\nCOMMENT ON COLUMN modules.has_go_mod IS\n'COLUMN has_go_mod records whether the module zip contains a go.mod file.';\n\nCREATE INDEX idx_modules_sort_version ON modules (sort_version DESC, version_type DESC);\nCOMMENT ON INDEX idx_modules_sort_version IS\n'INDEX idx_versions_semver_sort is used to sort versions in order of descending latest. It is used to get the latest version of a package/module and to fetch all versions of a package/module in semver order.';\n\n\nCREATE INDEX idx_modules_module_path_text_pattern_ops ON modules\n    (module_path text_pattern_ops);\nCOMMENT ON INDEX idx_modules_module_path_text_pattern_ops IS\n'INDEX idx_versions_module_path_text_pattern_ops is used to improve performance of LIKE statements for module_path. It is used to fetch directories matching a given module_path prefix.';\n\nCREATE INDEX idx_modules_version_type ON modules (version_type);\nCOMMENT ON INDEX idx_modules_version_type IS\n'INDEX idx_versions_version_type is used when fetching versions for a given version_type.';\n\nCREATE TRIGGER set_updated_at BEFORE INSERT OR UPDATE ON modules\n     FOR EACH ROW EXECUTE PROCEDURE trigger_modify_updated_at();\nCOMMENT ON TRIGGER set_updated_at ON modules IS\n'TRIGGER set_updated_at updates the value of the updated_at column to the current timestamp whenever a row is inserted or updated to the table.';\n\nCREATE TABLE packages (\n    path text NOT NULL,\n    module_path text NOT NULL,\n    version text NOT NULL,\n    commit_time timestamp with time zone NOT NULL,\n    name text NOT NULL,\n    synopsis text,\n    license_types text[],\n    license_paths text[],\n    v1_path text NOT NULL,\n    goos text NOT NULL,\n    goarch text NOT NULL,\n    redistributable boolean DEFAULT false NOT NULL,\n    documentation text,\n    tsv_parent_directories tsvector,\n    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,\n    updated_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,\n    PRIMARY KEY (path, module_path, version),\n    FOREIGN KEY (module_path, version) REFERENCES modules(module_path, version) ON DELETE CASCADE\n);\nCOMMENT ON TABLE packages IS\n'TABLE packages contains packages in a specific module version.';\nCOMMENT ON COLUMN packages.commit_time IS\n'commit_time is the same as verions.commit_time. It is added here so that we can reduce the number of joins in our queries.';\nCOMMENT ON COLUMN packages.tsv_parent_directories IS\n'tsv_parent_directories should always be NOT NULL, but it is populated by a trigger, so it will be initially NULL on insert.';\n\nCREATE INDEX idx_packages_v1_path ON packages (v1_path);\nCOMMENT ON INDEX idx_packages_v1_path IS\n'INDEX idx_packages_v1_path is used to get all of the packages in a series.';\n\nCREATE INDEX idx_packages_module_path_text_pattern_ops ON packages (module_path text_pattern_ops);\nCOMMENT ON INDEX idx_packages_module_path_text_pattern_ops IS\n'INDEX idx_packages_module_path_text_pattern_ops is used to improve performance of LIKE statements for module_path. It is used to fetch directories matching a given module_path prefix.';\n\nCREATE INDEX idx_packages_path_text_pattern_ops ON packages (path text_pattern_ops);\n\nCREATE INDEX idx_packages_tsv_parent_directories ON packages USING gin (tsv_parent_directories);\nCOMMENT ON INDEX idx_packages_tsv_parent_directories IS\n'INDEX idx_packages_tsv_parent_directories is used to search for packages that match a given prefix. These prefixes are stored as a tsv_vector type in tsv_parent_directories. This is used to fetch all packages in a given directory.';\n\nCREATE FUNCTION trigger_modify_packages_tsv_parent_directories() RETURNS TRIGGER\n    LANGUAGE plpgsql\n    AS $$\n  BEGIN\n    NEW.tsv_parent_directories = to_tsvector_parent_directories(NEW.path, NEW.module_path);\n  RETURN NEW;\nEND;\n$$;\nCOMMENT ON FUNCTION trigger_modify_packages_tsv_parent_directories IS\n'FUNCTION trigger_modify_packages_tsv_parent_directories invokes FUNCTION to_tsvector_parent_directories and sets the value of tsv_parent_directories to the output.';\n\nCREATE TRIGGER set_tsv_parent_directories BEFORE INSERT ON packages FOR EACH ROW EXECUTE PROCEDURE trigger_modify_packages_tsv_parent_directories();\nCOMMENT ON TRIGGER set_tsv_parent_directories ON packages IS\n'TRIGGER set_tsv_parent_directories sets the value of tsv_parent_directories to the output of FUNCTION trigger_modify_search_documents_tsv_parent_directories when a new row in inserted.';\n\n\nCREATE TRIGGER set_updated_at BEFORE INSERT OR UPDATE ON packages FOR EACH ROW EXECUTE PROCEDURE trigger_modify_updated_at();\nCOMMENT ON TRIGGER set_updated_at ON packages IS\n'TRIGGER set_updated_at updates the value of the updated_at column to the current timestamp whenever a row is inserted or updated to the table.';\n\nCREATE TABLE imports (\n    from_path text NOT NULL,\n    from_module_path text NOT NULL,\n    from_version text NOT NULL,\n    to_path text NOT NULL,\n    PRIMARY KEY (to_path, from_path, from_version, from_module_path),\n    FOREIGN KEY (from_path, from_module_path, from_version)\n        REFERENCES packages(path, module_path, version) ON DELETE CASCADE\n);\nCOMMENT ON TABLE imports IS\n'TABLE imports contains the imports for a package in the packages table. Package (from_path), in module (from_module_path) at version (from_version), imports package (to_path). We do not store the version and module at which to_path is imported because it is hard to compute.';\n\nCREATE INDEX idx_imports_from_path_from_version ON imports (from_path, from_version);\nCOMMENT ON INDEX idx_imports_from_path_from_version IS\n'INDEX idx_imports_from_path_from_version is used to improve performance of the imports tab.';\n\nCREATE TABLE imports_unique (\n    to_path text NOT NULL,\n    from_path text NOT NULL,\n    from_module_path text NOT NULL,\n    PRIMARY KEY (to_path, from_path, from_module_path)\n);\nCOMMENT ON TABLE imports_unique IS\n'TABLE imports_unique contains the imports for a unique import_path in the packages table. The from_version is dropped; each row says that package from_path in some version of from_module_path imports (some version of) to_path. Used to speed up imported-by computations.';\n\nCREATE TABLE licenses (\n    module_path text NOT NULL,\n    version text NOT NULL,\n    file_path text NOT NULL,\n    contents text NOT NULL,\n    types text[],\n    coverage jsonb,\n    PRIMARY KEY (module_path, version, file_path),\n    FOREIGN KEY (module_path, version) REFERENCES modules(module_path, version) ON DELETE CASCADE\n);\nCOMMENT ON TABLE licenses IS\n'TABLE licenses contains the license data for a given module version.';\nCOMMENT ON COLUMN licenses.coverage IS\n'COLUMN coverage contains the JSON-serialized contents of the licensecheck.Coverage value returned from calling licencecheck.Cover.';\n\nCREATE TABLE excluded_prefixes (\n    prefix text NOT NULL,\n    created_by text NOT NULL,\n    reason text NOT NULL,\n    created_at timestamp with time zone DEFAULT now(),\n    CONSTRAINT excluded_prefixes_created_by_check CHECK ((created_by <> ''::text)),\n    CONSTRAINT excluded_prefixes_prefix_check CHECK ((prefix <> ''::text)),\n    CONSTRAINT excluded_prefixes_reason_check CHECK ((reason <> ''::text)),\n    PRIMARY KEY (prefix)\n);\nCOMMENT ON TABLE excluded_prefixes IS\n'TABLE excluded_prefixes contains the prefixes of modules or groups of modules we exclude from serving and processing. This is used to deal with attacks.';\n\nCREATE TABLE module_version_states (\n    module_path text NOT NULL,\n    version text NOT NULL,\n    status integer DEFAULT 0 NOT NULL,\n    error text DEFAULT ''::text NOT NULL,\n    try_count integer DEFAULT 0 NOT NULL,\n    last_processed_at timestamp with time zone,\n    next_processed_after timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,\n    index_timestamp timestamp with time zone NOT NULL,\n    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,\n    app_version text DEFAULT ''::text NOT NULL,\n    sort_version text NOT NULL,\n    go_mod_path text DEFAULT ''::text NOT NULL,\n    PRIMARY KEY (module_path, version)\n);\nCOMMENT ON TABLE module_version_states IS\n'TABLE module_version_states is used by the ETL to record the state of every module we have seen from the proxy index.';\nCOMMENT ON COLUMN module_version_states.sort_version IS\n'COLUMN sort_version holds the version in a form suitable for use in ORDER BY. The string format is described in internal/version.ForSorting.';\nCOMMENT ON COLUMN module_version_states.go_mod_path IS\n'COLUMN go_mod_path holds the module path from the go.mod file.';\n\nCREATE INDEX idx_module_version_states_index_timestamp ON module_version_states (index_timestamp DESC);\nCOMMENT ON INDEX idx_module_version_states_index_timestamp IS\n'INDEX idx_module_version_states_index_timestamp is used to get the last time a module version was fetched from the the module index.';\n\nCREATE INDEX idx_module_version_states_last_processed_at ON module_version_states (last_processed_at);\nCOMMENT ON INDEX idx_module_version_states_last_processed_at IS\n'INDEX idx_module_version_states_last_processed_at is used to get the next time at which a module version should be retried for processing.';\n\nCREATE INDEX idx_module_version_states_next_processed_after ON module_version_states (next_processed_after);\nCOMMENT ON INDEX idx_module_version_states_next_processed_after IS\n'INDEX idx_module_version_states_next_processed_after is used to get the next time at which a module version should be retried for processing.';\n\nCREATE INDEX idx_module_version_states_sort_version ON module_version_states (sort_version DESC);\nCOMMENT ON INDEX idx_module_version_states_sort_version IS\n'INDEX idx_module_version_states_sort_version is used to sort by version, to determine when a module version should be retried for processing.';\n\nCREATE TABLE search_documents (\n    package_path text NOT NULL,\n    module_path text NOT NULL,\n    version text NOT NULL,\n    commit_time timestamp with time zone NOT NULL,\n    name text NOT NULL,\n    synopsis text,\n    license_types text[],\n    imported_by_count integer DEFAULT 0 NOT NULL,\n    redistributable boolean NOT NULL,\n    hll_register integer,\n    hll_leading_zeros integer,\n    tsv_parent_directories tsvector,\n    tsv_search_tokens tsvector NOT NULL,\n    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,\n    updated_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,\n    version_updated_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,\n    imported_by_count_updated_at timestamp with time zone,\n    has_go_mod boolean,\n    PRIMARY KEY (package_path),\n    FOREIGN KEY (package_path, module_path, version)\n        REFERENCES packages(path, module_path, version) ON DELETE CASCADE\n);\nCOMMENT ON TABLE search_documents IS\n'TABLE search_documents contains a record for the latest version of each package. It is used to generate search results.';\nCOMMENT ON COLUMN search_documents.hll_register IS\n'hll_* columns are added to help implement cardinality estimation using the hyperloglog algorithm. hll_register is the randomized bucket for this record.';\nCOMMENT ON COLUMN search_documents.hll_leading_zeros IS\n'hll_* columns are added to help implement cardinality estimation using the hyperloglog algorithm. hll_leading_zeros is the number of leading zeros in the binary representation of hll_hash(package_path).';\nCOMMENT ON COLUMN search_documents.has_go_mod IS\n'COLUMN has_go_mod records whether the module zip contains a go.mod file.';\n\nCREATE INDEX idx_imported_by_count_desc ON search_documents (imported_by_count DESC);\nCOMMENT ON INDEX idx_imported_by_count_desc IS\n'INDEX idx_imported_by_count_desc is used by popular_search to execute a partial scan of popular search documents.';\n\nCREATE INDEX idx_hll_register_leading_zeros ON search_documents (hll_register, hll_leading_zeros DESC);\nCOMMENT ON INDEX idx_hll_register_leading_zeros IS\n'INDEX idx_hll_register_leading_zeros allows us to quickly find the maximum number of leading zeros among search documents in each register matching a query, which is necessary for hyperloglog cardinality estimation.';\n\nCREATE INDEX idx_search_documents_imported_by_count_updated_at ON search_documents (imported_by_count_updated_at);\nCOMMENT ON INDEX idx_search_documents_imported_by_count_updated_at IS\n'INDEX idx_search_documents_imported_by_count_updated_at index is used for incremental update of imported_by counts.';\n\nCREATE INDEX idx_search_documents_module_path_version_package_path ON search_documents\n    (package_path, module_path, version);\nCOMMENT ON INDEX idx_search_documents_module_path_version_package_path IS\n'INDEX idx_search_documents_module_path_version_package_path is used for the FK reference to packages.';\n\nCREATE INDEX idx_search_documents_tsv_parent_directories ON search_documents USING gin (tsv_parent_directories);\nCOMMENT ON INDEX idx_search_documents_tsv_parent_directories IS\n'INDEX idx_search_documents_tsv_parent_directories is used to search for packages that match a given prefix. These prefixes are stored as a tsv_vector type in tsv_parent_directories. This is used to fetch all packages in a given directory.';\n\nCREATE INDEX idx_search_documents_tsv_search_tokens ON search_documents USING gin (tsv_search_tokens);\nCOMMENT ON INDEX idx_search_documents_tsv_search_tokens IS\n'INDEX idx_search_documents_tsv_search_tokens improves performance for full-text search.';\n\nCREATE INDEX idx_search_documents_version_updated_at ON search_documents (version_updated_at);\nCOMMENT ON INDEX idx_search_documents_version_updated_at IS\n'INDEX idx_search_documents_version_updated_at is used for incremental update of imported_by counts, in order to determine when the latest version of a package was last updated.';\n\nCREATE TRIGGER set_updated_at BEFORE INSERT OR UPDATE ON search_documents\n    FOR EACH ROW EXECUTE PROCEDURE trigger_modify_updated_at();\nCOMMENT ON TRIGGER set_updated_at ON search_documents IS\n'TRIGGER set_updated_at updates the value of the updated_at column to the current timestamp whenever a row is inserted or updated to the table.';\n\nCREATE FUNCTION trigger_modify_search_documents_tsv_parent_directories() RETURNS trigger\n    LANGUAGE plpgsql\n    AS $$\n  BEGIN\n    NEW.tsv_parent_directories = to_tsvector_parent_directories(NEW.package_path, NEW.module_path);\n  RETURN NEW;\nEND;\n$$;\nCOMMENT ON FUNCTION trigger_modify_search_documents_tsv_parent_directories IS\n'FUNCTION trigger_modify_search_documents_tsv_parent_directories invokes FUNCTION to_tsvector_parent_directories and sets the value of tsv_parent_directories to the output.';\n\nCREATE TRIGGER set_tsv_parent_directories BEFORE INSERT ON search_documents\n\tFOR EACH ROW EXECUTE PROCEDURE trigger_modify_search_documents_tsv_parent_directories();\nCOMMENT ON TRIGGER set_tsv_parent_directories ON search_documents IS\n'TRIGGER set_tsv_parent_directories sets the value of tsv_parent_directories to the output of FUNCTION trigger_modify_search_documents_tsv_parent_directories when a new row in inserted.';\n\nCREATE FUNCTION hll_hash(text) RETURNS bigint\n    LANGUAGE sql PARALLEL SAFE\n    AS $_$\n\t-- This is somewhat a hack, since there is no from_hex function in postgres.\n\t-- Take the first 64 bits of the md5 hash by converting the hexadecimal\n\t-- string to bitfield, and then bigint.\n\tSELECT ('x'||substr(md5($1),1,16))::BIT(64)::BIGINT;\n$_$;\nCOMMENT ON FUNCTION hll_hash IS\n'FUNCTION hll_hash is a 64-bit integral hash function, which is used in implementing the hyperloglog cardinality estimation algorithm.';\n\nCREATE FUNCTION hll_zeros(bigint) RETURNS integer\n    LANGUAGE plpgsql PARALLEL SAFE\n    AS $_$\nBEGIN\n\tIF $1 < 0 THEN\n\t\tRETURN 0;\n\tEND IF;\n\t-- For bigints, taking log(2, $1) is too inaccurate due to floating point\n\t-- issues. Specifically log(2, 1<<63-1) == 63.0...\n\tFOR i IN 0..62 LOOP\n\t\tIF ((1::BIGINT<<i) - 1) >= $1 THEN\n\t\t\tRETURN 64-i;\n\t\tEND IF;\n\tEND LOOP;\n\tRETURN 1;\nEND; $_$;\nCOMMENT ON FUNCTION hll_zeros(bigint) IS\n'FUNCTION hll_zeros returns the number of leading zeros in the binary representation of the given bigint.';\n\nCREATE TYPE search_result AS (\n\tpackage_path text,\n\tmodule_path text,\n\tversion text,\n\tcommit_time timestamp with time zone,\n\timported_by_count integer,\n\tscore double precision\n);\nCOMMENT ON TYPE search_result IS\n'TYPE search_result is used to simplify the popular_search function.';\n\nCREATE FUNCTION popular_search(rawquery text, lim integer, off integer) RETURNS SETOF search_result\n    LANGUAGE plpgsql\n    AS $$\n\tDECLARE cur CURSOR(query TSQUERY) FOR\n\t\tSELECT\n\t\t\tpackage_path,\n\t\t\tmodule_path,\n\t\t\tversion,\n\t\t\tcommit_time,\n\t\t\timported_by_count,\n\t\t\t(\n\t\t\t\tts_rank(tsv_search_tokens, query) *\n\t\t\t\tln(exp(1)+imported_by_count) *\n\t\t\t\tCASE WHEN redistributable THEN 1 ELSE 0.5 END *\n\t\t\t\t-- Rather than add this `tsv_search_tokens @@ query` check to a\n\t\t\t\t-- where clause, we simply annihilate the score. Adding it to the\n\t\t\t\t-- where clause caused the query planner to eventually decide to\n\t\t\t\t-- use the tsv_search_token gin index rather than the popular\n\t\t\t\t-- index, which is exactly what this stored proc is trying to\n\t\t\t\t-- avoid.\n\t\t\t\t-- It seems like this should be redundant with the ts_rank factor\n\t\t\t\t-- above, but in fact it is possible for ts_rank to be nonzero, yet\n\t\t\t\t-- tsv_search_tokens @@ query is false (I think because ts_rank doesn't\n\t\t\t\t-- have special handling for AND or OR conjunctions).\n\t\t\t\tCASE WHEN tsv_search_tokens @@ query THEN 1 ELSE 0 END\n\t\t\t) score\n\t\t\tFROM search_documents\n\t\t\t-- This should use the popular document index.\n\t\t\tORDER BY imported_by_count DESC;\n\t-- top is the top search results, sorted by score descending, commit time\n\t-- descending, then package_path ascending.\n\ttop search_result[];\n\t-- res is the current search result.\n\tres search_result;\n\t-- last_idx is the index of the last element in top.\n\tlast_idx INT;\nBEGIN\n\tlast_idx := lim+off;\n\ttop := array_fill(NULL::search_result, array[last_idx]);\n\tOPEN cur(query := websearch_to_tsquery(rawquery));\n\tFETCH cur INTO res;\n\tWHILE found LOOP\n\t\tIF top[last_idx] IS NULL OR res.score >= top[last_idx].score THEN\n\t\t\t-- Insert res into top, maintaining sort order.\n\t\t\tFOR i IN 1..last_idx LOOP\n\t\t\t\t-- We want to preserve order by score desc, commit_time desc,\n\t\t\t\t-- package_path asc, so insert res as soon as it sorted before top[i]\n\t\t\t\t-- according to this ordering.\n\t\t\t\tIF top[i] IS NULL OR\n\t\t\t\t\t(res.score > top[i].score) OR\n\t\t\t\t\t(res.score = top[i].score AND res.commit_time > top[i].commit_time) OR\n\t\t\t\t\t(res.score = top[i].score AND res.commit_time = top[i].commit_time AND\n\t\t\t\t\t res.package_path < top[i].package_path) THEN\n\t\t\t\t\ttop := (top[1:i-1] || res) || top[i:last_idx-1];\n\t\t\t\t\tEXIT;\n\t\t\t\tEND IF;\n\t\t\tEND LOOP;\n\t\tEND IF;\n\t\tIF top[last_idx].score > ln(exp(1)+res.imported_by_count) THEN\n\t\t\t-- No subsequent document can be scored higher than our lowest scoring\n\t\t\t-- document, as top[last_idx].score > 1.0*ln(e+imported_by_count), and\n\t\t\t-- for all subsequent records ts_rank <= 1.0 and ln(e+imported_by_count)\n\t\t\t-- is monotonically decreasing.\n\t\t\t-- So we're done.\n\t\t\tEXIT;\n\t\tEND IF;\n\t\tFETCH cur INTO res;\n\tEND LOOP;\n\tCLOSE cur;\n\tRETURN QUERY SELECT * FROM UNNEST(top[off+1:last_idx])\n\t\tWHERE package_path IS NOT NULL AND score > 0.1;\nEND; $$;\nCOMMENT ON FUNCTION popular_search(rawquery text, lim integer, off integer) IS\n'FUNCTION popular_search is used to generate results for search. It is implemented as a stored function, so that we can use a cursor to scan search documents procedurally, and stop scanning early, whenever our search results are provably correct.';\n\n\nCREATE TEXT SEARCH CONFIGURATION golang (\n    PARSER = pg_catalog.\"default\" );\n\nALTER TEXT SEARCH CONFIGURATION golang\n    ADD MAPPING FOR asciiword WITH simple, english_stem;\n\nALTER TEXT SEARCH CONFIGURATION golang\n    ADD MAPPING FOR word WITH english_stem;\n\nALTER TEXT SEARCH CONFIGURATION golang\n    ADD MAPPING FOR numword WITH simple;\n\nALTER TEXT SEARCH CONFIGURATION golang\n    ADD MAPPING FOR email WITH simple;\n\nALTER TEXT SEARCH CONFIGURATION golang\n    ADD MAPPING FOR url WITH simple;\n\nALTER TEXT SEARCH CONFIGURATION golang\n    ADD MAPPING FOR host WITH simple;\n\nALTER TEXT SEARCH CONFIGURATION golang\n    ADD MAPPING FOR sfloat WITH simple;\n\nALTER TEXT SEARCH CONFIGURATION golang\n    ADD MAPPING FOR version WITH simple;\n\nALTER TEXT SEARCH CONFIGURATION golang\n    ADD MAPPING FOR hword_numpart WITH simple;\n\nALTER TEXT SEARCH CONFIGURATION golang\n    ADD MAPPING FOR hword_part WITH english_stem;\n\nALTER TEXT SEARCH CONFIGURATION golang\n    ADD MAPPING FOR hword_asciipart WITH english_stem;\n\nALTER TEXT SEARCH CONFIGURATION golang\n    ADD MAPPING FOR numhword WITH simple;\n\nALTER TEXT SEARCH CONFIGURATION golang\n    ADD MAPPING FOR asciihword WITH english_stem;\n\nALTER TEXT SEARCH CONFIGURATION golang\n    ADD MAPPING FOR hword WITH english_stem;\n\nALTER TEXT SEARCH CONFIGURATION golang\n    ADD MAPPING FOR file WITH simple;\n\nALTER TEXT SEARCH CONFIGURATION golang\n    ADD MAPPING FOR \"float\" WITH simple;\n\nALTER TEXT SEARCH CONFIGURATION golang\n    ADD MAPPING FOR \"int\" WITH simple;\n\nALTER TEXT SEARCH CONFIGURATION golang\n    ADD MAPPING FOR uint WITH simple;\n\nCOMMENT ON TEXT SEARCH CONFIGURATION golang IS\n'TEXT SEARCH CONFIGURATION golang is a custom search configuration used when creating tsvector for search. The url_path token type is remove, so that \"github.com/foo/bar@v1.2.3\" is indexed only as the full URL string, and not also\"/foo/bar@v1.2.3\". The asciiword token type is set to a \"simple,english_stem\" mapping, so that \"plural\" words will be indexed without stemming. This idea came from the \"Morphological and Exact Search\" section here: https://asp437.github.io/posts/flexible-fts.html.';\n\nCREATE FUNCTION popular_search_go_mod(rawquery text, lim integer, off integer, redist_factor real, go_mod_factor real) RETURNS SETOF search_result\n    LANGUAGE plpgsql\n    AS $$\n\tDECLARE cur CURSOR(query TSQUERY) FOR\n\t\tSELECT\n\t\t\tpackage_path,\n\t\t\tmodule_path,\n\t\t\tversion,\n\t\t\tcommit_time,\n\t\t\timported_by_count,\n\t\t\t(\n\t\t\t\tts_rank(tsv_search_tokens, query) *\n\t\t\t\tln(exp(1)+imported_by_count) *\n\t\t\t\tCASE WHEN redistributable THEN 1 ELSE redist_factor END *\n\t\t\t\tCASE WHEN COALESCE(has_go_mod, true) THEN 1 ELSE go_mod_factor END *\n\t\t\t\tCASE WHEN tsv_search_tokens @@ query THEN 1 ELSE 0 END\n\t\t\t) score\n\t\t\tFROM search_documents\n\t\t\tORDER BY imported_by_count DESC;\n\ttop search_result[];\n\tres search_result;\n\tlast_idx INT;\nBEGIN\n\tlast_idx := lim+off;\n\ttop := array_fill(NULL::search_result, array[last_idx]);\n\tOPEN cur(query := websearch_to_tsquery(rawquery));\n\tFETCH cur INTO res;\n\tWHILE found LOOP\n\t\tIF top[last_idx] IS NULL OR res.score >= top[last_idx].score THEN\n\t\t\tFOR i IN 1..last_idx LOOP\n\t\t\t\tIF top[i] IS NULL OR\n\t\t\t\t\t(res.score > top[i].score) OR\n\t\t\t\t\t(res.score = top[i].score AND res.commit_time > top[i].commit_time) OR\n\t\t\t\t\t(res.score = top[i].score AND res.commit_time = top[i].commit_time AND\n\t\t\t\t\t res.package_path < top[i].package_path) THEN\n\t\t\t\t\ttop := (top[1:i-1] || res) || top[i:last_idx-1];\n\t\t\t\t\tEXIT;\n\t\t\t\tEND IF;\n\t\t\tEND LOOP;\n\t\tEND IF;\n\t\tIF top[last_idx].score > ln(exp(1)+res.imported_by_count) THEN\n\t\t\tEXIT;\n\t\tEND IF;\n\t\tFETCH cur INTO res;\n\tEND LOOP;\n\tCLOSE cur;\n\tRETURN QUERY SELECT * FROM UNNEST(top[off+1:last_idx])\n\t\tWHERE package_path IS NOT NULL AND score > 0.1;\nEND; $$;\nCOMMENT ON FUNCTION popular_search_go_mod(rawquery text, lim integer, off integer, redist_factor real, go_mod_factor real) IS\n'FUNCTION popular_search_go_mod is identical to popular_search except for the additional multiplier for the has_go_mod filed.';\n\n\nSET default_tablespace = '';\nSET default_with_oids = false;\n\n\nCREATE TABLE alternative_module_paths (\n    alternative text NOT NULL,\n    canonical text NOT NULL,\n    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,\n    UNIQUE(alternative, canonical)\n);\nCOMMENT ON TABLE alternative_module_paths IS\n'TABLE alternative_module_paths contains module_paths that are known to have (1) a vanity import path, such as github.com/rsc/quote vs rsc.io/quote (2) a mismatch between the module path in the go.mod and repository, such as in the case of forks, or (3) a case insensitive spelling, such as in the case of github.com/sirupsen/logrus vs github.com/Sirupsen/logrus. It is used to filter out modules with the alternative path from the discovery site dataset.';\nCOMMENT ON COLUMN alternative_module_paths.alternative IS\n'COLUMN alternative contains the path prefix of packages that should be filtered out from the discovery site search results. For example, github.com/google/go-cloud is the alternative prefix for all packages in the modules gocloud.dev and github.com/google/go-cloud.';\nCOMMENT ON COLUMN alternative_module_paths.canonical IS\n'COLUMN canonical contains the module path that can be found in the go.mod file of a package. For example, gocloud.dev is the canonical prefix for all packages in gocloud.dev and github.com/google/go-cloud.';\n\n\nCREATE TABLE experiments (\n    name text NOT NULL,\n    rollout integer DEFAULT 0 NOT NULL,\n    description text NOT NULL,\n    PRIMARY KEY (name),\n    CONSTRAINT experiments_rollout_check CHECK (((rollout >= 0) AND (rollout <= 100)))\n);\nCOMMENT ON TABLE experiments IS\n'TABLE experiments contains data for running experiments.';\nCOMMENT ON COLUMN experiments.name IS\n'COLUMN name is the name of the experiment.';\nCOMMENT ON COLUMN experiments.rollout IS\n'COLUMN rollout is the percentage of total requests that are included for the experiment.';\nCOMMENT ON COLUMN experiments.description IS\n'COLUMN description describes the experiment.';\n\nCREATE TABLE package_version_states (\n    package_path text NOT NULL,\n    module_path text NOT NULL,\n    version text NOT NULL,\n    status integer NOT NULL,\n    error text,\n    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,\n    updated_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,\n    PRIMARY KEY (package_path, module_path, version),\n    FOREIGN KEY (module_path, version) REFERENCES module_version_states(module_path, version) ON DELETE CASCADE\n);\nCOMMENT ON TABLE package_version_states IS\n'TABLE package_version_states is used to record the state of every package we have seen from the proxy.';\n\nCREATE TRIGGER set_updated_at BEFORE INSERT OR UPDATE ON package_version_states\n    FOR EACH ROW EXECUTE PROCEDURE trigger_modify_updated_at();\nCOMMENT ON TRIGGER set_updated_at ON package_version_states IS\n'TRIGGER set_updated_at updates the value of the updated_at column to the current timestamp whenever a row is inserted or updated to the table.';\n\nCREATE TABLE version_map (\n    module_path text NOT NULL,\n    requested_version text NOT NULL,\n    resolved_version text,\n    status integer NOT NULL,\n    error text,\n    created_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,\n    updated_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,\n    sort_version text,\n    PRIMARY KEY (module_path, requested_version)\n);\nCOMMENT ON TABLE version_map IS\n'TABLE version_map contains data about a user-requested path and the semantic version that it resolves to. It is used to support fetching frontend detail pages using module queries.';\nCOMMENT ON COLUMN version_map.requested_version IS\n'COLUMN requested_version is the version that was requested by a user from the frontend. It may or may not resolve to a semantic version.';\nCOMMENT ON COLUMN version_map.resolved_version IS\n'COLUMN resolved_version is the semantic version that a requested_version resolves to.';\nCOMMENT ON COLUMN version_map.status IS\n'COLUMN status is the status returned by the ETL when fetching the module version.';\nCOMMENT ON COLUMN version_map.error IS\n'COLUMN status is the error that occurred when fetching the module version, in cases when status != 200.';\n\n\nCREATE TRIGGER set_updated_at BEFORE INSERT OR UPDATE ON version_map\n    FOR EACH ROW EXECUTE PROCEDURE trigger_modify_updated_at();\n",
+	"000002_add_modules_identity.down.sql":                                 "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nALTER table modules DROP COLUMN id;\n\nEND;\n",
+	"000002_add_modules_identity.up.sql":                                   "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nALTER table modules ADD COLUMN id integer GENERATED ALWAYS AS IDENTITY UNIQUE;\n\nEND;\n",
+	"000003_add_paths_table.down.sql":                                      "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nDROP TABLE paths;\n\nEND;\n",
+	"000003_add_paths_table.up.sql":                                        "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nCREATE TABLE paths (\n    id              INTEGER GENERATED ALWAYS AS IDENTITY PRIMARY KEY,\n    path            text NOT NULL,\n    module_id       INTEGER NOT NULL REFERENCES modules (id) ON DELETE CASCADE,\n    v1_path         text NOT NULL, -- used to compute package history; empty for non-packages\n    name            text DEFAULT '' NOT NULL, -- empty for non-packages\n    license_types   text[],\n    license_paths   text[],\n    redistributable boolean DEFAULT false NOT NULL,\n    created_at      timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,\n    updated_at      timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,\n\n    UNIQUE (path, module_id)\n);\nCOMMENT ON TABLE paths IS\n'TABLE paths contains every module, package and directory path at every version.';\n\nCREATE TRIGGER set_updated_at BEFORE INSERT OR UPDATE ON paths\n    FOR EACH ROW EXECUTE PROCEDURE trigger_modify_updated_at();\nCOMMENT ON TRIGGER set_updated_at ON paths IS\n'TRIGGER set_updated_at updates the value of the updated_at column to the current timestamp whenever a row is inserted or updated to the table.';\n\nCREATE INDEX idx_paths_path ON paths (path);\nCOMMENT ON INDEX idx_paths_path is\n'INDEX idx_paths_path is used to get path information from a path.';\n\nCREATE INDEX idx_paths_v1_path ON paths USING btree (v1_path);\nCOMMENT ON INDEX idx_paths_v1_path IS\n'INDEX idx_paths_v1_path is used to get all of the packages in a series.';\n\n\nEND;\n",
+	"000004_redo_golang_search_config.down.sql":                            "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nDROP TEXT SEARCH CONFIGURATION golang;\n\nCREATE TEXT SEARCH CONFIGURATION golang (\n    PARSER = pg_catalog.\"default\" );\n\nALTER TEXT SEARCH CONFIGURATION golang\n    ADD MAPPING FOR asciiword WITH simple, english_stem;\n\nALTER TEXT SEARCH CONFIGURATION golang\n    ADD MAPPING FOR word WITH english_stem;\n\nALTER TEXT SEARCH CONFIGURATION golang\n    ADD MAPPING FOR numword WITH simple;\n\nALTER TEXT SEARCH CONFIGURATION golang\n    ADD MAPPING FOR email WITH simple;\n\nALTER TEXT SEARCH CONFIGURATION golang\n    ADD MAPPING FOR url WITH simple;\n\nALTER TEXT SEARCH CONFIGURATION golang\n    ADD MAPPING FOR host WITH simple;\n\nALTER TEXT SEARCH CONFIGURATION golang\n    ADD MAPPING FOR sfloat WITH simple;\n\nALTER TEXT SEARCH CONFIGURATION golang\n    ADD MAPPING FOR version WITH simple;\n\nALTER TEXT SEARCH CONFIGURATION golang\n    ADD MAPPING FOR hword_numpart WITH simple;\n\nALTER TEXT SEARCH CONFIGURATION golang\n    ADD MAPPING FOR hword_part WITH english_stem;\n\nALTER TEXT SEARCH CONFIGURATION golang\n    ADD MAPPING FOR hword_asciipart WITH english_stem;\n\nALTER TEXT SEARCH CONFIGURATION golang\n    ADD MAPPING FOR numhword WITH simple;\n\nALTER TEXT SEARCH CONFIGURATION golang\n    ADD MAPPING FOR asciihword WITH english_stem;\n\nALTER TEXT SEARCH CONFIGURATION golang\n    ADD MAPPING FOR hword WITH english_stem;\n\nALTER TEXT SEARCH CONFIGURATION golang\n    ADD MAPPING FOR file WITH simple;\n\nALTER TEXT SEARCH CONFIGURATION golang\n    ADD MAPPING FOR \"float\" WITH simple;\n\nALTER TEXT SEARCH CONFIGURATION golang\n    ADD MAPPING FOR \"int\" WITH simple;\n\nALTER TEXT SEARCH CONFIGURATION golang\n    ADD MAPPING FOR uint WITH simple;\n\nCOMMENT ON TEXT SEARCH CONFIGURATION golang IS\n'TEXT SEARCH CONFIGURATION golang is a custom search configuration used when creating tsvector for search. The url_path token type is remove, so that \"github.com/foo/bar@v1.2.3\" is indexed only as the full URL string, and not also\"/foo/bar@v1.2.3\". The asciiword token type is set to a \"simple,english_stem\" mapping, so that \"plural\" words will be indexed without stemming. This idea came from the \"Morphological and Exact Search\" section here: https://asp437.github.io/posts/flexible-fts.html.';\n\n\nEND;\n",
+	"000004_redo_golang_search_config.up.sql":                              "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nDROP TEXT SEARCH CONFIGURATION golang;\n\nDROP TEXT SEARCH DICTIONARY IF EXISTS simple_english;\n\nCREATE TEXT SEARCH CONFIGURATION golang (COPY = pg_catalog.english);\n\nCREATE TEXT SEARCH DICTIONARY simple_english (\n    TEMPLATE = pg_catalog.simple,\n    STOPWORDS = english\n);\n\nALTER TEXT SEARCH CONFIGURATION golang\n    ALTER MAPPING FOR asciiword, asciihword, hword_asciipart, numword\n    WITH simple_english;\n\nALTER TEXT SEARCH CONFIGURATION golang\n    DROP MAPPING FOR url_path;\n\n\nCOMMENT ON TEXT SEARCH CONFIGURATION golang IS\n'TEXT SEARCH CONFIGURATION golang is a custom search configuration used when creating tsvector for search.\nThe url_path token type is removed, so that \"github.com/foo/bar@v1.2.3\" is indexed only as the full URL string,\nand not also\"/foo/bar@v1.2.3\".\nThe ASCII token types are set to a \"simple_english\" mapping, so that \"plural\" words like Postgres and NATS\nwill be indexed without stemming.\nThis idea came from the \"Morphological and Exact Search\" section here:\nhttps://asp437.github.io/posts/flexible-fts.html.';\n\nEND;\n",
+	"000005_change_b_weight.down.sql":                                      "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nCREATE OR REPLACE FUNCTION popular_search(rawquery text, lim integer, off integer) RETURNS SETOF search_result\n    LANGUAGE plpgsql\n    AS $$\n\tDECLARE cur CURSOR(query TSQUERY) FOR\n\t\tSELECT\n\t\t\tpackage_path,\n\t\t\tmodule_path,\n\t\t\tversion,\n\t\t\tcommit_time,\n\t\t\timported_by_count,\n\t\t\t(\n\t\t\t\tts_rank(tsv_search_tokens, query) *\n\t\t\t\tln(exp(1)+imported_by_count) *\n\t\t\t\tCASE WHEN redistributable THEN 1 ELSE 0.5 END *\n\t\t\t\t-- Rather than add this `tsv_search_tokens @@ query` check to a\n\t\t\t\t-- where clause, we simply annihilate the score. Adding it to the\n\t\t\t\t-- where clause caused the query planner to eventually decide to\n\t\t\t\t-- use the tsv_search_token gin index rather than the popular\n\t\t\t\t-- index, which is exactly what this stored proc is trying to\n\t\t\t\t-- avoid.\n\t\t\t\t-- It seems like this should be redundant with the ts_rank factor\n\t\t\t\t-- above, but in fact it is possible for ts_rank to be nonzero, yet\n\t\t\t\t-- tsv_search_tokens @@ query is false (I think because ts_rank doesn't\n\t\t\t\t-- have special handling for AND or OR conjunctions).\n\t\t\t\tCASE WHEN tsv_search_tokens @@ query THEN 1 ELSE 0 END\n\t\t\t) score\n\t\t\tFROM search_documents\n\t\t\t-- This should use the popular document index.\n\t\t\tORDER BY imported_by_count DESC;\n\t-- top is the top search results, sorted by score descending, commit time\n\t-- descending, then package_path ascending.\n\ttop search_result[];\n\t-- res is the current search result.\n\tres search_result;\n\t-- last_idx is the index of the last element in top.\n\tlast_idx INT;\nBEGIN\n\tlast_idx := lim+off;\n\ttop := array_fill(NULL::search_result, array[last_idx]);\n\tOPEN cur(query := websearch_to_tsquery(rawquery));\n\tFETCH cur INTO res;\n\tWHILE found LOOP\n\t\tIF top[last_idx] IS NULL OR res.score >= top[last_idx].score THEN\n\t\t\t-- Insert res into top, maintaining sort order.\n\t\t\tFOR i IN 1..last_idx LOOP\n\t\t\t\t-- We want to preserve order by score desc, commit_time desc,\n\t\t\t\t-- package_path asc, so insert res as soon as it sorted before top[i]\n\t\t\t\t-- according to this ordering.\n\t\t\t\tIF top[i] IS NULL OR\n\t\t\t\t\t(res.score > top[i].score) OR\n\t\t\t\t\t(res.score = top[i].score AND res.commit_time > top[i].commit_time) OR\n\t\t\t\t\t(res.score = top[i].score AND res.commit_time = top[i].commit_time AND\n\t\t\t\t\t res.package_path < top[i].package_path) THEN\n\t\t\t\t\ttop := (top[1:i-1] || res) || top[i:last_idx-1];\n\t\t\t\t\tEXIT;\n\t\t\t\tEND IF;\n\t\t\tEND LOOP;\n\t\tEND IF;\n\t\tIF top[last_idx].score > ln(exp(1)+res.imported_by_count) THEN\n\t\t\t-- No subsequent document can be scored higher than our lowest scoring\n\t\t\t-- document, as top[last_idx].score > 1.0*ln(e+imported_by_count), and\n\t\t\t-- for all subsequent records ts_rank <= 1.0 and ln(e+imported_by_count)\n\t\t\t-- is monotonically decreasing.\n\t\t\t-- So we're done.\n\t\t\tEXIT;\n\t\tEND IF;\n\t\tFETCH cur INTO res;\n\tEND LOOP;\n\tCLOSE cur;\n\tRETURN QUERY SELECT * FROM UNNEST(top[off+1:last_idx])\n\t\tWHERE package_path IS NOT NULL AND score > 0.1;\nEND; $$;\nCOMMENT ON FUNCTION popular_search(rawquery text, lim integer, off integer) IS\n'FUNCTION popular_search is used to generate results for search. It is implemented as a stored function, so that we can use a cursor to scan search documents procedurally, and stop scanning early, whenever our search results are provably correct.';\n\n\nEND;\n",
+	"000005_change_b_weight.up.sql":                                        "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\n-- Redefine the popular_search function, which is currently unused,\n-- to be the same as popular_search_go_mod but with a B weight of 1.\n\nCREATE OR REPLACE FUNCTION popular_search(rawquery text, lim integer, off integer, redist_factor real, go_mod_factor real) RETURNS SETOF search_result\n    LANGUAGE plpgsql\n    AS $$\n\tDECLARE cur CURSOR(query TSQUERY) FOR\n\t\tSELECT\n\t\t\tpackage_path,\n\t\t\tmodule_path,\n\t\t\tversion,\n\t\t\tcommit_time,\n\t\t\timported_by_count,\n\t\t\t(\n\t\t\t\t-- default D, C, B, A weights are {0.1, 0.2, 0.4, 1.0}\n\t\t\t\tts_rank('{0.1, 0.2, 1.0, 1.0}', tsv_search_tokens, query) *\n\t\t\t\tln(exp(1)+imported_by_count) *\n\t\t\t\tCASE WHEN redistributable THEN 1 ELSE redist_factor END *\n\t\t\t\tCASE WHEN COALESCE(has_go_mod, true) THEN 1 ELSE go_mod_factor END *\n\t\t\t\tCASE WHEN tsv_search_tokens @@ query THEN 1 ELSE 0 END\n\t\t\t) score\n\t\t\tFROM search_documents\n\t\t\tORDER BY imported_by_count DESC;\n\ttop search_result[];\n\tres search_result;\n\tlast_idx INT;\nBEGIN\n\tlast_idx := lim+off;\n\ttop := array_fill(NULL::search_result, array[last_idx]);\n\tOPEN cur(query := websearch_to_tsquery(rawquery));\n\tFETCH cur INTO res;\n\tWHILE found LOOP\n\t\tIF top[last_idx] IS NULL OR res.score >= top[last_idx].score THEN\n\t\t\tFOR i IN 1..last_idx LOOP\n\t\t\t\tIF top[i] IS NULL OR\n\t\t\t\t\t(res.score > top[i].score) OR\n\t\t\t\t\t(res.score = top[i].score AND res.commit_time > top[i].commit_time) OR\n\t\t\t\t\t(res.score = top[i].score AND res.commit_time = top[i].commit_time AND\n\t\t\t\t\t res.package_path < top[i].package_path) THEN\n\t\t\t\t\ttop := (top[1:i-1] || res) || top[i:last_idx-1];\n\t\t\t\t\tEXIT;\n\t\t\t\tEND IF;\n\t\t\tEND LOOP;\n\t\tEND IF;\n\t\tIF top[last_idx].score > ln(exp(1)+res.imported_by_count) THEN\n\t\t\tEXIT;\n\t\tEND IF;\n\t\tFETCH cur INTO res;\n\tEND LOOP;\n\tCLOSE cur;\n\tRETURN QUERY SELECT * FROM UNNEST(top[off+1:last_idx])\n\t\tWHERE package_path IS NOT NULL AND score > 0.1;\nEND; $$;\nCOMMENT ON FUNCTION popular_search(rawquery text, lim integer, off integer, redist_factor real, go_mod_factor real) IS\n'FUNCTION popular_search is used to generate results for search. It is implemented as a stored function, so that we can use a cursor to scan search documents procedurally, and stop scanning early, whenever our search results are provably correct.';\n\n\nEND;\n",
+	"000006_add_identity_keys.down.sql":                                    "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nALTER table version_map DROP COLUMN module_id;\nALTER table licenses DROP COLUMN module_id;\n\nEND;\n",
+	"000006_add_identity_keys.up.sql":                                      "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nALTER table licenses ADD COLUMN module_id integer REFERENCES modules(id) ON DELETE CASCADE;\nALTER table version_map ADD COLUMN module_id integer;\n\nEND;\n",
+	"000007_add_readme_package_imports_documentation_tables.down.sql":      "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nDROP TABLE readmes;\nDROP TABLE documentation;\nDROP TABLE package_imports;\n\nEND;\n",
+	"000007_add_readme_package_imports_documentation_tables.up.sql":        "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nCREATE TABLE readmes (\n    path_id INTEGER NOT NULL PRIMARY KEY REFERENCES paths(id) ON DELETE CASCADE,\n    filename text NOT NULL,\n    contents text NOT NULL\n);\nCOMMENT ON TABLE readmes IS\n'TABLE readmes contains README files at a given path.';\n\nCREATE TABLE documentation (\n    path_id INTEGER NOT NULL REFERENCES paths(id) ON DELETE CASCADE,\n    goos text NOT NULL,\n    goarch text NOT NULL,\n    synopsis text NOT NULL,\n    html text NOT NULL,\n    PRIMARY KEY (path_id, goos, goarch)\n);\nCOMMENT ON TABLE documentation IS\n'TABLE documentation contains documentation for packages in the database.';\n\nCREATE TABLE package_imports (\n    path_id INTEGER NOT NULL REFERENCES paths(id) ON DELETE CASCADE,\n    to_path text NOT NULL,\n    PRIMARY KEY (path_id, to_path)\n);\nCREATE INDEX idx_package_imports_to_path ON package_imports USING btree (to_path);\nCOMMENT ON TABLE package_imports IS\n'TABLE package_imports contains the imports for a package in the paths table. The package represented by path_id imports to_path. We do not store the version and module at which to_path is imported because it is hard to compute.\n\nThis table will be renamed to imports, once the current imports table has been deprecated.';\n\nEND;\n",
+	"000008_remove_golang_text_config.down.sql":                            "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nCREATE TEXT SEARCH CONFIGURATION golang (COPY = pg_catalog.english);\n\nCREATE TEXT SEARCH DICTIONARY simple_english (\n    TEMPLATE = pg_catalog.simple,\n    STOPWORDS = english\n);\n\nALTER TEXT SEARCH CONFIGURATION golang\n    ALTER MAPPING FOR asciiword, asciihword, hword_asciipart, numword\n    WITH simple_english;\n\nALTER TEXT SEARCH CONFIGURATION golang\n    DROP MAPPING FOR url_path;\n\n\nCOMMENT ON TEXT SEARCH CONFIGURATION golang IS\n'TEXT SEARCH CONFIGURATION golang is a custom search configuration used when creating tsvector for search.\nThe url_path token type is removed, so that \"github.com/foo/bar@v1.2.3\" is indexed only as the full URL string,\nand not also\"/foo/bar@v1.2.3\".\nThe ASCII token types are set to a \"simple_english\" mapping, so that \"plural\" words like Postgres and NATS\nwill be indexed without stemming.\nThis idea came from the \"Morphological and Exact Search\" section here:\nhttps://asp437.github.io/posts/flexible-fts.html.';\n\nEND;\n",
+	"000008_remove_golang_text_config.up.sql":                              "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nDROP TEXT SEARCH CONFIGURATION golang;\n\nDROP TEXT SEARCH DICTIONARY simple_english;\n\nEND;\n",
+	"000009_add_path_tokens_config.down.sql":                               "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nDROP TEXT SEARCH CONFIGURATION path_tokens;\n\nEND;\n",
+	"000009_add_path_tokens_config.up.sql":                                 "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nCREATE TEXT SEARCH CONFIGURATION path_tokens (COPY = pg_catalog.english);\n\nALTER TEXT SEARCH CONFIGURATION path_tokens DROP MAPPING FOR hword_asciipart;\n\nCOMMENT ON TEXT SEARCH CONFIGURATION path_tokens IS\n'TEXT SEARCH CONFIGURATION path_tokens is a custom search configuration used when creating a tsvector\nfrom tokens that we generate from a path. The configuration ignores items that are part of a hyphenated\nword, because our token generator already splits at hyphens.';\n\nEND;\n",
+	"000010_rename_readme_filename_to_file_path.down.sql":                  "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nALTER TABLE readmes RENAME COLUMN file_path TO filename;\n\nEND;\n",
+	"000010_rename_readme_filename_to_file_path.up.sql":                    "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nALTER TABLE readmes RENAME COLUMN filename TO file_path;\n\nEND;\n",
+	"000011_add_packages_index.down.sql":                                   "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nDROP INDEX idx_packages_module_path_version;\n\nEND;\n",
+	"000011_add_packages_index.up.sql":                                     "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nCREATE INDEX idx_packages_module_path_version ON packages(module_path, version);\n\nEND;\n",
+	"000012_add_modules_series_path_index.down.sql":                        "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nDROP INDEX idx_modules_series_path;\n\nEND;\n",
+	"000012_add_modules_series_path_index.up.sql":                          "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nCREATE INDEX idx_modules_series_path ON modules(series_path);\n\nEND;\n",
+	"000013_add_version_map_indexes.down.sql":                              "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nDROP INDEX idx_version_map_module_id;\nDROP INDEX idx_version_map_module_path;\n\nEND;\n",
+	"000013_add_version_map_indexes.up.sql":                                "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nCREATE INDEX idx_version_map_module_id ON version_map (module_id);\nCREATE INDEX idx_version_map_module_path ON version_map (module_path, resolved_version);\n\nEND;\n",
+	"000014_add_paths_module_id_index.down.sql":                            "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nDROP INDEX idx_paths_module_id;\n\nEND;\n",
+	"000014_add_paths_module_id_index.up.sql":                              "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nCREATE INDEX idx_paths_module_id ON paths(module_id);\n\nEND;\n",
+	"000015_add_package_version_states_module_path_version_index.down.sql": "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nDROP INDEX idx_package_version_states_module_path_version;\n\nEND;\n",
+	"000015_add_package_version_states_module_path_version_index.up.sql":   "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nCREATE INDEX idx_package_version_states_module_path_version\n\tON package_version_states (module_path, version);\n\nEND;\n",
+	"000016_add_module_version_states_num_packages.down.sql":               "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nALTER TABLE module_version_states DROP COLUMN num_packages;\n\nEND;\n",
+	"000016_add_module_version_states_num_packages.up.sql":                 "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nALTER TABLE module_version_states ADD COLUMN num_packages INTEGER;\n\nEND;\n",
+	"000017_add_module_version_states_num_packages_index.down.sql":         "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nDROP INDEX idx_module_version_states_num_packages;\n\nEND;\n",
+	"000017_add_module_version_states_num_packages_index.up.sql":           "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nCREATE INDEX idx_module_version_states_num_packages ON module_version_states(num_packages);\n\nEND;\n",
+	"000018_add_module_version_states_status_index.down.sql":               "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nDROP INDEX idx_module_version_states_status;\n\nEND;\n",
+	"000018_add_module_version_states_status_index.up.sql":                 "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nCREATE INDEX idx_module_version_states_status ON module_version_states(status);\n\nEND;\n",
+	"000019_add_imports_unique_index.down.sql":                             "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nDROP INDEX idx_imports_unique_from_module_path;\n\nEND;\n",
+	"000019_add_imports_unique_index.up.sql":                               "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nCREATE INDEX idx_imports_unique_from_module_path ON imports_unique (from_module_path);\n\nEND;\n",
+	"000020_add_search_documents_module_path_index.down.sql":               "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nDROP INDEX idx_search_documents_module_path;\n\nEND;\n",
+	"000020_add_search_documents_module_path_index.up.sql":                 "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nCREATE INDEX idx_search_documents_module_path ON search_documents (module_path);\n\nEND;\n",
+	"000021_add_version_map_go_mod_path_column.down.sql":                   "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nALTER TABLE version_map DROP COLUMN go_mod_path;\n\nEND;\n",
+	"000021_add_version_map_go_mod_path_column.up.sql":                     "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nALTER TABLE version_map ADD COLUMN go_mod_path TEXT;\n\nEND;\n",
+	"000022_change_has_go_mod_not_null.down.sql":                           "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nALTER TABLE modules ALTER COLUMN has_go_mod DROP NOT NULL;\nALTER TABLE search_documents ALTER COLUMN has_go_mod DROP NOT NULL;\n\nEND;\n",
+	"000022_change_has_go_mod_not_null.up.sql":                             "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nALTER TABLE modules ALTER COLUMN has_go_mod SET NOT NULL;\nALTER TABLE search_documents ALTER COLUMN has_go_mod SET NOT NULL;\n\nEND;\n",
+	"000023_change_version_map_go_mod_path_not_null.down.sql":              "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nALTER TABLE version_map ALTER COLUMN go_mod_path DROP NOT NULL;\n\nEND;\n",
+	"000023_change_version_map_go_mod_path_not_null.up.sql":                "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nALTER TABLE version_map ALTER COLUMN go_mod_path SET NOT NULL;\n\nEND;\n",
+	"000024_add_documentation_source_files_column.down.sql":                "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nALTER TABLE documentation DROP COLUMN source;\nALTER TABLE documentation DROP COLUMN zip;\n\nEND;\n",
+	"000024_add_documentation_source_files_column.up.sql":                  "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nALTER TABLE documentation ADD COLUMN source BYTEA;\nALTER TABLE documentation ADD COLUMN zip BYTEA;\n\nCOMMENT ON COLUMN documentation.source IS\n'COLUMN source contains the uncompressed zip of the source files for the package.';\nCOMMENT ON COLUMN documentation.zip IS\n'COLUMN zip contains the compressed zip of the source files for the package.';\n\nEND;\n",
+	"000025_add_module_incomapatible_column.down.sql":                      "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nALTER TABLE modules DROP COLUMN incompatible;\nALTER TABLE module_version_states DROP COLUMN incompatible;\n\nEND;\n",
+	"000025_add_module_incomapatible_column.up.sql":                        "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nALTER TABLE modules ADD COLUMN incompatible boolean;\nALTER TABLE module_version_states ADD COLUMN incompatible boolean;\n\nCOMMENT ON COLUMN modules.incompatible IS\n'COLUMN incompatible defines whether the the version for the given module is incompatible';\nCOMMENT ON COLUMN module_version_states.incompatible IS\n'COLUMN incompatible defines whether the the version for the given module is incompatible';\n\nCREATE INDEX idx_modules_incompatible on modules (incompatible);\nCOMMENT ON INDEX idx_modules_incompatible IS\n'INDEX idx_modules_incompatible is used to sort versions if they are incompatible';\n\nCREATE INDEX idx_module_version_states_incompatible on module_version_states (incompatible);\nCOMMENT ON INDEX idx_module_version_states_incompatible IS\n'INDEX idx_module_version_states_incompatible is used to sort versions if they are incompatible';\n\nEND;\n",
+	"000026_change_incompatible_not_null.down.sql":                         "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nALTER TABLE modules ALTER COLUMN incompatible DROP NOT NULL;\nALTER TABLE module_version_states ALTER COLUMN incompatible DROP NOT NULL;\n\nEND;\n",
+	"000026_change_incompatible_not_null.up.sql":                           "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nALTER TABLE modules ALTER COLUMN incompatible SET NOT NULL;\nALTER TABLE module_version_states ALTER COLUMN incompatible SET NOT NULL;\n\nEND;\n",
+	"000027_add_modules_status_column.down.sql":                            "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nALTER TABLE modules DROP COLUMN status;\n\nEND;\n",
+	"000027_add_modules_status_column.up.sql":                              "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nALTER TABLE modules ADD COLUMN status INTEGER;\n\nCOMMENT ON COLUMN modules.status IS\n'COLUMN status describes the status of the module in the database. This status will match module_version_states.status.';\n\nEND;\n",
+	"000028_add_idx_licenses_module_id.down.sql":                           "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nDROP INDEX idx_licenses_module_id;\n\nEND;\n",
+	"000028_add_idx_licenses_module_id.up.sql":                             "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n--\n-- BEGIN and END were removed because of this error:\n-- (details: pq: CREATE INDEX CONCURRENTLY cannot run inside a transaction block)\n--\t  * pq: current transaction is aborted, commands ignored until end of\n--    transaction block in line 0: SELECT pg_advisory_unlock($1)\n\nCREATE INDEX CONCURRENTLY idx_licenses_module_id ON licenses (module_id);\n",
+	"000029_change_licenses_module_id_not_null.down.sql":                   "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nALTER TABLE licenses ALTER COLUMN module_id DROP NOT NULL;\n\nEND;\n",
+	"000029_change_licenses_module_id_not_null.up.sql":                     "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nALTER TABLE licenses ALTER COLUMN module_id SET NOT NULL;\n\nEND;\n",
+	"000030_add_synopsis_inferred_columns.down.sql":                        "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nALTER TABLE packages DROP COLUMN synopsis_inferred;\nALTER TABLE documentation DROP COLUMN synopsis_inferred;\n\nEND;\n",
+	"000030_add_synopsis_inferred_columns.up.sql":                          "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nALTER TABLE packages ADD COLUMN synopsis_inferred boolean NOT NULL DEFAULT false;\nALTER TABLE documentation ADD COLUMN synopsis_inferred boolean NOT NULL DEFAULT false;\n\nCOMMENT ON COLUMN packages.synopsis_inferred IS\n'COLUMN synopsis_inferred is true when the synopsis was derived from a README because the package has no doc comment.';\nCOMMENT ON COLUMN documentation.synopsis_inferred IS\n'COLUMN synopsis_inferred is true when the synopsis was derived from a README because the package has no doc comment.';\n\nEND;\n",
+	"000031_add_modules_metadata_column.down.sql":                          "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nALTER TABLE modules DROP COLUMN metadata;\n\nEND;\n",
+	"000031_add_modules_metadata_column.up.sql":                            "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nALTER TABLE modules ADD COLUMN metadata jsonb;\n\nCOMMENT ON COLUMN modules.metadata IS\n'COLUMN metadata holds the description, keywords and category supplied by the module author in go.mod comments or a pkgsite.yaml file.';\n\nEND;\n",
+	"000032_add_modules_deprecation_columns.down.sql":                      "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nALTER TABLE modules\n    DROP COLUMN deprecated_message,\n    DROP COLUMN successor_module_path;\n\nEND;\n",
+	"000032_add_modules_deprecation_columns.up.sql":                        "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nALTER TABLE modules\n    ADD COLUMN deprecated_message text,\n    ADD COLUMN successor_module_path text;\n\nCOMMENT ON COLUMN modules.deprecated_message IS\n'COLUMN deprecated_message holds the text after \"Deprecated:\" in the comment on the module directive of the go.mod file, or NULL if the module is not deprecated.';\n\nCOMMENT ON COLUMN modules.successor_module_path IS\n'COLUMN successor_module_path holds the path of the module that deprecated_message says to use instead, if any.';\n\nEND;\n",
+	"000033_add_response_snapshots.down.sql":                               "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nDROP TABLE response_snapshots;\nDROP TABLE snapshot_rules;\n\nEND;\n",
+	"000033_add_response_snapshots.up.sql":                                 "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nCREATE TABLE snapshot_rules (\n    id serial PRIMARY KEY,\n    path_pattern text NOT NULL,\n    sample_rate double precision NOT NULL CHECK (sample_rate > 0 AND sample_rate <= 1),\n    expires_at timestamp with time zone NOT NULL,\n    created_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP\n);\n\nCOMMENT ON TABLE snapshot_rules IS\n'TABLE snapshot_rules holds requests from admins to record a sample of frontend requests whose paths match path_pattern, and their responses, until expires_at.';\n\nCREATE TABLE response_snapshots (\n    id bigserial PRIMARY KEY,\n    rule_id integer NOT NULL REFERENCES snapshot_rules(id) ON DELETE CASCADE,\n    created_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,\n    method text NOT NULL,\n    url text NOT NULL,\n    request_headers jsonb,\n    status integer NOT NULL,\n    response_headers jsonb,\n    response_body bytea,\n    body_truncated boolean NOT NULL DEFAULT false,\n    app_version text NOT NULL\n);\n\nCOMMENT ON TABLE response_snapshots IS\n'TABLE response_snapshots holds frontend requests and responses recorded because of a snapshot rule, with sensitive headers removed.';\n\nCREATE INDEX idx_response_snapshots_rule_id ON response_snapshots(rule_id);\n\nEND;\n",
+	"000034_add_worker_load.down.sql":                                      "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nDROP TABLE worker_load;\n\nEND;\n",
+	"000034_add_worker_load.up.sql":                                        "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nCREATE TABLE worker_load (\n    instance_id text PRIMARY KEY,\n    fetches_in_flight integer NOT NULL,\n    memory_used bigint NOT NULL,\n    memory_limit bigint NOT NULL,\n    overloaded boolean NOT NULL,\n    updated_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP\n);\n\nCOMMENT ON TABLE worker_load IS\n'TABLE worker_load holds the most recent load reported by each worker instance. The frontend consults it to avoid enqueuing fetches when the workers are saturated.';\n\nEND;\n",
+	"000035_add_package_kind.down.sql":                                     "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nDROP FUNCTION popular_search(rawquery text, lim integer, off integer,\n\tredist_factor real, go_mod_factor real, no_decls_factor real);\n\nCREATE FUNCTION popular_search(rawquery text, lim integer, off integer, redist_factor real, go_mod_factor real) RETURNS SETOF search_result\n    LANGUAGE plpgsql\n    AS $$\n\tDECLARE cur CURSOR(query TSQUERY) FOR\n\t\tSELECT\n\t\t\tpackage_path,\n\t\t\tmodule_path,\n\t\t\tversion,\n\t\t\tcommit_time,\n\t\t\timported_by_count,\n\t\t\t(\n\t\t\t\t-- default D, C, B, A weights are {0.1, 0.2, 0.4, 1.0}\n\t\t\t\tts_rank('{0.1, 0.2, 1.0, 1.0}', tsv_search_tokens, query) *\n\t\t\t\tln(exp(1)+imported_by_count) *\n\t\t\t\tCASE WHEN redistributable THEN 1 ELSE redist_factor END *\n\t\t\t\tCASE WHEN COALESCE(has_go_mod, true) THEN 1 ELSE go_mod_factor END *\n\t\t\t\tCASE WHEN tsv_search_tokens @@ query THEN 1 ELSE 0 END\n\t\t\t) score\n\t\t\tFROM search_documents\n\t\t\tORDER BY imported_by_count DESC;\n\ttop search_result[];\n\tres search_result;\n\tlast_idx INT;\nBEGIN\n\tlast_idx := lim+off;\n\ttop := array_fill(NULL::search_result, array[last_idx]);\n\tOPEN cur(query := websearch_to_tsquery(rawquery));\n\tFETCH cur INTO res;\n\tWHILE found LOOP\n\t\tIF top[last_idx] IS NULL OR res.score >= top[last_idx].score THEN\n\t\t\tFOR i IN 1..last_idx LOOP\n\t\t\t\tIF top[i] IS NULL OR\n\t\t\t\t\t(res.score > top[i].score) OR\n\t\t\t\t\t(res.score = top[i].score AND res.commit_time > top[i].commit_time) OR\n\t\t\t\t\t(res.score = top[i].score AND res.commit_time = top[i].commit_time AND\n\t\t\t\t\t res.package_path < top[i].package_path) THEN\n\t\t\t\t\ttop := (top[1:i-1] || res) || top[i:last_idx-1];\n\t\t\t\t\tEXIT;\n\t\t\t\tEND IF;\n\t\t\tEND LOOP;\n\t\tEND IF;\n\t\tIF top[last_idx].score > ln(exp(1)+res.imported_by_count) THEN\n\t\t\tEXIT;\n\t\tEND IF;\n\t\tFETCH cur INTO res;\n\tEND LOOP;\n\tCLOSE cur;\n\tRETURN QUERY SELECT * FROM UNNEST(top[off+1:last_idx])\n\t\tWHERE package_path IS NOT NULL AND score > 0.1;\nEND; $$;\nCOMMENT ON FUNCTION popular_search(rawquery text, lim integer, off integer, redist_factor real, go_mod_factor real) IS\n'FUNCTION popular_search is used to generate results for search. It is implemented as a stored function, so that we can use a cursor to scan search documents procedurally, and stop scanning early, whenever our search results are provably correct.';\n\nALTER TABLE packages DROP COLUMN kind;\nALTER TABLE documentation DROP COLUMN kind;\nALTER TABLE search_documents DROP COLUMN kind;\n\nEND;\n",
+	"000035_add_package_kind.up.sql":                                       "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nALTER TABLE packages ADD COLUMN kind text NOT NULL DEFAULT '';\nALTER TABLE documentation ADD COLUMN kind text NOT NULL DEFAULT '';\nALTER TABLE search_documents ADD COLUMN kind text NOT NULL DEFAULT '';\n\nCOMMENT ON COLUMN packages.kind IS\n'COLUMN kind classifies the package by what its files contain: empty for a package with declarations, \"doc-only\" for one whose non-test files declare nothing, and \"example-only\" for one that declares nothing but has examples.';\nCOMMENT ON COLUMN documentation.kind IS\n'COLUMN kind classifies the package by what its files contain. See packages.kind.';\nCOMMENT ON COLUMN search_documents.kind IS\n'COLUMN kind classifies the package by what its files contain. See packages.kind.';\n\n-- Redefine popular_search to apply the penalty for packages that declare\n-- nothing, as deep search does.\n\nDROP FUNCTION popular_search(rawquery text, lim integer, off integer, redist_factor real, go_mod_factor real);\n\nCREATE FUNCTION popular_search(rawquery text, lim integer, off integer,\n\tredist_factor real, go_mod_factor real, no_decls_factor real)\n\tRETURNS SETOF search_result\n    LANGUAGE plpgsql\n    AS $$\n\tDECLARE cur CURSOR(query TSQUERY) FOR\n\t\tSELECT\n\t\t\tpackage_path,\n\t\t\tmodule_path,\n\t\t\tversion,\n\t\t\tcommit_time,\n\t\t\timported_by_count,\n\t\t\t(\n\t\t\t\t-- default D, C, B, A weights are {0.1, 0.2, 0.4, 1.0}\n\t\t\t\tts_rank('{0.1, 0.2, 1.0, 1.0}', tsv_search_tokens, query) *\n\t\t\t\tln(exp(1)+imported_by_count) *\n\t\t\t\tCASE WHEN redistributable THEN 1 ELSE redist_factor END *\n\t\t\t\tCASE WHEN COALESCE(has_go_mod, true) THEN 1 ELSE go_mod_factor END *\n\t\t\t\tCASE WHEN kind = '' THEN 1 ELSE no_decls_factor END *\n\t\t\t\tCASE WHEN tsv_search_tokens @@ query THEN 1 ELSE 0 END\n\t\t\t) score\n\t\t\tFROM search_documents\n\t\t\tORDER BY imported_by_count DESC;\n\ttop search_result[];\n\tres search_result;\n\tlast_idx INT;\nBEGIN\n\tlast_idx := lim+off;\n\ttop := array_fill(NULL::search_result, array[last_idx]);\n\tOPEN cur(query := websearch_to_tsquery(rawquery));\n\tFETCH cur INTO res;\n\tWHILE found LOOP\n\t\tIF top[last_idx] IS NULL OR res.score >= top[last_idx].score THEN\n\t\t\tFOR i IN 1..last_idx LOOP\n\t\t\t\tIF top[i] IS NULL OR\n\t\t\t\t\t(res.score > top[i].score) OR\n\t\t\t\t\t(res.score = top[i].score AND res.commit_time > top[i].commit_time) OR\n\t\t\t\t\t(res.score = top[i].score AND res.commit_time = top[i].commit_time AND\n\t\t\t\t\t res.package_path < top[i].package_path) THEN\n\t\t\t\t\ttop := (top[1:i-1] || res) || top[i:last_idx-1];\n\t\t\t\t\tEXIT;\n\t\t\t\tEND IF;\n\t\t\tEND LOOP;\n\t\tEND IF;\n\t\tIF top[last_idx].score > ln(exp(1)+res.imported_by_count) THEN\n\t\t\tEXIT;\n\t\tEND IF;\n\t\tFETCH cur INTO res;\n\tEND LOOP;\n\tCLOSE cur;\n\tRETURN QUERY SELECT * FROM UNNEST(top[off+1:last_idx])\n\t\tWHERE package_path IS NOT NULL AND score > 0.1;\nEND; $$;\nCOMMENT ON FUNCTION popular_search(rawquery text, lim integer, off integer,\n\tredist_factor real, go_mod_factor real, no_decls_factor real) IS\n'FUNCTION popular_search is used to generate results for search. It is implemented as a stored function, so that we can use a cursor to scan search documents procedurally, and stop scanning early, whenever our search results are provably correct.';\n\nEND;\n",
+	"000036_add_search_boosts.down.sql":                                    "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nDROP FUNCTION popular_search(rawquery text, lim integer, off integer,\n\tredist_factor real, go_mod_factor real, no_decls_factor real,\n\tname_query text, exact_name_boost real, stdlib_boost real, module_root_boost real);\n\nCREATE FUNCTION popular_search(rawquery text, lim integer, off integer,\n\tredist_factor real, go_mod_factor real, no_decls_factor real)\n\tRETURNS SETOF search_result\n    LANGUAGE plpgsql\n    AS $$\n\tDECLARE cur CURSOR(query TSQUERY) FOR\n\t\tSELECT\n\t\t\tpackage_path,\n\t\t\tmodule_path,\n\t\t\tversion,\n\t\t\tcommit_time,\n\t\t\timported_by_count,\n\t\t\t(\n\t\t\t\t-- default D, C, B, A weights are {0.1, 0.2, 0.4, 1.0}\n\t\t\t\tts_rank('{0.1, 0.2, 1.0, 1.0}', tsv_search_tokens, query) *\n\t\t\t\tln(exp(1)+imported_by_count) *\n\t\t\t\tCASE WHEN redistributable THEN 1 ELSE redist_factor END *\n\t\t\t\tCASE WHEN COALESCE(has_go_mod, true) THEN 1 ELSE go_mod_factor END *\n\t\t\t\tCASE WHEN kind = '' THEN 1 ELSE no_decls_factor END *\n\t\t\t\tCASE WHEN tsv_search_tokens @@ query THEN 1 ELSE 0 END\n\t\t\t) score\n\t\t\tFROM search_documents\n\t\t\tORDER BY imported_by_count DESC;\n\ttop search_result[];\n\tres search_result;\n\tlast_idx INT;\nBEGIN\n\tlast_idx := lim+off;\n\ttop := array_fill(NULL::search_result, array[last_idx]);\n\tOPEN cur(query := websearch_to_tsquery(rawquery));\n\tFETCH cur INTO res;\n\tWHILE found LOOP\n\t\tIF top[last_idx] IS NULL OR res.score >= top[last_idx].score THEN\n\t\t\tFOR i IN 1..last_idx LOOP\n\t\t\t\tIF top[i] IS NULL OR\n\t\t\t\t\t(res.score > top[i].score) OR\n\t\t\t\t\t(res.score = top[i].score AND res.commit_time > top[i].commit_time) OR\n\t\t\t\t\t(res.score = top[i].score AND res.commit_time = top[i].commit_time AND\n\t\t\t\t\t res.package_path < top[i].package_path) THEN\n\t\t\t\t\ttop := (top[1:i-1] || res) || top[i:last_idx-1];\n\t\t\t\t\tEXIT;\n\t\t\t\tEND IF;\n\t\t\tEND LOOP;\n\t\tEND IF;\n\t\tIF top[last_idx].score > ln(exp(1)+res.imported_by_count) THEN\n\t\t\tEXIT;\n\t\tEND IF;\n\t\tFETCH cur INTO res;\n\tEND LOOP;\n\tCLOSE cur;\n\tRETURN QUERY SELECT * FROM UNNEST(top[off+1:last_idx])\n\t\tWHERE package_path IS NOT NULL AND score > 0.1;\nEND; $$;\nCOMMENT ON FUNCTION popular_search(rawquery text, lim integer, off integer,\n\tredist_factor real, go_mod_factor real, no_decls_factor real) IS\n'FUNCTION popular_search is used to generate results for search. It is implemented as a stored function, so that we can use a cursor to scan search documents procedurally, and stop scanning early, whenever our search results are provably correct.';\n\nEND;\n",
+	"000036_add_search_boosts.up.sql":                                      "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\n-- Redefine popular_search to apply the search boosts, as deep search does.\n\nDROP FUNCTION popular_search(rawquery text, lim integer, off integer,\n\tredist_factor real, go_mod_factor real, no_decls_factor real);\n\nCREATE FUNCTION popular_search(rawquery text, lim integer, off integer,\n\tredist_factor real, go_mod_factor real, no_decls_factor real,\n\tname_query text, exact_name_boost real, stdlib_boost real, module_root_boost real)\n\tRETURNS SETOF search_result\n    LANGUAGE plpgsql\n    AS $$\n\tDECLARE cur CURSOR(query TSQUERY) FOR\n\t\tSELECT\n\t\t\tpackage_path,\n\t\t\tmodule_path,\n\t\t\tversion,\n\t\t\tcommit_time,\n\t\t\timported_by_count,\n\t\t\t(\n\t\t\t\t-- default D, C, B, A weights are {0.1, 0.2, 0.4, 1.0}\n\t\t\t\tts_rank('{0.1, 0.2, 1.0, 1.0}', tsv_search_tokens, query) *\n\t\t\t\tln(exp(1)+imported_by_count) *\n\t\t\t\tCASE WHEN redistributable THEN 1 ELSE redist_factor END *\n\t\t\t\tCASE WHEN COALESCE(has_go_mod, true) THEN 1 ELSE go_mod_factor END *\n\t\t\t\tCASE WHEN kind = '' THEN 1 ELSE no_decls_factor END *\n\t\t\t\tCASE WHEN lower(name) = name_query THEN exact_name_boost ELSE 1 END *\n\t\t\t\tCASE WHEN module_path = 'std' THEN stdlib_boost ELSE 1 END *\n\t\t\t\tCASE WHEN package_path = module_path THEN module_root_boost ELSE 1 END *\n\t\t\t\tCASE WHEN tsv_search_tokens @@ query THEN 1 ELSE 0 END\n\t\t\t) score\n\t\t\tFROM search_documents\n\t\t\tORDER BY imported_by_count DESC;\n\ttop search_result[];\n\tres search_result;\n\tlast_idx INT;\n\t-- The largest factor by which the boosts can increase a score.\n\tmax_boost REAL := GREATEST(exact_name_boost, 1) * GREATEST(stdlib_boost, 1) * GREATEST(module_root_boost, 1);\nBEGIN\n\tlast_idx := lim+off;\n\ttop := array_fill(NULL::search_result, array[last_idx]);\n\tOPEN cur(query := websearch_to_tsquery(rawquery));\n\tFETCH cur INTO res;\n\tWHILE found LOOP\n\t\tIF top[last_idx] IS NULL OR res.score >= top[last_idx].score THEN\n\t\t\tFOR i IN 1..last_idx LOOP\n\t\t\t\tIF top[i] IS NULL OR\n\t\t\t\t\t(res.score > top[i].score) OR\n\t\t\t\t\t(res.score = top[i].score AND res.commit_time > top[i].commit_time) OR\n\t\t\t\t\t(res.score = top[i].score AND res.commit_time = top[i].commit_time AND\n\t\t\t\t\t res.package_path < top[i].package_path) THEN\n\t\t\t\t\ttop := (top[1:i-1] || res) || top[i:last_idx-1];\n\t\t\t\t\tEXIT;\n\t\t\t\tEND IF;\n\t\t\tEND LOOP;\n\t\tEND IF;\n\t\tIF top[last_idx].score > ln(exp(1)+res.imported_by_count) * max_boost THEN\n\t\t\tEXIT;\n\t\tEND IF;\n\t\tFETCH cur INTO res;\n\tEND LOOP;\n\tCLOSE cur;\n\tRETURN QUERY SELECT * FROM UNNEST(top[off+1:last_idx])\n\t\tWHERE package_path IS NOT NULL AND score > 0.1;\nEND; $$;\nCOMMENT ON FUNCTION popular_search(rawquery text, lim integer, off integer,\n\tredist_factor real, go_mod_factor real, no_decls_factor real,\n\tname_query text, exact_name_boost real, stdlib_boost real, module_root_boost real) IS\n'FUNCTION popular_search is used to generate results for search. It is implemented as a stored function, so that we can use a cursor to scan search documents procedurally, and stop scanning early, whenever our search results are provably correct.';\n\nEND;\n",
+	"000037_add_feedback_reports.down.sql":                                 "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nDROP TABLE feedback_reports;\n\nEND;\n",
+	"000037_add_feedback_reports.up.sql":                                   "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nCREATE TABLE feedback_reports (\n    id serial PRIMARY KEY,\n    path text NOT NULL,\n    version text NOT NULL,\n    category text NOT NULL,\n    text text NOT NULL,\n    trace_id text NOT NULL DEFAULT '',\n    status text NOT NULL DEFAULT 'new' CHECK (status IN ('new', 'triaged', 'resolved', 'spam')),\n    created_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,\n    updated_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP\n);\n\nCOMMENT ON TABLE feedback_reports IS\n'TABLE feedback_reports holds problems with pages that users reported with the form on documentation pages, for admins to triage.';\n\nCREATE TRIGGER set_updated_at BEFORE INSERT OR UPDATE ON feedback_reports\n    FOR EACH ROW EXECUTE PROCEDURE trigger_modify_updated_at();\nCOMMENT ON TRIGGER set_updated_at ON feedback_reports IS\n'TRIGGER set_updated_at updates the value of the updated_at column to the current timestamp whenever a row is inserted or updated to the table.';\n\nCREATE INDEX idx_feedback_reports_status_created_at ON feedback_reports(status, created_at);\n\nEND;\n",
+	"000038_add_module_dependency_index.down.sql":                          "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nDROP TABLE module_dependency_index_states;\nDROP TABLE module_dependencies;\nDROP TABLE module_requirements;\nALTER TABLE modules DROP COLUMN requirements_recorded;\n\nEND;\n",
+	"000038_add_module_dependency_index.up.sql":                            "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nALTER TABLE modules ADD COLUMN requirements_recorded boolean NOT NULL DEFAULT false;\nCOMMENT ON COLUMN modules.requirements_recorded IS\n'COLUMN requirements_recorded tells whether the require directives of the module version are in module_requirements. It is false for module versions processed before the table existed, so that a module version without requirements can be told apart from one whose requirements are unknown.';\n\nCREATE TABLE module_requirements (\n    module_id INTEGER NOT NULL REFERENCES modules(id) ON DELETE CASCADE,\n    required_module_path text NOT NULL,\n    required_version text NOT NULL,\n    PRIMARY KEY (module_id, required_module_path)\n);\nCOMMENT ON TABLE module_requirements IS\n'TABLE module_requirements contains the require directives of the go.mod file of each module version in the modules table: the module version represented by module_id requires required_module_path at required_version.';\n\nCREATE TABLE module_dependencies (\n    module_id INTEGER NOT NULL REFERENCES modules(id) ON DELETE CASCADE,\n    dependency_module_path text NOT NULL,\n    dependency_version text NOT NULL,\n    depth INTEGER NOT NULL,\n    via_module_path text NOT NULL,\n    PRIMARY KEY (module_id, dependency_module_path)\n);\nCOMMENT ON TABLE module_dependencies IS\n'TABLE module_dependencies is the reachability index of the requirement graph in module_requirements. It has a row for every module that the module version represented by module_id transitively requires, with the highest version required anywhere in the graph, the length of the shortest chain of requirements to it, and the direct requirement that starts that chain.';\n\nCREATE TABLE module_dependency_index_states (\n    module_id INTEGER NOT NULL PRIMARY KEY REFERENCES modules(id) ON DELETE CASCADE,\n    incomplete boolean NOT NULL,\n    indexed_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP\n);\nCOMMENT ON TABLE module_dependency_index_states IS\n'TABLE module_dependency_index_states records when the rows of module_dependencies for a module version were computed, and whether some of the module versions in its requirement graph were missing from the database at the time. Rows are deleted when the requirements of the module version change.';\n\nCREATE INDEX idx_module_dependency_index_states_indexed_at ON module_dependency_index_states(indexed_at);\n\nEND;\n",
+	"000039_add_modules_proxy_status_columns.down.sql":                     "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nDROP INDEX idx_modules_proxy_checked_at;\n\nALTER TABLE modules\n    DROP COLUMN proxy_status,\n    DROP COLUMN proxy_checked_at;\n\nEND;\n",
+	"000039_add_modules_proxy_status_columns.up.sql":                       "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nALTER TABLE modules\n    ADD COLUMN proxy_status integer,\n    ADD COLUMN proxy_checked_at timestamp with time zone;\n\nCOMMENT ON COLUMN modules.proxy_status IS\n'COLUMN proxy_status holds the HTTP status of the last response from the proxy for the .info file of the module version, or NULL if it has not been checked since the module version was processed. 410 means that the proxy no longer serves the version.';\n\nCOMMENT ON COLUMN modules.proxy_checked_at IS\n'COLUMN proxy_checked_at holds the time the worker last asked the proxy whether it still serves the module version.';\n\nCREATE INDEX idx_modules_proxy_checked_at ON modules(proxy_checked_at NULLS FIRST);\n\nEND;\n",
+	"000040_add_documentation_parts.down.sql":                              "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nDROP TABLE documentation_parts;\n\nEND;\n",
+	"000040_add_documentation_parts.up.sql":                                "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nCREATE TABLE documentation_parts (\n    path_id INTEGER NOT NULL REFERENCES paths(id) ON DELETE CASCADE,\n    part integer NOT NULL,\n    title text NOT NULL,\n    html text NOT NULL,\n    PRIMARY KEY (path_id, part)\n);\nCOMMENT ON TABLE documentation_parts IS\n'TABLE documentation_parts contains the pages of documentation that was too large to display on one page and was split. The first page is in the html column of the documentation table; the others are numbered from 1.';\n\nEND;\n",
+	"000041_add_latest_checks.down.sql":                                    "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nDROP TABLE latest_checks;\n\nEND;\n",
+	"000041_add_latest_checks.up.sql":                                      "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nCREATE TABLE latest_checks (\n    module_path text PRIMARY KEY,\n    latest_version text,\n    checked_at timestamp with time zone NOT NULL\n);\n\nCOMMENT ON TABLE latest_checks IS\n'TABLE latest_checks records when the worker last asked the proxy for the @latest version of a module that has not been updated recently.';\n\nCOMMENT ON COLUMN latest_checks.latest_version IS\n'COLUMN latest_version holds the version the proxy returned for @latest, or NULL if the check failed.';\n\nCREATE INDEX idx_latest_checks_checked_at ON latest_checks(checked_at);\n\nEND;\n",
+	"000042_add_symbol_history.down.sql":                                   "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nDROP TABLE symbol_history;\n\nEND;\n",
+	"000042_add_symbol_history.up.sql":                                     "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nCREATE TABLE symbol_history (\n    package_path text NOT NULL,\n    module_path text NOT NULL,\n    symbol_name text NOT NULL,\n    since_version text NOT NULL,\n    sort_version text NOT NULL,\n    PRIMARY KEY (package_path, module_path, symbol_name)\n);\n\nCOMMENT ON TABLE symbol_history IS\n'TABLE symbol_history records, for each exported function, type and method of a package, the earliest version of its module that has it.';\n\nCOMMENT ON COLUMN symbol_history.symbol_name IS\n'COLUMN symbol_name is the name of a function or type, or \"Type.Method\" for a method.';\n\nCOMMENT ON COLUMN symbol_history.sort_version IS\n'COLUMN sort_version holds the value of since_version in a form that sorts in version order.';\n\nEND;\n",
+	"000043_add_user_preferences.down.sql":                                 "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nDROP TABLE user_preferences;\n\nEND;\n",
+	"000043_add_user_preferences.up.sql":                                   "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nCREATE TABLE user_preferences (\n    user_id text PRIMARY KEY,\n    goos text NOT NULL DEFAULT '',\n    goarch text NOT NULL DEFAULT '',\n    theme text NOT NULL DEFAULT '',\n    classic_layout boolean NOT NULL DEFAULT false,\n    hide_internal boolean NOT NULL DEFAULT false,\n    updated_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP\n);\n\nCOMMENT ON TABLE user_preferences IS\n'TABLE user_preferences holds the display preferences of users who are signed in through an authenticating proxy.';\n\nCOMMENT ON COLUMN user_preferences.user_id IS\n'COLUMN user_id is the identity of the user, as set by the proxy in the header named by GO_DISCOVERY_USER_HEADER.';\n\nEND;\n",
+	"000044_add_build_context_documentation.down.sql":                      "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nDROP TABLE build_context_documentation;\n\nEND;\n",
+	"000044_add_build_context_documentation.up.sql":                        "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nCREATE TABLE build_context_documentation (\n    path_id integer NOT NULL REFERENCES paths(id) ON DELETE CASCADE,\n    goos text NOT NULL,\n    goarch text NOT NULL,\n    doc_hash text NOT NULL,\n    source bytea,\n    PRIMARY KEY (path_id, goos, goarch)\n);\n\nCOMMENT ON TABLE build_context_documentation IS\n'TABLE build_context_documentation holds, for packages whose documentation text differs between build contexts, a hash of the documentation in each build context the package can be loaded in.';\n\nCOMMENT ON COLUMN build_context_documentation.doc_hash IS\n'COLUMN doc_hash is the SHA-256 hash of the documentation rendered as plain text.';\n\nCOMMENT ON COLUMN build_context_documentation.source IS\n'COLUMN source holds the encoded source of the package in the build context, if its documentation differs from the one in the documentation table.';\n\nEND;\n",
+	"000045_add_module_provenance.down.sql":                                "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nDROP TABLE module_provenance;\n\nEND;\n",
+	"000045_add_module_provenance.up.sql":                                  "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nCREATE TABLE module_provenance (\n    module_path text NOT NULL,\n    version text NOT NULL,\n    proxy_url text NOT NULL,\n    response_header jsonb,\n    zip_sha256 text NOT NULL,\n    zip_size bigint NOT NULL,\n    downloaded_at timestamp with time zone NOT NULL,\n    download_duration_ms bigint NOT NULL,\n    PRIMARY KEY (module_path, version, downloaded_at)\n);\n\nCOMMENT ON TABLE module_provenance IS\n'TABLE module_provenance records where and when the zip of a module version was downloaded each time it was processed, for auditing the integrity of modules and debugging inconsistencies between proxy caches.';\n\nCOMMENT ON COLUMN module_provenance.proxy_url IS\n'COLUMN proxy_url is the URL that the zip was downloaded from.';\n\nCOMMENT ON COLUMN module_provenance.zip_sha256 IS\n'COLUMN zip_sha256 is the hex-encoded SHA-256 hash of the zip as it was downloaded.';\n\nEND;\n",
+	"000046_add_modules_last_viewed_at.down.sql":                           "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nALTER TABLE modules DROP COLUMN last_viewed_at;\n\nEND;\n",
+	"000046_add_modules_last_viewed_at.up.sql":                             "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nALTER TABLE modules ADD COLUMN last_viewed_at timestamp with time zone;\n\nCOMMENT ON COLUMN modules.last_viewed_at IS\n'COLUMN last_viewed_at holds the time, to within a day, that a page of the module version was last served by the frontend, or NULL if none has been. It is used to prune the documentation HTML of versions that nobody looks at.';\n\nEND;\n",
+	"000047_add_package_symbols.down.sql":                                  "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nDROP TABLE package_symbols;\n\nEND;\n",
+	"000047_add_package_symbols.up.sql":                                    "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nCREATE TABLE package_symbols (\n    path_id integer NOT NULL REFERENCES paths(id) ON DELETE CASCADE,\n    name text NOT NULL,\n    declaration text NOT NULL,\n    PRIMARY KEY (path_id, name)\n);\n\nCOMMENT ON TABLE package_symbols IS\n'TABLE package_symbols holds the declarations of the exported functions, types and methods of each package, for comparing the API of two versions of a module.';\n\nCOMMENT ON COLUMN package_symbols.name IS\n'COLUMN name is the name of a function or type, or \"Type.Method\" for a method.';\n\nCOMMENT ON COLUMN package_symbols.declaration IS\n'COLUMN declaration is the Go source of the declaration, without comments, function bodies, or unexported fields and methods.';\n\nEND;\n",
+	"000048_create_fetch_stages.down.sql":                                  "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nDROP TABLE fetch_stages;\n\nEND;\n",
+	"000048_create_fetch_stages.up.sql":                                    "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nCREATE TABLE fetch_stages (\n    module_path text NOT NULL,\n    requested_version text NOT NULL,\n    stage text NOT NULL,\n    updated_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,\n    PRIMARY KEY (module_path, requested_version)\n);\n\nCOMMENT ON TABLE fetch_stages IS\n'TABLE fetch_stages records how far the worker has got in fetching a module version, so that the frontend can report the progress of a fetch that a user requested. The result of the fetch is in version_map.';\n\nCOMMENT ON COLUMN fetch_stages.stage IS\n'COLUMN stage is one of \"downloading\", \"processing\", \"done\" or \"error\".';\n\nEND;\n",
+	"000049_add_search_documents_fork_of.down.sql":                         "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nDROP FUNCTION popular_search(rawquery text, lim integer, off integer,\n\tredist_factor real, go_mod_factor real, no_decls_factor real, fork_factor real,\n\tname_query text, exact_name_boost real, stdlib_boost real, module_root_boost real);\n\nCREATE FUNCTION popular_search(rawquery text, lim integer, off integer,\n\tredist_factor real, go_mod_factor real, no_decls_factor real,\n\tname_query text, exact_name_boost real, stdlib_boost real, module_root_boost real)\n\tRETURNS SETOF search_result\n    LANGUAGE plpgsql\n    AS $$\n\tDECLARE cur CURSOR(query TSQUERY) FOR\n\t\tSELECT\n\t\t\tpackage_path,\n\t\t\tmodule_path,\n\t\t\tversion,\n\t\t\tcommit_time,\n\t\t\timported_by_count,\n\t\t\t(\n\t\t\t\t-- default D, C, B, A weights are {0.1, 0.2, 0.4, 1.0}\n\t\t\t\tts_rank('{0.1, 0.2, 1.0, 1.0}', tsv_search_tokens, query) *\n\t\t\t\tln(exp(1)+imported_by_count) *\n\t\t\t\tCASE WHEN redistributable THEN 1 ELSE redist_factor END *\n\t\t\t\tCASE WHEN COALESCE(has_go_mod, true) THEN 1 ELSE go_mod_factor END *\n\t\t\t\tCASE WHEN kind = '' THEN 1 ELSE no_decls_factor END *\n\t\t\t\tCASE WHEN lower(name) = name_query THEN exact_name_boost ELSE 1 END *\n\t\t\t\tCASE WHEN module_path = 'std' THEN stdlib_boost ELSE 1 END *\n\t\t\t\tCASE WHEN package_path = module_path THEN module_root_boost ELSE 1 END *\n\t\t\t\tCASE WHEN tsv_search_tokens @@ query THEN 1 ELSE 0 END\n\t\t\t) score\n\t\t\tFROM search_documents\n\t\t\tORDER BY imported_by_count DESC;\n\ttop search_result[];\n\tres search_result;\n\tlast_idx INT;\n\t-- The largest factor by which the boosts can increase a score.\n\tmax_boost REAL := GREATEST(exact_name_boost, 1) * GREATEST(stdlib_boost, 1) * GREATEST(module_root_boost, 1);\nBEGIN\n\tlast_idx := lim+off;\n\ttop := array_fill(NULL::search_result, array[last_idx]);\n\tOPEN cur(query := websearch_to_tsquery(rawquery));\n\tFETCH cur INTO res;\n\tWHILE found LOOP\n\t\tIF top[last_idx] IS NULL OR res.score >= top[last_idx].score THEN\n\t\t\tFOR i IN 1..last_idx LOOP\n\t\t\t\tIF top[i] IS NULL OR\n\t\t\t\t\t(res.score > top[i].score) OR\n\t\t\t\t\t(res.score = top[i].score AND res.commit_time > top[i].commit_time) OR\n\t\t\t\t\t(res.score = top[i].score AND res.commit_time = top[i].commit_time AND\n\t\t\t\t\t res.package_path < top[i].package_path) THEN\n\t\t\t\t\ttop := (top[1:i-1] || res) || top[i:last_idx-1];\n\t\t\t\t\tEXIT;\n\t\t\t\tEND IF;\n\t\t\tEND LOOP;\n\t\tEND IF;\n\t\tIF top[last_idx].score > ln(exp(1)+res.imported_by_count) * max_boost THEN\n\t\t\tEXIT;\n\t\tEND IF;\n\t\tFETCH cur INTO res;\n\tEND LOOP;\n\tCLOSE cur;\n\tRETURN QUERY SELECT * FROM UNNEST(top[off+1:last_idx])\n\t\tWHERE package_path IS NOT NULL AND score > 0.1;\nEND; $$;\nCOMMENT ON FUNCTION popular_search(rawquery text, lim integer, off integer,\n\tredist_factor real, go_mod_factor real, no_decls_factor real,\n\tname_query text, exact_name_boost real, stdlib_boost real, module_root_boost real) IS\n'FUNCTION popular_search is used to generate results for search. It is implemented as a stored function, so that we can use a cursor to scan search documents procedurally, and stop scanning early, whenever our search results are provably correct.';\n\nALTER TABLE search_documents DROP COLUMN doc_hash;\nALTER TABLE search_documents DROP COLUMN fork_of;\n\nEND;\n",
+	"000049_add_search_documents_fork_of.up.sql":                           "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nALTER TABLE search_documents ADD COLUMN doc_hash text NOT NULL DEFAULT '';\nALTER TABLE search_documents ADD COLUMN fork_of text NOT NULL DEFAULT '';\n\nCOMMENT ON COLUMN search_documents.doc_hash IS\n'COLUMN doc_hash is the SHA-256 hash of the package comment, or empty if the package has no comment.';\nCOMMENT ON COLUMN search_documents.fork_of IS\n'COLUMN fork_of is the path of the standard library package that the package is a copy of, or empty. A package is a copy of a standard library package if its path ends with the path of that package, and its name and doc_hash are the same.';\n\n-- Redefine popular_search to apply the penalty for copies of standard\n-- library packages, as deep search does.\n\nDROP FUNCTION popular_search(rawquery text, lim integer, off integer,\n\tredist_factor real, go_mod_factor real, no_decls_factor real,\n\tname_query text, exact_name_boost real, stdlib_boost real, module_root_boost real);\n\nCREATE FUNCTION popular_search(rawquery text, lim integer, off integer,\n\tredist_factor real, go_mod_factor real, no_decls_factor real, fork_factor real,\n\tname_query text, exact_name_boost real, stdlib_boost real, module_root_boost real)\n\tRETURNS SETOF search_result\n    LANGUAGE plpgsql\n    AS $$\n\tDECLARE cur CURSOR(query TSQUERY) FOR\n\t\tSELECT\n\t\t\tpackage_path,\n\t\t\tmodule_path,\n\t\t\tversion,\n\t\t\tcommit_time,\n\t\t\timported_by_count,\n\t\t\t(\n\t\t\t\t-- default D, C, B, A weights are {0.1, 0.2, 0.4, 1.0}\n\t\t\t\tts_rank('{0.1, 0.2, 1.0, 1.0}', tsv_search_tokens, query) *\n\t\t\t\tln(exp(1)+imported_by_count) *\n\t\t\t\tCASE WHEN redistributable THEN 1 ELSE redist_factor END *\n\t\t\t\tCASE WHEN COALESCE(has_go_mod, true) THEN 1 ELSE go_mod_factor END *\n\t\t\t\tCASE WHEN kind = '' THEN 1 ELSE no_decls_factor END *\n\t\t\t\tCASE WHEN fork_of = '' THEN 1 ELSE fork_factor END *\n\t\t\t\tCASE WHEN lower(name) = name_query THEN exact_name_boost ELSE 1 END *\n\t\t\t\tCASE WHEN module_path = 'std' THEN stdlib_boost ELSE 1 END *\n\t\t\t\tCASE WHEN package_path = module_path THEN module_root_boost ELSE 1 END *\n\t\t\t\tCASE WHEN tsv_search_tokens @@ query THEN 1 ELSE 0 END\n\t\t\t) score\n\t\t\tFROM search_documents\n\t\t\tORDER BY imported_by_count DESC;\n\ttop search_result[];\n\tres search_result;\n\tlast_idx INT;\n\t-- The largest factor by which the boosts can increase a score.\n\tmax_boost REAL := GREATEST(exact_name_boost, 1) * GREATEST(stdlib_boost, 1) * GREATEST(module_root_boost, 1);\nBEGIN\n\tlast_idx := lim+off;\n\ttop := array_fill(NULL::search_result, array[last_idx]);\n\tOPEN cur(query := websearch_to_tsquery(rawquery));\n\tFETCH cur INTO res;\n\tWHILE found LOOP\n\t\tIF top[last_idx] IS NULL OR res.score >= top[last_idx].score THEN\n\t\t\tFOR i IN 1..last_idx LOOP\n\t\t\t\tIF top[i] IS NULL OR\n\t\t\t\t\t(res.score > top[i].score) OR\n\t\t\t\t\t(res.score = top[i].score AND res.commit_time > top[i].commit_time) OR\n\t\t\t\t\t(res.score = top[i].score AND res.commit_time = top[i].commit_time AND\n\t\t\t\t\t res.package_path < top[i].package_path) THEN\n\t\t\t\t\ttop := (top[1:i-1] || res) || top[i:last_idx-1];\n\t\t\t\t\tEXIT;\n\t\t\t\tEND IF;\n\t\t\tEND LOOP;\n\t\tEND IF;\n\t\tIF top[last_idx].score > ln(exp(1)+res.imported_by_count) * max_boost THEN\n\t\t\tEXIT;\n\t\tEND IF;\n\t\tFETCH cur INTO res;\n\tEND LOOP;\n\tCLOSE cur;\n\tRETURN QUERY SELECT * FROM UNNEST(top[off+1:last_idx])\n\t\tWHERE package_path IS NOT NULL AND score > 0.1;\nEND; $$;\nCOMMENT ON FUNCTION popular_search(rawquery text, lim integer, off integer,\n\tredist_factor real, go_mod_factor real, no_decls_factor real, fork_factor real,\n\tname_query text, exact_name_boost real, stdlib_boost real, module_root_boost real) IS\n'FUNCTION popular_search is used to generate results for search. It is implemented as a stored function, so that we can use a cursor to scan search documents procedurally, and stop scanning early, whenever our search results are provably correct.';\n\nEND;\n",
+	"000050_add_modules_retractions.down.sql":                              "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nALTER TABLE modules DROP COLUMN retractions;\n\nEND;\n",
+	"000050_add_modules_retractions.up.sql":                                "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nALTER TABLE modules ADD COLUMN retractions jsonb;\n\nCOMMENT ON COLUMN modules.retractions IS\n'COLUMN retractions holds the versions that the retract directives of the go.mod file of the module version retract, as a JSON array of objects with the low and high bounds of each range of versions and the rationale from the comment on the directive. The go command applies the retractions of the latest version of a module.';\n\nEND;\n",
+	"000051_add_search_documents_completion_indexes.down.sql":              "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nDROP INDEX idx_search_documents_lower_package_path_text_pattern_ops;\nDROP INDEX idx_search_documents_lower_name_text_pattern_ops;\n\nEND;\n",
+	"000051_add_search_documents_completion_indexes.up.sql":                "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nCREATE INDEX idx_search_documents_lower_package_path_text_pattern_ops\n    ON search_documents (lower(package_path) text_pattern_ops);\nCOMMENT ON INDEX idx_search_documents_lower_package_path_text_pattern_ops IS\n'INDEX idx_search_documents_lower_package_path_text_pattern_ops is used to improve performance of LIKE statements for lower(package_path). It is used to complete package paths from a prefix when redis is not available.';\n\nCREATE INDEX idx_search_documents_lower_name_text_pattern_ops\n    ON search_documents (lower(name) text_pattern_ops);\nCOMMENT ON INDEX idx_search_documents_lower_name_text_pattern_ops IS\n'INDEX idx_search_documents_lower_name_text_pattern_ops is used to improve performance of LIKE statements for lower(name). It is used to complete package names from a prefix when redis is not available.';\n\nEND;\n",
+	"000052_add_user_preferences_editor.down.sql":                          "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nALTER TABLE user_preferences\n    DROP COLUMN editor,\n    DROP COLUMN editor_url,\n    DROP COLUMN module_root;\n\nEND;\n",
+	"000052_add_user_preferences_editor.up.sql":                            "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nALTER TABLE user_preferences\n    ADD COLUMN editor text NOT NULL DEFAULT '',\n    ADD COLUMN editor_url text NOT NULL DEFAULT '',\n    ADD COLUMN module_root text NOT NULL DEFAULT '';\n\nCOMMENT ON COLUMN user_preferences.editor IS\n'COLUMN editor is the editor in which the user opens source files from documentation pages, like \"vscode\", or empty for none.';\n\nCOMMENT ON COLUMN user_preferences.editor_url IS\n'COLUMN editor_url is the URL template of the editor when editor is \"custom\", with placeholders like {path} and {line}.';\n\nCOMMENT ON COLUMN user_preferences.module_root IS\n'COLUMN module_root is the local directory of the user that holds modules with the layout of the module cache, from which files are opened in the editor.';\n\nEND;\n",
+	"000053_add_noindex_prefixes.down.sql":                                 "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nDROP TABLE noindex_prefixes;\n\nEND;\n",
+	"000053_add_noindex_prefixes.up.sql":                                   "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nCREATE TABLE noindex_prefixes (\n    prefix text PRIMARY KEY,\n    created_by text NOT NULL,\n    reason text NOT NULL,\n    created_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP\n);\n\nCOMMENT ON TABLE noindex_prefixes IS\n'TABLE noindex_prefixes holds the path prefixes whose pages the frontend asks search engines not to index, as with internal modules on a corporate deployment. Unlike excluded_prefixes, the pages are still served.';\n\nEND;\n",
+	"000054_add_search_documents_has_tests.down.sql":                       "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nALTER TABLE search_documents DROP COLUMN has_tests;\n\nEND;\n",
+	"000054_add_search_documents_has_tests.up.sql":                         "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nALTER TABLE search_documents ADD COLUMN has_tests boolean NOT NULL DEFAULT false;\n\nCOMMENT ON COLUMN search_documents.has_tests IS\n'COLUMN has_tests reports whether the package has test files. It is false for packages that have not been fetched since the column was added.';\n\nEND;\n",
+	"000055_add_excluded_prefix_events.down.sql":                           "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nDROP TABLE excluded_prefix_events;\n\nEND;\n",
+	"000055_add_excluded_prefix_events.up.sql":                             "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nCREATE TABLE excluded_prefix_events (\n    id bigserial PRIMARY KEY,\n    prefix text NOT NULL,\n    action text NOT NULL CHECK (action IN ('add', 'remove')),\n    created_by text NOT NULL,\n    reason text NOT NULL,\n    created_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP\n);\n\nCREATE INDEX idx_excluded_prefix_events_created_at ON excluded_prefix_events (created_at);\n\nCOMMENT ON TABLE excluded_prefix_events IS\n'TABLE excluded_prefix_events is the history of changes to excluded_prefixes: who added or removed each prefix, when, and why.';\n\nEND;\n",
+	"000056_add_excluded_prefix_purges.down.sql":                           "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nDROP TABLE excluded_prefix_purges;\n\nEND;\n",
+	"000056_add_excluded_prefix_purges.up.sql":                             "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nCREATE TABLE excluded_prefix_purges (\n    id bigserial PRIMARY KEY,\n    prefix text NOT NULL,\n    created_by text NOT NULL,\n    status text NOT NULL CHECK (status IN ('running', 'done', 'failed')),\n    module_versions_total integer NOT NULL DEFAULT 0,\n    module_versions_deleted integer NOT NULL DEFAULT 0,\n    search_documents_deleted integer NOT NULL DEFAULT 0,\n    error text NOT NULL DEFAULT '',\n    created_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,\n    updated_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP\n);\n\nCOMMENT ON TABLE excluded_prefix_purges IS\n'TABLE excluded_prefix_purges records the progress of the background jobs that delete the modules and search documents matching an excluded prefix.';\n\nEND;\n",
+	"000057_add_excluded_prefixes_scope_expires_at.down.sql":               "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nALTER TABLE excluded_prefixes\n    DROP COLUMN scope,\n    DROP COLUMN expires_at;\n\nEND;\n",
+	"000057_add_excluded_prefixes_scope_expires_at.up.sql":                 "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nALTER TABLE excluded_prefixes\n    ADD COLUMN scope text NOT NULL DEFAULT 'all' CHECK (scope IN ('all', 'search', 'fetch')),\n    ADD COLUMN expires_at timestamp with time zone;\n\nCOMMENT ON COLUMN excluded_prefixes.scope IS\n'COLUMN scope says what the prefix is excluded from: everything (\"all\"), only search results (\"search\"), or only processing by the worker (\"fetch\").';\n\nCOMMENT ON COLUMN excluded_prefixes.expires_at IS\n'COLUMN expires_at is when the exclusion stops applying. If it is NULL, the exclusion never expires.';\n\nEND;\n",
+	"000058_add_imported_by_count_runs.down.sql":                           "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nDROP TABLE imported_by_count_runs;\n\nEND;\n",
+	"000058_add_imported_by_count_runs.up.sql":                             "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nCREATE TABLE imported_by_count_runs (\n    id bigserial PRIMARY KEY,\n    last_package_path text NOT NULL DEFAULT '',\n    packages_done integer NOT NULL DEFAULT 0,\n    packages_changed integer NOT NULL DEFAULT 0,\n    started_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,\n    updated_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,\n    finished_at timestamp with time zone\n);\n\nCOMMENT ON TABLE imported_by_count_runs IS\n'TABLE imported_by_count_runs records the progress of the jobs that recompute the imported_by_count column of search_documents in batches of packages, in order of package path.';\n\nCOMMENT ON COLUMN imported_by_count_runs.last_package_path IS\n'COLUMN last_package_path holds the path of the last package whose count was recomputed. A run that was interrupted resumes after it.';\n\nCOMMENT ON COLUMN imported_by_count_runs.finished_at IS\n'COLUMN finished_at holds the time the run recomputed the count of the last package, or NULL if it has not.';\n\nEND;\n",
+	"000059_add_module_requirements_indirect.down.sql":                     "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nALTER TABLE module_requirements DROP COLUMN indirect;\n\nEND;\n",
+	"000059_add_module_requirements_indirect.up.sql":                       "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nALTER TABLE module_requirements ADD COLUMN indirect boolean NOT NULL DEFAULT false;\n\nCOMMENT ON COLUMN module_requirements.indirect IS\n'COLUMN indirect tells whether the require directive is marked with an \"// indirect\" comment. It is false for module versions processed before the column existed, until they are processed again.';\n\nEND;\n",
+	"000060_add_module_path_redirects.down.sql":                            "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nDROP TABLE module_path_redirect_overrides;\nDROP TABLE module_path_redirects;\n\nEND;\n",
+	"000060_add_module_path_redirects.up.sql":                              "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nCREATE TABLE module_path_redirects (\n    from_module_path text PRIMARY KEY,\n    to_module_path text NOT NULL,\n    version text NOT NULL,\n    sort_version text NOT NULL,\n    created_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,\n    updated_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP\n);\n\nCOMMENT ON TABLE module_path_redirects IS\n'TABLE module_path_redirects records modules that moved to a new path, detected when the go.mod file of a version of from_module_path declares to_module_path (status 491). version is the highest such version. The frontend redirects pages of from_module_path to to_module_path, unless a later version of from_module_path is in the modules table.';\n\nCREATE TABLE module_path_redirect_overrides (\n    from_module_path text PRIMARY KEY,\n    to_module_path text NOT NULL,\n    created_by text NOT NULL,\n    reason text NOT NULL,\n    created_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP\n);\n\nCOMMENT ON TABLE module_path_redirect_overrides IS\n'TABLE module_path_redirect_overrides holds redirects set by hand, which take precedence over those in module_path_redirects. An empty to_module_path turns off the redirect of from_module_path.';\n\nEND;\n",
+	"000061_add_go_text_search_config.down.sql":                            "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nCREATE OR REPLACE FUNCTION popular_search(rawquery text, lim integer, off integer,\n\tredist_factor real, go_mod_factor real, no_decls_factor real, fork_factor real,\n\tname_query text, exact_name_boost real, stdlib_boost real, module_root_boost real)\n\tRETURNS SETOF search_result\n    LANGUAGE plpgsql\n    AS $$\n\tDECLARE cur CURSOR(query TSQUERY) FOR\n\t\tSELECT\n\t\t\tpackage_path,\n\t\t\tmodule_path,\n\t\t\tversion,\n\t\t\tcommit_time,\n\t\t\timported_by_count,\n\t\t\t(\n\t\t\t\t-- default D, C, B, A weights are {0.1, 0.2, 0.4, 1.0}\n\t\t\t\tts_rank('{0.1, 0.2, 1.0, 1.0}', tsv_search_tokens, query) *\n\t\t\t\tln(exp(1)+imported_by_count) *\n\t\t\t\tCASE WHEN redistributable THEN 1 ELSE redist_factor END *\n\t\t\t\tCASE WHEN COALESCE(has_go_mod, true) THEN 1 ELSE go_mod_factor END *\n\t\t\t\tCASE WHEN kind = '' THEN 1 ELSE no_decls_factor END *\n\t\t\t\tCASE WHEN fork_of = '' THEN 1 ELSE fork_factor END *\n\t\t\t\tCASE WHEN lower(name) = name_query THEN exact_name_boost ELSE 1 END *\n\t\t\t\tCASE WHEN module_path = 'std' THEN stdlib_boost ELSE 1 END *\n\t\t\t\tCASE WHEN package_path = module_path THEN module_root_boost ELSE 1 END *\n\t\t\t\tCASE WHEN tsv_search_tokens @@ query THEN 1 ELSE 0 END\n\t\t\t) score\n\t\t\tFROM search_documents\n\t\t\tORDER BY imported_by_count DESC;\n\ttop search_result[];\n\tres search_result;\n\tlast_idx INT;\n\t-- The largest factor by which the boosts can increase a score.\n\tmax_boost REAL := GREATEST(exact_name_boost, 1) * GREATEST(stdlib_boost, 1) * GREATEST(module_root_boost, 1);\nBEGIN\n\tlast_idx := lim+off;\n\ttop := array_fill(NULL::search_result, array[last_idx]);\n\tOPEN cur(query := websearch_to_tsquery(rawquery));\n\tFETCH cur INTO res;\n\tWHILE found LOOP\n\t\tIF top[last_idx] IS NULL OR res.score >= top[last_idx].score THEN\n\t\t\tFOR i IN 1..last_idx LOOP\n\t\t\t\tIF top[i] IS NULL OR\n\t\t\t\t\t(res.score > top[i].score) OR\n\t\t\t\t\t(res.score = top[i].score AND res.commit_time > top[i].commit_time) OR\n\t\t\t\t\t(res.score = top[i].score AND res.commit_time = top[i].commit_time AND\n\t\t\t\t\t res.package_path < top[i].package_path) THEN\n\t\t\t\t\ttop := (top[1:i-1] || res) || top[i:last_idx-1];\n\t\t\t\t\tEXIT;\n\t\t\t\tEND IF;\n\t\t\tEND LOOP;\n\t\tEND IF;\n\t\tIF top[last_idx].score > ln(exp(1)+res.imported_by_count) * max_boost THEN\n\t\t\tEXIT;\n\t\tEND IF;\n\t\tFETCH cur INTO res;\n\tEND LOOP;\n\tCLOSE cur;\n\tRETURN QUERY SELECT * FROM UNNEST(top[off+1:last_idx])\n\t\tWHERE package_path IS NOT NULL AND score > 0.1;\nEND; $$;\nCOMMENT ON FUNCTION popular_search(rawquery text, lim integer, off integer,\n\tredist_factor real, go_mod_factor real, no_decls_factor real, fork_factor real,\n\tname_query text, exact_name_boost real, stdlib_boost real, module_root_boost real) IS\n'FUNCTION popular_search is used to generate results for search. It is implemented as a stored function, so that we can use a cursor to scan search documents procedurally, and stop scanning early, whenever our search results are provably correct.';\n\nDROP TEXT SEARCH CONFIGURATION go_text;\n\nEND;\n",
+	"000061_add_go_text_search_config.up.sql":                              "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nCREATE TEXT SEARCH CONFIGURATION go_text (COPY = pg_catalog.english);\n\n-- Keep whole hyphenated words, like package names such as \"go-kit\" or\n-- \"json-iterator\", as they are written. Their parts are still stemmed.\nALTER TEXT SEARCH CONFIGURATION go_text\n    ALTER MAPPING FOR asciihword, hword, numhword WITH simple;\n\n-- Words with digits, like \"base64\", \"utf8\" or \"k8s\", are identifiers, not\n-- English.\nALTER TEXT SEARCH CONFIGURATION go_text\n    ALTER MAPPING FOR numword, hword_numpart WITH simple;\n\nCOMMENT ON TEXT SEARCH CONFIGURATION go_text IS\n'TEXT SEARCH CONFIGURATION go_text is the configuration of the tsvectors of the synopses and READMEs in search_documents, and of search queries. It is like english, but keeps hyphenated words and words with digits, which are usually names of packages or identifiers, without stemming them. CamelCase and snake_case identifiers are split into parts before they are converted.';\n\n-- Redefine popular_search to parse queries with go_text, as deep search does.\n\nCREATE OR REPLACE FUNCTION popular_search(rawquery text, lim integer, off integer,\n\tredist_factor real, go_mod_factor real, no_decls_factor real, fork_factor real,\n\tname_query text, exact_name_boost real, stdlib_boost real, module_root_boost real)\n\tRETURNS SETOF search_result\n    LANGUAGE plpgsql\n    AS $$\n\tDECLARE cur CURSOR(query TSQUERY) FOR\n\t\tSELECT\n\t\t\tpackage_path,\n\t\t\tmodule_path,\n\t\t\tversion,\n\t\t\tcommit_time,\n\t\t\timported_by_count,\n\t\t\t(\n\t\t\t\t-- default D, C, B, A weights are {0.1, 0.2, 0.4, 1.0}\n\t\t\t\tts_rank('{0.1, 0.2, 1.0, 1.0}', tsv_search_tokens, query) *\n\t\t\t\tln(exp(1)+imported_by_count) *\n\t\t\t\tCASE WHEN redistributable THEN 1 ELSE redist_factor END *\n\t\t\t\tCASE WHEN COALESCE(has_go_mod, true) THEN 1 ELSE go_mod_factor END *\n\t\t\t\tCASE WHEN kind = '' THEN 1 ELSE no_decls_factor END *\n\t\t\t\tCASE WHEN fork_of = '' THEN 1 ELSE fork_factor END *\n\t\t\t\tCASE WHEN lower(name) = name_query THEN exact_name_boost ELSE 1 END *\n\t\t\t\tCASE WHEN module_path = 'std' THEN stdlib_boost ELSE 1 END *\n\t\t\t\tCASE WHEN package_path = module_path THEN module_root_boost ELSE 1 END *\n\t\t\t\tCASE WHEN tsv_search_tokens @@ query THEN 1 ELSE 0 END\n\t\t\t) score\n\t\t\tFROM search_documents\n\t\t\tORDER BY imported_by_count DESC;\n\ttop search_result[];\n\tres search_result;\n\tlast_idx INT;\n\t-- The largest factor by which the boosts can increase a score.\n\tmax_boost REAL := GREATEST(exact_name_boost, 1) * GREATEST(stdlib_boost, 1) * GREATEST(module_root_boost, 1);\nBEGIN\n\tlast_idx := lim+off;\n\ttop := array_fill(NULL::search_result, array[last_idx]);\n\tOPEN cur(query := websearch_to_tsquery('go_text', rawquery));\n\tFETCH cur INTO res;\n\tWHILE found LOOP\n\t\tIF top[last_idx] IS NULL OR res.score >= top[last_idx].score THEN\n\t\t\tFOR i IN 1..last_idx LOOP\n\t\t\t\tIF top[i] IS NULL OR\n\t\t\t\t\t(res.score > top[i].score) OR\n\t\t\t\t\t(res.score = top[i].score AND res.commit_time > top[i].commit_time) OR\n\t\t\t\t\t(res.score = top[i].score AND res.commit_time = top[i].commit_time AND\n\t\t\t\t\t res.package_path < top[i].package_path) THEN\n\t\t\t\t\ttop := (top[1:i-1] || res) || top[i:last_idx-1];\n\t\t\t\t\tEXIT;\n\t\t\t\tEND IF;\n\t\t\tEND LOOP;\n\t\tEND IF;\n\t\tIF top[last_idx].score > ln(exp(1)+res.imported_by_count) * max_boost THEN\n\t\t\tEXIT;\n\t\tEND IF;\n\t\tFETCH cur INTO res;\n\tEND LOOP;\n\tCLOSE cur;\n\tRETURN QUERY SELECT * FROM UNNEST(top[off+1:last_idx])\n\t\tWHERE package_path IS NOT NULL AND score > 0.1;\nEND; $$;\nCOMMENT ON FUNCTION popular_search(rawquery text, lim integer, off integer,\n\tredist_factor real, go_mod_factor real, no_decls_factor real, fork_factor real,\n\tname_query text, exact_name_boost real, stdlib_boost real, module_root_boost real) IS\n'FUNCTION popular_search is used to generate results for search. It is implemented as a stored function, so that we can use a cursor to scan search documents procedurally, and stop scanning early, whenever our search results are provably correct.';\n\nEND;\n",
+	"000062_add_search_documents_text_search_config.down.sql":              "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nDROP INDEX idx_search_documents_text_search_config;\n\nALTER TABLE search_documents DROP COLUMN text_search_config;\n\nEND;\n",
+	"000062_add_search_documents_text_search_config.up.sql":                "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\n-- Search documents that exist now were converted before go_text existed.\nALTER TABLE search_documents ADD COLUMN text_search_config text NOT NULL DEFAULT 'english';\n\nCOMMENT ON COLUMN search_documents.text_search_config IS\n'COLUMN text_search_config is the text search configuration that the synopsis and README sections of tsv_search_tokens were converted with. Documents converted with a configuration other than go_text, which queries are parsed with, are converted again by the worker.';\n\nCREATE INDEX idx_search_documents_text_search_config ON search_documents (package_path)\n    WHERE text_search_config <> 'go_text';\nCOMMENT ON INDEX idx_search_documents_text_search_config IS\n'INDEX idx_search_documents_text_search_config finds the search documents that still need to be converted with go_text.';\n\nEND;\n",
+	"000063_add_worker_load_queue_depth.down.sql":                          "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nALTER TABLE worker_load DROP COLUMN queue_depth;\n\nEND;\n",
+	"000063_add_worker_load_queue_depth.up.sql":                            "-- Copyright 2020 The Go Authors. All rights reserved.\n-- Use of this source code is governed by a BSD-style\n-- license that can be found in the LICENSE file.\n\nBEGIN;\n\nALTER TABLE worker_load ADD COLUMN queue_depth integer NOT NULL DEFAULT 0;\n\nCOMMENT ON COLUMN worker_load.queue_depth IS\n'COLUMN queue_depth is the number of fetches that were waiting in the queue of the worker instance, counted up to the depth at which it considers itself overloaded.';\n\nEND;\n",
+}
@@ -0,0 +1,198 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package migrations applies the migrations of the database schema in the
+// top-level migrations directory, which are compiled into the binary.
+//
+// The version of the schema is kept in the schema_migrations table, as the
+// migrate command-line tool does. A migration that fails leaves the schema
+// dirty, and it must be repaired by hand and forced to a version before
+// migrations can be applied again.
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	bindata "github.com/golang-migrate/migrate/v4/source/go_bindata"
+	"golang.org/x/pkgsite/internal/database"
+	"golang.org/x/pkgsite/internal/derrors"
+
+	// imported to register the postgres database driver
+	_ "github.com/lib/pq"
+)
+
+//go:generate go run gen_migrations.go
+
+// names returns the sorted names of the migration files.
+func names() []string {
+	var ns []string
+	for n := range files {
+		ns = append(ns, n)
+	}
+	sort.Strings(ns)
+	return ns
+}
+
+// Latest returns the version of the latest migration.
+func Latest() uint {
+	var latest uint
+	for _, n := range names() {
+		var v uint
+		if _, err := fmt.Sscanf(n, "%d_", &v); err == nil && v > latest {
+			latest = v
+		}
+	}
+	return latest
+}
+
+// A Migrator applies the migrations to a database.
+type Migrator struct {
+	m *migrate.Migrate
+}
+
+// New returns a Migrator for the Postgres database with the given connection
+// information. The Migrator should be closed when it is no longer needed.
+func New(connInfo string) (_ *Migrator, err error) {
+	defer derrors.Wrap(&err, "migrations.New")
+
+	db, err := sql.Open("postgres", connInfo)
+	if err != nil {
+		return nil, err
+	}
+	driver, err := postgres.WithInstance(db, &postgres.Config{})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	src, err := bindata.WithInstance(bindata.Resource(names(), func(name string) ([]byte, error) {
+		data, ok := files[name]
+		if !ok {
+			return nil, fmt.Errorf("no migration file %q", name)
+		}
+		return []byte(data), nil
+	}))
+	if err != nil {
+		driver.Close()
+		return nil, err
+	}
+	m, err := migrate.NewWithInstance("go-bindata", src, "postgres", driver)
+	if err != nil {
+		driver.Close()
+		return nil, err
+	}
+	return &Migrator{m: m}, nil
+}
+
+// Close closes the connections of m to the database.
+func (m *Migrator) Close() error {
+	srcErr, dbErr := m.m.Close()
+	if srcErr != nil {
+		return srcErr
+	}
+	return dbErr
+}
+
+// Version returns the version of the schema of the database, and whether it
+// is dirty. The version is zero if no migrations have been applied.
+func (m *Migrator) Version() (version uint, dirty bool, err error) {
+	version, dirty, err = m.m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, nil
+	}
+	return version, dirty, err
+}
+
+// Up applies all the migrations that haven't been applied.
+func (m *Migrator) Up() error {
+	return ignoreNoChange(m.m.Up())
+}
+
+// Steps applies the next n migrations if n is positive, or reverts the last
+// -n if n is negative.
+func (m *Migrator) Steps(n int) error {
+	return ignoreNoChange(m.m.Steps(n))
+}
+
+// Migrate applies or reverts migrations until the schema has the given
+// version.
+func (m *Migrator) Migrate(version uint) error {
+	return ignoreNoChange(m.m.Migrate(version))
+}
+
+// Force sets the version of the schema, and marks it clean, without applying
+// any migrations. It is used after repairing a failed migration by hand.
+func (m *Migrator) Force(version int) error {
+	return m.m.Force(version)
+}
+
+func ignoreNoChange(err error) error {
+	if errors.Is(err, migrate.ErrNoChange) {
+		return nil
+	}
+	return err
+}
+
+// Check returns an error if the schema of the database is not compatible
+// with this binary. See checkVersion.
+func (m *Migrator) Check() (err error) {
+	defer derrors.Wrap(&err, "Migrator.Check")
+
+	version, dirty, err := m.Version()
+	if err != nil {
+		return err
+	}
+	return checkVersion(version, dirty)
+}
+
+// Check is like Migrator.Check, but reads the version of the schema with db.
+func Check(ctx context.Context, db *database.DB) (err error) {
+	defer derrors.Wrap(&err, "migrations.Check")
+
+	var (
+		version uint
+		dirty   bool
+	)
+	err = db.QueryRow(ctx, `SELECT version, dirty FROM schema_migrations`).Scan(&version, &dirty)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	return checkVersion(version, dirty)
+}
+
+// checkVersion returns an error if a schema with the given version is dirty,
+// or older than the latest migration. A newer schema is compatible, since
+// migrations are applied before the binaries that need them are deployed,
+// and must not break the ones that are running.
+func checkVersion(version uint, dirty bool) error {
+	if dirty {
+		return fmt.Errorf("schema is dirty at version %d: repair it and force the version", version)
+	}
+	if latest := Latest(); version < latest {
+		return fmt.Errorf("schema is at version %d, but this binary needs version %d", version, latest)
+	}
+	return nil
+}
+
+// Apply applies the migrations that haven't been applied to the database
+// with the given connection information.
+func Apply(connInfo string) (err error) {
+	defer derrors.Wrap(&err, "migrations.Apply")
+
+	m, err := New(connInfo)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := m.Close(); err == nil {
+			err = cerr
+		}
+	}()
+	return m.Up()
+}
@@ -0,0 +1,120 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/pkgsite/internal/database"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/testing/dbtest"
+)
+
+func TestGeneratedFilesUpToDate(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("..", "..", "migrations", "*.sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range paths {
+		data, err := ioutil.ReadFile(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, ok := files[filepath.Base(p)]; !ok || got != string(data) {
+			t.Errorf("%s is not in migrations.gen.go or differs; run 'go generate ./internal/migrations'", p)
+		}
+	}
+	if len(files) != len(paths) {
+		t.Errorf("migrations.gen.go has %d files, want %d; run 'go generate ./internal/migrations'", len(files), len(paths))
+	}
+}
+
+func TestLatest(t *testing.T) {
+	names := names()
+	var want uint
+	for _, n := range names {
+		var v uint
+		if _, err := fmt.Sscanf(n, "%d_", &v); err != nil {
+			t.Fatalf("%s: %v", n, err)
+		}
+		if v > want {
+			want = v
+		}
+	}
+	if got := Latest(); got != want || got == 0 {
+		t.Errorf("Latest() = %d, want %d", got, want)
+	}
+}
+
+func TestMigrator(t *testing.T) {
+	const dbName = "discovery_migrations_test"
+	ctx := context.Background()
+	if err := dbtest.CreateDBIfNotExists(dbName); err != nil {
+		if errors.Is(err, derrors.NotFound) && os.Getenv("GO_DISCOVERY_TESTDB") != "true" {
+			t.Skipf("could not connect to DB (see doc/postgres.md to set up): %v", err)
+		}
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := dbtest.DropDB(dbName); err != nil {
+			t.Error(err)
+		}
+	}()
+	connInfo := dbtest.DBConnURI(dbName)
+
+	db, err := database.Open("postgres", connInfo, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// A new database is incompatible until it is migrated.
+	if err := Check(ctx, db); err == nil {
+		t.Fatal("Check before applying migrations: got nil error, want error")
+	}
+	if err := Apply(connInfo); err != nil {
+		t.Fatal(err)
+	}
+	if err := Check(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := New(connInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	checkVersion := func(want uint) {
+		t.Helper()
+		v, dirty, err := m.Version()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v != want || dirty {
+			t.Fatalf("got version %d, dirty %t; want %d, clean", v, dirty, want)
+		}
+	}
+	checkVersion(Latest())
+	if err := m.Steps(-1); err != nil {
+		t.Fatal(err)
+	}
+	checkVersion(Latest() - 1)
+	if err := m.Check(); err == nil {
+		t.Error("Check of older schema: got nil error, want error")
+	}
+	if err := m.Up(); err != nil {
+		t.Fatal(err)
+	}
+	checkVersion(Latest())
+	if err := m.Check(); err != nil {
+		t.Error(err)
+	}
+}
@@ -36,6 +36,12 @@ func (p *PackageMeta) RemoveNonRedistributableData() {
 	}
 }
 
+func (e *DirectoryEntry) RemoveNonRedistributableData() {
+	if !e.IsRedistributable {
+		e.Synopsis = ""
+	}
+}
+
 func (d *LegacyDirectory) RemoveNonRedistributableData() {
 	for _, p := range d.Packages {
 		p.RemoveNonRedistributableData()
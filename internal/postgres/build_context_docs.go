@@ -0,0 +1,110 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/database"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// insertBuildContextDocs replaces the documentation of the given paths in
+// other build contexts with the one in pathToDoc.
+func insertBuildContextDocs(ctx context.Context, db *database.DB, paths []string, pathToID map[string]int, pathToDoc map[string]*internal.Documentation) (err error) {
+	defer derrors.Wrap(&err, "insertBuildContextDocs(ctx, db, %d paths)", len(paths))
+
+	var ids []int
+	for _, path := range paths {
+		ids = append(ids, pathToID[path])
+	}
+	if _, err := db.Exec(ctx, `DELETE FROM build_context_documentation WHERE path_id = ANY($1)`, pq.Array(ids)); err != nil {
+		return err
+	}
+	var values []interface{}
+	for _, path := range paths {
+		doc := pathToDoc[path]
+		if doc == nil {
+			continue
+		}
+		for _, d := range doc.BuildContexts {
+			values = append(values, pathToID[path], d.GOOS, d.GOARCH, d.Hash, d.Source)
+		}
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	logMemory(ctx, "before inserting into build_context_documentation")
+	cols := []string{"path_id", "goos", "goarch", "doc_hash", "source"}
+	return db.BulkInsert(ctx, "build_context_documentation", cols, values, "")
+}
+
+// GetBuildContextDocs returns the documentation of the package at path in
+// the given module version for each build context it was loaded in, if its
+// text differs between them. Only the GOOS, GOARCH and Hash fields are set;
+// see GetBuildContextSource.
+func (db *DB) GetBuildContextDocs(ctx context.Context, path, modulePath, version string) (_ []*internal.BuildContextDoc, err error) {
+	defer derrors.Wrap(&err, "GetBuildContextDocs(ctx, %q, %q, %q)", path, modulePath, version)
+
+	query := `
+		SELECT d.goos, d.goarch, d.doc_hash
+		FROM build_context_documentation d
+		INNER JOIN paths p ON p.id = d.path_id
+		INNER JOIN modules m ON m.id = p.module_id
+		WHERE
+			p.path = $1
+			AND m.module_path = $2
+			AND m.version = $3
+		ORDER BY d.goos, d.goarch`
+	var docs []*internal.BuildContextDoc
+	collect := func(rows *sql.Rows) error {
+		var d internal.BuildContextDoc
+		if err := rows.Scan(&d.GOOS, &d.GOARCH, &d.Hash); err != nil {
+			return err
+		}
+		docs = append(docs, &d)
+		return nil
+	}
+	if err := db.db.RunQuery(ctx, query, collect, path, modulePath, version); err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+// GetBuildContextSource returns the encoded source of the package at path in
+// the given module version for the build context given by goos and goarch. It
+// returns an error with derrors.NotFound in its chain if the source was not
+// stored, which is the case for build contexts whose documentation is the
+// same as the one displayed by default.
+func (db *DB) GetBuildContextSource(ctx context.Context, path, modulePath, version, goos, goarch string) (_ []byte, err error) {
+	defer derrors.Wrap(&err, "GetBuildContextSource(ctx, %q, %q, %q, %q, %q)", path, modulePath, version, goos, goarch)
+
+	var source []byte
+	err = db.db.QueryRow(ctx, `
+		SELECT d.source
+		FROM build_context_documentation d
+		INNER JOIN paths p ON p.id = d.path_id
+		INNER JOIN modules m ON m.id = p.module_id
+		WHERE
+			p.path = $1
+			AND m.module_path = $2
+			AND m.version = $3
+			AND d.goos = $4
+			AND d.goarch = $5`,
+		path, modulePath, version, goos, goarch).Scan(&source)
+	switch {
+	case err == sql.ErrNoRows:
+		return nil, derrors.NotFound
+	case err != nil:
+		return nil, err
+	case len(source) == 0:
+		return nil, derrors.NotFound
+	default:
+		return source, nil
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/experiment"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestBuildContextDocs(t *testing.T) {
+	defer ResetTestDB(testDB, t)
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	ctx = experiment.NewContext(ctx, internal.ExperimentBuildContextDocs)
+
+	m := sample.LegacyModule(sample.ModulePath, sample.VersionString, sample.Suffix)
+	pkgPath := sample.ModulePath + "/" + sample.Suffix
+	darwinSource := []byte("darwin source")
+	for _, u := range m.Units {
+		if u.Path == pkgPath {
+			u.Documentation.BuildContexts = []*internal.BuildContextDoc{
+				{GOOS: "linux", GOARCH: "amd64", Hash: "h1"},
+				{GOOS: "darwin", GOARCH: "amd64", Hash: "h2", Source: darwinSource},
+			}
+		}
+	}
+	if err := testDB.InsertModule(ctx, m); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := testDB.GetBuildContextDocs(ctx, pkgPath, sample.ModulePath, sample.VersionString)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*internal.BuildContextDoc{
+		{GOOS: "darwin", GOARCH: "amd64", Hash: "h2"},
+		{GOOS: "linux", GOARCH: "amd64", Hash: "h1"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("GetBuildContextDocs mismatch (-want +got):\n%s", diff)
+	}
+
+	src, err := testDB.GetBuildContextSource(ctx, pkgPath, sample.ModulePath, sample.VersionString, "darwin", "amd64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(darwinSource, src); diff != "" {
+		t.Errorf("GetBuildContextSource mismatch (-want +got):\n%s", diff)
+	}
+	for _, goos := range []string{"linux", "windows"} {
+		if _, err := testDB.GetBuildContextSource(ctx, pkgPath, sample.ModulePath, sample.VersionString, goos, "amd64"); !errors.Is(err, derrors.NotFound) {
+			t.Errorf("GetBuildContextSource(%q): got %v, want NotFound", goos, err)
+		}
+	}
+}
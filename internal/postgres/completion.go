@@ -0,0 +1,69 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"path"
+	"strings"
+
+	"golang.org/x/pkgsite/internal/complete"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// GetPackageCompletions returns up to limit packages from search_documents
+// whose paths or names start with prefix, ignoring case, ordered by number of
+// importers, most first. It is used for autocompletion when the redis
+// completion indexes are not available.
+func (db *DB) GetPackageCompletions(ctx context.Context, prefix string, limit int) (_ []*complete.Completion, err error) {
+	defer derrors.Wrap(&err, "DB.GetPackageCompletions(ctx, %q, %d)", prefix, limit)
+
+	prefix = strings.ToLower(prefix)
+	query := `
+		SELECT package_path, module_path, version, name, synopsis, imported_by_count
+		FROM search_documents
+		WHERE lower(package_path) LIKE $1 OR lower(name) LIKE $1
+		ORDER BY imported_by_count DESC, package_path
+		LIMIT $2`
+	var completions []*complete.Completion
+	collect := func(rows *sql.Rows) error {
+		var (
+			c        complete.Completion
+			name     string
+			synopsis sql.NullString
+		)
+		if err := rows.Scan(&c.PackagePath, &c.ModulePath, &c.Version, &name, &synopsis, &c.Importers); err != nil {
+			return err
+		}
+		c.Synopsis = synopsis.String
+		c.Suffix = completionSuffix(c.PackagePath, name, prefix)
+		completions = append(completions, &c)
+		return nil
+	}
+	if err := db.db.RunQuery(ctx, query, collect, escapeLike(prefix)+"%", limit); err != nil {
+		return nil, err
+	}
+	return completions, nil
+}
+
+// completionSuffix returns the suffix of pkgPath that matched prefix, which
+// is in lower case: the whole path if it starts with prefix, or else its last
+// element, which is usually the package name.
+func completionSuffix(pkgPath, name, prefix string) string {
+	if strings.HasPrefix(strings.ToLower(pkgPath), prefix) {
+		return pkgPath
+	}
+	if base := path.Base(pkgPath); strings.HasPrefix(strings.ToLower(base), prefix) {
+		return base
+	}
+	return name
+}
+
+// escapeLike escapes the characters of s that are special in the pattern
+// of a LIKE expression.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,104 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal/complete"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestGetPackageCompletions(t *testing.T) {
+	defer ResetTestDB(testDB, t)
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	for _, m := range [][]string{
+		{"github.com/pkg/errors", ""},
+		{"example.com/Errs", "x_errors"},
+		{"example.com/other", "foo"},
+	} {
+		if err := testDB.InsertModule(ctx, sample.LegacyModule(m[0], sample.VersionString, m[1])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := testDB.db.Exec(ctx, `
+		UPDATE search_documents SET imported_by_count = 10
+		WHERE package_path = 'github.com/pkg/errors'`); err != nil {
+		t.Fatal(err)
+	}
+
+	completion := func(pkgPath, modulePath, suffix string, importers int) *complete.Completion {
+		return &complete.Completion{
+			Suffix:      suffix,
+			ModulePath:  modulePath,
+			Version:     sample.VersionString,
+			PackagePath: pkgPath,
+			Importers:   importers,
+			Synopsis:    sample.Synopsis,
+		}
+	}
+	for _, test := range []struct {
+		prefix string
+		limit  int
+		want   []*complete.Completion
+	}{
+		{
+			prefix: "github.com/pkg/",
+			limit:  5,
+			want: []*complete.Completion{
+				completion("github.com/pkg/errors", "github.com/pkg/errors", "github.com/pkg/errors", 10),
+			},
+		},
+		{
+			prefix: "example.com/errs",
+			limit:  5,
+			want: []*complete.Completion{
+				completion("example.com/Errs/x_errors", "example.com/Errs", "example.com/Errs/x_errors", 0),
+			},
+		},
+		{
+			prefix: "errors",
+			limit:  5,
+			want: []*complete.Completion{
+				completion("github.com/pkg/errors", "github.com/pkg/errors", "errors", 10),
+			},
+		},
+		{
+			// LIKE wildcards in the prefix match literally.
+			prefix: "x_",
+			limit:  5,
+			want: []*complete.Completion{
+				completion("example.com/Errs/x_errors", "example.com/Errs", "x_errors", 0),
+			},
+		},
+		{
+			// "x%" would match "x_errors" as a pattern.
+			prefix: "x%",
+			limit:  5,
+			want:   nil,
+		},
+		{
+			prefix: "example.com/",
+			limit:  1,
+			want: []*complete.Completion{
+				completion("example.com/Errs/x_errors", "example.com/Errs", "example.com/Errs/x_errors", 0),
+			},
+		},
+	} {
+		t.Run(test.prefix, func(t *testing.T) {
+			got, err := testDB.GetPackageCompletions(ctx, test.prefix, test.limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("mismatch (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
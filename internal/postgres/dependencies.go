@@ -0,0 +1,333 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+
+	"github.com/lib/pq"
+	"golang.org/x/mod/semver"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/database"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/log"
+)
+
+// maxDependencyGraphSize is the largest number of module versions visited
+// when computing the dependencies of a module version. Larger graphs are
+// indexed as incomplete.
+const maxDependencyGraphSize = 10000
+
+// insertModuleRequirements records the requirements of m in the
+// module_requirements table. If they differ from the ones already recorded,
+// the dependency index of the module version is invalidated.
+func insertModuleRequirements(ctx context.Context, db *database.DB, m *internal.Module, moduleID int) (err error) {
+	defer derrors.Wrap(&err, "insertModuleRequirements(ctx, %q, %q)", m.ModulePath, m.Version)
+
+	var (
+		old      []*internal.ModuleRequirement
+		recorded bool
+	)
+	if err := db.QueryRow(ctx, `SELECT requirements_recorded FROM modules WHERE id = $1`, moduleID).Scan(&recorded); err != nil {
+		return err
+	}
+	collect := func(rows *sql.Rows) error {
+		var r internal.ModuleRequirement
+		if err := rows.Scan(&r.ModulePath, &r.Version, &r.Indirect); err != nil {
+			return err
+		}
+		old = append(old, &r)
+		return nil
+	}
+	if err := db.RunQuery(ctx, `
+		SELECT required_module_path, required_version, indirect
+		FROM module_requirements
+		WHERE module_id = $1
+		ORDER BY required_module_path`, collect, moduleID); err != nil {
+		return err
+	}
+	if recorded && sameRequirements(old, m.Requirements) {
+		return nil
+	}
+
+	if _, err := db.Exec(ctx, `DELETE FROM module_requirements WHERE module_id = $1`, moduleID); err != nil {
+		return err
+	}
+	if _, err := db.Exec(ctx, `DELETE FROM module_dependency_index_states WHERE module_id = $1`, moduleID); err != nil {
+		return err
+	}
+	var values []interface{}
+	for _, r := range m.Requirements {
+		values = append(values, moduleID, r.ModulePath, r.Version, r.Indirect)
+	}
+	if len(values) > 0 {
+		cols := []string{"module_id", "required_module_path", "required_version", "indirect"}
+		if err := db.BulkInsert(ctx, "module_requirements", cols, values, ""); err != nil {
+			return err
+		}
+	}
+	_, err = db.Exec(ctx, `UPDATE modules SET requirements_recorded = true WHERE id = $1`, moduleID)
+	return err
+}
+
+// sameRequirements reports whether the requirements a and b, both sorted by
+// module path, are the same.
+func sameRequirements(a, b []*internal.ModuleRequirement) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if *a[i] != *b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// GetModuleRequirements returns the require directives of the go.mod file of
+// the module version modulePath@version, sorted by module path. It returns an
+// error wrapping derrors.NotFound if the module version is not in the
+// database, or was processed before its requirements were recorded.
+func (db *DB) GetModuleRequirements(ctx context.Context, modulePath, version string) (_ []*internal.ModuleRequirement, err error) {
+	defer derrors.Wrap(&err, "DB.GetModuleRequirements(ctx, %q, %q)", modulePath, version)
+
+	var recorded bool
+	err = db.readDB().QueryRow(ctx, `
+		SELECT requirements_recorded
+		FROM modules
+		WHERE module_path = $1 AND version = $2`,
+		modulePath, version).Scan(&recorded)
+	switch {
+	case err == sql.ErrNoRows:
+		return nil, derrors.NotFound
+	case err != nil:
+		return nil, err
+	case !recorded:
+		return nil, fmt.Errorf("requirements not recorded: %w", derrors.NotFound)
+	}
+	var reqs []*internal.ModuleRequirement
+	collect := func(rows *sql.Rows) error {
+		var r internal.ModuleRequirement
+		if err := rows.Scan(&r.ModulePath, &r.Version, &r.Indirect); err != nil {
+			return err
+		}
+		reqs = append(reqs, &r)
+		return nil
+	}
+	if err := db.readDB().RunQuery(ctx, `
+		SELECT r.required_module_path, r.required_version, r.indirect
+		FROM module_requirements r
+		INNER JOIN modules m ON m.id = r.module_id
+		WHERE m.module_path = $1 AND m.version = $2
+		ORDER BY r.required_module_path`, collect, modulePath, version); err != nil {
+		return nil, err
+	}
+	return reqs, nil
+}
+
+// GetModuleDependency reports whether the module version modulePath@version
+// transitively requires the module depModulePath, according to the
+// dependency index. It returns an error wrapping derrors.NotFound if the
+// module version is not in the database or has not been indexed yet.
+func (db *DB) GetModuleDependency(ctx context.Context, modulePath, version, depModulePath string) (_ *internal.ModuleDependency, err error) {
+	defer derrors.Wrap(&err, "DB.GetModuleDependency(ctx, %q, %q, %q)", modulePath, version, depModulePath)
+
+	d := &internal.ModuleDependency{
+		ModulePath:           modulePath,
+		Version:              version,
+		DependencyModulePath: depModulePath,
+	}
+	var depth sql.NullInt64
+	err = db.db.QueryRow(ctx, `
+		SELECT s.incomplete, s.indexed_at, d.dependency_version, d.depth, d.via_module_path
+		FROM modules m
+		INNER JOIN module_dependency_index_states s ON s.module_id = m.id
+		LEFT JOIN module_dependencies d
+			ON d.module_id = m.id AND d.dependency_module_path = $3
+		WHERE m.module_path = $1 AND m.version = $2`,
+		modulePath, version, depModulePath).Scan(
+		&d.Incomplete, &d.IndexedAt,
+		database.NullIsEmpty(&d.DependencyVersion), &depth, database.NullIsEmpty(&d.Via))
+	switch {
+	case err == sql.ErrNoRows:
+		return nil, derrors.NotFound
+	case err != nil:
+		return nil, err
+	}
+	if depth.Valid {
+		d.Depends = true
+		d.Depth = int(depth.Int64)
+	}
+	return d, nil
+}
+
+// IndexModuleDependencies computes the dependencies of at most limit module
+// versions, and stores them in the dependency index. Module versions that
+// have never been indexed come first, followed by those whose index was
+// incomplete, least recently indexed first, since the module versions that
+// were missing from their requirement graph may have been processed since.
+// It returns the number of module versions indexed.
+func (db *DB) IndexModuleDependencies(ctx context.Context, limit int) (_ int, err error) {
+	defer derrors.Wrap(&err, "DB.IndexModuleDependencies(ctx, %d)", limit)
+
+	var ids []int
+	query := `
+		SELECT m.id
+		FROM modules m
+		LEFT JOIN module_dependency_index_states s ON s.module_id = m.id
+		WHERE m.requirements_recorded AND (s.module_id IS NULL OR s.incomplete)
+		ORDER BY s.indexed_at ASC NULLS FIRST, m.id
+		LIMIT $1`
+	collect := func(rows *sql.Rows) error {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return err
+		}
+		ids = append(ids, id)
+		return nil
+	}
+	if err := db.db.RunQuery(ctx, query, collect, limit); err != nil {
+		return 0, err
+	}
+	for _, id := range ids {
+		if err := db.indexModuleDependencies(ctx, id); err != nil {
+			return 0, err
+		}
+	}
+	return len(ids), nil
+}
+
+// A moduleDependency is a row of the module_dependencies table.
+type moduleDependency struct {
+	modulePath string
+	version    string // highest version required
+	depth      int
+	via        string
+}
+
+// indexModuleDependencies computes and stores the dependencies of the module
+// version with the given ID.
+func (db *DB) indexModuleDependencies(ctx context.Context, moduleID int) (err error) {
+	defer derrors.Wrap(&err, "indexModuleDependencies(ctx, %d)", moduleID)
+
+	deps, incomplete, err := db.computeModuleDependencies(ctx, moduleID)
+	if err != nil {
+		return err
+	}
+	return db.db.Transact(ctx, sql.LevelDefault, func(tx *database.DB) error {
+		if _, err := tx.Exec(ctx, `DELETE FROM module_dependencies WHERE module_id = $1`, moduleID); err != nil {
+			return err
+		}
+		var values []interface{}
+		for _, d := range deps {
+			values = append(values, moduleID, d.modulePath, d.version, d.depth, d.via)
+		}
+		if len(values) > 0 {
+			cols := []string{"module_id", "dependency_module_path", "dependency_version", "depth", "via_module_path"}
+			if err := tx.BulkInsert(ctx, "module_dependencies", cols, values, ""); err != nil {
+				return err
+			}
+		}
+		_, err := tx.Exec(ctx, `
+			INSERT INTO module_dependency_index_states (module_id, incomplete)
+			VALUES ($1, $2)
+			ON CONFLICT (module_id) DO UPDATE
+			SET incomplete = excluded.incomplete, indexed_at = CURRENT_TIMESTAMP`,
+			moduleID, incomplete)
+		return err
+	})
+}
+
+// computeModuleDependencies walks the requirement graph of the module version
+// with the given ID breadth first, one level per query, and returns the
+// modules it reaches, sorted by path. The graph includes every version of a
+// module that is required anywhere, as in the go command's minimal version
+// selection. It also reports whether the graph is incomplete, because some of
+// its module versions are not in the database, their requirements were not
+// recorded, or it is too large.
+func (db *DB) computeModuleDependencies(ctx context.Context, moduleID int) (_ []*moduleDependency, incomplete bool, err error) {
+	type modver struct{ path, version string }
+
+	var root modver
+	if err := db.db.QueryRow(ctx, `SELECT module_path, version FROM modules WHERE id = $1`, moduleID).Scan(&root.path, &root.version); err != nil {
+		return nil, false, err
+	}
+	deps := map[string]*moduleDependency{}
+	visited := map[modver]bool{root: true}
+	frontier := []modver{root}
+	for depth := 1; len(frontier) > 0; depth++ {
+		var paths, versions []string
+		for _, mv := range frontier {
+			paths = append(paths, mv.path)
+			versions = append(versions, mv.version)
+		}
+		found := map[modver]bool{}
+		var next []modver
+		collect := func(rows *sql.Rows) error {
+			var (
+				mv       modver
+				recorded bool
+				req      modver
+			)
+			if err := rows.Scan(&mv.path, &mv.version, &recorded,
+				database.NullIsEmpty(&req.path), database.NullIsEmpty(&req.version)); err != nil {
+				return err
+			}
+			if recorded {
+				found[mv] = true
+			}
+			if req.path == "" || req.path == root.path {
+				return nil
+			}
+			via := req.path
+			if d := deps[mv.path]; d != nil {
+				via = d.via
+			}
+			if d := deps[req.path]; d == nil {
+				deps[req.path] = &moduleDependency{modulePath: req.path, version: req.version, depth: depth, via: via}
+			} else if semver.Compare(req.version, d.version) > 0 {
+				d.version = req.version
+			}
+			if !visited[req] {
+				visited[req] = true
+				next = append(next, req)
+			}
+			return nil
+		}
+		query := `
+			SELECT m.module_path, m.version, m.requirements_recorded,
+				r.required_module_path, r.required_version
+			FROM modules m
+			INNER JOIN unnest($1::text[], $2::text[]) AS f(module_path, version)
+				ON m.module_path = f.module_path AND m.version = f.version
+			LEFT JOIN module_requirements r ON r.module_id = m.id
+			ORDER BY m.module_path, m.version, r.required_module_path`
+		if err := db.db.RunQuery(ctx, query, collect, pq.Array(paths), pq.Array(versions)); err != nil {
+			return nil, false, err
+		}
+		for _, mv := range frontier {
+			if !found[mv] {
+				incomplete = true
+			}
+		}
+		if len(visited) > maxDependencyGraphSize {
+			log.Warningf(ctx, "requirement graph of %s@%s has more than %d module versions",
+				root.path, root.version, maxDependencyGraphSize)
+			incomplete = true
+			break
+		}
+		frontier = next
+	}
+
+	var ds []*moduleDependency
+	for _, d := range deps {
+		ds = append(ds, d)
+	}
+	sort.Slice(ds, func(i, j int) bool { return ds[i].modulePath < ds[j].modulePath })
+	return ds, incomplete, nil
+}
@@ -0,0 +1,123 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestModuleDependencies(t *testing.T) {
+	defer ResetTestDB(testDB, t)
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	// a requires b and c; b@v1.0.0 requires c@v1.1.0 and d; d is not in
+	// the database until later.
+	insert := func(path, version string, reqs ...string) {
+		t.Helper()
+		m := sample.LegacyModule(path, version, sample.Suffix)
+		for i := 0; i < len(reqs); i += 2 {
+			m.Requirements = append(m.Requirements, &internal.ModuleRequirement{ModulePath: reqs[i], Version: reqs[i+1]})
+		}
+		if err := testDB.InsertModule(ctx, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	insert("a.com/a", "v1.0.0", "b.com/b", "v1.0.0", "c.com/c", "v1.0.0")
+	insert("b.com/b", "v1.0.0", "c.com/c", "v1.1.0", "d.com/d", "v0.1.0")
+	insert("c.com/c", "v1.0.0")
+	insert("c.com/c", "v1.1.0", "a.com/a", "v1.0.0")
+
+	if _, err := testDB.GetModuleDependency(ctx, "a.com/a", "v1.0.0", "c.com/c"); !errors.Is(err, derrors.NotFound) {
+		t.Fatalf("before indexing: got error %v, want NotFound", err)
+	}
+	n, err := testDB.IndexModuleDependencies(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 4; n != want {
+		t.Errorf("indexed %d module versions, want %d", n, want)
+	}
+
+	ignore := cmpopts.IgnoreFields(internal.ModuleDependency{}, "IndexedAt")
+	check := func(dep string, want *internal.ModuleDependency) {
+		t.Helper()
+		got, err := testDB.GetModuleDependency(ctx, "a.com/a", "v1.0.0", dep)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want.ModulePath = "a.com/a"
+		want.Version = "v1.0.0"
+		want.DependencyModulePath = dep
+		if diff := cmp.Diff(want, got, ignore); diff != "" {
+			t.Errorf("%s: mismatch (-want +got):\n%s", dep, diff)
+		}
+	}
+	check("c.com/c", &internal.ModuleDependency{
+		Depends: true, DependencyVersion: "v1.1.0", Depth: 1, Via: "c.com/c", Incomplete: true,
+	})
+	check("d.com/d", &internal.ModuleDependency{
+		Depends: true, DependencyVersion: "v0.1.0", Depth: 2, Via: "b.com/b", Incomplete: true,
+	})
+	check("e.com/e", &internal.ModuleDependency{Incomplete: true})
+
+	// Once d is processed, the graph of a is complete.
+	insert("d.com/d", "v0.1.0", "e.com/e", "v1.0.0")
+	insert("e.com/e", "v1.0.0")
+	if _, err := testDB.IndexModuleDependencies(ctx, 10); err != nil {
+		t.Fatal(err)
+	}
+	check("e.com/e", &internal.ModuleDependency{
+		Depends: true, DependencyVersion: "v1.0.0", Depth: 3, Via: "b.com/b",
+	})
+	check("a.com/a", &internal.ModuleDependency{})
+}
+
+func TestGetModuleRequirements(t *testing.T) {
+	defer ResetTestDB(testDB, t)
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	m := sample.LegacyModule("a.com/a", "v1.0.0", sample.Suffix)
+	m.Requirements = []*internal.ModuleRequirement{
+		{ModulePath: "b.com/b", Version: "v1.0.0"},
+		{ModulePath: "c.com/c", Version: "v0.1.0", Indirect: true},
+	}
+	if err := testDB.InsertModule(ctx, m); err != nil {
+		t.Fatal(err)
+	}
+	got, err := testDB.GetModuleRequirements(ctx, "a.com/a", "v1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(m.Requirements, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	// Changing whether a requirement is indirect is recorded.
+	m.Requirements[1].Indirect = false
+	if err := testDB.InsertModule(ctx, m); err != nil {
+		t.Fatal(err)
+	}
+	got, err = testDB.GetModuleRequirements(ctx, "a.com/a", "v1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(m.Requirements, got); diff != "" {
+		t.Errorf("after change: mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := testDB.GetModuleRequirements(ctx, "a.com/a", "v1.1.0"); !errors.Is(err, derrors.NotFound) {
+		t.Errorf("missing version: got error %v, want NotFound", err)
+	}
+}
@@ -59,7 +59,7 @@ func (db *DB) GetNestedModules(ctx context.Context, modulePath string) (_ []*int
 		return nil
 	}
 	seriesPath := internal.SeriesPathForModule(modulePath)
-	if err := db.db.RunQuery(ctx, query, collect, seriesPath); err != nil {
+	if err := db.readDB().RunQuery(ctx, query, collect, seriesPath); err != nil {
 		return nil, err
 	}
 
@@ -99,12 +99,100 @@ func (db *DB) GetImportedBy(ctx context.Context, pkgPath, modulePath string, lim
 		importedby = append(importedby, fromPath)
 		return nil
 	}
-	if err := db.db.RunQuery(ctx, query, collect, pkgPath, modulePath, limit); err != nil {
+	if err := db.readDB().RunQuery(ctx, query, collect, pkgPath, modulePath, limit); err != nil {
 		return nil, err
 	}
 	return importedby, nil
 }
 
+// An Importer is a package that imports another, together with the number
+// of packages that import it in turn.
+type Importer struct {
+	Path          string
+	NumImportedBy int
+}
+
+// GetImportedByPage returns up to limit packages that import the package
+// with path, other than those in the module with modulePath. They are ordered
+// by descending NumImportedBy, then by path as by internal.PathLess. If after
+// is non-nil, the page starts with the first importer that comes after it in
+// that order, so that a page is unaffected by changes to the importers before
+// it.
+func (db *DB) GetImportedByPage(ctx context.Context, pkgPath, modulePath string, after *Importer, limit int) (_ []*Importer, err error) {
+	defer derrors.Wrap(&err, "GetImportedByPage(ctx, %q, %q, %+v, %d)", pkgPath, modulePath, after, limit)
+	if pkgPath == "" {
+		return nil, fmt.Errorf("pkgPath cannot be empty: %w", derrors.InvalidArgument)
+	}
+	args := []interface{}{pkgPath, modulePath, limit}
+	afterCond := "TRUE"
+	if after != nil {
+		afterCond = fmt.Sprintf(`num_imported_by < $4 OR (num_imported_by = $4 AND %s > %s)`,
+			pathOrder("from_path"), pathOrder("$5"))
+		args = append(args, after.NumImportedBy, after.Path)
+	}
+	query := fmt.Sprintf(`
+		SELECT from_path, num_imported_by
+		FROM (
+			SELECT
+				i.from_path,
+				COALESCE(s.imported_by_count, 0) AS num_imported_by
+			FROM (
+				SELECT DISTINCT from_path
+				FROM imports_unique
+				WHERE to_path = $1 AND from_module_path <> $2
+			) i
+			LEFT JOIN search_documents s
+			ON s.package_path = i.from_path
+		) ib
+		WHERE %s
+		ORDER BY num_imported_by DESC, %s
+		LIMIT $3`, afterCond, pathOrder("from_path"))
+
+	var importers []*Importer
+	collect := func(rows *sql.Rows) error {
+		var imp Importer
+		if err := rows.Scan(&imp.Path, &imp.NumImportedBy); err != nil {
+			return fmt.Errorf("row.Scan(): %v", err)
+		}
+		importers = append(importers, &imp)
+		return nil
+	}
+	if err := db.readDB().RunQuery(ctx, query, collect, args...); err != nil {
+		return nil, err
+	}
+	return importers, nil
+}
+
+// StreamImportedBy calls f on each package that imports the package with
+// path, in path order. Unlike GetImportedBy, it has no limit, and does not
+// hold the full list in memory.
+func (db *DB) StreamImportedBy(ctx context.Context, pkgPath, modulePath string, f func(fromPath string) error) (err error) {
+	defer derrors.Wrap(&err, "StreamImportedBy(ctx, %q, %q)", pkgPath, modulePath)
+	if pkgPath == "" {
+		return fmt.Errorf("pkgPath cannot be empty: %w", derrors.InvalidArgument)
+	}
+	query := `
+		SELECT
+			DISTINCT from_path
+		FROM
+			imports_unique
+		WHERE
+			to_path = $1
+		AND
+			from_module_path <> $2
+		ORDER BY
+			from_path`
+
+	collect := func(rows *sql.Rows) error {
+		var fromPath string
+		if err := rows.Scan(&fromPath); err != nil {
+			return fmt.Errorf("row.Scan(): %v", err)
+		}
+		return f(fromPath)
+	}
+	return db.readDB().RunQuery(ctx, query, collect, pkgPath, modulePath)
+}
+
 // GetModuleInfo fetches a module version from the database with the primary key
 // (module_path, version).
 func (db *DB) GetModuleInfo(ctx context.Context, modulePath, resolvedVersion string) (_ *internal.ModuleInfo, err error) {
@@ -124,7 +212,7 @@ func (db *DB) GetModuleInfo(ctx context.Context, modulePath, resolvedVersion str
 			module_path = $1
 			AND version = $2;`
 
-	row := db.db.QueryRow(ctx, query, modulePath, resolvedVersion)
+	row := db.readDB().QueryRow(ctx, query, modulePath, resolvedVersion)
 	mi, err := scanModuleInfo(row.Scan)
 	if err == sql.ErrNoRows {
 		return nil, derrors.NotFound
@@ -290,6 +290,74 @@ func TestGetImportedBy(t *testing.T) {
 	}
 }
 
+func TestGetImportedByPage(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer ResetTestDB(testDB, t)
+
+	// Packages in path4.to and path2.to import path.to, and one in path3.to
+	// imports path4.to, so that path4.to/foo/bar4 has more importers than
+	// the others but sorts after them by path.
+	var (
+		m1 = sample.LegacyModule("path.to/foo", "v1.1.0", "bar")
+		m2 = sample.LegacyModule("path2.to/foo", "v1.2.0", "bar2")
+		m3 = sample.LegacyModule("path3.to/foo", "v1.3.0", "bar3")
+		m4 = sample.LegacyModule("path4.to/foo", "v1.4.0", "bar4")
+	)
+	pkg1, pkg2, pkg3, pkg4 := m1.Units[1].Path, m2.Units[1].Path, m3.Units[1].Path, m4.Units[1].Path
+	m2.Units[1].Imports = []string{pkg1}
+	m3.Units[1].Imports = []string{pkg1, pkg4}
+	m4.Units[1].Imports = []string{pkg1}
+	for _, m := range []*internal.Module{m1, m2, m3, m4} {
+		if err := testDB.InsertModule(ctx, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := testDB.UpdateSearchDocumentsImportedByCount(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		name  string
+		after *Importer
+		limit int
+		want  []*Importer
+	}{
+		{
+			name:  "all",
+			limit: 10,
+			want:  []*Importer{{pkg4, 1}, {pkg2, 0}, {pkg3, 0}},
+		},
+		{
+			name:  "first page",
+			limit: 2,
+			want:  []*Importer{{pkg4, 1}, {pkg2, 0}},
+		},
+		{
+			name:  "second page",
+			after: &Importer{pkg2, 0},
+			limit: 2,
+			want:  []*Importer{{pkg3, 0}},
+		},
+		{
+			name:  "after a removed importer",
+			after: &Importer{"path1.to/foo/bar", 1},
+			limit: 2,
+			want:  []*Importer{{pkg4, 1}, {pkg2, 0}},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := testDB.GetImportedByPage(ctx, pkg1, m1.ModulePath, test.after, test.limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("mismatch (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestJSONBScanner(t *testing.T) {
 	type S struct{ A int }
 
@@ -7,90 +7,347 @@ package postgres
 import (
 	"context"
 	"database/sql"
-	"strings"
+	"fmt"
+	"strconv"
 	"sync"
 	"time"
 
+	"golang.org/x/pkgsite/internal/database"
 	"golang.org/x/pkgsite/internal/derrors"
 	"golang.org/x/pkgsite/internal/log"
+	"golang.org/x/sync/singleflight"
 )
 
-// IsExcluded reports whether the path matches the excluded list.
+// An ExclusionScope says what an excluded prefix excludes its paths from.
+type ExclusionScope string
+
+const (
+	// ExcludeEverywhere excludes paths from processing, serving and search.
+	ExcludeEverywhere ExclusionScope = "all"
+	// ExcludeFromSearch leaves paths out of search results only.
+	ExcludeFromSearch ExclusionScope = "search"
+	// ExcludeFromFetch stops the worker from processing paths, but serves
+	// what is already stored for them.
+	ExcludeFromFetch ExclusionScope = "fetch"
+)
+
+// ExclusionScopes are the valid exclusion scopes.
+var ExclusionScopes = []ExclusionScope{ExcludeEverywhere, ExcludeFromSearch, ExcludeFromFetch}
+
+// IsExcluded reports whether the path matches a prefix that is excluded
+// everywhere. It is used when serving paths. See regexpPatternPrefix for how
+// paths are matched.
 func (db *DB) IsExcluded(ctx context.Context, path string) (_ bool, err error) {
 	defer derrors.Wrap(&err, "DB.IsExcluded(ctx, %q)", path)
+	return db.isExcluded(ctx, path, ExcludeEverywhere)
+}
 
-	db.ensureExcludedPrefixes(ctx)
-	excludedPrefixes.mu.Lock()
-	defer excludedPrefixes.mu.Unlock()
-	if excludedPrefixes.err != nil {
-		return false, excludedPrefixes.err
+// IsExcludedFromSearch reports whether the path matches a prefix that is
+// excluded everywhere or from search results.
+func (db *DB) IsExcludedFromSearch(ctx context.Context, path string) (_ bool, err error) {
+	defer derrors.Wrap(&err, "DB.IsExcludedFromSearch(ctx, %q)", path)
+	return db.isExcluded(ctx, path, ExcludeFromSearch)
+}
+
+// IsExcludedFromFetch reports whether the path matches a prefix that is
+// excluded everywhere or from processing.
+func (db *DB) IsExcludedFromFetch(ctx context.Context, path string) (_ bool, err error) {
+	defer derrors.Wrap(&err, "DB.IsExcludedFromFetch(ctx, %q)", path)
+	return db.isExcluded(ctx, path, ExcludeFromFetch)
+}
+
+// isExcluded reports whether the path matches an unexpired prefix whose
+// scope is scope or ExcludeEverywhere.
+func (db *DB) isExcluded(ctx context.Context, path string, scope ExclusionScope) (bool, error) {
+	snap := db.excludedPrefixesSnapshot(ctx)
+	if snap.err != nil {
+		return false, snap.err
 	}
-	for _, prefix := range excludedPrefixes.prefixes {
-		if strings.HasPrefix(path, prefix) {
-			log.Infof(ctx, "path %q matched excluded prefix %q", path, prefix)
+	now := time.Now()
+	for _, m := range snap.matchers {
+		if m.scope != ExcludeEverywhere && m.scope != scope {
+			continue
+		}
+		if !m.expiresAt.IsZero() && !now.Before(m.expiresAt) {
+			continue
+		}
+		if m.match(path) {
+			log.Infof(ctx, "path %q matched excluded prefix %q (scope %s)", path, m.prefix, m.scope)
 			return true, nil
 		}
 	}
 	return false, nil
 }
 
-// InsertExcludedPrefix inserts prefix into the excluded_prefixes table.
+// An ExcludedPrefix is a path prefix whose modules and packages are not
+// processed, served or found by search, depending on its scope.
+type ExcludedPrefix struct {
+	Prefix    string
+	CreatedBy string
+	Reason    string
+	Scope     ExclusionScope
+	// ExpiresAt is when the exclusion stops applying. If it is zero, it
+	// never does.
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+// Expired reports whether the exclusion has stopped applying.
+func (ep *ExcludedPrefix) Expired() bool {
+	return !ep.ExpiresAt.IsZero() && !time.Now().Before(ep.ExpiresAt)
+}
+
+// An ExcludedPrefixEvent records a change to the excluded_prefixes table.
+type ExcludedPrefixEvent struct {
+	Prefix string
+	// Action is "add" or "remove".
+	Action    string
+	CreatedBy string
+	Reason    string
+	CreatedAt time.Time
+}
+
+// InsertExcludedPrefix inserts prefix into the excluded_prefixes table,
+// excluding it everywhere and forever, and records who added it and why in
+// the excluded_prefix_events table. See InsertExcludedPrefixEntry.
 //
-// For real-time administration (e.g. DOS prevention), use the dbadmin tool.
-// to exclude or unexclude a prefix. If the exclusion is permanent (e.g. a user
-// request), also add the prefix and reason to the excluded.txt file.
-func (db *DB) InsertExcludedPrefix(ctx context.Context, prefix, user, reason string) (err error) {
-	defer derrors.Wrap(&err, "DB.InsertExcludedPrefix(ctx, %q, %q)", prefix, reason)
-
-	_, err = db.db.Exec(ctx, "INSERT INTO excluded_prefixes (prefix, created_by, reason) VALUES ($1, $2, $3)",
-		prefix, user, reason)
+// For real-time administration (e.g. DOS prevention), use the worker's
+// /excluded page to exclude or unexclude a prefix. If the exclusion is
+// permanent (e.g. a user request), also add the prefix and reason to the
+// excluded.txt file.
+func (db *DB) InsertExcludedPrefix(ctx context.Context, prefix, user, reason string) error {
+	return db.InsertExcludedPrefixEntry(ctx, &ExcludedPrefix{
+		Prefix:    prefix,
+		CreatedBy: user,
+		Reason:    reason,
+		Scope:     ExcludeEverywhere,
+	})
+}
+
+// InsertExcludedPrefixEntry inserts ep into the excluded_prefixes table, and
+// records who added it and why in the excluded_prefix_events table. The
+// prefix may be a pattern, as described at regexpPatternPrefix. If the
+// prefix is already there but has expired, it is replaced.
+//
+// It returns an error wrapping derrors.InvalidArgument if the pattern or
+// scope is not valid.
+func (db *DB) InsertExcludedPrefixEntry(ctx context.Context, ep *ExcludedPrefix) (err error) {
+	defer derrors.Wrap(&err, "DB.InsertExcludedPrefixEntry(ctx, %q, %q, %q)", ep.Prefix, ep.Reason, ep.Scope)
+
+	if !validExclusionScope(ep.Scope) {
+		return fmt.Errorf("%w: unknown scope %q", derrors.InvalidArgument, ep.Scope)
+	}
+	re, err := excludedPatternRegexp(ep.Prefix)
 	if err != nil {
-		// Arrange to re-read the excluded_prefixes table on the next call to IsExcluded.
-		setExcludedPrefixesLastFetched(time.Time{})
+		return err
+	}
+	var expiresAt sql.NullTime
+	if !ep.ExpiresAt.IsZero() {
+		expiresAt = sql.NullTime{Time: ep.ExpiresAt, Valid: true}
+	}
+	err = db.db.Transact(ctx, sql.LevelDefault, func(tx *database.DB) error {
+		// Check that PostgreSQL accepts the regular expression too, since
+		// purging an excluded prefix matches it there.
+		var b bool
+		if err := tx.QueryRow(ctx, `SELECT '' ~ $1`, re).Scan(&b); err != nil {
+			return fmt.Errorf("%w: %v", derrors.InvalidArgument, err)
+		}
+		n, err := tx.Exec(ctx, `
+			INSERT INTO excluded_prefixes (prefix, created_by, reason, scope, expires_at)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (prefix) DO UPDATE SET
+				created_by = excluded.created_by,
+				reason = excluded.reason,
+				scope = excluded.scope,
+				expires_at = excluded.expires_at,
+				created_at = CURRENT_TIMESTAMP
+			WHERE excluded_prefixes.expires_at <= CURRENT_TIMESTAMP`,
+			ep.Prefix, ep.CreatedBy, ep.Reason, ep.Scope, expiresAt)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return fmt.Errorf("%w: prefix %q is already excluded", derrors.InvalidArgument, ep.Prefix)
+		}
+		return insertExcludedPrefixEvent(ctx, tx, ep.Prefix, "add", ep.CreatedBy, ep.Reason)
+	})
+	// Arrange to re-read the excluded_prefixes table on the next call to IsExcluded.
+	invalidateExcludedPrefixes()
+	return err
+}
+
+func validExclusionScope(scope ExclusionScope) bool {
+	for _, s := range ExclusionScopes {
+		if scope == s {
+			return true
+		}
 	}
+	return false
+}
+
+// DeleteExcludedPrefix removes prefix from the excluded_prefixes table, and
+// records who removed it and why in the excluded_prefix_events table. It
+// returns an error wrapping derrors.NotFound if prefix is not there.
+func (db *DB) DeleteExcludedPrefix(ctx context.Context, prefix, user, reason string) (err error) {
+	defer derrors.Wrap(&err, "DB.DeleteExcludedPrefix(ctx, %q, %q)", prefix, reason)
+
+	err = db.db.Transact(ctx, sql.LevelDefault, func(tx *database.DB) error {
+		n, err := tx.Exec(ctx, `DELETE FROM excluded_prefixes WHERE prefix = $1`, prefix)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return derrors.NotFound
+		}
+		return insertExcludedPrefixEvent(ctx, tx, prefix, "remove", user, reason)
+	})
+	invalidateExcludedPrefixes()
 	return err
 }
 
-// In-memory copy of excluded_prefixes.
+func insertExcludedPrefixEvent(ctx context.Context, tx *database.DB, prefix, action, user, reason string) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO excluded_prefix_events (prefix, action, created_by, reason)
+		VALUES ($1, $2, $3, $4)`,
+		prefix, action, user, reason)
+	return err
+}
+
+// GetExcludedPrefixDetails returns the rows of the excluded_prefixes table,
+// ordered by prefix, including those that have expired.
+func (db *DB) GetExcludedPrefixDetails(ctx context.Context) (_ []*ExcludedPrefix, err error) {
+	defer derrors.Wrap(&err, "DB.GetExcludedPrefixDetails(ctx)")
+
+	var eps []*ExcludedPrefix
+	err = db.db.RunQuery(ctx, `
+		SELECT prefix, created_by, reason, scope, expires_at, created_at
+		FROM excluded_prefixes
+		ORDER BY prefix`, func(rows *sql.Rows) error {
+		var (
+			ep                   ExcludedPrefix
+			expiresAt, createdAt sql.NullTime
+		)
+		if err := rows.Scan(&ep.Prefix, &ep.CreatedBy, &ep.Reason, &ep.Scope, &expiresAt, &createdAt); err != nil {
+			return err
+		}
+		ep.ExpiresAt = expiresAt.Time
+		ep.CreatedAt = createdAt.Time
+		eps = append(eps, &ep)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return eps, nil
+}
+
+// GetExcludedPrefixEvents returns the limit most recent changes to the
+// excluded_prefixes table, most recent first.
+func (db *DB) GetExcludedPrefixEvents(ctx context.Context, limit int) (_ []*ExcludedPrefixEvent, err error) {
+	defer derrors.Wrap(&err, "DB.GetExcludedPrefixEvents(ctx, %d)", limit)
+
+	var events []*ExcludedPrefixEvent
+	err = db.db.RunQuery(ctx, `
+		SELECT prefix, action, created_by, reason, created_at
+		FROM excluded_prefix_events
+		ORDER BY created_at DESC, id DESC
+		LIMIT $1`, func(rows *sql.Rows) error {
+		var e ExcludedPrefixEvent
+		if err := rows.Scan(&e.Prefix, &e.Action, &e.CreatedBy, &e.Reason, &e.CreatedAt); err != nil {
+			return err
+		}
+		events = append(events, &e)
+		return nil
+	}, limit)
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// An excludedSnapshot is an in-memory copy of the excluded_prefixes table.
+// It is never modified after it is created, so it can be read without
+// locking.
+type excludedSnapshot struct {
+	matchers []*excludedMatcher
+	err      error
+	fetched  time.Time
+}
+
+// excludedPrefixes holds the current snapshot of excluded_prefixes, which is
+// shared by all the DBs of a process. IsExcluded or one of its variants is
+// called for every path that is fetched or served, and for every search
+// result, so the table is read at most once per excludedPrefixesExpiration,
+// and by only one caller at a time.
 var excludedPrefixes struct {
-	mu          sync.Mutex
-	prefixes    []string
-	err         error
-	lastFetched time.Time
+	mu       sync.Mutex
+	snapshot *excludedSnapshot // nil if it must be read again
+	// generation counts invalidations, so that a read that started before
+	// an invalidation is not used after it.
+	generation int
+	group      singleflight.Group
 }
 
-func setExcludedPrefixesLastFetched(t time.Time) {
+// invalidateExcludedPrefixes arranges for the next call to IsExcluded to read
+// the excluded_prefixes table. It is called whenever this process changes
+// the table; other processes see the change when their snapshots expire.
+func invalidateExcludedPrefixes() {
 	excludedPrefixes.mu.Lock()
-	excludedPrefixes.lastFetched = t
+	excludedPrefixes.snapshot = nil
+	excludedPrefixes.generation++
 	excludedPrefixes.mu.Unlock()
 }
 
 const excludedPrefixesExpiration = time.Minute
 
-// ensureExcludedPrefixes makes sure the in-memory copy of the
-// excluded_prefixes table is up to date.
-func (db *DB) ensureExcludedPrefixes(ctx context.Context) {
+// excludedPrefixesSnapshot returns an up-to-date snapshot of the
+// excluded_prefixes table. If the current one has expired, the table is read
+// again; concurrent callers wait for the same read. If the read fails, the
+// previous snapshot, if any, is kept until the next expiration.
+func (db *DB) excludedPrefixesSnapshot(ctx context.Context) *excludedSnapshot {
 	excludedPrefixes.mu.Lock()
-	lastFetched := excludedPrefixes.lastFetched
+	old := excludedPrefixes.snapshot
+	gen := excludedPrefixes.generation
 	excludedPrefixes.mu.Unlock()
-	if time.Since(lastFetched) < excludedPrefixesExpiration {
-		return
-	}
-	prefixes, err := db.GetExcludedPrefixes(ctx)
-	excludedPrefixes.mu.Lock()
-	defer excludedPrefixes.mu.Unlock()
-	excludedPrefixes.lastFetched = time.Now()
-	excludedPrefixes.prefixes = prefixes
-	excludedPrefixes.err = err
-	if err != nil {
-		log.Errorf(ctx, "reading excluded_prefixes: %v", err)
+	if old != nil && time.Since(old.fetched) < excludedPrefixesExpiration {
+		return old
 	}
+	v, _, _ := excludedPrefixes.group.Do(strconv.Itoa(gen), func() (interface{}, error) {
+		snap := &excludedSnapshot{fetched: time.Now()}
+		eps, err := db.GetExcludedPrefixDetails(ctx)
+		if err != nil {
+			log.Errorf(ctx, "reading excluded_prefixes: %v", err)
+			if old != nil && old.err == nil {
+				snap.matchers = old.matchers
+			} else {
+				snap.err = err
+			}
+		}
+		for _, ep := range eps {
+			if !ep.Expired() {
+				snap.matchers = append(snap.matchers, newExcludedMatcher(ep))
+			}
+		}
+		excludedPrefixes.mu.Lock()
+		// Don't keep the result of a read that failed only because its
+		// caller went away.
+		if excludedPrefixes.generation == gen && ctx.Err() == nil {
+			excludedPrefixes.snapshot = snap
+		}
+		excludedPrefixes.mu.Unlock()
+		return snap, nil
+	})
+	return v.(*excludedSnapshot)
 }
 
-// GetExcludedPrefixes reads all the excluded prefixes from the database.
+// GetExcludedPrefixes reads all the excluded prefixes that have not expired
+// from the database.
 func (db *DB) GetExcludedPrefixes(ctx context.Context) ([]string, error) {
 	var eps []string
-	err := db.db.RunQuery(ctx, `SELECT prefix FROM excluded_prefixes`, func(rows *sql.Rows) error {
+	err := db.db.RunQuery(ctx, `
+		SELECT prefix
+		FROM excluded_prefixes
+		WHERE expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP`, func(rows *sql.Rows) error {
 		var ep string
 		if err := rows.Scan(&ep); err != nil {
 			return err
@@ -6,7 +6,12 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal/derrors"
 )
 
 func TestIsExcluded(t *testing.T) {
@@ -37,3 +42,152 @@ func TestIsExcluded(t *testing.T) {
 		}
 	}
 }
+
+func TestExcludedPrefixAdministration(t *testing.T) {
+	defer ResetTestDB(testDB, t)
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	if err := testDB.InsertExcludedPrefix(ctx, "b.com/", "alice", "spam"); err != nil {
+		t.Fatal(err)
+	}
+	if err := testDB.InsertExcludedPrefix(ctx, "a.com/", "bob", "abuse"); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := testDB.IsExcluded(ctx, "b.com/x"); err != nil || !got {
+		t.Fatalf("IsExcluded(b.com/x) = %t, %v; want true, nil", got, err)
+	}
+	eps, err := testDB.GetExcludedPrefixDetails(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, ep := range eps {
+		got = append(got, ep.Prefix+" "+ep.CreatedBy+" "+ep.Reason)
+	}
+	want := []string{"a.com/ bob abuse", "b.com/ alice spam"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("GetExcludedPrefixDetails mismatch (-want +got):\n%s", diff)
+	}
+
+	if err := testDB.DeleteExcludedPrefix(ctx, "b.com/", "carol", "resolved"); err != nil {
+		t.Fatal(err)
+	}
+	if err := testDB.DeleteExcludedPrefix(ctx, "b.com/", "carol", "resolved"); !errors.Is(err, derrors.NotFound) {
+		t.Errorf("deleting twice: got %v, want NotFound", err)
+	}
+	if got, err := testDB.IsExcluded(ctx, "b.com/x"); err != nil || got {
+		t.Errorf("after delete, IsExcluded(b.com/x) = %t, %v; want false, nil", got, err)
+	}
+
+	events, err := testDB.GetExcludedPrefixEvents(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = nil
+	for _, e := range events {
+		got = append(got, e.Action+" "+e.Prefix+" "+e.CreatedBy+" "+e.Reason)
+	}
+	want = []string{"remove b.com/ carol resolved", "add a.com/ bob abuse", "add b.com/ alice spam"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("GetExcludedPrefixEvents mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestIsExcludedCache(t *testing.T) {
+	defer ResetTestDB(testDB, t)
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	check := func(want bool) {
+		t.Helper()
+		got, err := testDB.IsExcluded(ctx, "bad.com/x")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("got %t, want %t", got, want)
+		}
+	}
+
+	check(false)
+	// A change made by another process is not seen until the snapshot
+	// expires or is invalidated.
+	if _, err := testDB.db.Exec(ctx, "INSERT INTO excluded_prefixes (prefix, created_by, reason) VALUES ('bad', 'someone', 'because')"); err != nil {
+		t.Fatal(err)
+	}
+	check(false)
+	invalidateExcludedPrefixes()
+	check(true)
+	// Changes made through the DB are seen at once.
+	if err := testDB.DeleteExcludedPrefix(ctx, "bad", "someone", "fixed"); err != nil {
+		t.Fatal(err)
+	}
+	check(false)
+}
+
+func TestExcludedScopesAndExpiration(t *testing.T) {
+	defer ResetTestDB(testDB, t)
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	for _, ep := range []*ExcludedPrefix{
+		{Prefix: "all.com/", Scope: ExcludeEverywhere},
+		{Prefix: "search.com/", Scope: ExcludeFromSearch},
+		{Prefix: "fetch.com/", Scope: ExcludeFromFetch},
+		{Prefix: "later.com/", Scope: ExcludeEverywhere, ExpiresAt: time.Now().Add(time.Hour)},
+		{Prefix: "past.com/", Scope: ExcludeEverywhere, ExpiresAt: time.Now().Add(-time.Hour)},
+	} {
+		ep.CreatedBy = "me"
+		ep.Reason = "testing"
+		if err := testDB.InsertExcludedPrefixEntry(ctx, ep); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, test := range []struct {
+		path                  string
+		wantServe, wantSearch bool
+		wantFetch             bool
+	}{
+		{"all.com/x", true, true, true},
+		{"search.com/x", false, true, false},
+		{"fetch.com/x", false, false, true},
+		{"later.com/x", true, true, true},
+		{"past.com/x", false, false, false},
+	} {
+		for _, c := range []struct {
+			name string
+			f    func(context.Context, string) (bool, error)
+			want bool
+		}{
+			{"IsExcluded", testDB.IsExcluded, test.wantServe},
+			{"IsExcludedFromSearch", testDB.IsExcludedFromSearch, test.wantSearch},
+			{"IsExcludedFromFetch", testDB.IsExcludedFromFetch, test.wantFetch},
+		} {
+			got, err := c.f(ctx, test.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != c.want {
+				t.Errorf("%s(%q) = %t, want %t", c.name, test.path, got, c.want)
+			}
+		}
+	}
+
+	// An expired prefix can be excluded again; an unexpired one can't.
+	again := &ExcludedPrefix{Prefix: "past.com/", CreatedBy: "me", Reason: "again", Scope: ExcludeFromSearch}
+	if err := testDB.InsertExcludedPrefixEntry(ctx, again); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := testDB.IsExcludedFromSearch(ctx, "past.com/x"); err != nil || !got {
+		t.Errorf("after excluding again: got %t, %v; want true, nil", got, err)
+	}
+	if err := testDB.InsertExcludedPrefixEntry(ctx, again); !errors.Is(err, derrors.InvalidArgument) {
+		t.Errorf("excluding twice: got %v, want InvalidArgument", err)
+	}
+	bad := &ExcludedPrefix{Prefix: "x.com/", CreatedBy: "me", Reason: "r", Scope: "serve"}
+	if err := testDB.InsertExcludedPrefixEntry(ctx, bad); !errors.Is(err, derrors.InvalidArgument) {
+		t.Errorf("unknown scope: got %v, want InvalidArgument", err)
+	}
+}
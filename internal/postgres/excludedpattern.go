@@ -0,0 +1,90 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// An excluded prefix is usually a plain path prefix, like "github.com/spam/".
+// It may instead be a pattern for a path prefix: a glob, in which "*" matches
+// any sequence of characters other than "/" and "?" matches any one of them,
+// like "github.com/*/spam", or a regular expression following "re:", like
+// `re:github\.com/[a-z]+-spam/`. Regular expressions are matched both in Go
+// and by PostgreSQL, so they may only use syntax that both accept, and they
+// match only at the start of a path.
+const regexpPatternPrefix = "re:"
+
+// excludedPatternRegexp returns a regular expression, anchored at the start,
+// for the paths that match the excluded prefix pattern. It returns an error
+// wrapping derrors.InvalidArgument if pattern is not valid.
+func excludedPatternRegexp(pattern string) (_ string, err error) {
+	defer derrors.Wrap(&err, "excludedPatternRegexp(%q)", pattern)
+
+	var re string
+	switch {
+	case strings.HasPrefix(pattern, regexpPatternPrefix):
+		expr := strings.TrimPrefix(pattern, regexpPatternPrefix)
+		if expr == "" {
+			return "", fmt.Errorf("%w: empty regular expression", derrors.InvalidArgument)
+		}
+		re = "^(?:" + expr + ")"
+	case strings.ContainsAny(pattern, "*?"):
+		var b strings.Builder
+		b.WriteByte('^')
+		for _, r := range pattern {
+			switch r {
+			case '*':
+				b.WriteString("[^/]*")
+			case '?':
+				b.WriteString("[^/]")
+			default:
+				b.WriteString(regexp.QuoteMeta(string(r)))
+			}
+		}
+		re = b.String()
+	default:
+		re = "^" + regexp.QuoteMeta(pattern)
+	}
+	if _, err := regexp.Compile(re); err != nil {
+		return "", fmt.Errorf("%w: %v", derrors.InvalidArgument, err)
+	}
+	return re, nil
+}
+
+// An excludedMatcher matches the paths that an excluded prefix excludes.
+type excludedMatcher struct {
+	prefix    string
+	re        *regexp.Regexp // nil for a plain prefix
+	scope     ExclusionScope
+	expiresAt time.Time
+}
+
+// newExcludedMatcher returns a matcher for ep. If its prefix is not a valid
+// pattern, which can only happen if it was added without
+// InsertExcludedPrefixEntry, it is matched as a plain prefix.
+func newExcludedMatcher(ep *ExcludedPrefix) *excludedMatcher {
+	prefix := ep.Prefix
+	m := &excludedMatcher{prefix: prefix, scope: ep.Scope, expiresAt: ep.ExpiresAt}
+	if !strings.HasPrefix(prefix, regexpPatternPrefix) && !strings.ContainsAny(prefix, "*?") {
+		return m
+	}
+	if re, err := excludedPatternRegexp(prefix); err == nil {
+		m.re = regexp.MustCompile(re)
+	}
+	return m
+}
+
+func (m *excludedMatcher) match(path string) bool {
+	if m.re != nil {
+		return m.re.MatchString(path)
+	}
+	return strings.HasPrefix(path, m.prefix)
+}
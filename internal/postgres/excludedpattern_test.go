@@ -0,0 +1,43 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"errors"
+	"testing"
+
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+func TestExcludedMatcher(t *testing.T) {
+	for _, test := range []struct {
+		prefix string
+		path   string
+		want   bool
+	}{
+		{"bad", "badness", true},
+		{"bad", "a/bad", false},
+		{"a.com/x+y", "a.com/x+y/z", true},
+		{"a.com/x+y", "a.com/xxy", false},
+		{"github.com/*/spam", "github.com/joe/spam/pkg", true},
+		{"github.com/*/spam", "github.com/joe/x/spam", false},
+		{"github.com/*/spam", "github.comx/joe/spam", false},
+		{"a.com/v?", "a.com/v2/x", true},
+		{"a.com/v?", "a.com/v/x", false},
+		{`re:github\.com/[a-z]+-spam/`, "github.com/buy-spam/x", true},
+		{`re:github\.com/[a-z]+-spam/`, "x/github.com/buy-spam/x", false},
+		{`re:a|b`, "bx", true},
+	} {
+		if got := newExcludedMatcher(&ExcludedPrefix{Prefix: test.prefix}).match(test.path); got != test.want {
+			t.Errorf("%q.match(%q) = %t, want %t", test.prefix, test.path, got, test.want)
+		}
+	}
+
+	for _, bad := range []string{"re:", "re:a(b"} {
+		if _, err := excludedPatternRegexp(bad); !errors.Is(err, derrors.InvalidArgument) {
+			t.Errorf("%q: got error %v, want InvalidArgument", bad, err)
+		}
+	}
+}
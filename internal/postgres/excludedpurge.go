@@ -0,0 +1,139 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// An ExcludedPrefixPurge records the progress of a job that deletes the
+// content that matches an excluded prefix.
+type ExcludedPrefixPurge struct {
+	ID        int64
+	Prefix    string
+	CreatedBy string
+	// Status is "running", "done" or "failed".
+	Status string
+	// ModuleVersionsTotal is the number of module versions that matched
+	// when the purge started.
+	ModuleVersionsTotal    int
+	ModuleVersionsDeleted  int
+	SearchDocumentsDeleted int
+	// Error is the error that the purge failed with.
+	Error     string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// InsertExcludedPrefixPurge records the start of a purge of prefix, which
+// must be in the excluded_prefixes table with scope ExcludeEverywhere, and
+// counts the module versions it matches. It returns an error wrapping
+// derrors.NotFound if prefix is not excluded everywhere.
+func (db *DB) InsertExcludedPrefixPurge(ctx context.Context, prefix, user string) (_ *ExcludedPrefixPurge, err error) {
+	defer derrors.Wrap(&err, "DB.InsertExcludedPrefixPurge(ctx, %q, %q)", prefix, user)
+
+	re, err := excludedPatternRegexp(prefix)
+	if err != nil {
+		return nil, err
+	}
+	p := &ExcludedPrefixPurge{Prefix: prefix, CreatedBy: user, Status: "running"}
+	err = db.db.QueryRow(ctx, `
+		INSERT INTO excluded_prefix_purges (prefix, created_by, status, module_versions_total)
+		SELECT $1, $2, $3, (SELECT COUNT(*) FROM modules WHERE module_path ~ $4)
+		WHERE EXISTS (SELECT 1 FROM excluded_prefixes WHERE prefix = $1 AND scope = 'all')
+		RETURNING id, module_versions_total, created_at, updated_at`,
+		prefix, user, p.Status, re).Scan(&p.ID, &p.ModuleVersionsTotal, &p.CreatedAt, &p.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, derrors.NotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// UpdateExcludedPrefixPurge records the progress of p.
+func (db *DB) UpdateExcludedPrefixPurge(ctx context.Context, p *ExcludedPrefixPurge) (err error) {
+	defer derrors.Wrap(&err, "DB.UpdateExcludedPrefixPurge(ctx, %d)", p.ID)
+
+	_, err = db.db.Exec(ctx, `
+		UPDATE excluded_prefix_purges
+		SET
+			status = $2,
+			module_versions_deleted = $3,
+			search_documents_deleted = $4,
+			error = $5,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1`,
+		p.ID, p.Status, p.ModuleVersionsDeleted, p.SearchDocumentsDeleted, p.Error)
+	return err
+}
+
+// GetExcludedPrefixPurges returns the limit most recent purges, most recent
+// first.
+func (db *DB) GetExcludedPrefixPurges(ctx context.Context, limit int) (_ []*ExcludedPrefixPurge, err error) {
+	defer derrors.Wrap(&err, "DB.GetExcludedPrefixPurges(ctx, %d)", limit)
+
+	var ps []*ExcludedPrefixPurge
+	err = db.db.RunQuery(ctx, `
+		SELECT
+			id, prefix, created_by, status,
+			module_versions_total, module_versions_deleted, search_documents_deleted,
+			error, created_at, updated_at
+		FROM excluded_prefix_purges
+		ORDER BY id DESC
+		LIMIT $1`, func(rows *sql.Rows) error {
+		var p ExcludedPrefixPurge
+		if err := rows.Scan(&p.ID, &p.Prefix, &p.CreatedBy, &p.Status,
+			&p.ModuleVersionsTotal, &p.ModuleVersionsDeleted, &p.SearchDocumentsDeleted,
+			&p.Error, &p.CreatedAt, &p.UpdatedAt); err != nil {
+			return err
+		}
+		ps = append(ps, &p)
+		return nil
+	}, limit)
+	if err != nil {
+		return nil, err
+	}
+	return ps, nil
+}
+
+// GetModuleVersionsMatchingExcludedPrefix returns at most limit module
+// versions whose module paths match prefix, ordered by module path and
+// version. Only the ModulePath and Version fields are set.
+func (db *DB) GetModuleVersionsMatchingExcludedPrefix(ctx context.Context, prefix string, limit int) (_ []*internal.ModuleInfo, err error) {
+	defer derrors.Wrap(&err, "DB.GetModuleVersionsMatchingExcludedPrefix(ctx, %q, %d)", prefix, limit)
+
+	re, err := excludedPatternRegexp(prefix)
+	if err != nil {
+		return nil, err
+	}
+	return db.collectModuleVersions(ctx, `
+		SELECT module_path, version
+		FROM modules
+		WHERE module_path ~ $1
+		ORDER BY module_path, version
+		LIMIT $2`, re, limit)
+}
+
+// DeleteSearchDocumentsMatchingExcludedPrefix deletes the search documents
+// whose package paths match prefix, and returns how many it deleted.
+// Deleting a module deletes its search documents, so this only matters for
+// a prefix that matches packages but not their modules, like
+// "github.com/a/b/internal/".
+func (db *DB) DeleteSearchDocumentsMatchingExcludedPrefix(ctx context.Context, prefix string) (n int64, err error) {
+	defer derrors.Wrap(&err, "DB.DeleteSearchDocumentsMatchingExcludedPrefix(ctx, %q)", prefix)
+
+	re, err := excludedPatternRegexp(prefix)
+	if err != nil {
+		return 0, err
+	}
+	return db.db.Exec(ctx, `DELETE FROM search_documents WHERE package_path ~ $1`, re)
+}
@@ -0,0 +1,84 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestExcludedPrefixPurge(t *testing.T) {
+	defer ResetTestDB(testDB, t)
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	for _, m := range []*internal.Module{
+		sample.LegacyModule("github.com/joe/spam", "v1.0.0", "a"),
+		sample.LegacyModule("github.com/joe/spam", "v1.1.0", "a"),
+		sample.LegacyModule("github.com/ann/spam", "v1.0.0", "b"),
+		sample.LegacyModule("github.com/ann/ham", "v1.0.0", "internal/c", "d"),
+	} {
+		if err := testDB.InsertModule(ctx, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	const prefix = "github.com/*/spam"
+	if _, err := testDB.InsertExcludedPrefixPurge(ctx, prefix, "me"); !errors.Is(err, derrors.NotFound) {
+		t.Fatalf("purging a prefix that is not excluded: got %v, want NotFound", err)
+	}
+	if err := testDB.InsertExcludedPrefix(ctx, prefix, "me", "spam"); err != nil {
+		t.Fatal(err)
+	}
+	p, err := testDB.InsertExcludedPrefixPurge(ctx, prefix, "me")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.ModuleVersionsTotal != 3 {
+		t.Errorf("got %d matching module versions, want 3", p.ModuleVersionsTotal)
+	}
+
+	mis, err := testDB.GetModuleVersionsMatchingExcludedPrefix(ctx, prefix, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, mi := range mis {
+		got = append(got, mi.ModulePath+"@"+mi.Version)
+	}
+	want := []string{"github.com/ann/spam@v1.0.0", "github.com/joe/spam@v1.0.0"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("GetModuleVersionsMatchingExcludedPrefix mismatch (-want +got):\n%s", diff)
+	}
+
+	n, err := testDB.DeleteSearchDocumentsMatchingExcludedPrefix(ctx, "github.com/ann/ham/internal/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("deleted %d search documents, want 1", n)
+	}
+
+	p.Status = "done"
+	p.ModuleVersionsDeleted = 3
+	p.SearchDocumentsDeleted = 1
+	if err := testDB.UpdateExcludedPrefixPurge(ctx, p); err != nil {
+		t.Fatal(err)
+	}
+	ps, err := testDB.GetExcludedPrefixPurges(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]*ExcludedPrefixPurge{p}, ps, cmpopts.IgnoreFields(ExcludedPrefixPurge{}, "CreatedAt", "UpdatedAt")); diff != "" {
+		t.Errorf("GetExcludedPrefixPurges mismatch (-want +got):\n%s", diff)
+	}
+}
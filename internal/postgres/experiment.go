@@ -0,0 +1,71 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// GetExperiments returns the experiments in the experiments table, ordered
+// by name.
+func (db *DB) GetExperiments(ctx context.Context) (_ []*internal.Experiment, err error) {
+	defer derrors.Wrap(&err, "DB.GetExperiments(ctx)")
+
+	var exps []*internal.Experiment
+	err = db.db.RunQuery(ctx, `
+		SELECT name, rollout, description
+		FROM experiments
+		ORDER BY name`, func(rows *sql.Rows) error {
+		var e internal.Experiment
+		if err := rows.Scan(&e.Name, &e.Rollout, &e.Description); err != nil {
+			return err
+		}
+		exps = append(exps, &e)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return exps, nil
+}
+
+// InsertExperiment inserts e into the experiments table, or updates its
+// rollout and description if it is already there.
+func (db *DB) InsertExperiment(ctx context.Context, e *internal.Experiment) (err error) {
+	defer derrors.Wrap(&err, "DB.InsertExperiment(ctx, %v)", e)
+
+	if e.Name == "" || e.Rollout > 100 {
+		return fmt.Errorf("experiment %q with rollout %d: %w", e.Name, e.Rollout, derrors.InvalidArgument)
+	}
+	_, err = db.db.Exec(ctx, `
+		INSERT INTO experiments (name, rollout, description)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (name) DO UPDATE SET
+			rollout = excluded.rollout,
+			description = excluded.description`,
+		e.Name, e.Rollout, e.Description)
+	return err
+}
+
+// RemoveExperiment removes the experiment with the given name from the
+// experiments table. It returns an error wrapping derrors.NotFound if it is
+// not there.
+func (db *DB) RemoveExperiment(ctx context.Context, name string) (err error) {
+	defer derrors.Wrap(&err, "DB.RemoveExperiment(ctx, %q)", name)
+
+	n, err := db.db.Exec(ctx, `DELETE FROM experiments WHERE name = $1`, name)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return derrors.NotFound
+	}
+	return nil
+}
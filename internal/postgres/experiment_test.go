@@ -0,0 +1,58 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+func TestExperiments(t *testing.T) {
+	defer ResetTestDB(testDB, t)
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	b := &internal.Experiment{Name: "b", Rollout: 10, Description: "B"}
+	a := &internal.Experiment{Name: "a", Rollout: 100, Description: "A"}
+	for _, e := range []*internal.Experiment{b, a} {
+		if err := testDB.InsertExperiment(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Inserting again updates the rollout.
+	b.Rollout = 50
+	if err := testDB.InsertExperiment(ctx, b); err != nil {
+		t.Fatal(err)
+	}
+	if err := testDB.InsertExperiment(ctx, &internal.Experiment{Name: "c", Rollout: 101}); !errors.Is(err, derrors.InvalidArgument) {
+		t.Errorf("rollout over 100: got error %v, want InvalidArgument", err)
+	}
+	got, err := testDB.GetExperiments(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]*internal.Experiment{a, b}, got); diff != "" {
+		t.Errorf("GetExperiments mismatch (-want +got):\n%s", diff)
+	}
+
+	if err := testDB.RemoveExperiment(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := testDB.RemoveExperiment(ctx, "a"); !errors.Is(err, derrors.NotFound) {
+		t.Errorf("removing a missing experiment: got error %v, want NotFound", err)
+	}
+	got, err = testDB.GetExperiments(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]*internal.Experiment{b}, got); diff != "" {
+		t.Errorf("GetExperiments after removal mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// InsertFeedbackReport inserts a report into the feedback_reports table and
+// returns its ID. The report's status is new.
+func (db *DB) InsertFeedbackReport(ctx context.Context, r *internal.FeedbackReport) (_ int, err error) {
+	defer derrors.Wrap(&err, "DB.InsertFeedbackReport(ctx, %q, %q)", r.Path, r.Version)
+
+	var id int
+	err = db.db.QueryRow(ctx, `
+		INSERT INTO feedback_reports (path, version, category, text, trace_id)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id`,
+		r.Path, r.Version, r.Category, r.Text, r.TraceID).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// GetFeedbackReports returns the most recent feedback reports with the given
+// status, newest first. At most limit reports are returned.
+func (db *DB) GetFeedbackReports(ctx context.Context, status internal.FeedbackStatus, limit int) (_ []*internal.FeedbackReport, err error) {
+	defer derrors.Wrap(&err, "DB.GetFeedbackReports(ctx, %q, %d)", status, limit)
+
+	var reports []*internal.FeedbackReport
+	collect := func(rows *sql.Rows) error {
+		var r internal.FeedbackReport
+		if err := rows.Scan(&r.ID, &r.Path, &r.Version, &r.Category, &r.Text,
+			&r.TraceID, &r.Status, &r.CreatedAt); err != nil {
+			return err
+		}
+		reports = append(reports, &r)
+		return nil
+	}
+	query := `
+		SELECT id, path, version, category, text, trace_id, status, created_at
+		FROM feedback_reports
+		WHERE status = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2`
+	if err := db.db.RunQuery(ctx, query, collect, status, limit); err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
+
+// UpdateFeedbackReportStatus sets the status of the feedback report with the
+// given ID. It returns an error wrapping derrors.NotFound if there is no such
+// report.
+func (db *DB) UpdateFeedbackReportStatus(ctx context.Context, id int, status internal.FeedbackStatus) (err error) {
+	defer derrors.Wrap(&err, "DB.UpdateFeedbackReportStatus(ctx, %d, %q)", id, status)
+
+	n, err := db.db.Exec(ctx, `UPDATE feedback_reports SET status = $2 WHERE id = $1`, id, status)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return derrors.NotFound
+	}
+	return nil
+}
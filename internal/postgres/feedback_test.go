@@ -0,0 +1,55 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+func TestFeedbackReports(t *testing.T) {
+	defer ResetTestDB(testDB, t)
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	reports := []*internal.FeedbackReport{
+		{Path: "a.com/m/p", Version: "v1.0.0", Category: internal.FeedbackCategoryRendering, Text: "broken", TraceID: "t1"},
+		{Path: "b.com/m", Version: "v2.0.0", Category: internal.FeedbackCategoryOther, Text: "buy now"},
+	}
+	for _, r := range reports {
+		id, err := testDB.InsertFeedbackReport(ctx, r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.ID = id
+		r.Status = internal.FeedbackStatusNew
+	}
+	if err := testDB.UpdateFeedbackReportStatus(ctx, reports[1].ID, internal.FeedbackStatusSpam); err != nil {
+		t.Fatal(err)
+	}
+	reports[1].Status = internal.FeedbackStatusSpam
+
+	for _, r := range reports {
+		got, err := testDB.GetFeedbackReports(ctx, r.Status, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []*internal.FeedbackReport{r}
+		if diff := cmp.Diff(want, got, cmpopts.IgnoreFields(internal.FeedbackReport{}, "CreatedAt")); diff != "" {
+			t.Errorf("GetFeedbackReports(%q) mismatch (-want +got):\n%s", r.Status, diff)
+		}
+	}
+
+	err := testDB.UpdateFeedbackReportStatus(ctx, reports[1].ID+1000, internal.FeedbackStatusTriaged)
+	if !errors.Is(err, derrors.NotFound) {
+		t.Errorf("got error %v, want NotFound", err)
+	}
+}
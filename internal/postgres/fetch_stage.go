@@ -0,0 +1,52 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// UpdateFetchStage records that the fetch of modulePath at requestedVersion
+// has reached stage.
+func (db *DB) UpdateFetchStage(ctx context.Context, modulePath, requestedVersion string, stage internal.FetchStage) (err error) {
+	defer derrors.Wrap(&err, "UpdateFetchStage(ctx, %q, %q, %q)", modulePath, requestedVersion, stage)
+
+	_, err = db.db.Exec(ctx, `
+		INSERT INTO fetch_stages (module_path, requested_version, stage)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (module_path, requested_version)
+		DO UPDATE SET
+			stage = excluded.stage,
+			updated_at = CURRENT_TIMESTAMP`,
+		modulePath, requestedVersion, string(stage))
+	return err
+}
+
+// GetFetchStage returns the stage that the most recent fetch of modulePath at
+// requestedVersion has reached, and when it reached it. It returns an error
+// that wraps derrors.NotFound if the module version has never been fetched.
+func (db *DB) GetFetchStage(ctx context.Context, modulePath, requestedVersion string) (_ internal.FetchStage, updatedAt time.Time, err error) {
+	defer derrors.Wrap(&err, "GetFetchStage(ctx, %q, %q)", modulePath, requestedVersion)
+
+	var stage string
+	err = db.db.QueryRow(ctx, `
+		SELECT stage, updated_at
+		FROM fetch_stages
+		WHERE module_path = $1 AND requested_version = $2`,
+		modulePath, requestedVersion).Scan(&stage, &updatedAt)
+	switch err {
+	case sql.ErrNoRows:
+		return "", time.Time{}, derrors.NotFound
+	case nil:
+		return internal.FetchStage(stage), updatedAt, nil
+	default:
+		return "", time.Time{}, err
+	}
+}
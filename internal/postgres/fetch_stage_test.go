@@ -0,0 +1,40 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+func TestFetchStage(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer ResetTestDB(testDB, t)
+
+	const modulePath, version = "example.com/mod", "v1.0.0"
+	if _, _, err := testDB.GetFetchStage(ctx, modulePath, version); !errors.Is(err, derrors.NotFound) {
+		t.Fatalf("GetFetchStage before update: got error %v, want NotFound", err)
+	}
+	for _, stage := range []internal.FetchStage{internal.FetchDownloading, internal.FetchProcessing, internal.FetchDone} {
+		if err := testDB.UpdateFetchStage(ctx, modulePath, version, stage); err != nil {
+			t.Fatal(err)
+		}
+		got, updatedAt, err := testDB.GetFetchStage(ctx, modulePath, version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != stage {
+			t.Errorf("got stage %q, want %q", got, stage)
+		}
+		if updatedAt.IsZero() {
+			t.Error("updatedAt is zero")
+		}
+	}
+}
@@ -0,0 +1,240 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"golang.org/x/pkgsite/internal/database"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/stdlib"
+)
+
+// An ImportedByCountRun records the progress of a job that recomputes the
+// imported-by counts of all the packages in search_documents, in batches in
+// order of package path.
+type ImportedByCountRun struct {
+	ID int64
+	// LastPackagePath is the path of the last package whose count was
+	// recomputed, or empty if none has been.
+	LastPackagePath string
+	// PackagesDone is the number of packages whose counts were recomputed,
+	// and PackagesChanged the number of those whose counts changed.
+	PackagesDone    int
+	PackagesChanged int
+	StartedAt       time.Time
+	UpdatedAt       time.Time
+	// FinishedAt is the time that the last package was done, or zero if the
+	// run has not finished.
+	FinishedAt time.Time
+}
+
+const importedByCountRunColumns = `
+	id, last_package_path, packages_done, packages_changed,
+	started_at, updated_at, finished_at`
+
+func scanImportedByCountRun(scan func(dest ...interface{}) error) (*ImportedByCountRun, error) {
+	var (
+		r        ImportedByCountRun
+		finished sql.NullTime
+	)
+	if err := scan(&r.ID, &r.LastPackagePath, &r.PackagesDone, &r.PackagesChanged,
+		&r.StartedAt, &r.UpdatedAt, &finished); err != nil {
+		return nil, err
+	}
+	r.FinishedAt = finished.Time
+	return &r, nil
+}
+
+// StartImportedByCountRun returns the run that recomputes imported-by counts
+// which has not finished, or starts a new one if there is none.
+func (db *DB) StartImportedByCountRun(ctx context.Context) (_ *ImportedByCountRun, err error) {
+	defer derrors.Wrap(&err, "DB.StartImportedByCountRun(ctx)")
+
+	var r *ImportedByCountRun
+	err = db.db.Transact(ctx, sql.LevelSerializable, func(tx *database.DB) error {
+		var err error
+		r, err = scanImportedByCountRun(tx.QueryRow(ctx, `
+			SELECT `+importedByCountRunColumns+`
+			FROM imported_by_count_runs
+			WHERE finished_at IS NULL
+			ORDER BY id DESC
+			LIMIT 1`).Scan)
+		if err != sql.ErrNoRows {
+			return err
+		}
+		r, err = scanImportedByCountRun(tx.QueryRow(ctx, `
+			INSERT INTO imported_by_count_runs DEFAULT VALUES
+			RETURNING `+importedByCountRunColumns).Scan)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetLastFinishedImportedByCountRun returns the most recent run that
+// recomputed the imported-by counts of all packages. It returns an error
+// wrapping derrors.NotFound if no run has finished.
+func (db *DB) GetLastFinishedImportedByCountRun(ctx context.Context) (_ *ImportedByCountRun, err error) {
+	defer derrors.Wrap(&err, "DB.GetLastFinishedImportedByCountRun(ctx)")
+
+	r, err := scanImportedByCountRun(db.db.QueryRow(ctx, `
+		SELECT `+importedByCountRunColumns+`
+		FROM imported_by_count_runs
+		WHERE finished_at IS NOT NULL
+		ORDER BY finished_at DESC
+		LIMIT 1`).Scan)
+	if err == sql.ErrNoRows {
+		return nil, derrors.NotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// UpdateImportedByCountBatch recomputes the imported-by counts of the next
+// batchSize packages of the run r from the imports_unique table, and records
+// its progress in r and in the database. It reports whether the run has
+// finished.
+//
+// Only importers in search_documents are counted, and not those in the same
+// module as the package they import. That is approximated by whether the
+// path of the imported package starts with the module path of the importer,
+// which is wrong for nested modules.
+//
+// The imported_by_count_updated_at column is set for every package with
+// importers, even if its count doesn't change, and for every package whose
+// count changes. A package that has never been imported keeps the default
+// count of zero, and its imported_by_count_updated_at is never set.
+func (db *DB) UpdateImportedByCountBatch(ctx context.Context, r *ImportedByCountRun, batchSize int) (finished bool, err error) {
+	defer derrors.Wrap(&err, "DB.UpdateImportedByCountBatch(ctx, %d, %d)", r.ID, batchSize)
+
+	const query = `
+		WITH batch AS (
+			SELECT package_path, imported_by_count
+			FROM search_documents
+			WHERE package_path > $1
+			ORDER BY package_path
+			LIMIT $2
+		), counts AS (
+			SELECT
+				b.package_path,
+				b.imported_by_count AS old_count,
+				COUNT(DISTINCT i.from_path) AS new_count
+			FROM batch b
+			LEFT JOIN (
+				SELECT i.from_path, i.to_path
+				FROM imports_unique i
+				INNER JOIN search_documents f
+				ON f.package_path = i.from_path
+				WHERE i.to_path IN (SELECT package_path FROM batch)
+				AND NOT (i.from_module_path = $3 AND split_part(i.to_path, '/', 1) NOT LIKE '%.%')
+				AND left(i.to_path || '/', length(i.from_module_path) + 1) <> i.from_module_path || '/'
+			) i
+			ON i.to_path = b.package_path
+			GROUP BY b.package_path, b.imported_by_count
+		), updated AS (
+			UPDATE search_documents s
+			SET
+				imported_by_count = c.new_count,
+				imported_by_count_updated_at = CURRENT_TIMESTAMP
+			FROM counts c
+			WHERE s.package_path = c.package_path
+			AND (c.new_count > 0 OR c.new_count <> c.old_count)
+			RETURNING c.new_count <> c.old_count AS changed
+		)
+		SELECT
+			(SELECT COUNT(*) FROM batch),
+			(SELECT MAX(package_path) FROM batch),
+			(SELECT COUNT(*) FROM updated WHERE changed)`
+
+	err = db.db.Transact(ctx, sql.LevelDefault, func(tx *database.DB) error {
+		var (
+			done, changed int
+			last          sql.NullString
+		)
+		if err := tx.QueryRow(ctx, query, r.LastPackagePath, batchSize, stdlib.ModulePath).Scan(&done, &last, &changed); err != nil {
+			return err
+		}
+		finished = done < batchSize
+		if last.Valid {
+			r.LastPackagePath = last.String
+		}
+		r.PackagesDone += done
+		r.PackagesChanged += changed
+		var finishedAt sql.NullTime
+		err := tx.QueryRow(ctx, `
+			UPDATE imported_by_count_runs
+			SET
+				last_package_path = $2,
+				packages_done = $3,
+				packages_changed = $4,
+				updated_at = CURRENT_TIMESTAMP,
+				finished_at = CASE WHEN $5 THEN CURRENT_TIMESTAMP END
+			WHERE id = $1
+			RETURNING updated_at, finished_at`,
+			r.ID, r.LastPackagePath, r.PackagesDone, r.PackagesChanged, finished).Scan(&r.UpdatedAt, &finishedAt)
+		r.FinishedAt = finishedAt.Time
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+	return finished, nil
+}
+
+// importedByCountBatchSize is the number of packages whose imported-by
+// counts UpdateSearchDocumentsImportedByCount recomputes in each transaction.
+const importedByCountBatchSize = 1000
+
+// UpdateSearchDocumentsImportedByCount recomputes the imported-by counts of
+// all the packages in search_documents, resuming the run that has not
+// finished if there is one. See UpdateImportedByCountBatch.
+//
+// UpdateSearchDocumentsImportedByCount returns the number of counts that
+// changed.
+func (db *DB) UpdateSearchDocumentsImportedByCount(ctx context.Context) (nChanged int64, err error) {
+	defer derrors.Wrap(&err, "UpdateSearchDocumentsImportedByCount(ctx)")
+
+	r, err := db.StartImportedByCountRun(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for {
+		finished, err := db.UpdateImportedByCountBatch(ctx, r, importedByCountBatchSize)
+		if err != nil {
+			return 0, err
+		}
+		if finished {
+			return int64(r.PackagesChanged), nil
+		}
+	}
+}
+
+// GetImportedByCount returns the number of packages that import pkgPath, as
+// last recomputed for search_documents; see UpdateImportedByCountBatch. It
+// returns an error wrapping derrors.NotFound if pkgPath is not in
+// search_documents.
+func (db *DB) GetImportedByCount(ctx context.Context, pkgPath string) (_ int, err error) {
+	defer derrors.Wrap(&err, "DB.GetImportedByCount(ctx, %q)", pkgPath)
+
+	var n int
+	err = db.readDB().QueryRow(ctx, `
+		SELECT imported_by_count
+		FROM search_documents
+		WHERE package_path = $1`, pkgPath).Scan(&n)
+	if err == sql.ErrNoRows {
+		return 0, derrors.NotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
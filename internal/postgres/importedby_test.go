@@ -0,0 +1,123 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestUpdateImportedByCountBatch(t *testing.T) {
+	defer ResetTestDB(testDB, t)
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	// Insert mod.com/X/X importing mod.com/I/I for each I in imports.
+	insert := func(x string, imports ...string) {
+		t.Helper()
+		m := sample.LegacyModule("mod.com/"+x, "v1.0.0", x)
+		pkg := m.Units[1]
+		pkg.Imports = nil
+		for _, imp := range imports {
+			pkg.Imports = append(pkg.Imports, fmt.Sprintf("mod.com/%s/%[1]s", imp))
+		}
+		if err := testDB.InsertModule(ctx, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	checkCount := func(x string, want int) {
+		t.Helper()
+		got, err := testDB.GetImportedByCount(ctx, fmt.Sprintf("mod.com/%s/%[1]s", x))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("%s: got %d importers, want %d", x, got, want)
+		}
+	}
+	// runAll recomputes all the counts in batches of one package, and
+	// checks the progress of the run.
+	runAll := func(wantChanged int) {
+		t.Helper()
+		r, err := testDB.StartImportedByCountRun(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.PackagesDone != 0 || !r.FinishedAt.IsZero() {
+			t.Fatalf("new run: got %+v", r)
+		}
+		var batches int
+		for finished := false; !finished; batches++ {
+			finished, err = testDB.UpdateImportedByCountBatch(ctx, r, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		// Each of the three packages, and a final empty batch.
+		if batches != 4 {
+			t.Errorf("got %d batches, want 4", batches)
+		}
+		if r.PackagesDone != 3 || r.PackagesChanged != wantChanged || r.LastPackagePath != "mod.com/C/C" {
+			t.Errorf("got %+v, want 3 packages done, %d changed, last mod.com/C/C", r, wantChanged)
+		}
+		last, err := testDB.GetLastFinishedImportedByCountRun(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if last.ID != r.ID || last.FinishedAt.IsZero() {
+			t.Errorf("last finished run: got %+v, want %+v", last, r)
+		}
+	}
+
+	if _, err := testDB.GetLastFinishedImportedByCountRun(ctx); !errors.Is(err, derrors.NotFound) {
+		t.Fatalf("no runs: got %v, want NotFound", err)
+	}
+	insert("A")
+	insert("B", "A")
+	insert("C", "A", "B")
+	runAll(2)
+	checkCount("A", 2)
+	checkCount("B", 1)
+	checkCount("C", 0)
+
+	// A run that is interrupted is resumed.
+	r, err := testDB.StartImportedByCountRun(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := testDB.UpdateImportedByCountBatch(ctx, r, 1); err != nil {
+		t.Fatal(err)
+	}
+	resumed, err := testDB.StartImportedByCountRun(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resumed.ID != r.ID || resumed.LastPackagePath != "mod.com/A/A" {
+		t.Errorf("resumed run: got %+v, want %+v", resumed, r)
+	}
+	for finished := false; !finished; {
+		if finished, err = testDB.UpdateImportedByCountBatch(ctx, resumed, 10); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Counts go down to zero when importers go away.
+	if err := testDB.DeleteModule(ctx, "mod.com/C", "v1.0.0"); err != nil {
+		t.Fatal(err)
+	}
+	insert("C")
+	runAll(2)
+	checkCount("A", 1)
+	checkCount("B", 0)
+
+	if _, err := testDB.GetImportedByCount(ctx, "mod.com/D/D"); !errors.Is(err, derrors.NotFound) {
+		t.Errorf("package not in search_documents: got %v, want NotFound", err)
+	}
+}
@@ -94,6 +94,9 @@ func (db *DB) saveModule(ctx context.Context, m *internal.Module) (err error) {
 		}
 
 		logMemory(ctx, "after insertLicenses")
+		if err := insertModuleRequirements(ctx, tx, m, moduleID); err != nil {
+			return err
+		}
 		if err := legacyInsertPackages(ctx, tx, m); err != nil {
 			return err
 		}
@@ -113,6 +116,11 @@ func (db *DB) saveModule(ctx context.Context, m *internal.Module) (err error) {
 		if err := lock(ctx, tx, m.ModulePath); err != nil {
 			return err
 		}
+		if experiment.IsActive(ctx, internal.ExperimentSymbolHistory) {
+			if err := insertSymbolHistory(ctx, tx, m); err != nil {
+				return err
+			}
+		}
 
 		// We only insert into imports_unique and search_documents if this is
 		// the latest version of the module.
@@ -170,10 +178,25 @@ func insertModule(ctx context.Context, db *database.DB, m *internal.Module) (_ i
 	if err != nil {
 		return 0, err
 	}
+	metadataJSON, err := json.Marshal(m.Metadata)
+	if err != nil {
+		return 0, err
+	}
+	retractionsJSON, err := json.Marshal(m.Retractions)
+	if err != nil {
+		return 0, err
+	}
 	versionType, err := version.ParseType(m.Version)
 	if err != nil {
 		return 0, err
 	}
+	var deprecatedMessage, successorModulePath *string
+	if m.Deprecation != nil {
+		deprecatedMessage = &m.Deprecation.Message
+		if m.Deprecation.Successor != "" {
+			successorModulePath = &m.Deprecation.Successor
+		}
+	}
 	var moduleID int
 	err = db.QueryRow(ctx,
 		`INSERT INTO modules(
@@ -186,13 +209,23 @@ func insertModule(ctx context.Context, db *database.DB, m *internal.Module) (_ i
 			source_info,
 			redistributable,
 			has_go_mod,
-			incompatible)
-		VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10)
+			incompatible,
+			metadata,
+			deprecated_message,
+			successor_module_path,
+			retractions)
+		VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14)
 		ON CONFLICT
 			(module_path, version)
 		DO UPDATE SET
 			source_info=excluded.source_info,
-			redistributable=excluded.redistributable
+			redistributable=excluded.redistributable,
+			metadata=excluded.metadata,
+			deprecated_message=excluded.deprecated_message,
+			successor_module_path=excluded.successor_module_path,
+			retractions=excluded.retractions,
+			-- The version was just fetched, so the proxy serves it.
+			proxy_status=NULL
 		RETURNING id`,
 		m.ModulePath,
 		m.Version,
@@ -204,6 +237,10 @@ func insertModule(ctx context.Context, db *database.DB, m *internal.Module) (_ i
 		m.IsRedistributable,
 		m.HasGoMod,
 		isIncompatible(m.Version),
+		metadataJSON,
+		deprecatedMessage,
+		successorModulePath,
+		retractionsJSON,
 	).Scan(&moduleID)
 	if err != nil {
 		return 0, err
@@ -222,7 +259,7 @@ func insertLicenses(ctx context.Context, db *database.DB, m *internal.Module, mo
 			return fmt.Errorf("marshalling %+v: %v", l.Coverage, err)
 		}
 		licenseValues = append(licenseValues, m.ModulePath, m.Version,
-			l.FilePath, makeValidUnicode(string(l.Contents)), pq.Array(l.Types), covJSON, moduleID)
+			l.FilePath, makeValidUnicode(string(l.Contents)), pq.Array(l.Types), string(covJSON), moduleID)
 	}
 	if len(licenseValues) > 0 {
 		licenseCols := []string{
@@ -234,7 +271,7 @@ func insertLicenses(ctx context.Context, db *database.DB, m *internal.Module, mo
 			"coverage",
 			"module_id",
 		}
-		return db.BulkUpsert(ctx, "licenses", licenseCols, licenseValues,
+		return db.CopyUpsert(ctx, "licenses", licenseCols, licenseValues,
 			[]string{"module_path", "version", "file_path"})
 	}
 	return nil
@@ -284,6 +321,8 @@ func legacyInsertPackages(ctx context.Context, db *database.DB, m *internal.Modu
 		pkgValues = append(pkgValues,
 			p.Path,
 			p.Synopsis,
+			p.SynopsisInferred,
+			p.Kind,
 			p.Name,
 			m.Version,
 			m.ModulePath,
@@ -305,6 +344,8 @@ func legacyInsertPackages(ctx context.Context, db *database.DB, m *internal.Modu
 		pkgCols := []string{
 			"path",
 			"synopsis",
+			"synopsis_inferred",
+			"kind",
 			"name",
 			"version",
 			"module_path",
@@ -317,7 +358,7 @@ func legacyInsertPackages(ctx context.Context, db *database.DB, m *internal.Modu
 			"goarch",
 			"commit_time",
 		}
-		if err := db.BulkUpsert(ctx, "packages", pkgCols, pkgValues, uniqueCols); err != nil {
+		if err := db.CopyUpsert(ctx, "packages", pkgCols, pkgValues, uniqueCols); err != nil {
 			return err
 		}
 	}
@@ -329,7 +370,7 @@ func legacyInsertPackages(ctx context.Context, db *database.DB, m *internal.Modu
 			"from_version",
 			"to_path",
 		}
-		if err := db.BulkUpsert(ctx, "imports", importCols, importValues, importCols); err != nil {
+		if err := db.CopyUpsert(ctx, "imports", importCols, importValues, importCols); err != nil {
 			return err
 		}
 	}
@@ -498,17 +539,36 @@ func insertUnits(ctx context.Context, db *database.DB, m *internal.Module, modul
 				continue
 			}
 			id := pathToID[path]
-			docValues = append(docValues, id, doc.GOOS, doc.GOARCH, doc.Synopsis, makeValidUnicode(doc.HTML.String()))
+			html := makeValidUnicode(doc.HTML.String())
+			if sourceOnlyDoc(ctx, doc) {
+				// The frontend renders documentation without HTML from
+				// its source.
+				html = ""
+			}
+			docValues = append(docValues, id, doc.GOOS, doc.GOARCH, doc.Synopsis, doc.SynopsisInferred, doc.Kind, html)
 			if experiment.IsActive(ctx, internal.ExperimentInsertPackageSource) {
 				docValues = append(docValues, doc.Source)
 			}
 		}
 		uniqueCols := []string{"path_id", "goos", "goarch"}
-		docCols := append(uniqueCols, "synopsis", "html")
+		docCols := append(uniqueCols, "synopsis", "synopsis_inferred", "kind", "html")
 		if experiment.IsActive(ctx, internal.ExperimentInsertPackageSource) {
 			docCols = append(docCols, "source")
 		}
-		if err := db.BulkUpsert(ctx, "documentation", docCols, docValues, uniqueCols); err != nil {
+		if err := db.CopyUpsert(ctx, "documentation", docCols, docValues, uniqueCols); err != nil {
+			return err
+		}
+	}
+	if err := insertDocumentationParts(ctx, db, paths, pathToID, pathToDoc); err != nil {
+		return err
+	}
+	if experiment.IsActive(ctx, internal.ExperimentBuildContextDocs) {
+		if err := insertBuildContextDocs(ctx, db, paths, pathToID, pathToDoc); err != nil {
+			return err
+		}
+	}
+	if experiment.IsActive(ctx, internal.ExperimentAPIDiff) {
+		if err := insertPackageSymbols(ctx, db, paths, pathToID, pathToDoc); err != nil {
 			return err
 		}
 	}
@@ -526,7 +586,46 @@ func insertUnits(ctx context.Context, db *database.DB, m *internal.Module, modul
 		}
 	}
 	importCols := []string{"path_id", "to_path"}
-	return db.BulkUpsert(ctx, "package_imports", importCols, importValues, importCols)
+	return db.CopyUpsert(ctx, "package_imports", importCols, importValues, importCols)
+}
+
+// sourceOnlyDoc reports whether only the source of doc is stored, and not
+// its HTML or the parts of its HTML. The HTML is not stored unless the source
+// can be.
+func sourceOnlyDoc(ctx context.Context, doc *internal.Documentation) bool {
+	return experiment.IsActive(ctx, internal.ExperimentSourceOnlyDoc) &&
+		experiment.IsActive(ctx, internal.ExperimentInsertPackageSource) &&
+		len(doc.Source) > 0
+}
+
+// insertDocumentationParts replaces the documentation parts of the given
+// paths with the ones in pathToDoc.
+func insertDocumentationParts(ctx context.Context, db *database.DB, paths []string, pathToID map[string]int, pathToDoc map[string]*internal.Documentation) (err error) {
+	defer derrors.Wrap(&err, "insertDocumentationParts(ctx, db, %d paths)", len(paths))
+
+	var ids []int
+	for _, path := range paths {
+		ids = append(ids, pathToID[path])
+	}
+	if _, err := db.Exec(ctx, `DELETE FROM documentation_parts WHERE path_id = ANY($1)`, pq.Array(ids)); err != nil {
+		return err
+	}
+	var values []interface{}
+	for _, path := range paths {
+		doc := pathToDoc[path]
+		if doc == nil || sourceOnlyDoc(ctx, doc) {
+			continue
+		}
+		for i, p := range doc.Parts {
+			values = append(values, pathToID[path], i+1, p.Title, makeValidUnicode(p.HTML.String()))
+		}
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	logMemory(ctx, "before inserting into documentation_parts")
+	cols := []string{"path_id", "part", "title", "html"}
+	return db.BulkInsert(ctx, "documentation_parts", cols, values, "")
 }
 
 // lock obtains an exclusive, transaction-scoped advisory lock on modulePath.
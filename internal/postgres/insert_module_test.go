@@ -23,6 +23,7 @@ import (
 	"golang.org/x/pkgsite/internal"
 	"golang.org/x/pkgsite/internal/database"
 	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/experiment"
 	"golang.org/x/pkgsite/internal/licenses"
 	"golang.org/x/pkgsite/internal/source"
 	"golang.org/x/pkgsite/internal/stdlib"
@@ -211,6 +212,42 @@ func TestUpsertModule(t *testing.T) {
 	checkModule(ctx, t, m)
 }
 
+func TestInsertModuleSourceOnlyDoc(t *testing.T) {
+	defer ResetTestDB(testDB, t)
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	ctx = experiment.NewContext(ctx, internal.ExperimentInsertPackageSource, internal.ExperimentSourceOnlyDoc)
+
+	m := sample.LegacyModule("m.com", "v1.0.0", "p")
+	doc := m.Units[1].Documentation
+	doc.Source = []byte("source")
+	doc.Parts = []*internal.DocumentationPart{{Title: "Functions", HTML: testconversions.MakeHTMLForTest("part")}}
+	if err := testDB.InsertModule(ctx, m); err != nil {
+		t.Fatal(err)
+	}
+	um, err := testDB.GetUnitMeta(ctx, "m.com/p", "m.com", "v1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := testDB.GetUnit(ctx, um, internal.WithDocumentation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Only the source and the information that isn't rendered are stored.
+	if got := u.Documentation.HTML.String(); got != "" {
+		t.Errorf("got HTML %q, want none", got)
+	}
+	if len(u.Documentation.Parts) != 0 {
+		t.Errorf("got %d documentation parts, want none", len(u.Documentation.Parts))
+	}
+	if got, want := string(u.Documentation.Source), "source"; got != want {
+		t.Errorf("got source %q, want %q", got, want)
+	}
+	if got, want := u.Documentation.Synopsis, sample.Synopsis; got != want {
+		t.Errorf("got synopsis %q, want %q", got, want)
+	}
+}
+
 func TestInsertModuleErrors(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), testTimeout*2)
 	defer cancel()
@@ -597,3 +634,32 @@ func TestLock(t *testing.T) {
 		t.Errorf("got %d, want %d", count, n)
 	}
 }
+
+// BenchmarkInsertModule measures the insertion of modules with as many
+// packages as the largest ones, like k8s.io/kubernetes. Run it with
+//
+//	go test ./internal/postgres -run NONE -bench InsertModule
+func BenchmarkInsertModule(b *testing.B) {
+	ctx := context.Background()
+	for _, numPackages := range []int{100, 1000, 5000} {
+		b.Run(fmt.Sprintf("%d-packages", numPackages), func(b *testing.B) {
+			defer ResetTestDB(testDB, b)
+			var modules []*internal.Module
+			for i := 0; i < b.N; i++ {
+				m := sample.LegacyModule(sample.ModulePath, fmt.Sprintf("v1.0.%d", i))
+				for j := 0; j < numPackages; j++ {
+					p := sample.LegacyPackage(sample.ModulePath, fmt.Sprintf("pkg%d/sub%d", j/100, j))
+					p.Imports = []string{"fmt", "strings", fmt.Sprintf("%s/pkg%d/sub%d", sample.ModulePath, j/100, j/2)}
+					sample.LegacyAddPackage(m, p)
+				}
+				modules = append(modules, m)
+			}
+			b.ResetTimer()
+			for _, m := range modules {
+				if err := testDB.InsertModule(ctx, m); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
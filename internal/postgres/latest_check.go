@@ -0,0 +1,76 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/stdlib"
+)
+
+// GetStaleModules returns at most limit modules that have had no new version
+// in the module index since the given time, and whose @latest version has
+// not been checked since then either, those never checked first. For each
+// module, the highest version known in module_version_states is returned;
+// only the ModulePath and Version fields are set. The standard library is
+// not served by the proxy, so it is never returned.
+func (db *DB) GetStaleModules(ctx context.Context, since time.Time, limit int) (_ []*internal.ModuleInfo, err error) {
+	defer derrors.Wrap(&err, "DB.GetStaleModules(ctx, %s, %d)", since, limit)
+
+	query := `
+		SELECT m.module_path, m.version
+		FROM (
+			SELECT DISTINCT ON (s.module_path)
+				s.module_path, s.version, c.checked_at
+			FROM module_version_states s
+			LEFT JOIN latest_checks c
+			ON c.module_path = s.module_path
+			WHERE s.module_path != $1
+			AND (c.checked_at IS NULL OR c.checked_at < $2)
+			AND NOT EXISTS (
+				SELECT 1
+				FROM module_version_states s2
+				WHERE s2.module_path = s.module_path
+				AND s2.index_timestamp >= $2
+			)
+			ORDER BY s.module_path, s.sort_version DESC
+		) m
+		ORDER BY m.checked_at NULLS FIRST, m.module_path
+		LIMIT $3`
+	var mis []*internal.ModuleInfo
+	collect := func(rows *sql.Rows) error {
+		var mi internal.ModuleInfo
+		if err := rows.Scan(&mi.ModulePath, &mi.Version); err != nil {
+			return err
+		}
+		mis = append(mis, &mi)
+		return nil
+	}
+	if err := db.db.RunQuery(ctx, query, collect, stdlib.ModulePath, since, limit); err != nil {
+		return nil, err
+	}
+	return mis, nil
+}
+
+// UpdateLatestCheck records that the proxy was just asked for the @latest
+// version of modulePath, and that it returned latestVersion. An empty
+// latestVersion means that the check failed.
+func (db *DB) UpdateLatestCheck(ctx context.Context, modulePath, latestVersion string) (err error) {
+	defer derrors.Wrap(&err, "DB.UpdateLatestCheck(ctx, %q, %q)", modulePath, latestVersion)
+
+	_, err = db.db.Exec(ctx, `
+		INSERT INTO latest_checks (module_path, latest_version, checked_at)
+		VALUES ($1, NULLIF($2, ''), CURRENT_TIMESTAMP)
+		ON CONFLICT (module_path)
+		DO UPDATE SET
+			latest_version=excluded.latest_version,
+			checked_at=excluded.checked_at`,
+		modulePath, latestVersion)
+	return err
+}
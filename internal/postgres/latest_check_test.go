@@ -0,0 +1,66 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/stdlib"
+)
+
+func TestGetStaleModules(t *testing.T) {
+	defer ResetTestDB(testDB, t)
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	now := time.Now()
+	old := now.Add(-60 * 24 * time.Hour)
+	since := now.Add(-30 * 24 * time.Hour)
+	if err := testDB.InsertIndexVersions(ctx, []*internal.IndexVersion{
+		{Path: "stale.com/a", Version: "v1.0.0", Timestamp: old},
+		{Path: "stale.com/a", Version: "v1.10.0", Timestamp: old},
+		{Path: "stale.com/a", Version: "v1.2.0", Timestamp: old},
+		{Path: "stale.com/b", Version: "v0.1.0", Timestamp: old},
+		{Path: "fresh.com/c", Version: "v1.0.0", Timestamp: old},
+		{Path: "fresh.com/c", Version: "v1.1.0", Timestamp: now},
+		{Path: stdlib.ModulePath, Version: "v1.15.0", Timestamp: old},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(limit int, want []*internal.ModuleInfo) {
+		t.Helper()
+		got, err := testDB.GetStaleModules(ctx, since, limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("mismatch (-want +got):\n%s", diff)
+		}
+	}
+	check(10, []*internal.ModuleInfo{
+		{ModulePath: "stale.com/a", Version: "v1.10.0"},
+		{ModulePath: "stale.com/b", Version: "v0.1.0"},
+	})
+	check(1, []*internal.ModuleInfo{
+		{ModulePath: "stale.com/a", Version: "v1.10.0"},
+	})
+
+	// Modules that were just checked are skipped, whatever the outcome.
+	if err := testDB.UpdateLatestCheck(ctx, "stale.com/a", "v1.10.0"); err != nil {
+		t.Fatal(err)
+	}
+	check(10, []*internal.ModuleInfo{
+		{ModulePath: "stale.com/b", Version: "v0.1.0"},
+	})
+	if err := testDB.UpdateLatestCheck(ctx, "stale.com/b", ""); err != nil {
+		t.Fatal(err)
+	}
+	check(10, nil)
+}
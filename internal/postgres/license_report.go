@@ -0,0 +1,70 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/licenses"
+	"golang.org/x/pkgsite/internal/stdlib"
+)
+
+// GetLicenseSample returns a random sample of at most limit module versions,
+// for checking how a change to license detection would affect them. Only the
+// ModulePath, Version and IsRedistributable fields are set. The standard
+// library is not served by the proxy, so it is never returned.
+func (db *DB) GetLicenseSample(ctx context.Context, limit int) (_ []*internal.ModuleInfo, err error) {
+	defer derrors.Wrap(&err, "DB.GetLicenseSample(ctx, %d)", limit)
+
+	query := `
+		SELECT module_path, version, redistributable
+		FROM modules
+		WHERE module_path != $1
+		ORDER BY random()
+		LIMIT $2`
+	var mis []*internal.ModuleInfo
+	collect := func(rows *sql.Rows) error {
+		var mi internal.ModuleInfo
+		if err := rows.Scan(&mi.ModulePath, &mi.Version, &mi.IsRedistributable); err != nil {
+			return err
+		}
+		mis = append(mis, &mi)
+		return nil
+	}
+	if err := db.db.RunQuery(ctx, query, collect, stdlib.ModulePath, limit); err != nil {
+		return nil, err
+	}
+	return mis, nil
+}
+
+// GetModuleLicenseMetadata returns the metadata of all the licenses stored for
+// the module version modulePath@version, including those of packages, sorted
+// by file path.
+func (db *DB) GetModuleLicenseMetadata(ctx context.Context, modulePath, version string) (_ []*licenses.Metadata, err error) {
+	defer derrors.Wrap(&err, "DB.GetModuleLicenseMetadata(ctx, %q, %q)", modulePath, version)
+
+	query := `
+		SELECT types, file_path
+		FROM licenses
+		WHERE module_path = $1 AND version = $2
+		ORDER BY file_path`
+	var mds []*licenses.Metadata
+	collect := func(rows *sql.Rows) error {
+		var md licenses.Metadata
+		if err := rows.Scan(pq.Array(&md.Types), &md.FilePath); err != nil {
+			return err
+		}
+		mds = append(mds, &md)
+		return nil
+	}
+	if err := db.db.RunQuery(ctx, query, collect, modulePath, version); err != nil {
+		return nil, err
+	}
+	return mds, nil
+}
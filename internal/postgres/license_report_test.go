@@ -0,0 +1,63 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/licenses"
+	"golang.org/x/pkgsite/internal/stdlib"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestLicenseSample(t *testing.T) {
+	defer ResetTestDB(testDB, t)
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	for _, m := range []*internal.Module{
+		sample.LegacyModule("example.com/a", "v1.0.0", ""),
+		sample.LegacyModule("example.com/b", "v1.0.0", ""),
+		sample.LegacyModule(stdlib.ModulePath, "v1.15.0", "fmt"),
+	} {
+		if err := testDB.InsertModule(ctx, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := testDB.GetLicenseSample(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*internal.ModuleInfo{
+		{ModulePath: "example.com/a", Version: "v1.0.0", IsRedistributable: true},
+		{ModulePath: "example.com/b", Version: "v1.0.0", IsRedistributable: true},
+	}
+	sortByPath := cmpopts.SortSlices(func(a, b *internal.ModuleInfo) bool { return a.ModulePath < b.ModulePath })
+	if diff := cmp.Diff(want, got, sortByPath); diff != "" {
+		t.Errorf("GetLicenseSample mismatch (-want +got):\n%s", diff)
+	}
+
+	got, err = testDB.GetLicenseSample(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 {
+		t.Errorf("GetLicenseSample with limit 1 returned %d module versions", len(got))
+	}
+
+	mds, err := testDB.GetModuleLicenseMetadata(ctx, "example.com/a", "v1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantMDs := []*licenses.Metadata{{Types: []string{"MIT"}, FilePath: "LICENSE"}}
+	if diff := cmp.Diff(wantMDs, mds); diff != "" {
+		t.Errorf("GetModuleLicenseMetadata mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -41,7 +41,7 @@ func (db *DB) getLicenses(ctx context.Context, fullPath, modulePath string, path
 		WHERE
 			p.id = $1;`
 
-	rows, err := db.db.Query(ctx, query, pathID)
+	rows, err := db.readDB().Query(ctx, query, pathID)
 	if err != nil {
 		return nil, err
 	}
@@ -92,7 +92,7 @@ func (db *DB) getModuleLicenses(ctx context.Context, modulePath, resolvedVersion
 	WHERE
 		module_path = $1 AND version = $2 AND position('/' in file_path) = 0
     `
-	rows, err := db.db.Query(ctx, query, modulePath, resolvedVersion)
+	rows, err := db.readDB().Query(ctx, query, modulePath, resolvedVersion)
 	if err != nil {
 		return nil, err
 	}
@@ -100,6 +100,38 @@ func (db *DB) getModuleLicenses(ctx context.Context, modulePath, resolvedVersion
 	return collectLicenses(rows, db.bypassLicenseCheck)
 }
 
+// GetLicensesInModule returns all licenses in the given module version,
+// including those in subdirectories that apply only to some of its packages,
+// sorted by file path.
+func (db *DB) GetLicensesInModule(ctx context.Context, modulePath, resolvedVersion string) (_ []*licenses.License, err error) {
+	defer derrors.Wrap(&err, "GetLicensesInModule(ctx, %q, %q)", modulePath, resolvedVersion)
+
+	query := `
+	SELECT
+		types, file_path, contents, coverage
+	FROM
+		licenses
+	WHERE
+		module_path = $1 AND version = $2
+	ORDER BY file_path
+	`
+	rows, err := db.readDB().Query(ctx, query, modulePath, resolvedVersion)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	lics, err := collectLicenses(rows, db.bypassLicenseCheck)
+	if err != nil {
+		return nil, err
+	}
+	if !db.bypassLicenseCheck {
+		for _, l := range lics {
+			l.RemoveNonRedistributableData()
+		}
+	}
+	return lics, nil
+}
+
 // collectLicenses converts the sql rows to a list of licenses. The columns
 // must be types, file_path and contents, in that order.
 func collectLicenses(rows *sql.Rows, bypassLicenseCheck bool) ([]*licenses.License, error) {
@@ -155,6 +155,33 @@ func TestGetModuleLicenses(t *testing.T) {
 	}
 }
 
+func TestGetLicensesInModule(t *testing.T) {
+	modulePath := "test.module"
+	testModule := sample.LegacyModule(modulePath, "v1.2.3", "", "foo")
+	testModule.Licenses = []*licenses.License{
+		{Metadata: &licenses.Metadata{Types: []string{"MIT"}, FilePath: "foo/LICENSE"}, Contents: []byte("MIT text")},
+		{Metadata: &licenses.Metadata{Types: []string{"ISC"}, FilePath: "LICENSE"}, Contents: []byte("ISC text")},
+	}
+	testModule.LegacyPackages[0].Licenses = []*licenses.Metadata{testModule.Licenses[1].Metadata}
+	testModule.LegacyPackages[1].Licenses = []*licenses.Metadata{testModule.Licenses[1].Metadata, testModule.Licenses[0].Metadata}
+
+	defer ResetTestDB(testDB, t)
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	if err := testDB.InsertModule(ctx, testModule); err != nil {
+		t.Fatal(err)
+	}
+	got, err := testDB.GetLicensesInModule(ctx, modulePath, testModule.Version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*licenses.License{testModule.Licenses[1], testModule.Licenses[0]}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
 func TestGetLicensesBypass(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
 	defer cancel()
@@ -0,0 +1,140 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/log"
+)
+
+// A NoIndexPrefix is a path prefix whose pages search engines are asked not
+// to index.
+type NoIndexPrefix struct {
+	Prefix    string    `json:"prefix"`
+	CreatedBy string    `json:"created_by"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// IsNoIndex reports whether the path matches a prefix in the
+// noindex_prefixes table. The table is read at most once a minute.
+func (db *DB) IsNoIndex(ctx context.Context, path string) (_ bool, err error) {
+	defer derrors.Wrap(&err, "DB.IsNoIndex(ctx, %q)", path)
+
+	db.ensureNoIndexPrefixes(ctx)
+	noIndexPrefixes.mu.Lock()
+	defer noIndexPrefixes.mu.Unlock()
+	if noIndexPrefixes.err != nil {
+		return false, noIndexPrefixes.err
+	}
+	for _, prefix := range noIndexPrefixes.prefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// InsertNoIndexPrefix adds prefix to the noindex_prefixes table, or updates
+// its reason if it is already there.
+func (db *DB) InsertNoIndexPrefix(ctx context.Context, prefix, user, reason string) (err error) {
+	defer derrors.Wrap(&err, "DB.InsertNoIndexPrefix(ctx, %q, %q, %q)", prefix, user, reason)
+
+	_, err = db.db.Exec(ctx, `
+		INSERT INTO noindex_prefixes (prefix, created_by, reason)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (prefix) DO UPDATE SET
+			created_by = excluded.created_by,
+			reason = excluded.reason`,
+		prefix, user, reason)
+	// Arrange to re-read the table on the next call to IsNoIndex.
+	setNoIndexPrefixesLastFetched(time.Time{})
+	return err
+}
+
+// DeleteNoIndexPrefix removes prefix from the noindex_prefixes table. It
+// returns an error wrapping derrors.NotFound if it is not there.
+func (db *DB) DeleteNoIndexPrefix(ctx context.Context, prefix string) (err error) {
+	defer derrors.Wrap(&err, "DB.DeleteNoIndexPrefix(ctx, %q)", prefix)
+
+	n, err := db.db.Exec(ctx, `DELETE FROM noindex_prefixes WHERE prefix = $1`, prefix)
+	if err != nil {
+		return err
+	}
+	setNoIndexPrefixesLastFetched(time.Time{})
+	if n == 0 {
+		return derrors.NotFound
+	}
+	return nil
+}
+
+// GetNoIndexPrefixes returns the rows of the noindex_prefixes table, ordered
+// by prefix.
+func (db *DB) GetNoIndexPrefixes(ctx context.Context) (_ []*NoIndexPrefix, err error) {
+	defer derrors.Wrap(&err, "DB.GetNoIndexPrefixes(ctx)")
+
+	var nps []*NoIndexPrefix
+	err = db.db.RunQuery(ctx, `
+		SELECT prefix, created_by, reason, created_at
+		FROM noindex_prefixes
+		ORDER BY prefix`, func(rows *sql.Rows) error {
+		var np NoIndexPrefix
+		if err := rows.Scan(&np.Prefix, &np.CreatedBy, &np.Reason, &np.CreatedAt); err != nil {
+			return err
+		}
+		nps = append(nps, &np)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return nps, nil
+}
+
+// In-memory copy of the prefixes in noindex_prefixes.
+var noIndexPrefixes struct {
+	mu          sync.Mutex
+	prefixes    []string
+	err         error
+	lastFetched time.Time
+}
+
+func setNoIndexPrefixesLastFetched(t time.Time) {
+	noIndexPrefixes.mu.Lock()
+	noIndexPrefixes.lastFetched = t
+	noIndexPrefixes.mu.Unlock()
+}
+
+const noIndexPrefixesExpiration = time.Minute
+
+// ensureNoIndexPrefixes makes sure the in-memory copy of the
+// noindex_prefixes table is up to date.
+func (db *DB) ensureNoIndexPrefixes(ctx context.Context) {
+	noIndexPrefixes.mu.Lock()
+	lastFetched := noIndexPrefixes.lastFetched
+	noIndexPrefixes.mu.Unlock()
+	if time.Since(lastFetched) < noIndexPrefixesExpiration {
+		return
+	}
+	nps, err := db.GetNoIndexPrefixes(ctx)
+	var prefixes []string
+	for _, np := range nps {
+		prefixes = append(prefixes, np.Prefix)
+	}
+	noIndexPrefixes.mu.Lock()
+	defer noIndexPrefixes.mu.Unlock()
+	noIndexPrefixes.lastFetched = time.Now()
+	noIndexPrefixes.prefixes = prefixes
+	noIndexPrefixes.err = err
+	if err != nil {
+		log.Errorf(ctx, "reading noindex_prefixes: %v", err)
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+func TestNoIndexPrefixes(t *testing.T) {
+	defer ResetTestDB(testDB, t)
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	if err := testDB.InsertNoIndexPrefix(ctx, "corp.example.com/", "admin", "internal modules"); err != nil {
+		t.Fatal(err)
+	}
+	// Inserting again updates the reason.
+	if err := testDB.InsertNoIndexPrefix(ctx, "corp.example.com/", "admin", "private"); err != nil {
+		t.Fatal(err)
+	}
+	nps, err := testDB.GetNoIndexPrefixes(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nps) != 1 || nps[0].Prefix != "corp.example.com/" || nps[0].Reason != "private" {
+		t.Fatalf("GetNoIndexPrefixes = %+v, want one prefix with reason %q", nps, "private")
+	}
+
+	check := func(path string, want bool) {
+		t.Helper()
+		got, err := testDB.IsNoIndex(ctx, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("IsNoIndex(%q) = %t, want %t", path, got, want)
+		}
+	}
+	check("corp.example.com/mod", true)
+	check("corp.example.com", false)
+	check("github.com/corp.example.com/mod", false)
+
+	if err := testDB.DeleteNoIndexPrefix(ctx, "corp.example.com/"); err != nil {
+		t.Fatal(err)
+	}
+	check("corp.example.com/mod", false)
+	if err := testDB.DeleteNoIndexPrefix(ctx, "corp.example.com/"); !errors.Is(err, derrors.NotFound) {
+		t.Errorf("deleting a missing prefix: got error %v, want NotFound", err)
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/database"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// insertPackageSymbols replaces the symbol declarations of the given paths
+// with the ones in pathToDoc.
+func insertPackageSymbols(ctx context.Context, db *database.DB, paths []string, pathToID map[string]int, pathToDoc map[string]*internal.Documentation) (err error) {
+	defer derrors.Wrap(&err, "insertPackageSymbols(ctx, db, %d paths)", len(paths))
+
+	var ids []int
+	for _, path := range paths {
+		ids = append(ids, pathToID[path])
+	}
+	if _, err := db.Exec(ctx, `DELETE FROM package_symbols WHERE path_id = ANY($1)`, pq.Array(ids)); err != nil {
+		return err
+	}
+	var values []interface{}
+	for _, path := range paths {
+		doc := pathToDoc[path]
+		if doc == nil {
+			continue
+		}
+		for _, sd := range doc.SymbolDecls {
+			values = append(values, pathToID[path], sd.Name, makeValidUnicode(sd.Decl))
+		}
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	logMemory(ctx, "before inserting into package_symbols")
+	cols := []string{"path_id", "name", "declaration"}
+	return db.BulkInsert(ctx, "package_symbols", cols, values, "")
+}
+
+// GetModuleSymbols returns the declarations of the exported functions, types
+// and methods of the packages in the module version modulePath@version, as
+// a map from package path to a map from symbol name to declaration. Packages
+// without stored declarations are omitted; they have none or were processed
+// while the api-diff experiment was inactive.
+func (db *DB) GetModuleSymbols(ctx context.Context, modulePath, version string) (_ map[string]map[string]string, err error) {
+	defer derrors.Wrap(&err, "DB.GetModuleSymbols(ctx, %q, %q)", modulePath, version)
+
+	query := `
+		SELECT p.path, s.name, s.declaration
+		FROM package_symbols s
+		INNER JOIN paths p ON p.id = s.path_id
+		INNER JOIN modules m ON m.id = p.module_id
+		WHERE
+			m.module_path = $1
+			AND m.version = $2`
+	symbols := map[string]map[string]string{}
+	collect := func(rows *sql.Rows) error {
+		var path, name, decl string
+		if err := rows.Scan(&path, &name, &decl); err != nil {
+			return err
+		}
+		if symbols[path] == nil {
+			symbols[path] = map[string]string{}
+		}
+		symbols[path][name] = decl
+		return nil
+	}
+	if err := db.db.RunQuery(ctx, query, collect, modulePath, version); err != nil {
+		return nil, err
+	}
+	return symbols, nil
+}
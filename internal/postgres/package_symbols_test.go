@@ -0,0 +1,56 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/experiment"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestModuleSymbols(t *testing.T) {
+	defer ResetTestDB(testDB, t)
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	ctx = experiment.NewContext(ctx, internal.ExperimentAPIDiff)
+
+	const modulePath = "example.com/symbols"
+	pkgPath := modulePath + "/" + sample.Suffix
+	m := sample.LegacyModule(modulePath, "v1.0.0", sample.Suffix)
+	for _, u := range m.Units {
+		if u.Documentation != nil {
+			u.Documentation.SymbolDecls = []*internal.SymbolDecl{
+				{Name: "F", Decl: "func F()"},
+				{Name: "T", Decl: "type T int"},
+			}
+		}
+	}
+	if err := testDB.InsertModule(ctx, m); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := testDB.GetModuleSymbols(ctx, modulePath, "v1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]map[string]string{
+		pkgPath: {"F": "func F()", "T": "type T int"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	got, err = testDB.GetModuleSymbols(ctx, modulePath, "v1.1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Errorf("got %v for a version that was not inserted, want none", got)
+	}
+}
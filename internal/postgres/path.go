@@ -12,12 +12,15 @@ import (
 
 	"github.com/lib/pq"
 	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/database"
 	"golang.org/x/pkgsite/internal/derrors"
 	"golang.org/x/pkgsite/internal/stdlib"
 )
 
 // orderByLatest orders paths according to the go command.
-// Versions are ordered by:
+// Versions that the proxy no longer serves come last, so that they are only
+// considered latest when there is no other version.
+// Versions are then ordered by:
 // (1) release (non-incompatible)
 // (2) prerelease (non-incompatible)
 // (3) release, incompatible
@@ -27,6 +30,7 @@ import (
 // that nested modules are preferred).
 const orderByLatest = `
 			ORDER BY
+				m.proxy_status IS NOT DISTINCT FROM 410,
 				CASE
 					WHEN m.version_type = 'release' AND NOT m.incompatible THEN 1
 					WHEN m.version_type = 'prerelease' AND NOT m.incompatible THEN 2
@@ -37,6 +41,13 @@ const orderByLatest = `
 				m.sort_version DESC,
 				m.module_path DESC`
 
+// pathOrder returns an expression that orders the paths in the text
+// expression expr as internal.PathLess does: by element, comparing bytes
+// regardless of the database's collation.
+func pathOrder(expr string) string {
+	return fmt.Sprintf(`string_to_array(%s, '/') COLLATE "C"`, expr)
+}
+
 // GetUnitMeta returns information about the "best" entity (module, path or directory) with
 // the given path. The module and version arguments provide additional constraints.
 // If the module is unknown, pass internal.UnknownModulePath; if the version is unknown, pass
@@ -69,9 +80,11 @@ func (db *DB) GetUnitMeta(ctx context.Context, path, requestedModulePath, reques
 	}
 
 	var (
-		licenseTypes []string
-		licensePaths []string
-		um           = internal.UnitMeta{Path: path}
+		licenseTypes        []string
+		licensePaths        []string
+		deprecatedMessage   sql.NullString
+		successorModulePath string
+		um                  = internal.UnitMeta{Path: path}
 	)
 	query := fmt.Sprintf(`
 		SELECT
@@ -79,6 +92,10 @@ func (db *DB) GetUnitMeta(ctx context.Context, path, requestedModulePath, reques
 			m.version,
 			m.commit_time,
 			m.source_info,
+			m.metadata,
+			m.deprecated_message,
+			m.successor_module_path,
+			m.proxy_status IS NOT DISTINCT FROM 410,
 			p.name,
 			p.redistributable,
 			p.license_types,
@@ -91,11 +108,15 @@ func (db *DB) GetUnitMeta(ctx context.Context, path, requestedModulePath, reques
 		%s
 		LIMIT 1
 	`, joinStmt, strings.Join(constraints, " "), orderByLatest)
-	err = db.db.QueryRow(ctx, query, args...).Scan(
+	err = db.readDB().QueryRow(ctx, query, args...).Scan(
 		&um.ModulePath,
 		&um.Version,
 		&um.CommitTime,
 		jsonbScanner{&um.SourceInfo},
+		jsonbScanner{&um.Metadata},
+		&deprecatedMessage,
+		database.NullIsEmpty(&successorModulePath),
+		&um.GoneFromProxy,
 		&um.Name,
 		&um.IsRedistributable,
 		pq.Array(&licenseTypes),
@@ -109,6 +130,12 @@ func (db *DB) GetUnitMeta(ctx context.Context, path, requestedModulePath, reques
 			return nil, err
 		}
 		um.Licenses = lics
+		if deprecatedMessage.Valid {
+			um.Deprecation = &internal.Deprecation{
+				Message:   deprecatedMessage.String,
+				Successor: successorModulePath,
+			}
+		}
 		return &um, nil
 	default:
 		return nil, err
@@ -159,12 +186,133 @@ func (db *DB) getPathsInModule(ctx context.Context, modulePath, resolvedVersion
 		paths = append(paths, &p)
 		return nil
 	}
-	if err := db.db.RunQuery(ctx, query, collect, modulePath, resolvedVersion); err != nil {
+	if err := db.readDB().RunQuery(ctx, query, collect, modulePath, resolvedVersion); err != nil {
 		return nil, err
 	}
 	return paths, nil
 }
 
+// GetExistingPaths returns the set of paths that exist in the module version
+// modulePath@resolvedVersion, among the given ones.
+func (db *DB) GetExistingPaths(ctx context.Context, paths []string, modulePath, resolvedVersion string) (_ map[string]bool, err error) {
+	defer derrors.Wrap(&err, "DB.GetExistingPaths(ctx, %v, %q, %q)", paths, modulePath, resolvedVersion)
+
+	query := `
+		SELECT p.path
+		FROM paths p
+		INNER JOIN modules m ON (p.module_id = m.id)
+		WHERE
+			m.module_path = $1
+			AND m.version = $2
+			AND p.path = ANY($3);`
+	existing := map[string]bool{}
+	collect := func(rows *sql.Rows) error {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			return err
+		}
+		existing[path] = true
+		return nil
+	}
+	if err := db.readDB().RunQuery(ctx, query, collect, modulePath, resolvedVersion, pq.Array(paths)); err != nil {
+		return nil, err
+	}
+	return existing, nil
+}
+
+// GetDirectoryListing returns the immediate subdirectories of fullPath in
+// the module version modulePath@resolvedVersion, ordered by path, with the
+// synopses of those that are packages. A subdirectory that is missing from
+// the paths table, as in a sparse module, is listed if it has paths below it.
+func (db *DB) GetDirectoryListing(ctx context.Context, fullPath, modulePath, resolvedVersion string) (_ []*internal.DirectoryEntry, err error) {
+	defer derrors.Wrap(&err, "DB.GetDirectoryListing(ctx, %q, %q, %q)", fullPath, modulePath, resolvedVersion)
+
+	// The paths of the standard library don't start with its module path.
+	dirPrefix := fullPath + "/"
+	if fullPath == stdlib.ModulePath {
+		dirPrefix = ""
+	}
+	query := `
+		SELECT
+			p.path,
+			p.name,
+			p.redistributable,
+			p.license_types,
+			p.license_paths,
+			COALESCE((
+				SELECT d.synopsis
+				FROM documentation d
+				WHERE d.path_id = p.id
+				ORDER BY d.goos, d.goarch
+				LIMIT 1
+			), '')
+		FROM paths p
+		INNER JOIN modules m ON (p.module_id = m.id)
+		WHERE
+			m.module_path = $1
+			AND m.version = $2
+			AND p.path LIKE $3
+			AND p.path != $4
+		ORDER BY ` + pathOrder("p.path")
+	var entries []*internal.DirectoryEntry
+	collect := func(rows *sql.Rows) error {
+		var (
+			e            internal.DirectoryEntry
+			licenseTypes []string
+			licensePaths []string
+		)
+		if err := rows.Scan(&e.Path, &e.Name, &e.IsRedistributable,
+			pq.Array(&licenseTypes), pq.Array(&licensePaths), &e.Synopsis); err != nil {
+			return fmt.Errorf("row.Scan(): %v", err)
+		}
+		// Paths are ordered so that a directory comes right before the
+		// paths below it.
+		rest := strings.TrimPrefix(e.Path, dirPrefix)
+		if i := strings.IndexByte(rest, '/'); i >= 0 {
+			dir := e.Path[:len(e.Path)-len(rest)+i]
+			if len(entries) == 0 || entries[len(entries)-1].Path != dir {
+				// There is nothing in the directory to restrict.
+				entries = append(entries, &internal.DirectoryEntry{Path: dir, IsRedistributable: true})
+			}
+			return nil
+		}
+		lics, err := zipLicenseMetadata(licenseTypes, licensePaths)
+		if err != nil {
+			return err
+		}
+		e.Licenses = lics
+		if db.bypassLicenseCheck {
+			e.IsRedistributable = true
+		} else {
+			e.RemoveNonRedistributableData()
+		}
+		entries = append(entries, &e)
+		return nil
+	}
+	if err := db.readDB().RunQuery(ctx, query, collect, modulePath, resolvedVersion, escapeLike(dirPrefix)+"%", fullPath); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// GetPackagePaths returns the set of paths of the packages in the module
+// version modulePath@resolvedVersion.
+func (db *DB) GetPackagePaths(ctx context.Context, modulePath, resolvedVersion string) (_ map[string]bool, err error) {
+	defer derrors.Wrap(&err, "DB.GetPackagePaths(ctx, %q, %q)", modulePath, resolvedVersion)
+
+	paths, err := db.getPathsInModule(ctx, modulePath, resolvedVersion)
+	if err != nil {
+		return nil, err
+	}
+	pkgs := map[string]bool{}
+	for _, p := range paths {
+		if p.name != "" {
+			pkgs[p.path] = true
+		}
+	}
+	return pkgs, nil
+}
+
 // GetStdlibPathsWithSuffix returns information about all paths in the latest version of the standard
 // library whose last component is suffix. A path that exactly match suffix is not included;
 // the path must end with "/" + suffix.
@@ -191,7 +339,7 @@ func (db *DB) GetStdlibPathsWithSuffix(ctx context.Context, suffix string) (path
 			AND path LIKE '%/' || $2
 		ORDER BY path
 	`
-	err = db.db.RunQuery(ctx, q, func(rows *sql.Rows) error {
+	err = db.readDB().RunQuery(ctx, q, func(rows *sql.Rows) error {
 		var p string
 		if err := rows.Scan(&p); err != nil {
 			return err
@@ -204,3 +352,29 @@ func (db *DB) GetStdlibPathsWithSuffix(ctx context.Context, suffix string) (path
 	}
 	return paths, nil
 }
+
+// GetPackagePathsWithName returns the paths of up to limit packages in
+// search_documents whose name is name, most imported first.
+func (db *DB) GetPackagePathsWithName(ctx context.Context, name string, limit int) (_ []string, err error) {
+	defer derrors.Wrap(&err, "DB.GetPackagePathsWithName(ctx, %q, %d)", name, limit)
+
+	query := `
+		SELECT package_path
+		FROM search_documents
+		WHERE name = $1
+		ORDER BY imported_by_count DESC, package_path
+		LIMIT $2`
+	var paths []string
+	collect := func(rows *sql.Rows) error {
+		var p string
+		if err := rows.Scan(&p); err != nil {
+			return err
+		}
+		paths = append(paths, p)
+		return nil
+	}
+	if err := db.readDB().RunQuery(ctx, query, collect, name, limit); err != nil {
+		return nil, err
+	}
+	return paths, nil
+}
@@ -259,3 +259,154 @@ func TestGetStdlibPaths(t *testing.T) {
 		t.Errorf("got %v, want %v", got, want)
 	}
 }
+
+func TestGetExistingPaths(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer ResetTestDB(testDB, t)
+
+	const modulePath = "m.com"
+	m := sample.LegacyModule(modulePath, sample.VersionString, "a/b/c")
+	// Leave out the directory m.com/a/b, as in a sparse module.
+	var units []*internal.Unit
+	for _, u := range m.Units {
+		if u.Path != "m.com/a/b" {
+			units = append(units, u)
+		}
+	}
+	m.Units = units
+	if err := testDB.InsertModule(ctx, m); err != nil {
+		t.Fatal(err)
+	}
+
+	paths := []string{"m.com/a/b/c", "m.com/a/b", "m.com/a", "m.com", "m.com/x"}
+	got, err := testDB.GetExistingPaths(ctx, paths, modulePath, sample.VersionString)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]bool{"m.com/a/b/c": true, "m.com/a": true, "m.com": true}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	got, err = testDB.GetExistingPaths(ctx, paths, modulePath, "v9.9.9")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Errorf("got %v for unknown version, want no paths", got)
+	}
+}
+
+func TestGetPackagePaths(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer ResetTestDB(testDB, t)
+
+	const modulePath = "m.com"
+	m := sample.LegacyModule(modulePath, sample.VersionString, "a", "a/b/c")
+	if err := testDB.InsertModule(ctx, m); err != nil {
+		t.Fatal(err)
+	}
+	got, err := testDB.GetPackagePaths(ctx, modulePath, sample.VersionString)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The directories m.com and m.com/a/b are not packages.
+	want := map[string]bool{"m.com/a": true, "m.com/a/b/c": true}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestGetPackagePathsWithName(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer ResetTestDB(testDB, t)
+
+	for _, m := range [][]string{{"m.com", "yaml"}, {"n.com", "yaml"}, {"o.com", "toml"}} {
+		if err := testDB.InsertModule(ctx, sample.LegacyModule(m[0], sample.VersionString, m[1])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := testDB.db.Exec(ctx, `
+		UPDATE search_documents SET imported_by_count = 10
+		WHERE package_path = 'n.com/yaml'`); err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		name  string
+		limit int
+		want  []string
+	}{
+		{"yaml", 5, []string{"n.com/yaml", "m.com/yaml"}},
+		{"yaml", 1, []string{"n.com/yaml"}},
+		{"toml", 5, []string{"o.com/toml"}},
+		{"json", 5, nil},
+	} {
+		got, err := testDB.GetPackagePathsWithName(ctx, test.name, test.limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("%s, %d: mismatch (-want +got):\n%s", test.name, test.limit, diff)
+		}
+	}
+}
+
+func TestGetDirectoryListing(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer ResetTestDB(testDB, t)
+
+	const modulePath = "m.com"
+	m := sample.LegacyModule(modulePath, sample.VersionString, "a", "a/b", "x/y/z", "x_y")
+	// Leave out the directory m.com/x/y, as in a sparse module.
+	var units []*internal.Unit
+	for _, u := range m.Units {
+		if u.Path != "m.com/x/y" {
+			units = append(units, u)
+		}
+	}
+	m.Units = units
+	if err := testDB.InsertModule(ctx, m); err != nil {
+		t.Fatal(err)
+	}
+
+	pkg := func(p string) *internal.DirectoryEntry {
+		return &internal.DirectoryEntry{
+			Path:              p,
+			Name:              path.Base(p),
+			Synopsis:          sample.Synopsis,
+			IsRedistributable: true,
+			Licenses:          sample.LicenseMetadata,
+		}
+	}
+	dir := func(p string) *internal.DirectoryEntry {
+		return &internal.DirectoryEntry{
+			Path:              p,
+			IsRedistributable: true,
+			Licenses:          sample.LicenseMetadata,
+		}
+	}
+	for _, test := range []struct {
+		path string
+		want []*internal.DirectoryEntry
+	}{
+		{"m.com", []*internal.DirectoryEntry{pkg("m.com/a"), dir("m.com/x"), pkg("m.com/x_y")}},
+		{"m.com/a", []*internal.DirectoryEntry{pkg("m.com/a/b")}},
+		// m.com/x/y is missing, so nothing is known about it.
+		{"m.com/x", []*internal.DirectoryEntry{{Path: "m.com/x/y", IsRedistributable: true}}},
+		{"m.com/a/b", nil},
+	} {
+		t.Run(test.path, func(t *testing.T) {
+			got, err := testDB.GetDirectoryListing(ctx, test.path, modulePath, sample.VersionString)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
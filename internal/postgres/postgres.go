@@ -7,28 +7,42 @@
 package postgres
 
 import (
+	"golang.org/x/pkgsite/internal/config"
 	"golang.org/x/pkgsite/internal/database"
 )
 
 type DB struct {
 	db                 *database.DB
+	replica            *replica
 	bypassLicenseCheck bool
+	searchBoosts       config.SearchBoostSettings
 }
 
 // New returns a new postgres DB.
 func New(db *database.DB) *DB {
-	return &DB{db, false}
+	return &DB{db: db, searchBoosts: noSearchBoosts}
 }
 
 // NewBypassingLicenseCheck returns a new postgres DB that bypasses license
 // checks. That means all data will be inserted and returned for
 // non-redistributable modules, packages and directories.
 func NewBypassingLicenseCheck(db *database.DB) *DB {
-	return &DB{db, true}
+	return &DB{db: db, bypassLicenseCheck: true, searchBoosts: noSearchBoosts}
 }
 
-// Close closes a DB.
+// SetSearchBoosts sets the boosts applied to search scores. By default,
+// no boosts are applied.
+func (db *DB) SetSearchBoosts(b config.SearchBoostSettings) {
+	db.searchBoosts = b
+}
+
+// Close closes a DB, and its replica if it has one.
 func (db *DB) Close() error {
+	if db.replica != nil {
+		if err := db.replica.db.Close(); err != nil {
+			return err
+		}
+	}
 	return db.db.Close()
 }
 
@@ -0,0 +1,58 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/preferences"
+)
+
+// GetUserPreferences returns the preferences stored for the given user. It
+// returns an error wrapping derrors.NotFound if the user has stored none.
+func (db *DB) GetUserPreferences(ctx context.Context, userID string) (_ *preferences.Preferences, err error) {
+	defer derrors.Wrap(&err, "DB.GetUserPreferences(ctx, %q)", userID)
+
+	var p preferences.Preferences
+	err = db.db.QueryRow(ctx, `
+		SELECT goos, goarch, theme, classic_layout, hide_internal, editor, editor_url, module_root
+		FROM user_preferences
+		WHERE user_id = $1`, userID).Scan(&p.GOOS, &p.GOARCH, &p.Theme, &p.ClassicLayout, &p.HideInternal,
+		&p.Editor, &p.EditorURL, &p.ModuleRoot)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, derrors.NotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// UpsertUserPreferences stores the preferences of the given user, replacing
+// any that were stored before.
+func (db *DB) UpsertUserPreferences(ctx context.Context, userID string, p *preferences.Preferences) (err error) {
+	defer derrors.Wrap(&err, "DB.UpsertUserPreferences(ctx, %q)", userID)
+
+	_, err = db.db.Exec(ctx, `
+		INSERT INTO user_preferences (user_id, goos, goarch, theme, classic_layout, hide_internal,
+			editor, editor_url, module_root)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (user_id) DO UPDATE SET
+			goos = excluded.goos,
+			goarch = excluded.goarch,
+			theme = excluded.theme,
+			classic_layout = excluded.classic_layout,
+			hide_internal = excluded.hide_internal,
+			editor = excluded.editor,
+			editor_url = excluded.editor_url,
+			module_root = excluded.module_root,
+			updated_at = CURRENT_TIMESTAMP`,
+		userID, p.GOOS, p.GOARCH, p.Theme, p.ClassicLayout, p.HideInternal,
+		p.Editor, p.EditorURL, p.ModuleRoot)
+	return err
+}
@@ -0,0 +1,42 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/preferences"
+)
+
+func TestUserPreferences(t *testing.T) {
+	defer ResetTestDB(testDB, t)
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	const user = "gopher@example.com"
+	if _, err := testDB.GetUserPreferences(ctx, user); !errors.Is(err, derrors.NotFound) {
+		t.Fatalf("got error %v, want NotFound", err)
+	}
+	for _, want := range []*preferences.Preferences{
+		{GOOS: "windows", GOARCH: "amd64", Theme: "dark", HideInternal: true},
+		{ClassicLayout: true},
+		{Editor: "custom", EditorURL: "subl://open?url=file://{path}&line={line}", ModuleRoot: "/mod"},
+	} {
+		if err := testDB.UpsertUserPreferences(ctx, user, want); err != nil {
+			t.Fatal(err)
+		}
+		got, err := testDB.GetUserPreferences(ctx, user)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("GetUserPreferences mismatch (-want +got):\n%s", diff)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// InsertProvenance records where and when the zip of a module version was
+// downloaded.
+func (db *DB) InsertProvenance(ctx context.Context, p *internal.Provenance) (err error) {
+	defer derrors.Wrap(&err, "DB.InsertProvenance(ctx, %q, %q)", p.ModulePath, p.Version)
+
+	headerJSON, err := json.Marshal(p.ResponseHeader)
+	if err != nil {
+		return err
+	}
+	_, err = db.db.Exec(ctx, `
+		INSERT INTO module_provenance (
+			module_path, version, proxy_url, response_header,
+			zip_sha256, zip_size, downloaded_at, download_duration_ms)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT DO NOTHING`,
+		p.ModulePath, p.Version, p.ProxyURL, headerJSON,
+		p.ZipSHA256, p.ZipSize, p.DownloadedAt, p.DownloadDuration.Milliseconds())
+	return err
+}
+
+// GetProvenance returns the provenance records of the given module version,
+// most recent first.
+func (db *DB) GetProvenance(ctx context.Context, modulePath, version string) (_ []*internal.Provenance, err error) {
+	defer derrors.Wrap(&err, "DB.GetProvenance(ctx, %q, %q)", modulePath, version)
+
+	query := `
+		SELECT
+			module_path, version, proxy_url, response_header,
+			zip_sha256, zip_size, downloaded_at, download_duration_ms
+		FROM module_provenance
+		WHERE module_path = $1 AND version = $2
+		ORDER BY downloaded_at DESC`
+	var records []*internal.Provenance
+	collect := func(rows *sql.Rows) error {
+		var (
+			p          internal.Provenance
+			durationMS int64
+		)
+		if err := rows.Scan(&p.ModulePath, &p.Version, &p.ProxyURL, jsonbScanner{&p.ResponseHeader},
+			&p.ZipSHA256, &p.ZipSize, &p.DownloadedAt, &durationMS); err != nil {
+			return err
+		}
+		p.DownloadDuration = time.Duration(durationMS) * time.Millisecond
+		records = append(records, &p)
+		return nil
+	}
+	if err := db.db.RunQuery(ctx, query, collect, modulePath, version); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
@@ -0,0 +1,59 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestProvenance(t *testing.T) {
+	defer ResetTestDB(testDB, t)
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	downloaded := time.Date(2020, 11, 1, 12, 0, 0, 0, time.UTC)
+	record := func(at time.Time, hash string) *internal.Provenance {
+		return &internal.Provenance{
+			ModulePath:       sample.ModulePath,
+			Version:          sample.VersionString,
+			ProxyURL:         "https://proxy.golang.org/" + sample.ModulePath + "/@v/" + sample.VersionString + ".zip",
+			ResponseHeader:   map[string][]string{"Content-Type": {"application/zip"}},
+			ZipSHA256:        hash,
+			ZipSize:          1234,
+			DownloadedAt:     at,
+			DownloadDuration: 250 * time.Millisecond,
+		}
+	}
+	first := record(downloaded, "aaaa")
+	second := record(downloaded.Add(time.Hour), "bbbb")
+	for _, p := range []*internal.Provenance{first, second} {
+		if err := testDB.InsertProvenance(ctx, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := testDB.GetProvenance(ctx, sample.ModulePath, sample.VersionString)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*internal.Provenance{second, first}
+	if diff := cmp.Diff(want, got, cmp.Comparer(time.Time.Equal)); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	got, err = testDB.GetProvenance(ctx, sample.ModulePath, "v9.9.9")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Errorf("got %d records for unknown version, want none", len(got))
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/stdlib"
+)
+
+// GetModuleVersionsToVerify returns at most limit module versions whose
+// availability from the proxy should be checked, those never checked first,
+// followed by the least recently checked ones. Only the ModulePath and
+// Version fields are set. The standard library is not served by the proxy,
+// so it is never returned.
+func (db *DB) GetModuleVersionsToVerify(ctx context.Context, limit int) (_ []*internal.ModuleInfo, err error) {
+	defer derrors.Wrap(&err, "DB.GetModuleVersionsToVerify(ctx, %d)", limit)
+
+	query := `
+		SELECT module_path, version
+		FROM modules
+		WHERE module_path != $1
+		ORDER BY proxy_checked_at NULLS FIRST, id
+		LIMIT $2`
+	var mis []*internal.ModuleInfo
+	collect := func(rows *sql.Rows) error {
+		var mi internal.ModuleInfo
+		if err := rows.Scan(&mi.ModulePath, &mi.Version); err != nil {
+			return err
+		}
+		mis = append(mis, &mi)
+		return nil
+	}
+	if err := db.db.RunQuery(ctx, query, collect, stdlib.ModulePath, limit); err != nil {
+		return nil, err
+	}
+	return mis, nil
+}
+
+// UpdateModuleProxyStatus records that the proxy was asked for
+// modulePath@version and responded with the given HTTP status. A status of
+// http.StatusGone marks the version as no longer served by the proxy, which
+// excludes it from latest-version resolution. A status of zero means that
+// the check was inconclusive: only the time of the check is recorded.
+// Module versions that are not in the database are ignored.
+func (db *DB) UpdateModuleProxyStatus(ctx context.Context, modulePath, version string, status int) (err error) {
+	defer derrors.Wrap(&err, "DB.UpdateModuleProxyStatus(ctx, %q, %q, %d)", modulePath, version, status)
+
+	_, err = db.db.Exec(ctx, `
+		UPDATE modules
+		SET
+			proxy_status = CASE WHEN $3 = 0 THEN proxy_status ELSE $3 END,
+			proxy_checked_at = CURRENT_TIMESTAMP
+		WHERE module_path = $1 AND version = $2`,
+		modulePath, version, status)
+	return err
+}
@@ -0,0 +1,82 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestModuleProxyStatus(t *testing.T) {
+	defer ResetTestDB(testDB, t)
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	const modulePath = "m.com/a"
+	for _, v := range []string{"v1.0.0", "v1.1.0"} {
+		if err := testDB.InsertModule(ctx, sample.LegacyModule(modulePath, v, "")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	checkLatest := func(want string) {
+		t.Helper()
+		um, err := testDB.GetUnitMeta(ctx, modulePath, internal.UnknownModulePath, internal.LatestVersion)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if um.Version != want {
+			t.Errorf("latest version: got %s, want %s", um.Version, want)
+		}
+	}
+	checkLatest("v1.1.0")
+
+	mis, err := testDB.GetModuleVersionsToVerify(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mis) != 2 {
+		t.Fatalf("got %d module versions to verify, want 2", len(mis))
+	}
+
+	if err := testDB.UpdateModuleProxyStatus(ctx, modulePath, "v1.1.0", http.StatusGone); err != nil {
+		t.Fatal(err)
+	}
+	// An inconclusive check leaves the status alone.
+	if err := testDB.UpdateModuleProxyStatus(ctx, modulePath, "v1.1.0", 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := testDB.UpdateModuleProxyStatus(ctx, modulePath, "v1.0.0", http.StatusOK); err != nil {
+		t.Fatal(err)
+	}
+	checkLatest("v1.0.0")
+
+	um, err := testDB.GetUnitMeta(ctx, modulePath, modulePath, "v1.1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !um.GoneFromProxy {
+		t.Error("v1.1.0: GoneFromProxy = false, want true")
+	}
+
+	// The least recently checked version comes first.
+	mis, err = testDB.GetModuleVersionsToVerify(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mis) != 1 || mis[0].Version != "v1.1.0" {
+		t.Errorf("got %+v, want v1.1.0 only", mis)
+	}
+
+	// Processing the version again means the proxy serves it.
+	if err := testDB.InsertModule(ctx, sample.LegacyModule(modulePath, "v1.1.0", "")); err != nil {
+		t.Fatal(err)
+	}
+	checkLatest("v1.1.0")
+}
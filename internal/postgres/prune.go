@@ -0,0 +1,158 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/database"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// RecordModuleView records that a page of modulePath@version is being served.
+// To limit writes, the time of the view is only updated once a day.
+func (db *DB) RecordModuleView(ctx context.Context, modulePath, version string) (err error) {
+	defer derrors.Wrap(&err, "DB.RecordModuleView(ctx, %q, %q)", modulePath, version)
+
+	_, err = db.db.Exec(ctx, `
+		UPDATE modules
+		SET last_viewed_at = CURRENT_TIMESTAMP
+		WHERE module_path = $1
+		AND version = $2
+		AND (last_viewed_at IS NULL OR last_viewed_at < CURRENT_TIMESTAMP - INTERVAL '1 day')`,
+		modulePath, version)
+	return err
+}
+
+// GetPrunablePseudoVersions returns at most limit pseudo-versions of modules
+// that have at least keep newer pseudo-versions, and whose commit time is
+// before the given time, ordered by module path and version. Only
+// pseudo-versions older than a tagged version of the same module are
+// returned: until a module has a release or prerelease after them, its
+// pseudo-versions are the only way to see its recent development. Versions
+// that are in search_documents, which are those that search results link to,
+// are never returned. Only the ModulePath and Version fields are set.
+func (db *DB) GetPrunablePseudoVersions(ctx context.Context, keep int, before time.Time, limit int) (_ []*internal.ModuleInfo, err error) {
+	defer derrors.Wrap(&err, "DB.GetPrunablePseudoVersions(ctx, %d, %s, %d)", keep, before, limit)
+
+	query := `
+		SELECT v.module_path, v.version
+		FROM (
+			SELECT
+				m.module_path,
+				m.version,
+				m.sort_version,
+				m.commit_time,
+				ROW_NUMBER() OVER (PARTITION BY m.module_path ORDER BY m.sort_version DESC) AS rank
+			FROM modules m
+			WHERE m.version_type = 'pseudo'
+		) v
+		WHERE v.rank > $1
+		AND v.commit_time < $2
+		AND EXISTS (
+			SELECT 1
+			FROM modules t
+			WHERE t.module_path = v.module_path
+			AND t.version_type IN ('release', 'prerelease')
+			AND t.sort_version > v.sort_version
+		)
+		AND NOT EXISTS (
+			SELECT 1
+			FROM search_documents s
+			WHERE s.module_path = v.module_path
+			AND s.version = v.version
+		)
+		ORDER BY v.module_path, v.version
+		LIMIT $3`
+	return db.collectModuleVersions(ctx, query, keep, before, limit)
+}
+
+// GetUnviewedDocumentationVersions returns at most limit module versions
+// that have not been viewed since the given time, and whose documentation
+// HTML can be dropped because it can be rendered again from the stored
+// source. Versions that were never viewed are treated as if they were viewed
+// when they were inserted. Versions that are in search_documents are never
+// returned. Only the ModulePath and Version fields are set.
+func (db *DB) GetUnviewedDocumentationVersions(ctx context.Context, since time.Time, limit int) (_ []*internal.ModuleInfo, err error) {
+	defer derrors.Wrap(&err, "DB.GetUnviewedDocumentationVersions(ctx, %s, %d)", since, limit)
+
+	query := `
+		SELECT m.module_path, m.version
+		FROM modules m
+		WHERE COALESCE(m.last_viewed_at, m.created_at) < $1
+		AND EXISTS (
+			SELECT 1
+			FROM paths p
+			INNER JOIN documentation d ON d.path_id = p.id
+			WHERE p.module_id = m.id
+			AND d.html != ''
+			AND d.source IS NOT NULL
+		)
+		AND NOT EXISTS (
+			SELECT 1
+			FROM search_documents s
+			WHERE s.module_path = m.module_path
+			AND s.version = m.version
+		)
+		ORDER BY COALESCE(m.last_viewed_at, m.created_at), m.module_path, m.version
+		LIMIT $2`
+	return db.collectModuleVersions(ctx, query, since, limit)
+}
+
+func (db *DB) collectModuleVersions(ctx context.Context, query string, args ...interface{}) ([]*internal.ModuleInfo, error) {
+	var mis []*internal.ModuleInfo
+	collect := func(rows *sql.Rows) error {
+		var mi internal.ModuleInfo
+		if err := rows.Scan(&mi.ModulePath, &mi.Version); err != nil {
+			return err
+		}
+		mis = append(mis, &mi)
+		return nil
+	}
+	if err := db.db.RunQuery(ctx, query, collect, args...); err != nil {
+		return nil, err
+	}
+	return mis, nil
+}
+
+// DropDocumentationHTML removes the rendered documentation HTML of the
+// packages in modulePath@version whose source is stored, along with the
+// other parts of split documentation. All other information about the
+// packages is kept; the frontend renders their documentation from the source
+// when it is requested. It returns the number of packages affected.
+func (db *DB) DropDocumentationHTML(ctx context.Context, modulePath, version string) (n int64, err error) {
+	defer derrors.Wrap(&err, "DB.DropDocumentationHTML(ctx, %q, %q)", modulePath, version)
+
+	err = db.db.Transact(ctx, sql.LevelDefault, func(tx *database.DB) error {
+		const pathIDs = `
+			SELECT d.path_id
+			FROM documentation d
+			INNER JOIN paths p ON p.id = d.path_id
+			INNER JOIN modules m ON m.id = p.module_id
+			WHERE m.module_path = $1
+			AND m.version = $2
+			AND d.source IS NOT NULL`
+		if _, err := tx.Exec(ctx, `DELETE FROM documentation_parts WHERE path_id IN (`+pathIDs+`)`,
+			modulePath, version); err != nil {
+			return err
+		}
+		var err error
+		n, err = tx.Exec(ctx, `
+			UPDATE documentation
+			SET html = ''
+			WHERE html != ''
+			AND source IS NOT NULL
+			AND path_id IN (`+pathIDs+`)`,
+			modulePath, version)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
@@ -0,0 +1,174 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/experiment"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestGetPrunablePseudoVersions(t *testing.T) {
+	defer ResetTestDB(testDB, t)
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	for _, mv := range []struct{ path, version string }{
+		{"m.com/a", "v0.0.0-20200101000000-000000000001"},
+		{"m.com/a", "v0.0.0-20200102000000-000000000002"},
+		{"m.com/a", "v0.0.0-20200103000000-000000000003"},
+		{"m.com/a", "v1.0.0"},
+		{"m.com/a", "v1.0.1-0.20200104000000-000000000004"},
+		{"m.com/b", "v0.0.0-20200101000000-000000000001"},
+		{"m.com/b", "v0.0.0-20200102000000-000000000002"},
+	} {
+		if err := testDB.InsertModule(ctx, sample.LegacyModule(mv.path, mv.version, "")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	future := time.Now().Add(time.Hour)
+	for _, test := range []struct {
+		name   string
+		keep   int
+		before time.Time
+		want   []*internal.ModuleInfo
+	}{
+		{
+			// Tagged versions, pseudo-versions newer than every tagged
+			// version of their module, and the pseudo-versions of
+			// m.com/b, which has no tagged versions, are kept.
+			name:   "keep 0",
+			keep:   0,
+			before: future,
+			want: []*internal.ModuleInfo{
+				{ModulePath: "m.com/a", Version: "v0.0.0-20200101000000-000000000001"},
+				{ModulePath: "m.com/a", Version: "v0.0.0-20200102000000-000000000002"},
+				{ModulePath: "m.com/a", Version: "v0.0.0-20200103000000-000000000003"},
+			},
+		},
+		{
+			name:   "keep 2",
+			keep:   2,
+			before: future,
+			want: []*internal.ModuleInfo{
+				{ModulePath: "m.com/a", Version: "v0.0.0-20200101000000-000000000001"},
+				{ModulePath: "m.com/a", Version: "v0.0.0-20200102000000-000000000002"},
+			},
+		},
+		{
+			// All the sample modules were committed just now.
+			name:   "committed before",
+			keep:   0,
+			before: time.Now().Add(-time.Hour),
+			want:   nil,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := testDB.GetPrunablePseudoVersions(ctx, test.keep, test.before, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDropDocumentationHTML(t *testing.T) {
+	defer ResetTestDB(testDB, t)
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	ctx = experiment.NewContext(ctx, internal.ExperimentInsertPackageSource)
+
+	const modulePath = "m.com/a"
+	for _, v := range []string{"v1.0.0", "v1.1.0"} {
+		m := sample.LegacyModule(modulePath, v, "p")
+		m.Units[1].Documentation.Source = []byte("source")
+		if err := testDB.InsertModule(ctx, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	check := func(since time.Time, want []*internal.ModuleInfo) {
+		t.Helper()
+		got, err := testDB.GetUnviewedDocumentationVersions(ctx, since, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("mismatch (-want +got):\n%s", diff)
+		}
+	}
+	future := time.Now().Add(time.Hour)
+	// v1.1.0 is in search_documents.
+	check(future, []*internal.ModuleInfo{{ModulePath: modulePath, Version: "v1.0.0"}})
+	check(time.Now().Add(-time.Hour), nil)
+
+	n, err := testDB.DropDocumentationHTML(ctx, modulePath, "v1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("got %d packages affected, want 1", n)
+	}
+	check(future, nil)
+
+	um, err := testDB.GetUnitMeta(ctx, modulePath+"/p", modulePath, "v1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := testDB.GetUnit(ctx, um, internal.WithDocumentation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := u.Documentation.HTML.String(); got != "" {
+		t.Errorf("got HTML %q, want none", got)
+	}
+	if got, want := u.Documentation.Synopsis, sample.Synopsis; got != want {
+		t.Errorf("got synopsis %q, want %q", got, want)
+	}
+}
+
+func TestRecordModuleView(t *testing.T) {
+	defer ResetTestDB(testDB, t)
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	ctx = experiment.NewContext(ctx, internal.ExperimentInsertPackageSource)
+
+	const modulePath = "m.com/a"
+	for _, v := range []string{"v1.0.0", "v1.1.0"} {
+		m := sample.LegacyModule(modulePath, v, "p")
+		m.Units[1].Documentation.Source = []byte("source")
+		if err := testDB.InsertModule(ctx, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := testDB.db.Exec(ctx, `UPDATE modules SET created_at = $1`, time.Now().Add(-48*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(want []*internal.ModuleInfo) {
+		t.Helper()
+		got, err := testDB.GetUnviewedDocumentationVersions(ctx, time.Now().Add(-24*time.Hour), 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("mismatch (-want +got):\n%s", diff)
+		}
+	}
+	check([]*internal.ModuleInfo{{ModulePath: modulePath, Version: "v1.0.0"}})
+	if err := testDB.RecordModuleView(ctx, modulePath, "v1.0.0"); err != nil {
+		t.Fatal(err)
+	}
+	check(nil)
+}
@@ -0,0 +1,184 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"golang.org/x/mod/module"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/version"
+)
+
+// A ModulePathRedirect forwards the pages of a module that moved to a new
+// path, and of the packages in it, to that path.
+type ModulePathRedirect struct {
+	FromModulePath string `json:"from_module_path"`
+	ToModulePath   string `json:"to_module_path"`
+}
+
+// Path returns the path of the unit in r.ToModulePath that corresponds to
+// unitPath, which must be r.FromModulePath or a path in it.
+func (r *ModulePathRedirect) Path(unitPath string) string {
+	return r.ToModulePath + strings.TrimPrefix(unitPath, r.FromModulePath)
+}
+
+// A ModulePathRedirectOverride is a redirect set by hand, which takes
+// precedence over one detected by the worker.
+type ModulePathRedirectOverride struct {
+	FromModulePath string `json:"from_module_path"`
+	// ToModulePath is empty if the redirect of FromModulePath is turned
+	// off.
+	ToModulePath string    `json:"to_module_path"`
+	CreatedBy    string    `json:"created_by"`
+	Reason       string    `json:"reason"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// RecordModulePathRedirect records that version of fromModulePath has a
+// go.mod file declaring toModulePath, so fromModulePath has moved there. If
+// a redirect from fromModulePath is already recorded for a higher version,
+// it is left alone.
+func (db *DB) RecordModulePathRedirect(ctx context.Context, fromModulePath, toModulePath, resolvedVersion string) (err error) {
+	defer derrors.Wrap(&err, "DB.RecordModulePathRedirect(ctx, %q, %q, %q)", fromModulePath, toModulePath, resolvedVersion)
+
+	if toModulePath == "" || toModulePath == fromModulePath {
+		return nil
+	}
+	_, err = db.db.Exec(ctx, `
+		INSERT INTO module_path_redirects (from_module_path, to_module_path, version, sort_version)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (from_module_path) DO UPDATE SET
+			to_module_path = excluded.to_module_path,
+			version = excluded.version,
+			sort_version = excluded.sort_version,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE module_path_redirects.sort_version <= excluded.sort_version`,
+		fromModulePath, toModulePath, resolvedVersion, version.ForSorting(resolvedVersion))
+	return err
+}
+
+// GetModulePathRedirect returns the redirect for the longest module path
+// that is fullPath or a prefix of it ending at a slash, provided that fullPath
+// isn't in a more specific module: one whose path ends in a major version
+// suffix, like example.com/m/v2, or any other module that is known to be
+// nested in the redirected one. A redirect set by hand takes precedence over
+// a detected one for the same module path, and a detected one applies only
+// if no later version of its module has been processed successfully.
+// GetModulePathRedirect returns an error wrapping derrors.NotFound if there
+// is no redirect, or if it has been turned off by hand.
+func (db *DB) GetModulePathRedirect(ctx context.Context, fullPath string) (_ *ModulePathRedirect, err error) {
+	defer derrors.Wrap(&err, "DB.GetModulePathRedirect(ctx, %q)", fullPath)
+
+	// Look up the prefixes of fullPath ending at a slash, so that the primary
+	// keys can be used. A path with a major version suffix is the root of its
+	// own module, so shorter prefixes can't hold fullPath.
+	var prefixes []string
+	for p := fullPath; ; {
+		prefixes = append(prefixes, p)
+		if _, pathMajor, ok := module.SplitPathVersion(p); ok && pathMajor != "" {
+			break
+		}
+		i := strings.LastIndexByte(p, '/')
+		if i < 0 {
+			break
+		}
+		p = p[:i]
+	}
+	var r ModulePathRedirect
+	err = db.readDB().QueryRow(ctx, `
+		SELECT from_module_path, to_module_path
+		FROM (
+			SELECT from_module_path, to_module_path, 1 AS priority
+			FROM module_path_redirect_overrides
+			WHERE from_module_path = ANY($1)
+			UNION ALL
+			SELECT r.from_module_path, r.to_module_path, 2 AS priority
+			FROM module_path_redirects r
+			WHERE r.from_module_path = ANY($1)
+			AND NOT EXISTS (
+				SELECT 1
+				FROM modules m
+				WHERE m.module_path = r.from_module_path
+				AND m.sort_version > r.sort_version
+			)
+		) x
+		WHERE NOT EXISTS (
+			SELECT 1
+			FROM modules m
+			WHERE m.module_path = ANY($1)
+			AND length(m.module_path) > length(x.from_module_path)
+		)
+		ORDER BY length(from_module_path) DESC, priority
+		LIMIT 1`, pq.Array(prefixes)).Scan(&r.FromModulePath, &r.ToModulePath)
+	if err == sql.ErrNoRows || (err == nil && r.ToModulePath == "") {
+		return nil, derrors.NotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// InsertModulePathRedirectOverride redirects fromModulePath to toModulePath,
+// or turns off the redirect of fromModulePath if toModulePath is empty,
+// regardless of any redirect detected by the worker. It replaces any
+// override for fromModulePath.
+func (db *DB) InsertModulePathRedirectOverride(ctx context.Context, fromModulePath, toModulePath, user, reason string) (err error) {
+	defer derrors.Wrap(&err, "DB.InsertModulePathRedirectOverride(ctx, %q, %q, %q, %q)", fromModulePath, toModulePath, user, reason)
+
+	_, err = db.db.Exec(ctx, `
+		INSERT INTO module_path_redirect_overrides (from_module_path, to_module_path, created_by, reason)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (from_module_path) DO UPDATE SET
+			to_module_path = excluded.to_module_path,
+			created_by = excluded.created_by,
+			reason = excluded.reason,
+			created_at = CURRENT_TIMESTAMP`,
+		fromModulePath, toModulePath, user, reason)
+	return err
+}
+
+// DeleteModulePathRedirectOverride removes the override for fromModulePath.
+// It returns an error wrapping derrors.NotFound if there is none.
+func (db *DB) DeleteModulePathRedirectOverride(ctx context.Context, fromModulePath string) (err error) {
+	defer derrors.Wrap(&err, "DB.DeleteModulePathRedirectOverride(ctx, %q)", fromModulePath)
+
+	n, err := db.db.Exec(ctx, `DELETE FROM module_path_redirect_overrides WHERE from_module_path = $1`, fromModulePath)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return derrors.NotFound
+	}
+	return nil
+}
+
+// GetModulePathRedirectOverrides returns the rows of the
+// module_path_redirect_overrides table, ordered by module path.
+func (db *DB) GetModulePathRedirectOverrides(ctx context.Context) (_ []*ModulePathRedirectOverride, err error) {
+	defer derrors.Wrap(&err, "DB.GetModulePathRedirectOverrides(ctx)")
+
+	var overrides []*ModulePathRedirectOverride
+	err = db.db.RunQuery(ctx, `
+		SELECT from_module_path, to_module_path, created_by, reason, created_at
+		FROM module_path_redirect_overrides
+		ORDER BY from_module_path`, func(rows *sql.Rows) error {
+		var o ModulePathRedirectOverride
+		if err := rows.Scan(&o.FromModulePath, &o.ToModulePath, &o.CreatedBy, &o.Reason, &o.CreatedAt); err != nil {
+			return err
+		}
+		overrides = append(overrides, &o)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return overrides, nil
+}
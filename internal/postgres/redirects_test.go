@@ -0,0 +1,94 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestModulePathRedirects(t *testing.T) {
+	defer ResetTestDB(testDB, t)
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	check := func(path, want string) {
+		t.Helper()
+		r, err := testDB.GetModulePathRedirect(ctx, path)
+		if want == "" {
+			if !errors.Is(err, derrors.NotFound) {
+				t.Errorf("GetModulePathRedirect(%q) = %+v, %v; want NotFound", path, r, err)
+			}
+			return
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := r.Path(path); got != want {
+			t.Errorf("GetModulePathRedirect(%q): redirected to %q, want %q", path, got, want)
+		}
+	}
+
+	if err := testDB.InsertModule(ctx, sample.LegacyModule("old.com/m", "v1.0.0", "p")); err != nil {
+		t.Fatal(err)
+	}
+	check("old.com/m/p", "")
+
+	if err := testDB.RecordModulePathRedirect(ctx, "old.com/m", "new.com/m", "v1.1.0"); err != nil {
+		t.Fatal(err)
+	}
+	// A lower version doesn't replace the redirect.
+	if err := testDB.RecordModulePathRedirect(ctx, "old.com/m", "other.com/m", "v1.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	check("old.com/m", "new.com/m")
+	check("old.com/m/p", "new.com/m/p")
+	check("old.com/mod", "")
+	check("old.com", "")
+	// Paths in other major versions of the module, or in modules nested in it,
+	// are not redirected.
+	check("old.com/m/v2", "")
+	check("old.com/m/v2/p", "")
+	if err := testDB.InsertModule(ctx, sample.LegacyModule("old.com/m/nested", "v1.0.0", "q")); err != nil {
+		t.Fatal(err)
+	}
+	check("old.com/m/nested/q", "")
+	check("old.com/m/nestedq", "new.com/m/nestedq")
+
+	// Overrides take precedence, and can turn off the redirect.
+	if err := testDB.InsertModulePathRedirectOverride(ctx, "old.com/m", "", "admin", "fork"); err != nil {
+		t.Fatal(err)
+	}
+	check("old.com/m/p", "")
+	if err := testDB.InsertModulePathRedirectOverride(ctx, "old.com/m", "vanity.org/m", "admin", "vanity path"); err != nil {
+		t.Fatal(err)
+	}
+	check("old.com/m/p", "vanity.org/m/p")
+	overrides, err := testDB.GetModulePathRedirectOverrides(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(overrides) != 1 || overrides[0].ToModulePath != "vanity.org/m" || overrides[0].Reason != "vanity path" {
+		t.Errorf("GetModulePathRedirectOverrides = %+v, want one override to vanity.org/m", overrides)
+	}
+	if err := testDB.DeleteModulePathRedirectOverride(ctx, "old.com/m"); err != nil {
+		t.Fatal(err)
+	}
+	if err := testDB.DeleteModulePathRedirectOverride(ctx, "old.com/m"); !errors.Is(err, derrors.NotFound) {
+		t.Errorf("deleting a missing override: got error %v, want NotFound", err)
+	}
+	check("old.com/m/p", "new.com/m/p")
+
+	// A later version of the module at its old path turns off the detected
+	// redirect.
+	if err := testDB.InsertModule(ctx, sample.LegacyModule("old.com/m", "v1.2.0", "p")); err != nil {
+		t.Fatal(err)
+	}
+	check("old.com/m/p", "")
+}
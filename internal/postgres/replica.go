@@ -0,0 +1,126 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/pkgsite/internal/database"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/log"
+)
+
+// MaxReplicaLag is the longest that a replica can fall behind the primary
+// before read-only queries are sent to the primary instead. Data written less
+// than MaxReplicaLag ago may not be on the replica yet.
+const MaxReplicaLag = 30 * time.Second
+
+// replicaCheckTimeout bounds the time of one health check of a replica.
+const replicaCheckTimeout = 5 * time.Second
+
+// A replica is a read-only copy of the database that serves read-only
+// queries while it is healthy.
+type replica struct {
+	db      *database.DB
+	healthy int32 // 1 if the last health check passed; accessed atomically
+}
+
+// SetReplica makes db send the read-only queries of search, unit pages and
+// version lists to rdb, a read replica of the database, while it is healthy.
+// The replica is not used until a call to CheckReplica finds it healthy.
+// SetReplica must be called before db is used.
+func (db *DB) SetReplica(rdb *database.DB) {
+	db.replica = &replica{db: rdb}
+}
+
+// Primary returns a DB that sends all queries to the primary, even if db has
+// a replica. Use it to read data that may have just been written, and so
+// may not have reached the replica yet.
+func (db *DB) Primary() *DB {
+	if db.replica == nil {
+		return db
+	}
+	p := *db
+	p.replica = nil
+	return &p
+}
+
+// readDB returns the database to use for read-only queries: the replica, if
+// there is one and it is healthy, and otherwise the primary.
+func (db *DB) readDB() *database.DB {
+	if r := db.replica; r != nil && atomic.LoadInt32(&r.healthy) == 1 {
+		return r.db
+	}
+	return db.db
+}
+
+// replicaLagQuery returns the number of seconds by which the replica lags
+// behind the primary. A replica that has replayed everything it has received
+// doesn't lag, however old its last transaction is. A database that is not a
+// replica doesn't lag either.
+const replicaLagQuery = `
+	SELECT COALESCE(
+		CASE WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+		ELSE EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp())
+		END, 0)`
+
+// CheckReplica checks the health of the replica set by SetReplica, and
+// routes read-only queries to the primary if the replica can't be reached
+// or lags by more than MaxReplicaLag, or back to the replica once it
+// recovers. It reports whether the replica is healthy. It does nothing and
+// returns false if there is no replica.
+func (db *DB) CheckReplica(ctx context.Context) bool {
+	r := db.replica
+	if r == nil {
+		return false
+	}
+	err := checkReplica(ctx, r.db)
+	var healthy int32
+	if err == nil {
+		healthy = 1
+	}
+	if old := atomic.SwapInt32(&r.healthy, healthy); old != healthy {
+		if err != nil {
+			log.Errorf(ctx, "read replica is unhealthy, using the primary: %v", err)
+		} else {
+			log.Infof(ctx, "read replica is healthy, using it")
+		}
+	}
+	return err == nil
+}
+
+func checkReplica(ctx context.Context, rdb *database.DB) (err error) {
+	defer derrors.Wrap(&err, "checkReplica")
+
+	ctx, cancel := context.WithTimeout(ctx, replicaCheckTimeout)
+	defer cancel()
+	var lag float64
+	if err := rdb.QueryRow(ctx, replicaLagQuery).Scan(&lag); err != nil {
+		return err
+	}
+	if d := time.Duration(lag * float64(time.Second)); d > MaxReplicaLag {
+		return fmt.Errorf("replica lags by %s", d.Round(time.Second))
+	}
+	return nil
+}
+
+// MonitorReplica calls CheckReplica right away, and then every interval
+// until ctx is done.
+func (db *DB) MonitorReplica(ctx context.Context, interval time.Duration) {
+	db.CheckReplica(ctx)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			db.CheckReplica(ctx)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"golang.org/x/pkgsite/internal/database"
+	"golang.org/x/pkgsite/internal/testing/dbtest"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestReplicaRouting(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer ResetTestDB(testDB, t)
+
+	m := sample.LegacyModule(sample.ModulePath, sample.VersionString, "foo")
+	if err := testDB.InsertModule(ctx, m); err != nil {
+		t.Fatal(err)
+	}
+
+	// Use a second connection to the test database as the replica. The test
+	// database is not a replica, so it never lags.
+	rdb, err := database.Open("postgres", dbtest.DBConnURI("discovery_postgres_test"), "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := New(testDB.db)
+	if db.CheckReplica(ctx) {
+		t.Error("CheckReplica with no replica: got true, want false")
+	}
+	if got := db.readDB(); got != testDB.db {
+		t.Error("no replica: reads don't go to the primary")
+	}
+	db.SetReplica(rdb)
+	if got := db.readDB(); got != testDB.db {
+		t.Error("unchecked replica: reads don't go to the primary")
+	}
+	if !db.CheckReplica(ctx) {
+		t.Fatal("CheckReplica: got false, want true")
+	}
+	if got := db.readDB(); got != rdb {
+		t.Error("healthy replica: reads don't go to the replica")
+	}
+	if got := db.Primary().readDB(); got != testDB.db {
+		t.Error("Primary: reads don't go to the primary")
+	}
+	if _, err := db.GetUnitMeta(ctx, sample.ModulePath+"/foo", sample.ModulePath, sample.VersionString); err != nil {
+		t.Errorf("GetUnitMeta from replica: %v", err)
+	}
+
+	// Once the replica is down, reads go to the primary.
+	if err := rdb.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if db.CheckReplica(ctx) {
+		t.Fatal("CheckReplica of closed replica: got true, want false")
+	}
+	if got := db.readDB(); got != testDB.db {
+		t.Error("unhealthy replica: reads don't go to the primary")
+	}
+	if _, err := db.GetUnitMeta(ctx, sample.ModulePath+"/foo", sample.ModulePath, sample.VersionString); err != nil {
+		t.Errorf("GetUnitMeta after replica failure: %v", err)
+	}
+}
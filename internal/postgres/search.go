@@ -21,8 +21,10 @@ import (
 	"go.opencensus.io/trace"
 	"golang.org/x/mod/semver"
 	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/config"
 	"golang.org/x/pkgsite/internal/database"
 	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/experiment"
 	"golang.org/x/pkgsite/internal/log"
 	"golang.org/x/pkgsite/internal/stdlib"
 )
@@ -100,10 +102,11 @@ var searchers = map[string]searcher{
 // complete.
 //
 // Because 0 <= ts_rank() <= 1, we know that the highest score of any unscanned
-// package is ln(e+N), where N is imported_by_count of the package we are
-// currently considering.  Therefore if the lowest scoring result of popular
-// search is greater than ln(e+N), we know that we haven't missed any results
-// and can return the search result immediately, cancelling other searches.
+// package is ln(e+N) times the largest product of boosts, where N is
+// imported_by_count of the package we are currently considering.  Therefore if
+// the lowest scoring result of popular search is greater than that, we know
+// that we haven't missed any results and can return the search result
+// immediately, cancelling other searches.
 //
 // On the other hand, if the popular search is slow, it is likely that the
 // search term is infrequent, and deep search will be fast due to our inverted
@@ -114,14 +117,18 @@ var searchers = map[string]searcher{
 // the penalty of a deep search that scans nearly every package.
 func (db *DB) Search(ctx context.Context, q string, limit, offset, maxResultCount int) (_ []*internal.SearchResult, err error) {
 	defer derrors.Wrap(&err, "DB.Search(ctx, %q, %d, %d)", q, limit, offset)
-	resp, err := db.hedgedSearch(ctx, q, limit, offset, maxResultCount, searchers, nil)
+	ss := searchers
+	if experiment.IsActive(ctx, internal.ExperimentSearchRecency) {
+		ss = deepSearchers
+	}
+	resp, err := db.hedgedSearch(ctx, q, limit, offset, maxResultCount, ss, nil)
 	if err != nil {
 		return nil, err
 	}
 	// Filter out excluded paths.
 	var results []*internal.SearchResult
 	for _, r := range resp.results {
-		ex, err := db.IsExcluded(ctx, r.PackagePath)
+		ex, err := db.IsExcludedFromSearch(ctx, r.PackagePath)
 		if err != nil {
 			return nil, err
 		}
@@ -140,8 +147,31 @@ const (
 	// Start this off gently (close to 1), but consider lowering
 	// it as time goes by and more of the ecosystem converts to modules.
 	noGoModPenalty = 0.8
+	// Package declares nothing; it has only documentation or examples.
+	noDeclsPenalty = 0.5
+	// Package is a copy of a standard library package. Copies often have
+	// names and synopses that match queries as well as the original, and
+	// would otherwise crowd out the packages that users are looking for.
+	forkPenalty = 0.25
 )
 
+// noSearchBoosts applies no boosts to search scores.
+var noSearchBoosts = config.SearchBoostSettings{ExactName: 1, Stdlib: 1, ModuleRoot: 1}
+
+// rankExpr is the Postgres ts_rank score of a search document for the query $1.
+// The first argument to ts_rank is an array of weights for the four tsvector sections,
+// in the order D, C, B, A.
+// The weights below match the defaults except for B.
+//
+// Queries, like the B, C and D sections of search documents, are converted
+// with the go_text text search configuration, which doesn't stem hyphenated
+// words and words with digits. The configuration doesn't split identifiers,
+// so queries are passed through textSearchQuery first, as documents are
+// through processWords. Changing how search documents are converted
+// requires repopulating them; see the worker's
+// /repopulate-search-documents endpoint.
+const rankExpr = `ts_rank('{0.1, 0.2, 1.0, 1.0}', tsv_search_tokens, websearch_to_tsquery('go_text', $1))`
+
 // scoreExpr is the expression that computes the search score.
 // It is the product of:
 // - The Postgres ts_rank score, based the relevance of the document to the query.
@@ -151,15 +181,36 @@ const (
 //   dramatic: being 2x as popular only has an additive effect.
 // - A penalty factor for non-redistributable modules, since a lot of
 //   details cannot be displayed.
-// The first argument to ts_rank is an array of weights for the four tsvector sections,
-// in the order D, C, B, A.
-// The weights below match the defaults except for B.
+// - A penalty factor for packages that declare nothing, since they can't be
+//   usefully imported.
+// - A penalty factor for copies of standard library packages; see
+//   forkOfExpr.
 var scoreExpr = fmt.Sprintf(`
-		ts_rank('{0.1, 0.2, 1.0, 1.0}', tsv_search_tokens, websearch_to_tsquery($1)) *
+		%s *
 		ln(exp(1)+imported_by_count) *
 		CASE WHEN redistributable THEN 1 ELSE %f END *
-		CASE WHEN COALESCE(has_go_mod, true) THEN 1 ELSE %f END
-	`, nonRedistributablePenalty, noGoModPenalty)
+		CASE WHEN COALESCE(has_go_mod, true) THEN 1 ELSE %f END *
+		CASE WHEN kind = '' THEN 1 ELSE %f END *
+		CASE WHEN fork_of = '' THEN 1 ELSE %f END
+	`, rankExpr, nonRedistributablePenalty, noGoModPenalty, noDeclsPenalty, forkPenalty)
+
+// boostExpr is the expression that computes the product of the boosts that
+// apply to a search document. The boosts are deterministic, and multiply the
+// score computed by scoreExpr:
+// - $5 for a package whose name is the query, given lowercased as $4.
+// - $6 for a standard library package.
+// - $7 for a package at the root of its module.
+var boostExpr = fmt.Sprintf(`
+		CASE WHEN lower(name) = $4 THEN $5::float8 ELSE 1 END *
+		CASE WHEN module_path = '%s' THEN $6::float8 ELSE 1 END *
+		CASE WHEN package_path = module_path THEN $7::float8 ELSE 1 END
+	`, stdlib.ModulePath)
+
+// searchNameQuery returns the form of the search query q that is compared to
+// package names for the exact name boost.
+func searchNameQuery(q string) string {
+	return strings.ToLower(strings.TrimSpace(q))
+}
 
 // hedgedSearch executes multiple search methods and returns the first
 // available result.
@@ -222,6 +273,13 @@ const hllRegisterCount = 128
 // deepSearch searches all packages for the query. It is slower, but results
 // are always valid.
 func (db *DB) deepSearch(ctx context.Context, q string, limit, offset, maxResultCount int) searchResponse {
+	return db.filteredDeepSearch(ctx, q, nil, limit, offset, maxResultCount)
+}
+
+// filteredDeepSearch is like deepSearch, but only returns results that match
+// filters, which may be nil.
+func (db *DB) filteredDeepSearch(ctx context.Context, q string, filters *SearchFilters, limit, offset, maxResultCount int) searchResponse {
+	where, filterArgs := filters.where(8)
 	query := fmt.Sprintf(`
 		SELECT *, COUNT(*) OVER() AS total
 		FROM (
@@ -231,10 +289,10 @@ func (db *DB) deepSearch(ctx context.Context, q string, limit, offset, maxResult
 				module_path,
 				commit_time,
 				imported_by_count,
-				(%s) AS score
+				(%s) * (%s) AS score
 				FROM
 					search_documents
-				WHERE tsv_search_tokens @@ websearch_to_tsquery($1)
+				WHERE tsv_search_tokens @@ websearch_to_tsquery('go_text', $1) AND %s
 				ORDER BY
 					score DESC,
 					commit_time DESC,
@@ -242,7 +300,7 @@ func (db *DB) deepSearch(ctx context.Context, q string, limit, offset, maxResult
 		) r
 		WHERE r.score > 0.1
 		LIMIT $2
-		OFFSET $3`, scoreExpr)
+		OFFSET $3`, searchScoreExpr(ctx), boostExpr, where)
 	var results []*internal.SearchResult
 	collect := func(rows *sql.Rows) error {
 		var r internal.SearchResult
@@ -253,7 +311,10 @@ func (db *DB) deepSearch(ctx context.Context, q string, limit, offset, maxResult
 		results = append(results, &r)
 		return nil
 	}
-	err := db.db.RunQuery(ctx, query, collect, q, limit, offset)
+	b := db.searchBoosts
+	args := append([]interface{}{textSearchQuery(q), limit, offset,
+		searchNameQuery(q), b.ExactName, b.Stdlib, b.ModuleRoot}, filterArgs...)
+	err := db.readDB().RunQuery(ctx, query, collect, args...)
 	if err != nil {
 		results = nil
 	}
@@ -278,7 +339,7 @@ func (db *DB) popularSearch(ctx context.Context, searchQuery string, limit, offs
 			commit_time,
 			imported_by_count,
 			score
-		FROM popular_search($1, $2, $3, $4, $5)`
+		FROM popular_search($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
 	var results []*internal.SearchResult
 	collect := func(rows *sql.Rows) error {
 		var r internal.SearchResult
@@ -289,7 +350,10 @@ func (db *DB) popularSearch(ctx context.Context, searchQuery string, limit, offs
 		results = append(results, &r)
 		return nil
 	}
-	err := db.db.RunQuery(ctx, query, collect, searchQuery, limit, offset, nonRedistributablePenalty, noGoModPenalty)
+	b := db.searchBoosts
+	err := db.readDB().RunQuery(ctx, query, collect, textSearchQuery(searchQuery), limit, offset,
+		nonRedistributablePenalty, noGoModPenalty, noDeclsPenalty, forkPenalty,
+		searchNameQuery(searchQuery), b.ExactName, b.Stdlib, b.ModuleRoot)
 	if err != nil {
 		results = nil
 	}
@@ -311,6 +375,94 @@ func (db *DB) popularSearch(ctx context.Context, searchQuery string, limit, offs
 	}
 }
 
+// ExplainSearchResults sets the Explanation of each of the results, which
+// were returned by Search for the query q, to the factors of its score.
+// It is meant for debugging the ranking of search results.
+func (db *DB) ExplainSearchResults(ctx context.Context, q string, results []*internal.SearchResult) (err error) {
+	defer derrors.Wrap(&err, "DB.ExplainSearchResults(ctx, %q)", q)
+	if len(results) == 0 {
+		return nil
+	}
+	var paths []string
+	resultMap := make(map[string]*internal.SearchResult)
+	for _, r := range results {
+		paths = append(paths, r.PackagePath)
+		resultMap[r.PackagePath] = r
+	}
+	query := fmt.Sprintf(`
+		SELECT
+			package_path,
+			module_path,
+			name,
+			%s,
+			imported_by_count,
+			redistributable,
+			COALESCE(has_go_mod, true),
+			kind,
+			fork_of,
+			commit_time
+		FROM
+			search_documents
+		WHERE
+			package_path = ANY($2)`, rankExpr)
+	recency := experiment.IsActive(ctx, internal.ExperimentSearchRecency)
+	now := time.Now()
+	collect := func(rows *sql.Rows) error {
+		var (
+			d          searchScoreData
+			commitTime time.Time
+		)
+		if err := rows.Scan(&d.packagePath, &d.modulePath, &d.name, &d.rank, &d.importedByCount,
+			&d.redistributable, &d.hasGoMod, &d.kind, &d.forkOf, &commitTime); err != nil {
+			return fmt.Errorf("rows.Scan(): %v", err)
+		}
+		r, ok := resultMap[d.packagePath]
+		if !ok {
+			return fmt.Errorf("BUG: unexpected package path: %q", d.packagePath)
+		}
+		r.Explanation = searchScoreFactors(q, d, db.searchBoosts)
+		if f := recencyFactor(commitTime, now); recency && f != 1 {
+			r.Explanation = append(r.Explanation, &internal.SearchScoreFactor{Name: "age decay", Value: f})
+		}
+		return nil
+	}
+	return db.readDB().RunQuery(ctx, query, collect, textSearchQuery(q), pq.Array(paths))
+}
+
+// searchScoreData holds the properties of a search document that its score
+// depends on.
+type searchScoreData struct {
+	packagePath, modulePath, name string
+	rank                          float64 // see rankExpr
+	importedByCount               int
+	redistributable, hasGoMod     bool
+	kind                          internal.PackageKind
+	forkOf                        string
+}
+
+// searchScoreFactors returns the factors of the score of the search document
+// d for the query q, as computed by scoreExpr and boostExpr. Penalties and
+// boosts are only included if they apply.
+func searchScoreFactors(q string, d searchScoreData, boosts config.SearchBoostSettings) []*internal.SearchScoreFactor {
+	factors := []*internal.SearchScoreFactor{
+		{Name: "relevance", Value: d.rank},
+		{Name: "popularity", Value: math.Log(math.E + float64(d.importedByCount))},
+	}
+	add := func(applies bool, name string, value float64) {
+		if applies && value != 1 {
+			factors = append(factors, &internal.SearchScoreFactor{Name: name, Value: value})
+		}
+	}
+	add(!d.redistributable, "non-redistributable penalty", nonRedistributablePenalty)
+	add(!d.hasGoMod, "no go.mod penalty", noGoModPenalty)
+	add(d.kind != internal.PackageKindDefault, "no declarations penalty", noDeclsPenalty)
+	add(d.forkOf != "", "standard library copy penalty", forkPenalty)
+	add(strings.ToLower(d.name) == searchNameQuery(q), "exact name boost", boosts.ExactName)
+	add(d.modulePath == stdlib.ModulePath, "standard library boost", boosts.Stdlib)
+	add(d.packagePath == d.modulePath, "module root boost", boosts.ModuleRoot)
+	return factors
+}
+
 // addPackageDataToSearchResults adds package information to SearchResults that is not stored
 // in the search_documents table.
 func (db *DB) addPackageDataToSearchResults(ctx context.Context, results []*internal.SearchResult) (err error) {
@@ -332,22 +484,33 @@ func (db *DB) addPackageDataToSearchResults(ctx context.Context, results []*inte
 	}
 	query := fmt.Sprintf(`
 		SELECT
-			path,
-			name,
-			synopsis,
-			license_types,
-			redistributable
+			p.path,
+			p.name,
+			p.synopsis,
+			p.synopsis_inferred,
+			p.kind,
+			p.license_types,
+			p.redistributable,
+			COALESCE(sd.fork_of, '')
 		FROM
-			packages
+			packages p
+		LEFT JOIN
+			search_documents sd
+		ON
+			sd.package_path = p.path
+			AND sd.module_path = p.module_path
+			AND sd.version = p.version
 		WHERE
-			(path, version, module_path) IN (%s)`, strings.Join(keys, ","))
+			(p.path, p.version, p.module_path) IN (%s)`, strings.Join(keys, ","))
 	collect := func(rows *sql.Rows) error {
 		var (
-			path, name, synopsis string
-			licenseTypes         []string
-			redist               bool
+			path, name, synopsis     string
+			forkOf                   string
+			licenseTypes             []string
+			redist, synopsisInferred bool
+			kind                     internal.PackageKind
 		)
-		if err := rows.Scan(&path, &name, &synopsis, pq.Array(&licenseTypes), &redist); err != nil {
+		if err := rows.Scan(&path, &name, &synopsis, &synopsisInferred, &kind, pq.Array(&licenseTypes), &redist, &forkOf); err != nil {
 			return fmt.Errorf("rows.Scan(): %v", err)
 		}
 		r, ok := resultMap[path]
@@ -355,8 +518,11 @@ func (db *DB) addPackageDataToSearchResults(ctx context.Context, results []*inte
 			return fmt.Errorf("BUG: unexpected package path: %q", path)
 		}
 		r.Name = name
+		r.Kind = kind
+		r.ForkOf = forkOf
 		if redist || db.bypassLicenseCheck {
 			r.Synopsis = synopsis
+			r.SynopsisInferred = synopsisInferred
 		}
 		for _, l := range licenseTypes {
 			if l != "" {
@@ -365,10 +531,35 @@ func (db *DB) addPackageDataToSearchResults(ctx context.Context, results []*inte
 		}
 		return nil
 	}
-	return db.db.RunQuery(ctx, query, collect)
+	return db.readDB().RunQuery(ctx, query, collect)
 }
 
-var upsertSearchStatement = fmt.Sprintf(`
+// forkOfExpr is the path of the standard library package that the package p
+// is a copy of, or the empty string. The package is a copy if its path ends
+// with the path of the standard library package, and it has the same name
+// and the same package comment, whose hash is $6. The longest such path is
+// chosen. Only standard library packages already in search_documents are
+// considered, so a copy that is inserted before the standard library is
+// recognized when its search document is next updated.
+var forkOfExpr = fmt.Sprintf(`
+		COALESCE((
+			SELECT s.package_path
+			FROM search_documents s
+			WHERE
+				s.module_path = '%[1]s'
+				AND p.module_path <> '%[1]s'
+				AND $6 <> ''
+				AND s.doc_hash = $6
+				AND s.name = p.name
+				AND right(p.path, length(s.package_path) + 1) = '/' || s.package_path
+			ORDER BY length(s.package_path) DESC
+			LIMIT 1
+		), '')`, stdlib.ModulePath)
+
+// upsertSearchStatementFormat is the format of the statements that write the
+// search document of a package. Its fourth argument is a condition on the
+// update of an existing search document.
+const upsertSearchStatementFormat = `
 	INSERT INTO search_documents (
 		package_path,
 		version,
@@ -380,7 +571,12 @@ var upsertSearchStatement = fmt.Sprintf(`
 		version_updated_at,
 		commit_time,
 		has_go_mod,
+		kind,
+		doc_hash,
+		fork_of,
+		has_tests,
 		tsv_search_tokens,
+		text_search_config,
 		hll_register,
 		hll_leading_zeros
 	)
@@ -395,12 +591,17 @@ var upsertSearchStatement = fmt.Sprintf(`
 		CURRENT_TIMESTAMP,
 		m.commit_time,
 		m.has_go_mod,
+		p.kind,
+		$6,
+		%[3]s,
+		$7,
 		(
 			SETWEIGHT(TO_TSVECTOR('path_tokens', $2), 'A') ||
-			SETWEIGHT(TO_TSVECTOR($3), 'B') ||
-			SETWEIGHT(TO_TSVECTOR($4), 'C') ||
-			SETWEIGHT(TO_TSVECTOR($5), 'D')
+			SETWEIGHT(TO_TSVECTOR('go_text', $3), 'B') ||
+			SETWEIGHT(TO_TSVECTOR('go_text', $4), 'C') ||
+			SETWEIGHT(TO_TSVECTOR('go_text', $5), 'D')
 		),
+		'go_text',
 		hll_hash(p.path) & (%[1]d - 1),
 		hll_zeros(hll_hash(p.path))
 	FROM
@@ -413,7 +614,7 @@ var upsertSearchStatement = fmt.Sprintf(`
 		AND p.version = m.version
 	WHERE
 		p.path = $1
-	%s
+	%[2]s
 	LIMIT 1
 	ON CONFLICT (package_path)
 	DO UPDATE SET
@@ -426,21 +627,77 @@ var upsertSearchStatement = fmt.Sprintf(`
 		redistributable=excluded.redistributable,
 		commit_time=excluded.commit_time,
 		has_go_mod=excluded.has_go_mod,
+		kind=excluded.kind,
+		doc_hash=excluded.doc_hash,
+		fork_of=excluded.fork_of,
+		has_tests=excluded.has_tests,
 		tsv_search_tokens=excluded.tsv_search_tokens,
+		text_search_config=excluded.text_search_config,
 		-- the hll fields are functions of path, so they don't change
 		version_updated_at=(
 			CASE WHEN excluded.version = search_documents.version
 			THEN search_documents.version_updated_at
 			ELSE CURRENT_TIMESTAMP
 			END)
-	;`, hllRegisterCount, orderByLatest)
+	%[4]s;`
+
+// upsertSearchStatement inserts or replaces the search document of a package.
+var upsertSearchStatement = fmt.Sprintf(upsertSearchStatementFormat,
+	hllRegisterCount, orderByLatest, forkOfExpr, "")
+
+// updateChangedSearchStatement is like upsertSearchStatement, but leaves an
+// existing search document alone if none of its columns would change, so that
+// reinserting a module doesn't rewrite the rows of its unchanged packages.
+var updateChangedSearchStatement = fmt.Sprintf(upsertSearchStatementFormat,
+	hllRegisterCount, orderByLatest, forkOfExpr, `
+	WHERE (
+		search_documents.version,
+		search_documents.module_path,
+		search_documents.name,
+		search_documents.synopsis,
+		search_documents.license_types,
+		search_documents.redistributable,
+		search_documents.commit_time,
+		search_documents.has_go_mod,
+		search_documents.kind,
+		search_documents.doc_hash,
+		search_documents.fork_of,
+		search_documents.has_tests,
+		search_documents.tsv_search_tokens,
+		search_documents.text_search_config
+	) IS DISTINCT FROM (
+		excluded.version,
+		excluded.module_path,
+		excluded.name,
+		excluded.synopsis,
+		excluded.license_types,
+		excluded.redistributable,
+		excluded.commit_time,
+		excluded.has_go_mod,
+		excluded.kind,
+		excluded.doc_hash,
+		excluded.fork_of,
+		excluded.has_tests,
+		excluded.tsv_search_tokens,
+		excluded.text_search_config
+	)`)
 
 // upsertSearchDocuments adds search information for mod ot the search_documents table.
 // It assumes that all non-redistributable data has been removed from mod.
+//
+// Only the search documents of packages whose search information changed are
+// written. Imported-by counts are not touched: they are propagated to
+// search_documents in batches by UpdateSearchDocumentsImportedByCount.
 func upsertSearchDocuments(ctx context.Context, db *database.DB, mod *internal.Module) (err error) {
 	defer derrors.Wrap(&err, "UpsertSearchDocuments(ctx, %q)", mod.ModulePath)
 	ctx, span := trace.StartSpan(ctx, "UpsertSearchDocuments")
 	defer span.End()
+	var numPackages, numChanged int64
+	defer func() {
+		span.AddAttributes(
+			trace.Int64Attribute("numPackages", numPackages),
+			trace.Int64Attribute("numChanged", numChanged))
+	}()
 	for _, pkg := range mod.Packages() {
 		if isInternalPackage(pkg.Path) {
 			continue
@@ -448,17 +705,23 @@ func upsertSearchDocuments(ctx context.Context, db *database.DB, mod *internal.M
 		args := upsertSearchDocumentArgs{
 			PackagePath: pkg.Path,
 			ModulePath:  mod.ModulePath,
+			Metadata:    mod.Metadata,
 		}
 		if pkg.Documentation != nil {
 			args.Synopsis = pkg.Documentation.Synopsis
+			args.DocHash = pkg.Documentation.DocHash
+			args.HasTests = pkg.Documentation.HasTests
 		}
 		if pkg.Readme != nil {
 			args.ReadmeFilePath = pkg.Readme.Filepath
 			args.ReadmeContents = pkg.Readme.Contents
 		}
-		if err := UpsertSearchDocument(ctx, db, args); err != nil {
+		n, err := upsertSearchDocument(ctx, db, updateChangedSearchStatement, args)
+		if err != nil {
 			return err
 		}
+		numPackages++
+		numChanged += n
 	}
 	return nil
 }
@@ -467,8 +730,11 @@ type upsertSearchDocumentArgs struct {
 	PackagePath    string
 	ModulePath     string
 	Synopsis       string
+	DocHash        string
+	HasTests       bool
 	ReadmeFilePath string
 	ReadmeContents string
+	Metadata       *internal.ModuleMetadata
 }
 
 // UpsertSearchDocument inserts a row for each package in the module, if that
@@ -476,8 +742,19 @@ type upsertSearchDocumentArgs struct {
 //
 // The given module should have already been validated via a call to
 // validateModule.
+//
+// The search document is rewritten even if it doesn't change, so that its
+// updated_at column records that it was processed.
 func UpsertSearchDocument(ctx context.Context, db *database.DB, args upsertSearchDocumentArgs) (err error) {
 	defer derrors.Wrap(&err, "UpsertSearchDocument(ctx, db, %q, %q)", args.PackagePath, args.ModulePath)
+	_, err = upsertSearchDocument(ctx, db, upsertSearchStatement, args)
+	return err
+}
+
+// upsertSearchDocument executes stmt, which is upsertSearchStatement or
+// updateChangedSearchStatement, for args. It returns the number of rows
+// written, which is zero if stmt skipped an unchanged search document.
+func upsertSearchDocument(ctx context.Context, db *database.DB, stmt string, args upsertSearchDocumentArgs) (int64, error) {
 
 	// Only summarize the README if the package and module have the same path.
 	if args.PackagePath != args.ModulePath {
@@ -486,23 +763,52 @@ func UpsertSearchDocument(ctx context.Context, db *database.DB, args upsertSearc
 	}
 	pathTokens := strings.Join(GeneratePathTokens(args.PackagePath), " ")
 	sectionB, sectionC, sectionD := SearchDocumentSections(args.Synopsis, args.ReadmeFilePath, args.ReadmeContents)
-	_, err = db.Exec(ctx, upsertSearchStatement, args.PackagePath, pathTokens, sectionB, sectionC, sectionD)
-	return err
+	if md := args.Metadata; md != nil {
+		// Keywords and category are chosen by the author to describe the
+		// module, so weight them like the synopsis. The description is
+		// weighted like the README.
+		terms := append([]string{sectionB}, md.Keywords...)
+		if md.Category != "" {
+			terms = append(terms, md.Category)
+		}
+		sectionB = strings.Join(terms, " ")
+		if md.Description != "" {
+			sectionC = strings.TrimSpace(md.Description + " " + sectionC)
+		}
+	}
+	return db.Exec(ctx, stmt, args.PackagePath, pathTokens, sectionB, sectionC, sectionD, args.DocHash, args.HasTests)
 }
 
 // GetPackagesForSearchDocumentUpsert fetches search information for packages in search_documents
 // whose update time is before the given time.
 func (db *DB) GetPackagesForSearchDocumentUpsert(ctx context.Context, before time.Time, limit int) (argsList []upsertSearchDocumentArgs, err error) {
 	defer derrors.Wrap(&err, "GetPackagesForSearchDocumentUpsert(ctx, %s, %d)", before, limit)
+	return db.getPackagesForSearchDocumentUpsert(ctx, limit, "sd.updated_at < $2", before)
+}
+
+// GetPackagesForSearchDocumentReconvert fetches search information for
+// packages in search_documents whose tokens were not converted with the
+// go_text text search configuration.
+func (db *DB) GetPackagesForSearchDocumentReconvert(ctx context.Context, limit int) (argsList []upsertSearchDocumentArgs, err error) {
+	defer derrors.Wrap(&err, "GetPackagesForSearchDocumentReconvert(ctx, %d)", limit)
+	return db.getPackagesForSearchDocumentUpsert(ctx, limit, "sd.text_search_config <> 'go_text'")
+}
 
+// getPackagesForSearchDocumentUpsert fetches search information for at most
+// limit packages in search_documents that satisfy where, a condition on the
+// search document sd whose arguments, args, start at $2.
+func (db *DB) getPackagesForSearchDocumentUpsert(ctx context.Context, limit int, where string, args ...interface{}) (argsList []upsertSearchDocumentArgs, err error) {
 	query := `
 		SELECT
 			sd.package_path,
 			sd.module_path,
 			sd.synopsis,
+			sd.doc_hash,
+			sd.has_tests,
 			sd.redistributable,
 			r.file_path,
-			r.contents
+			r.contents,
+			m.metadata
 		FROM modules m
 		INNER JOIN paths p
 		ON m.id = p.module_id
@@ -512,16 +818,17 @@ func (db *DB) GetPackagesForSearchDocumentUpsert(ctx context.Context, before tim
 		ON sd.package_path = p.path
 		    AND sd.module_path = m.module_path
 		    AND sd.version = m.version
-		WHERE sd.updated_at < $1
-		LIMIT $2`
+		WHERE ` + where + `
+		LIMIT $1`
 
 	collect := func(rows *sql.Rows) error {
 		var (
 			a      upsertSearchDocumentArgs
 			redist bool
 		)
-		if err := rows.Scan(&a.PackagePath, &a.ModulePath, &a.Synopsis, &redist,
-			database.NullIsEmpty(&a.ReadmeFilePath), database.NullIsEmpty(&a.ReadmeContents)); err != nil {
+		if err := rows.Scan(&a.PackagePath, &a.ModulePath, &a.Synopsis, &a.DocHash, &a.HasTests, &redist,
+			database.NullIsEmpty(&a.ReadmeFilePath), database.NullIsEmpty(&a.ReadmeContents),
+			jsonbScanner{&a.Metadata}); err != nil {
 			return err
 		}
 		if !redist && !db.bypassLicenseCheck {
@@ -532,195 +839,12 @@ func (db *DB) GetPackagesForSearchDocumentUpsert(ctx context.Context, before tim
 		argsList = append(argsList, a)
 		return nil
 	}
-	if err := db.db.RunQuery(ctx, query, collect, before, limit); err != nil {
+	if err := db.db.RunQuery(ctx, query, collect, append([]interface{}{limit}, args...)...); err != nil {
 		return nil, err
 	}
 	return argsList, nil
 }
 
-// UpdateSearchDocumentsImportedByCount updates imported_by_count and
-// imported_by_count_updated_at.
-//
-// It does so by completely recalculating the imported-by counts
-// from the imports_unique table.
-//
-// UpdateSearchDocumentsImportedByCount returns the number of rows updated.
-func (db *DB) UpdateSearchDocumentsImportedByCount(ctx context.Context) (nUpdated int64, err error) {
-	defer derrors.Wrap(&err, "UpdateSearchDocumentsImportedByCount(ctx)")
-
-	searchPackages, err := db.getSearchPackages(ctx)
-	if err != nil {
-		return 0, err
-	}
-	counts, err := db.computeImportedByCounts(ctx, searchPackages)
-	if err != nil {
-		return 0, err
-	}
-	err = db.db.Transact(ctx, sql.LevelDefault, func(tx *database.DB) error {
-		if err := insertImportedByCounts(ctx, tx, counts); err != nil {
-			return err
-		}
-		if err := compareImportedByCounts(ctx, tx); err != nil {
-			return err
-		}
-		nUpdated, err = updateImportedByCounts(ctx, tx)
-		return err
-	})
-	return nUpdated, err
-}
-
-// getSearchPackages returns the set of package paths that are in the search_documents table.
-func (db *DB) getSearchPackages(ctx context.Context) (set map[string]bool, err error) {
-	defer derrors.Wrap(&err, "DB.getSearchPackages(ctx)")
-
-	set = map[string]bool{}
-	err = db.db.RunQuery(ctx, `SELECT package_path FROM search_documents`, func(rows *sql.Rows) error {
-		var p string
-		if err := rows.Scan(&p); err != nil {
-			return err
-		}
-		set[p] = true
-		return nil
-	})
-	if err != nil {
-		return nil, err
-	}
-	return set, nil
-}
-
-func (db *DB) computeImportedByCounts(ctx context.Context, searchDocsPackages map[string]bool) (counts map[string]int, err error) {
-	defer derrors.Wrap(&err, "db.computeImportedByCounts(ctx)")
-
-	counts = map[string]int{}
-	// Get all (from_path, to_path) pairs, deduped.
-	// Also get the from_path's module path.
-	rows, err := db.db.Query(ctx, `
-		SELECT
-			from_path, from_module_path, to_path
-		FROM
-			imports_unique
-		GROUP BY
-			from_path, from_module_path, to_path;
-	`)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	for rows.Next() {
-		var from, fromMod, to string
-		if err := rows.Scan(&from, &fromMod, &to); err != nil {
-			return nil, err
-		}
-		// Don't count an importer if it's not in search_documents.
-		if !searchDocsPackages[from] {
-			continue
-		}
-		// Don't count an importer if it's in the same module as what it's importing.
-		// Approximate that check by seeing if from_module_path is a prefix of to_path.
-		// (In some cases, e.g. when to_path is in a nested module, that is not correct.)
-		if (fromMod == stdlib.ModulePath && stdlib.Contains(to)) || strings.HasPrefix(to+"/", fromMod+"/") {
-			continue
-		}
-		counts[to]++
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return counts, nil
-}
-
-func insertImportedByCounts(ctx context.Context, db *database.DB, counts map[string]int) (err error) {
-	defer derrors.Wrap(&err, "insertImportedByCounts(ctx, db, counts)")
-
-	const createTableQuery = `
-		CREATE TEMPORARY TABLE computed_imported_by_counts (
-			package_path      TEXT NOT NULL,
-			imported_by_count INTEGER DEFAULT 0 NOT NULL
-		) ON COMMIT DROP;
-    `
-	if _, err := db.Exec(ctx, createTableQuery); err != nil {
-		return fmt.Errorf("CREATE TABLE: %v", err)
-	}
-	values := make([]interface{}, 0, 2*len(counts))
-	for p, c := range counts {
-		values = append(values, p, c)
-	}
-	columns := []string{"package_path", "imported_by_count"}
-	return db.BulkInsert(ctx, "computed_imported_by_counts", columns, values, "")
-}
-
-func compareImportedByCounts(ctx context.Context, db *database.DB) (err error) {
-	defer derrors.Wrap(&err, "compareImportedByCounts(ctx, tx)")
-
-	query := `
-		SELECT
-			s.package_path,
-			s.imported_by_count,
-			c.imported_by_count
-		FROM
-			search_documents s
-		INNER JOIN
-			computed_imported_by_counts c
-		ON
-			s.package_path = c.package_path
-	`
-	// Compute some info about the changes to import-by counts.
-	const changeThreshold = 0.05 // count how many counts change by at least this fraction
-	var total, zero, change, diff int
-	err = db.RunQuery(ctx, query, func(rows *sql.Rows) error {
-		var path string
-		var old, new int
-		if err := rows.Scan(&path, &old, &new); err != nil {
-			return err
-		}
-		total++
-		if old != new {
-			change++
-		}
-		if old == 0 {
-			zero++
-			return nil
-		}
-		fracDiff := math.Abs(float64(new-old)) / float64(old)
-		if fracDiff > changeThreshold {
-			diff++
-		}
-		return nil
-	})
-	if err != nil {
-		return err
-	}
-	log.Infof(ctx, "%6d total rows in search_documents match computed_imported_by_counts", total)
-	log.Infof(ctx, "%6d will change", change)
-	log.Infof(ctx, "%6d currently have a zero imported-by count", zero)
-	log.Infof(ctx, "%6d of the non-zero rows will change by more than %d%%", diff, int(changeThreshold*100))
-	return nil
-}
-
-// updateImportedByCounts updates the imported_by_count column in search_documents
-// for every package in computed_imported_by_counts.
-//
-// A row is updated even if the value doesn't change, so that the imported_by_count_updated_at
-// column is set.
-//
-// Note that if a package is never imported, its imported_by_count column will
-// be the default (0) and its imported_by_count_updated_at column will never be set.
-func updateImportedByCounts(ctx context.Context, db *database.DB) (int64, error) {
-	const updateStmt = `
-		UPDATE search_documents s
-		SET
-			imported_by_count = c.imported_by_count,
-			imported_by_count_updated_at = CURRENT_TIMESTAMP
-		FROM computed_imported_by_counts c
-		WHERE s.package_path = c.package_path;`
-
-	n, err := db.Exec(ctx, updateStmt)
-	if err != nil {
-		return 0, fmt.Errorf("error updating imported_by_count and imported_by_count_updated_at for search documents: %v", err)
-	}
-	return n, nil
-}
-
 var (
 	commonHostnames = map[string]bool{
 		"bitbucket.org":         true,
@@ -748,8 +872,9 @@ var (
 // GeneratePathTokens returns the subPaths and path token parts that will be
 // indexed for search, which includes (1) the packagePath (2) all sub-paths of
 // the packagePath (3) all parts for a path element that is delimited by a dash
-// and (4) all parts of a path element that is delimited by a dot, except for
-// the last element.
+// (4) all parts of a path element that is delimited by a dot, except for
+// the last element and (5) all parts of a path element that is written in
+// CamelCase or snake_case.
 func GeneratePathTokens(packagePath string) []string {
 	packagePath = strings.Trim(packagePath, "/")
 
@@ -768,6 +893,11 @@ func GeneratePathTokens(packagePath string) []string {
 			subPathSet[p] = true
 		}
 
+		if i > 0 {
+			for _, p := range identifierTokens(part) {
+				subPathSet[p] = true
+			}
+		}
 		if i == 0 && commonHostnames[part] {
 			continue
 		}
@@ -0,0 +1,70 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// GetSearchSnapshot calls f on an entry for each search document, in order of
+// package path, so that the search corpus can be exported for offline
+// relevance experiments. If limit is positive, at most limit entries are
+// produced. If f returns an error, GetSearchSnapshot stops and returns it.
+func (db *DB) GetSearchSnapshot(ctx context.Context, limit int, f func(*internal.SearchSnapshotEntry) error) (err error) {
+	defer derrors.Wrap(&err, "DB.GetSearchSnapshot(ctx, %d)", limit)
+
+	query := `
+		SELECT
+			package_path,
+			module_path,
+			version,
+			name,
+			CASE WHEN redistributable THEN COALESCE(synopsis, '') ELSE '' END,
+			kind,
+			fork_of,
+			imported_by_count,
+			redistributable,
+			COALESCE(has_go_mod, true)
+		FROM search_documents
+		ORDER BY package_path`
+	if limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", limit)
+	}
+	collect := func(rows *sql.Rows) error {
+		var e internal.SearchSnapshotEntry
+		if err := rows.Scan(&e.PackagePath, &e.ModulePath, &e.Version, &e.Name, &e.Synopsis,
+			&e.Kind, &e.ForkOf, &e.ImportedByCount, &e.Redistributable, &e.HasGoMod); err != nil {
+			return err
+		}
+		e.StaticScore = staticSearchScore(searchScoreData{
+			packagePath:     e.PackagePath,
+			modulePath:      e.ModulePath,
+			name:            e.Name,
+			importedByCount: e.ImportedByCount,
+			redistributable: e.Redistributable,
+			hasGoMod:        e.HasGoMod,
+			kind:            e.Kind,
+			forkOf:          e.ForkOf,
+		})
+		return f(&e)
+	}
+	return db.db.RunQuery(ctx, query, collect)
+}
+
+// staticSearchScore returns the product of the factors of the search score of
+// d that don't depend on the query or on the configured boosts.
+func staticSearchScore(d searchScoreData) float64 {
+	d.rank = 1
+	score := 1.0
+	for _, f := range searchScoreFactors("", d, noSearchBoosts) {
+		score *= f.Value
+	}
+	return score
+}
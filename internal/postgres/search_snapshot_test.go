@@ -0,0 +1,84 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestGetSearchSnapshot(t *testing.T) {
+	defer ResetTestDB(testDB, t)
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	redist := sample.LegacyModule("a.com/m", "v1.2.3", "")
+	nonRedist := sample.LegacyModule("b.com/m", "v1.2.3", "")
+	sample.AddLicense(nonRedist, sample.NonRedistributableLicense)
+	nonRedist.IsRedistributable = false
+	nonRedist.LegacyPackages[0].IsRedistributable = false
+	nonRedist.Units[0].IsRedistributable = false
+	for _, m := range []*internal.Module{nonRedist, redist} {
+		if err := testDB.InsertModule(ctx, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := []*internal.SearchSnapshotEntry{
+		{
+			PackagePath:     "a.com/m",
+			ModulePath:      "a.com/m",
+			Version:         "v1.2.3",
+			Name:            "m",
+			Synopsis:        sample.Synopsis,
+			Redistributable: true,
+			HasGoMod:        true,
+			StaticScore:     1,
+		},
+		{
+			PackagePath: "b.com/m",
+			ModulePath:  "b.com/m",
+			Version:     "v1.2.3",
+			Name:        "m",
+			HasGoMod:    true,
+			StaticScore: nonRedistributablePenalty,
+		},
+	}
+	for _, limit := range []int{0, 1} {
+		var got []*internal.SearchSnapshotEntry
+		if err := testDB.GetSearchSnapshot(ctx, limit, func(e *internal.SearchSnapshotEntry) error {
+			got = append(got, e)
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		w := want
+		if limit > 0 {
+			w = want[:limit]
+		}
+		if diff := cmp.Diff(w, got); diff != "" {
+			t.Errorf("limit %d: mismatch (-want +got):\n%s", limit, diff)
+		}
+	}
+}
+
+func TestStaticSearchScore(t *testing.T) {
+	d := searchScoreData{
+		packagePath:     "a.com/m",
+		modulePath:      "a.com/m",
+		name:            "m",
+		rank:            0.3,
+		redistributable: true,
+		hasGoMod:        false,
+		kind:            internal.PackageKindDefault,
+	}
+	if got, want := staticSearchScore(d), noGoModPenalty; got != want {
+		t.Errorf("staticSearchScore = %g, want %g", got, want)
+	}
+}
@@ -22,7 +22,9 @@ import (
 	"github.com/lib/pq"
 	"go.opencensus.io/stats/view"
 	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/config"
 	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/stdlib"
 	"golang.org/x/pkgsite/internal/testing/sample"
 )
 
@@ -127,6 +129,22 @@ func TestPathTokens(t *testing.T) {
 				"valuecollector",
 			},
 		},
+		{
+			path: "example.com/HTTPClient/retry_policy",
+			want: []string{
+				"HTTPClient",
+				"HTTPClient/retry_policy",
+				"client",
+				"example",
+				"example.com",
+				"example.com/HTTPClient",
+				"example.com/HTTPClient/retry_policy",
+				"http",
+				"policy",
+				"retry",
+				"retry_policy",
+			},
+		},
 		{
 			path: "code.cloud.gitlab.google.k8s.io",
 			want: []string{
@@ -611,6 +629,261 @@ func TestSearchPenalties(t *testing.T) {
 	}
 }
 
+func TestSearchBoosts(t *testing.T) {
+	// Verify that search boosts are applied on top of the score.
+	defer ResetTestDB(testDB, t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	boosts := config.SearchBoostSettings{ExactName: 2, Stdlib: 1.5, ModuleRoot: 1.2}
+	modules := map[string]struct {
+		suffix     string
+		multiplier float64 // applied to unboosted score
+	}{
+		// The package root.com/foo is named foo, and is at its module root.
+		"root.com/foo": {"", boosts.ExactName * boosts.ModuleRoot},
+		// The package sub.com/foo/p is named p.
+		"sub.com/foo": {"p", 1},
+	}
+	for path, m := range modules {
+		if err := testDB.InsertModule(ctx, sample.LegacyModule(path, sample.VersionString, m.suffix)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	boostedDB := &DB{db: testDB.db, searchBoosts: boosts}
+	for method, searcher := range searchers {
+		t.Run(method, func(t *testing.T) {
+			scores := func(db *DB) map[string]float64 {
+				t.Helper()
+				res := searcher(db, ctx, "foo", 10, 0, 100)
+				if res.err != nil {
+					t.Fatal(res.err)
+				}
+				m := map[string]float64{}
+				for _, r := range res.results {
+					m[r.ModulePath] = r.Score
+				}
+				return m
+			}
+			unboosted := scores(testDB)
+			boosted := scores(boostedDB)
+			if got, want := len(boosted), len(modules); got != want {
+				t.Fatalf("got %d search results, want %d", got, want)
+			}
+			for path, m := range modules {
+				got := boosted[path]
+				want := unboosted[path] * m.multiplier
+				if math.Abs(got-want) > 1e-6 {
+					t.Errorf("%s: got %f, want %f", path, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSearchForks(t *testing.T) {
+	// Verify that copies of standard library packages are recognized when
+	// their search documents are upserted, and annotated in search results.
+	defer ResetTestDB(testDB, t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	insert := func(modulePath, suffix, docHash string) {
+		t.Helper()
+		m := sample.LegacyModule(modulePath, sample.VersionString, suffix)
+		for _, u := range m.Units {
+			if u.Documentation != nil {
+				u.Documentation.DocHash = docHash
+			}
+		}
+		if err := testDB.InsertModule(ctx, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	forkOf := func(packagePath string) string {
+		t.Helper()
+		var f string
+		if err := testDB.db.QueryRow(ctx, `SELECT fork_of FROM search_documents WHERE package_path = $1`,
+			packagePath).Scan(&f); err != nil {
+			t.Fatal(err)
+		}
+		return f
+	}
+
+	// A copy inserted before the standard library is recognized only when
+	// its search document is next upserted.
+	insert("early.com/m", "encoding/json", "json-hash")
+	insert(stdlib.ModulePath, "encoding/json", "json-hash")
+	insert("fork.com/m", "encoding/json", "json-hash")
+	insert("other.com/json", "", "other-hash")
+	insert("suffix.com/m", "json", "json-hash")
+
+	if got := forkOf("early.com/m/encoding/json"); got != "" {
+		t.Errorf("early copy: got fork_of %q, want empty", got)
+	}
+	argsList, err := testDB.GetPackagesForSearchDocumentUpsert(ctx, time.Now(), 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range argsList {
+		if err := UpsertSearchDocument(ctx, testDB.db, args); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for path, want := range map[string]string{
+		"encoding/json":             "",
+		"early.com/m/encoding/json": "encoding/json",
+		"fork.com/m/encoding/json":  "encoding/json",
+		"other.com/json":            "",
+		"suffix.com/m/json":         "",
+	} {
+		if got := forkOf(path); got != want {
+			t.Errorf("%s: got fork_of %q, want %q", path, got, want)
+		}
+	}
+
+	results, err := testDB.Search(ctx, "json", 10, 0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range results {
+		if got, want := r.ForkOf, forkOf(r.PackagePath); got != want {
+			t.Errorf("%s: got ForkOf %q, want %q", r.PackagePath, got, want)
+		}
+	}
+}
+
+func TestSearchTextConfig(t *testing.T) {
+	defer ResetTestDB(testDB, t)
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	// Hyphenated words and words with digits are not stemmed, but other
+	// words, including the parts of hyphenated words, are.
+	var got string
+	if err := testDB.db.QueryRow(ctx, `
+		SELECT array_to_string(tsvector_to_array(to_tsvector('go_text', 'json-logging base64 loggers')), ' ')`).Scan(&got); err != nil {
+		t.Fatal(err)
+	}
+	if want := "base64 json json-logging log logger"; got != want {
+		t.Errorf("got lexemes %q, want %q", got, want)
+	}
+
+	m := sample.LegacyModule("kit.com/m", sample.VersionString, "log")
+	for _, u := range m.Units {
+		if u.Documentation != nil {
+			u.Documentation.Synopsis = "Package log is a go-kit compatible HTTPClient logger."
+		}
+	}
+	if err := testDB.InsertModule(ctx, m); err != nil {
+		t.Fatal(err)
+	}
+	for _, q := range []string{"go-kit", "kit", "http client", "httpclient", "logging"} {
+		results, err := testDB.Search(ctx, q, 10, 0, 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) != 1 || results[0].PackagePath != "kit.com/m/log" {
+			t.Errorf("Search(%q): got %d results, want kit.com/m/log", q, len(results))
+		}
+	}
+}
+
+func TestSearchScoreFactors(t *testing.T) {
+	boosts := config.SearchBoostSettings{ExactName: 2, Stdlib: 1.5, ModuleRoot: 1}
+	for _, test := range []struct {
+		name string
+		q    string
+		d    searchScoreData
+		want []*internal.SearchScoreFactor
+	}{
+		{
+			name: "no penalties or boosts",
+			q:    "foo",
+			d: searchScoreData{
+				packagePath:     "a.com/m/bar",
+				modulePath:      "a.com/m",
+				name:            "bar",
+				rank:            0.5,
+				redistributable: true,
+				hasGoMod:        true,
+			},
+			want: []*internal.SearchScoreFactor{
+				{Name: "relevance", Value: 0.5},
+				{Name: "popularity", Value: 1},
+			},
+		},
+		{
+			name: "penalties",
+			q:    "foo",
+			d: searchScoreData{
+				packagePath:     "a.com/m/bar",
+				modulePath:      "a.com/m",
+				name:            "bar",
+				rank:            0.5,
+				importedByCount: 10,
+				kind:            internal.PackageKindDocOnly,
+			},
+			want: []*internal.SearchScoreFactor{
+				{Name: "relevance", Value: 0.5},
+				{Name: "popularity", Value: math.Log(math.E + 10)},
+				{Name: "non-redistributable penalty", Value: nonRedistributablePenalty},
+				{Name: "no go.mod penalty", Value: noGoModPenalty},
+				{Name: "no declarations penalty", Value: noDeclsPenalty},
+			},
+		},
+		{
+			name: "copy of a standard library package",
+			q:    "json",
+			d: searchScoreData{
+				packagePath:     "a.com/m/encoding/json",
+				modulePath:      "a.com/m",
+				name:            "json",
+				rank:            0.5,
+				redistributable: true,
+				hasGoMod:        true,
+				forkOf:          "encoding/json",
+			},
+			want: []*internal.SearchScoreFactor{
+				{Name: "relevance", Value: 0.5},
+				{Name: "popularity", Value: 1},
+				{Name: "standard library copy penalty", Value: forkPenalty},
+				{Name: "exact name boost", Value: 2},
+			},
+		},
+		{
+			// The module root boost is 1, so it is omitted.
+			name: "boosts",
+			q:    " Strings ",
+			d: searchScoreData{
+				packagePath:     "strings",
+				modulePath:      "std",
+				name:            "strings",
+				rank:            0.5,
+				redistributable: true,
+				hasGoMod:        true,
+			},
+			want: []*internal.SearchScoreFactor{
+				{Name: "relevance", Value: 0.5},
+				{Name: "popularity", Value: 1},
+				{Name: "exact name boost", Value: 2},
+				{Name: "standard library boost", Value: 1.5},
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got := searchScoreFactors(test.q, test.d, boosts)
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestExcludedFromSearch(t *testing.T) {
 	// Verify that excluded paths are omitted from search results.
 	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
@@ -778,6 +1051,58 @@ func TestUpsertSearchDocument(t *testing.T) {
 	}
 }
 
+func TestUpsertSearchDocumentsSkipsUnchanged(t *testing.T) {
+	defer ResetTestDB(testDB, t)
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	updatedAt := func(path string) time.Time {
+		t.Helper()
+		var u time.Time
+		if err := testDB.db.QueryRow(ctx, `SELECT updated_at FROM search_documents WHERE package_path = $1`,
+			path).Scan(&u); err != nil {
+			t.Fatal(err)
+		}
+		return u
+	}
+
+	m := sample.LegacyModule(sample.ModulePath, sample.VersionString, "A", "B")
+	if err := testDB.InsertModule(ctx, m); err != nil {
+		t.Fatal(err)
+	}
+	pathA, pathB := sample.ModulePath+"/A", sample.ModulePath+"/B"
+	beforeA, beforeB := updatedAt(pathA), updatedAt(pathB)
+
+	// Reinsert the module with a new synopsis for B only.
+	m = sample.LegacyModule(sample.ModulePath, sample.VersionString, "A", "B")
+	for _, p := range m.LegacyPackages {
+		if p.Path == pathB {
+			p.Synopsis = "changed"
+		}
+	}
+	for _, u := range m.Units {
+		if u.Path == pathB {
+			u.Documentation.Synopsis = "changed"
+		}
+	}
+	if err := testDB.InsertModule(ctx, m); err != nil {
+		t.Fatal(err)
+	}
+	if got := updatedAt(pathA); !got.Equal(beforeA) {
+		t.Errorf("%s: updated_at changed from %v to %v, want unchanged", pathA, beforeA, got)
+	}
+	if got := updatedAt(pathB); got.Equal(beforeB) {
+		t.Errorf("%s: updated_at unchanged, want changed", pathB)
+	}
+	sd, err := getSearchDocument(ctx, testDB, pathB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sd.synopsis != "changed" {
+		t.Errorf("%s: got synopsis %q, want %q", pathB, sd.synopsis, "changed")
+	}
+}
+
 func TestUpsertSearchDocumentVersionHasGoMod(t *testing.T) {
 	defer ResetTestDB(testDB, t)
 
@@ -1017,6 +1342,53 @@ func TestGetPackagesForSearchDocumentUpsert(t *testing.T) {
 	}
 }
 
+func TestGetPackagesForSearchDocumentReconvert(t *testing.T) {
+	defer ResetTestDB(testDB, t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	m := sample.LegacyModule("mod.com", "v1.2.3", "A", "B")
+	if err := testDB.InsertModule(ctx, m); err != nil {
+		t.Fatal(err)
+	}
+
+	// New search documents are converted with go_text.
+	got, err := testDB.GetPackagesForSearchDocumentReconvert(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Fatalf("got %d search documents to reconvert, want none", len(got))
+	}
+
+	// Mark mod.com/A as converted before go_text existed.
+	if _, err := testDB.db.Exec(ctx, `
+		UPDATE search_documents SET text_search_config = 'english'
+		WHERE package_path = 'mod.com/A'`); err != nil {
+		t.Fatal(err)
+	}
+	got, err = testDB.GetPackagesForSearchDocumentReconvert(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].PackagePath != "mod.com/A" {
+		t.Fatalf("got %v, want only mod.com/A", got)
+	}
+
+	// Reconverting it leaves nothing to reconvert.
+	if err := UpsertSearchDocument(ctx, testDB.db, got[0]); err != nil {
+		t.Fatal(err)
+	}
+	got, err = testDB.GetPackagesForSearchDocumentReconvert(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Fatalf("got %v after reconverting, want none", got)
+	}
+}
+
 func TestHllHash(t *testing.T) {
 	tests := []string{
 		"",
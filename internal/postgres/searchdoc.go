@@ -5,6 +5,7 @@
 package postgres
 
 import (
+	"net/url"
 	"path/filepath"
 	"strings"
 	"unicode"
@@ -108,15 +109,116 @@ func sentenceEndIndex(s string) int {
 }
 
 // processWords splits s into words at whitespace, then processes each word.
+// Words that are Go identifiers made of several parts, like HTTPClientRetrier
+// or http_client, are followed by their parts.
 func processWords(s string) []string {
-	fields := strings.Fields(strings.ToLower(s))
 	var words []string
-	for _, f := range fields {
-		words = append(words, processWord(f)...)
+	for _, f := range strings.Fields(s) {
+		words = append(words, processWord(strings.ToLower(f))...)
+		if !isURL(f) {
+			words = append(words, identifierTokens(strings.TrimFunc(f, unicode.IsPunct))...)
+		}
 	}
 	return words
 }
 
+// textSearchQuery returns the search query q in the form that is passed to
+// websearch_to_tsquery. The go_text configuration can't split identifiers,
+// so words of q that are identifiers made of several parts are replaced by
+// their parts, as processWords adds them to search documents. That way, the
+// query "ReadAll" matches documents mentioning ReadAll or ioutil.ReadAll.
+// Other words, including quoted and negated ones, are left alone.
+func textSearchQuery(q string) string {
+	fields := strings.Fields(q)
+	for i, f := range fields {
+		if !isIdentifier(f) {
+			continue
+		}
+		if parts := identifierParts(f); len(parts) > 1 {
+			fields[i] = strings.Join(parts, " ")
+		}
+	}
+	return strings.Join(fields, " ")
+}
+
+// isIdentifier reports whether s is made only of letters, digits and
+// underscores.
+func isIdentifier(s string) bool {
+	for _, r := range s {
+		if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// identifierTokens returns the lower-cased parts of an identifier that is
+// written in CamelCase or snake_case, along with stems for the parts that
+// the Postgres stemmer misses. It returns nil if id has only one part.
+//
+// For example, the tokens of "HTTPClientRetrier" are "http", "client",
+// "retrier" and "retry", so that the query "http client retry" matches it.
+func identifierTokens(id string) []string {
+	parts := identifierParts(id)
+	if len(parts) < 2 {
+		return nil
+	}
+	var tokens []string
+	for _, p := range parts {
+		p = strings.ToLower(p)
+		tokens = append(tokens, p)
+		if verb, ok := agentNounVerbs[p]; ok {
+			tokens = append(tokens, verb)
+		}
+	}
+	return tokens
+}
+
+// identifierParts splits id into words at underscores and changes of case.
+// A run of upper-case letters is treated as an initialism, so "HTTPClient"
+// has parts "HTTP" and "Client", while "URLs" is one part.
+// Digits stay with the preceding part.
+func identifierParts(id string) []string {
+	var parts []string
+	for _, word := range strings.FieldsFunc(id, func(r rune) bool { return r == '_' }) {
+		rs := []rune(word)
+		start := 0
+		for i := 1; i < len(rs); i++ {
+			lowerToUpper := unicode.IsUpper(rs[i]) && !unicode.IsUpper(rs[i-1])
+			// The last letter of an initialism starts the next word, as in
+			// the "C" of "HTTPClient", unless it is followed by a plural "s".
+			initialismEnd := unicode.IsUpper(rs[i]) && unicode.IsUpper(rs[i-1]) &&
+				i+1 < len(rs) && unicode.IsLower(rs[i+1]) &&
+				!(i+2 == len(rs) && rs[i+1] == 's')
+			if lowerToUpper || initialismEnd {
+				parts = append(parts, string(rs[start:i]))
+				start = i
+			}
+		}
+		parts = append(parts, string(rs[start:]))
+	}
+	return parts
+}
+
+// agentNounVerbs maps identifier parts like "retrier" or "marshaler" to the
+// verb they are derived from. Identifiers are often named this way, but the
+// Postgres English stemmer does not map them to the verb. Only words for
+// which this has been checked are listed: removing the suffix in general
+// produces junk like "numb" for "number".
+var agentNounVerbs = map[string]string{
+	"builder":     "build",
+	"handler":     "handle",
+	"loader":      "load",
+	"marshaler":   "marshal",
+	"matcher":     "match",
+	"reader":      "read",
+	"retrier":     "retry",
+	"scanner":     "scan",
+	"unmarshaler": "unmarshal",
+	"wrapper":     "wrap",
+	"writer":      "write",
+}
+
 // summaryReplacements is used to replace words with other words.
 // It is used by processWord, below.
 // Example key-value pairs:
@@ -165,6 +267,12 @@ func processWord(s string) []string {
 	return result
 }
 
+// isURL reports whether s is an absolute URL, like "https://golang.org/x".
+func isURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && u.Scheme != "" && u.Host != ""
+}
+
 // hyphenSplit reports whether s should be split on hyphens.
 func hyphenSplit(s string) bool {
 	return !(strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://"))
@@ -85,6 +85,11 @@ func TestProcessWords(t *testing.T) {
 			"a", "postgres", "postgresql", "and", "nats", "server", "over", "http"}},
 		{"http://a-b-c.com full-text chart-parser", []string{
 			"http://a-b-c.com", "full-text", "chart-parser", "parser", "parse"}},
+		{"Use HTTPClientRetrier or retry_policy, not URLs.", []string{
+			"use", "httpclientretrier", "http", "client", "retrier", "retry",
+			"or", "retry_policy", "retry", "policy", "not", "urls"}},
+		{"NumberOrder ftp://a.example.com/Foo_Bar", []string{
+			"numberorder", "number", "order", "ftp://a.example.com/foo_bar"}},
 	} {
 		got := processWords(test.in)
 		if !cmp.Equal(got, test.want) {
@@ -93,6 +98,42 @@ func TestProcessWords(t *testing.T) {
 	}
 }
 
+func TestTextSearchQuery(t *testing.T) {
+	for _, test := range []struct {
+		in, want string
+	}{
+		{"", ""},
+		{"ReadAll", "Read All"},
+		{"ioutil readall", "ioutil readall"},
+		{"http_client  HTTPClientRetrier", "http client HTTP Client Retrier"},
+		{"base64 go-kit", "base64 go-kit"},
+		{`"ReadAll" -ReadFile or ioutil.ReadAll`, `"ReadAll" -ReadFile or ioutil.ReadAll`},
+	} {
+		if got := textSearchQuery(test.in); got != test.want {
+			t.Errorf("textSearchQuery(%q) = %q, want %q", test.in, got, test.want)
+		}
+	}
+}
+
+func TestIdentifierParts(t *testing.T) {
+	for _, test := range []struct {
+		in   string
+		want []string
+	}{
+		{"foo", []string{"foo"}},
+		{"HTTPClientRetrier", []string{"HTTP", "Client", "Retrier"}},
+		{"parseURL", []string{"parse", "URL"}},
+		{"Base64Encoding", []string{"Base64", "Encoding"}},
+		{"snake_case_name", []string{"snake", "case", "name"}},
+		{"IDs", []string{"IDs"}},
+	} {
+		got := identifierParts(test.in)
+		if !cmp.Equal(got, test.want) {
+			t.Errorf("%q: got %q, want %q", test.in, got, test.want)
+		}
+	}
+}
+
 func TestProcessMarkdown(t *testing.T) {
 	const (
 		in = `
@@ -0,0 +1,250 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/stdlib"
+)
+
+// SearchFilters restrict the results of a search. A result must match all
+// of the filters that are set, except that it need only match one of
+// Modules.
+type SearchFilters struct {
+	// Licenses are license types, like "MIT", that the package must be
+	// covered by, ignoring case.
+	Licenses []string
+	// Modules are patterns for the path of the module of the package. A "*"
+	// matches any sequence of characters, as in "github.com/foo/*".
+	Modules []string
+	// Redistributable restricts results to redistributable packages.
+	Redistributable bool
+	// Stdlib restricts results to the standard library.
+	Stdlib bool
+	// HasTests restricts results to packages with test files.
+	HasTests bool
+	// ExcludeDeprecated leaves out the packages of deprecated modules.
+	ExcludeDeprecated bool
+}
+
+// IsZero reports whether f restricts no results.
+func (f *SearchFilters) IsZero() bool {
+	return f == nil || (len(f.Licenses) == 0 && len(f.Modules) == 0 &&
+		!f.Redistributable && !f.Stdlib && !f.HasTests && !f.ExcludeDeprecated)
+}
+
+// ParseSearchQuery splits a search query into the text to search for and
+// the filters in it. A filter is a word of the form key:value:
+//
+//	license:<type>       covered by a license of the type, like license:MIT
+//	module:<pattern>     in a module whose path matches the pattern, like
+//	                     module:github.com/foo/*
+//	is:redistributable   redistributable
+//	is:stdlib            in the standard library
+//	has:tests            with test files
+//	exclude:deprecated   not in a deprecated module
+//
+// Words whose key is not one of these, like URLs, are part of the text. It
+// returns an error wrapping derrors.InvalidArgument for a filter with an
+// unknown or empty value.
+func ParseSearchQuery(q string) (text string, filters *SearchFilters, err error) {
+	filters = &SearchFilters{}
+	var words []string
+	for _, w := range strings.Fields(q) {
+		i := strings.IndexByte(w, ':')
+		if i < 0 {
+			words = append(words, w)
+			continue
+		}
+		key, value := strings.ToLower(w[:i]), w[i+1:]
+		var ok bool
+		switch key {
+		case "license":
+			filters.Licenses = append(filters.Licenses, value)
+			ok = value != ""
+		case "module":
+			filters.Modules = append(filters.Modules, value)
+			ok = value != ""
+		case "is":
+			switch strings.ToLower(value) {
+			case "redistributable":
+				filters.Redistributable, ok = true, true
+			case "stdlib", "std":
+				filters.Stdlib, ok = true, true
+			}
+		case "has":
+			if strings.ToLower(value) == "tests" {
+				filters.HasTests, ok = true, true
+			}
+		case "exclude":
+			if strings.ToLower(value) == "deprecated" {
+				filters.ExcludeDeprecated, ok = true, true
+			}
+		default:
+			words = append(words, w)
+			continue
+		}
+		if !ok {
+			return "", nil, fmt.Errorf("%w: unknown search filter %q", derrors.InvalidArgument, w)
+		}
+	}
+	return strings.Join(words, " "), filters, nil
+}
+
+// deprecatedExpr is true for a search document of a deprecated module.
+const deprecatedExpr = `EXISTS (
+			SELECT 1 FROM modules m
+			WHERE m.module_path = search_documents.module_path
+				AND m.version = search_documents.version
+				AND m.deprecated_message IS NOT NULL)`
+
+// where returns a SQL condition on the columns of search_documents that
+// holds for the documents that match f, with placeholders numbered from
+// firstArg, and the arguments for them. It returns "TRUE" if f is zero.
+func (f *SearchFilters) where(firstArg int) (string, []interface{}) {
+	if f.IsZero() {
+		return "TRUE", nil
+	}
+	var (
+		conds []string
+		args  []interface{}
+	)
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", firstArg+len(args)-1)
+	}
+	for _, l := range f.Licenses {
+		conds = append(conds, fmt.Sprintf(
+			"EXISTS (SELECT 1 FROM unnest(license_types) l WHERE lower(l) = lower(%s))", arg(l)))
+	}
+	if len(f.Modules) > 0 {
+		var ors []string
+		for _, m := range f.Modules {
+			ors = append(ors, fmt.Sprintf("module_path LIKE %s", arg(modulePatternToLike(m))))
+		}
+		conds = append(conds, "("+strings.Join(ors, " OR ")+")")
+	}
+	if f.Redistributable {
+		conds = append(conds, "redistributable")
+	}
+	if f.Stdlib {
+		conds = append(conds, fmt.Sprintf("module_path = '%s'", stdlib.ModulePath))
+	}
+	if f.HasTests {
+		conds = append(conds, "has_tests")
+	}
+	if f.ExcludeDeprecated {
+		conds = append(conds, "NOT "+deprecatedExpr)
+	}
+	return strings.Join(conds, " AND "), args
+}
+
+// modulePatternToLike converts a module path pattern, in which "*" matches
+// any sequence of characters, to a pattern for the SQL LIKE operator.
+func modulePatternToLike(pattern string) string {
+	r := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`, `*`, `%`)
+	return r.Replace(pattern)
+}
+
+// SearchWithFilters is like Search, but only returns results that match
+// filters. If filters is zero, it is the same as Search. Otherwise only a
+// deep search is performed, since the popular search can't be restricted.
+func (db *DB) SearchWithFilters(ctx context.Context, q string, filters *SearchFilters, limit, offset, maxResultCount int) (_ []*internal.SearchResult, err error) {
+	if filters.IsZero() {
+		return db.Search(ctx, q, limit, offset, maxResultCount)
+	}
+	defer derrors.Wrap(&err, "DB.SearchWithFilters(ctx, %q, %+v, %d, %d)", q, filters, limit, offset)
+	resp := db.filteredDeepSearch(ctx, q, filters, limit, offset, maxResultCount)
+	if resp.err != nil {
+		return nil, resp.err
+	}
+	if err := db.addPackageDataToSearchResults(ctx, resp.results); err != nil {
+		return nil, err
+	}
+	var results []*internal.SearchResult
+	for _, r := range resp.results {
+		ex, err := db.IsExcludedFromSearch(ctx, r.PackagePath)
+		if err != nil {
+			return nil, err
+		}
+		if !ex {
+			results = append(results, r)
+		}
+	}
+	return results, nil
+}
+
+// SearchFacets counts the search documents that match a query and its
+// filters by the properties that filters select, so that users can narrow
+// the results.
+type SearchFacets struct {
+	// Total is the number of search documents that match.
+	Total int
+	// Licenses are the most common license types, most common first.
+	Licenses        []*LicenseFacet
+	Redistributable int
+	Stdlib          int
+	HasTests        int
+	Deprecated      int
+}
+
+// A LicenseFacet is the number of search documents with a license type.
+type LicenseFacet struct {
+	Type  string
+	Count int
+}
+
+// maxLicenseFacets is the largest number of license types in SearchFacets.
+const maxLicenseFacets = 10
+
+// GetSearchFacets returns the facets of the search documents that match the
+// text q and filters.
+func (db *DB) GetSearchFacets(ctx context.Context, q string, filters *SearchFilters) (_ *SearchFacets, err error) {
+	defer derrors.Wrap(&err, "DB.GetSearchFacets(ctx, %q, %+v)", q, filters)
+
+	where, args := filters.where(2)
+	args = append([]interface{}{textSearchQuery(q)}, args...)
+	var facets SearchFacets
+	query := fmt.Sprintf(`
+		SELECT
+			COUNT(*),
+			COUNT(*) FILTER (WHERE redistributable),
+			COUNT(*) FILTER (WHERE module_path = '%s'),
+			COUNT(*) FILTER (WHERE has_tests),
+			COUNT(*) FILTER (WHERE %s)
+		FROM search_documents
+		WHERE tsv_search_tokens @@ websearch_to_tsquery('go_text', $1) AND %s`,
+		stdlib.ModulePath, deprecatedExpr, where)
+	err = db.readDB().QueryRow(ctx, query, args...).Scan(
+		&facets.Total, &facets.Redistributable, &facets.Stdlib, &facets.HasTests, &facets.Deprecated)
+	if err != nil {
+		return nil, err
+	}
+	query = fmt.Sprintf(`
+		SELECT l, COUNT(*)
+		FROM search_documents, unnest(license_types) l
+		WHERE tsv_search_tokens @@ websearch_to_tsquery('go_text', $1) AND %s AND l <> ''
+		GROUP BY l
+		ORDER BY COUNT(*) DESC, l
+		LIMIT %d`, where, maxLicenseFacets)
+	collect := func(rows *sql.Rows) error {
+		var f LicenseFacet
+		if err := rows.Scan(&f.Type, &f.Count); err != nil {
+			return err
+		}
+		facets.Licenses = append(facets.Licenses, &f)
+		return nil
+	}
+	if err := db.readDB().RunQuery(ctx, query, collect, args...); err != nil {
+		return nil, err
+	}
+	return &facets, nil
+}
@@ -0,0 +1,155 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/stdlib"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestParseSearchQuery(t *testing.T) {
+	for _, test := range []struct {
+		in          string
+		wantText    string
+		wantFilters *SearchFilters
+	}{
+		{"yaml", "yaml", &SearchFilters{}},
+		{
+			"yaml  License:MIT license:bsd-3-clause has:tests",
+			"yaml",
+			&SearchFilters{Licenses: []string{"MIT", "bsd-3-clause"}, HasTests: true},
+		},
+		{
+			"module:github.com/a/* http json module:golang.org/x/* is:STD exclude:deprecated is:redistributable",
+			"http json",
+			&SearchFilters{
+				Modules:           []string{"github.com/a/*", "golang.org/x/*"},
+				Stdlib:            true,
+				ExcludeDeprecated: true,
+				Redistributable:   true,
+			},
+		},
+		{"https://github.com/a/b", "https://github.com/a/b", &SearchFilters{}},
+	} {
+		gotText, gotFilters, err := ParseSearchQuery(test.in)
+		if err != nil {
+			t.Fatalf("%q: %v", test.in, err)
+		}
+		if gotText != test.wantText {
+			t.Errorf("%q: got text %q, want %q", test.in, gotText, test.wantText)
+		}
+		if diff := cmp.Diff(test.wantFilters, gotFilters); diff != "" {
+			t.Errorf("%q: filters mismatch (-want +got):\n%s", test.in, diff)
+		}
+	}
+
+	for _, in := range []string{"a license:", "a module:", "a is:popular", "a has:examples", "a exclude:stdlib"} {
+		if _, _, err := ParseSearchQuery(in); !errors.Is(err, derrors.InvalidArgument) {
+			t.Errorf("%q: got error %v, want InvalidArgument", in, err)
+		}
+	}
+}
+
+func TestSearchFiltersWhere(t *testing.T) {
+	f := &SearchFilters{
+		Licenses:        []string{"MIT"},
+		Modules:         []string{"github.com/a_b/*", "x.com/m"},
+		Redistributable: true,
+	}
+	gotWhere, gotArgs := f.where(3)
+	wantWhere := "EXISTS (SELECT 1 FROM unnest(license_types) l WHERE lower(l) = lower($3))" +
+		" AND (module_path LIKE $4 OR module_path LIKE $5) AND redistributable"
+	if gotWhere != wantWhere {
+		t.Errorf("got\n%s\nwant\n%s", gotWhere, wantWhere)
+	}
+	wantArgs := []interface{}{"MIT", `github.com/a\_b/%`, "x.com/m"}
+	if diff := cmp.Diff(wantArgs, gotArgs); diff != "" {
+		t.Errorf("args mismatch (-want +got):\n%s", diff)
+	}
+
+	var zero *SearchFilters
+	if got, args := zero.where(1); got != "TRUE" || args != nil {
+		t.Errorf("nil filters: got %q, %v; want TRUE, nil", got, args)
+	}
+}
+
+func TestSearchWithFilters(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer ResetTestDB(testDB, t)
+
+	tested := sample.LegacyModule("a.com/tested", sample.VersionString, "foo")
+	for _, u := range tested.Units {
+		if u.Documentation != nil {
+			u.Documentation.HasTests = true
+		}
+	}
+	deprecated := sample.LegacyModule("b.com/deprecated", sample.VersionString, "bar")
+	deprecated.Deprecation = &internal.Deprecation{Message: "use a.com/tested"}
+	closed := sample.LegacyModule("c.com/closed", sample.VersionString, "")
+	closed.IsRedistributable = false
+	for _, u := range closed.Units {
+		u.IsRedistributable = false
+	}
+	std := sample.LegacyModule(stdlib.ModulePath, "v1.15.0", "strings")
+	for _, m := range []*internal.Module{tested, deprecated, closed, std} {
+		if err := testDB.InsertModule(ctx, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, test := range []struct {
+		filters *SearchFilters
+		want    []string
+	}{
+		{&SearchFilters{HasTests: true}, []string{"a.com/tested/foo"}},
+		{&SearchFilters{Stdlib: true}, []string{"strings"}},
+		{
+			&SearchFilters{ExcludeDeprecated: true, Redistributable: true},
+			[]string{"a.com/tested/foo", "strings"},
+		},
+		{
+			&SearchFilters{Licenses: []string{"mit"}, Modules: []string{"a.com/*", "c.com/closed"}},
+			[]string{"a.com/tested/foo", "c.com/closed"},
+		},
+		{&SearchFilters{Licenses: []string{"Apache-2.0"}}, nil},
+	} {
+		results, err := testDB.SearchWithFilters(ctx, "package", test.filters, 10, 0, 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, r := range results {
+			got = append(got, r.PackagePath)
+		}
+		sort.Strings(got)
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("%+v: mismatch (-want +got):\n%s", test.filters, diff)
+		}
+	}
+
+	got, err := testDB.GetSearchFacets(ctx, "package", &SearchFilters{Modules: []string{"a.com/*", "b.com/*", "c.com/*"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &SearchFacets{
+		Total:           3,
+		Licenses:        []*LicenseFacet{{Type: "MIT", Count: 3}},
+		Redistributable: 2,
+		HasTests:        1,
+		Deprecated:      1,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("GetSearchFacets mismatch (-want +got):\n%s", diff)
+	}
+}
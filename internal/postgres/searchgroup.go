@@ -0,0 +1,162 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// maxSameModule is the largest number of other packages of a module that a
+// grouped search result holds.
+const maxSameModule = 5
+
+// SearchGroupedByModule is like SearchWithFilters, but returns one result for
+// each module that has matching packages: its best matching package, with up
+// to maxSameModule of the others in SameModule. Modules are ordered by their
+// best package, and limit, offset and maxResultCount count modules. Only a
+// deep search is performed, since the popular search can't group results.
+func (db *DB) SearchGroupedByModule(ctx context.Context, q string, filters *SearchFilters, limit, offset, maxResultCount int) (_ []*internal.SearchResult, err error) {
+	defer derrors.Wrap(&err, "DB.SearchGroupedByModule(ctx, %q, %+v, %d, %d)", q, filters, limit, offset)
+
+	resp := db.groupedDeepSearch(ctx, q, filters, limit, offset, maxResultCount)
+	if resp.err != nil {
+		return nil, resp.err
+	}
+	var all []*internal.SearchResult
+	for _, r := range resp.results {
+		all = append(all, r)
+		all = append(all, r.SameModule...)
+	}
+	if err := db.addPackageDataToSearchResults(ctx, all); err != nil {
+		return nil, err
+	}
+	// Filter out excluded paths.
+	var results []*internal.SearchResult
+	for _, r := range resp.results {
+		ex, err := db.IsExcludedFromSearch(ctx, r.PackagePath)
+		if err != nil {
+			return nil, err
+		}
+		if ex {
+			continue
+		}
+		var same []*internal.SearchResult
+		for _, s := range r.SameModule {
+			ex, err := db.IsExcludedFromSearch(ctx, s.PackagePath)
+			if err != nil {
+				return nil, err
+			}
+			if !ex {
+				same = append(same, s)
+			}
+		}
+		r.SameModule = same
+		results = append(results, r)
+	}
+	return results, nil
+}
+
+// groupedDeepSearch is like filteredDeepSearch, but groups the matching
+// packages by module, as described at SearchGroupedByModule. The packages of
+// a module are ranked in the same order as the results of an ungrouped
+// search.
+func (db *DB) groupedDeepSearch(ctx context.Context, q string, filters *SearchFilters, limit, offset, maxResultCount int) searchResponse {
+	where, filterArgs := filters.where(9)
+	query := fmt.Sprintf(`
+		WITH ranked AS (
+			SELECT
+				*,
+				ROW_NUMBER() OVER (
+					PARTITION BY module_path
+					ORDER BY score DESC, commit_time DESC, package_path) AS module_rank,
+				COUNT(*) OVER (PARTITION BY module_path) AS module_count
+			FROM (
+				SELECT
+					package_path,
+					version,
+					module_path,
+					commit_time,
+					imported_by_count,
+					(%s) * (%s) AS score
+				FROM search_documents
+				WHERE tsv_search_tokens @@ websearch_to_tsquery('go_text', $1) AND %s
+			) r
+			WHERE r.score > 0.1
+		), modules AS (
+			SELECT
+				module_path,
+				score,
+				commit_time,
+				package_path,
+				COUNT(*) OVER() AS total
+			FROM ranked
+			WHERE module_rank = 1
+			ORDER BY score DESC, commit_time DESC, package_path
+			LIMIT $2
+			OFFSET $3
+		)
+		SELECT
+			r.package_path,
+			r.version,
+			r.module_path,
+			r.commit_time,
+			r.imported_by_count,
+			r.score,
+			m.total,
+			r.module_rank,
+			r.module_count
+		FROM ranked r
+		INNER JOIN modules m ON m.module_path = r.module_path
+		WHERE r.module_rank <= $8 + 1
+		ORDER BY
+			m.score DESC,
+			m.commit_time DESC,
+			m.package_path,
+			r.module_rank`, scoreExpr, boostExpr, where)
+	var results []*internal.SearchResult
+	collect := func(rows *sql.Rows) error {
+		var (
+			r                 internal.SearchResult
+			rank, moduleCount int
+		)
+		if err := rows.Scan(&r.PackagePath, &r.Version, &r.ModulePath, &r.CommitTime,
+			&r.NumImportedBy, &r.Score, &r.NumResults, &rank, &moduleCount); err != nil {
+			return fmt.Errorf("rows.Scan(): %v", err)
+		}
+		if rank == 1 {
+			r.NumSameModule = moduleCount - 1
+			results = append(results, &r)
+			return nil
+		}
+		if len(results) == 0 {
+			return fmt.Errorf("BUG: package %q ranked before its module", r.PackagePath)
+		}
+		best := results[len(results)-1]
+		best.SameModule = append(best.SameModule, &r)
+		return nil
+	}
+	b := db.searchBoosts
+	args := append([]interface{}{textSearchQuery(q), limit, offset,
+		searchNameQuery(q), b.ExactName, b.Stdlib, b.ModuleRoot, maxSameModule}, filterArgs...)
+	err := db.readDB().RunQuery(ctx, query, collect, args...)
+	if err != nil {
+		results = nil
+	}
+	if len(results) > 0 && results[0].NumResults > uint64(maxResultCount) {
+		for _, r := range results {
+			r.NumResults = uint64(maxResultCount)
+		}
+	}
+	return searchResponse{
+		source:  "grouped",
+		results: results,
+		err:     err,
+	}
+}
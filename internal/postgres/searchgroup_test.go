@@ -0,0 +1,92 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestSearchGroupedByModule(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer ResetTestDB(testDB, t)
+
+	for _, m := range []*internal.Module{
+		sample.LegacyModule("a.com/big", sample.VersionString, "p1", "p2", "p3", "p4", "p5", "p6", "p7"),
+		sample.LegacyModule("b.com/small", sample.VersionString, "x"),
+		sample.LegacyModule("c.com/other", sample.VersionString, "y", "z"),
+	} {
+		if err := testDB.InsertModule(ctx, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	type group struct {
+		NumSameModule, LenSameModule int
+		NumResults                   uint64
+	}
+	groups := func(results []*internal.SearchResult) map[string]group {
+		t.Helper()
+		gs := map[string]group{}
+		for _, r := range results {
+			for _, s := range r.SameModule {
+				if s.ModulePath != r.ModulePath {
+					t.Errorf("%s: SameModule has %s, of module %s", r.PackagePath, s.PackagePath, s.ModulePath)
+				}
+				if s.Score > r.Score {
+					t.Errorf("%s: SameModule has %s, with a higher score", r.PackagePath, s.PackagePath)
+				}
+				if s.Name == "" {
+					t.Errorf("%s: package data not added", s.PackagePath)
+				}
+			}
+			gs[r.ModulePath] = group{r.NumSameModule, len(r.SameModule), r.NumResults}
+		}
+		return gs
+	}
+
+	results, err := testDB.SearchGroupedByModule(ctx, "package", nil, 10, 0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]group{
+		"a.com/big":   {6, maxSameModule, 3},
+		"b.com/small": {0, 0, 3},
+		"c.com/other": {1, 1, 3},
+	}
+	if diff := cmp.Diff(want, groups(results)); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	// Pages count modules.
+	seen := map[string]bool{}
+	for offset := 0; offset < 3; offset++ {
+		results, err := testDB.SearchGroupedByModule(ctx, "package", nil, 1, offset, 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) != 1 {
+			t.Fatalf("offset %d: got %d results, want 1", offset, len(results))
+		}
+		seen[results[0].ModulePath] = true
+	}
+	if len(seen) != 3 {
+		t.Errorf("pages returned modules %v, want all three", seen)
+	}
+
+	results, err = testDB.SearchGroupedByModule(ctx, "package", &SearchFilters{Modules: []string{"c.com/*"}}, 10, 0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = map[string]group{"c.com/other": {1, 1, 1}}
+	if diff := cmp.Diff(want, groups(results)); diff != "" {
+		t.Errorf("filtered: mismatch (-want +got):\n%s", diff)
+	}
+}
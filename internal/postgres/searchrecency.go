@@ -0,0 +1,64 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/experiment"
+)
+
+// With the search-recency experiment, the score of a search document is also
+// multiplied by a decay factor for the age of its version, which is the
+// latest version of its module. Versions released within the last
+// recencyGraceYears are not affected. After that, the factor halves every
+// recencyHalfLifeYears, down to recencyMinFactor, so that a module that was
+// abandoned years ago ranks below an active one of similar relevance and
+// popularity, but still ahead of obscure ones.
+const (
+	recencyGraceYears    = 2
+	recencyHalfLifeYears = 2
+	recencyMinFactor     = 0.25
+
+	secondsPerYear = 365.25 * 24 * 60 * 60
+)
+
+// recencyExpr is the expression that computes the decay factor of a search
+// document. It matches recencyFactor.
+var recencyExpr = fmt.Sprintf(`
+		GREATEST(%f, power(0.5,
+			GREATEST(0, extract(epoch FROM CURRENT_TIMESTAMP - commit_time) / %f - %d) / %d))
+	`, recencyMinFactor, secondsPerYear, recencyGraceYears, recencyHalfLifeYears)
+
+// recencyFactor returns the decay factor of a search document whose version
+// was committed at commitTime, as of now.
+func recencyFactor(commitTime, now time.Time) float64 {
+	years := now.Sub(commitTime).Seconds()/secondsPerYear - recencyGraceYears
+	if years <= 0 {
+		return 1
+	}
+	return math.Max(recencyMinFactor, math.Pow(0.5, years/recencyHalfLifeYears))
+}
+
+// searchScoreExpr returns the expression that computes the search score,
+// without boosts, for the search: scoreExpr, multiplied by recencyExpr if the
+// search-recency experiment is active.
+func searchScoreExpr(ctx context.Context) string {
+	if experiment.IsActive(ctx, internal.ExperimentSearchRecency) {
+		return scoreExpr + " * " + recencyExpr
+	}
+	return scoreExpr
+}
+
+// deepSearchers are the searchers used by Search when the search-recency
+// experiment is active. The popular search can't be used, because the
+// popular_search function computes scores without the decay factor.
+var deepSearchers = map[string]searcher{
+	"deep": (*DB).deepSearch,
+}
@@ -0,0 +1,75 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/experiment"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestRecencyFactor(t *testing.T) {
+	now := time.Date(2020, 11, 1, 0, 0, 0, 0, time.UTC)
+	year := time.Duration(secondsPerYear * float64(time.Second))
+	for _, test := range []struct {
+		age  time.Duration
+		want float64
+	}{
+		{0, 1},
+		{recencyGraceYears * year, 1},
+		{(recencyGraceYears + recencyHalfLifeYears) * year, 0.5},
+		{100 * year, recencyMinFactor},
+	} {
+		got := recencyFactor(now.Add(-test.age), now)
+		if diff := got - test.want; diff > 1e-9 || diff < -1e-9 {
+			t.Errorf("age %s: got %g, want %g", test.age, got, test.want)
+		}
+	}
+}
+
+func TestSearchRecency(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer ResetTestDB(testDB, t)
+
+	fresh := sample.LegacyModule("a.com/fresh", sample.VersionString, "")
+	stale := sample.LegacyModule("b.com/stale", sample.VersionString, "")
+	stale.CommitTime = time.Now().AddDate(-10, 0, 0)
+	for _, m := range []*internal.Module{fresh, stale} {
+		if err := testDB.InsertModule(ctx, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Make the stale module more popular, so that it ranks first unless its
+	// age counts against it.
+	if _, err := testDB.db.Exec(ctx, `UPDATE search_documents SET imported_by_count = 5 WHERE module_path = 'b.com/stale'`); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		ctx  context.Context
+		want []string
+	}{
+		{ctx, []string{"b.com/stale", "a.com/fresh"}},
+		{experiment.NewContext(ctx, internal.ExperimentSearchRecency), []string{"a.com/fresh", "b.com/stale"}},
+	} {
+		results, err := testDB.Search(test.ctx, "package", 10, 0, 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, r := range results {
+			got = append(got, r.PackagePath)
+		}
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("mismatch (-want +got):\n%s", diff)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// InsertSnapshotRule inserts a rule into the snapshot_rules table and returns
+// its ID.
+func (db *DB) InsertSnapshotRule(ctx context.Context, rule *internal.SnapshotRule) (_ int, err error) {
+	defer derrors.Wrap(&err, "DB.InsertSnapshotRule(ctx, %q)", rule.PathPattern)
+
+	var id int
+	err = db.db.QueryRow(ctx, `
+		INSERT INTO snapshot_rules (path_pattern, sample_rate, expires_at)
+		VALUES ($1, $2, $3)
+		RETURNING id`,
+		rule.PathPattern, rule.SampleRate, rule.ExpiresAt).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// GetActiveSnapshotRules returns the snapshot rules that have not expired.
+func (db *DB) GetActiveSnapshotRules(ctx context.Context) (_ []*internal.SnapshotRule, err error) {
+	defer derrors.Wrap(&err, "DB.GetActiveSnapshotRules(ctx)")
+
+	var rules []*internal.SnapshotRule
+	collect := func(rows *sql.Rows) error {
+		var r internal.SnapshotRule
+		if err := rows.Scan(&r.ID, &r.PathPattern, &r.SampleRate, &r.ExpiresAt); err != nil {
+			return err
+		}
+		rules = append(rules, &r)
+		return nil
+	}
+	query := `
+		SELECT id, path_pattern, sample_rate, expires_at
+		FROM snapshot_rules
+		WHERE expires_at > CURRENT_TIMESTAMP
+		ORDER BY id`
+	if err := db.db.RunQuery(ctx, query, collect); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// InsertResponseSnapshot inserts s into the response_snapshots table.
+func (db *DB) InsertResponseSnapshot(ctx context.Context, s *internal.ResponseSnapshot) (err error) {
+	defer derrors.Wrap(&err, "DB.InsertResponseSnapshot(ctx, %q)", s.URL)
+
+	reqHeaders, err := json.Marshal(s.RequestHeader)
+	if err != nil {
+		return err
+	}
+	respHeaders, err := json.Marshal(s.ResponseHeader)
+	if err != nil {
+		return err
+	}
+	_, err = db.db.Exec(ctx, `
+		INSERT INTO response_snapshots (
+			rule_id, method, url, request_headers, status,
+			response_headers, response_body, body_truncated, app_version)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		s.RuleID, s.Method, s.URL, reqHeaders, s.Status,
+		respHeaders, s.ResponseBody, s.BodyTruncated, s.AppVersion)
+	return err
+}
@@ -0,0 +1,75 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/database"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/version"
+)
+
+// insertSymbolHistory records m.Version as the version in which the symbols
+// of each package of m appeared, unless they are already known to have
+// appeared in an earlier version. Pseudo-versions are not recorded, so that
+// symbols are only attributed to versions that were tagged.
+func insertSymbolHistory(ctx context.Context, db *database.DB, m *internal.Module) (err error) {
+	defer derrors.Wrap(&err, "insertSymbolHistory(ctx, db, %q, %q)", m.ModulePath, m.Version)
+
+	if version.IsPseudo(m.Version) {
+		return nil
+	}
+	sortVersion := version.ForSorting(m.Version)
+	// m.Units is sorted by path, which ensures a consistent lock ordering.
+	for _, u := range m.Units {
+		if u.Documentation == nil || len(u.Documentation.Symbols) == 0 {
+			continue
+		}
+		if _, err := db.Exec(ctx, `
+			INSERT INTO symbol_history AS h
+				(package_path, module_path, symbol_name, since_version, sort_version)
+			SELECT $1, $2, unnest($3::text[]), $4, $5
+			ON CONFLICT (package_path, module_path, symbol_name)
+			DO UPDATE SET
+				since_version=excluded.since_version,
+				sort_version=excluded.sort_version
+			WHERE excluded.sort_version < h.sort_version`,
+			u.Path, m.ModulePath, pq.Array(u.Documentation.Symbols), m.Version, sortVersion); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetSymbolHistory returns a map from the names of the exported functions,
+// types and methods of the package at packagePath in the module at
+// modulePath, the latter as "Type.Method", to the earliest version of the
+// module that has them. It only knows about the versions that were
+// processed while the symbol-history experiment was active.
+func (db *DB) GetSymbolHistory(ctx context.Context, packagePath, modulePath string) (_ map[string]string, err error) {
+	defer derrors.Wrap(&err, "DB.GetSymbolHistory(ctx, %q, %q)", packagePath, modulePath)
+
+	query := `
+		SELECT symbol_name, since_version
+		FROM symbol_history
+		WHERE package_path = $1 AND module_path = $2`
+	history := map[string]string{}
+	collect := func(rows *sql.Rows) error {
+		var name, since string
+		if err := rows.Scan(&name, &since); err != nil {
+			return err
+		}
+		history[name] = since
+		return nil
+	}
+	if err := db.db.RunQuery(ctx, query, collect, packagePath, modulePath); err != nil {
+		return nil, err
+	}
+	return history, nil
+}
@@ -0,0 +1,56 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/experiment"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestSymbolHistory(t *testing.T) {
+	defer ResetTestDB(testDB, t)
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	ctx = experiment.NewContext(ctx, internal.ExperimentSymbolHistory)
+
+	const modulePath = "example.com/symbols"
+	pkgPath := modulePath + "/" + sample.Suffix
+	insert := func(version string, symbols ...string) {
+		t.Helper()
+		m := sample.LegacyModule(modulePath, version, sample.Suffix)
+		for _, u := range m.Units {
+			if u.Documentation != nil {
+				u.Documentation.Symbols = symbols
+			}
+		}
+		if err := testDB.InsertModule(ctx, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Versions are processed out of order, and pseudo-versions are ignored.
+	insert("v1.2.0", "F", "G", "T", "T.M")
+	insert("v1.0.0", "F", "T")
+	insert("v1.1.0", "F", "T", "T.M")
+	insert("v1.0.1-0.20200101000000-0123456789ab", "F", "G", "T", "T.M")
+
+	got, err := testDB.GetSymbolHistory(ctx, pkgPath, modulePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"F":   "v1.0.0",
+		"T":   "v1.0.0",
+		"T.M": "v1.1.0",
+		"G":   "v1.2.0",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -98,7 +98,7 @@ func SetupTestDB(dbName string) (_ *DB, err error) {
 
 // ResetTestDB truncates all data from the given test DB.  It should be called
 // after every test that mutates the database.
-func ResetTestDB(db *DB, t *testing.T) {
+func ResetTestDB(db *DB, t testing.TB) {
 	ctx := context.Background()
 	t.Helper()
 	if err := db.db.Transact(ctx, sql.LevelDefault, func(tx *database.DB) error {
@@ -115,7 +115,50 @@ func ResetTestDB(db *DB, t *testing.T) {
 		if _, err := tx.Exec(ctx, `TRUNCATE excluded_prefixes;`); err != nil {
 			return err
 		}
-		setExcludedPrefixesLastFetched(time.Time{})
+		if _, err := tx.Exec(ctx, `TRUNCATE snapshot_rules CASCADE;`); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `TRUNCATE worker_load;`); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `TRUNCATE feedback_reports;`); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `TRUNCATE latest_checks;`); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `TRUNCATE symbol_history;`); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `TRUNCATE user_preferences;`); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `TRUNCATE module_provenance;`); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `TRUNCATE fetch_stages;`); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `TRUNCATE noindex_prefixes;`); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `TRUNCATE excluded_prefix_events;`); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `TRUNCATE excluded_prefix_purges;`); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `TRUNCATE imported_by_count_runs;`); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `TRUNCATE module_path_redirects;`); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `TRUNCATE module_path_redirect_overrides;`); err != nil {
+			return err
+		}
+		invalidateExcludedPrefixes()
+		setNoIndexPrefixesLastFetched(time.Time{})
 		return nil
 	}); err != nil {
 		t.Fatalf("error resetting test DB: %v", err)
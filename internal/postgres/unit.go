@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/google/safehtml"
 	"github.com/lib/pq"
 	"golang.org/x/pkgsite/internal"
 	"golang.org/x/pkgsite/internal/database"
@@ -91,7 +92,7 @@ func (db *DB) getPathID(ctx context.Context, fullPath, modulePath, resolvedVersi
 		    p.path = $1
 		    AND m.module_path = $2
 		    AND m.version = $3;`
-	err = db.db.QueryRow(ctx, query, fullPath, modulePath, resolvedVersion).Scan(&pathID)
+	err = db.readDB().QueryRow(ctx, query, fullPath, modulePath, resolvedVersion).Scan(&pathID)
 	switch err {
 	case sql.ErrNoRows:
 		return 0, derrors.NotFound
@@ -109,11 +110,13 @@ func (db *DB) getDocumentation(ctx context.Context, pathID int) (_ *internal.Doc
 		doc     internal.Documentation
 		docHTML string
 	)
-	err = db.db.QueryRow(ctx, `
+	err = db.readDB().QueryRow(ctx, `
 		SELECT
 			d.goos,
 			d.goarch,
 			d.synopsis,
+			d.synopsis_inferred,
+			d.kind,
 			d.html,
 			d.source
 		FROM documentation d
@@ -122,6 +125,8 @@ func (db *DB) getDocumentation(ctx context.Context, pathID int) (_ *internal.Doc
 		database.NullIsEmpty(&doc.GOOS),
 		database.NullIsEmpty(&doc.GOARCH),
 		database.NullIsEmpty(&doc.Synopsis),
+		&doc.SynopsisInferred,
+		&doc.Kind,
 		database.NullIsEmpty(&docHTML),
 		&doc.Source,
 	)
@@ -130,17 +135,32 @@ func (db *DB) getDocumentation(ctx context.Context, pathID int) (_ *internal.Doc
 		return nil, derrors.NotFound
 	case nil:
 		doc.HTML = convertDocumentation(docHTML)
-		return &doc, nil
 	default:
 		return nil, err
 	}
+	collect := func(rows *sql.Rows) error {
+		var p internal.DocumentationPart
+		if err := rows.Scan(&p.Title); err != nil {
+			return err
+		}
+		doc.Parts = append(doc.Parts, &p)
+		return nil
+	}
+	if err := db.readDB().RunQuery(ctx, `
+		SELECT title
+		FROM documentation_parts
+		WHERE path_id = $1
+		ORDER BY part`, collect, pathID); err != nil {
+		return nil, err
+	}
+	return &doc, nil
 }
 
 // getReadme returns the README corresponding to the modulePath and version.
 func (db *DB) getReadme(ctx context.Context, pathID int) (_ *internal.Readme, err error) {
 	defer derrors.Wrap(&err, "getReadme(ctx, %d)", pathID)
 	var readme internal.Readme
-	err = db.db.QueryRow(ctx, `
+	err = db.readDB().QueryRow(ctx, `
 		SELECT file_path, contents
 		FROM readmes
 		WHERE path_id=$1;`, pathID).Scan(&readme.Filepath, &readme.Contents)
@@ -158,7 +178,7 @@ func (db *DB) getReadme(ctx context.Context, pathID int) (_ *internal.Readme, er
 func (db *DB) getModuleReadme(ctx context.Context, modulePath, resolvedVersion string) (_ *internal.Readme, err error) {
 	defer derrors.Wrap(&err, "getModuleReadme(ctx, %q, %q)", modulePath, resolvedVersion)
 	var readme internal.Readme
-	err = db.db.QueryRow(ctx, `
+	err = db.readDB().QueryRow(ctx, `
 		SELECT file_path, contents
 		FROM modules m
 		INNER JOIN paths p
@@ -191,7 +211,7 @@ func (db *DB) getImports(ctx context.Context, pathID int) (_ []string, err error
 		imports = append(imports, path)
 		return nil
 	}
-	if err := db.db.RunQuery(ctx, `
+	if err := db.readDB().RunQuery(ctx, `
 		SELECT to_path
 		FROM package_imports
 		WHERE path_id = $1`, collect, pathID); err != nil {
@@ -221,7 +241,7 @@ func (db *DB) getPackagesInUnit(ctx context.Context, fullPath, modulePath, resol
 		WHERE
 			m.module_path = $1
 			AND m.version = $2
-		ORDER BY path;`
+		ORDER BY ` + pathOrder("p.path")
 	var packages []*internal.PackageMeta
 	collect := func(rows *sql.Rows) error {
 		var (
@@ -249,7 +269,7 @@ func (db *DB) getPackagesInUnit(ctx context.Context, fullPath, modulePath, resol
 		}
 		return nil
 	}
-	if err := db.db.RunQuery(ctx, query, collect, modulePath, resolvedVersion); err != nil {
+	if err := db.readDB().RunQuery(ctx, query, collect, modulePath, resolvedVersion); err != nil {
 		return nil, err
 	}
 	for _, p := range packages {
@@ -261,3 +281,32 @@ func (db *DB) getPackagesInUnit(ctx context.Context, fullPath, modulePath, resol
 	}
 	return packages, nil
 }
+
+// GetDocumentationPart returns the HTML of the given part of the
+// documentation of the package at path in modulePath@version, if it was
+// split because it was too large. Parts are numbered from 1; the first part
+// is the documentation HTML returned by GetUnit.
+func (db *DB) GetDocumentationPart(ctx context.Context, path, modulePath, version string, part int) (_ safehtml.HTML, err error) {
+	defer derrors.Wrap(&err, "GetDocumentationPart(ctx, %q, %q, %q, %d)", path, modulePath, version, part)
+
+	var docHTML string
+	err = db.readDB().QueryRow(ctx, `
+		SELECT d.html
+		FROM documentation_parts d
+		INNER JOIN paths p ON p.id = d.path_id
+		INNER JOIN modules m ON m.id = p.module_id
+		WHERE
+			p.path = $1
+			AND m.module_path = $2
+			AND m.version = $3
+			AND d.part = $4`,
+		path, modulePath, version, part).Scan(&docHTML)
+	switch err {
+	case sql.ErrNoRows:
+		return safehtml.HTML{}, derrors.NotFound
+	case nil:
+		return convertDocumentation(docHTML), nil
+	default:
+		return safehtml.HTML{}, err
+	}
+}
@@ -6,6 +6,7 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"path"
 	"testing"
 
@@ -13,6 +14,7 @@ import (
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/google/safehtml"
 	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
 	"golang.org/x/pkgsite/internal/experiment"
 	"golang.org/x/pkgsite/internal/licenses"
 	"golang.org/x/pkgsite/internal/source"
@@ -362,3 +364,54 @@ func findDirectory(m *internal.Module, path string) *internal.Unit {
 	}
 	return nil
 }
+
+func TestDocumentationParts(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	defer ResetTestDB(testDB, t)
+
+	const pkgPath = "a.com/m/dir/p"
+	m := sample.LegacyModule("a.com/m", "v1.2.3", "dir/p")
+	for _, u := range m.Units {
+		if u.Path == pkgPath {
+			doc := *u.Documentation
+			doc.Parts = []*internal.DocumentationPart{
+				{Title: "A – M", HTML: safehtml.HTMLEscaped("part 1")},
+				{Title: "N – Z", HTML: safehtml.HTMLEscaped("part 2")},
+			}
+			u.Documentation = &doc
+		}
+	}
+	if err := testDB.InsertModule(ctx, m); err != nil {
+		t.Fatal(err)
+	}
+
+	um := sample.UnitMeta(pkgPath, "a.com/m", "v1.2.3", "p", true)
+	u, err := testDB.GetUnit(ctx, um, internal.WithDocumentation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*internal.DocumentationPart{{Title: "A – M"}, {Title: "N – Z"}}
+	if diff := cmp.Diff(want, u.Documentation.Parts, cmp.AllowUnexported(safehtml.HTML{})); diff != "" {
+		t.Errorf("parts mismatch (-want +got):\n%s", diff)
+	}
+	got, err := testDB.GetDocumentationPart(ctx, pkgPath, "a.com/m", "v1.2.3", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.String() != "part 2" {
+		t.Errorf("part 2: got %q, want %q", got, "part 2")
+	}
+	if _, err := testDB.GetDocumentationPart(ctx, pkgPath, "a.com/m", "v1.2.3", 3); !errors.Is(err, derrors.NotFound) {
+		t.Errorf("part 3: got error %v, want NotFound", err)
+	}
+
+	// Reprocessing the module without parts removes them.
+	if err := testDB.InsertModule(ctx, sample.LegacyModule("a.com/m", "v1.2.3", "dir/p")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := testDB.GetDocumentationPart(ctx, pkgPath, "a.com/m", "v1.2.3", 1); !errors.Is(err, derrors.NotFound) {
+		t.Errorf("after reprocessing: got error %v, want NotFound", err)
+	}
+}
@@ -9,9 +9,11 @@ import (
 	"database/sql"
 	"fmt"
 	"strings"
+	"time"
 
 	"golang.org/x/mod/module"
 	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/database"
 	"golang.org/x/pkgsite/internal/derrors"
 	"golang.org/x/pkgsite/internal/version"
 )
@@ -38,7 +40,9 @@ func (db *DB) GetVersionsForPath(ctx context.Context, path string) (_ []*interna
 
 // getPathVersions returns a list of versions sorted in descending semver
 // order. The version types included in the list are specified by a list of
-// VersionTypes.
+// VersionTypes. Along with the fields set by scanModuleInfo, the deprecation
+// and retractions of each version are set, so that the versions page can
+// apply those of the latest version of each module.
 func getPathVersions(ctx context.Context, db *DB, path string, versionTypes ...version.Type) (_ []*internal.ModuleInfo, err error) {
 	defer derrors.Wrap(&err, "getPathVersions(ctx, db, %q, %v)", path, versionTypes)
 
@@ -49,7 +53,10 @@ func getPathVersions(ctx context.Context, db *DB, path string, versionTypes ...v
 		m.commit_time,
 		m.redistributable,
 		m.has_go_mod,
-		m.source_info
+		m.source_info,
+		m.deprecated_message,
+		m.successor_module_path,
+		m.retractions
 	FROM modules m
 	INNER JOIN paths p
 	ON p.module_id = m.id
@@ -61,10 +68,7 @@ func getPathVersions(ctx context.Context, db *DB, path string, versionTypes ...v
 			LIMIT 1
 		)
 		AND version_type in (%s)
-	ORDER BY
-		m.incompatible,
-		m.module_path DESC,
-		m.sort_version DESC %s`
+	%s %s`
 
 	queryEnd := `;`
 	if len(versionTypes) == 0 {
@@ -72,22 +76,51 @@ func getPathVersions(ctx context.Context, db *DB, path string, versionTypes ...v
 	} else if len(versionTypes) == 1 && versionTypes[0] == version.TypePseudo {
 		queryEnd = `LIMIT 10;`
 	}
-	query := fmt.Sprintf(baseQuery, versionTypeExpr(versionTypes), queryEnd)
+	query := fmt.Sprintf(baseQuery, versionTypeExpr(versionTypes), orderByVersion, queryEnd)
 	var versions []*internal.ModuleInfo
 	collect := func(rows *sql.Rows) error {
-		mi, err := scanModuleInfo(rows.Scan)
+		var (
+			deprecatedMessage   sql.NullString
+			successorModulePath string
+			retractions         []*internal.Retraction
+		)
+		mi, err := scanModuleInfo(func(dest ...interface{}) error {
+			dest = append(dest, &deprecatedMessage, database.NullIsEmpty(&successorModulePath),
+				jsonbScanner{&retractions})
+			return rows.Scan(dest...)
+		})
 		if err != nil {
 			return fmt.Errorf("row.Scan(): %v", err)
 		}
+		if deprecatedMessage.Valid {
+			mi.Deprecation = &internal.Deprecation{
+				Message:   deprecatedMessage.String,
+				Successor: successorModulePath,
+			}
+		}
+		mi.Retractions = retractions
 		versions = append(versions, mi)
 		return nil
 	}
-	if err := db.db.RunQuery(ctx, query, collect, path); err != nil {
+	if err := db.readDB().RunQuery(ctx, query, collect, path); err != nil {
 		return nil, err
 	}
 	return versions, nil
 }
 
+// orderByVersion orders the versions of a path for display: versions of
+// compatible modules before "+incompatible" ones, then by the major version
+// of the module path, numerically and highest first, so that v10 comes
+// before v9, then by descending semantic version. The module path breaks
+// ties between modules with the same major version, so every module version
+// has a distinct position in the order.
+const orderByVersion = `
+	ORDER BY
+		m.incompatible,
+		COALESCE(substring(m.module_path from '[/.]v([0-9]+)$')::int, 1) DESC,
+		m.module_path DESC,
+		m.sort_version DESC`
+
 // versionTypeExpr returns a comma-separated list of version types,
 // for use in a clause like "WHERE version_type IN (%s)"
 func versionTypeExpr(vts []version.Type) string {
@@ -116,7 +149,7 @@ func (db *DB) GetLatestMajorVersion(ctx context.Context, seriesPath string) (_ s
 			m.series_path = $1
 		%s
 		LIMIT 1;`, orderByLatest)
-	row := db.db.QueryRow(ctx, latestModulePathQuery, seriesPath)
+	row := db.readDB().QueryRow(ctx, latestModulePathQuery, seriesPath)
 	if err := row.Scan(&latestPath); err != nil {
 		return "", err
 	}
@@ -126,3 +159,56 @@ func (db *DB) GetLatestMajorVersion(ctx context.Context, seriesPath string) (_ s
 	}
 	return majorPath, nil
 }
+
+// A FeedEntry is a version of a module in the feed of its new versions.
+type FeedEntry struct {
+	ModulePath string
+	Version    string
+	CommitTime time.Time
+	// IndexedAt is when the module version was first stored.
+	IndexedAt time.Time
+	// Synopsis is that of the package at the root of the module, if there
+	// is one.
+	Synopsis string
+}
+
+// GetModuleFeed returns up to limit release and prerelease versions of the
+// module, most recently indexed first, for the feed of its new versions.
+// Pseudo-versions are left out, since they are not releases.
+func (db *DB) GetModuleFeed(ctx context.Context, modulePath string, limit int) (_ []*FeedEntry, err error) {
+	defer derrors.Wrap(&err, "DB.GetModuleFeed(ctx, %q, %d)", modulePath, limit)
+
+	query := fmt.Sprintf(`
+		SELECT
+			m.module_path,
+			m.version,
+			m.commit_time,
+			m.created_at,
+			COALESCE((
+				SELECT d.synopsis
+				FROM paths p
+				INNER JOIN documentation d ON d.path_id = p.id
+				WHERE p.module_id = m.id AND p.path = m.module_path
+				ORDER BY d.goos, d.goarch
+				LIMIT 1
+			), '')
+		FROM modules m
+		WHERE
+			m.module_path = $1
+			AND m.version_type IN (%s)
+		ORDER BY m.created_at DESC, m.sort_version DESC
+		LIMIT $2`, versionTypeExpr([]version.Type{version.TypeRelease, version.TypePrerelease}))
+	var entries []*FeedEntry
+	collect := func(rows *sql.Rows) error {
+		var e FeedEntry
+		if err := rows.Scan(&e.ModulePath, &e.Version, &e.CommitTime, &e.IndexedAt, &e.Synopsis); err != nil {
+			return fmt.Errorf("row.Scan(): %v", err)
+		}
+		entries = append(entries, &e)
+		return nil
+	}
+	if err := db.readDB().RunQuery(ctx, query, collect, modulePath, limit); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
@@ -8,9 +8,13 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"time"
 
+	"github.com/lib/pq"
 	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/database"
 	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/stdlib"
 	"golang.org/x/pkgsite/internal/version"
 )
 
@@ -98,3 +102,140 @@ func (db *DB) GetVersionMap(ctx context.Context, modulePath, requestedVersion st
 		return nil, err
 	}
 }
+
+// GetVersionMaps fetches the version_map entries for each of the given
+// module versions, where requestedVersions[i] is the requested version of
+// modulePaths[i]. Module versions that have no entry are omitted from the
+// result.
+func (db *DB) GetVersionMaps(ctx context.Context, modulePaths, requestedVersions []string) (_ []*internal.VersionMap, err error) {
+	defer derrors.Wrap(&err, "DB.GetVersionMaps(ctx, %d module versions)", len(modulePaths))
+	if len(modulePaths) != len(requestedVersions) {
+		return nil, fmt.Errorf("got %d module paths and %d versions: %w", len(modulePaths), len(requestedVersions), derrors.InvalidArgument)
+	}
+
+	query := `
+		SELECT
+			vm.module_path,
+			vm.requested_version,
+			vm.resolved_version,
+			vm.go_mod_path,
+			vm.status,
+			vm.error,
+			vm.updated_at
+		FROM
+			version_map vm
+		INNER JOIN (
+			SELECT
+				UNNEST($1::text[]) AS module_path,
+				UNNEST($2::text[]) AS requested_version
+		) r
+		ON
+			vm.module_path = r.module_path
+			AND vm.requested_version = r.requested_version;`
+	var vms []*internal.VersionMap
+	collect := func(rows *sql.Rows) error {
+		var vm internal.VersionMap
+		if err := rows.Scan(&vm.ModulePath, &vm.RequestedVersion, &vm.ResolvedVersion, &vm.GoModPath,
+			&vm.Status, &vm.Error, &vm.UpdatedAt); err != nil {
+			return fmt.Errorf("rows.Scan(): %v", err)
+		}
+		vms = append(vms, &vm)
+		return nil
+	}
+	if err := db.db.RunQuery(ctx, query, collect, pq.Array(modulePaths), pq.Array(requestedVersions)); err != nil {
+		return nil, err
+	}
+	return vms, nil
+}
+
+// GetStaleNamedVersionMaps returns at most limit version_map entries for
+// requested versions that don't name the version they resolve to, like
+// "master" or "latest", which resolve to different versions as time goes by.
+// Only entries that were last updated before the given time are returned,
+// and only for modules with a version whose page has been viewed since
+// viewedSince. They are ordered by the time they were last updated, oldest
+// first. The standard library is not served by the proxy, so its entries are
+// never returned.
+func (db *DB) GetStaleNamedVersionMaps(ctx context.Context, before, viewedSince time.Time, limit int) (_ []*internal.VersionMap, err error) {
+	defer derrors.Wrap(&err, "DB.GetStaleNamedVersionMaps(ctx, %s, %s, %d)", before, viewedSince, limit)
+
+	query := `
+		SELECT
+			vm.module_path,
+			vm.requested_version,
+			vm.resolved_version,
+			vm.go_mod_path,
+			vm.status,
+			vm.error,
+			vm.updated_at
+		FROM version_map vm
+		WHERE vm.requested_version <> COALESCE(vm.resolved_version, '')
+		AND vm.module_path <> $1
+		AND vm.updated_at < $2
+		AND EXISTS (
+			SELECT 1
+			FROM modules m
+			WHERE m.module_path = vm.module_path
+			AND m.last_viewed_at >= $3
+		)
+		ORDER BY vm.updated_at
+		LIMIT $4`
+	var vms []*internal.VersionMap
+	collect := func(rows *sql.Rows) error {
+		var vm internal.VersionMap
+		if err := rows.Scan(&vm.ModulePath, &vm.RequestedVersion, database.NullIsEmpty(&vm.ResolvedVersion), &vm.GoModPath,
+			&vm.Status, database.NullIsEmpty(&vm.Error), &vm.UpdatedAt); err != nil {
+			return err
+		}
+		vms = append(vms, &vm)
+		return nil
+	}
+	if err := db.db.RunQuery(ctx, query, collect, stdlib.ModulePath, before, viewedSince, limit); err != nil {
+		return nil, err
+	}
+	return vms, nil
+}
+
+// TouchVersionMap records that the version_map entry for modulePath and
+// requestedVersion was found to be up to date, by setting its updated_at
+// time. It returns an error wrapping derrors.NotFound if there is no such
+// entry.
+func (db *DB) TouchVersionMap(ctx context.Context, modulePath, requestedVersion string) (err error) {
+	defer derrors.Wrap(&err, "DB.TouchVersionMap(ctx, %q, %q)", modulePath, requestedVersion)
+
+	// The set_updated_at trigger sets updated_at to the current time.
+	n, err := db.db.Exec(ctx, `
+		UPDATE version_map
+		SET updated_at = CURRENT_TIMESTAMP
+		WHERE module_path = $1
+		AND requested_version = $2`,
+		modulePath, requestedVersion)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return derrors.NotFound
+	}
+	return nil
+}
+
+// DeleteDanglingVersionMaps deletes the successful version_map entries for
+// requested versions like "master", which don't name the version they
+// resolve to, whose resolved version is no longer in the modules table.
+// Pages for such a requested version can't be served, and without its entry,
+// the next request for it fetches it again. It returns the number of entries
+// deleted.
+func (db *DB) DeleteDanglingVersionMaps(ctx context.Context) (n int64, err error) {
+	defer derrors.Wrap(&err, "DB.DeleteDanglingVersionMaps(ctx)")
+
+	return db.db.Exec(ctx, `
+		DELETE FROM version_map vm
+		WHERE vm.status = 200
+		AND vm.requested_version <> vm.resolved_version
+		AND NOT EXISTS (
+			SELECT 1
+			FROM modules m
+			WHERE m.module_path = vm.module_path
+			AND m.version = vm.resolved_version
+		)`)
+}
@@ -6,11 +6,14 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
 	"golang.org/x/pkgsite/internal/testing/sample"
 )
 
@@ -76,3 +79,103 @@ func TestUpsertVersionMap(t *testing.T) {
 	vm.Status = 200
 	upsertAndVerifyVersionMap(vm)
 }
+
+func TestGetVersionMaps(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer ResetTestDB(testDB, t)
+
+	vms := []*internal.VersionMap{
+		{ModulePath: "github.com/a", RequestedVersion: "v1.0.0", ResolvedVersion: "v1.0.0", Status: 200},
+		{ModulePath: "github.com/b", RequestedVersion: "v1.0.0", Status: 404, Error: "not found"},
+		{ModulePath: "github.com/b", RequestedVersion: "v2.0.0", ResolvedVersion: "v2.0.0", Status: 200},
+	}
+	for _, vm := range vms {
+		if err := testDB.UpsertVersionMap(ctx, vm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := testDB.GetVersionMaps(ctx,
+		[]string{"github.com/a", "github.com/b", "github.com/c"},
+		[]string{"v1.0.0", "v1.0.0", "v1.0.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(vms[:2], got,
+		cmpopts.IgnoreFields(internal.VersionMap{}, "UpdatedAt"),
+		cmpopts.SortSlices(func(a, b *internal.VersionMap) bool { return a.ModulePath < b.ModulePath })); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestStaleNamedVersionMaps(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer ResetTestDB(testDB, t)
+
+	for _, m := range []*internal.Module{
+		sample.LegacyModule("viewed.com/m", "v1.0.0", "p"),
+		sample.LegacyModule("unviewed.com/m", "v1.0.0", "p"),
+	} {
+		if err := testDB.InsertModule(ctx, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := testDB.RecordModuleView(ctx, "viewed.com/m", "v1.0.0"); err != nil {
+		t.Fatal(err)
+	}
+	for _, vm := range []*internal.VersionMap{
+		{ModulePath: "viewed.com/m", RequestedVersion: "master", ResolvedVersion: "v1.0.0", Status: 200},
+		{ModulePath: "viewed.com/m", RequestedVersion: "v1.0.0", ResolvedVersion: "v1.0.0", Status: 200},
+		{ModulePath: "viewed.com/m", RequestedVersion: "dev", ResolvedVersion: "v1.1.0", Status: 200},
+		{ModulePath: "unviewed.com/m", RequestedVersion: "master", ResolvedVersion: "v1.0.0", Status: 200},
+	} {
+		if err := testDB.UpsertVersionMap(ctx, vm); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// viewed.com/m@dev resolves to a version that isn't stored.
+	n, err := testDB.DeleteDanglingVersionMaps(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("DeleteDanglingVersionMaps: deleted %d entries, want 1", n)
+	}
+	if _, err := testDB.GetVersionMap(ctx, "viewed.com/m", "dev"); !errors.Is(err, derrors.NotFound) {
+		t.Errorf("GetVersionMap of dangling entry: got %v, want NotFound", err)
+	}
+
+	now := time.Now()
+	dayAgo := now.Add(-24 * time.Hour)
+	get := func() []string {
+		t.Helper()
+		vms, err := testDB.GetStaleNamedVersionMaps(ctx, now.Add(time.Minute), dayAgo, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, vm := range vms {
+			got = append(got, vm.ModulePath+"@"+vm.RequestedVersion)
+		}
+		return got
+	}
+	if got, want := get(), []string{"viewed.com/m@master"}; !cmp.Equal(got, want) {
+		t.Errorf("GetStaleNamedVersionMaps = %v, want %v", got, want)
+	}
+	vms, err := testDB.GetStaleNamedVersionMaps(ctx, dayAgo, dayAgo, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vms) != 0 {
+		t.Errorf("GetStaleNamedVersionMaps before a day ago: got %d entries, want none", len(vms))
+	}
+
+	if err := testDB.TouchVersionMap(ctx, "viewed.com/m", "master"); err != nil {
+		t.Fatal(err)
+	}
+	if err := testDB.TouchVersionMap(ctx, "viewed.com/m", "dev"); !errors.Is(err, derrors.NotFound) {
+		t.Errorf("TouchVersionMap of missing entry: got %v, want NotFound", err)
+	}
+}
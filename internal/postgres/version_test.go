@@ -26,6 +26,7 @@ func TestGetVersions(t *testing.T) {
 		taggedAndPseudoModule = "path.to/foo"
 		taggedModuleV2        = "path.to/foo/v2"
 		taggedModuleV3        = "path.to/foo/v3"
+		taggedModuleV10       = "path.to/foo/v10"
 		pseudoModule          = "golang.org/x/tools"
 		otherModule           = "path.to/other"
 		incompatibleModule    = "path.to/incompatible"
@@ -34,6 +35,7 @@ func TestGetVersions(t *testing.T) {
 		testModules           = []*internal.Module{
 			sample.LegacyModule(stdlib.ModulePath, "v1.15.0-beta.1", "cmd/go"),
 			sample.LegacyModule(stdlib.ModulePath, "v1.14.6", "cmd/go"),
+			sample.LegacyModule(taggedModuleV10, "v10.0.0", "bar"),
 			sample.LegacyModule(taggedModuleV3, "v3.2.0-beta", "bar"),
 			sample.LegacyModule(taggedModuleV3, "v3.2.0-alpha.2", "bar"),
 			sample.LegacyModule(taggedModuleV3, "v3.2.0-alpha.1", "bar"),
@@ -78,6 +80,10 @@ func TestGetVersions(t *testing.T) {
 	}
 
 	fooModuleVersions := []*internal.ModuleInfo{
+		{
+			ModulePath: taggedModuleV10,
+			Version:    "v10.0.0",
+		},
 		{
 			ModulePath: taggedModuleV3,
 			Version:    "v3.2.0-beta",
@@ -279,3 +285,45 @@ func TestGetLatestMajorVersion(t *testing.T) {
 		}
 	}
 }
+
+func TestGetModuleFeed(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	defer ResetTestDB(testDB, t)
+	const modulePath = "example.com/feed"
+	for _, m := range []*internal.Module{
+		sample.LegacyModule(modulePath, "v1.0.0", ""),
+		sample.LegacyModule(modulePath, "v1.1.0", sample.Suffix),
+		sample.LegacyModule(modulePath, "v0.0.0-20200101120000-000000000000", ""),
+		sample.LegacyModule(modulePath, "v1.2.0-pre", ""),
+		sample.LegacyModule("example.com/other", "v1.3.0", ""),
+	} {
+		if err := testDB.InsertModule(ctx, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, test := range []struct {
+		limit int
+		want  []string
+	}{
+		{10, []string{"v1.2.0-pre " + sample.Synopsis, "v1.1.0 ", "v1.0.0 " + sample.Synopsis}},
+		{1, []string{"v1.2.0-pre " + sample.Synopsis}},
+	} {
+		entries, err := testDB.GetModuleFeed(ctx, modulePath, test.limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, e := range entries {
+			if e.ModulePath != modulePath || e.CommitTime.IsZero() || e.IndexedAt.IsZero() {
+				t.Errorf("unexpected entry %+v", e)
+			}
+			got = append(got, e.Version+" "+e.Synopsis)
+		}
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("limit %d: mismatch (-want, +got):\n%s", test.limit, diff)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// UpsertWorkerLoad records the current load of a worker instance.
+func (db *DB) UpsertWorkerLoad(ctx context.Context, load *internal.WorkerLoad) (err error) {
+	defer derrors.Wrap(&err, "DB.UpsertWorkerLoad(ctx, %q)", load.InstanceID)
+
+	_, err = db.db.Exec(ctx, `
+		INSERT INTO worker_load (
+			instance_id, fetches_in_flight, queue_depth, memory_used, memory_limit, overloaded, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, CURRENT_TIMESTAMP)
+		ON CONFLICT (instance_id) DO UPDATE SET
+			fetches_in_flight = excluded.fetches_in_flight,
+			queue_depth = excluded.queue_depth,
+			memory_used = excluded.memory_used,
+			memory_limit = excluded.memory_limit,
+			overloaded = excluded.overloaded,
+			updated_at = excluded.updated_at`,
+		load.InstanceID, load.FetchesInFlight, load.QueueDepth, int64(load.MemoryUsed), int64(load.MemoryLimit), load.Overloaded)
+	return err
+}
+
+// WorkersOverloaded reports whether every worker instance that has reported
+// its load within maxAge says that it is overloaded. It returns false if no
+// worker has reported within maxAge, so that stale reports from instances
+// that have shut down are ignored.
+func (db *DB) WorkersOverloaded(ctx context.Context, maxAge time.Duration) (_ bool, err error) {
+	defer derrors.Wrap(&err, "DB.WorkersOverloaded(ctx, %s)", maxAge)
+
+	var total, overloaded int
+	err = db.db.QueryRow(ctx, `
+		SELECT COUNT(*), COUNT(*) FILTER (WHERE overloaded)
+		FROM worker_load
+		WHERE updated_at > CURRENT_TIMESTAMP - make_interval(secs => $1)`,
+		maxAge.Seconds()).Scan(&total, &overloaded)
+	if err != nil {
+		return false, err
+	}
+	return total > 0 && overloaded == total, nil
+}
@@ -0,0 +1,50 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"golang.org/x/pkgsite/internal"
+)
+
+func TestWorkersOverloaded(t *testing.T) {
+	defer ResetTestDB(testDB, t)
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	check := func(want bool) {
+		t.Helper()
+		got, err := testDB.WorkersOverloaded(ctx, time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("got %t, want %t", got, want)
+		}
+	}
+	upsert := func(id string, overloaded bool) {
+		t.Helper()
+		if err := testDB.UpsertWorkerLoad(ctx, &internal.WorkerLoad{InstanceID: id, Overloaded: overloaded}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// No reports.
+	check(false)
+	upsert("a", true)
+	check(true)
+	upsert("b", false)
+	check(false)
+	upsert("b", true)
+	check(true)
+	// Stale reports are ignored.
+	if _, err := testDB.db.Exec(ctx, `UPDATE worker_load SET updated_at = updated_at - interval '1 hour'`); err != nil {
+		t.Fatal(err)
+	}
+	check(false)
+}
@@ -0,0 +1,168 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package preferences
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/mod/module"
+	"golang.org/x/pkgsite/internal/stdlib"
+)
+
+// Editors are the values that Preferences.Editor may have, other than the
+// empty string, which means that no editor links are shown.
+var Editors = []string{"vscode", "jetbrains", "custom"}
+
+// editorURLTemplates are the URL templates of the editors other than
+// "custom", whose template is Preferences.EditorURL.
+var editorURLTemplates = map[string]string{
+	"vscode":    "vscode://file{path}:{line}",
+	"jetbrains": "jetbrains://goland/navigate/reference?project={project}&path={file}:{line}",
+}
+
+const (
+	maxModuleRootLen = 512
+	maxEditorURLLen  = 1024
+)
+
+var (
+	// windowsRootRx matches the start of an absolute Windows path.
+	windowsRootRx = regexp.MustCompile(`^[A-Za-z]:[/\\]`)
+	// editorSchemeRx matches the scheme of a custom editor URL template.
+	editorSchemeRx = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9+.-]*):`)
+)
+
+// unsafeSchemes are the URL schemes that custom editor URL templates may not
+// use, because browsers run or display their content.
+var unsafeSchemes = map[string]bool{
+	"javascript": true,
+	"data":       true,
+	"vbscript":   true,
+	"blob":       true,
+	"file":       true,
+}
+
+// validateEditor returns an error if the editor preferences of p are
+// invalid.
+func (p *Preferences) validateEditor() error {
+	if p.Editor == "" {
+		if p.EditorURL != "" || p.ModuleRoot != "" {
+			return fmt.Errorf("editor URL and module root need an editor")
+		}
+		return nil
+	}
+	valid := false
+	for _, e := range Editors {
+		if p.Editor == e {
+			valid = true
+		}
+	}
+	if !valid {
+		return fmt.Errorf("invalid editor %q", p.Editor)
+	}
+	if p.ModuleRoot == "" {
+		return fmt.Errorf("editor %q needs a module root", p.Editor)
+	}
+	if len(p.ModuleRoot) > maxModuleRootLen || hasControlChars(p.ModuleRoot) ||
+		!(strings.HasPrefix(p.ModuleRoot, "/") || windowsRootRx.MatchString(p.ModuleRoot)) {
+		return fmt.Errorf("invalid module root %q: must be an absolute path", p.ModuleRoot)
+	}
+	if p.Editor != "custom" {
+		if p.EditorURL != "" {
+			return fmt.Errorf("editor URL is only used by the custom editor")
+		}
+		return nil
+	}
+	if p.EditorURL == "" {
+		return fmt.Errorf("custom editor needs a URL")
+	}
+	if len(p.EditorURL) > maxEditorURLLen || hasControlChars(p.EditorURL) {
+		return fmt.Errorf("invalid editor URL %q", p.EditorURL)
+	}
+	m := editorSchemeRx.FindStringSubmatch(p.EditorURL)
+	if m == nil || unsafeSchemes[strings.ToLower(m[1])] {
+		return fmt.Errorf("invalid editor URL %q: needs a scheme like vscode: or http:", p.EditorURL)
+	}
+	if !strings.Contains(p.EditorURL, "{path}") && !strings.Contains(p.EditorURL, "{file}") {
+		return fmt.Errorf("invalid editor URL %q: needs {path} or {file}", p.EditorURL)
+	}
+	return nil
+}
+
+func hasControlChars(s string) bool {
+	return strings.IndexFunc(s, func(r rune) bool { return r < ' ' || r == 0x7f }) >= 0
+}
+
+// EditorLink returns the URL that opens the given line of a file of a module
+// version in the preferred editor, or the empty string if there is no
+// preferred editor. file is the path of the file relative to the module
+// root. The file is expected in ModuleRoot with the layout of the module
+// cache, as in $GOPATH/pkg/mod, so there are no links to files of the
+// standard library.
+//
+// The URL template of the editor may contain these placeholders:
+//
+//	{path}     the absolute path of the file, with a leading slash
+//	{dir}      the absolute path of the module directory, with a leading slash
+//	{project}  the name of the module directory, like "errors@v0.9.1"
+//	{file}     the path of the file relative to the module directory
+//	{line}     the line number
+//	{module}   the module path
+//	{version}  the module version
+//
+// Paths use forward slashes and are escaped for use in URLs.
+func (p *Preferences) EditorLink(modulePath, version, file string, line int) string {
+	if p.Editor == "" || modulePath == stdlib.ModulePath {
+		return ""
+	}
+	tmpl := editorURLTemplates[p.Editor]
+	if p.Editor == "custom" {
+		tmpl = p.EditorURL
+	}
+	if tmpl == "" {
+		return ""
+	}
+	escPath, err := module.EscapePath(modulePath)
+	if err != nil {
+		return ""
+	}
+	escVersion, err := module.EscapeVersion(version)
+	if err != nil {
+		return ""
+	}
+	if line < 1 {
+		line = 1
+	}
+	root := strings.TrimRight(strings.ReplaceAll(p.ModuleRoot, `\`, "/"), "/")
+	if !strings.HasPrefix(root, "/") {
+		root = "/" + root
+	}
+	project := path.Base(escPath) + "@" + escVersion
+	dir := root + "/" + escPath + "@" + escVersion
+	return strings.NewReplacer(
+		"{path}", escapeFilePath(path.Join(dir, file)),
+		"{dir}", escapeFilePath(dir),
+		"{project}", url.PathEscape(project),
+		"{file}", escapeFilePath(file),
+		"{line}", strconv.Itoa(line),
+		"{module}", escapeFilePath(modulePath),
+		"{version}", url.PathEscape(version),
+	).Replace(tmpl)
+}
+
+// escapeFilePath escapes each element of the slash-separated path p for use
+// in a URL.
+func escapeFilePath(p string) string {
+	parts := strings.Split(p, "/")
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+	return strings.Join(parts, "/")
+}
@@ -0,0 +1,124 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package preferences holds the display preferences of signed-in users.
+//
+// On deployments behind an authenticating proxy, the frontend stores the
+// preferences of each user in the database, so that they follow the user
+// across devices, and applies them when rendering pages.
+package preferences
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+)
+
+// Themes are the values that Preferences.Theme may have, other than the
+// empty string, which means the default theme.
+var Themes = []string{"light", "dark"}
+
+// Preferences are the display preferences of a user. The zero value is the
+// default display.
+type Preferences struct {
+	// GOOS and GOARCH are the build context in which the user prefers to read
+	// documentation. Either both or neither are set.
+	GOOS   string `json:"goos"`
+	GOARCH string `json:"goarch"`
+
+	// Theme is one of Themes, or empty for the default theme.
+	Theme string `json:"theme"`
+
+	// ClassicLayout is whether to show pages in the layout of the
+	// package, module and directory pages instead of the unit page.
+	ClassicLayout bool `json:"classic_layout"`
+
+	// HideInternal is whether to leave internal packages out of the
+	// directories listed on a page.
+	HideInternal bool `json:"hide_internal"`
+
+	// Editor is one of Editors, or empty for no "open in editor" links.
+	// ModuleRoot is the local directory that holds the modules opened in
+	// the editor, and EditorURL is the URL template of the custom editor.
+	// See EditorLink.
+	Editor     string `json:"editor"`
+	EditorURL  string `json:"editor_url"`
+	ModuleRoot string `json:"module_root"`
+}
+
+var buildContextRx = regexp.MustCompile(`^[a-z0-9]{1,32}$`)
+
+// Validate returns an error if p has invalid values.
+func (p *Preferences) Validate() error {
+	if p.Theme != "" {
+		valid := false
+		for _, t := range Themes {
+			if p.Theme == t {
+				valid = true
+			}
+		}
+		if !valid {
+			return fmt.Errorf("invalid theme %q", p.Theme)
+		}
+	}
+	if (p.GOOS == "") != (p.GOARCH == "") {
+		return fmt.Errorf("GOOS and GOARCH must be set together")
+	}
+	if p.GOOS != "" && (!buildContextRx.MatchString(p.GOOS) || !buildContextRx.MatchString(p.GOARCH)) {
+		return fmt.Errorf("invalid build context %s/%s", p.GOOS, p.GOARCH)
+	}
+	return p.validateEditor()
+}
+
+// BuildContext returns the preferred build context as GOOS/GOARCH, or the
+// empty string if there is none.
+func (p *Preferences) BuildContext() string {
+	if p.GOOS == "" {
+		return ""
+	}
+	return p.GOOS + "/" + p.GOARCH
+}
+
+// CacheKey returns a string that identifies the way pages are rendered for
+// p. It is empty for the default preferences, so that their pages are cached
+// like those of users who are not signed in.
+func (p *Preferences) CacheKey() string {
+	if *p == (Preferences{}) {
+		return ""
+	}
+	return fmt.Sprintf("%s,%s,%t,%t,%s,%q,%q", p.BuildContext(), p.Theme, p.ClassicLayout, p.HideInternal,
+		p.Editor, p.EditorURL, p.ModuleRoot)
+}
+
+type contextKey struct{}
+
+// user is the value stored in a context by NewContext.
+type user struct {
+	id    string
+	prefs *Preferences
+}
+
+// NewContext returns a context that holds the ID of the signed-in user and
+// their preferences.
+func NewContext(ctx context.Context, userID string, p *Preferences) context.Context {
+	return context.WithValue(ctx, contextKey{}, &user{id: userID, prefs: p})
+}
+
+// FromContext returns the preferences stored in ctx by NewContext, or the
+// default preferences if there are none.
+func FromContext(ctx context.Context) *Preferences {
+	if u, ok := ctx.Value(contextKey{}).(*user); ok && u.prefs != nil {
+		return u.prefs
+	}
+	return &Preferences{}
+}
+
+// UserFromContext returns the ID of the user stored in ctx by NewContext, or
+// the empty string if no user is signed in.
+func UserFromContext(ctx context.Context) string {
+	if u, ok := ctx.Value(contextKey{}).(*user); ok {
+		return u.id
+	}
+	return ""
+}
@@ -0,0 +1,109 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package preferences
+
+import (
+	"context"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	for _, test := range []struct {
+		prefs Preferences
+		ok    bool
+	}{
+		{Preferences{}, true},
+		{Preferences{GOOS: "windows", GOARCH: "amd64", Theme: "dark", ClassicLayout: true}, true},
+		{Preferences{Theme: "light"}, true},
+		{Preferences{Theme: "pink"}, false},
+		{Preferences{GOOS: "linux"}, false},
+		{Preferences{GOOS: "linux", GOARCH: "amd64 x"}, false},
+		{Preferences{Editor: "vscode", ModuleRoot: "/home/gopher/go/pkg/mod"}, true},
+		{Preferences{Editor: "vscode", ModuleRoot: `C:\Users\gopher\go\pkg\mod`}, true},
+		{Preferences{Editor: "vscode"}, false},
+		{Preferences{Editor: "vscode", ModuleRoot: "go/pkg/mod"}, false},
+		{Preferences{Editor: "emacs", ModuleRoot: "/mod"}, false},
+		{Preferences{ModuleRoot: "/mod"}, false},
+		{Preferences{Editor: "jetbrains", ModuleRoot: "/mod", EditorURL: "x://{path}"}, false},
+		{Preferences{Editor: "custom", ModuleRoot: "/mod", EditorURL: "subl://open?url=file://{path}&line={line}"}, true},
+		{Preferences{Editor: "custom", ModuleRoot: "/mod"}, false},
+		{Preferences{Editor: "custom", ModuleRoot: "/mod", EditorURL: "subl://open"}, false},
+		{Preferences{Editor: "custom", ModuleRoot: "/mod", EditorURL: "javascript:alert({path})"}, false},
+		{Preferences{Editor: "custom", ModuleRoot: "/mod", EditorURL: "{path}"}, false},
+	} {
+		err := test.prefs.Validate()
+		if got := err == nil; got != test.ok {
+			t.Errorf("%+v: got error %v, want ok = %t", test.prefs, err, test.ok)
+		}
+	}
+}
+
+func TestCacheKey(t *testing.T) {
+	if got := (&Preferences{}).CacheKey(); got != "" {
+		t.Errorf("default CacheKey() = %q, want empty", got)
+	}
+	a := &Preferences{Theme: "dark"}
+	b := &Preferences{Theme: "dark", HideInternal: true}
+	if a.CacheKey() == b.CacheKey() {
+		t.Errorf("CacheKey() = %q for both %+v and %+v", a.CacheKey(), a, b)
+	}
+}
+
+func TestEditorLink(t *testing.T) {
+	const (
+		modulePath = "github.com/Masterminds/semver"
+		version    = "v1.5.0"
+		file       = "internal/a b.go"
+		line       = 42
+	)
+	for _, test := range []struct {
+		prefs Preferences
+		want  string
+	}{
+		{Preferences{}, ""},
+		{
+			Preferences{Editor: "vscode", ModuleRoot: "/home/gopher/go/pkg/mod/"},
+			"vscode://file/home/gopher/go/pkg/mod/github.com/%21masterminds/semver@v1.5.0/internal/a%20b.go:42",
+		},
+		{
+			Preferences{Editor: "vscode", ModuleRoot: `C:\mod`},
+			"vscode://file/C:/mod/github.com/%21masterminds/semver@v1.5.0/internal/a%20b.go:42",
+		},
+		{
+			Preferences{Editor: "jetbrains", ModuleRoot: "/mod"},
+			"jetbrains://goland/navigate/reference?project=semver@v1.5.0&path=internal/a%20b.go:42",
+		},
+		{
+			Preferences{Editor: "custom", ModuleRoot: "/mod", EditorURL: "https://ide.example.com/open?dir={dir}&file={file}&line={line}&m={module}&v={version}"},
+			"https://ide.example.com/open?dir=/mod/github.com/%21masterminds/semver@v1.5.0&file=internal/a%20b.go&line=42&m=github.com/Masterminds/semver&v=v1.5.0",
+		},
+	} {
+		if got := test.prefs.EditorLink(modulePath, version, file, line); got != test.want {
+			t.Errorf("%+v: EditorLink = %q, want %q", test.prefs, got, test.want)
+		}
+	}
+	p := &Preferences{Editor: "vscode", ModuleRoot: "/mod"}
+	if got := p.EditorLink("std", "v1.15.0", "fmt/print.go", 1); got != "" {
+		t.Errorf("EditorLink for the standard library = %q, want empty", got)
+	}
+}
+
+func TestContext(t *testing.T) {
+	ctx := context.Background()
+	if got := FromContext(ctx); *got != (Preferences{}) {
+		t.Errorf("FromContext(empty) = %+v, want default", got)
+	}
+	if got := UserFromContext(ctx); got != "" {
+		t.Errorf("UserFromContext(empty) = %q, want empty", got)
+	}
+	p := &Preferences{Theme: "dark"}
+	ctx = NewContext(ctx, "gopher@example.com", p)
+	if got := FromContext(ctx); got != p {
+		t.Errorf("FromContext = %+v, want %+v", got, p)
+	}
+	if got, want := UserFromContext(ctx), "gopher@example.com"; got != want {
+		t.Errorf("UserFromContext = %q, want %q", got, want)
+	}
+}
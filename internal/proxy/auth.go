@@ -0,0 +1,145 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Credentials are used to authenticate requests to a private proxy, such as
+// an Artifactory or Athens instance.
+type Credentials struct {
+	// Username and Password are sent using basic authentication.
+	Username, Password string
+
+	// Token is sent as a bearer token. If set, Username and Password are
+	// ignored.
+	Token string
+}
+
+// authorize adds credentials to req, if there are any.
+func (c *Credentials) authorize(req *http.Request) {
+	switch {
+	case c == nil:
+	case c.Token != "":
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	case c.Username != "" || c.Password != "":
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+}
+
+// splitCredentials removes any user information from the proxy URL u,
+// returning the URL without it and the credentials it held, or nil if there
+// were none.
+func splitCredentials(u *url.URL) (*url.URL, *Credentials) {
+	if u.User == nil {
+		return u, nil
+	}
+	password, _ := u.User.Password()
+	creds := &Credentials{Username: u.User.Username(), Password: password}
+	u2 := *u
+	u2.User = nil
+	return &u2, creds
+}
+
+// redactURL returns rawURL with any password replaced, so that it can be
+// logged or included in errors. If rawURL cannot be parsed, it is replaced
+// entirely, since it may still contain credentials.
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "<unparseable URL>"
+	}
+	if u.User == nil {
+		return rawURL
+	}
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), "xxxxx")
+	}
+	return u.String()
+}
+
+// netrcCredentials returns the credentials for host in the user's .netrc
+// file, or nil if there are none. As with the go command, the file is named
+// by $NETRC, or else is .netrc (_netrc on Windows) in the home directory.
+func netrcCredentials(host string) *Credentials {
+	path := os.Getenv("NETRC")
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil
+		}
+		name := ".netrc"
+		if runtime.GOOS == "windows" {
+			name = "_netrc"
+		}
+		path = filepath.Join(home, name)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	return parseNetrc(data, host)
+}
+
+// parseNetrc returns the login and password for the machine named host in
+// the netrc file contents data, or nil if there is no such machine. Entries
+// for the "default" machine and macro definitions are ignored.
+func parseNetrc(data []byte, host string) *Credentials {
+	var (
+		creds   *Credentials
+		current *Credentials
+		inMacro bool
+	)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if inMacro {
+			// A macro definition ends at a blank line.
+			inMacro = line != ""
+			continue
+		}
+		fields := strings.Fields(line)
+		for i := 0; i < len(fields); i++ {
+			var next string
+			if i+1 < len(fields) {
+				next = fields[i+1]
+			}
+			switch fields[i] {
+			case "machine":
+				current = nil
+				if next == host && creds == nil {
+					creds = &Credentials{}
+					current = creds
+				}
+				i++
+			case "default":
+				current = nil
+			case "login":
+				if current != nil {
+					current.Username = next
+				}
+				i++
+			case "password":
+				if current != nil {
+					current.Password = next
+				}
+				i++
+			case "macdef":
+				inMacro = true
+				i = len(fields)
+			}
+		}
+	}
+	return creds
+}
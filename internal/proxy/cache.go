@@ -0,0 +1,93 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package proxy
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// defaultCacheTTL is how long the results of @latest requests are
+	// cached. It is short, because new versions can be published at any
+	// time.
+	defaultCacheTTL = time.Minute
+
+	// validatorCacheTTL is how long the bodies of .info responses are kept
+	// along with their validators (ETag and Last-Modified headers), so that
+	// they can be requested conditionally. It is long, because the server
+	// checks that they are still current.
+	validatorCacheTTL = 24 * time.Hour
+
+	// maxCacheEntries bounds the memory used by a cache.
+	maxCacheEntries = 10000
+)
+
+// A responseCache is an in-memory cache of proxy responses whose entries
+// expire after a fixed duration.
+type responseCache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+// A validatedResponse is the body of a response along with the validators
+// needed to make a conditional request for it.
+type validatedResponse struct {
+	etag         string
+	lastModified string
+	body         []byte
+}
+
+type cacheEntry struct {
+	value   interface{}
+	expires time.Time
+}
+
+func newResponseCache(ttl time.Duration) *responseCache {
+	return &responseCache{ttl: ttl, entries: map[string]cacheEntry{}}
+}
+
+// get returns the unexpired value for key, if any. A nil *responseCache
+// caches nothing.
+func (c *responseCache) get(key string) (interface{}, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(e.expires) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return e.value, true
+}
+
+// put caches value for key.
+func (c *responseCache) put(key string, value interface{}) {
+	if c == nil || c.ttl <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	if len(c.entries) >= maxCacheEntries {
+		for k, e := range c.entries {
+			if now.After(e.expires) {
+				delete(c.entries, k)
+			}
+		}
+		if len(c.entries) >= maxCacheEntries {
+			// Everything is recent; start over rather than track usage.
+			c.entries = map[string]cacheEntry{}
+		}
+	}
+	c.entries[key] = cacheEntry{value: value, expires: now.Add(c.ttl)}
+}
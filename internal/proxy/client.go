@@ -16,6 +16,7 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 
@@ -24,6 +25,7 @@ import (
 	"golang.org/x/net/context/ctxhttp"
 	"golang.org/x/pkgsite/internal"
 	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/requestid"
 )
 
 // A Client is used by the fetch service to communicate with a module
@@ -34,6 +36,16 @@ type Client struct {
 
 	// client used for HTTP requests. It is mutable for testing purposes.
 	httpClient *http.Client
+
+	// cache holds the results of GetLatestInfo.
+	cache *responseCache
+
+	// validators holds .info responses by URL, so that they can be
+	// requested again with If-None-Match and If-Modified-Since.
+	validators *responseCache
+
+	// credentials, if non-nil, are added to every request to the proxy.
+	credentials *Credentials
 }
 
 // A VersionInfo contains metadata about a given version of a module.
@@ -44,11 +56,35 @@ type VersionInfo struct {
 
 // New constructs a *Client using the provided url, which is expected to
 // be an absolute URI that can be directly passed to http.Get.
+//
+// If the URL contains a username and password, they are used to
+// authenticate to the proxy. Otherwise, credentials are taken from the
+// user's .netrc file, if it has an entry for the proxy's host.
 func New(u string) (_ *Client, err error) {
-	defer derrors.Wrap(&err, "proxy.New(%q)", u)
+	return NewWithCredentials(u, nil)
+}
+
+// NewWithCredentials is like New, but uses creds to authenticate to the
+// proxy. If creds is nil, it behaves exactly like New.
+func NewWithCredentials(u string, creds *Credentials) (_ *Client, err error) {
+	defer derrors.Wrap(&err, "proxy.NewWithCredentials(%q)", redactURL(u))
+	pu, err := url.Parse(u)
+	if err != nil {
+		return nil, fmt.Errorf("url.Parse: %v: %w", err, derrors.InvalidArgument)
+	}
+	pu, urlCreds := splitCredentials(pu)
+	if creds == nil {
+		creds = urlCreds
+	}
+	if creds == nil {
+		creds = netrcCredentials(pu.Hostname())
+	}
 	return &Client{
-		url:        strings.TrimRight(u, "/"),
-		httpClient: &http.Client{Transport: &ochttp.Transport{}},
+		url:         strings.TrimRight(pu.String(), "/"),
+		httpClient:  &http.Client{Transport: &requestid.Transport{Base: &ochttp.Transport{}}},
+		cache:       newResponseCache(defaultCacheTTL),
+		validators:  newResponseCache(validatorCacheTTL),
+		credentials: creds,
 	}, nil
 }
 
@@ -81,7 +117,12 @@ func (c *Client) GetMod(ctx context.Context, modulePath, resolvedVersion string)
 func (c *Client) GetZip(ctx context.Context, modulePath, resolvedVersion string) (_ *zip.Reader, err error) {
 	defer derrors.Wrap(&err, "proxy.Client.GetZip(ctx, %q, %q)", modulePath, resolvedVersion)
 
+	done, err := startZipDownload(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("waiting to download zip: %v: %w", err, derrors.ProxyTimedOut)
+	}
 	bodyBytes, err := c.readBody(ctx, modulePath, resolvedVersion, "zip")
+	done()
 	if err != nil {
 		return nil, err
 	}
@@ -101,11 +142,23 @@ func (c *Client) GetZipSize(ctx context.Context, modulePath, resolvedVersion str
 	if err != nil {
 		return 0, err
 	}
-	res, err := ctxhttp.Head(ctx, c.httpClient, url)
+	req, err := c.newRequest(http.MethodHead, url)
 	if err != nil {
-		return 0, fmt.Errorf("ctxhttp.Head(ctx, client, %q): %v", url, err)
+		return 0, err
+	}
+	done, err := startRequest(ctx, url)
+	if err != nil {
+		return 0, fmt.Errorf("waiting for rate limit: %v: %w", err, derrors.ProxyTimedOut)
+	}
+	defer done()
+	start := time.Now()
+	res, err := ctxhttp.Do(ctx, c.httpClient, req)
+	if err != nil {
+		recordRequest(ctx, url, endpointZipSize, start, 0)
+		return 0, fmt.Errorf("ctxhttp.Do(ctx, client, HEAD %q): %v", url, err)
 	}
 	defer res.Body.Close()
+	recordRequest(ctx, url, endpointZipSize, start, res.StatusCode)
 	if err := responseError(res); err != nil {
 		return 0, err
 	}
@@ -147,8 +200,12 @@ func (c *Client) readBody(ctx context.Context, modulePath, requestedVersion, suf
 	if err != nil {
 		return nil, err
 	}
+	endpoint := suffix
+	if requestedVersion == internal.LatestVersion {
+		endpoint = endpointLatest
+	}
 	var data []byte
-	err = c.executeRequest(ctx, u, func(body io.Reader) error {
+	err = c.executeRequest(ctx, u, endpoint, func(body io.Reader, _ int64, _ http.Header) error {
 		var err error
 		data, err = ioutil.ReadAll(body)
 		return err
@@ -159,6 +216,25 @@ func (c *Client) readBody(ctx context.Context, modulePath, requestedVersion, suf
 	return data, nil
 }
 
+// GetLatestInfo makes a request to $GOPROXY/<module>/@latest and transforms
+// that data into a *VersionInfo. Unlike GetInfo, results are cached for a
+// short time.
+func (c *Client) GetLatestInfo(ctx context.Context, modulePath string) (_ *VersionInfo, err error) {
+	defer derrors.Wrap(&err, "proxy.Client.GetLatestInfo(%q)", modulePath)
+	key := "latest:" + modulePath
+	if v, ok := c.cache.get(key); ok {
+		info := *v.(*VersionInfo)
+		return &info, nil
+	}
+	info, err := c.GetInfo(ctx, modulePath, internal.LatestVersion)
+	if err != nil {
+		return nil, err
+	}
+	cached := *info
+	c.cache.put(key, &cached)
+	return info, nil
+}
+
 // ListVersions makes a request to $GOPROXY/<path>/@v/list and returns the
 // resulting version strings.
 func (c *Client) ListVersions(ctx context.Context, modulePath string) ([]string, error) {
@@ -168,37 +244,91 @@ func (c *Client) ListVersions(ctx context.Context, modulePath string) ([]string,
 	}
 	u := fmt.Sprintf("%s/%s/@v/list", c.url, escapedPath)
 	var versions []string
-	collect := func(body io.Reader) error {
+	collect := func(body io.Reader, _ int64, _ http.Header) error {
 		scanner := bufio.NewScanner(body)
 		for scanner.Scan() {
 			versions = append(versions, scanner.Text())
 		}
 		return scanner.Err()
 	}
-	if err := c.executeRequest(ctx, u, collect); err != nil {
+	if err := c.executeRequest(ctx, u, endpointList, collect); err != nil {
 		return nil, err
 	}
 	return versions, nil
 }
 
 // executeRequest executes an HTTP GET request for u, then calls the bodyFunc
-// on the response body, if no error occurred.
-func (c *Client) executeRequest(ctx context.Context, u string, bodyFunc func(body io.Reader) error) (err error) {
+// on the response body, its size (-1 if unknown) and the response header, if
+// no error occurred. The request is recorded in the proxy metrics under the
+// given endpoint.
+//
+// Requests for .info files are made conditionally if a previous response had
+// an ETag or Last-Modified header, and the previous body is reused if the
+// proxy says it has not changed.
+func (c *Client) executeRequest(ctx context.Context, u, endpoint string, bodyFunc func(body io.Reader, size int64, header http.Header) error) (err error) {
 	defer func() {
 		if ctx.Err() != nil {
 			err = fmt.Errorf("%v: %w", err, derrors.ProxyTimedOut)
 		}
 		derrors.Wrap(&err, "executeRequest(ctx, %q)", u)
 	}()
-	r, err := ctxhttp.Get(ctx, c.httpClient, u)
+	req, err := c.newRequest(http.MethodGet, u)
+	if err != nil {
+		return err
+	}
+	conditional := endpoint == endpointInfo || endpoint == endpointLatest
+	var cached *validatedResponse
+	if conditional {
+		if v, ok := c.validators.get(u); ok {
+			cached = v.(*validatedResponse)
+			if cached.etag != "" {
+				req.Header.Set("If-None-Match", cached.etag)
+			}
+			if cached.lastModified != "" {
+				req.Header.Set("If-Modified-Since", cached.lastModified)
+			}
+		}
+	}
+	done, err := startRequest(ctx, u)
 	if err != nil {
-		return fmt.Errorf("ctxhttp.Get(ctx, client, %q): %v", u, err)
+		return fmt.Errorf("waiting for rate limit: %v: %w", err, derrors.ProxyTimedOut)
+	}
+	defer done()
+	start := time.Now()
+	r, err := ctxhttp.Do(ctx, c.httpClient, req)
+	if err != nil {
+		recordRequest(ctx, u, endpoint, start, 0)
+		return fmt.Errorf("ctxhttp.Do(ctx, client, GET %q): %v", u, err)
 	}
 	defer r.Body.Close()
+	recordRequest(ctx, u, endpoint, start, r.StatusCode)
+	if r.StatusCode == http.StatusNotModified && cached != nil {
+		return bodyFunc(bytes.NewReader(cached.body), int64(len(cached.body)), r.Header)
+	}
 	if err := responseError(r); err != nil {
 		return err
 	}
-	return bodyFunc(r.Body)
+	etag, lastModified := r.Header.Get("ETag"), r.Header.Get("Last-Modified")
+	if !conditional || (etag == "" && lastModified == "") {
+		return bodyFunc(r.Body, r.ContentLength, r.Header)
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	c.validators.put(u, &validatedResponse{etag: etag, lastModified: lastModified, body: body})
+	return bodyFunc(bytes.NewReader(body), int64(len(body)), r.Header)
+}
+
+// newRequest returns a request to the proxy, with the client's credentials
+// added.
+func (c *Client) newRequest(method, u string) (*http.Request, error) {
+	req, err := http.NewRequest(method, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	c.credentials.authorize(req)
+	return req, nil
 }
 
 // responseError translates the response status code to an appropriate error.
@@ -221,8 +351,26 @@ func responseError(r *http.Response) error {
 		if strings.Contains(d, "fetch timed out") {
 			return fmt.Errorf("%q: %w", d, derrors.ProxyTimedOut)
 		}
+		if r.StatusCode == http.StatusGone {
+			return fmt.Errorf("%q: %w", d, goneError{})
+		}
 		return fmt.Errorf("%q: %w", d, derrors.NotFound)
 	default:
 		return fmt.Errorf("unexpected status %d %s", r.StatusCode, r.Status)
 	}
 }
+
+// goneError is the error for a 410 Gone response from the proxy. It is also
+// a derrors.NotFound error, so callers that don't care about the difference
+// can treat it like a 404.
+type goneError struct{}
+
+func (goneError) Error() string { return "gone" }
+func (goneError) Unwrap() error { return derrors.NotFound }
+
+// IsGone reports whether err resulted from a 410 Gone response from the
+// proxy, which means that the proxy no longer serves the module version,
+// for example because it was removed at the request of its author.
+func IsGone(err error) bool {
+	return errors.As(err, &goneError{})
+}
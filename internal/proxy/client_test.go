@@ -6,14 +6,18 @@ package proxy
 
 import (
 	"context"
+	"crypto/sha256"
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"go.opencensus.io/stats/view"
 	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/dcensus"
 	"golang.org/x/pkgsite/internal/derrors"
 	"golang.org/x/pkgsite/internal/testing/sample"
 	"golang.org/x/pkgsite/internal/testing/testhelper"
@@ -84,6 +88,79 @@ func TestGetLatestInfo(t *testing.T) {
 	}
 }
 
+func TestCachedLatestInfo(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	s := NewServer([]*Module{{ModulePath: sample.ModulePath, Version: "v1.1.0"}})
+	client, teardownProxy, err := NewClientForServer(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer teardownProxy()
+
+	check := func(want string) {
+		t.Helper()
+		info, err := client.GetLatestInfo(ctx, sample.ModulePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Version != want {
+			t.Errorf("GetLatestInfo: got %q, want %q", info.Version, want)
+		}
+	}
+
+	check("v1.1.0")
+	s.AddModule(&Module{ModulePath: sample.ModulePath, Version: "v1.2.0"})
+	// The old result is still cached.
+	check("v1.1.0")
+	client.cache = newResponseCache(0)
+	check("v1.2.0")
+}
+
+func TestConditionalInfo(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	var requests, notModified int
+	version := "v1.0.0"
+	s := NewServer(nil)
+	s.AddRoute(fmt.Sprintf("/%s/@latest", sample.ModulePath), func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		etag := `"` + version + `"`
+		if r.Header.Get("If-None-Match") == etag {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		fmt.Fprintf(w, `{"Version": %q}`, version)
+	})
+	client, teardownProxy, err := NewClientForServer(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer teardownProxy()
+
+	check := func(want string) {
+		t.Helper()
+		info, err := client.GetInfo(ctx, sample.ModulePath, internal.LatestVersion)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Version != want {
+			t.Errorf("got version %q, want %q", info.Version, want)
+		}
+	}
+	check("v1.0.0")
+	check("v1.0.0")
+	version = "v1.1.0"
+	check("v1.1.0")
+	if requests != 3 || notModified != 1 {
+		t.Errorf("got %d requests and %d not modified, want 3 and 1", requests, notModified)
+	}
+}
+
 func TestListVersions(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
 	defer cancel()
@@ -173,6 +250,71 @@ func TestGetInfo_Errors(t *testing.T) {
 	}
 }
 
+func TestGetInfo_Gone(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	proxyServer := NewServer(nil)
+	proxyServer.AddRoute(
+		fmt.Sprintf("/%s/@v/%s.info", "module.com/gone", sample.VersionString),
+		func(w http.ResponseWriter, r *http.Request) { http.Error(w, "removed", http.StatusGone) })
+	client, teardownProxy, err := NewClientForServer(proxyServer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer teardownProxy()
+
+	_, err = client.GetInfo(ctx, "module.com/gone", sample.VersionString)
+	if !IsGone(err) || !errors.Is(err, derrors.NotFound) {
+		t.Errorf("got %v, want a gone error that is also NotFound", err)
+	}
+	_, err = client.GetInfo(ctx, sample.ModulePath, sample.VersionString)
+	if IsGone(err) {
+		t.Errorf("404 response: IsGone(%v) = true, want false", err)
+	}
+}
+
+func TestRequestMetrics(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	if err := view.Register(ProxyRequestCount); err != nil {
+		t.Fatal(err)
+	}
+	defer view.Unregister(ProxyRequestCount)
+
+	client, teardownProxy := SetupTestClient(t, []*Module{testModule})
+	defer teardownProxy()
+
+	if _, err := client.GetMod(ctx, sample.ModulePath, sample.VersionString); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.GetMod(ctx, sample.ModulePath, "v9.9.9"); !errors.Is(err, derrors.NotFound) {
+		t.Fatalf("got %v, want NotFound", err)
+	}
+	rows, err := view.RetrieveData(ProxyRequestCount.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]int64{}
+	for _, row := range rows {
+		var endpoint, status string
+		for _, tag := range row.Tags {
+			switch tag.Key {
+			case keyEndpoint:
+				endpoint = tag.Value
+			case dcensus.KeyStatus:
+				status = tag.Value
+			}
+		}
+		got[endpoint+" "+status] += row.Data.(*view.CountData).Value
+	}
+	want := map[string]int64{"mod 200": 1, "mod 404": 1}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+}
+
 func TestGetMod(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
 	defer cancel()
@@ -230,6 +372,84 @@ func TestGetZip(t *testing.T) {
 	}
 }
 
+func TestGetZipStream(t *testing.T) {
+	defer func(n int64) { zipSpoolThreshold = n }(zipSpoolThreshold)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	client, teardownProxy := SetupTestClient(t, []*Module{testModule})
+	defer teardownProxy()
+
+	size, err := client.GetZipSize(ctx, sample.ModulePath, sample.VersionString)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := client.readBody(ctx, sample.ModulePath, sample.VersionString, "zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantSHA256 := fmt.Sprintf("%x", sha256.Sum256(data))
+	for _, test := range []struct {
+		name      string
+		threshold int64
+		spooled   bool
+	}{
+		{"in memory", size, false},
+		{"spooled", size / 2, true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			zipSpoolThreshold = test.threshold
+			z, err := client.GetZipStream(ctx, sample.ModulePath, sample.VersionString, size)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer z.Close()
+			if got := z.file != nil; got != test.spooled {
+				t.Errorf("spooled = %t, want %t", got, test.spooled)
+			}
+			if z.Size != size {
+				t.Errorf("got size %d, want %d", z.Size, size)
+			}
+			if got, want := len(z.File), len(testModule.Files); got != want {
+				t.Errorf("got %d files, want %d", got, want)
+			}
+			if z.SHA256 != wantSHA256 {
+				t.Errorf("got SHA256 %s, want %s", z.SHA256, wantSHA256)
+			}
+			if !strings.HasSuffix(z.URL, "/@v/"+sample.VersionString+".zip") {
+				t.Errorf("got URL %q", z.URL)
+			}
+			if z.DownloadedAt.IsZero() {
+				t.Error("DownloadedAt not set")
+			}
+		})
+	}
+
+	t.Run("too large", func(t *testing.T) {
+		zipSpoolThreshold = size / 2
+		_, err := client.GetZipStream(ctx, sample.ModulePath, sample.VersionString, size-1)
+		if !errors.Is(err, derrors.ModuleTooLarge) {
+			t.Errorf("got %v, want %v", err, derrors.ModuleTooLarge)
+		}
+	})
+}
+
+func TestReadZipUnknownSize(t *testing.T) {
+	defer func(n int64) { zipSpoolThreshold = n }(zipSpoolThreshold)
+	zipSpoolThreshold = 10
+
+	// Without a Content-Length, the limit is enforced as the body is read,
+	// even after the zip has been spooled to a file.
+	data := strings.Repeat("x", 100)
+	if _, err := readZip(strings.NewReader(data), -1, 50); !errors.Is(err, derrors.ModuleTooLarge) {
+		t.Errorf("got %v, want %v", err, derrors.ModuleTooLarge)
+	}
+	if _, err := readZip(strings.NewReader(data), -1, 0); !errors.Is(err, derrors.BadModule) {
+		t.Errorf("got %v, want %v", err, derrors.BadModule)
+	}
+}
+
 func TestGetZipNonExist(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
 	defer cancel()
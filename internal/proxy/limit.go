@@ -0,0 +1,118 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package proxy
+
+import (
+	"context"
+	"expvar"
+	"net/url"
+	"sync"
+
+	"golang.org/x/pkgsite/internal/config"
+	"golang.org/x/time/rate"
+)
+
+// The limits below are shared by all Clients in the process, since they exist
+// to protect the proxy servers rather than any one client.
+var (
+	// requestQPS and requestBurst configure a token bucket for each proxy
+	// host. If requestQPS is not positive, requests are not rate limited.
+	requestQPS   float64
+	requestBurst = 10
+
+	// zipDownloads limits the number of zips being downloaded at once. It is
+	// nil if there is no limit.
+	zipDownloads chan struct{}
+
+	limitMu       sync.Mutex
+	hostLimiters  = map[string]*rate.Limiter{}
+	hostsInFlight = map[string]int{}
+	zipsInFlight  int
+)
+
+func init() {
+	requestQPS = config.GetEnvFloat64("GO_MODULE_PROXY_QPS", 0)
+	requestBurst = config.GetEnvInt("GO_MODULE_PROXY_BURST", requestBurst)
+	if n := config.GetEnvInt("GO_MODULE_PROXY_MAX_ZIP_DOWNLOADS", 0); n > 0 {
+		zipDownloads = make(chan struct{}, n)
+	}
+	expvar.Publish("proxy", expvar.Func(func() interface{} { return inFlightStats() }))
+}
+
+// InFlightStats holds the number of requests to the proxy that are currently
+// in progress.
+type InFlightStats struct {
+	// Requests is the number of requests in progress for each proxy host.
+	Requests map[string]int
+	// ZipDownloads is the number of zip downloads in progress, across all
+	// hosts.
+	ZipDownloads int
+}
+
+func inFlightStats() InFlightStats {
+	limitMu.Lock()
+	defer limitMu.Unlock()
+	s := InFlightStats{Requests: map[string]int{}, ZipDownloads: zipsInFlight}
+	for h, n := range hostsInFlight {
+		s.Requests[h] = n
+	}
+	return s
+}
+
+// startRequest waits until a request to the proxy at rawURL is allowed by
+// the host's rate limit, and records it as in flight. The returned function
+// must be called when the request is done.
+func startRequest(ctx context.Context, rawURL string) (done func(), err error) {
+	host := ""
+	if u, err := url.Parse(rawURL); err == nil {
+		host = u.Host
+	}
+	limitMu.Lock()
+	lim := hostLimiters[host]
+	if lim == nil && requestQPS > 0 {
+		lim = rate.NewLimiter(rate.Limit(requestQPS), requestBurst)
+		hostLimiters[host] = lim
+	}
+	limitMu.Unlock()
+	if lim != nil {
+		if err := lim.Wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+	limitMu.Lock()
+	hostsInFlight[host]++
+	limitMu.Unlock()
+	return func() {
+		limitMu.Lock()
+		defer limitMu.Unlock()
+		if hostsInFlight[host]--; hostsInFlight[host] <= 0 {
+			delete(hostsInFlight, host)
+		}
+	}, nil
+}
+
+// startZipDownload waits until fewer than the maximum number of zips are
+// being downloaded. The returned function must be called when the download
+// is done.
+func startZipDownload(ctx context.Context) (done func(), err error) {
+	if zipDownloads != nil {
+		select {
+		case zipDownloads <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	limitMu.Lock()
+	zipsInFlight++
+	limitMu.Unlock()
+	return func() {
+		limitMu.Lock()
+		zipsInFlight--
+		limitMu.Unlock()
+		if zipDownloads != nil {
+			<-zipDownloads
+		}
+	}, nil
+}
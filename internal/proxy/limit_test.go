@@ -0,0 +1,74 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package proxy
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestStartZipDownload(t *testing.T) {
+	defer func(c chan struct{}) { zipDownloads = c }(zipDownloads)
+	zipDownloads = make(chan struct{}, 1)
+
+	ctx := context.Background()
+	done, err := startZipDownload(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := inFlightStats().ZipDownloads; got != 1 {
+		t.Errorf("got %d zip downloads in flight, want 1", got)
+	}
+	// A second download must wait for the first.
+	tctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if _, err := startZipDownload(tctx); err == nil {
+		t.Fatal("second download started, want it to wait")
+	}
+	done()
+	done, err = startZipDownload(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done()
+	if got := inFlightStats().ZipDownloads; got != 0 {
+		t.Errorf("got %d zip downloads in flight, want 0", got)
+	}
+}
+
+func TestStartRequest(t *testing.T) {
+	defer func(qps float64, burst int) { requestQPS, requestBurst = qps, burst }(requestQPS, requestBurst)
+	requestQPS, requestBurst = 0.001, 1
+
+	const (
+		limited   = "https://limited.example.com/mod/@v/list"
+		unlimited = "https://other.example.com/mod/@v/list"
+	)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	done, err := startRequest(ctx, limited)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := inFlightStats().Requests["limited.example.com"]; got != 1 {
+		t.Errorf("got %d requests in flight, want 1", got)
+	}
+	done()
+	// The burst is used up, so the next request to the same host must wait
+	// much longer than the context allows.
+	if _, err := startRequest(ctx, limited); err == nil {
+		t.Error("second request to limited host was allowed, want error")
+	}
+	// Other hosts have their own limit.
+	done, err = startRequest(ctx, unlimited)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done()
+	if got := inFlightStats().Requests; len(got) != 0 {
+		t.Errorf("got requests in flight %v, want none", got)
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package proxy
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"time"
+
+	"go.opencensus.io/plugin/ochttp"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	"golang.org/x/pkgsite/internal/dcensus"
+)
+
+// Values of the endpoint tag.
+const (
+	endpointInfo    = "info"
+	endpointMod     = "mod"
+	endpointZip     = "zip"
+	endpointZipSize = "zip-size"
+	endpointLatest  = "latest"
+	endpointList    = "list"
+)
+
+// Values of the status tag other than HTTP status codes.
+const (
+	statusTimeout = "timeout"
+	statusError   = "error"
+)
+
+var (
+	keyEndpoint = tag.MustNewKey("proxy.endpoint")
+	keyHost     = tag.MustNewKey("proxy.host")
+
+	proxyLatency = stats.Float64(
+		"go-discovery/proxy/request-latency",
+		"Latency of a proxy request.",
+		stats.UnitMilliseconds,
+	)
+
+	// ProxyRequestCount counts proxy requests by host, endpoint and status.
+	// The status is the HTTP status code of the response, or "timeout" or
+	// "error" if there was no response.
+	ProxyRequestCount = &view.View{
+		Name:        "go-discovery/proxy/request-count",
+		Measure:     proxyLatency,
+		Aggregation: view.Count(),
+		Description: "Proxy request count by host, endpoint and status",
+		TagKeys:     []tag.Key{keyHost, keyEndpoint, dcensus.KeyStatus},
+	}
+	// ProxyLatencyDistribution aggregates proxy request latency by host,
+	// endpoint and status.
+	ProxyLatencyDistribution = &view.View{
+		Name:        "go-discovery/proxy/request-latency",
+		Measure:     proxyLatency,
+		Aggregation: ochttp.DefaultLatencyDistribution,
+		Description: "Proxy request latency by host, endpoint and status",
+		TagKeys:     []tag.Key{keyHost, keyEndpoint, dcensus.KeyStatus},
+	}
+)
+
+// recordRequest records the latency and outcome of a request to the proxy at
+// rawURL. statusCode is the HTTP status of the response, or zero if the
+// request failed without one.
+func recordRequest(ctx context.Context, rawURL, endpoint string, start time.Time, statusCode int) {
+	status := statusError
+	switch {
+	case statusCode != 0:
+		status = strconv.Itoa(statusCode)
+	case ctx.Err() != nil:
+		status = statusTimeout
+	}
+	host := ""
+	if u, err := url.Parse(rawURL); err == nil {
+		host = u.Host
+	}
+	stats.RecordWithTags(ctx, []tag.Mutator{
+		tag.Upsert(keyHost, host),
+		tag.Upsert(keyEndpoint, endpoint),
+		tag.Upsert(dcensus.KeyStatus, status),
+	}, proxyLatency.M(float64(time.Since(start))/float64(time.Millisecond)))
+}
@@ -58,7 +58,9 @@ func (s *Server) handleLatest(modulePath, urlPath string) {
 	s.mux.HandleFunc(urlPath, func(w http.ResponseWriter, r *http.Request) {
 		modules := s.modules[modulePath]
 		resolvedVersion := modules[len(modules)-1].Version
-		http.ServeContent(w, r, modulePath, time.Now(), defaultInfo(resolvedVersion))
+		// The response changes when modules are added, so don't send a
+		// Last-Modified header: it only has a resolution of one second.
+		http.ServeContent(w, r, modulePath, time.Time{}, defaultInfo(resolvedVersion))
 	})
 }
 
@@ -0,0 +1,177 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package proxy
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"time"
+
+	"golang.org/x/pkgsite/internal/config"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// zipSpoolThreshold is the largest zip that GetZipStream holds in memory.
+// Larger zips are written to a temporary file.
+var zipSpoolThreshold int64 = 32 * 1024 * 1024
+
+func init() {
+	if mi := config.GetEnvInt("GO_MODULE_PROXY_ZIP_SPOOL_MI", -1); mi >= 0 {
+		zipSpoolThreshold = int64(mi) * 1024 * 1024
+	}
+}
+
+// A Zip is a module zip downloaded by GetZipStream. Its contents are either
+// in memory or in a temporary file, so Close must be called when it is no
+// longer needed.
+type Zip struct {
+	*zip.Reader
+
+	// Size is the size of the zip in bytes.
+	Size int64
+
+	// SHA256 is the hex-encoded SHA-256 hash of the zip as it was
+	// downloaded.
+	SHA256 string
+
+	// URL is where the zip was downloaded from, and Header holds the
+	// headers of the proxy's response.
+	URL    string
+	Header http.Header
+
+	// DownloadedAt is when the download started, and DownloadDuration how
+	// long it took to read the whole zip.
+	DownloadedAt     time.Time
+	DownloadDuration time.Duration
+
+	// file is the temporary file holding the zip, or nil if it is in memory.
+	file *os.File
+}
+
+// Close releases the resources held by z.
+func (z *Zip) Close() error {
+	if z.file == nil {
+		return nil
+	}
+	err := z.file.Close()
+	if err2 := os.Remove(z.file.Name()); err == nil {
+		err = err2
+	}
+	return err
+}
+
+// GetZipStream is like GetZip, but does not hold large zips in memory. Zips
+// larger than a threshold are written to a temporary file as they are
+// downloaded, and read from there.
+//
+// If maxSize is positive, zips larger than maxSize bytes are rejected with an
+// error wrapping derrors.ModuleTooLarge. The check is made against the
+// response's Content-Length before any of the body is read, and again as it
+// is read. Callers that want to avoid the download entirely can check
+// GetZipSize first.
+func (c *Client) GetZipStream(ctx context.Context, modulePath, resolvedVersion string, maxSize int64) (_ *Zip, err error) {
+	defer derrors.Wrap(&err, "proxy.Client.GetZipStream(ctx, %q, %q, %d)", modulePath, resolvedVersion, maxSize)
+
+	u, err := c.escapedURL(modulePath, resolvedVersion, "zip")
+	if err != nil {
+		return nil, err
+	}
+	done, err := startZipDownload(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("waiting to download zip: %v: %w", err, derrors.ProxyTimedOut)
+	}
+	defer done()
+	var z *Zip
+	start := time.Now()
+	err = c.executeRequest(ctx, u, endpointZip, func(body io.Reader, size int64, header http.Header) error {
+		var err error
+		z, err = readZip(body, size, maxSize)
+		if err != nil {
+			return err
+		}
+		z.URL = u
+		z.Header = header
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	z.DownloadedAt = start
+	z.DownloadDuration = time.Since(start)
+	return z, nil
+}
+
+// readZip reads a zip of the given size from r, which is -1 if unknown. It
+// spools the zip to a temporary file if it is larger than zipSpoolThreshold.
+func readZip(r io.Reader, size, maxSize int64) (_ *Zip, err error) {
+	if maxSize > 0 && size > maxSize {
+		return nil, zipTooLarge(maxSize)
+	}
+	hash := sha256.New()
+	r = io.TeeReader(&maxSizeReader{r: r, max: maxSize}, hash)
+	data, err := ioutil.ReadAll(io.LimitReader(r, zipSpoolThreshold+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) <= zipSpoolThreshold {
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, fmt.Errorf("zip.NewReader: %v: %w", err, derrors.BadModule)
+		}
+		return &Zip{Reader: zr, Size: int64(len(data)), SHA256: fmt.Sprintf("%x", hash.Sum(nil))}, nil
+	}
+
+	f, err := ioutil.TempFile("", "pkgsite-zip-")
+	if err != nil {
+		return nil, err
+	}
+	z := &Zip{file: f}
+	defer func() {
+		if err != nil {
+			z.Close()
+		}
+	}()
+	if _, err := f.Write(data); err != nil {
+		return nil, err
+	}
+	n, err := io.Copy(f, r)
+	if err != nil {
+		return nil, err
+	}
+	z.Size = int64(len(data)) + n
+	z.SHA256 = fmt.Sprintf("%x", hash.Sum(nil))
+	z.Reader, err = zip.NewReader(f, z.Size)
+	if err != nil {
+		return nil, fmt.Errorf("zip.NewReader: %v: %w", err, derrors.BadModule)
+	}
+	return z, nil
+}
+
+// maxSizeReader reads from r, and fails once more than max bytes have been
+// read. If max is not positive, there is no limit.
+type maxSizeReader struct {
+	r      io.Reader
+	n, max int64
+}
+
+func (m *maxSizeReader) Read(p []byte) (int, error) {
+	n, err := m.r.Read(p)
+	m.n += int64(n)
+	if m.max > 0 && m.n > m.max {
+		return n, zipTooLarge(m.max)
+	}
+	return n, err
+}
+
+func zipTooLarge(maxSize int64) error {
+	return fmt.Errorf("zip is larger than %d bytes: %w", maxSize, derrors.ModuleTooLarge)
+}
@@ -48,9 +48,11 @@ func (ds *DataSource) GetUnitMeta(ctx context.Context, path, inModulePath, inVer
 		return nil, err
 	}
 	um := &internal.UnitMeta{
-		Path:       path,
-		ModulePath: inModulePath,
-		Version:    inVersion,
+		Path:        path,
+		ModulePath:  inModulePath,
+		Version:     inVersion,
+		Metadata:    m.Metadata,
+		Deprecation: m.Deprecation,
 	}
 	for _, d := range m.Units {
 		if d.Path == path {
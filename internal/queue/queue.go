@@ -15,19 +15,41 @@ import (
 
 	cloudtasks "cloud.google.com/go/cloudtasks/apiv2"
 	"github.com/golang/protobuf/ptypes"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
 	"golang.org/x/pkgsite/internal/config"
 	"golang.org/x/pkgsite/internal/derrors"
 	"golang.org/x/pkgsite/internal/experiment"
 	"golang.org/x/pkgsite/internal/log"
 	"golang.org/x/pkgsite/internal/middleware"
+	"google.golang.org/api/iterator"
 	taskspb "google.golang.org/genproto/googleapis/cloud/tasks/v2"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
+var (
+	inMemoryDepth = stats.Int64(
+		"go-discovery/queue/in_memory_depth",
+		"The number of fetches waiting in an in-memory queue.",
+		stats.UnitDimensionless,
+	)
+	// InMemoryDepth is the number of fetches waiting in the InMemory queue
+	// for a worker. Cloud Tasks queues report their depth themselves.
+	InMemoryDepth = &view.View{
+		Name:        "go-discovery/queue/in_memory_depth",
+		Measure:     inMemoryDepth,
+		Aggregation: view.LastValue(),
+		Description: "fetches waiting in the in-memory queue",
+	}
+)
+
 // A Queue provides an interface for asynchronous scheduling of fetch actions.
 type Queue interface {
 	ScheduleFetch(ctx context.Context, modulePath, version, suffix string, taskIDChangeInterval time.Duration) (bool, error)
+	// Depth returns the number of fetches waiting in the queue. It may stop
+	// counting at max.
+	Depth(ctx context.Context, max int) (int, error)
 }
 
 // New creates a new Queue with name queueName based on the configuration
@@ -140,6 +162,23 @@ func (q *GCP) ScheduleFetch(ctx context.Context, modulePath, version, suffix str
 	return enqueued, nil
 }
 
+// Depth returns the number of tasks in the queue, counting at most max of
+// them: the Cloud Tasks API can only list them, one page at a time.
+func (q *GCP) Depth(ctx context.Context, max int) (n int, err error) {
+	defer derrors.Wrap(&err, "queue.Depth(%d)", max)
+	it := q.client.ListTasks(ctx, &taskspb.ListTasksRequest{Parent: q.queueName, PageSize: 1000})
+	for n < max {
+		if _, err := it.Next(); err != nil {
+			if err == iterator.Done {
+				break
+			}
+			return 0, err
+		}
+		n++
+	}
+	return n, nil
+}
+
 // Maximum timeout for HTTP tasks.
 // See https://cloud.google.com/tasks/docs/creating-http-target-tasks.
 const maxCloudTasksTimeout = 30 * time.Minute
@@ -223,6 +262,7 @@ func NewInMemory(ctx context.Context, workerCount int, experiments []string, pro
 	}
 	go func() {
 		for v := range q.queue {
+			stats.Record(ctx, inMemoryDepth.M(int64(len(q.queue))))
 			select {
 			case <-ctx.Done():
 				return
@@ -253,9 +293,15 @@ func NewInMemory(ctx context.Context, workerCount int, experiments []string, pro
 // asynchronously.
 func (q *InMemory) ScheduleFetch(ctx context.Context, modulePath, version, suffix string, taskIDChangeInterval time.Duration) (bool, error) {
 	q.queue <- moduleVersion{modulePath, version}
+	stats.Record(ctx, inMemoryDepth.M(int64(len(q.queue))))
 	return true, nil
 }
 
+// Depth returns the number of fetches waiting in the queue for a worker.
+func (q *InMemory) Depth(context.Context, int) (int, error) {
+	return len(q.queue), nil
+}
+
 // WaitForTesting waits for all queued requests to finish. It should only be
 // used by test code.
 func (q InMemory) WaitForTesting(ctx context.Context) {
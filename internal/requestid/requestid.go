@@ -0,0 +1,77 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package requestid provides identifiers for incoming requests, which are
+// passed along with the outgoing requests and database queries made to serve
+// them, so that they can be correlated in the logs of each.
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"regexp"
+)
+
+// Header is the HTTP header that carries a request ID.
+const Header = "X-Request-ID"
+
+type contextKey struct{}
+
+// validRegexp matches the request IDs that are accepted from clients. They
+// are restricted so that they can be safely embedded in log entries, headers
+// and SQL comments.
+var validRegexp = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// Valid reports whether id is a well-formed request ID.
+func Valid(id string) bool {
+	return validRegexp.MatchString(id)
+}
+
+// New returns a new random request ID.
+func New() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		// crypto/rand doesn't fail in practice; an ID that is not unique only
+		// makes correlation harder.
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
+
+// NewContext returns a context derived from ctx that carries id.
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the request ID stored in ctx by NewContext, or the
+// empty string if there is none.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// Transport is an http.RoundTripper that sets the request ID header of
+// outgoing requests to the ID in their context, if there is one and the
+// header isn't already set.
+type Transport struct {
+	// Base is the RoundTripper that makes the requests. If it is nil,
+	// http.DefaultTransport is used.
+	Base http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if id := FromContext(req.Context()); id != "" && req.Header.Get(Header) == "" {
+		// A RoundTripper must not modify the request it is given.
+		req = req.Clone(req.Context())
+		req.Header.Set(Header, id)
+	}
+	return base.RoundTrip(req)
+}
@@ -0,0 +1,63 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package requestid
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	a, b := New(), New()
+	if !Valid(a) {
+		t.Errorf("New() = %q, which is not valid", a)
+	}
+	if a == b {
+		t.Errorf("New() returned %q twice", a)
+	}
+}
+
+func TestTransport(t *testing.T) {
+	var got string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get(Header)
+	}))
+	defer ts.Close()
+	client := &http.Client{Transport: &Transport{}}
+
+	for _, test := range []struct {
+		ctxID, header, want string
+	}{
+		{"", "", ""},
+		{"abc", "", "abc"},
+		{"abc", "def", "def"},
+	} {
+		ctx := context.Background()
+		if test.ctxID != "" {
+			ctx = NewContext(ctx, test.ctxID)
+		}
+		req, err := http.NewRequest("GET", ts.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req = req.WithContext(ctx)
+		if test.header != "" {
+			req.Header.Set(Header, test.header)
+		}
+		res, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if got != test.want {
+			t.Errorf("ctx ID %q, header %q: server got %q, want %q", test.ctxID, test.header, got, test.want)
+		}
+		if test.header == "" && req.Header.Get(Header) != "" {
+			t.Errorf("ctx ID %q: the request was modified", test.ctxID)
+		}
+	}
+}
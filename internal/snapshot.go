@@ -0,0 +1,40 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package internal
+
+import (
+	"net/http"
+	"time"
+)
+
+// A SnapshotRule asks the frontend to record a sample of the requests whose
+// paths match PathPattern, along with their responses, until ExpiresAt. It is
+// used to debug pages that only render incorrectly in production.
+type SnapshotRule struct {
+	ID int
+	// PathPattern is matched against the request path using path.Match.
+	PathPattern string
+	// SampleRate is the fraction of matching requests that are recorded,
+	// between 0 and 1.
+	SampleRate float64
+	ExpiresAt  time.Time
+}
+
+// A ResponseSnapshot is a request to the frontend and the response to it,
+// recorded because of a SnapshotRule. Sensitive headers are removed before
+// it is stored.
+type ResponseSnapshot struct {
+	RuleID         int
+	Method         string
+	URL            string
+	RequestHeader  http.Header
+	Status         int
+	ResponseHeader http.Header
+	ResponseBody   []byte
+	// BodyTruncated reports whether ResponseBody holds only the start of
+	// the response.
+	BodyTruncated bool
+	AppVersion    string
+}
@@ -36,6 +36,7 @@ import (
 	"golang.org/x/net/context/ctxhttp"
 	"golang.org/x/pkgsite/internal/derrors"
 	"golang.org/x/pkgsite/internal/log"
+	"golang.org/x/pkgsite/internal/requestid"
 	"golang.org/x/pkgsite/internal/stdlib"
 	"golang.org/x/pkgsite/internal/version"
 )
@@ -212,7 +213,7 @@ type Client struct {
 func NewClient(timeout time.Duration) *Client {
 	return &Client{
 		httpClient: &http.Client{
-			Transport: &ochttp.Transport{},
+			Transport: &requestid.Transport{Base: &ochttp.Transport{}},
 			Timeout:   timeout,
 		},
 	}
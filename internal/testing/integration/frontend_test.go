@@ -200,10 +200,9 @@ func setupFrontend(ctx context.Context, t *testing.T, q queue.Queue) *httptest.S
 	if err != nil {
 		t.Fatal(err)
 	}
-	enableCSP := true
 	mw := middleware.Chain(
 		middleware.AcceptRequests(http.MethodGet, http.MethodPost),
-		middleware.SecureHeaders(enableCSP),
+		middleware.SecureHeaders(middleware.SecureHeadersOptions{CSP: true}),
 		middleware.LatestVersions(s.GetLatestMinorVersion, s.GetLatestMajorVersion),
 		middleware.Experiment(experimenter),
 	)
@@ -23,10 +23,15 @@ type UnitMeta struct {
 
 	// Module level information
 	//
-	Version    string
-	ModulePath string
-	CommitTime time.Time
-	SourceInfo *source.Info
+	Version     string
+	ModulePath  string
+	CommitTime  time.Time
+	SourceInfo  *source.Info
+	Metadata    *ModuleMetadata
+	Deprecation *Deprecation
+	// GoneFromProxy reports whether the proxy has stopped serving this
+	// version of the module. Its documentation is still shown.
+	GoneFromProxy bool
 }
 
 // IsPackage reports whether the path represents a package path.
@@ -65,10 +70,95 @@ type Documentation struct {
 	GOOS     string
 	GOARCH   string
 	Synopsis string
-	HTML     safehtml.HTML
-	Source   []byte // encoded ast.Files; see godoc.Package.Encode
+	// SynopsisInferred reports whether Synopsis was derived from a README
+	// because the package has no doc comment.
+	SynopsisInferred bool
+	// Kind classifies the package by what its files contain.
+	Kind   PackageKind
+	HTML   safehtml.HTML
+	Source []byte // encoded ast.Files; see godoc.Package.Encode
+	// Parts holds the rest of the documentation if it was too large to be
+	// displayed on one page and was split. HTML is then the first part, with
+	// the package comment and the constants and variables. When read with
+	// GetUnit, only the titles of the parts are set.
+	Parts []*DocumentationPart
+	// Text and Markdown are the documentation rendered as plain text and
+	// Markdown. They are only set when requested with
+	// fetch.ProcessingOptions, and are not stored in the database.
+	Text     string
+	Markdown string
+	// Symbols holds the names of the exported functions, types and methods
+	// of the package, the latter as "Type.Method". It is only set when the
+	// symbol-history experiment is active, and is not read by GetUnit.
+	Symbols []string
+	// BuildContexts describes the documentation of the package in each
+	// build context it can be loaded in, if its text differs between them.
+	// It is only set when the build-context-docs experiment is active, and
+	// is not read by GetUnit.
+	BuildContexts []*BuildContextDoc
+	// SymbolDecls holds the declarations of the exported functions, types
+	// and methods of the package, sorted by name. It is only set when the
+	// api-diff experiment is active, and is not read by GetUnit.
+	SymbolDecls []*SymbolDecl
+	// DocHash is a hash of the package comment, which identifies copies of
+	// a package that keep its comment. It is stored only with the search
+	// document of the package, to recognize copies of standard library
+	// packages, and is not read by GetUnit.
+	DocHash string
+	// HasTests reports whether the package has test files. It is stored only
+	// with the search document of the package, for the has:tests search
+	// filter, and is not read by GetUnit.
+	HasTests bool
 }
 
+// A SymbolDecl is the declaration of an exported function, type or method
+// of a package, without its doc comment, function body, or unexported
+// fields and methods. Two versions of a package have the same API for the
+// symbol if their declarations are equal.
+type SymbolDecl struct {
+	// Name is the name of the symbol, as in Documentation.Symbols.
+	Name string
+	// Decl is the Go source of the declaration, as in "func F(x int) error".
+	Decl string
+}
+
+// A BuildContextDoc describes the documentation of a package in one build
+// context.
+type BuildContextDoc struct {
+	GOOS   string
+	GOARCH string
+	// Hash identifies the plain text of the documentation; see
+	// godoc.Package.TextHash.
+	Hash string
+	// Source holds the encoded ast.Files of the package in the build
+	// context. It is only set if the documentation differs from the one
+	// displayed by default.
+	Source []byte
+}
+
+// A DocumentationPart is one page of documentation that was split because
+// it was too large.
+type DocumentationPart struct {
+	// Title names the first and last top-level functions or types in
+	// the part.
+	Title string
+	HTML  safehtml.HTML
+}
+
+// PackageKind classifies a package by what its files contain.
+type PackageKind string
+
+const (
+	// PackageKindDefault is the kind of a package with declarations.
+	PackageKindDefault PackageKind = ""
+	// PackageKindDocOnly is the kind of a package whose non-test files
+	// contain no declarations, like a package made of a doc.go file.
+	PackageKindDocOnly PackageKind = "doc-only"
+	// PackageKindExampleOnly is the kind of a package whose non-test files
+	// contain no declarations, but whose test files contain examples.
+	PackageKindExampleOnly PackageKind = "example-only"
+)
+
 // Readme is a README at the specified filepath.
 type Readme struct {
 	Filepath string
@@ -84,6 +174,16 @@ type PackageMeta struct {
 	Licenses          []*licenses.Metadata // metadata of applicable licenses
 }
 
+// DirectoryEntry represents an immediate subdirectory of a unit in a module
+// version, as in a directory listing. It is a package if Name is not empty.
+type DirectoryEntry struct {
+	Path              string
+	Name              string
+	Synopsis          string
+	IsRedistributable bool
+	Licenses          []*licenses.Metadata // metadata of applicable licenses
+}
+
 // A FieldSet is a bit set of struct fields. It is used to avoid reading large
 // struct fields from the data store. FieldSet is also the type of the
 // individual bit values. (Think of them as singleton sets.)
@@ -0,0 +1,107 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package worker
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/config"
+	"golang.org/x/pkgsite/internal/fetch"
+	"golang.org/x/pkgsite/internal/log"
+)
+
+// Thresholds above which the worker reports itself as overloaded. A
+// threshold that is not positive is not checked.
+var (
+	// maxFetchesInFlight is the number of concurrent fetches above which the
+	// worker is overloaded.
+	maxFetchesInFlight = 20
+	// maxQueueDepth is the number of fetches waiting in the queue above which
+	// the worker is overloaded.
+	maxQueueDepth = 1000
+	// maxMemoryFraction is the fraction of the memory limit above which the
+	// worker is overloaded.
+	maxMemoryFraction = 0.9
+)
+
+func init() {
+	maxFetchesInFlight = config.GetEnvInt("GO_DISCOVERY_BACKPRESSURE_MAX_FETCHES", maxFetchesInFlight)
+	maxQueueDepth = config.GetEnvInt("GO_DISCOVERY_BACKPRESSURE_MAX_QUEUE_DEPTH", maxQueueDepth)
+	maxMemoryFraction = config.GetEnvFloat64("GO_DISCOVERY_BACKPRESSURE_MAX_MEMORY_FRACTION", maxMemoryFraction)
+}
+
+// ReportLoad records the load of this worker instance in the database every
+// interval, until ctx is done. The frontend uses it to decide whether to
+// enqueue more fetches.
+func (s *Server) ReportLoad(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		load := s.currentLoad(ctx)
+		if load.Overloaded {
+			log.Warningf(ctx, "worker overloaded: %d fetches in flight, %d queued, %dM of %dM memory used",
+				load.FetchesInFlight, load.QueueDepth, load.MemoryUsed/(1024*1024), load.MemoryLimit/(1024*1024))
+		}
+		if err := s.db.UpsertWorkerLoad(ctx, load); err != nil {
+			log.Errorf(ctx, "ReportLoad: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// currentLoad returns the load of this process.
+func (s *Server) currentLoad(ctx context.Context) *internal.WorkerLoad {
+	load := &internal.WorkerLoad{
+		InstanceID:      s.cfg.InstanceID,
+		FetchesInFlight: fetch.ZipLoadShedStats().RequestsInFlight,
+	}
+	if maxQueueDepth > 0 {
+		// Counting the tasks in a Cloud Tasks queue takes a request for
+		// each page of them, so stop once it's clear the queue is too deep.
+		depth, err := s.queue.Depth(ctx, maxQueueDepth+1)
+		if err != nil {
+			log.Errorf(ctx, "currentLoad: %v", err)
+		}
+		load.QueueDepth = depth
+	}
+	load.MemoryUsed, load.MemoryLimit = memoryUsage()
+	load.Overloaded = isOverloaded(load)
+	return load
+}
+
+// isOverloaded reports whether load exceeds the thresholds.
+func isOverloaded(load *internal.WorkerLoad) bool {
+	if maxFetchesInFlight > 0 && load.FetchesInFlight > maxFetchesInFlight {
+		return true
+	}
+	if maxQueueDepth > 0 && load.QueueDepth > maxQueueDepth {
+		return true
+	}
+	if maxMemoryFraction > 0 && load.MemoryLimit > 0 &&
+		float64(load.MemoryUsed) > maxMemoryFraction*float64(load.MemoryLimit) {
+		return true
+	}
+	return false
+}
+
+// memoryUsage returns the memory used by this worker and the limit on it, in
+// bytes. It prefers the container's figures, if they are available and the
+// container has a limit, and falls back to those of the machine.
+func memoryUsage() (used, limit uint64) {
+	sms, err := getSystemMemStats()
+	if err != nil {
+		sms = systemMemStats{}
+	}
+	if m := getCgroupMemStats(); m != nil && m["limit"] > 0 && (sms.Total == 0 || m["limit"] < sms.Total) {
+		return m["workingSet"], m["limit"]
+	}
+	return sms.Used, sms.Total
+}
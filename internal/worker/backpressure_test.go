@@ -0,0 +1,36 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package worker
+
+import (
+	"testing"
+
+	"golang.org/x/pkgsite/internal"
+)
+
+func TestIsOverloaded(t *testing.T) {
+	defer func(n, d int, f float64) {
+		maxFetchesInFlight, maxQueueDepth, maxMemoryFraction = n, d, f
+	}(maxFetchesInFlight, maxQueueDepth, maxMemoryFraction)
+	maxFetchesInFlight, maxQueueDepth, maxMemoryFraction = 10, 100, 0.9
+
+	for _, test := range []struct {
+		name string
+		load internal.WorkerLoad
+		want bool
+	}{
+		{"idle", internal.WorkerLoad{FetchesInFlight: 1, MemoryUsed: 10, MemoryLimit: 100}, false},
+		{"many fetches", internal.WorkerLoad{FetchesInFlight: 11, MemoryUsed: 10, MemoryLimit: 100}, true},
+		{"deep queue", internal.WorkerLoad{FetchesInFlight: 1, QueueDepth: 101, MemoryUsed: 10, MemoryLimit: 100}, true},
+		{"high memory", internal.WorkerLoad{FetchesInFlight: 1, MemoryUsed: 91, MemoryLimit: 100}, true},
+		{"unknown limit", internal.WorkerLoad{FetchesInFlight: 1, MemoryUsed: 91}, false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if got := isOverloaded(&test.load); got != test.want {
+				t.Errorf("got %t, want %t", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/log"
+	"golang.org/x/pkgsite/internal/postgres"
+)
+
+// handleExcludedAdd excludes the "prefix" form value, for the "reason" form
+// value, and returns to the excluded page. The optional "scope" form value is
+// what to exclude the prefix from (default "all"), and "expires-in" is a
+// duration, like "72h", after which the exclusion stops applying. If the
+// "purge" form value is "true", it also starts a purge of the prefix.
+func (s *Server) handleExcludedAdd(w http.ResponseWriter, r *http.Request) error {
+	prefix, reason, err := excludedForm(r)
+	if err != nil {
+		return err
+	}
+	ep := &postgres.ExcludedPrefix{
+		Prefix:    prefix,
+		CreatedBy: s.requestUser(r),
+		Reason:    reason,
+		Scope:     postgres.ExcludeEverywhere,
+	}
+	if v := r.FormValue("scope"); v != "" {
+		ep.Scope = postgres.ExclusionScope(v)
+	}
+	if v := strings.TrimSpace(r.FormValue("expires-in")); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return &serverError{http.StatusBadRequest, fmt.Errorf("expires-in must be a positive duration: %q", v)}
+		}
+		ep.ExpiresAt = time.Now().Add(d)
+	}
+	purge := r.FormValue("purge") == "true"
+	if purge && ep.Scope != postgres.ExcludeEverywhere {
+		return &serverError{http.StatusBadRequest, errors.New("only prefixes excluded everywhere can be purged")}
+	}
+	if err := s.db.InsertExcludedPrefixEntry(r.Context(), ep); err != nil {
+		if errors.Is(err, derrors.InvalidArgument) {
+			return &serverError{http.StatusBadRequest, err}
+		}
+		return err
+	}
+	if purge {
+		if err := s.startExcludedPurge(r.Context(), prefix, ep.CreatedBy); err != nil {
+			return err
+		}
+	}
+	http.Redirect(w, r, "/excluded", http.StatusSeeOther)
+	return nil
+}
+
+// handleExcludedRemove stops excluding the "prefix" form value, for the
+// "reason" form value, and returns to the excluded page.
+func (s *Server) handleExcludedRemove(w http.ResponseWriter, r *http.Request) error {
+	prefix, reason, err := excludedForm(r)
+	if err != nil {
+		return err
+	}
+	if err := s.db.DeleteExcludedPrefix(r.Context(), prefix, s.requestUser(r), reason); err != nil {
+		if errors.Is(err, derrors.NotFound) {
+			return &serverError{http.StatusNotFound, fmt.Errorf("no excluded prefix %q", prefix)}
+		}
+		return err
+	}
+	http.Redirect(w, r, "/excluded", http.StatusSeeOther)
+	return nil
+}
+
+// handleExcludedPurge starts a purge of the excluded "prefix" form value, and
+// returns to the excluded page.
+func (s *Server) handleExcludedPurge(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		return &serverError{http.StatusMethodNotAllowed, errors.New("method must be POST")}
+	}
+	prefix := strings.TrimSpace(r.FormValue("prefix"))
+	if prefix == "" {
+		return &serverError{http.StatusBadRequest, errors.New("prefix is required")}
+	}
+	if err := s.startExcludedPurge(r.Context(), prefix, s.requestUser(r)); err != nil {
+		if errors.Is(err, derrors.NotFound) {
+			return &serverError{http.StatusNotFound, fmt.Errorf("no prefix %q excluded everywhere", prefix)}
+		}
+		return err
+	}
+	http.Redirect(w, r, "/excluded", http.StatusSeeOther)
+	return nil
+}
+
+// purgeBatchSize is the number of module versions that a purge deletes
+// between updates of its progress.
+const purgeBatchSize = 100
+
+// startExcludedPurge starts a background job that deletes the module
+// versions and search documents that match the excluded prefix. Its progress
+// is recorded in the excluded_prefix_purges table.
+func (s *Server) startExcludedPurge(ctx context.Context, prefix, user string) error {
+	p, err := s.db.InsertExcludedPrefixPurge(ctx, prefix, user)
+	if err != nil {
+		return err
+	}
+	// The purge outlives the request that started it.
+	go s.runExcludedPurge(log.NewContextWithLabel(context.Background(), "purge", prefix), p)
+	return nil
+}
+
+// runExcludedPurge carries out the purge p, and records how it ended.
+func (s *Server) runExcludedPurge(ctx context.Context, p *postgres.ExcludedPrefixPurge) {
+	log.Infof(ctx, "purging %d module versions matching excluded prefix %q", p.ModuleVersionsTotal, p.Prefix)
+	if err := s.purgeExcluded(ctx, p); err != nil {
+		log.Errorf(ctx, "purging excluded prefix %q: %v", p.Prefix, err)
+		p.Status = "failed"
+		p.Error = err.Error()
+	} else {
+		log.Infof(ctx, "purged excluded prefix %q: deleted %d module versions and %d search documents",
+			p.Prefix, p.ModuleVersionsDeleted, p.SearchDocumentsDeleted)
+		p.Status = "done"
+	}
+	if err := s.db.UpdateExcludedPrefixPurge(ctx, p); err != nil {
+		log.Errorf(ctx, "recording purge of %q: %v", p.Prefix, err)
+	}
+}
+
+func (s *Server) purgeExcluded(ctx context.Context, p *postgres.ExcludedPrefixPurge) error {
+	for {
+		mis, err := s.db.GetModuleVersionsMatchingExcludedPrefix(ctx, p.Prefix, purgeBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(mis) == 0 {
+			break
+		}
+		for _, mi := range mis {
+			if err := s.db.DeleteModule(ctx, mi.ModulePath, mi.Version); err != nil {
+				return err
+			}
+			p.ModuleVersionsDeleted++
+		}
+		if err := s.db.UpdateExcludedPrefixPurge(ctx, p); err != nil {
+			return err
+		}
+	}
+	n, err := s.db.DeleteSearchDocumentsMatchingExcludedPrefix(ctx, p.Prefix)
+	if err != nil {
+		return err
+	}
+	p.SearchDocumentsDeleted = int(n)
+	return nil
+}
+
+// excludedForm returns the prefix and reason posted from the excluded page.
+func excludedForm(r *http.Request) (prefix, reason string, err error) {
+	if r.Method != http.MethodPost {
+		return "", "", &serverError{http.StatusMethodNotAllowed, errors.New("method must be POST")}
+	}
+	prefix = strings.TrimSpace(r.FormValue("prefix"))
+	reason = strings.TrimSpace(r.FormValue("reason"))
+	if prefix == "" || reason == "" {
+		return "", "", &serverError{http.StatusBadRequest, errors.New("prefix and reason are required")}
+	}
+	return prefix, reason, nil
+}
+
+// requestUser returns the user who made r, for the record of a change. It is
+// the value of the configured user header, which the authenticating proxy in
+// front of the worker sets, if there is one. Otherwise it is the "user" form
+// value, or "worker" if that is empty.
+func (s *Server) requestUser(r *http.Request) string {
+	if s.cfg.UserHeader != "" {
+		if u := r.Header.Get(s.cfg.UserHeader); u != "" {
+			return u
+		}
+	}
+	if u := strings.TrimSpace(r.FormValue("user")); u != "" {
+		return u
+	}
+	return "worker"
+}
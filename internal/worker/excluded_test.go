@@ -0,0 +1,56 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package worker
+
+import (
+	"context"
+	"testing"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/postgres"
+	"golang.org/x/pkgsite/internal/testing/sample"
+)
+
+func TestRunExcludedPurge(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+	defer postgres.ResetTestDB(testDB, t)
+
+	for _, m := range []*internal.Module{
+		sample.LegacyModule("github.com/joe/spam", "v1.0.0", "a"),
+		sample.LegacyModule("github.com/ann/spam", "v1.0.0", "b"),
+		sample.LegacyModule("github.com/ann/ham", "v1.0.0", "c"),
+	} {
+		if err := testDB.InsertModule(ctx, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	const prefix = "github.com/*/spam"
+	if err := testDB.InsertExcludedPrefix(ctx, prefix, "me", "spam"); err != nil {
+		t.Fatal(err)
+	}
+	p, err := testDB.InsertExcludedPrefixPurge(ctx, prefix, "me")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Server{db: testDB}
+	s.runExcludedPurge(ctx, p)
+
+	ps, err := testDB.GetExcludedPrefixPurges(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ps[0]; got.Status != "done" || got.ModuleVersionsDeleted != 2 {
+		t.Errorf("got status %q with %d module versions deleted (error %q), want done with 2",
+			got.Status, got.ModuleVersionsDeleted, got.Error)
+	}
+	mis, err := testDB.GetModuleVersionsMatchingExcludedPrefix(ctx, "github.com/", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mis) != 1 || mis[0].ModulePath != "github.com/ann/ham" {
+		t.Errorf("remaining module versions: got %v, want only github.com/ann/ham", mis)
+	}
+}
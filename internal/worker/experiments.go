@@ -0,0 +1,76 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package worker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// handleExperiments administers the experiments table, whose experiments
+// take precedence over those of the same name in the dynamic config. GET
+// lists them as JSON. POST sets the "rollout", a percentage of requests, of
+// the experiment with the given "name", and optionally its "description".
+// DELETE removes the experiment "name" from the table. The frontend and
+// worker read the experiments once a minute.
+func (s *Server) handleExperiments(w http.ResponseWriter, r *http.Request) (err error) {
+	defer derrors.Wrap(&err, "handleExperiments(%s)", r.Method)
+
+	ctx := r.Context()
+	name := strings.TrimSpace(r.FormValue("name"))
+	switch r.Method {
+	case http.MethodGet:
+		exps, err := s.db.GetExperiments(ctx)
+		if err != nil {
+			return err
+		}
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(exps)
+	case http.MethodPost:
+		if _, ok := internal.Experiments[name]; !ok {
+			return &serverError{http.StatusBadRequest, fmt.Errorf("unknown experiment %q", name)}
+		}
+		rollout, err := strconv.ParseUint(r.FormValue("rollout"), 10, 0)
+		if err != nil || rollout > 100 {
+			return &serverError{http.StatusBadRequest, fmt.Errorf("rollout must be an integer from 0 to 100: %q", r.FormValue("rollout"))}
+		}
+		e := &internal.Experiment{
+			Name:        name,
+			Rollout:     uint(rollout),
+			Description: strings.TrimSpace(r.FormValue("description")),
+		}
+		if e.Description == "" {
+			e.Description = internal.Experiments[name]
+		}
+		if err := s.db.InsertExperiment(ctx, e); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "Experiment %q is rolled out to %d%% of requests.\n", name, e.Rollout)
+		return nil
+	case http.MethodDelete:
+		if name == "" {
+			return &serverError{http.StatusBadRequest, errors.New("name is required")}
+		}
+		if err := s.db.RemoveExperiment(ctx, name); err != nil {
+			if errors.Is(err, derrors.NotFound) {
+				return &serverError{http.StatusNotFound, fmt.Errorf("no experiment %q", name)}
+			}
+			return err
+		}
+		fmt.Fprintf(w, "Removed experiment %q.\n", name)
+		return nil
+	default:
+		return &serverError{http.StatusMethodNotAllowed, errors.New("method must be GET, POST or DELETE")}
+	}
+}
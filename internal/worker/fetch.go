@@ -6,6 +6,7 @@ package worker
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"sort"
@@ -58,11 +59,27 @@ func FetchAndUpdateState(ctx context.Context, modulePath, requestedVersion strin
 
 	ft := fetchAndInsertModule(ctx, modulePath, requestedVersion, proxyClient, sourceClient, db)
 	span.AddAttributes(trace.Int64Attribute("numPackages", int64(len(ft.PackageVersionStates))))
+	if ft.Provenance != nil {
+		// Provenance is only kept for audits, so failing to record it doesn't
+		// affect the result of this fetch.
+		if err := db.InsertProvenance(ctx, ft.Provenance); err != nil {
+			log.Error(ctx, err)
+		}
+	}
 
 	// If there were any errors processing the module then we didn't insert it.
-	// Delete it in case we are reprocessing an existing module.
+	// Delete it in case we are reprocessing an existing module, unless the
+	// proxy has stopped serving it: then keep its documentation, but mark it
+	// so that it is no longer considered the latest version.
 	if ft.Status >= 400 {
-		if err := deleteModule(ctx, db, ft); err != nil {
+		var err error
+		if proxy.IsGone(ft.Error) {
+			log.Infof(ctx, "%s@%s is gone from the proxy", ft.ModulePath, ft.ResolvedVersion)
+			err = db.UpdateModuleProxyStatus(ctx, ft.ModulePath, ft.ResolvedVersion, http.StatusGone)
+		} else {
+			err = deleteModule(ctx, db, ft)
+		}
+		if err != nil {
 			log.Error(ctx, err)
 			ft.Error = err
 			ft.Status = http.StatusInternalServerError
@@ -81,6 +98,18 @@ func FetchAndUpdateState(ctx context.Context, modulePath, requestedVersion strin
 		// Do not return an error here, because we want to insert into
 		// module_version_states below.
 	}
+	stage := internal.FetchDone
+	if ft.Status >= 400 {
+		stage = internal.FetchError
+	}
+	updateFetchStage(ctx, db, modulePath, requestedVersion, stage)
+	if ft.Status < 300 && ft.Module != nil && ft.Module.Deprecation != nil && ft.Module.Deprecation.Successor != "" {
+		// The successor is only a suggestion, so failing to process it
+		// doesn't affect the result of this fetch.
+		if err := enqueueSuccessor(ctx, ft.Module.Deprecation.Successor, proxyClient, db); err != nil {
+			log.Warning(ctx, err)
+		}
+	}
 	if !semver.IsValid(ft.ResolvedVersion) {
 		// If the requestedVersion was not successfully resolved to a semantic
 		// version, then at this point it will be the same as the
@@ -142,7 +171,7 @@ func fetchAndInsertModule(ctx context.Context, modulePath, requestedVersion stri
 		return ft
 	}
 
-	exc, err := db.IsExcluded(ctx, modulePath)
+	exc, err := db.IsExcludedFromFetch(ctx, modulePath)
 	if err != nil {
 		ft.Error = err
 		return ft
@@ -152,8 +181,11 @@ func fetchAndInsertModule(ctx context.Context, modulePath, requestedVersion stri
 		return ft
 	}
 
+	updateFetchStage(ctx, db, modulePath, requestedVersion, internal.FetchDownloading)
 	start := time.Now()
-	fr := fetch.FetchModule(ctx, modulePath, requestedVersion, proxyClient, sourceClient)
+	fr := fetch.FetchModuleWithOptions(ctx, modulePath, requestedVersion, proxyClient, sourceClient, fetch.ProcessingOptions{
+		Downloaded: func() { updateFetchStage(ctx, db, modulePath, requestedVersion, internal.FetchProcessing) },
+	})
 	if fr == nil {
 		panic("fetch.FetchModule should never return a nil FetchResult")
 	}
@@ -186,6 +218,38 @@ func fetchAndInsertModule(ctx context.Context, modulePath, requestedVersion stri
 	return ft
 }
 
+// updateFetchStage records that the fetch of modulePath at requestedVersion
+// has reached stage, so that the frontend can report its progress. The stage
+// is only informational, so failing to record it is not an error.
+func updateFetchStage(ctx context.Context, db *postgres.DB, modulePath, requestedVersion string, stage internal.FetchStage) {
+	if err := db.UpdateFetchStage(ctx, modulePath, requestedVersion, stage); err != nil {
+		log.Warning(ctx, err)
+	}
+}
+
+// enqueueSuccessor makes sure that the latest version of the module that a
+// deprecated module names as its successor will be processed, by adding it to
+// module_version_states if it isn't already there.
+func enqueueSuccessor(ctx context.Context, successor string, proxyClient *proxy.Client, db *postgres.DB) (err error) {
+	defer derrors.Wrap(&err, "enqueueSuccessor(%q)", successor)
+
+	info, err := proxyClient.GetLatestInfo(ctx, successor)
+	if err != nil {
+		return err
+	}
+	_, err = db.GetModuleVersionState(ctx, successor, info.Version)
+	if !errors.Is(err, derrors.NotFound) {
+		// Either the successor is already known, or there was an error.
+		return err
+	}
+	log.Infof(ctx, "enqueuing successor %s@%s", successor, info.Version)
+	return db.InsertIndexVersions(ctx, []*internal.IndexVersion{{
+		Path:      successor,
+		Version:   info.Version,
+		Timestamp: info.Time,
+	}})
+}
+
 func updateVersionMap(ctx context.Context, db *postgres.DB, ft *fetchTask) (err error) {
 	start := time.Now()
 	defer func() {
@@ -241,6 +305,12 @@ func deleteModule(ctx context.Context, db *postgres.DB, ft *fetchTask) (err erro
 		if err := db.DeleteOlderVersionFromSearchDocuments(ctx, ft.ModulePath, ft.ResolvedVersion); err != nil {
 			return err
 		}
+		// Also forward the pages of the module to its new path. The
+		// redirect applies only while this is the latest version of the
+		// module; see DB.GetModulePathRedirect.
+		if err := db.RecordModulePathRedirect(ctx, ft.ModulePath, ft.GoModPath, ft.ResolvedVersion); err != nil {
+			return err
+		}
 	}
 	return nil
 }
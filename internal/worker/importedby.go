@@ -0,0 +1,108 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/log"
+)
+
+var (
+	importedByCountStaleness = stats.Float64(
+		"go-discovery/worker/imported-by-count-staleness",
+		"The time since the start of the last run that recomputed all imported-by counts.",
+		stats.UnitSeconds,
+	)
+
+	// ImportedByCountStaleness is the age of the imported-by counts in
+	// search_documents: the time since the last run that recomputed all of
+	// them started. It is recorded each time update-imported-by-count is
+	// run.
+	ImportedByCountStaleness = &view.View{
+		Name:        "go-discovery/worker/imported-by-count-staleness",
+		Measure:     importedByCountStaleness,
+		Aggregation: view.LastValue(),
+		Description: "Age of the imported-by counts, in seconds",
+	}
+)
+
+// handleUpdateImportedByCount recomputes the imported-by counts of the
+// packages in search_documents, in batches of the size given by the "batch"
+// query parameter, until they are all done or the number of minutes given by
+// the "minutes" query parameter have passed. Its progress is recorded after
+// each batch, so the next request resumes where it stopped.
+func (s *Server) handleUpdateImportedByCount(w http.ResponseWriter, r *http.Request) (err error) {
+	defer derrors.Wrap(&err, "handleUpdateImportedByCount(%q)", r.URL.RawQuery)
+
+	ctx := r.Context()
+	batchSize, err := positiveIntParam(r, "batch", 1000)
+	if err != nil {
+		return &serverError{http.StatusBadRequest, err}
+	}
+	minutes, err := positiveIntParam(r, "minutes", 10)
+	if err != nil {
+		return &serverError{http.StatusBadRequest, err}
+	}
+	deadline := time.Now().Add(time.Duration(minutes) * time.Minute)
+
+	run, err := s.db.StartImportedByCountRun(ctx)
+	if err != nil {
+		return err
+	}
+	for finished := false; !finished; {
+		if time.Now().After(deadline) {
+			log.Infof(ctx, "imported-by count run %d stopped after %q: %d packages done, %d changed",
+				run.ID, run.LastPackagePath, run.PackagesDone, run.PackagesChanged)
+			fmt.Fprintf(w, "run %d: %d packages done, %d changed; not finished\n", run.ID, run.PackagesDone, run.PackagesChanged)
+			return s.recordImportedByCountStaleness(ctx)
+		}
+		finished, err = s.db.UpdateImportedByCountBatch(ctx, run, batchSize)
+		if err != nil {
+			return err
+		}
+	}
+	log.Infof(ctx, "imported-by count run %d finished: %d packages done, %d changed",
+		run.ID, run.PackagesDone, run.PackagesChanged)
+	fmt.Fprintf(w, "run %d: %d packages done, %d changed; finished\n", run.ID, run.PackagesDone, run.PackagesChanged)
+	return s.recordImportedByCountStaleness(ctx)
+}
+
+// recordImportedByCountStaleness records the age of the imported-by counts,
+// if they have ever all been computed.
+func (s *Server) recordImportedByCountStaleness(ctx context.Context) error {
+	run, err := s.db.GetLastFinishedImportedByCountRun(ctx)
+	if errors.Is(err, derrors.NotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	stats.Record(ctx, importedByCountStaleness.M(time.Since(run.StartedAt).Seconds()))
+	return nil
+}
+
+// positiveIntParam returns the value of the query parameter of r with the
+// given name, which must be a positive integer, or defaultValue if it is not
+// set.
+func positiveIntParam(r *http.Request, name string, defaultValue int) (int, error) {
+	v := r.FormValue(name)
+	if v == "" {
+		return defaultValue, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("%s must be a positive integer: %q", name, v)
+	}
+	return n, nil
+}
@@ -0,0 +1,116 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package worker
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/licenses"
+	"golang.org/x/pkgsite/internal/log"
+)
+
+// handleLicenseReport detects the licenses of a random sample of at most
+// "limit" stored module versions again, with the license detection of this
+// binary, and reports the module versions whose redistributability or license
+// types would change if they were reprocessed. It changes nothing, so it can
+// be run before a change to license detection is rolled out, to assess its
+// impact.
+func (s *Server) handleLicenseReport(w http.ResponseWriter, r *http.Request) (err error) {
+	defer derrors.Wrap(&err, "handleLicenseReport(%q)", r.URL.Path)
+
+	ctx := r.Context()
+	mis, err := s.db.GetLicenseSample(ctx, parseLimitParam(r, 100))
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	var nRedist, nTypes, nErrors int
+	for _, mi := range mis {
+		stored, err := s.db.GetModuleLicenseMetadata(ctx, mi.ModulePath, mi.Version)
+		if err != nil {
+			return err
+		}
+		zr, err := s.proxyClient.GetZip(ctx, mi.ModulePath, mi.Version)
+		if err != nil {
+			// Report the error, so that one module version that can't be
+			// downloaded doesn't prevent the others from being checked.
+			log.Warningf(ctx, "license report: %v", err)
+			fmt.Fprintf(w, "%s@%s: error: %v\n", mi.ModulePath, mi.Version, err)
+			nErrors++
+			continue
+		}
+		d := licenses.NewDetector(mi.ModulePath, mi.Version, zr, nil)
+		var detected []*licenses.Metadata
+		for _, l := range d.AllLicenses() {
+			detected = append(detected, l.Metadata)
+		}
+		changes := diffLicenseMetadata(stored, detected)
+		redist := d.ModuleIsRedistributable()
+		switch {
+		case redist != mi.IsRedistributable:
+			fmt.Fprintf(w, "%s@%s: redistributable: %t -> %t\n", mi.ModulePath, mi.Version, mi.IsRedistributable, redist)
+			nRedist++
+		case len(changes) > 0:
+			fmt.Fprintf(w, "%s@%s: license types changed\n", mi.ModulePath, mi.Version)
+			nTypes++
+		}
+		for _, c := range changes {
+			fmt.Fprintf(w, "\t%s\n", c)
+		}
+	}
+	fmt.Fprintf(w, "checked %d module versions: %d would change redistributability, %d only license types, %d errors\n",
+		len(mis), nRedist, nTypes, nErrors)
+	return nil
+}
+
+// diffLicenseMetadata describes the differences between the license files in
+// stored and detected, one per file path, in the form
+//
+//	LICENSE: MIT -> UNKNOWN
+//
+// where "(none)" stands for a file that is missing from one of them.
+func diffLicenseMetadata(stored, detected []*licenses.Metadata) []string {
+	oldTypes := licenseTypesByPath(stored)
+	newTypes := licenseTypesByPath(detected)
+	paths := map[string]bool{}
+	for p := range oldTypes {
+		paths[p] = true
+	}
+	for p := range newTypes {
+		paths[p] = true
+	}
+	var changes []string
+	for p := range paths {
+		o, ok := oldTypes[p]
+		if !ok {
+			o = "(none)"
+		}
+		n, ok := newTypes[p]
+		if !ok {
+			n = "(none)"
+		}
+		if o != n {
+			changes = append(changes, fmt.Sprintf("%s: %s -> %s", p, o, n))
+		}
+	}
+	sort.Strings(changes)
+	return changes
+}
+
+// licenseTypesByPath returns a map from the file path of each element of mds
+// to its sorted license types, joined by commas.
+func licenseTypesByPath(mds []*licenses.Metadata) map[string]string {
+	m := map[string]string{}
+	for _, md := range mds {
+		types := append([]string(nil), md.Types...)
+		sort.Strings(types)
+		m[md.FilePath] = strings.Join(types, ",")
+	}
+	return m
+}
@@ -0,0 +1,36 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package worker
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/pkgsite/internal/licenses"
+)
+
+func TestDiffLicenseMetadata(t *testing.T) {
+	stored := []*licenses.Metadata{
+		{FilePath: "LICENSE", Types: []string{"MIT"}},
+		{FilePath: "a/LICENSE", Types: []string{"BSD-3-Clause", "Apache-2.0"}},
+		{FilePath: "b/LICENSE", Types: []string{"UNKNOWN"}},
+	}
+	detected := []*licenses.Metadata{
+		{FilePath: "LICENSE", Types: []string{"MIT"}},
+		{FilePath: "a/LICENSE", Types: []string{"Apache-2.0", "BSD-3-Clause"}},
+		{FilePath: "b/LICENSE", Types: []string{"BSD-0-Clause"}},
+		{FilePath: "c/COPYING", Types: []string{"GPL2"}},
+	}
+	want := []string{
+		"b/LICENSE: UNKNOWN -> BSD-0-Clause",
+		"c/COPYING: (none) -> GPL2",
+	}
+	if diff := cmp.Diff(want, diffLicenseMetadata(stored, detected)); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+	if got := diffLicenseMetadata(detected, detected); got != nil {
+		t.Errorf("diffLicenseMetadata of the same licenses = %v, want nil", got)
+	}
+}
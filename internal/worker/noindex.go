@@ -0,0 +1,65 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package worker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// handleNoIndex administers the path prefixes whose pages the frontend asks
+// search engines not to index. GET lists them as JSON. POST adds the
+// "prefix" query parameter, with a "reason" and optionally the "user" who
+// added it. DELETE removes the "prefix".
+func (s *Server) handleNoIndex(w http.ResponseWriter, r *http.Request) (err error) {
+	defer derrors.Wrap(&err, "handleNoIndex(%s)", r.Method)
+
+	ctx := r.Context()
+	prefix := strings.TrimSpace(r.FormValue("prefix"))
+	switch r.Method {
+	case http.MethodGet:
+		nps, err := s.db.GetNoIndexPrefixes(ctx)
+		if err != nil {
+			return err
+		}
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(nps)
+	case http.MethodPost:
+		reason := strings.TrimSpace(r.FormValue("reason"))
+		if prefix == "" || reason == "" {
+			return &serverError{http.StatusBadRequest, errors.New("prefix and reason are required")}
+		}
+		user := r.FormValue("user")
+		if user == "" {
+			user = "worker"
+		}
+		if err := s.db.InsertNoIndexPrefix(ctx, prefix, user, reason); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "Search engines will not index pages of paths with prefix %q.\n", prefix)
+		return nil
+	case http.MethodDelete:
+		if prefix == "" {
+			return &serverError{http.StatusBadRequest, errors.New("prefix is required")}
+		}
+		if err := s.db.DeleteNoIndexPrefix(ctx, prefix); err != nil {
+			if errors.Is(err, derrors.NotFound) {
+				return &serverError{http.StatusNotFound, fmt.Errorf("no prefix %q", prefix)}
+			}
+			return err
+		}
+		fmt.Fprintf(w, "Removed prefix %q.\n", prefix)
+		return nil
+	default:
+		return &serverError{http.StatusMethodNotAllowed, errors.New("method must be GET, POST or DELETE")}
+	}
+}
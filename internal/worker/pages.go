@@ -89,6 +89,7 @@ func (s *Server) doIndexPage(w http.ResponseWriter, r *http.Request) (err error)
 		SystemStats           systemMemStats
 		CgroupStats           map[string]uint64
 		Fetches               []*fetch.FetchInfo
+		DocSizes              *fetch.DocSizeStats
 	}{
 		Config:                s.cfg,
 		Env:                   env(s.cfg),
@@ -105,6 +106,7 @@ func (s *Server) doIndexPage(w http.ResponseWriter, r *http.Request) (err error)
 		SystemStats:           sms,
 		CgroupStats:           getCgroupMemStats(),
 		Fetches:               fetch.FetchInfos(),
+		DocSizes:              fetch.DocSizes(),
 	}
 	return renderPage(ctx, w, page, s.templates[indexTemplate])
 }
@@ -191,6 +193,79 @@ func (s *Server) doVersionsPage(w http.ResponseWriter, r *http.Request) (err err
 	return renderPage(ctx, w, page, s.templates[versionsTemplate])
 }
 
+// maxFeedbackReports is the number of problem reports shown on the feedback
+// page.
+const maxFeedbackReports = 100
+
+// doFeedbackPage writes the page that lists problem reports for triage.
+func (s *Server) doFeedbackPage(w http.ResponseWriter, r *http.Request) (err error) {
+	defer derrors.Wrap(&err, "doFeedbackPage")
+	ctx := r.Context()
+	status := internal.FeedbackStatusNew
+	if v := r.FormValue("status"); v != "" {
+		status, err = parseFeedbackStatus(v)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return nil
+		}
+	}
+	reports, err := s.db.GetFeedbackReports(ctx, status, maxFeedbackReports)
+	if err != nil {
+		return err
+	}
+	page := struct {
+		Env      string
+		Status   internal.FeedbackStatus
+		Statuses []internal.FeedbackStatus
+		Reports  []*internal.FeedbackReport
+	}{
+		Env:      env(s.cfg),
+		Status:   status,
+		Statuses: internal.FeedbackStatuses,
+		Reports:  reports,
+	}
+	return renderPage(ctx, w, page, s.templates[feedbackTemplate])
+}
+
+// maxExcludedPrefixEvents is the number of changes to the excluded prefixes
+// shown on the excluded page.
+const maxExcludedPrefixEvents = 100
+
+// maxExcludedPrefixPurges is the number of purges shown on the excluded page.
+const maxExcludedPrefixPurges = 20
+
+// doExcludedPage writes the page that administers the excluded prefixes.
+func (s *Server) doExcludedPage(w http.ResponseWriter, r *http.Request) (err error) {
+	defer derrors.Wrap(&err, "doExcludedPage")
+	ctx := r.Context()
+	prefixes, err := s.db.GetExcludedPrefixDetails(ctx)
+	if err != nil {
+		return err
+	}
+	events, err := s.db.GetExcludedPrefixEvents(ctx, maxExcludedPrefixEvents)
+	if err != nil {
+		return err
+	}
+	purges, err := s.db.GetExcludedPrefixPurges(ctx, maxExcludedPrefixPurges)
+	if err != nil {
+		return err
+	}
+	page := struct {
+		Env      string
+		Scopes   []postgres.ExclusionScope
+		Prefixes []*postgres.ExcludedPrefix
+		Events   []*postgres.ExcludedPrefixEvent
+		Purges   []*postgres.ExcludedPrefixPurge
+	}{
+		Env:      env(s.cfg),
+		Scopes:   postgres.ExclusionScopes,
+		Prefixes: prefixes,
+		Events:   events,
+		Purges:   purges,
+	}
+	return renderPage(ctx, w, page, s.templates[excludedTemplate])
+}
+
 func env(cfg *config.Config) string {
 	e := cfg.DeploymentEnvironment()
 	return strings.ToUpper(e[:1]) + e[1:]
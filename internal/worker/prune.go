@@ -0,0 +1,152 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package worker
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/log"
+)
+
+// A retentionPolicy says which module versions, or parts of them, are
+// removed from the database to limit its growth. Tagged versions are always
+// kept, as is every version that search results link to.
+type retentionPolicy struct {
+	// keepPseudo is the number of the most recent pseudo-versions of each
+	// module that are kept; older ones are deleted, once the module has a
+	// tagged version after them. Deleting a module version deletes
+	// everything stored about it.
+	keepPseudo int
+
+	// pseudoDays is the number of days after its commit that a
+	// pseudo-version is kept, even if it is not among the newest keepPseudo.
+	// If both keepPseudo and pseudoDays are zero, no pseudo-versions are
+	// deleted.
+	pseudoDays int
+
+	// unviewedDays is the number of days after which the documentation HTML
+	// of a module version that nobody has viewed is dropped. Its other
+	// information is kept, and the frontend renders the documentation from
+	// its source if it is viewed again. If it is zero, no documentation
+	// HTML is dropped.
+	unviewedDays int
+
+	// limit is the maximum number of module versions affected by each rule
+	// in one run.
+	limit int
+
+	// apply says whether to carry out the policy. Otherwise, the effects of
+	// the policy are only reported.
+	apply bool
+}
+
+// parseRetentionPolicy parses a retentionPolicy from the query parameters
+// of r: "pseudo" for keepPseudo, "pseudo-days" for pseudoDays,
+// "unviewed-days" for unviewedDays, "limit" and "apply". Without
+// "apply=true", the run is a dry run.
+func parseRetentionPolicy(r *http.Request) (_ *retentionPolicy, err error) {
+	defer derrors.Wrap(&err, "parseRetentionPolicy(%q)", r.URL.RawQuery)
+
+	p := &retentionPolicy{limit: parseLimitParam(r, 100)}
+	for _, param := range []struct {
+		name string
+		ptr  *int
+	}{
+		{"pseudo", &p.keepPseudo},
+		{"pseudo-days", &p.pseudoDays},
+		{"unviewed-days", &p.unviewedDays},
+	} {
+		v := r.FormValue(param.name)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("%s must be a positive integer: %q", param.name, v)
+		}
+		*param.ptr = n
+	}
+	if p.keepPseudo == 0 && p.pseudoDays == 0 && p.unviewedDays == 0 {
+		return nil, fmt.Errorf(`at least one of "pseudo", "pseudo-days" and "unviewed-days" must be set`)
+	}
+	if v := r.FormValue("apply"); v != "" {
+		p.apply, err = strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("apply must be a boolean: %q", v)
+		}
+	}
+	return p, nil
+}
+
+// handlePrune removes module versions, or parts of them, from the database
+// according to the retention policy in the query parameters; see
+// parseRetentionPolicy. It reports what it did, or would do in a dry run.
+func (s *Server) handlePrune(w http.ResponseWriter, r *http.Request) (err error) {
+	defer derrors.Wrap(&err, "handlePrune(%q)", r.URL.Path)
+
+	ctx := r.Context()
+	p, err := parseRetentionPolicy(r)
+	if err != nil {
+		return &serverError{http.StatusBadRequest, err}
+	}
+	// report reports an action, in the past tense if the policy is applied.
+	report := func(did, wouldDo, format string, args ...interface{}) {
+		verb := wouldDo
+		if p.apply {
+			verb = did
+		}
+		msg := verb + " " + fmt.Sprintf(format, args...)
+		log.Infof(ctx, "prune: %s", msg)
+		fmt.Fprintln(w, msg)
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if !p.apply {
+		io.WriteString(w, "dry run: pass apply=true to carry out the policy\n")
+	}
+
+	if p.keepPseudo > 0 || p.pseudoDays > 0 {
+		before := time.Now().Add(-time.Duration(p.pseudoDays) * 24 * time.Hour)
+		mis, err := s.db.GetPrunablePseudoVersions(ctx, p.keepPseudo, before, p.limit)
+		if err != nil {
+			return err
+		}
+		for _, mi := range mis {
+			if p.apply {
+				if err := s.db.DeleteModule(ctx, mi.ModulePath, mi.Version); err != nil {
+					return err
+				}
+			}
+			report("deleted", "would delete", "%s@%s", mi.ModulePath, mi.Version)
+		}
+		report("deleted", "would delete", "%d pseudo-versions, keeping the newest %d of each module and those committed in the last %d days",
+			len(mis), p.keepPseudo, p.pseudoDays)
+	}
+
+	if p.unviewedDays > 0 {
+		since := time.Now().Add(-time.Duration(p.unviewedDays) * 24 * time.Hour)
+		mis, err := s.db.GetUnviewedDocumentationVersions(ctx, since, p.limit)
+		if err != nil {
+			return err
+		}
+		for _, mi := range mis {
+			what := fmt.Sprintf("%s@%s", mi.ModulePath, mi.Version)
+			if p.apply {
+				n, err := s.db.DropDocumentationHTML(ctx, mi.ModulePath, mi.Version)
+				if err != nil {
+					return err
+				}
+				what += fmt.Sprintf(" (%d packages)", n)
+			}
+			report("dropped", "would drop", "documentation HTML of %s", what)
+		}
+		report("dropped", "would drop", "documentation HTML of %d module versions not viewed for %d days", len(mis), p.unviewedDays)
+	}
+	return nil
+}
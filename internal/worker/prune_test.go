@@ -0,0 +1,52 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package worker
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseRetentionPolicy(t *testing.T) {
+	for _, test := range []struct {
+		query   string
+		want    *retentionPolicy
+		wantErr bool
+	}{
+		{
+			query: "pseudo=3",
+			want:  &retentionPolicy{keepPseudo: 3, limit: 100},
+		},
+		{
+			query: "unviewed-days=365&limit=10&apply=true",
+			want:  &retentionPolicy{unviewedDays: 365, limit: 10, apply: true},
+		},
+		{
+			query: "pseudo-days=90",
+			want:  &retentionPolicy{pseudoDays: 90, limit: 100},
+		},
+		{
+			query: "pseudo=2&pseudo-days=30",
+			want:  &retentionPolicy{keepPseudo: 2, pseudoDays: 30, limit: 100},
+		},
+		{query: "", wantErr: true},
+		{query: "pseudo-days=-1", wantErr: true},
+		{query: "pseudo=0", wantErr: true},
+		{query: "pseudo=x", wantErr: true},
+		{query: "pseudo=1&apply=maybe", wantErr: true},
+	} {
+		r := httptest.NewRequest("GET", "/prune?"+test.query, nil)
+		got, err := parseRetentionPolicy(r)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%q: got error %v, want error: %t", test.query, err, test.wantErr)
+			continue
+		}
+		if diff := cmp.Diff(test.want, got, cmp.AllowUnexported(retentionPolicy{})); diff != "" {
+			t.Errorf("%q: mismatch (-want +got):\n%s", test.query, diff)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package worker
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/pkgsite/internal/log"
+	"golang.org/x/pkgsite/internal/postgres"
+)
+
+// ReconvertSearchDocuments converts the search documents whose tokens were
+// not converted with the go_text text search configuration again, batchSize
+// of them every interval, until there are none left or ctx is done.
+//
+// Queries are parsed with go_text, so until a document is reconverted, search
+// may miss words in its synopsis or README that the two configurations
+// convert differently.
+func (s *Server) ReconvertSearchDocuments(ctx context.Context, batchSize int, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	total := 0
+	for {
+		n, err := s.reconvertSearchDocuments(ctx, batchSize)
+		if err != nil {
+			log.Errorf(ctx, "ReconvertSearchDocuments: %v", err)
+		} else if n == 0 {
+			if total > 0 {
+				log.Infof(ctx, "ReconvertSearchDocuments: done after %d search documents", total)
+			}
+			return
+		}
+		total += n
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// reconvertSearchDocuments converts at most limit search documents with
+// outdated tokens again, and returns how many it converted.
+func (s *Server) reconvertSearchDocuments(ctx context.Context, limit int) (int, error) {
+	sdargs, err := s.db.GetPackagesForSearchDocumentReconvert(ctx, limit)
+	if err != nil {
+		return 0, err
+	}
+	for _, args := range sdargs {
+		if err := postgres.UpsertSearchDocument(ctx, s.db.Underlying(), args); err != nil {
+			return 0, err
+		}
+	}
+	return len(sdargs), nil
+}
@@ -0,0 +1,75 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package worker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"golang.org/x/pkgsite/internal/derrors"
+)
+
+// handleModuleRedirects administers the overrides of the redirects from the
+// pages of modules that moved to a new path. GET lists them as JSON. POST
+// redirects the module path in the "from" query parameter to the one in
+// "to", or turns off its redirect if "to" is empty, with a "reason" and
+// optionally the "user" who set it. DELETE removes the override for "from",
+// so that the redirect detected by the worker, if any, applies again.
+func (s *Server) handleModuleRedirects(w http.ResponseWriter, r *http.Request) (err error) {
+	defer derrors.Wrap(&err, "handleModuleRedirects(%s)", r.Method)
+
+	ctx := r.Context()
+	from := strings.TrimSpace(r.FormValue("from"))
+	switch r.Method {
+	case http.MethodGet:
+		overrides, err := s.db.GetModulePathRedirectOverrides(ctx)
+		if err != nil {
+			return err
+		}
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(overrides)
+	case http.MethodPost:
+		to := strings.TrimSpace(r.FormValue("to"))
+		reason := strings.TrimSpace(r.FormValue("reason"))
+		if from == "" || reason == "" {
+			return &serverError{http.StatusBadRequest, errors.New("from and reason are required")}
+		}
+		if to == from {
+			return &serverError{http.StatusBadRequest, errors.New("from and to must differ")}
+		}
+		user := r.FormValue("user")
+		if user == "" {
+			user = "worker"
+		}
+		if err := s.db.InsertModulePathRedirectOverride(ctx, from, to, user, reason); err != nil {
+			return err
+		}
+		if to == "" {
+			fmt.Fprintf(w, "Pages of %q will not be redirected.\n", from)
+		} else {
+			fmt.Fprintf(w, "Pages of %q will be redirected to %q.\n", from, to)
+		}
+		return nil
+	case http.MethodDelete:
+		if from == "" {
+			return &serverError{http.StatusBadRequest, errors.New("from is required")}
+		}
+		if err := s.db.DeleteModulePathRedirectOverride(ctx, from); err != nil {
+			if errors.Is(err, derrors.NotFound) {
+				return &serverError{http.StatusNotFound, fmt.Errorf("no override for %q", from)}
+			}
+			return err
+		}
+		fmt.Fprintf(w, "Removed the override for %q.\n", from)
+		return nil
+	default:
+		return &serverError{http.StatusMethodNotAllowed, errors.New("method must be GET, POST or DELETE")}
+	}
+}
@@ -9,10 +9,13 @@ package worker
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"path"
 	"reflect"
 	"strconv"
 	"strings"
@@ -26,7 +29,9 @@ import (
 	"go.opencensus.io/stats/view"
 	"go.opencensus.io/tag"
 	"go.opencensus.io/trace"
+	"golang.org/x/mod/semver"
 	"golang.org/x/pkgsite/internal"
+	"golang.org/x/pkgsite/internal/apierror"
 	"golang.org/x/pkgsite/internal/config"
 	"golang.org/x/pkgsite/internal/derrors"
 	"golang.org/x/pkgsite/internal/index"
@@ -74,6 +79,8 @@ type ServerConfig struct {
 const (
 	indexTemplate    = "index.tmpl"
 	versionsTemplate = "versions.tmpl"
+	feedbackTemplate = "feedback.tmpl"
+	excludedTemplate = "excluded.tmpl"
 )
 
 // NewServer creates a new Server with the given dependencies.
@@ -87,9 +94,19 @@ func NewServer(cfg *config.Config, scfg ServerConfig) (_ *Server, err error) {
 	if err != nil {
 		return nil, err
 	}
+	t3, err := parseTemplate(scfg.StaticPath, template.TrustedSourceFromConstant(feedbackTemplate))
+	if err != nil {
+		return nil, err
+	}
+	t4, err := parseTemplate(scfg.StaticPath, template.TrustedSourceFromConstant(excludedTemplate))
+	if err != nil {
+		return nil, err
+	}
 	templates := map[string]*template.Template{
 		indexTemplate:    t1,
 		versionsTemplate: t2,
+		feedbackTemplate: t3,
+		excludedTemplate: t4,
 	}
 
 	return &Server{
@@ -125,12 +142,54 @@ func (s *Server) Install(handle func(string, http.Handler)) {
 	// See the note about duplicate tasks for "/enqueue" below.
 	handle("/poll", rmw(s.errorHandler(s.handlePollIndex)))
 
-	// scheduled: update-imported-by-count update the imported_by_count for
-	// packages in search_documents where imported_by_count_updated_at is null
-	// or imported_by_count_updated_at < version_updated_at.
+	// scheduled: update-imported-by-count recomputes the imported_by_count
+	// of the packages in search_documents, in batches of "batch" packages
+	// (default 1000), for at most "minutes" minutes (default 10). A run
+	// that doesn't finish is resumed by the next request. See doc/worker.md.
 	// This endpoint is intended to be invoked periodically by a scheduler.
 	handle("/update-imported-by-count", rmw(s.errorHandler(s.handleUpdateImportedByCount)))
 
+	// scheduled: index-module-dependencies computes the transitive module
+	// dependencies of module versions that have not been indexed, or whose
+	// requirement graph was incomplete when they were, so that the frontend
+	// can answer whether one module depends on another.
+	// This endpoint is intended to be invoked periodically by a scheduler.
+	handle("/index-module-dependencies", rmw(s.errorHandler(s.handleIndexModuleDependencies)))
+
+	// scheduled: verify-proxy-versions asks the proxy whether it still serves
+	// the module versions that were least recently checked, and marks those
+	// for which it responds 410 Gone, so that they are no longer considered
+	// the latest version. Their documentation is kept.
+	// This endpoint is intended to be invoked periodically by a scheduler.
+	handle("/verify-proxy-versions", rmw(s.errorHandler(s.handleVerifyProxyVersions)))
+
+	// scheduled: check-stale-latest asks the proxy for the @latest version of
+	// modules that have had no new version in the index for the number of
+	// days in the "days" query parameter (default 30), and enqueues a fetch
+	// for those whose latest version is newer than any we know of. This keeps
+	// latest versions fresh without scanning the whole index.
+	// This endpoint is intended to be invoked periodically by a scheduler.
+	handle("/check-stale-latest", rmw(s.errorHandler(s.handleCheckStaleLatest)))
+
+	// scheduled: refresh-version-map asks the proxy again what requested
+	// versions like "master" resolve to, for the version_map entries of
+	// recently viewed modules that have not been updated for a while, and
+	// enqueues fetches of those that moved. See handleRefreshVersionMap.
+	// This endpoint is intended to be invoked periodically by a scheduler.
+	handle("/refresh-version-map", rmw(s.errorHandler(s.handleRefreshVersionMap)))
+
+	// scheduled: prune removes module versions, or parts of them, from the
+	// database according to a retention policy given by query parameters:
+	// "pseudo" keeps only that many of the newest pseudo-versions of each
+	// module, "pseudo-days" keeps pseudo-versions committed within that many
+	// days, and "unviewed-days" drops the documentation HTML of versions
+	// not viewed for that many days. Tagged versions, pseudo-versions newer
+	// than every tagged version, and the versions in search results are
+	// always kept. Unless "apply" is true, it only
+	// reports what it would do. See doc/worker.md.
+	// This endpoint is intended to be invoked periodically by a scheduler.
+	handle("/prune", rmw(s.errorHandler(s.handlePrune)))
+
 	// scheduled: download search document data and update the redis sorted
 	// set(s) used in auto-completion.
 	handle("/update-redis-indexes", rmw(s.errorHandler(s.handleUpdateRedisIndexes)))
@@ -173,6 +232,13 @@ func (s *Server) Install(handle func(string, http.Handler)) {
 	// be reprocessed.
 	handle("/reprocess", rmw(s.errorHandler(s.handleReprocess)))
 
+	// manual: license-report detects the licenses of a random sample of at
+	// most "limit" stored module versions again, and reports those whose
+	// redistributability or license types would change. Run it with a new
+	// version of license detection before rolling it out. It changes
+	// nothing. See doc/worker.md.
+	handle("/license-report", rmw(s.errorHandler(s.handleLicenseReport)))
+
 	// manual: populate-stdlib inserts all modules of the Go standard
 	// library into the tasks queue to be processed and inserted into the
 	// database. handlePopulateStdLib should be updated whenever a new
@@ -188,6 +254,57 @@ func (s *Server) Install(handle func(string, http.Handler)) {
 	// manual: clear-cache clears the redis cache.
 	handle("/clear-cache", rmw(s.errorHandler(s.clearCache)))
 
+	// manual: snapshot asks the frontend to record a sample of the requests
+	// whose paths match the "pattern" query parameter, along with their
+	// responses, into the response_snapshots table. The optional "rate"
+	// parameter is the fraction of matching requests to record (default 0.1),
+	// and "minutes" is how long to record for (default 30, at most a day).
+	handle("/snapshot", rmw(s.errorHandler(s.handleSnapshot)))
+
+	// manual: search-snapshot writes a snapshot of the search corpus, for
+	// offline relevance experiments, in the format described in
+	// doc/worker.md. If the "limit" query parameter is positive, at most that
+	// many search documents are included.
+	handle("/search-snapshot", rmw(s.errorHandler(s.handleSearchSnapshot)))
+
+	// manual: feedback/triage sets the status of the problem report with
+	// the given "id" to "status", and returns to the feedback page.
+	handle("/feedback/triage", rmw(s.errorHandler(s.handleFeedbackTriage)))
+
+	// manual: excluded/add excludes the path "prefix", which may be a
+	// pattern, for the given "reason", and returns to the excluded page. With
+	// "purge=true", it also starts a purge of the prefix, as excluded/purge
+	// does.
+	handle("/excluded/add", rmw(s.errorHandler(s.handleExcludedAdd)))
+
+	// manual: excluded/remove stops excluding the path "prefix", for the
+	// given "reason", and returns to the excluded page.
+	handle("/excluded/remove", rmw(s.errorHandler(s.handleExcludedRemove)))
+
+	// manual: excluded/purge starts a background job that deletes the module
+	// versions and search documents matching the excluded "prefix", and
+	// returns to the excluded page, which shows the job's progress.
+	handle("/excluded/purge", rmw(s.errorHandler(s.handleExcludedPurge)))
+
+	// manual: provenance returns, as JSON, the records of where and when the
+	// zip of the given module version was downloaded, most recent first. The
+	// path is of the form /provenance/<module>/@v/<version>.
+	handle("/provenance/", http.StripPrefix("/provenance", rmw(s.jsonErrorHandler(s.handleProvenance))))
+
+	// manual: noindex lists, adds or removes the path prefixes whose pages
+	// the frontend asks search engines not to index, with GET, POST and
+	// DELETE. See handleNoIndex.
+	handle("/noindex", rmw(s.errorHandler(s.handleNoIndex)))
+
+	// manual: experiments lists, sets or removes the experiments in the
+	// experiments table, with GET, POST and DELETE. See handleExperiments.
+	handle("/experiments", rmw(s.errorHandler(s.handleExperiments)))
+
+	// manual: module-redirects lists, sets or removes the overrides of the
+	// redirects from the pages of modules that moved to a new path, with
+	// GET, POST and DELETE. See handleModuleRedirects.
+	handle("/module-redirects", rmw(s.errorHandler(s.handleModuleRedirects)))
+
 	// manual: delete the specified module version.
 	handle("/delete/", http.StripPrefix("/delete", rmw(s.errorHandler(s.handleDelete))))
 
@@ -196,6 +313,15 @@ func (s *Server) Install(handle func(string, http.Handler)) {
 	// returns an HTML page displaying information about recent versions that were processed.
 	handle("/versions", http.HandlerFunc(s.handleHTMLPage(s.doVersionsPage)))
 
+	// manual: feedback lists the problem reports that users sent from
+	// documentation pages, with the status given by the "status" query
+	// parameter (default "new"), for triage.
+	handle("/feedback", http.HandlerFunc(s.handleHTMLPage(s.doFeedbackPage)))
+
+	// manual: excluded lists the excluded path prefixes, with forms to add
+	// and remove them, and the recent history of changes to them.
+	handle("/excluded", http.HandlerFunc(s.handleHTMLPage(s.doExcludedPage)))
+
 	// Health check.
 	handle("/healthz", http.HandlerFunc(s.handleHealthCheck))
 
@@ -203,16 +329,123 @@ func (s *Server) Install(handle func(string, http.Handler)) {
 	handle("/", http.HandlerFunc(s.handleHTMLPage(s.doIndexPage)))
 }
 
-// handleUpdateImportedByCount updates imported_by_count for all packages.
-func (s *Server) handleUpdateImportedByCount(w http.ResponseWriter, r *http.Request) error {
-	n, err := s.db.UpdateSearchDocumentsImportedByCount(r.Context())
+// handleIndexModuleDependencies indexes the dependencies of at most "limit"
+// module versions.
+func (s *Server) handleIndexModuleDependencies(w http.ResponseWriter, r *http.Request) error {
+	n, err := s.db.IndexModuleDependencies(r.Context(), parseLimitParam(r, 100))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "indexed dependencies of %d module versions", n)
+	return nil
+}
+
+// handleVerifyProxyVersions checks whether the proxy still serves at most
+// "limit" module versions.
+func (s *Server) handleVerifyProxyVersions(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	mis, err := s.db.GetModuleVersionsToVerify(ctx, parseLimitParam(r, 100))
+	if err != nil {
+		return err
+	}
+	var gone int
+	for _, mi := range mis {
+		status, err := proxyStatus(ctx, s.proxyClient, mi.ModulePath, mi.Version)
+		if err != nil {
+			// Record the check anyway, so that one failing module
+			// version doesn't prevent the others from being checked.
+			log.Warningf(ctx, "checking %s@%s: %v", mi.ModulePath, mi.Version, err)
+		}
+		if status == http.StatusGone {
+			log.Infof(ctx, "%s@%s is gone from the proxy", mi.ModulePath, mi.Version)
+			gone++
+		}
+		if err := s.db.UpdateModuleProxyStatus(ctx, mi.ModulePath, mi.Version, status); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(w, "checked %d module versions, %d gone from the proxy", len(mis), gone)
+	return nil
+}
+
+// defaultStaleDays is the default number of days without a new version after
+// which the @latest version of a module is checked.
+const defaultStaleDays = 30
+
+// handleCheckStaleLatest checks the @latest version of at most "limit" modules
+// that have not been updated for "days" days, and enqueues fetches of the
+// versions that are newer than the ones we know of.
+func (s *Server) handleCheckStaleLatest(w http.ResponseWriter, r *http.Request) (err error) {
+	defer derrors.Wrap(&err, "handleCheckStaleLatest(%q)", r.URL.Path)
+	ctx := r.Context()
+	days := defaultStaleDays
+	if v := r.FormValue("days"); v != "" {
+		days, err = strconv.Atoi(v)
+		if err != nil || days <= 0 {
+			return &serverError{http.StatusBadRequest, fmt.Errorf("days must be a positive integer: %q", v)}
+		}
+	}
+	since := time.Now().Add(-time.Duration(days) * 24 * time.Hour)
+	mis, err := s.db.GetStaleModules(ctx, since, parseLimitParam(r, 100))
 	if err != nil {
 		return err
 	}
-	fmt.Fprintf(w, "updated %d packages", n)
+	var nEnqueued int
+	for _, mi := range mis {
+		latest, err := latestVersion(ctx, s.proxyClient, mi.ModulePath)
+		if err != nil {
+			// Record the check anyway, so that one failing module doesn't
+			// prevent the others from being checked.
+			log.Warningf(ctx, "checking latest version of %s: %v", mi.ModulePath, err)
+		}
+		if latest != "" && semver.Compare(latest, mi.Version) > 0 {
+			enqueued, err := s.queue.ScheduleFetch(ctx, mi.ModulePath, latest, "", s.taskIDChangeInterval)
+			if err != nil {
+				return err
+			}
+			if enqueued {
+				log.Infof(ctx, "enqueued %s@%s, newer than %s", mi.ModulePath, latest, mi.Version)
+				nEnqueued++
+			}
+		}
+		if err := s.db.UpdateLatestCheck(ctx, mi.ModulePath, latest); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(w, "checked %d modules, enqueued %d newer versions", len(mis), nEnqueued)
 	return nil
 }
 
+// latestVersion returns the version the proxy resolves @latest to for
+// modulePath, or the empty string if the proxy doesn't know the module.
+func latestVersion(ctx context.Context, proxyClient *proxy.Client, modulePath string) (string, error) {
+	info, err := proxyClient.GetLatestInfo(ctx, modulePath)
+	if err != nil {
+		if errors.Is(err, derrors.NotFound) {
+			return "", nil
+		}
+		return "", err
+	}
+	return info.Version, nil
+}
+
+// proxyStatus asks the proxy for the .info file of modulePath@version and
+// returns the HTTP status of its response: http.StatusOK, http.StatusNotFound
+// or http.StatusGone. For any other outcome, it returns zero and the error.
+func proxyStatus(ctx context.Context, proxyClient *proxy.Client, modulePath, version string) (int, error) {
+	_, err := proxyClient.GetInfo(ctx, modulePath, version)
+	switch {
+	case err == nil:
+		return http.StatusOK, nil
+	case proxy.IsGone(err):
+		return http.StatusGone, nil
+	case errors.Is(err, derrors.NotFound):
+		return http.StatusNotFound, nil
+	default:
+		return 0, err
+	}
+}
+
 // handleRepopulateSearchDocuments repopulates every row in the search_documents table
 // that was last updated before the given time.
 func (s *Server) handleRepopulateSearchDocuments(w http.ResponseWriter, r *http.Request) error {
@@ -241,6 +474,10 @@ func (s *Server) handleRepopulateSearchDocuments(w http.ResponseWriter, r *http.
 			return err
 		}
 	}
+	// Each repopulated search document is marked as updated now, so calling
+	// this repeatedly with the same "before" works through all of them. A
+	// count of zero means that it is done.
+	fmt.Fprintf(w, "repopulated %d search documents\n", len(sdargs))
 	return nil
 }
 
@@ -283,6 +520,11 @@ func (s *Server) doFetch(r *http.Request) (string, int) {
 	}
 
 	code, err := FetchAndUpdateState(r.Context(), modulePath, version, s.proxyClient, s.sourceClient, s.db, s.cfg.AppVersionLabel())
+	if code < http.StatusInternalServerError {
+		// The module version was inserted, or its status was recorded, so the
+		// pages of the module may have changed.
+		s.invalidateModuleCache(r.Context(), modulePath)
+	}
 	if err != nil {
 		return err.Error(), code
 	}
@@ -321,8 +563,13 @@ func (s *Server) handlePollIndex(w http.ResponseWriter, r *http.Request) (err er
 	if err != nil {
 		return err
 	}
-	modules, err := s.indexClient.GetVersions(ctx, since, limit)
-	if err != nil {
+	// Stream pages through the index, so limit may be larger than the number
+	// of versions that the index returns for one request.
+	var modules []*internal.IndexVersion
+	if err := s.indexClient.Stream(ctx, since, limit, func(v *internal.IndexVersion) error {
+		modules = append(modules, v)
+		return nil
+	}); err != nil {
 		return err
 	}
 	if err := s.db.InsertIndexVersions(ctx, modules); err != nil {
@@ -466,6 +713,102 @@ func (s *Server) handleReprocess(w http.ResponseWriter, r *http.Request) error {
 	return nil
 }
 
+const (
+	defaultSnapshotRate    = 0.1
+	defaultSnapshotMinutes = 30
+	maxSnapshotMinutes     = 24 * 60
+)
+
+// handleSnapshot creates a rule for the frontend to record requests and
+// responses.
+func (s *Server) handleSnapshot(w http.ResponseWriter, r *http.Request) error {
+	pattern := r.FormValue("pattern")
+	if pattern == "" {
+		return &serverError{http.StatusBadRequest, errors.New("pattern was not specified")}
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return &serverError{http.StatusBadRequest, fmt.Errorf("invalid pattern %q: %v", pattern, err)}
+	}
+	rate := defaultSnapshotRate
+	if v := r.FormValue("rate"); v != "" {
+		var err error
+		rate, err = strconv.ParseFloat(v, 64)
+		if err != nil || rate <= 0 || rate > 1 {
+			return &serverError{http.StatusBadRequest, fmt.Errorf("rate must be in (0, 1]: %q", v)}
+		}
+	}
+	minutes := defaultSnapshotMinutes
+	if v := r.FormValue("minutes"); v != "" {
+		var err error
+		minutes, err = strconv.Atoi(v)
+		if err != nil || minutes <= 0 || minutes > maxSnapshotMinutes {
+			return &serverError{http.StatusBadRequest, fmt.Errorf("minutes must be in [1, %d]: %q", maxSnapshotMinutes, v)}
+		}
+	}
+	rule := &internal.SnapshotRule{
+		PathPattern: pattern,
+		SampleRate:  rate,
+		ExpiresAt:   time.Now().Add(time.Duration(minutes) * time.Minute),
+	}
+	id, err := s.db.InsertSnapshotRule(r.Context(), rule)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Created snapshot rule %d: recording %g of requests matching %q until %s.",
+		id, rate, pattern, rule.ExpiresAt.Format(time.RFC3339))
+	return nil
+}
+
+// handleSearchSnapshot writes a snapshot of the search corpus as JSON lines,
+// one search document per line.
+func (s *Server) handleSearchSnapshot(w http.ResponseWriter, r *http.Request) (err error) {
+	defer derrors.Wrap(&err, "handleSearchSnapshot(%q)", r.URL.Path)
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", `attachment; filename="search-snapshot.jsonl"`)
+	enc := json.NewEncoder(w)
+	return s.db.GetSearchSnapshot(r.Context(), parseLimitParam(r, 0), func(e *internal.SearchSnapshotEntry) error {
+		return enc.Encode(e)
+	})
+}
+
+// handleFeedbackTriage sets the status of a problem report.
+func (s *Server) handleFeedbackTriage(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		return &serverError{http.StatusMethodNotAllowed, errors.New("method must be POST")}
+	}
+	id, err := strconv.Atoi(r.FormValue("id"))
+	if err != nil {
+		return &serverError{http.StatusBadRequest, fmt.Errorf("invalid id %q", r.FormValue("id"))}
+	}
+	status, err := parseFeedbackStatus(r.FormValue("status"))
+	if err != nil {
+		return &serverError{http.StatusBadRequest, err}
+	}
+	if err := s.db.UpdateFeedbackReportStatus(r.Context(), id, status); err != nil {
+		if errors.Is(err, derrors.NotFound) {
+			return &serverError{http.StatusNotFound, err}
+		}
+		return err
+	}
+	back := "/feedback"
+	if from := r.FormValue("from"); from != "" {
+		back += "?status=" + url.QueryEscape(from)
+	}
+	http.Redirect(w, r, back, http.StatusSeeOther)
+	return nil
+}
+
+// parseFeedbackStatus returns the feedback status named s.
+func parseFeedbackStatus(s string) (internal.FeedbackStatus, error) {
+	for _, st := range internal.FeedbackStatuses {
+		if s == string(st) {
+			return st, nil
+		}
+	}
+	return "", fmt.Errorf("invalid status %q", s)
+}
+
 func (s *Server) clearCache(w http.ResponseWriter, r *http.Request) error {
 	if s.redisCacheClient == nil {
 		return errors.New("redis cache client is not configured")
@@ -487,10 +830,49 @@ func (s *Server) handleDelete(w http.ResponseWriter, r *http.Request) error {
 	if err := s.db.DeleteModule(r.Context(), modulePath, version); err != nil {
 		return &serverError{http.StatusInternalServerError, err}
 	}
+	s.invalidateModuleCache(r.Context(), modulePath)
 	fmt.Fprintf(w, "Deleted %s@%s", modulePath, version)
 	return nil
 }
 
+// invalidateModuleCache removes the cached frontend pages of the module with
+// the given path from the redis cache, if there is one. Failing to do so is
+// not an error: the pages expire anyway.
+func (s *Server) invalidateModuleCache(ctx context.Context, modulePath string) {
+	if s.redisCacheClient == nil {
+		return
+	}
+	n, err := middleware.InvalidateModuleCache(ctx, s.redisCacheClient, modulePath)
+	if err != nil {
+		log.Warning(ctx, err)
+		return
+	}
+	if n > 0 {
+		log.Infof(ctx, "removed %d cached pages of %s", n, modulePath)
+	}
+}
+
+// handleProvenance writes the provenance records of a module version.
+func (s *Server) handleProvenance(w http.ResponseWriter, r *http.Request) (err error) {
+	defer derrors.Wrap(&err, "handleProvenance(%q)", r.URL.Path)
+
+	modulePath, version, err := parseModulePathAndVersion(r.URL.Path)
+	if err != nil {
+		return &serverError{http.StatusBadRequest, err}
+	}
+	records, err := s.db.GetProvenance(r.Context(), modulePath, version)
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		return &serverError{http.StatusNotFound, fmt.Errorf("no provenance for %s@%s", modulePath, version)}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(records)
+}
+
 func (s *Server) handleHealthCheck(w http.ResponseWriter, r *http.Request) {
 	if err := s.db.Underlying().Ping(); err != nil {
 		http.Error(w, fmt.Sprintf("DB ping failed: %v", err), http.StatusInternalServerError)
@@ -609,8 +991,24 @@ func (s *Server) errorHandler(f func(w http.ResponseWriter, r *http.Request) err
 	}
 }
 
+// jsonErrorHandler is like errorHandler, for endpoints that respond with
+// JSON. Their errors are written with apierror.
+func (s *Server) jsonErrorHandler(f func(w http.ResponseWriter, r *http.Request) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := f(w, r); err != nil {
+			serr := toServerError(r.Context(), err)
+			apierror.Write(w, serr.status, serr.err.Error(), serr.err)
+		}
+	}
+}
+
 func (s *Server) serveError(w http.ResponseWriter, r *http.Request, err error) {
-	ctx := r.Context()
+	serr := toServerError(r.Context(), err)
+	http.Error(w, serr.err.Error(), serr.status)
+}
+
+// toServerError logs err and returns it as a serverError.
+func toServerError(ctx context.Context, err error) *serverError {
 	serr, ok := err.(*serverError)
 	if !ok {
 		serr = &serverError{status: http.StatusInternalServerError, err: err}
@@ -620,5 +1018,5 @@ func (s *Server) serveError(w http.ResponseWriter, r *http.Request, err error) {
 	} else {
 		log.Infof(ctx, "returning %d (%s) for error %v", serr.status, http.StatusText(serr.status), err)
 	}
-	http.Error(w, serr.err.Error(), serr.status)
+	return serr
 }
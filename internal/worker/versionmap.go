@@ -0,0 +1,85 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package worker
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"golang.org/x/pkgsite/internal/derrors"
+	"golang.org/x/pkgsite/internal/log"
+	"golang.org/x/pkgsite/internal/proxy"
+)
+
+// handleRefreshVersionMap asks the proxy again what the requested versions
+// like "master" and "latest" in version_map resolve to, for at most "limit"
+// entries that have not been updated for "hours" hours (default 24), of
+// modules whose pages were viewed in the last "days" days (default 7). It
+// enqueues a fetch of each requested version that now resolves to a
+// different version, which updates its entry, and marks the others as up to
+// date. It first deletes the entries whose resolved version is no longer
+// stored.
+func (s *Server) handleRefreshVersionMap(w http.ResponseWriter, r *http.Request) (err error) {
+	defer derrors.Wrap(&err, "handleRefreshVersionMap(%q)", r.URL.RawQuery)
+
+	ctx := r.Context()
+	hours, err := positiveIntParam(r, "hours", 24)
+	if err != nil {
+		return &serverError{http.StatusBadRequest, err}
+	}
+	days, err := positiveIntParam(r, "days", 7)
+	if err != nil {
+		return &serverError{http.StatusBadRequest, err}
+	}
+	now := time.Now()
+	before := now.Add(-time.Duration(hours) * time.Hour)
+	viewedSince := now.Add(-time.Duration(days) * 24 * time.Hour)
+
+	nDeleted, err := s.db.DeleteDanglingVersionMaps(ctx)
+	if err != nil {
+		return err
+	}
+	vms, err := s.db.GetStaleNamedVersionMaps(ctx, before, viewedSince, parseLimitParam(r, 100))
+	if err != nil {
+		return err
+	}
+	var nEnqueued, nFailed int
+	for _, vm := range vms {
+		var resolved string
+		switch info, err := s.proxyClient.GetInfo(ctx, vm.ModulePath, vm.RequestedVersion); {
+		case err == nil:
+			resolved = info.Version
+		case errors.Is(err, derrors.NotFound) || proxy.IsGone(err):
+			// The proxy no longer resolves the requested version, as when a
+			// branch is deleted. Fetching it again records that.
+		default:
+			// Leave the entry to be checked next time, so that one failing
+			// module doesn't prevent the others from being checked.
+			log.Warningf(ctx, "resolving %s@%s: %v", vm.ModulePath, vm.RequestedVersion, err)
+			nFailed++
+			continue
+		}
+		if resolved == vm.ResolvedVersion && (resolved == "" || vm.Status == http.StatusOK) {
+			if err := s.db.TouchVersionMap(ctx, vm.ModulePath, vm.RequestedVersion); err != nil {
+				return err
+			}
+			continue
+		}
+		enqueued, err := s.queue.ScheduleFetch(ctx, vm.ModulePath, vm.RequestedVersion, "", s.taskIDChangeInterval)
+		if err != nil {
+			return err
+		}
+		if enqueued {
+			log.Infof(ctx, "enqueued %s@%s, which resolves to %q instead of %q",
+				vm.ModulePath, vm.RequestedVersion, resolved, vm.ResolvedVersion)
+			nEnqueued++
+		}
+	}
+	fmt.Fprintf(w, "deleted %d dangling entries; checked %d entries, enqueued %d fetches, %d failed\n",
+		nDeleted, len(vms), nEnqueued, nFailed)
+	return nil
+}
@@ -0,0 +1,26 @@
+// Copyright 2020 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package internal
+
+import "time"
+
+// WorkerLoad describes how busy a worker instance is. Workers report it
+// regularly, so that the frontend can stop adding fetches to their queue
+// when they are saturated.
+type WorkerLoad struct {
+	InstanceID string
+	// FetchesInFlight is the number of fetches the worker is processing.
+	FetchesInFlight int
+	// QueueDepth is the number of fetches waiting in the queue that the
+	// worker takes them from, counted up to the worker's threshold.
+	QueueDepth int
+	// MemoryUsed and MemoryLimit are in bytes. MemoryLimit is zero if it is
+	// unknown.
+	MemoryUsed  uint64
+	MemoryLimit uint64
+	// Overloaded reports whether the worker considers itself saturated.
+	Overloaded bool
+	UpdatedAt  time.Time
+}
@@ -0,0 +1,15 @@
+-- Copyright 2020 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+ALTER TABLE packages ADD COLUMN synopsis_inferred boolean NOT NULL DEFAULT false;
+ALTER TABLE documentation ADD COLUMN synopsis_inferred boolean NOT NULL DEFAULT false;
+
+COMMENT ON COLUMN packages.synopsis_inferred IS
+'COLUMN synopsis_inferred is true when the synopsis was derived from a README because the package has no doc comment.';
+COMMENT ON COLUMN documentation.synopsis_inferred IS
+'COLUMN synopsis_inferred is true when the synopsis was derived from a README because the package has no doc comment.';
+
+END;
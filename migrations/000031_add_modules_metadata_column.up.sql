@@ -0,0 +1,12 @@
+-- Copyright 2020 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+ALTER TABLE modules ADD COLUMN metadata jsonb;
+
+COMMENT ON COLUMN modules.metadata IS
+'COLUMN metadata holds the description, keywords and category supplied by the module author in go.mod comments or a pkgsite.yaml file.';
+
+END;
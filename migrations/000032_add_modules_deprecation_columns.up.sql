@@ -0,0 +1,17 @@
+-- Copyright 2020 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+ALTER TABLE modules
+    ADD COLUMN deprecated_message text,
+    ADD COLUMN successor_module_path text;
+
+COMMENT ON COLUMN modules.deprecated_message IS
+'COLUMN deprecated_message holds the text after "Deprecated:" in the comment on the module directive of the go.mod file, or NULL if the module is not deprecated.';
+
+COMMENT ON COLUMN modules.successor_module_path IS
+'COLUMN successor_module_path holds the path of the module that deprecated_message says to use instead, if any.';
+
+END;
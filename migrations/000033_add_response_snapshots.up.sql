@@ -0,0 +1,37 @@
+-- Copyright 2020 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+CREATE TABLE snapshot_rules (
+    id serial PRIMARY KEY,
+    path_pattern text NOT NULL,
+    sample_rate double precision NOT NULL CHECK (sample_rate > 0 AND sample_rate <= 1),
+    expires_at timestamp with time zone NOT NULL,
+    created_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMENT ON TABLE snapshot_rules IS
+'TABLE snapshot_rules holds requests from admins to record a sample of frontend requests whose paths match path_pattern, and their responses, until expires_at.';
+
+CREATE TABLE response_snapshots (
+    id bigserial PRIMARY KEY,
+    rule_id integer NOT NULL REFERENCES snapshot_rules(id) ON DELETE CASCADE,
+    created_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    method text NOT NULL,
+    url text NOT NULL,
+    request_headers jsonb,
+    status integer NOT NULL,
+    response_headers jsonb,
+    response_body bytea,
+    body_truncated boolean NOT NULL DEFAULT false,
+    app_version text NOT NULL
+);
+
+COMMENT ON TABLE response_snapshots IS
+'TABLE response_snapshots holds frontend requests and responses recorded because of a snapshot rule, with sensitive headers removed.';
+
+CREATE INDEX idx_response_snapshots_rule_id ON response_snapshots(rule_id);
+
+END;
@@ -0,0 +1,19 @@
+-- Copyright 2020 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+CREATE TABLE worker_load (
+    instance_id text PRIMARY KEY,
+    fetches_in_flight integer NOT NULL,
+    memory_used bigint NOT NULL,
+    memory_limit bigint NOT NULL,
+    overloaded boolean NOT NULL,
+    updated_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMENT ON TABLE worker_load IS
+'TABLE worker_load holds the most recent load reported by each worker instance. The frontend consults it to avoid enqueuing fetches when the workers are saturated.';
+
+END;
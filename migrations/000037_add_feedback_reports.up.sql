@@ -0,0 +1,29 @@
+-- Copyright 2020 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+CREATE TABLE feedback_reports (
+    id serial PRIMARY KEY,
+    path text NOT NULL,
+    version text NOT NULL,
+    category text NOT NULL,
+    text text NOT NULL,
+    trace_id text NOT NULL DEFAULT '',
+    status text NOT NULL DEFAULT 'new' CHECK (status IN ('new', 'triaged', 'resolved', 'spam')),
+    created_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMENT ON TABLE feedback_reports IS
+'TABLE feedback_reports holds problems with pages that users reported with the form on documentation pages, for admins to triage.';
+
+CREATE TRIGGER set_updated_at BEFORE INSERT OR UPDATE ON feedback_reports
+    FOR EACH ROW EXECUTE PROCEDURE trigger_modify_updated_at();
+COMMENT ON TRIGGER set_updated_at ON feedback_reports IS
+'TRIGGER set_updated_at updates the value of the updated_at column to the current timestamp whenever a row is inserted or updated to the table.';
+
+CREATE INDEX idx_feedback_reports_status_created_at ON feedback_reports(status, created_at);
+
+END;
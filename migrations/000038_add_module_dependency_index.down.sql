@@ -0,0 +1,12 @@
+-- Copyright 2020 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+DROP TABLE module_dependency_index_states;
+DROP TABLE module_dependencies;
+DROP TABLE module_requirements;
+ALTER TABLE modules DROP COLUMN requirements_recorded;
+
+END;
@@ -0,0 +1,41 @@
+-- Copyright 2020 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+ALTER TABLE modules ADD COLUMN requirements_recorded boolean NOT NULL DEFAULT false;
+COMMENT ON COLUMN modules.requirements_recorded IS
+'COLUMN requirements_recorded tells whether the require directives of the module version are in module_requirements. It is false for module versions processed before the table existed, so that a module version without requirements can be told apart from one whose requirements are unknown.';
+
+CREATE TABLE module_requirements (
+    module_id INTEGER NOT NULL REFERENCES modules(id) ON DELETE CASCADE,
+    required_module_path text NOT NULL,
+    required_version text NOT NULL,
+    PRIMARY KEY (module_id, required_module_path)
+);
+COMMENT ON TABLE module_requirements IS
+'TABLE module_requirements contains the require directives of the go.mod file of each module version in the modules table: the module version represented by module_id requires required_module_path at required_version.';
+
+CREATE TABLE module_dependencies (
+    module_id INTEGER NOT NULL REFERENCES modules(id) ON DELETE CASCADE,
+    dependency_module_path text NOT NULL,
+    dependency_version text NOT NULL,
+    depth INTEGER NOT NULL,
+    via_module_path text NOT NULL,
+    PRIMARY KEY (module_id, dependency_module_path)
+);
+COMMENT ON TABLE module_dependencies IS
+'TABLE module_dependencies is the reachability index of the requirement graph in module_requirements. It has a row for every module that the module version represented by module_id transitively requires, with the highest version required anywhere in the graph, the length of the shortest chain of requirements to it, and the direct requirement that starts that chain.';
+
+CREATE TABLE module_dependency_index_states (
+    module_id INTEGER NOT NULL PRIMARY KEY REFERENCES modules(id) ON DELETE CASCADE,
+    incomplete boolean NOT NULL,
+    indexed_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+COMMENT ON TABLE module_dependency_index_states IS
+'TABLE module_dependency_index_states records when the rows of module_dependencies for a module version were computed, and whether some of the module versions in its requirement graph were missing from the database at the time. Rows are deleted when the requirements of the module version change.';
+
+CREATE INDEX idx_module_dependency_index_states_indexed_at ON module_dependency_index_states(indexed_at);
+
+END;
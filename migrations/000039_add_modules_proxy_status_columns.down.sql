@@ -0,0 +1,13 @@
+-- Copyright 2020 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+DROP INDEX idx_modules_proxy_checked_at;
+
+ALTER TABLE modules
+    DROP COLUMN proxy_status,
+    DROP COLUMN proxy_checked_at;
+
+END;
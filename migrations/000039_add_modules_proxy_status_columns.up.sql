@@ -0,0 +1,19 @@
+-- Copyright 2020 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+ALTER TABLE modules
+    ADD COLUMN proxy_status integer,
+    ADD COLUMN proxy_checked_at timestamp with time zone;
+
+COMMENT ON COLUMN modules.proxy_status IS
+'COLUMN proxy_status holds the HTTP status of the last response from the proxy for the .info file of the module version, or NULL if it has not been checked since the module version was processed. 410 means that the proxy no longer serves the version.';
+
+COMMENT ON COLUMN modules.proxy_checked_at IS
+'COLUMN proxy_checked_at holds the time the worker last asked the proxy whether it still serves the module version.';
+
+CREATE INDEX idx_modules_proxy_checked_at ON modules(proxy_checked_at NULLS FIRST);
+
+END;
@@ -0,0 +1,17 @@
+-- Copyright 2020 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+CREATE TABLE documentation_parts (
+    path_id INTEGER NOT NULL REFERENCES paths(id) ON DELETE CASCADE,
+    part integer NOT NULL,
+    title text NOT NULL,
+    html text NOT NULL,
+    PRIMARY KEY (path_id, part)
+);
+COMMENT ON TABLE documentation_parts IS
+'TABLE documentation_parts contains the pages of documentation that was too large to display on one page and was split. The first page is in the html column of the documentation table; the others are numbered from 1.';
+
+END;
@@ -0,0 +1,21 @@
+-- Copyright 2020 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+CREATE TABLE latest_checks (
+    module_path text PRIMARY KEY,
+    latest_version text,
+    checked_at timestamp with time zone NOT NULL
+);
+
+COMMENT ON TABLE latest_checks IS
+'TABLE latest_checks records when the worker last asked the proxy for the @latest version of a module that has not been updated recently.';
+
+COMMENT ON COLUMN latest_checks.latest_version IS
+'COLUMN latest_version holds the version the proxy returned for @latest, or NULL if the check failed.';
+
+CREATE INDEX idx_latest_checks_checked_at ON latest_checks(checked_at);
+
+END;
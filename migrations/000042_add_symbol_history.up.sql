@@ -0,0 +1,25 @@
+-- Copyright 2020 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+CREATE TABLE symbol_history (
+    package_path text NOT NULL,
+    module_path text NOT NULL,
+    symbol_name text NOT NULL,
+    since_version text NOT NULL,
+    sort_version text NOT NULL,
+    PRIMARY KEY (package_path, module_path, symbol_name)
+);
+
+COMMENT ON TABLE symbol_history IS
+'TABLE symbol_history records, for each exported function, type and method of a package, the earliest version of its module that has it.';
+
+COMMENT ON COLUMN symbol_history.symbol_name IS
+'COLUMN symbol_name is the name of a function or type, or "Type.Method" for a method.';
+
+COMMENT ON COLUMN symbol_history.sort_version IS
+'COLUMN sort_version holds the value of since_version in a form that sorts in version order.';
+
+END;
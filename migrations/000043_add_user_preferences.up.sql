@@ -0,0 +1,23 @@
+-- Copyright 2020 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+CREATE TABLE user_preferences (
+    user_id text PRIMARY KEY,
+    goos text NOT NULL DEFAULT '',
+    goarch text NOT NULL DEFAULT '',
+    theme text NOT NULL DEFAULT '',
+    classic_layout boolean NOT NULL DEFAULT false,
+    hide_internal boolean NOT NULL DEFAULT false,
+    updated_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMENT ON TABLE user_preferences IS
+'TABLE user_preferences holds the display preferences of users who are signed in through an authenticating proxy.';
+
+COMMENT ON COLUMN user_preferences.user_id IS
+'COLUMN user_id is the identity of the user, as set by the proxy in the header named by GO_DISCOVERY_USER_HEADER.';
+
+END;
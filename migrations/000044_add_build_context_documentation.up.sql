@@ -0,0 +1,25 @@
+-- Copyright 2020 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+CREATE TABLE build_context_documentation (
+    path_id integer NOT NULL REFERENCES paths(id) ON DELETE CASCADE,
+    goos text NOT NULL,
+    goarch text NOT NULL,
+    doc_hash text NOT NULL,
+    source bytea,
+    PRIMARY KEY (path_id, goos, goarch)
+);
+
+COMMENT ON TABLE build_context_documentation IS
+'TABLE build_context_documentation holds, for packages whose documentation text differs between build contexts, a hash of the documentation in each build context the package can be loaded in.';
+
+COMMENT ON COLUMN build_context_documentation.doc_hash IS
+'COLUMN doc_hash is the SHA-256 hash of the documentation rendered as plain text.';
+
+COMMENT ON COLUMN build_context_documentation.source IS
+'COLUMN source holds the encoded source of the package in the build context, if its documentation differs from the one in the documentation table.';
+
+END;
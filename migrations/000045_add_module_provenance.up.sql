@@ -0,0 +1,28 @@
+-- Copyright 2020 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+CREATE TABLE module_provenance (
+    module_path text NOT NULL,
+    version text NOT NULL,
+    proxy_url text NOT NULL,
+    response_header jsonb,
+    zip_sha256 text NOT NULL,
+    zip_size bigint NOT NULL,
+    downloaded_at timestamp with time zone NOT NULL,
+    download_duration_ms bigint NOT NULL,
+    PRIMARY KEY (module_path, version, downloaded_at)
+);
+
+COMMENT ON TABLE module_provenance IS
+'TABLE module_provenance records where and when the zip of a module version was downloaded each time it was processed, for auditing the integrity of modules and debugging inconsistencies between proxy caches.';
+
+COMMENT ON COLUMN module_provenance.proxy_url IS
+'COLUMN proxy_url is the URL that the zip was downloaded from.';
+
+COMMENT ON COLUMN module_provenance.zip_sha256 IS
+'COLUMN zip_sha256 is the hex-encoded SHA-256 hash of the zip as it was downloaded.';
+
+END;
@@ -0,0 +1,12 @@
+-- Copyright 2020 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+ALTER TABLE modules ADD COLUMN last_viewed_at timestamp with time zone;
+
+COMMENT ON COLUMN modules.last_viewed_at IS
+'COLUMN last_viewed_at holds the time, to within a day, that a page of the module version was last served by the frontend, or NULL if none has been. It is used to prune the documentation HTML of versions that nobody looks at.';
+
+END;
@@ -0,0 +1,23 @@
+-- Copyright 2020 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+CREATE TABLE package_symbols (
+    path_id integer NOT NULL REFERENCES paths(id) ON DELETE CASCADE,
+    name text NOT NULL,
+    declaration text NOT NULL,
+    PRIMARY KEY (path_id, name)
+);
+
+COMMENT ON TABLE package_symbols IS
+'TABLE package_symbols holds the declarations of the exported functions, types and methods of each package, for comparing the API of two versions of a module.';
+
+COMMENT ON COLUMN package_symbols.name IS
+'COLUMN name is the name of a function or type, or "Type.Method" for a method.';
+
+COMMENT ON COLUMN package_symbols.declaration IS
+'COLUMN declaration is the Go source of the declaration, without comments, function bodies, or unexported fields and methods.';
+
+END;
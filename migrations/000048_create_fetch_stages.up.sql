@@ -0,0 +1,21 @@
+-- Copyright 2020 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+CREATE TABLE fetch_stages (
+    module_path text NOT NULL,
+    requested_version text NOT NULL,
+    stage text NOT NULL,
+    updated_at timestamp with time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    PRIMARY KEY (module_path, requested_version)
+);
+
+COMMENT ON TABLE fetch_stages IS
+'TABLE fetch_stages records how far the worker has got in fetching a module version, so that the frontend can report the progress of a fetch that a user requested. The result of the fetch is in version_map.';
+
+COMMENT ON COLUMN fetch_stages.stage IS
+'COLUMN stage is one of "downloading", "processing", "done" or "error".';
+
+END;
@@ -0,0 +1,12 @@
+-- Copyright 2020 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+ALTER TABLE modules ADD COLUMN retractions jsonb;
+
+COMMENT ON COLUMN modules.retractions IS
+'COLUMN retractions holds the versions that the retract directives of the go.mod file of the module version retract, as a JSON array of objects with the low and high bounds of each range of versions and the rationale from the comment on the directive. The go command applies the retractions of the latest version of a module.';
+
+END;
@@ -0,0 +1,10 @@
+-- Copyright 2020 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+DROP INDEX idx_search_documents_lower_package_path_text_pattern_ops;
+DROP INDEX idx_search_documents_lower_name_text_pattern_ops;
+
+END;
@@ -0,0 +1,12 @@
+-- Copyright 2020 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+ALTER TABLE user_preferences
+    DROP COLUMN editor,
+    DROP COLUMN editor_url,
+    DROP COLUMN module_root;
+
+END;
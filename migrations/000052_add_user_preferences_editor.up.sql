@@ -0,0 +1,21 @@
+-- Copyright 2020 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+ALTER TABLE user_preferences
+    ADD COLUMN editor text NOT NULL DEFAULT '',
+    ADD COLUMN editor_url text NOT NULL DEFAULT '',
+    ADD COLUMN module_root text NOT NULL DEFAULT '';
+
+COMMENT ON COLUMN user_preferences.editor IS
+'COLUMN editor is the editor in which the user opens source files from documentation pages, like "vscode", or empty for none.';
+
+COMMENT ON COLUMN user_preferences.editor_url IS
+'COLUMN editor_url is the URL template of the editor when editor is "custom", with placeholders like {path} and {line}.';
+
+COMMENT ON COLUMN user_preferences.module_root IS
+'COLUMN module_root is the local directory of the user that holds modules with the layout of the module cache, from which files are opened in the editor.';
+
+END;
@@ -0,0 +1,17 @@
+-- Copyright 2020 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+CREATE TABLE noindex_prefixes (
+    prefix text PRIMARY KEY,
+    created_by text NOT NULL,
+    reason text NOT NULL,
+    created_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMENT ON TABLE noindex_prefixes IS
+'TABLE noindex_prefixes holds the path prefixes whose pages the frontend asks search engines not to index, as with internal modules on a corporate deployment. Unlike excluded_prefixes, the pages are still served.';
+
+END;
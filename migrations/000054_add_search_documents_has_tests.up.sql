@@ -0,0 +1,12 @@
+-- Copyright 2020 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+ALTER TABLE search_documents ADD COLUMN has_tests boolean NOT NULL DEFAULT false;
+
+COMMENT ON COLUMN search_documents.has_tests IS
+'COLUMN has_tests reports whether the package has test files. It is false for packages that have not been fetched since the column was added.';
+
+END;
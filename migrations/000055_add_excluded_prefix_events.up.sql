@@ -0,0 +1,21 @@
+-- Copyright 2020 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+CREATE TABLE excluded_prefix_events (
+    id bigserial PRIMARY KEY,
+    prefix text NOT NULL,
+    action text NOT NULL CHECK (action IN ('add', 'remove')),
+    created_by text NOT NULL,
+    reason text NOT NULL,
+    created_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_excluded_prefix_events_created_at ON excluded_prefix_events (created_at);
+
+COMMENT ON TABLE excluded_prefix_events IS
+'TABLE excluded_prefix_events is the history of changes to excluded_prefixes: who added or removed each prefix, when, and why.';
+
+END;
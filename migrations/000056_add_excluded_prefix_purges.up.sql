@@ -0,0 +1,23 @@
+-- Copyright 2020 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+CREATE TABLE excluded_prefix_purges (
+    id bigserial PRIMARY KEY,
+    prefix text NOT NULL,
+    created_by text NOT NULL,
+    status text NOT NULL CHECK (status IN ('running', 'done', 'failed')),
+    module_versions_total integer NOT NULL DEFAULT 0,
+    module_versions_deleted integer NOT NULL DEFAULT 0,
+    search_documents_deleted integer NOT NULL DEFAULT 0,
+    error text NOT NULL DEFAULT '',
+    created_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMENT ON TABLE excluded_prefix_purges IS
+'TABLE excluded_prefix_purges records the progress of the background jobs that delete the modules and search documents matching an excluded prefix.';
+
+END;
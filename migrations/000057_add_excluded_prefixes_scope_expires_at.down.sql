@@ -0,0 +1,11 @@
+-- Copyright 2020 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+ALTER TABLE excluded_prefixes
+    DROP COLUMN scope,
+    DROP COLUMN expires_at;
+
+END;
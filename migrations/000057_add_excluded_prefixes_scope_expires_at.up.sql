@@ -0,0 +1,17 @@
+-- Copyright 2020 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+ALTER TABLE excluded_prefixes
+    ADD COLUMN scope text NOT NULL DEFAULT 'all' CHECK (scope IN ('all', 'search', 'fetch')),
+    ADD COLUMN expires_at timestamp with time zone;
+
+COMMENT ON COLUMN excluded_prefixes.scope IS
+'COLUMN scope says what the prefix is excluded from: everything ("all"), only search results ("search"), or only processing by the worker ("fetch").';
+
+COMMENT ON COLUMN excluded_prefixes.expires_at IS
+'COLUMN expires_at is when the exclusion stops applying. If it is NULL, the exclusion never expires.';
+
+END;
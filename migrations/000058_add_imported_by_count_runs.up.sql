@@ -0,0 +1,26 @@
+-- Copyright 2020 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+CREATE TABLE imported_by_count_runs (
+    id bigserial PRIMARY KEY,
+    last_package_path text NOT NULL DEFAULT '',
+    packages_done integer NOT NULL DEFAULT 0,
+    packages_changed integer NOT NULL DEFAULT 0,
+    started_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    finished_at timestamp with time zone
+);
+
+COMMENT ON TABLE imported_by_count_runs IS
+'TABLE imported_by_count_runs records the progress of the jobs that recompute the imported_by_count column of search_documents in batches of packages, in order of package path.';
+
+COMMENT ON COLUMN imported_by_count_runs.last_package_path IS
+'COLUMN last_package_path holds the path of the last package whose count was recomputed. A run that was interrupted resumes after it.';
+
+COMMENT ON COLUMN imported_by_count_runs.finished_at IS
+'COLUMN finished_at holds the time the run recomputed the count of the last package, or NULL if it has not.';
+
+END;
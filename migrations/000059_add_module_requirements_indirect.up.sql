@@ -0,0 +1,12 @@
+-- Copyright 2020 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+ALTER TABLE module_requirements ADD COLUMN indirect boolean NOT NULL DEFAULT false;
+
+COMMENT ON COLUMN module_requirements.indirect IS
+'COLUMN indirect tells whether the require directive is marked with an "// indirect" comment. It is false for module versions processed before the column existed, until they are processed again.';
+
+END;
@@ -0,0 +1,30 @@
+-- Copyright 2020 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+CREATE TABLE module_path_redirects (
+    from_module_path text PRIMARY KEY,
+    to_module_path text NOT NULL,
+    version text NOT NULL,
+    sort_version text NOT NULL,
+    created_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMENT ON TABLE module_path_redirects IS
+'TABLE module_path_redirects records modules that moved to a new path, detected when the go.mod file of a version of from_module_path declares to_module_path (status 491). version is the highest such version. The frontend redirects pages of from_module_path to to_module_path, unless a later version of from_module_path is in the modules table.';
+
+CREATE TABLE module_path_redirect_overrides (
+    from_module_path text PRIMARY KEY,
+    to_module_path text NOT NULL,
+    created_by text NOT NULL,
+    reason text NOT NULL,
+    created_at timestamp with time zone NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMENT ON TABLE module_path_redirect_overrides IS
+'TABLE module_path_redirect_overrides holds redirects set by hand, which take precedence over those in module_path_redirects. An empty to_module_path turns off the redirect of from_module_path.';
+
+END;
@@ -0,0 +1,18 @@
+-- Copyright 2020 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+-- Search documents that exist now were converted before go_text existed.
+ALTER TABLE search_documents ADD COLUMN text_search_config text NOT NULL DEFAULT 'english';
+
+COMMENT ON COLUMN search_documents.text_search_config IS
+'COLUMN text_search_config is the text search configuration that the synopsis and README sections of tsv_search_tokens were converted with. Documents converted with a configuration other than go_text, which queries are parsed with, are converted again by the worker.';
+
+CREATE INDEX idx_search_documents_text_search_config ON search_documents (package_path)
+    WHERE text_search_config <> 'go_text';
+COMMENT ON INDEX idx_search_documents_text_search_config IS
+'INDEX idx_search_documents_text_search_config finds the search documents that still need to be converted with go_text.';
+
+END;
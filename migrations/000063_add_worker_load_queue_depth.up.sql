@@ -0,0 +1,12 @@
+-- Copyright 2020 The Go Authors. All rights reserved.
+-- Use of this source code is governed by a BSD-style
+-- license that can be found in the LICENSE file.
+
+BEGIN;
+
+ALTER TABLE worker_load ADD COLUMN queue_depth integer NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN worker_load.queue_depth IS
+'COLUMN queue_depth is the number of fetches that were waiting in the queue of the worker instance, counted up to the depth at which it considers itself overloaded.';
+
+END;